@@ -60,11 +60,14 @@ func NewChatAgent(
 	}
 
 	// 3. Get model
-	model, _ := llms.GetModel(
+	model, err := llms.GetModel(
 		llms.ModelId{
 			Provider: llms.ModelProvider(cfg.Provider),
 			ID:       cfg.ModelName,
 		})
+	if err != nil {
+		return nil, errors.Errorf(ErrorCodeInitAgentFailed, "failed to get model: %v", err)
+	}
 
 	// 4. Create behavior pattern
 	behavior, err := behavior_patterns.NewGenericPattern()
@@ -19,7 +19,7 @@ func TestChatComponents(t *testing.T) {
 		Provider:            "openai",
 		APIKey:              "test-key",
 		BaseURL:             "http://localhost:8080",
-		ModelName:           "gpt-3.5-turbo",
+		ModelName:           "gpt-4o-mini",
 		SystemPrompt:        "You are a helpful assistant.",
 		RuntimeDir:          "./test-runtime",
 		OpenAICompatibility: false,
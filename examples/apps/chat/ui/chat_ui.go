@@ -110,6 +110,8 @@ func (c *ChatUI) handleAgentEvents(ctx context.Context, event *eventbus.Event) e
 	case agent.EventTypeAgentResponseEnd:
 		c.resetLoadingCancel(nil)
 		c.handleAgentResponseEnd(ctx, agent.GetAgentResponseEndEventData(event))
+	case agent.EventTypeAgentUsage, agent.EventTypeAgentSources:
+		// usage and sources are not displayed
 	default:
 		return errors.Errorf(agent.ErrorCodeInvalidInputEvent, "invalid input event type: %s", event.Topic)
 	}
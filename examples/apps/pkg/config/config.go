@@ -66,6 +66,9 @@ type EmbedderConfig struct {
 	APIKey   string `json:"api_key"`
 	BaseURL  string `json:"base_url"`
 	Model    string `json:"model"` // embedding model name
+	// Dimensions shrinks the vectors to save their storage, for the models supporting it,
+	// e.g. OpenAI text-embedding-3. 0 keeps the default dimensions of the model.
+	Dimensions int `json:"dimensions"`
 }
 
 // LoadConfig loads configuration from a JSON file
@@ -249,7 +249,11 @@ func createEmbedder(cfg config.EmbedderConfig) (embedder.Embedder, error) {
 			Provider: llms.ModelProvider(cfg.Provider),
 			ID:       cfg.Model,
 		}
-		model, _ := llms.GetModel(modelId)
+		model, err := llms.GetModel(modelId)
+		if err != nil {
+			return nil, errors.Errorf(ErrorCodeCreateEmbedderFailed,
+				"failed to get embedding model: %v", err)
+		}
 
 		embedderProvider, err := llms.NewEmbedderProvider(
 			llms.ModelProvider(cfg.Provider),
@@ -262,7 +266,7 @@ func createEmbedder(cfg config.EmbedderConfig) (embedder.Embedder, error) {
 		}
 
 		// Create LLM embedder
-		return createLlmEmbedder(embedderProvider), nil
+		return createLlmEmbedder(embedderProvider, cfg.Dimensions), nil
 	default:
 		return nil, errors.Errorf(ErrorCodeCreateEmbedderFailed,
 			"unsupported embedder type: %s", cfg.Type)
@@ -270,21 +274,26 @@ func createEmbedder(cfg config.EmbedderConfig) (embedder.Embedder, error) {
 }
 
 // createLlmEmbedder creates an embedder with the given provider
-func createLlmEmbedder(embedderProvider llms.EmbedderProvider) embedder.Embedder {
+func createLlmEmbedder(embedderProvider llms.EmbedderProvider, dimensions int) embedder.Embedder {
 	// Since we can't access the private field directly, we'll create a wrapper
-	// that implements the Embedder interface
-	return &embedderWrapper{
+	// that implements the Embedder interface, batched to stay under the item cap of the providers
+	wrapper := &embedderWrapper{
 		provider: embedderProvider,
 	}
+	if dimensions > 0 {
+		wrapper.options = append(wrapper.options, llms.WithDimensions(dimensions))
+	}
+	return embedder.NewBatchingEmbedder(wrapper)
 }
 
 // embedderWrapper wraps the embedder provider to implement the Embedder interface
 type embedderWrapper struct {
 	provider llms.EmbedderProvider
+	options  []llms.EmbeddingOption
 }
 
 func (w *embedderWrapper) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
-	response, err := w.provider.GetEmbeddings(ctx, texts)
+	response, err := w.provider.GetEmbeddings(ctx, texts, w.options...)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/oopslink/agent-go/pkg/core/agent/behavior_patterns"
 	"github.com/oopslink/agent-go/pkg/core/tools"
 	duckduckgo "github.com/oopslink/agent-go/pkg/core/tools/duckduckgo"
+	"github.com/oopslink/agent-go/pkg/core/tools/weather"
 	"github.com/oopslink/agent-go/pkg/support/eventbus"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 	_ "github.com/oopslink/agent-go/pkg/support/llms/anthropic"
@@ -39,7 +41,7 @@ func main() {
 	}
 
 	toolRegistry := tools.OfTools(
-		u.NewWeatherTool(),
+		weather.NewWeatherTool(""),
 		duckduckgo.NewDuckDuckGoTool(),
 	)
 	theAgent, err := u.CreateAgent(
@@ -52,10 +54,12 @@ func main() {
 		log.Fatalf("Failed to create PlanAndExecute agent: %v", err)
 	}
 
-	// Create run context
+	// Create run context, canceled on Ctrl+C to abort the agent
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 	runCtx := &agent.RunContext{
 		SessionId: fmt.Sprintf("session-%d", time.Now().Unix()),
-		Context:   context.Background(),
+		Context:   ctx,
 	}
 
 	// Start agent
@@ -71,18 +75,16 @@ func main() {
 
 	inputChan <- agent.NewUserRequestEvent(userRequest)
 
-	// Handle agent responses
-	go func() {
-		for event := range outputChan {
-			handleEvent(event, inputChan, toolRegistry)
+	// Handle agent responses until the agent stops
+	for event := range outputChan {
+		if aborted := handleEvent(event, inputChan, toolRegistry); aborted {
+			close(inputChan)
 		}
-	}()
-
-	// Keep the program running
-	select {}
+	}
 }
 
-func handleEvent(event *eventbus.Event, inputChan chan<- *eventbus.Event, registry *tools.ToolCollection) {
+// handleEvent prints the event, returns true when the agent aborted its response
+func handleEvent(event *eventbus.Event, inputChan chan<- *eventbus.Event, registry *tools.ToolCollection) bool {
 	switch event.Topic {
 	case agent.EventTypeAgentMessage:
 		if messageEvent := agent.GetAgentMessageEventData(event); messageEvent != nil {
@@ -123,13 +125,26 @@ func handleEvent(event *eventbus.Event, inputChan chan<- *eventbus.Event, regist
 		}
 		fmt.Println("---")
 
-		if data.Abort {
-			os.Exit(0)
+		return data.Abort
+
+	case agent.EventTypeAgentUsage:
+		data := agent.GetAgentUsageEventData(event)
+		fmt.Printf("Usage of %s: %d input tokens, %d output tokens\n",
+			data.ModelId.String(), data.Usage.InputTokens, data.Usage.OutputTokens)
+		fmt.Println("---")
+
+	case agent.EventTypeAgentSources:
+		data := agent.GetAgentSourcesEventData(event)
+		fmt.Println("Sources:")
+		for _, source := range data.Sources {
+			fmt.Printf("  - %s (score %.2f)\n", source.Name, source.Score)
 		}
+		fmt.Println("---")
 
 	default:
 		fmt.Printf("[skip] event: %s\n", event.Topic)
 	}
+	return false
 }
 
 func simulateUserInteractions(inputChan chan<- *eventbus.Event,
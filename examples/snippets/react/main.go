@@ -6,6 +6,8 @@ import (
 	"github.com/oopslink/agent-go/pkg/core/agent/behavior_patterns"
 	"github.com/oopslink/agent-go/pkg/core/tools"
 	duckduckgo "github.com/oopslink/agent-go/pkg/core/tools/duckduckgo"
+	"github.com/oopslink/agent-go/pkg/core/tools/weather"
+	"github.com/oopslink/agent-go/pkg/core/tools/wikipedia"
 	_ "github.com/oopslink/agent-go/pkg/support/llms/anthropic"
 	_ "github.com/oopslink/agent-go/pkg/support/llms/gemini"
 	_ "github.com/oopslink/agent-go/pkg/support/llms/openai"
@@ -30,8 +32,9 @@ func main() {
 		apiKey, provider, modelName,
 		behavior, nil,
 		tools.OfTools(
-			u.NewWeatherTool(),
+			weather.NewWeatherTool(""),
 			duckduckgo.NewDuckDuckGoTool(),
+			wikipedia.NewWikipediaTool(),
 		),
 		true,
 	)
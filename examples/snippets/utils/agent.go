@@ -41,6 +41,18 @@ func HandleAgentEvent(event *eventbus.Event) (bool, error) {
 			}
 			return true, nil // Signal to stop processing
 		}
+	case agent.EventTypeAgentUsage:
+		if usageEvent := agent.GetAgentUsageEventData(event); usageEvent != nil {
+			fmt.Printf("\n📊 Usage of %s: %d input tokens, %d output tokens\n",
+				usageEvent.ModelId.String(), usageEvent.Usage.InputTokens, usageEvent.Usage.OutputTokens)
+		}
+	case agent.EventTypeAgentSources:
+		if sourcesEvent := agent.GetAgentSourcesEventData(event); sourcesEvent != nil {
+			fmt.Println("\n📚 Sources:")
+			for _, source := range sourcesEvent.Sources {
+				fmt.Printf("  - %s (score %.2f)\n", source.Name, source.Score)
+			}
+		}
 	default:
 		fmt.Println(fmt.Sprintf("[SKIP]: %v", event))
 	}
@@ -65,11 +77,14 @@ func CreateAgent(agentId, systemPrompt string,
 	}
 
 	// Get model
-	model, _ := llms.GetModel(
+	model, err := llms.GetModel(
 		llms.ModelId{
 			Provider: llms.ModelProvider(provider),
 			ID:       modelName,
 		})
+	if err != nil {
+		return nil, errors.Errorf(errors.InternalError, "failed to get model: %v", err)
+	}
 
 	// Create default behavior
 	if behavior == nil {
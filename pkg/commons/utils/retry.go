@@ -23,10 +23,28 @@ type ExponentialBackOff struct {
 	RandomizationFactor float64       // Factor for randomizing the delay (0.0 to 1.0)
 	Multiplier          float64       // Factor to multiply the delay by on each retry
 	MaxInterval         time.Duration // Maximum delay between retries
+	Jitter              JitterMode    // How the delay is randomized, JitterProportional by default
+	MaxElapsedTime      time.Duration // Time since Reset after which NextBackOff returns Stop, 0 for no limit
+	MaxRetries          uint          // Number of backoffs after which NextBackOff returns Stop, 0 for no limit
 
 	currentInterval time.Duration // Current delay interval (internal state)
+	startTime       time.Time     // Time of the last Reset (internal state)
+	retries         uint          // Number of backoffs since the last Reset (internal state)
+	clock           clock         // Clock measuring the elapsed time, the default clock if nil
 }
 
+// JitterMode defines how the delays of an ExponentialBackOff are randomized.
+type JitterMode int
+
+const (
+	// JitterProportional randomizes the delay by ± RandomizationFactor of the interval.
+	JitterProportional JitterMode = iota
+	// JitterNone uses the interval as is, without randomization.
+	JitterNone
+	// JitterFull picks a random delay between zero and the interval.
+	JitterFull
+)
+
 // Default values for ExponentialBackOff.
 const (
 	DefaultInitialInterval     = 500 * time.Millisecond // Default initial delay
@@ -50,6 +68,8 @@ func NewExponentialBackOff() *ExponentialBackOff {
 // Reset must be called before using b.
 func (b *ExponentialBackOff) Reset() {
 	b.currentInterval = b.InitialInterval
+	b.startTime = b.now()
+	b.retries = 0
 }
 
 // NextBackOff calculates the next backoff interval using the formula:
@@ -58,16 +78,43 @@ func (b *ExponentialBackOff) Reset() {
 //
 // The interval increases exponentially up to MaxInterval, with randomization
 // to prevent synchronized retries from multiple clients.
+//
+// It returns Stop once MaxRetries backoffs were returned or MaxElapsedTime passed since Reset.
 func (b *ExponentialBackOff) NextBackOff() time.Duration {
 	if b.currentInterval == 0 {
 		b.currentInterval = b.InitialInterval
 	}
-
-	next := getRandomValueFromInterval(b.RandomizationFactor, rand.Float64(), b.currentInterval)
+	if b.startTime.IsZero() {
+		b.startTime = b.now()
+	}
+	if b.MaxRetries > 0 && b.retries >= b.MaxRetries {
+		return Stop
+	}
+	if b.MaxElapsedTime > 0 && b.now().Sub(b.startTime) > b.MaxElapsedTime {
+		return Stop
+	}
+	b.retries++
+
+	var next time.Duration
+	switch b.Jitter {
+	case JitterNone:
+		next = b.currentInterval
+	case JitterFull:
+		next = time.Duration(rand.Float64() * float64(b.currentInterval))
+	default:
+		next = getRandomValueFromInterval(b.RandomizationFactor, rand.Float64(), b.currentInterval)
+	}
 	b.incrementCurrentInterval()
 	return next
 }
 
+func (b *ExponentialBackOff) now() time.Time {
+	if b.clock == nil {
+		return defaultClock{}.Now()
+	}
+	return b.clock.Now()
+}
+
 // Increments the current interval by multiplying it with the multiplier.
 // Checks for overflow and caps the interval at MaxInterval.
 func (b *ExponentialBackOff) incrementCurrentInterval() {
@@ -106,6 +153,19 @@ type timer interface {
 	C() <-chan time.Time          // Return the timer's channel
 }
 
+// clock abstracts the current time for testing.
+type clock interface {
+	Now() time.Time // Return the current time
+}
+
+// defaultClock implements clock interface using time.Now
+type defaultClock struct{}
+
+// Now returns the current local time.
+func (defaultClock) Now() time.Time {
+	return time.Now()
+}
+
 // defaultTimer implements Timer interface using time.Timer
 type defaultTimer struct {
 	timer *time.Timer // The underlying time.Timer
@@ -199,6 +259,7 @@ type Notify func(error, time.Duration)
 type retryOptions struct {
 	BackOff        BackOff       // Strategy for calculating backoff periods.
 	Timer          timer         // Timer to manage retry delays.
+	Clock          clock         // Clock to measure the elapsed time.
 	Notify         Notify        // Optional function to notify on each retry error.
 	MaxTries       uint          // Maximum number of retry attempts.
 	MaxElapsedTime time.Duration // Maximum total time for all retries.
@@ -221,6 +282,13 @@ func withTimer(t timer) RetryOption {
 	}
 }
 
+// withClock sets a custom clock for measuring the elapsed time of retries.
+func withClock(c clock) RetryOption {
+	return func(args *retryOptions) {
+		args.Clock = c
+	}
+}
+
 // WithNotify sets a notification function to handle retry errors.
 func WithNotify(n Notify) RetryOption {
 	return func(args *retryOptions) {
@@ -255,12 +323,16 @@ func WithMaxElapsedTime(d time.Duration) RetryOption {
 // - PermanentError: stops retrying immediately
 // - RetryAfterError: uses the specified duration for the next retry
 //
+// The context is observed while waiting between the attempts: when it is cancelled or its
+// deadline passes during a backoff, Retry returns its cause at once rather than after the delay.
+//
 // Returns the operation result or error if retries are exhausted or context is cancelled.
 func Retry[T any](ctx context.Context, operation Operation[T], opts ...RetryOption) (T, error) {
 	// Initialize default retry options.
 	args := &retryOptions{
 		BackOff:        NewExponentialBackOff(),
 		Timer:          &defaultTimer{},
+		Clock:          defaultClock{},
 		MaxElapsedTime: DefaultMaxElapsedTime,
 	}
 
@@ -271,7 +343,7 @@ func Retry[T any](ctx context.Context, operation Operation[T], opts ...RetryOpti
 
 	defer args.Timer.Stop()
 
-	startedAt := time.Now()
+	startedAt := args.Clock.Now()
 	args.BackOff.Reset()
 	for numTries := uint(1); ; numTries++ {
 		// Execute the operation.
@@ -310,7 +382,7 @@ func Retry[T any](ctx context.Context, operation Operation[T], opts ...RetryOpti
 		}
 
 		// Stop retrying if maximum elapsed time exceeded.
-		if args.MaxElapsedTime > 0 && time.Since(startedAt)+next > args.MaxElapsedTime {
+		if args.MaxElapsedTime > 0 && args.Clock.Now().Sub(startedAt)+next > args.MaxElapsedTime {
 			return res, err
 		}
 
@@ -319,7 +391,7 @@ func Retry[T any](ctx context.Context, operation Operation[T], opts ...RetryOpti
 			args.Notify(err, next)
 		}
 
-		// Wait for the next backoff period or context cancellation.
+		// Wait for the next backoff period, or return as soon as the context is done.
 		args.Timer.Start(next)
 		select {
 		case <-args.Timer.C():
@@ -92,6 +92,32 @@ func TestExponentialBackOffWithRandomization(t *testing.T) {
 	}
 }
 
+func TestExponentialBackOffJitterModes(t *testing.T) {
+	backoff := &ExponentialBackOff{
+		InitialInterval:     100 * time.Millisecond,
+		RandomizationFactor: 0.5,
+		Multiplier:          2.0,
+		MaxInterval:         1 * time.Second,
+		Jitter:              JitterNone,
+	}
+
+	// JitterNone ignores the randomization factor
+	for _, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if next := backoff.NextBackOff(); next != want {
+			t.Errorf("NextBackOff without jitter = %v, want %v", next, want)
+		}
+	}
+
+	// JitterFull picks a delay between zero and the interval
+	backoff.Jitter = JitterFull
+	for i := 0; i < 10; i++ {
+		backoff.Reset()
+		if next := backoff.NextBackOff(); next < 0 || next > 100*time.Millisecond {
+			t.Errorf("NextBackOff with full jitter = %v, should be between 0 and %v", next, 100*time.Millisecond)
+		}
+	}
+}
+
 func TestZeroBackOff(t *testing.T) {
 	backoff := &ZeroBackOff{}
 
@@ -229,6 +255,68 @@ func TestRetryWithContextCancellation(t *testing.T) {
 	}
 }
 
+func TestRetryCancelledDuringBackOff(t *testing.T) {
+	tests := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc)
+		cancel  bool
+		want    error
+	}{
+		{
+			name:    "cancelled",
+			context: func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			cancel:  true,
+			want:    context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			context: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.context()
+			defer cancel()
+
+			attempts := 0
+			waiting := make(chan struct{})
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				_, err := Retry(ctx, func() (string, error) {
+					attempts++
+					return "", errors.New("temporary error")
+				}, WithBackOff(NewConstantBackOff(time.Minute)), WithNotify(func(error, time.Duration) {
+					close(waiting)
+				}))
+				done <- err
+			}()
+
+			<-waiting
+			if tt.cancel {
+				cancel()
+			}
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.want) {
+					t.Errorf("Retry() error = %v, want %v", err, tt.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Retry() did not return when the context was done during the backoff")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Retry() returned after %v, want promptly", elapsed)
+			}
+			if attempts != 1 {
+				t.Errorf("Retry() attempts = %d, want 1", attempts)
+			}
+		})
+	}
+}
+
 func TestRetryWithRetryAfterError(t *testing.T) {
 	ctx := context.Background()
 	callCount := 0
@@ -355,6 +443,153 @@ func TestRetryWithMaxElapsedTime(t *testing.T) {
 	}
 }
 
+// fakeClock is a clock advanced by fakeTimer
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// fakeTimer fires immediately, advancing the fake clock by the duration it was started with
+type fakeTimer struct {
+	clock     *fakeClock
+	durations []time.Duration
+	c         chan time.Time
+}
+
+func (t *fakeTimer) Start(duration time.Duration) {
+	t.durations = append(t.durations, duration)
+	t.clock.now = t.clock.now.Add(duration)
+	t.c <- t.clock.now
+}
+
+func (t *fakeTimer) Stop() {}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func TestRetryWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	timer := &fakeTimer{clock: clock, c: make(chan time.Time, 1)}
+	backoff := &ExponentialBackOff{
+		InitialInterval: time.Second,
+		Multiplier:      3,
+		MaxInterval:     10 * time.Second,
+		Jitter:          JitterNone,
+	}
+
+	attempts := 0
+	_, err := Retry(context.Background(), func() (string, error) {
+		attempts++
+		return "", errors.New("temporary error")
+	}, WithBackOff(backoff), WithMaxElapsedTime(30*time.Second), withTimer(timer), withClock(clock))
+
+	if err == nil {
+		t.Error("Retry() should return error after max elapsed time")
+	}
+	// 1s + 3s + 9s + 10s = 23s, the next 10s delay would exceed 30s
+	want := []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second}
+	if fmt.Sprint(timer.durations) != fmt.Sprint(want) {
+		t.Errorf("Retry() delays = %v, want %v", timer.durations, want)
+	}
+	if attempts != 5 {
+		t.Errorf("Retry() attempts = %d, want 5", attempts)
+	}
+}
+
+func TestExponentialBackOffMaxRetries(t *testing.T) {
+	backoff := &ExponentialBackOff{
+		InitialInterval: time.Second,
+		Multiplier:      2,
+		MaxInterval:     10 * time.Second,
+		Jitter:          JitterNone,
+		MaxRetries:      2,
+	}
+
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delays = append(delays, backoff.NextBackOff())
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, Stop, Stop}
+	if fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Errorf("NextBackOff() = %v, want %v", delays, want)
+	}
+
+	// Reset starts counting the retries again
+	backoff.Reset()
+	if next := backoff.NextBackOff(); next != time.Second {
+		t.Errorf("NextBackOff() after Reset = %v, want %v", next, time.Second)
+	}
+}
+
+func TestExponentialBackOffMaxElapsedTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	backoff := &ExponentialBackOff{
+		InitialInterval: time.Second,
+		Multiplier:      2,
+		MaxInterval:     10 * time.Second,
+		Jitter:          JitterNone,
+		MaxElapsedTime:  5 * time.Second,
+		clock:           clock,
+	}
+	backoff.Reset()
+
+	if next := backoff.NextBackOff(); next != time.Second {
+		t.Errorf("NextBackOff() = %v, want %v", next, time.Second)
+	}
+	clock.now = clock.now.Add(5 * time.Second)
+	if next := backoff.NextBackOff(); next != 2*time.Second {
+		t.Errorf("NextBackOff() at the max elapsed time = %v, want %v", next, 2*time.Second)
+	}
+	clock.now = clock.now.Add(time.Millisecond)
+	if next := backoff.NextBackOff(); next != Stop {
+		t.Errorf("NextBackOff() after the max elapsed time = %v, want Stop", next)
+	}
+}
+
+func TestRetryGivesUpAfterBackOffBounds(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	timer := &fakeTimer{clock: clock, c: make(chan time.Time, 1)}
+
+	tests := []struct {
+		name     string
+		backoff  *ExponentialBackOff
+		attempts int
+	}{
+		{
+			name:     "max retries",
+			backoff:  &ExponentialBackOff{InitialInterval: time.Second, Multiplier: 2, MaxInterval: time.Minute, MaxRetries: 3},
+			attempts: 4,
+		},
+		{
+			// 1s + 2s + 4s elapsed when the fourth retry is asked, more than 5s
+			name:     "max elapsed time",
+			backoff:  &ExponentialBackOff{InitialInterval: time.Second, Multiplier: 2, MaxInterval: time.Minute, MaxElapsedTime: 5 * time.Second, clock: clock},
+			attempts: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.backoff.Jitter = JitterNone
+			attempts := 0
+			_, err := Retry(context.Background(), func() (string, error) {
+				attempts++
+				return "", errors.New("throttled")
+			}, WithBackOff(tt.backoff), WithMaxElapsedTime(0), withTimer(timer), withClock(clock))
+
+			if err == nil || err.Error() != "throttled" {
+				t.Errorf("Retry() error = %v, want throttled", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("Retry() attempts = %d, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
 func TestDefaultTimer(t *testing.T) {
 	timer := &defaultTimer{}
 
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ElisionMarker formats the marker replacing the omitted middle of a truncated text
+const ElisionMarker = "\n... [%d bytes omitted] ...\n"
+
+// readChunkSize is the size of the reads of ReadMiddleToFit past the beginning of the content
+const readChunkSize = 32 * 1024
+
+// TruncateMiddle keeps the first head and the last tail bytes of s and replaces the
+// middle by an elision marker telling how many bytes were omitted. The beginning and
+// the end of a document or tool result are often the most informative parts, e.g.
+// the title and the conclusion, or the command and its final error.
+// Cuts never split a UTF-8 character, s is returned untouched when it is not longer
+// than head + tail bytes or when the marker would not make it shorter.
+func TruncateMiddle(s string, head, tail int) string {
+	head, tail = MaxInt(head, 0), MaxInt(tail, 0)
+	if len(s) <= head+tail {
+		return s
+	}
+	if truncated := elideMiddle(s, s, len(s), head, tail); len(truncated) < len(s) {
+		return truncated
+	}
+	return s
+}
+
+// TruncateMiddleToFit truncates the middle of s so the result, elision marker
+// included, is at most maxBytes long, keeping as much of the head as of the tail.
+// When maxBytes leaves no room for the marker, s is cut at maxBytes instead.
+// s is returned untouched when it fits.
+func TruncateMiddleToFit(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	return fitMiddle(s, s, len(s), maxBytes)
+}
+
+// ReadMiddleToFit reads r to its end and returns its content as TruncateMiddleToFit would,
+// without holding more than a few times maxBytes in memory: the middle is dropped as it is read.
+// It returns the content read so far with the error of the reader.
+func ReadMiddleToFit(r io.Reader, maxBytes int) (string, error) {
+	maxBytes = MaxInt(maxBytes, 0)
+	start, err := io.ReadAll(io.LimitReader(r, int64(maxBytes)+1))
+	if err != nil || len(start) <= maxBytes {
+		return string(start), err
+	}
+
+	// the end of the content are its last maxBytes bytes, the bytes before them are dropped
+	size := len(start)
+	end := append(make([]byte, 0, 2*maxBytes+readChunkSize), start[1:]...)
+	chunk := make([]byte, readChunkSize)
+	for {
+		n, err := r.Read(chunk)
+		size += n
+		end = append(end, chunk[:n]...)
+		if len(end) > 2*maxBytes {
+			end = end[:copy(end, end[len(end)-maxBytes:])]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return string(start), err
+		}
+	}
+	return fitMiddle(string(start), string(end[len(end)-maxBytes:]), size, maxBytes), nil
+}
+
+// fitMiddle truncates the middle of a text of size bytes to at most maxBytes, start holding more
+// than maxBytes of its first bytes and end at least maxBytes of its last bytes
+func fitMiddle(start, end string, size, maxBytes int) string {
+	markerLen := len(fmt.Sprintf(ElisionMarker, size))
+	budget := maxBytes - markerLen
+	if budget <= 0 {
+		// no room left for the marker, cut the end
+		return start[:runeStartBefore(start, MaxInt(maxBytes, 0))]
+	}
+	head := (budget + 1) / 2
+	return elideMiddle(start, end, size, head, budget-head)
+}
+
+// elideMiddle joins the first head bytes of start and the last tail bytes of end with the marker
+// of the bytes omitted from a text of size bytes. start holds more than head bytes and end at
+// least tail bytes, the cuts are moved to character boundaries, omitting the partial characters.
+func elideMiddle(start, end string, size, head, tail int) string {
+	headEnd := runeStartBefore(start, head)
+	tailStart := len(end) - tail
+	for tailStart < len(end) && !utf8.RuneStart(end[tailStart]) {
+		tailStart++
+	}
+	omitted := size - headEnd - (len(end) - tailStart)
+	return start[:headEnd] + fmt.Sprintf(ElisionMarker, omitted) + end[tailStart:]
+}
+
+// runeStartBefore returns the start of the character at the index n of s, so s[:n] can be cut there
+func runeStartBefore(s string, n int) int {
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	s := "HEAD-" + strings.Repeat("x", 100) + "-TAIL"
+
+	truncated := TruncateMiddle(s, 5, 5)
+	assert.Equal(t, "HEAD-\n... [100 bytes omitted] ...\n-TAIL", truncated)
+	assert.True(t, strings.HasPrefix(truncated, "HEAD-"))
+	assert.True(t, strings.HasSuffix(truncated, "-TAIL"))
+
+	// uneven head and tail
+	assert.Equal(t, "HEAD-x\n... [103 bytes omitted] ...\nL", TruncateMiddle(s, 6, 1))
+}
+
+func TestTruncateMiddle_SmallInputs(t *testing.T) {
+	assert.Equal(t, "", TruncateMiddle("", 5, 5))
+	assert.Equal(t, "short", TruncateMiddle("short", 5, 5))
+	assert.Equal(t, "exactly ten", TruncateMiddle("exactly ten", 6, 5))
+	assert.Equal(t, "tiny", TruncateMiddleToFit("tiny", 4))
+}
+
+func TestTruncateMiddle_MultiByteCharacters(t *testing.T) {
+	// each character is 3 bytes
+	s := strings.Repeat("世", 30)
+
+	truncated := TruncateMiddle(s, 4, 4)
+	assert.True(t, utf8.ValidString(truncated))
+	assert.Equal(t, "世\n... [84 bytes omitted] ...\n世", truncated)
+}
+
+func TestTruncateMiddleToFit(t *testing.T) {
+	s := strings.Repeat("a", 500) + strings.Repeat("b", 500)
+
+	truncated := TruncateMiddleToFit(s, 100)
+	assert.LessOrEqual(t, len(truncated), 100)
+	assert.True(t, strings.HasPrefix(truncated, "aaaa"))
+	assert.True(t, strings.HasSuffix(truncated, "bbbb"))
+
+	// the marker tells the omitted size accurately
+	head := strings.Index(truncated, "\n")
+	tail := len(truncated) - strings.LastIndex(truncated, "\n") - 1
+	assert.Equal(t, TruncateMiddle(s, head, tail), truncated)
+	omitted := len(s) - head - tail
+	assert.Contains(t, truncated, "["+strconv.Itoa(omitted)+" bytes omitted]")
+
+	// no room for the marker, the end is cut
+	assert.Equal(t, "aaaaaaaaaa", TruncateMiddleToFit(s, 10))
+	assert.Equal(t, "世", TruncateMiddleToFit(strings.Repeat("世", 10), 5))
+	assert.Equal(t, "", TruncateMiddleToFit(s, 0))
+}
+
+func TestTruncateMiddle_MarkerLongerThanMiddle(t *testing.T) {
+	// eliding 4 bytes with a marker of about 30 bytes would grow the text
+	s := "0123456789"
+	assert.Equal(t, s, TruncateMiddle(s, 3, 3))
+}
+
+func TestReadMiddleToFit(t *testing.T) {
+	s := strings.Repeat("a", 50000) + strings.Repeat("世", 20000) + strings.Repeat("b", 50000)
+
+	for _, maxBytes := range []int{0, 10, 100, 4096, len(s) - 1, len(s), len(s) + 1} {
+		content, err := ReadMiddleToFit(strings.NewReader(s), maxBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, TruncateMiddleToFit(s, maxBytes), content, "max %d bytes", maxBytes)
+	}
+
+	// the errors of the reader are returned
+	_, err := ReadMiddleToFit(iotest.ErrReader(assert.AnError), 10)
+	assert.ErrorIs(t, err, assert.AnError)
+	_, err = ReadMiddleToFit(io.MultiReader(strings.NewReader(s), iotest.ErrReader(assert.AnError)), 10)
+	assert.ErrorIs(t, err, assert.AnError)
+}
@@ -2,7 +2,9 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/oopslink/agent-go/pkg/commons/errors"
@@ -12,6 +14,13 @@ import (
 )
 
 type Agent interface {
+	// Run starts a session of the agent, the requests are sent to the agent through the ask channel
+	// and its events are read from the response channel. The response channel is buffered, once full
+	// the generic agent applies its OutputOverflowPolicy: by default its loop blocks until the events
+	// are read, so a slow consumer slows the agent down, see WithOutputBuffer.
+	// Canceling the context of the run aborts the response in progress, ending it with Abort, and
+	// stops the agent; closing the ask channel stops the agent once the current response ended.
+	// The response channel is closed when the agent stopped.
 	Run(ctx *RunContext) (ask chan<- *eventbus.Event, response <-chan *eventbus.Event, err error)
 }
 
@@ -22,6 +31,16 @@ type BehaviorPattern interface {
 	NextStep(ctx *StepContext) error
 }
 
+// ResumableBehaviorPattern is a BehaviorPattern keeping its progress in the agent state, e.g. the
+// current plan, the progress is saved into the snapshots of the agent, see Snapshotter
+type ResumableBehaviorPattern interface {
+	BehaviorPattern
+	// SaveProgress serializes the progress kept in the state
+	SaveProgress(state AgentState) (json.RawMessage, error)
+	// RestoreProgress puts the saved progress back into the state
+	RestoreProgress(state AgentState, progress json.RawMessage) error
+}
+
 type RunContext struct {
 	SessionId string
 	Context   context.Context
@@ -45,18 +64,94 @@ type StepContext struct {
 
 	OutputChan  chan<- *eventbus.Event
 	ChatOptions []llms.ChatOption
+
+	// MaxEmptyResponses is the number of consecutive empty responses, without text nor tool calls,
+	// after which the step is aborted: 0 for DefaultMaxEmptyResponses, negative to never abort
+	MaxEmptyResponses int
+	// EmptyResponses counts the consecutive empty responses of the model during the step
+	EmptyResponses int
+}
+
+// EmptyResponseLimit returns the number of consecutive empty responses aborting the step, 0 for no limit
+func (c *StepContext) EmptyResponseLimit() int {
+	switch {
+	case c.MaxEmptyResponses < 0:
+		return 0
+	case c.MaxEmptyResponses == 0:
+		return DefaultMaxEmptyResponses
+	default:
+		return c.MaxEmptyResponses
+	}
 }
 
 func (c *StepContext) StepId() string {
 	return fmt.Sprintf("step:%s:%s:%d", c.AgentContext.AgentId(), c.SessionId, c.StepIndex)
 }
 
+// DefaultMaxEmptyResponses is the default number of consecutive empty model responses aborting a step
+const DefaultMaxEmptyResponses = 3
+
+// DefaultOutputBufferSize is the default size of the buffer of the output channel of a run
+const DefaultOutputBufferSize = 10
+
+// OutputOverflowPolicy tells what the agent does with its events when the output channel of a run is full
+type OutputOverflowPolicy int
+
+const (
+	// OutputOverflowBlock blocks the agent until the consumer reads the output, no event is lost
+	OutputOverflowBlock OutputOverflowPolicy = iota
+	// OutputOverflowDropOldest drops the oldest buffered event to make room for the new one, the agent
+	// never waits for the consumer and the consumer only misses the events it did not read in time
+	OutputOverflowDropOldest
+)
+
+func (p OutputOverflowPolicy) String() string {
+	switch p {
+	case OutputOverflowBlock:
+		return "block"
+	case OutputOverflowDropOldest:
+		return "drop-oldest"
+	default:
+		return fmt.Sprintf("OutputOverflowPolicy(%d)", int(p))
+	}
+}
+
+// AgentOption configures the generic agent
+type AgentOption func(*genericAgent)
+
+// WithMaxEmptyResponses aborts a step once the model returned n consecutive empty responses,
+// without text nor tool calls, instead of looping; n < 0 never aborts.
+func WithMaxEmptyResponses(n int) AgentOption {
+	return func(a *genericAgent) {
+		a.maxEmptyResponses = n
+	}
+}
+
+// WithEventStore records every event emitted by the agent into the store, keyed by the
+// session id of the run. Recording happens in the background and never blocks the agent.
+func WithEventStore(store EventStore) AgentOption {
+	return func(a *genericAgent) {
+		a.eventStore = store
+	}
+}
+
+// WithOutputBuffer sets the size of the buffer of the output channel of the runs, negative for
+// DefaultOutputBufferSize, and the policy applied once the buffer is full. Dropping the oldest
+// events needs a buffer, a size of 0 is then raised to 1.
+func WithOutputBuffer(size int, policy OutputOverflowPolicy) AgentOption {
+	return func(a *genericAgent) {
+		a.outputBufferSize = size
+		a.outputOverflowPolicy = policy
+	}
+}
+
 var _ Agent = &genericAgent{}
 
 func NewGenericAgent(
 	agentContext Context, behavior BehaviorPattern,
-	llmProvider llms.ChatProvider, model *llms.Model, chatOptions []llms.ChatOption) (Agent, error) {
-	return &genericAgent{
+	llmProvider llms.ChatProvider, model *llms.Model, chatOptions []llms.ChatOption,
+	opts ...AgentOption) (Agent, error) {
+	a := &genericAgent{
 		agentContext: agentContext,
 		behavior:     behavior,
 
@@ -64,8 +159,21 @@ func NewGenericAgent(
 		model:       model,
 		chatOptions: chatOptions,
 
+		maxEmptyResponses: DefaultMaxEmptyResponses,
+		outputBufferSize:  DefaultOutputBufferSize,
+
 		stepCounter: &atomic.Uint64{},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.outputBufferSize < 0 {
+		a.outputBufferSize = DefaultOutputBufferSize
+	}
+	if a.outputOverflowPolicy == OutputOverflowDropOldest && a.outputBufferSize == 0 {
+		a.outputBufferSize = 1
+	}
+	return a, nil
 }
 
 type genericAgent struct {
@@ -76,45 +184,123 @@ type genericAgent struct {
 	model       *llms.Model
 	chatOptions []llms.ChatOption
 
+	maxEmptyResponses    int
+	eventStore           EventStore
+	outputBufferSize     int
+	outputOverflowPolicy OutputOverflowPolicy
+
 	stepCounter *atomic.Uint64
+
+	sessionLock sync.RWMutex
+	sessionId   string // id of the last session, or of the restored one
 }
 
 func (a *genericAgent) Run(ctx *RunContext) (input chan<- *eventbus.Event, output <-chan *eventbus.Event, err error) {
+	a.sessionLock.Lock()
+	if ctx.SessionId == "" {
+		// resume the restored session
+		ctx = &RunContext{SessionId: a.sessionId, Context: ctx.Context}
+	}
+	a.sessionId = ctx.SessionId
+	a.sessionLock.Unlock()
+
 	session, err := a.llmProvider.NewChat(a.agentContext.SystemPrompt(), a.model)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	inputChan := make(chan *eventbus.Event, 10)
-	outputChan := make(chan *eventbus.Event, 10)
-
-	go a.startLoop(
-		&SessionContext{
-			RunContext: ctx,
-			InputChan:  inputChan,
-		},
-		session, outputChan)
+	outputChan := make(chan *eventbus.Event, a.outputBufferSize)
+
+	sessionContext := &SessionContext{
+		RunContext: ctx,
+		InputChan:  inputChan,
+	}
+	if a.eventStore == nil && a.outputOverflowPolicy == OutputOverflowBlock {
+		go func() {
+			defer close(outputChan)
+			a.startLoop(sessionContext, session, outputChan)
+		}()
+		return inputChan, outputChan, nil
+	}
+
+	send := func(event *eventbus.Event) {
+		outputChan <- event
+	}
+	if a.outputOverflowPolicy == OutputOverflowDropOldest {
+		send = func(event *eventbus.Event) {
+			a.sendDroppingOldest(outputChan, event)
+		}
+	}
+
+	// forward the emitted events to the output, teeing them into the event store
+	loopOutput := make(chan *eventbus.Event, 10)
+	if a.eventStore != nil {
+		recorder := newEventRecorder(a.eventStore, ctx.SessionId, a.agentContext.AgentId())
+		go func() {
+			defer close(outputChan)
+			recorder.tee(loopOutput, send)
+		}()
+	} else {
+		go func() {
+			defer close(outputChan)
+			for event := range loopOutput {
+				send(event)
+			}
+		}()
+	}
+	go func() {
+		defer close(loopOutput)
+		a.startLoop(sessionContext, session, loopOutput)
+	}()
 	return inputChan, outputChan, nil
 }
 
+// sendDroppingOldest sends the event to the output, dropping the oldest buffered events while it is full
+func (a *genericAgent) sendDroppingOldest(output chan *eventbus.Event, event *eventbus.Event) {
+	for {
+		select {
+		case output <- event:
+			return
+		default:
+		}
+		select {
+		case dropped := <-output:
+			journal.Warning("agent", a.agentContext.AgentId(),
+				"output full, dropped the oldest event", "event", dropped.ID, "topic", dropped.Topic)
+		default:
+		}
+	}
+}
+
 func (a *genericAgent) startLoop(ctx *SessionContext, session llms.Chat, output chan<- *eventbus.Event) {
 	for {
 		select {
 		case <-ctx.Context.Done():
 			journal.Info("agent", a.agentContext.AgentId(),
 				"Chat cancelled during response processing", "err", ctx.Context.Err())
-			output <- NewAgentResponseEndEvent("", &AgentResponseEnd{
-				Abort:        true,
-				Error:        errors.Errorf(ErrorCodeChatSessionAbort, "canceled: %s", ctx.Context.Err()),
-				FinishReason: llms.FinishReasonCanceled,
-			})
+			output <- NewAgentResponseEndEvent("", NewCanceledResponseEnd(ctx.Context))
 			return
-		case inputEvent := <-ctx.InputChan:
+		case inputEvent, ok := <-ctx.InputChan:
+			if !ok {
+				journal.Info("agent", a.agentContext.AgentId(), "input closed, stop the agent")
+				return
+			}
 			journal.Info("agent", a.agentContext.AgentId(), "receive an input event", "input", inputEvent)
-			if err := a.nextStep(ctx, session, inputEvent, output); err != nil {
+			stepId, err := a.nextStep(ctx, session, inputEvent, output)
+			if ctx.Context.Err() != nil {
+				// the step was canceled, it ended its response unless it failed
+				journal.Info("agent", a.agentContext.AgentId(),
+					"Chat cancelled during response processing", "err", ctx.Context.Err())
+				if err != nil {
+					output <- NewAgentResponseEndEvent(stepId, NewCanceledResponseEnd(ctx.Context))
+				}
+				return
+			}
+			if err != nil {
 				journal.Info("agent", a.agentContext.AgentId(),
 					"Failed to process next step", "err", err.Error())
-				output <- NewAgentResponseEndEvent("", &AgentResponseEnd{
+				output <- NewAgentResponseEndEvent(stepId, &AgentResponseEnd{
 					Error:        err,
 					FinishReason: llms.FinishReasonError,
 				})
@@ -124,10 +310,11 @@ func (a *genericAgent) startLoop(ctx *SessionContext, session llms.Chat, output
 	}
 }
 
+// nextStep runs the step of the input event, returning its id: the trace id of the response of the step
 func (a *genericAgent) nextStep(ctx *SessionContext,
-	session llms.Chat, inputEvent *eventbus.Event, output chan<- *eventbus.Event) error {
+	session llms.Chat, inputEvent *eventbus.Event, output chan<- *eventbus.Event) (string, error) {
 	if inputEvent == nil {
-		return nil
+		return "", nil
 	}
 
 	stepContext := &StepContext{
@@ -140,6 +327,8 @@ func (a *genericAgent) nextStep(ctx *SessionContext,
 
 		OutputChan:  output,
 		ChatOptions: a.chatOptions,
+
+		MaxEmptyResponses: a.maxEmptyResponses,
 	}
 
 	switch inputEvent.Topic {
@@ -155,8 +344,8 @@ func (a *genericAgent) nextStep(ctx *SessionContext,
 	case EventTypeUserRequest:
 		stepContext.UserRequest = inputEvent.Data.(*UserRequest)
 	default:
-		return errors.Errorf(ErrorCodeInvalidInputEvent, "invalid input event type: %s", inputEvent.Topic)
+		return stepContext.StepId(), errors.Errorf(ErrorCodeInvalidInputEvent, "invalid input event type: %s", inputEvent.Topic)
 	}
 
-	return a.behavior.NextStep(stepContext)
+	return stepContext.StepId(), a.behavior.NextStep(stepContext)
 }
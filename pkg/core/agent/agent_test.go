@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChattyAgent creates an agent answering each user request with n messages, closing done once it answered
+func newChattyAgent(t *testing.T, n int, done chan<- struct{}, opts ...AgentOption) Agent {
+	ctrl := gomock.NewController(t)
+
+	provider := llms.NewMockChatProvider(ctrl)
+	provider.EXPECT().NewChat(gomock.Any(), gomock.Any()).Return(llms.NewMockChat(ctrl), nil)
+
+	behavior := NewMockBehaviorPattern(ctrl)
+	behavior.EXPECT().NextStep(gomock.Any()).DoAndReturn(func(ctx *StepContext) error {
+		for i := 0; i < n; i++ {
+			message := llms.NewAssistantMessage(fmt.Sprintf("message-%d", i), llms.ModelId{}, fmt.Sprint(i))
+			ctx.OutputChan <- NewAgentMessageEvent(ctx.StepId(), message)
+		}
+		ctx.OutputChan <- NewAgentResponseEndEvent(ctx.StepId(), &AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+		close(done)
+		return nil
+	})
+
+	a, err := NewGenericAgent(&stubContext{}, behavior, provider, &llms.Model{}, nil, opts...)
+	require.NoError(t, err)
+	return a
+}
+
+func startRun(t *testing.T, a Agent) <-chan *eventbus.Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	input, output, err := a.Run(&RunContext{SessionId: "session-1", Context: ctx})
+	require.NoError(t, err)
+	input <- NewUserRequestEvent(&UserRequest{Message: "hello"})
+	return output
+}
+
+func receiveEvents(t *testing.T, output <-chan *eventbus.Event, n int) []*eventbus.Event {
+	var events []*eventbus.Event
+	for len(events) < n {
+		select {
+		case event := <-output:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "agent did not respond")
+		}
+	}
+	return events
+}
+
+func messageIds(events []*eventbus.Event) []string {
+	var ids []string
+	for _, event := range events {
+		if event.Topic == EventTypeAgentMessage {
+			ids = append(ids, GetAgentMessageEventData(event).Message.MessageId)
+		}
+	}
+	return ids
+}
+
+func TestOutputOverflowBlock_SlowConsumerBlocksAgent(t *testing.T) {
+	done := make(chan struct{})
+	output := startRun(t, newChattyAgent(t, 5, done, WithOutputBuffer(2, OutputOverflowBlock)))
+
+	// the buffer is full, the agent waits for the consumer
+	select {
+	case <-done:
+		require.FailNow(t, "agent did not block on a full output")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// and no event is lost
+	events := receiveEvents(t, output, 6)
+	<-done
+	assert.Equal(t, []string{"message-0", "message-1", "message-2", "message-3", "message-4"}, messageIds(events))
+	assert.Equal(t, EventTypeAgentResponseEnd, events[5].Topic)
+}
+
+func TestOutputOverflowDropOldest_SlowConsumerMissesOldestEvents(t *testing.T) {
+	done := make(chan struct{})
+	output := startRun(t, newChattyAgent(t, 5, done, WithOutputBuffer(3, OutputOverflowDropOldest)))
+
+	// the agent answers without waiting for the consumer
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "agent blocked on a full output")
+	}
+	time.Sleep(50 * time.Millisecond) // the last events reach the output
+
+	// the consumer gets the latest events
+	events := receiveEvents(t, output, 3)
+	assert.Equal(t, []string{"message-3", "message-4"}, messageIds(events))
+	assert.Equal(t, EventTypeAgentResponseEnd, events[2].Topic)
+	select {
+	case event := <-output:
+		assert.Failf(t, "unexpected event", "%v", event)
+	default:
+	}
+}
+
+func TestOutputBuffer_FastConsumerSeesAllEventsInOrder(t *testing.T) {
+	for _, opts := range [][]AgentOption{
+		nil,
+		{WithOutputBuffer(0, OutputOverflowBlock)},
+		{WithOutputBuffer(1, OutputOverflowBlock), WithEventStore(NewInMemoryEventStore())},
+	} {
+		done := make(chan struct{})
+		output := startRun(t, newChattyAgent(t, 50, done, opts...))
+
+		events := receiveEvents(t, output, 51)
+		ids := messageIds(events)
+		require.Len(t, ids, 50)
+		for i, id := range ids {
+			assert.Equal(t, fmt.Sprintf("message-%d", i), id)
+		}
+		assert.Equal(t, EventTypeAgentResponseEnd, events[50].Topic)
+	}
+}
+
+func TestRun_ClosingInputStopsAgent(t *testing.T) {
+	for _, opts := range [][]AgentOption{
+		nil,
+		{WithOutputBuffer(2, OutputOverflowDropOldest)},
+		{WithEventStore(NewInMemoryEventStore())},
+	} {
+		done := make(chan struct{})
+		a := newChattyAgent(t, 1, done, opts...)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		input, output, err := a.Run(&RunContext{SessionId: "session-1", Context: ctx})
+		require.NoError(t, err)
+		input <- NewUserRequestEvent(&UserRequest{Message: "hello"})
+		close(input)
+
+		// the current response ends, then the output is closed
+		events := receiveEvents(t, output, 2)
+		assert.Equal(t, EventTypeAgentResponseEnd, events[1].Topic)
+		select {
+		case _, ok := <-output:
+			assert.False(t, ok, "output not closed")
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "agent did not close its output")
+		}
+		cancel()
+	}
+}
+
+func TestRun_FailedStepEndsWithItsStepId(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := llms.NewMockChatProvider(ctrl)
+	provider.EXPECT().NewChat(gomock.Any(), gomock.Any()).Return(llms.NewMockChat(ctrl), nil)
+
+	var stepId string
+	behavior := NewMockBehaviorPattern(ctrl)
+	behavior.EXPECT().NextStep(gomock.Any()).DoAndReturn(func(ctx *StepContext) error {
+		stepId = ctx.StepId()
+		ctx.OutputChan <- NewAgentResponseStartEvent(stepId)
+		return fmt.Errorf("boom")
+	})
+	a, err := NewGenericAgent(&stubContext{}, behavior, provider, &llms.Model{}, nil)
+	require.NoError(t, err)
+
+	// the end of the failed step closes the response it started
+	events := receiveEvents(t, startRun(t, a), 2)
+	require.Equal(t, EventTypeAgentResponseEnd, events[1].Topic)
+	end := GetAgentResponseEndEventData(events[1])
+	assert.Equal(t, stepId, end.TraceId)
+	assert.EqualError(t, end.Error, "boom")
+}
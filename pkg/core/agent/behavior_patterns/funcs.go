@@ -3,6 +3,7 @@ package behavior_patterns
 import (
 	"fmt"
 	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"strings"
 	"time"
 
 	"github.com/oopslink/agent-go/pkg/core/agent"
@@ -21,7 +22,14 @@ func runNextStep(ctx *agent.StepContext, nextStep nextStepFunc) error {
 	for {
 		end, err := nextStep(ctx)
 		if err != nil {
-			return err
+			if ctx.Context.Err() == nil {
+				return err
+			}
+			end = nil
+		}
+		if end == nil && ctx.Context.Err() != nil {
+			// canceled while waiting for the model or a tool, do not ask the model again
+			end = agent.NewCanceledResponseEnd(ctx.Context)
 		}
 		if end != nil {
 			agentResponseEnd(stepId, ctx.OutputChan, end)
@@ -51,6 +59,11 @@ func autoCallTool(ctx *agent.StepContext, toolCall *llms.ToolCall) error {
 	if err != nil {
 		journal.Warning("step/auto_call_tool", traceId,
 			fmt.Sprintf("failed to call tool %s: %v", toolCall.Name, err))
+		// tell the model the call failed, as a failed call confirmed by the user would
+		failure := agent.GetToolCallResultEventData(
+			agent.NewFailedToolCallEvent(toolCall.ToolCallId, toolCall.Name, err))
+		_ = ctx.AgentContext.UpdateMemory(
+			ctx.Context, llms.NewToolCallResultMessage(failure, time.Now()))
 		return err
 	}
 
@@ -125,16 +138,14 @@ func askLLM(ctx *agent.StepContext,
 	var toolCalls []*llms.ToolCall
 	var fullMessageContent string
 	var stepRuntimeContext *StepRuntimeContext
+	var usage llms.UsageMetadata
+	var reasoningParts []llms.Part
 
 	for response, iterErr := range responseIterator {
 		// Check for context cancellation before processing each response
 		select {
 		case <-ctx.Context.Done():
-			return &agent.AgentResponseEnd{
-				Abort:        true,
-				Error:        errors.Errorf(agent.ErrorCodeChatSessionAbort, "canceled: %s", ctx.Context.Err()),
-				FinishReason: llms.FinishReasonCanceled,
-			}, nil
+			return agent.NewCanceledResponseEnd(ctx.Context), nil
 		default:
 			// Continue with response processing
 		}
@@ -153,6 +164,11 @@ func askLLM(ctx *agent.StepContext,
 			continue
 		}
 
+		if !response.Usage.IsZero() {
+			// the streamed responses carry the usage accumulated so far
+			usage = response.Usage
+		}
+
 		if len(messageId) == 0 {
 			messageId = response.MessageId
 			modelId = response.Model
@@ -165,7 +181,14 @@ func askLLM(ctx *agent.StepContext,
 
 		// process the response
 		for _, part := range response.Parts {
-			if textPart, ok := part.(*llms.TextPart); ok {
+			if textPart, ok := part.(*llms.TextPart); ok && textPart.Reasoning {
+				// the reasoning is not the answer, it is kept with its signature to be sent back to the model
+				reasoningParts = appendReasoningPart(reasoningParts, textPart)
+				if message := newReasoningMessage(messageId, modelId, textPart); message != nil {
+					sendEvent(stepId, "agent response a reasoning part",
+						ctx.OutputChan, agent.NewAgentMessageEvent(stepId, message))
+				}
+			} else if ok {
 				fullMessageContent = fullMessageContent + textPart.Text
 				if options.textPartHandleFn != nil {
 					toolCallList, err := options.textPartHandleFn(stepRuntimeContext, textPart)
@@ -193,9 +216,19 @@ func askLLM(ctx *agent.StepContext,
 		}
 	}
 
+	if !usage.IsZero() {
+		sendEvent(stepId, "agent usage",
+			ctx.OutputChan, agent.NewAgentUsageEvent(stepId, modelId, usage))
+	}
+
+	if end := checkEmptyResponse(ctx, fullMessageContent, toolCalls); end != nil {
+		return end, nil
+	}
+
 	agentContext := ctx.AgentContext
 
 	if assistantMessage := llms.NewAssistantMessage(messageId, modelId, fullMessageContent, toolCalls...); assistantMessage != nil {
+		assistantMessage.Parts = append(reasoningParts, assistantMessage.Parts...)
 		if err = agentContext.UpdateMemory(ctx.Context, assistantMessage); err != nil {
 			journal.Warning("step", stepId,
 				fmt.Sprintf("failed to add assistant message to memory: %v", err))
@@ -256,6 +289,58 @@ func askLLM(ctx *agent.StepContext,
 	}
 }
 
+// appendReasoningPart appends the streamed reasoning part, the deltas of a thinking being merged
+// into one part until its signature
+func appendReasoningPart(parts []llms.Part, part *llms.TextPart) []llms.Part {
+	if len(parts) > 0 && !part.Redacted {
+		if last, ok := parts[len(parts)-1].(*llms.TextPart); ok && !last.Redacted && last.Signature == "" {
+			last.Text += part.Text
+			last.Signature = part.Signature
+			return parts
+		}
+	}
+	return append(parts, llms.NewTextPartBuilder().Text(part.Text).Reasoning(true).
+		Signature(part.Signature).Redacted(part.Redacted).Build())
+}
+
+// newReasoningMessage returns the assistant message of the reasoning part, keeping its flags
+func newReasoningMessage(messageId string, modelId llms.ModelId, part *llms.TextPart) *llms.Message {
+	if part.Text == "" && part.Signature == "" {
+		return nil
+	}
+	return &llms.Message{
+		MessageId: messageId,
+		Creator:   llms.MessageCreator{Role: llms.MessageRoleAssistant},
+		Model:     modelId,
+		Parts:     []llms.Part{part},
+		Timestamp: time.Now(),
+	}
+}
+
+// checkEmptyResponse counts the consecutive empty responses of the model, ending the step
+// when the limit is reached rather than asking the model again and again
+func checkEmptyResponse(ctx *agent.StepContext, fullMessageContent string, toolCalls []*llms.ToolCall) *agent.AgentResponseEnd {
+	if strings.TrimSpace(fullMessageContent) != "" || len(toolCalls) > 0 {
+		ctx.EmptyResponses = 0
+		return nil
+	}
+
+	ctx.EmptyResponses++
+	journal.Warning("step", ctx.StepId(),
+		fmt.Sprintf("model returned an empty response, %d in a row", ctx.EmptyResponses))
+
+	limit := ctx.EmptyResponseLimit()
+	if limit == 0 || ctx.EmptyResponses < limit {
+		return nil
+	}
+	return &agent.AgentResponseEnd{
+		Abort: true,
+		Error: errors.Errorf(agent.ErrorCodeEmptyResponses,
+			"model returned %d consecutive empty responses, without text nor tool calls", ctx.EmptyResponses),
+		FinishReason: llms.FinishReasonEmptyResponse,
+	}
+}
+
 func recordCurrentContext(ctx *agent.StepContext, messages []*llms.Message) {
 	_ = journal.Info("context", ctx.AgentContext.AgentId(),
 		fmt.Sprintf("context for %s", ctx.StepId()), "instruction", ctx.AgentContext.SystemPrompt(), "context", messages)
@@ -0,0 +1,285 @@
+package behavior_patterns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyChatProvider creates chats answering every request with an empty message
+type emptyChatProvider struct {
+	chat *emptyChat
+}
+
+func (p *emptyChatProvider) Close() error { return nil }
+
+func (p *emptyChatProvider) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return p.chat, nil
+}
+
+func (p *emptyChatProvider) IsRetryableError(error) bool { return false }
+
+type emptyChat struct {
+	sends int
+}
+
+func (c *emptyChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	c.sends++
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		yield(&llms.ChatResponse{
+			Message:      llms.Message{MessageId: "empty", Model: llms.ModelId{Provider: "test", ID: "model"}},
+			FinishReason: llms.FinishReasonNormalEnd,
+		}, nil)
+	}, nil
+}
+
+// stubAgentContext is an agent context without memory nor tools
+type stubAgentContext struct{}
+
+func (c *stubAgentContext) AgentId() string            { return "test-agent" }
+func (c *stubAgentContext) SystemPrompt() string       { return "" }
+func (c *stubAgentContext) GetModel() *llms.Model      { return nil }
+func (c *stubAgentContext) GetState() agent.AgentState { return nil }
+
+func (c *stubAgentContext) Generate(ctx context.Context, params *agent.GenerateContextParams) (*agent.GeneratedContext, error) {
+	return &agent.GeneratedContext{Messages: params.ToMessages()}, nil
+}
+
+func (c *stubAgentContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	return nil
+}
+
+func (c *stubAgentContext) CallTool(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+	return nil, nil
+}
+
+func (c *stubAgentContext) CanAutoCall(toolCall *llms.ToolCall) bool { return false }
+
+func (c *stubAgentContext) ValidateToolCall(call *llms.ToolCall) error { return nil }
+
+func runUntilResponseEnd(t *testing.T, a agent.Agent) *agent.AgentResponseEnd {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	input, output, err := a.Run(&agent.RunContext{SessionId: "session", Context: ctx})
+	require.NoError(t, err)
+	input <- agent.NewUserRequestEvent(&agent.UserRequest{Message: "hello"})
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-output:
+			if event.Topic == agent.EventTypeAgentResponseEnd {
+				return agent.GetAgentResponseEndEventData(event)
+			}
+		case <-timeout:
+			require.FailNow(t, "agent did not end its response")
+		}
+	}
+}
+
+func TestAskLLM_StopsAfterConsecutiveEmptyResponses(t *testing.T) {
+	pattern, err := NewReActPattern(100)
+	require.NoError(t, err)
+
+	chat := &emptyChat{}
+	a, err := agent.NewGenericAgent(&stubAgentContext{}, pattern,
+		&emptyChatProvider{chat: chat}, &llms.Model{}, nil, agent.WithMaxEmptyResponses(4))
+	require.NoError(t, err)
+
+	end := runUntilResponseEnd(t, a)
+	assert.True(t, end.Abort)
+	assert.Equal(t, llms.FinishReasonEmptyResponse, end.FinishReason)
+	assert.True(t, errors.IsCode(end.Error, agent.ErrorCodeEmptyResponses))
+	assert.Contains(t, end.Error.Error(), "4 consecutive empty responses")
+	assert.Equal(t, 4, chat.sends)
+}
+
+func TestAskLLM_DefaultEmptyResponseLimit(t *testing.T) {
+	pattern, err := NewReActPattern(100)
+	require.NoError(t, err)
+
+	chat := &emptyChat{}
+	a, err := agent.NewGenericAgent(&stubAgentContext{}, pattern,
+		&emptyChatProvider{chat: chat}, &llms.Model{}, nil)
+	require.NoError(t, err)
+
+	end := runUntilResponseEnd(t, a)
+	assert.Equal(t, llms.FinishReasonEmptyResponse, end.FinishReason)
+	assert.Equal(t, agent.DefaultMaxEmptyResponses, chat.sends)
+}
+
+func TestCheckEmptyResponse(t *testing.T) {
+	ctx := &agent.StepContext{
+		AgentContext:      &stubAgentContext{},
+		OutputChan:        make(chan *eventbus.Event, 1),
+		MaxEmptyResponses: 2,
+	}
+
+	assert.Nil(t, checkEmptyResponse(ctx, "  ", nil))
+	// a response with content resets the count
+	assert.Nil(t, checkEmptyResponse(ctx, "text", nil))
+	assert.Equal(t, 0, ctx.EmptyResponses)
+	assert.Nil(t, checkEmptyResponse(ctx, "", nil))
+	assert.Nil(t, checkEmptyResponse(ctx, "", []*llms.ToolCall{{Name: "search"}}))
+	assert.Nil(t, checkEmptyResponse(ctx, "", nil))
+
+	end := checkEmptyResponse(ctx, "", nil)
+	require.NotNil(t, end)
+	assert.Equal(t, llms.FinishReasonEmptyResponse, end.FinishReason)
+
+	// a negative limit never aborts
+	ctx = &agent.StepContext{AgentContext: &stubAgentContext{}, MaxEmptyResponses: -1}
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, checkEmptyResponse(ctx, "", nil))
+	}
+}
+
+// blockingChat waits for the context to be done, as a provider honoring the context would
+type blockingChat struct {
+	emptyChatProvider
+	sent chan struct{}
+}
+
+func (c *blockingChat) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return c, nil
+}
+
+func (c *blockingChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	close(c.sent)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAskLLM_CanceledMidTurn(t *testing.T) {
+	pattern, err := NewGenericPattern()
+	require.NoError(t, err)
+
+	chat := &blockingChat{sent: make(chan struct{})}
+	a, err := agent.NewGenericAgent(&stubAgentContext{}, pattern, chat, &llms.Model{}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input, output, err := a.Run(&agent.RunContext{SessionId: "session", Context: ctx})
+	require.NoError(t, err)
+	input <- agent.NewUserRequestEvent(&agent.UserRequest{Message: "hello"})
+
+	<-chat.sent
+	cancel()
+
+	var events []*eventbus.Event
+	timeout := time.After(5 * time.Second)
+	for closed := false; !closed; {
+		select {
+		case event, ok := <-output:
+			if !ok {
+				closed = true
+				continue
+			}
+			events = append(events, event)
+		case <-timeout:
+			require.FailNow(t, "agent did not close its output")
+		}
+	}
+
+	// the response is aborted once, then the agent stops
+	require.Len(t, events, 2)
+	assert.Equal(t, agent.EventTypeAgentResponseStart, events[0].Topic)
+	end := agent.GetAgentResponseEndEventData(events[1])
+	assert.True(t, end.Abort)
+	assert.Equal(t, llms.FinishReasonCanceled, end.FinishReason)
+	assert.True(t, errors.IsCode(end.Error, agent.ErrorCodeChatSessionAbort))
+	assert.Equal(t, events[0].Data.(*agent.AgentResponseStart).TraceId, end.TraceId)
+}
+
+// streamedChat streams each part of its response in its own chunk
+type streamedChat struct {
+	emptyChatProvider
+	parts []llms.Part
+}
+
+func (c *streamedChat) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return c, nil
+}
+
+func (c *streamedChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		for _, part := range c.parts {
+			if !yield(&llms.ChatResponse{
+				Message: llms.Message{
+					MessageId: "m",
+					Model:     llms.ModelId{Provider: "test", ID: "model"},
+					Parts:     []llms.Part{part},
+				},
+			}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+func TestAskLLM_ReasoningWithToolCall(t *testing.T) {
+	pattern, err := NewReActPattern(100)
+	require.NoError(t, err)
+
+	agentContext := &memoryAgentContext{}
+	chat := &streamedChat{parts: []llms.Part{
+		llms.NewTextPartBuilder().Text("Action: search\n").Reasoning(true).Build(),
+		llms.NewTextPartBuilder().Text("I should fetch it").Reasoning(true).Build(),
+		llms.NewTextPartBuilder().Reasoning(true).Signature("sig").Build(),
+		&llms.ToolCall{ToolCallId: "call-1", Name: "fetch"},
+	}}
+	output := make(chan *eventbus.Event, 100)
+	err = pattern.NextStep(&agent.StepContext{
+		Context:      context.Background(),
+		AgentContext: agentContext,
+		UserRequest:  &agent.UserRequest{Message: "get the data"},
+		Session:      chat,
+		OutputChan:   output,
+	})
+	require.NoError(t, err)
+	close(output)
+
+	// the thinking is streamed as reasoning, the tool call is not parsed out of it
+	var reasoning []*llms.TextPart
+	var externalActions []*agent.ExternalAction
+	for event := range output {
+		switch event.Topic {
+		case agent.EventTypeAgentMessage:
+			for _, part := range agent.GetAgentMessageEventData(event).Message.Parts {
+				textPart := part.(*llms.TextPart)
+				assert.True(t, textPart.Reasoning, "unexpected answer %q", textPart.Text)
+				reasoning = append(reasoning, textPart)
+			}
+		case agent.EventTypeExternalAction:
+			externalActions = append(externalActions, agent.GetExternalActionEventData(event))
+		}
+	}
+	require.Len(t, reasoning, 3)
+	assert.Equal(t, "sig", reasoning[2].Signature)
+	require.Len(t, externalActions, 1)
+	assert.Equal(t, "fetch", externalActions[0].ToolCall.Name)
+
+	// the memory keeps the signed thinking ahead of the tool call
+	var assistant *llms.Message
+	for _, message := range agentContext.messages {
+		if message.Creator.Role == llms.MessageRoleAssistant {
+			assistant = message
+		}
+	}
+	require.NotNil(t, assistant)
+	require.Len(t, assistant.Parts, 2)
+	thinking := assistant.Parts[0].(*llms.TextPart)
+	assert.True(t, thinking.Reasoning)
+	assert.Equal(t, "Action: search\nI should fetch it", thinking.Text)
+	assert.Equal(t, "sig", thinking.Signature)
+	assert.Equal(t, "call-1", assistant.Parts[1].(*llms.ToolCall).ToolCallId)
+}
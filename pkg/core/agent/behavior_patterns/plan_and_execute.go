@@ -17,12 +17,32 @@ var _planAndExecutePrompt string
 
 const (
 	StateKeyPlan = "plan"
+	// StateKeyPlanRecovery is the state of the recovery from the failed tasks of the plan
+	StateKeyPlanRecovery = "plan_recovery"
+
+	// DefaultMaxReplans is the default number of times a plan is revised after failed tasks
+	DefaultMaxReplans = 3
 )
 
 // PlanAndExecuteConfig defines the configuration for plan and execute behavior
 type PlanAndExecuteConfig struct {
 	RequirePlanConfirmation bool // whether plan needs user confirmation
 	RequireStepConfirmation bool // whether each step needs user confirmation
+
+	// MaxStepRetries is the number of times the model is asked to retry a failed task, 0 for no retry
+	MaxStepRetries int
+	// ReplanOnFailure asks the model to revise the remaining plan once a task failed more than
+	// MaxStepRetries times, rather than letting the plan fail
+	ReplanOnFailure bool
+	// MaxReplans limits the revisions of a plan after failed tasks, 0 for DefaultMaxReplans
+	MaxReplans int
+}
+
+func (c *PlanAndExecuteConfig) maxReplans() int {
+	if c.MaxReplans <= 0 {
+		return DefaultMaxReplans
+	}
+	return c.MaxReplans
 }
 
 type TaskState string
@@ -49,6 +69,7 @@ type Task struct {
 	State       TaskState `json:"state"`
 
 	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
 }
 
 func (t *Task) Marshal() string {
@@ -93,12 +114,30 @@ type PlanAndExecuteAgentResponse struct {
 	FinalResult string `json:"finalResult,omitempty"`
 }
 
+// PlanRecovery tracks the recovery from the failed tasks of the current plan
+type PlanRecovery struct {
+	// Failures counts the failures of the tasks of the plan, by task id
+	Failures map[string]int `json:"failures,omitempty"`
+	// Replans counts the revisions of the plan after failed tasks
+	Replans int `json:"replans,omitempty"`
+	// Replanning is set while the model is asked to revise the plan
+	Replanning bool `json:"replanning,omitempty"`
+	// FailedTask is the task the plan is revised for
+	FailedTask string `json:"failedTask,omitempty"`
+}
+
 type PlanExecuteResult struct {
 	*Plan
 	FinalResult string `json:"FinalResult,omitempty"`
 }
 
-var _ agent.BehaviorPattern = &planAndExecutePattern{}
+// PlanProgress is the progress of the plan saved into the snapshots of the agent
+type PlanProgress struct {
+	Plan     *Plan         `json:"plan,omitempty"`
+	Recovery *PlanRecovery `json:"recovery,omitempty"`
+}
+
+var _ agent.ResumableBehaviorPattern = &planAndExecutePattern{}
 
 func NewPlanExecutePattern(config *PlanAndExecuteConfig) (agent.BehaviorPattern, error) {
 	if config == nil {
@@ -124,6 +163,45 @@ func (p *planAndExecutePattern) NextStep(ctx *agent.StepContext) error {
 	return runNextStep(ctx, p.nextStep)
 }
 
+// SaveProgress saves the current plan and the recovery from its failed tasks
+func (p *planAndExecutePattern) SaveProgress(state agent.AgentState) (json.RawMessage, error) {
+	progress := &PlanProgress{}
+	value, err := state.Get(StateKeyPlan)
+	if err != nil {
+		return nil, err
+	}
+	if plan, ok := value.(*Plan); ok {
+		progress.Plan = plan
+	}
+	value, err = state.Get(StateKeyPlanRecovery)
+	if err != nil {
+		return nil, err
+	}
+	if recovery, ok := value.(*PlanRecovery); ok {
+		progress.Recovery = recovery
+	}
+	return json.Marshal(progress)
+}
+
+// RestoreProgress puts the saved plan and its recovery back into the state
+func (p *planAndExecutePattern) RestoreProgress(state agent.AgentState, data json.RawMessage) error {
+	progress := &PlanProgress{}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return errors.Errorf(agent.ErrorCodeLoadPlanFailed, "invalid plan progress: %v", err)
+	}
+	if progress.Plan != nil {
+		if err := state.Put(StateKeyPlan, progress.Plan); err != nil {
+			return err
+		}
+	}
+	if progress.Recovery != nil {
+		if err := state.Put(StateKeyPlanRecovery, progress.Recovery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *planAndExecutePattern) nextStep(ctx *agent.StepContext) (endResponse *agent.AgentResponseEnd, err error) {
 
 	processor := &planAndExecuteProcessor{
@@ -172,7 +250,14 @@ func (p *planAndExecuteProcessor) OnBeforeEnd(ctx *StepRuntimeContext, fullMessa
 		if err = p.savePlan(ctx, result.PlanResult); err != nil {
 			return nil, err
 		}
+		recovery, err := p.resetRecovery()
+		if err != nil {
+			return nil, err
+		}
 		if p.config.RequirePlanConfirmation {
+			if recovery.FailedTask != "" {
+				return p.requireConfirmRevisedPlan(ctx, result.PlanResult, recovery.FailedTask)
+			}
 			return p.requireConfirmPlan(ctx, result.PlanResult)
 		}
 	}
@@ -182,6 +267,16 @@ func (p *planAndExecuteProcessor) OnBeforeEnd(ctx *StepRuntimeContext, fullMessa
 		if err = p.updatePlan(ctx, result.ExecuteState, result.CurrentTaskStatus); err != nil {
 			return nil, err
 		}
+		if result.CurrentTaskStatus.State == TaskStateFailed {
+			recovering, err := p.recoverFailedTask(ctx, result.CurrentTaskStatus, result.Reason)
+			if err != nil {
+				return nil, err
+			}
+			if recovering {
+				// the plan goes on, even when the model reported it failed
+				return nil, p.updatePlan(ctx, PlanStateExecuting, result.CurrentTaskStatus)
+			}
+		}
 		if result.CurrentTaskStatus.State == TaskStatePending && p.config.RequireStepConfirmation {
 			return p.requireConfirmTask(ctx, result.CurrentTaskStatus)
 		}
@@ -245,6 +340,92 @@ func (p *planAndExecuteProcessor) loadPlan(ctx *StepRuntimeContext) (*Plan, erro
 	return plan, nil
 }
 
+// recoverFailedTask asks the model to retry the failed task, or to revise the plan once the retries are
+// exhausted; it returns false when the failure is not recovered from and the plan goes on as reported
+func (p *planAndExecuteProcessor) recoverFailedTask(ctx *StepRuntimeContext, task *Task, reason string) (bool, error) {
+	if p.config.MaxStepRetries <= 0 && !p.config.ReplanOnFailure {
+		return false, nil
+	}
+	recovery, err := p.loadRecovery()
+	if err != nil {
+		return false, err
+	}
+
+	cause := task.Error
+	if cause == "" {
+		cause = reason
+	}
+	recovery.Failures[task.ID]++
+	failures := recovery.Failures[task.ID]
+
+	var instruction string
+	switch {
+	case failures <= p.config.MaxStepRetries:
+		_ = journal.Info("plan", p.ctx.AgentContext.AgentId(),
+			"retry failed task", "task", task.ID, "failures", failures)
+		instruction = fmt.Sprintf("Task %s failed (attempt %d of %d): %s\n"+
+			"Retry the task, and report its status in currentTaskStatus.",
+			task.ID, failures, p.config.MaxStepRetries+1, cause)
+	case p.config.ReplanOnFailure && recovery.Replans < p.config.maxReplans():
+		_ = journal.Info("plan", p.ctx.AgentContext.AgentId(),
+			"revise the plan after failed task", "task", task.ID, "failures", failures)
+		recovery.Replans++
+		recovery.Replanning = true
+		recovery.FailedTask = task.ID
+		instruction = fmt.Sprintf("Task %s failed %d times: %s\n"+
+			"Do not abort the plan: revise the remaining plan to reach the goal another way. Keep the succeeded tasks, "+
+			"replace the failed task and the tasks depending on it, and respond with the revised plan in planResult.",
+			task.ID, failures, cause)
+	default:
+		return false, p.saveRecovery(recovery)
+	}
+
+	if err = p.saveRecovery(recovery); err != nil {
+		return false, err
+	}
+	if err = p.ctx.AgentContext.UpdateMemory(p.ctx.Context, llms.NewUserMessage(instruction)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// loadRecovery loads the recovery state of the current plan
+func (p *planAndExecuteProcessor) loadRecovery() (*PlanRecovery, error) {
+	value, err := p.ctx.AgentContext.GetState().Get(StateKeyPlanRecovery)
+	if err != nil {
+		return nil, err
+	}
+	recovery, ok := value.(*PlanRecovery)
+	if !ok || recovery == nil {
+		recovery = &PlanRecovery{}
+	}
+	if recovery.Failures == nil {
+		recovery.Failures = make(map[string]int)
+	}
+	return recovery, nil
+}
+
+func (p *planAndExecuteProcessor) saveRecovery(recovery *PlanRecovery) error {
+	return p.ctx.AgentContext.GetState().Put(StateKeyPlanRecovery, recovery)
+}
+
+// resetRecovery resets the recovery state for a new plan, it returns the state before the reset.
+// The revisions are only counted across the plans revised after failed tasks.
+func (p *planAndExecuteProcessor) resetRecovery() (*PlanRecovery, error) {
+	recovery, err := p.loadRecovery()
+	if err != nil {
+		return nil, err
+	}
+	reset := &PlanRecovery{}
+	if recovery.Replanning {
+		reset.Replans = recovery.Replans
+	}
+	if err = p.saveRecovery(reset); err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
 func (p *planAndExecuteProcessor) requireConfirmPlan(ctx *StepRuntimeContext, plan *Plan) (*agent.AgentResponseEnd, error) {
 	stepId := p.ctx.StepId()
 	event := agent.NewExternalActionEvent(
@@ -257,6 +438,18 @@ func (p *planAndExecuteProcessor) requireConfirmPlan(ctx *StepRuntimeContext, pl
 	}, nil
 }
 
+func (p *planAndExecuteProcessor) requireConfirmRevisedPlan(ctx *StepRuntimeContext, plan *Plan, failedTask string) (*agent.AgentResponseEnd, error) {
+	stepId := p.ctx.StepId()
+	event := agent.NewExternalActionEvent(
+		fmt.Sprintf("plan revised after the failure of task %s, please confirm:\n\n*** plan ***\n%s", failedTask, plan.Marshal()))
+	sendEvent(stepId, "agent revised the plan",
+		p.ctx.OutputChan, event)
+	return &agent.AgentResponseEnd{
+		TraceId:      stepId,
+		FinishReason: llms.FinishReasonNormalEnd,
+	}, nil
+}
+
 func (p *planAndExecuteProcessor) requireConfirmTask(ctx *StepRuntimeContext, taskStatus *Task) (*agent.AgentResponseEnd, error) {
 	stepId := p.ctx.StepId()
 	event := agent.NewExternalActionEvent(
@@ -1,10 +1,19 @@
 package behavior_patterns
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/core/agent/state"
+	"github.com/oopslink/agent-go/pkg/core/memory"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -169,3 +178,337 @@ func TestPlanAndExecuteConfigDefaults(t *testing.T) {
 	assert.True(t, planPattern.config.RequirePlanConfirmation)
 	assert.True(t, planPattern.config.RequireStepConfirmation)
 }
+
+// scriptedChat answers the requests with the scripted texts or tool calls, in order
+type scriptedChat struct {
+	script []llms.Part
+	sends  int
+}
+
+func (c *scriptedChat) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return c, nil
+}
+
+func (c *scriptedChat) IsRetryableError(error) bool { return false }
+
+func (c *scriptedChat) Close() error { return nil }
+
+func (c *scriptedChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	if c.sends >= len(c.script) {
+		return nil, errors.New("end of the script")
+	}
+	part := c.script[c.sends]
+	c.sends++
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		yield(&llms.ChatResponse{
+			Message: llms.Message{
+				MessageId: "m",
+				Model:     llms.ModelId{Provider: "test", ID: "model"},
+				Parts:     []llms.Part{part},
+			},
+		}, nil)
+	}, nil
+}
+
+// planAgentContext is an agent context with a state, a memory and a flaky auto called tool
+type planAgentContext struct {
+	memoryAgentContext
+	state     agent.AgentState
+	toolCalls int
+	failCalls int // number of first calls of the tool failing
+}
+
+func (c *planAgentContext) GetModel() *llms.Model {
+	return &llms.Model{ModelId: llms.ModelId{Provider: "test", ID: "model"}}
+}
+
+func (c *planAgentContext) GetState() agent.AgentState { return c.state }
+
+func (c *planAgentContext) Generate(ctx context.Context, params *agent.GenerateContextParams) (*agent.GeneratedContext, error) {
+	_ = c.UpdateMemory(ctx, params.ToMessages()...)
+	return &agent.GeneratedContext{Messages: c.messages}, nil
+}
+
+func (c *planAgentContext) CanAutoCall(toolCall *llms.ToolCall) bool { return true }
+
+func (c *planAgentContext) CallTool(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+	c.toolCalls++
+	if c.toolCalls <= c.failCalls {
+		return nil, errors.New("service unavailable")
+	}
+	return &llms.ToolCallResult{ToolCallId: call.ToolCallId, Name: call.Name, Result: map[string]any{"data": 42}}, nil
+}
+
+// userTexts returns the texts of the user messages of the memory
+func (c *planAgentContext) userTexts() []string {
+	var texts []string
+	for _, message := range c.messages {
+		if message.Creator.Role == llms.MessageRoleUser {
+			texts = append(texts, message.Parts[0].(*llms.TextPart).Text)
+		}
+	}
+	return texts
+}
+
+func planText(response string) llms.Part {
+	return &llms.TextPart{Text: response}
+}
+
+func fetchCall(id string) llms.Part {
+	return &llms.ToolCall{ToolCallId: id, Name: "fetch"}
+}
+
+const (
+	planTask1 = `{"planResult": {"state": "Pending", "tasks": [{"id": "task-1", "description": "fetch the data", "state": "Pending"}]}, "executeState": "Pending", "reason": "plan"}`
+	task1Fail = `{"currentTaskStatus": {"id": "task-1", "description": "fetch the data", "state": "Failed", "error": "service unavailable"}, "executeState": "Failed", "reason": "fetch failed"}`
+	planTask2 = `{"planResult": {"state": "Pending", "tasks": [{"id": "task-1", "description": "fetch the data", "state": "Failed"}, {"id": "task-2", "description": "fetch the data from the mirror", "state": "Pending"}]}, "executeState": "Pending", "reason": "revised plan"}`
+	task2Done = `{"currentTaskStatus": {"id": "task-2", "description": "fetch the data from the mirror", "state": "Succeed", "result": "42"}, "executeState": "Executing", "reason": "fetched"}`
+	task1Done = `{"currentTaskStatus": {"id": "task-1", "description": "fetch the data", "state": "Succeed", "result": "42"}, "executeState": "Executing", "reason": "fetched"}`
+	planDone  = `{"executeState": "Succeed", "reason": "done", "finalResult": "the data is 42"}`
+)
+
+func runPlan(t *testing.T, config *PlanAndExecuteConfig,
+	agentContext *planAgentContext, chat *scriptedChat) ([]*eventbus.Event, *agent.AgentResponseEnd) {
+	pattern, err := NewPlanExecutePattern(config)
+	require.NoError(t, err)
+
+	output := make(chan *eventbus.Event, 100)
+	err = pattern.NextStep(&agent.StepContext{
+		Context:      context.Background(),
+		AgentContext: agentContext,
+		UserRequest:  &agent.UserRequest{Message: "get the data"},
+		Session:      chat,
+		OutputChan:   output,
+	})
+	require.NoError(t, err)
+	close(output)
+
+	var events []*eventbus.Event
+	for event := range output {
+		events = append(events, event)
+	}
+	require.NotEmpty(t, events)
+	last := events[len(events)-1]
+	require.Equal(t, agent.EventTypeAgentResponseEnd, last.Topic)
+	return events, agent.GetAgentResponseEndEventData(last)
+}
+
+func loadTestPlan(t *testing.T, agentContext *planAgentContext) *Plan {
+	value, err := agentContext.state.Get(StateKeyPlan)
+	require.NoError(t, err)
+	return value.(*Plan)
+}
+
+func TestPlanAndExecute_ReplanOnFailure(t *testing.T) {
+	agentContext := &planAgentContext{state: state.NewInMemoryState(), failCalls: 1}
+	chat := &scriptedChat{script: []llms.Part{
+		planText(planTask1), fetchCall("c1"), planText(task1Fail),
+		planText(planTask2), fetchCall("c2"), planText(task2Done), planText(planDone),
+	}}
+
+	_, end := runPlan(t, &PlanAndExecuteConfig{ReplanOnFailure: true}, agentContext, chat)
+	assert.Nil(t, end.Error)
+	assert.Equal(t, llms.FinishReasonNormalEnd, end.FinishReason)
+	assert.Equal(t, 7, chat.sends)
+	assert.Equal(t, 2, agentContext.toolCalls)
+
+	// the failure of the tool is reported to the model, then the model is asked to revise the plan
+	var results []*llms.ToolCallResult
+	for _, message := range agentContext.messages {
+		if result, ok := message.Parts[0].(*llms.ToolCallResult); ok {
+			results = append(results, result)
+		}
+	}
+	require.Len(t, results, 2)
+	assert.Equal(t, "InvokeFailed", results[0].Result["state"])
+	assert.Equal(t, "c2", results[1].ToolCallId)
+	assert.True(t, slicesContainPrefix(agentContext.userTexts(), "Task task-1 failed 1 times: service unavailable"))
+
+	plan := loadTestPlan(t, agentContext)
+	require.Len(t, plan.Tasks, 2)
+	assert.Equal(t, TaskState(TaskStateSucceed), plan.Tasks[1].State)
+}
+
+func TestPlanAndExecute_ReplanNeedsConfirmation(t *testing.T) {
+	agentContext := &planAgentContext{state: state.NewInMemoryState(), failCalls: 1}
+	chat := &scriptedChat{script: []llms.Part{
+		planText(planTask1), fetchCall("c1"), planText(task1Fail), planText(planTask2),
+	}}
+	config := &PlanAndExecuteConfig{ReplanOnFailure: true, RequirePlanConfirmation: true}
+
+	confirmations := func(events []*eventbus.Event) []string {
+		var messages []string
+		for _, event := range events {
+			if event.Topic == agent.EventTypeExternalAction {
+				messages = append(messages, agent.GetExternalActionEventData(event).Message)
+			}
+		}
+		return messages
+	}
+
+	events, end := runPlan(t, config, agentContext, chat)
+	assert.Nil(t, end.Error)
+	require.Len(t, confirmations(events), 1)
+	assert.True(t, strings.HasPrefix(confirmations(events)[0], "plan made, please confirm"))
+
+	// once confirmed the task fails, the revised plan is confirmed as the first one
+	events, end = runPlan(t, config, agentContext, chat)
+	assert.Nil(t, end.Error)
+	assert.Equal(t, 4, chat.sends)
+	require.Len(t, confirmations(events), 1)
+	assert.True(t, strings.HasPrefix(confirmations(events)[0], "plan revised after the failure of task task-1, please confirm"))
+}
+
+func TestPlanAndExecute_RetryFailedStep(t *testing.T) {
+	agentContext := &planAgentContext{state: state.NewInMemoryState(), failCalls: 1}
+	chat := &scriptedChat{script: []llms.Part{
+		planText(planTask1), fetchCall("c1"), planText(task1Fail),
+		fetchCall("c2"), planText(task1Done), planText(planDone),
+	}}
+
+	_, end := runPlan(t, &PlanAndExecuteConfig{MaxStepRetries: 1}, agentContext, chat)
+	assert.Nil(t, end.Error)
+	assert.Equal(t, 6, chat.sends)
+	assert.True(t, slicesContainPrefix(agentContext.userTexts(), "Task task-1 failed (attempt 1 of 2): service unavailable"))
+
+	// once the retries are exhausted the plan fails as reported
+	agentContext = &planAgentContext{state: state.NewInMemoryState(), failCalls: 2}
+	chat = &scriptedChat{script: []llms.Part{
+		planText(planTask1), fetchCall("c1"), planText(task1Fail), fetchCall("c2"), planText(task1Fail),
+	}}
+	_, end = runPlan(t, &PlanAndExecuteConfig{MaxStepRetries: 1}, agentContext, chat)
+	require.Error(t, end.Error)
+	assert.Equal(t, "fetch failed", end.Error.Error())
+	assert.Equal(t, PlanState(PlanStateFailed), loadTestPlan(t, agentContext).State)
+}
+
+func TestPlanAndExecute_FailureWithoutRecovery(t *testing.T) {
+	agentContext := &planAgentContext{state: state.NewInMemoryState(), failCalls: 1}
+	chat := &scriptedChat{script: []llms.Part{planText(planTask1), fetchCall("c1"), planText(task1Fail)}}
+
+	_, end := runPlan(t, &PlanAndExecuteConfig{}, agentContext, chat)
+	require.Error(t, end.Error)
+	assert.Equal(t, "fetch failed", end.Error.Error())
+	assert.Equal(t, 3, chat.sends)
+}
+
+// resumableAgentContext is a plan agent context keeping its history in a memory
+type resumableAgentContext struct {
+	planAgentContext
+	memory memory.Memory
+}
+
+func newResumableAgentContext() *resumableAgentContext {
+	return &resumableAgentContext{
+		planAgentContext: planAgentContext{state: state.NewInMemoryState()},
+		memory:           memory.NewSimpleMemory(),
+	}
+}
+
+func (c *resumableAgentContext) GetMemory() memory.Memory { return c.memory }
+
+func (c *resumableAgentContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	for _, message := range messages {
+		if err := c.memory.Add(ctx, memory.NewChatMessageMemoryItem(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *resumableAgentContext) Generate(ctx context.Context, params *agent.GenerateContextParams) (*agent.GeneratedContext, error) {
+	_ = c.UpdateMemory(ctx, params.ToMessages()...)
+	items, err := c.memory.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &agent.GeneratedContext{Messages: memory.AsMessages(items)}, nil
+}
+
+// respond runs the agent on the input event until the end of its response
+func respond(t *testing.T, a agent.Agent, sessionId string, event *eventbus.Event) *agent.AgentResponseEnd {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	input, output, err := a.Run(&agent.RunContext{SessionId: sessionId, Context: ctx})
+	require.NoError(t, err)
+	input <- event
+	for {
+		select {
+		case event := <-output:
+			if event.Topic == agent.EventTypeAgentResponseEnd {
+				return agent.GetAgentResponseEndEventData(event)
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "agent did not end its response")
+		}
+	}
+}
+
+func TestPlanAndExecute_SnapshotAndRestore(t *testing.T) {
+	config := &PlanAndExecuteConfig{RequirePlanConfirmation: true}
+
+	// the plan is made, then waits for its confirmation
+	agentContext := newResumableAgentContext()
+	pattern, err := NewPlanExecutePattern(config)
+	require.NoError(t, err)
+	a, err := agent.NewGenericAgent(agentContext, pattern,
+		&scriptedChat{script: []llms.Part{planText(planTask1)}}, &llms.Model{}, nil)
+	require.NoError(t, err)
+	end := respond(t, a, "session-1", agent.NewUserRequestEvent(&agent.UserRequest{Message: "get the data"}))
+	require.Nil(t, end.Error)
+
+	snapshot, err := a.(agent.Snapshotter).Snapshot()
+	require.NoError(t, err)
+
+	// the restored agent continues the plan from a fresh memory and state
+	resumedContext := newResumableAgentContext()
+	pattern, err = NewPlanExecutePattern(config)
+	require.NoError(t, err)
+	chat := &scriptedChat{script: []llms.Part{fetchCall("c1"), planText(task1Done), planText(planDone)}}
+	resumed, err := agent.Restore(snapshot, resumedContext, pattern, chat, &llms.Model{}, nil)
+	require.NoError(t, err)
+
+	end = respond(t, resumed, "", agent.NewExternalActionResultEvent("confirmed"))
+	require.Nil(t, end.Error)
+	assert.Equal(t, llms.FinishReasonNormalEnd, end.FinishReason)
+	assert.Equal(t, "step:test-agent:session-1:2", end.TraceId)
+	assert.Equal(t, 3, chat.sends)
+	assert.Equal(t, 1, resumedContext.toolCalls)
+
+	plan := loadTestPlan(t, &resumedContext.planAgentContext)
+	require.Len(t, plan.Tasks, 1)
+	assert.Equal(t, TaskState(TaskStateSucceed), plan.Tasks[0].State)
+
+	// the history goes on from the one of the snapshot
+	items, err := resumedContext.memory.Retrieve(context.Background())
+	require.NoError(t, err)
+	messages := memory.AsMessages(items)
+	require.Greater(t, len(messages), 3)
+	assert.Equal(t, "get the data", messages[0].Parts[0].(*llms.TextPart).Text)
+	assert.Equal(t, planTask1, messages[1].Parts[0].(*llms.TextPart).Text)
+	assert.Equal(t, "confirmed", messages[2].Parts[0].(*llms.TextPart).Text)
+}
+
+func TestRestore_InvalidSnapshot(t *testing.T) {
+	pattern, err := NewPlanExecutePattern(nil)
+	require.NoError(t, err)
+
+	_, err = agent.Restore([]byte("not a snapshot"), newResumableAgentContext(), pattern,
+		&scriptedChat{}, &llms.Model{}, nil)
+	assert.True(t, commonerrors.IsCode(err, agent.ErrorCodeRestoreFailed))
+
+	// the history needs a memory to be restored into
+	_, err = agent.Restore([]byte(`{"sessionId": "s", "history": [{}]}`), &stubAgentContext{}, pattern,
+		&scriptedChat{}, &llms.Model{}, nil)
+	assert.True(t, commonerrors.IsCode(err, agent.ErrorCodeRestoreFailed))
+}
+
+func slicesContainPrefix(texts []string, prefix string) bool {
+	for _, text := range texts {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,10 +3,14 @@ package behavior_patterns
 import (
 	_ "embed"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/oopslink/agent-go/pkg/core/agent"
 	"github.com/oopslink/agent-go/pkg/core/knowledge"
+	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/journal"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
@@ -14,22 +18,87 @@ import (
 //go:embed prompts/rag.md
 var _ragPrompt string
 
+const (
+	// DefaultRAGMaxResults is the default number of items retrieved from each knowledge base
+	DefaultRAGMaxResults = 3
+	// DefaultRAGScoreThreshold is the default minimum score of the retrieved items
+	DefaultRAGScoreThreshold = 0.7
+	// DefaultRAGMultiQueryCount is the default number of paraphrases of the user request searched for
+	DefaultRAGMultiQueryCount = 3
+
+	ragMultiQueryPrompt = `Rephrase the question below in %d different ways, to search a knowledge base for the passages answering it.
+Keep the meaning of the question, vary the wording and the keywords.
+Reply with one rephrased question per line, nothing else.
+
+Question: %s`
+
+	ragHyDEPrompt = `Write a short passage answering the question below, as a document of a knowledge base would.
+Answer even if you are unsure of the facts, reply with the passage only.
+
+Question: %s`
+)
+
+var ragListMarkerPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// RAGConfig configures the retrieval of the RAG pattern
+type RAGConfig struct {
+	// KnowledgeBases are searched for the items relevant to the user request
+	KnowledgeBases []knowledge.KnowledgeBase
+	// MaxResults is the number of items retrieved from each knowledge base, 0 for DefaultRAGMaxResults
+	MaxResults int
+	// ScoreThreshold is the minimum score of the retrieved items, 0 for DefaultRAGScoreThreshold
+	ScoreThreshold float32
+	// Reranker reorders the items retrieved from all the knowledge bases before they are
+	// added to the prompt, nil to keep the retrieval order
+	Reranker knowledge.Reranker
+	// MultiQuery enables the query expansion: the model rephrases the user request and the knowledge
+	// bases are also searched for each paraphrase, the items found are merged by their ids
+	MultiQuery bool
+	// MultiQueryCount is the number of paraphrases of the user request, 0 for DefaultRAGMultiQueryCount
+	MultiQueryCount int
+	// MultiQueryChat rephrases the user request, preferably with a cheap model, nil for the chat of the step
+	MultiQueryChat llms.Chat
+	// HyDE enables the hypothetical document embeddings: the model drafts an answer to the user
+	// request and the knowledge bases are searched for the draft rather than for the request
+	HyDE bool
+	// HyDEChat drafts the answers, preferably with a cheap model, nil for the chat of the step
+	HyDEChat llms.Chat
+}
+
 var _ agent.BehaviorPattern = &ragPattern{}
 
 func NewRAGPattern() (agent.BehaviorPattern, error) {
-	return &ragPattern{
-		knowledgeBases: []knowledge.KnowledgeBase{},
-	}, nil
+	return NewRAGPatternWithConfig(&RAGConfig{
+		KnowledgeBases: []knowledge.KnowledgeBase{},
+	})
 }
 
 func NewRAGPatternWithKnowledgeBases(knowledgeBases []knowledge.KnowledgeBase) (agent.BehaviorPattern, error) {
+	return NewRAGPatternWithConfig(&RAGConfig{
+		KnowledgeBases: knowledgeBases,
+	})
+}
+
+func NewRAGPatternWithConfig(config *RAGConfig) (agent.BehaviorPattern, error) {
+	if config == nil {
+		config = &RAGConfig{}
+	}
+	if config.MaxResults <= 0 {
+		config.MaxResults = DefaultRAGMaxResults
+	}
+	if config.ScoreThreshold <= 0 {
+		config.ScoreThreshold = DefaultRAGScoreThreshold
+	}
+	if config.MultiQueryCount <= 0 {
+		config.MultiQueryCount = DefaultRAGMultiQueryCount
+	}
 	return &ragPattern{
-		knowledgeBases: knowledgeBases,
+		config: config,
 	}, nil
 }
 
 type ragPattern struct {
-	knowledgeBases []knowledge.KnowledgeBase
+	config *RAGConfig
 }
 
 func (s *ragPattern) SystemInstruction(header string) string {
@@ -63,54 +132,187 @@ func (s *ragPattern) nextStep(ctx *agent.StepContext) (endResponse *agent.AgentR
 
 func (s *ragPattern) rag(ctx *agent.StepContext, stepId string) error {
 	// Retrieve relevant knowledge based on user request
-	knowledgeItems, err := s.retrieveKnowledge(ctx, stepId)
+	docs, err := s.retrieveKnowledge(ctx, stepId)
 	if err != nil {
 		journal.Warning("step", stepId,
 			fmt.Sprintf("failed to retrieve knowledge: %v", err))
 		return err
 	}
 
-	if len(knowledgeItems) == 0 {
+	if len(docs) == 0 {
 		return nil
 	}
 
-	knowledgeMessage := llms.NewUserMessage(s.makeKnowledgeText(knowledgeItems))
+	sendEvent(stepId, "agent sources",
+		ctx.OutputChan, agent.NewAgentSourcesEvent(stepId, s.makeSources(docs)))
+
+	knowledgeMessage := llms.NewUserMessage(s.makeKnowledgeText(docs))
 	return ctx.AgentContext.UpdateMemory(ctx.Context, knowledgeMessage)
 }
 
-func (s *ragPattern) retrieveKnowledge(ctx *agent.StepContext, stepId string) ([]knowledge.KnowledgeItem, error) {
+func (s *ragPattern) retrieveKnowledge(ctx *agent.StepContext, stepId string) ([]*knowledge.ScoredDocument, error) {
 	if ctx.UserRequest == nil || ctx.UserRequest.Message == "" {
-		return []knowledge.KnowledgeItem{}, nil
+		return []*knowledge.ScoredDocument{}, nil
 	}
 
-	if len(s.knowledgeBases) == 0 {
+	if len(s.config.KnowledgeBases) == 0 {
 		journal.Info("step", stepId, "no knowledge bases configured")
-		return []knowledge.KnowledgeItem{}, nil
+		return []*knowledge.ScoredDocument{}, nil
+	}
+
+	queries := []string{ctx.UserRequest.Message}
+	if s.config.HyDE {
+		// the draft is searched for rather than the request, an answer is embedded closer to
+		// the passages answering the request than the request itself
+		queries[0] = s.hypotheticalAnswer(ctx, stepId)
+	}
+	if s.config.MultiQuery {
+		queries = append(queries, s.rephraseQuery(ctx, stepId)...)
 	}
 
 	var allItems []knowledge.KnowledgeItem
-	for i, kb := range s.knowledgeBases {
-		items, err := kb.Search(ctx.Context, ctx.UserRequest.Message,
-			knowledge.WithMaxResults(3),
-			knowledge.WithScoreThreshold(0.7))
+	for _, query := range queries {
+		for i, kb := range s.config.KnowledgeBases {
+			items, err := kb.Search(ctx.Context, query,
+				knowledge.WithMaxResults(s.config.MaxResults),
+				knowledge.WithScoreThreshold(s.config.ScoreThreshold))
+			if err != nil {
+				journal.Warning("step", stepId,
+					fmt.Sprintf("failed to search knowledge base %d: %v", i, err))
+				continue
+			}
+			allItems = append(allItems, items...)
+		}
+	}
+
+	journal.Info("step", stepId, fmt.Sprintf("retrieved %d knowledge items from %d knowledge bases for %d queries",
+		len(allItems), len(s.config.KnowledgeBases), len(queries)))
+
+	docs := knowledge.ToScoredDocuments(allItems)
+	if len(queries) > 1 {
+		docs = mergeScoredDocuments(docs)
+	}
+	if s.config.Reranker == nil || len(docs) < 2 {
+		return docs, nil
+	}
+	reranked, err := s.config.Reranker.Rerank(ctx.Context, ctx.UserRequest.Message, docs)
+	if err != nil {
+		// the retrieval order is still relevant
+		journal.Warning("step", stepId,
+			fmt.Sprintf("failed to rerank knowledge items: %v", err))
+		return docs, nil
+	}
+	return reranked, nil
+}
+
+// rephraseQuery asks the model for the paraphrases of the user request, none when it fails
+func (s *ragPattern) rephraseQuery(ctx *agent.StepContext, stepId string) []string {
+	answer, err := s.complete(ctx, s.config.MultiQueryChat,
+		fmt.Sprintf(ragMultiQueryPrompt, s.config.MultiQueryCount, ctx.UserRequest.Message))
+	if err != nil {
+		journal.Warning("step", stepId, fmt.Sprintf("failed to rephrase the user request: %v", err))
+		return nil
+	}
+
+	var paraphrases []string
+	for _, line := range strings.Split(answer, "\n") {
+		paraphrase := strings.TrimSpace(ragListMarkerPattern.ReplaceAllString(line, ""))
+		if paraphrase == "" || paraphrase == ctx.UserRequest.Message || slices.Contains(paraphrases, paraphrase) {
+			continue
+		}
+		paraphrases = append(paraphrases, paraphrase)
+		if len(paraphrases) == s.config.MultiQueryCount {
+			break
+		}
+	}
+	return paraphrases
+}
+
+// hypotheticalAnswer asks the model to draft an answer to the user request, the request itself
+// when it fails
+func (s *ragPattern) hypotheticalAnswer(ctx *agent.StepContext, stepId string) string {
+	answer, err := s.complete(ctx, s.config.HyDEChat, fmt.Sprintf(ragHyDEPrompt, ctx.UserRequest.Message))
+	if err != nil {
+		journal.Warning("step", stepId, fmt.Sprintf("failed to draft a hypothetical answer: %v", err))
+		return ctx.UserRequest.Message
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return ctx.UserRequest.Message
+	}
+	return answer
+}
+
+// complete returns the text answered by the chat, or the chat of the step when nil, to the prompt
+func (s *ragPattern) complete(ctx *agent.StepContext, chat llms.Chat, prompt string) (string, error) {
+	if chat == nil {
+		chat = ctx.Session
+	}
+	if chat == nil {
+		return "", fmt.Errorf("no chat to complete the prompt")
+	}
+
+	responses, err := chat.Send(ctx.Context, []*llms.Message{llms.NewUserMessage(prompt)})
+	if err != nil {
+		return "", err
+	}
+	var answer strings.Builder
+	for response, err := range responses {
 		if err != nil {
-			journal.Warning("step", stepId,
-				fmt.Sprintf("failed to search knowledge base %d: %v", i, err))
+			return "", err
+		}
+		for _, part := range response.Parts {
+			if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning {
+				answer.WriteString(textPart.Text)
+			}
+		}
+	}
+	return answer.String(), nil
+}
+
+// mergeScoredDocuments keeps the best scored document of each id, from the best to the worst scored
+func mergeScoredDocuments(docs []*knowledge.ScoredDocument) []*knowledge.ScoredDocument {
+	merged := make([]*knowledge.ScoredDocument, 0, len(docs))
+	indexes := make(map[document.DocumentId]int, len(docs))
+	for _, doc := range docs {
+		if idx, ok := indexes[doc.Id]; ok {
+			if doc.Score > merged[idx].Score {
+				merged[idx] = doc
+			}
 			continue
 		}
-		allItems = append(allItems, items...)
+		indexes[doc.Id] = len(merged)
+		merged = append(merged, doc)
 	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	return merged
+}
 
-	journal.Info("step", stepId, fmt.Sprintf("retrieved %d knowledge items from %d knowledge bases", len(allItems), len(s.knowledgeBases)))
-	return allItems, nil
+func (s *ragPattern) makeSources(docs []*knowledge.ScoredDocument) []agent.Source {
+	sources := make([]agent.Source, 0, len(docs))
+	for _, doc := range docs {
+		metadata := make(map[string]any, len(doc.Metadata))
+		for key, value := range doc.Metadata {
+			if key != knowledge.MetadataKeyScore {
+				metadata[key] = value
+			}
+		}
+		sources = append(sources, agent.Source{
+			DocumentId: doc.Id,
+			Name:       doc.Name,
+			Score:      doc.Score,
+			Metadata:   metadata,
+		})
+	}
+	return sources
 }
 
-func (s *ragPattern) makeKnowledgeText(knowledgeItems []knowledge.KnowledgeItem) string {
+func (s *ragPattern) makeKnowledgeText(docs []*knowledge.ScoredDocument) string {
 	var prompt strings.Builder
-	if len(knowledgeItems) > 0 {
+	if len(docs) > 0 {
 		prompt.WriteString("Retrieved Knowledge:\n")
-		for i, item := range knowledgeItems {
-			doc := item.ToDocument()
+		for i, doc := range docs {
 			prompt.WriteString(fmt.Sprintf("\n--- Knowledge Item %d ---\n", i+1))
 			prompt.WriteString(fmt.Sprintf("ID: %s\n", doc.Id))
 			prompt.WriteString(fmt.Sprintf("Content: %s\n", doc.Content))
@@ -1,11 +1,21 @@
 package behavior_patterns
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/oopslink/agent-go/pkg/core/agent"
 	"github.com/oopslink/agent-go/pkg/core/knowledge"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/vectordb/inmem"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRAGPattern(t *testing.T) {
@@ -28,3 +38,211 @@ func TestNewRAGPatternWithKnowledgeBases(t *testing.T) {
 	assert.NotNil(t, pattern)
 	assert.Implements(t, (*agent.BehaviorPattern)(nil), pattern)
 }
+
+// keywordEmbedder embeds texts as counts of a fixed vocabulary
+type keywordEmbedder struct {
+	vocabulary []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i, text := range texts {
+		vector := make(embedder.FloatVector, len(e.vocabulary))
+		for j, word := range e.vocabulary {
+			vector[j] = float64(strings.Count(text, word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// reversingReranker reverses the retrieval order
+type reversingReranker struct {
+	queries []string
+}
+
+func (r *reversingReranker) Rerank(ctx context.Context, query string, docs []*knowledge.ScoredDocument) ([]*knowledge.ScoredDocument, error) {
+	r.queries = append(r.queries, query)
+	reversed := slices.Clone(docs)
+	slices.Reverse(reversed)
+	return reversed, nil
+}
+
+// memoryAgentContext records the messages added to the memory
+type memoryAgentContext struct {
+	stubAgentContext
+	messages []*llms.Message
+}
+
+func (c *memoryAgentContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	c.messages = append(c.messages, messages...)
+	return nil
+}
+
+func newInMemoryKnowledgeBase(t *testing.T, contents ...string) knowledge.KnowledgeBase {
+	storage := knowledge.NewVectorDBStorage("rag", inmem.New(),
+		&keywordEmbedder{vocabulary: []string{"cat", "dog", "fish"}})
+	kb := knowledge.NewKnowledgeBase(storage, knowledge.NewBaseKnowledgeItemFactory(), nil)
+	for i, content := range contents {
+		err := kb.AddItem(context.Background(), knowledge.NewKnowledgeItem(&document.Document{
+			Id:      document.DocumentId(fmt.Sprintf("doc-%d", i+1)),
+			Content: content,
+		}))
+		require.NoError(t, err)
+	}
+	return kb
+}
+
+// retrievedIds returns the ids of the knowledge items of the message, in order
+func retrievedIds(message *llms.Message) []string {
+	var ids []string
+	for _, line := range strings.Split(message.Parts[0].(*llms.TextPart).Text, "\n") {
+		if id, ok := strings.CutPrefix(line, "ID: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestRAGPattern_Reranker(t *testing.T) {
+	kb := newInMemoryKnowledgeBase(t, "cat and dog", "cat cat", "cat dog fish", "fish only")
+
+	retrieve := func(reranker knowledge.Reranker) []string {
+		pattern, err := NewRAGPatternWithConfig(&RAGConfig{
+			KnowledgeBases: []knowledge.KnowledgeBase{kb},
+			ScoreThreshold: 0.1,
+			Reranker:       reranker,
+		})
+		require.NoError(t, err)
+
+		agentContext := &memoryAgentContext{}
+		err = pattern.(*ragPattern).rag(&agent.StepContext{
+			Context:      context.Background(),
+			AgentContext: agentContext,
+			UserRequest:  &agent.UserRequest{Message: "cat"},
+			OutputChan:   make(chan *eventbus.Event, 1),
+		}, "step")
+		require.NoError(t, err)
+		require.Len(t, agentContext.messages, 1)
+		return retrievedIds(agentContext.messages[0])
+	}
+
+	// by vector similarity
+	assert.Equal(t, []string{"doc-2", "doc-1", "doc-3"}, retrieve(nil))
+
+	reranker := &reversingReranker{}
+	assert.Equal(t, []string{"doc-3", "doc-1", "doc-2"}, retrieve(reranker))
+	assert.Equal(t, []string{"cat"}, reranker.queries)
+}
+
+// paraphrasingChat answers with fixed paraphrases, recording the requests
+type paraphrasingChat struct {
+	answer   string
+	requests []string
+}
+
+func (c *paraphrasingChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	c.requests = append(c.requests, messages[0].Parts[0].(*llms.TextPart).Text)
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		yield(&llms.ChatResponse{
+			Message: llms.Message{Parts: []llms.Part{&llms.TextPart{Text: c.answer}}},
+		}, nil)
+	}, nil
+}
+
+func TestRAGPattern_MultiQuery(t *testing.T) {
+	kb := newInMemoryKnowledgeBase(t, "cat and dog", "fish only", "dog dog")
+	chat := &paraphrasingChat{answer: "1. a dog\n2. some fish\n\n3. cat\n4. a dog"}
+
+	retrieve := func(multiQuery bool) []string {
+		pattern, err := NewRAGPatternWithConfig(&RAGConfig{
+			KnowledgeBases: []knowledge.KnowledgeBase{kb},
+			ScoreThreshold: 0.1,
+			MultiQuery:     multiQuery,
+		})
+		require.NoError(t, err)
+
+		agentContext := &memoryAgentContext{}
+		err = pattern.(*ragPattern).rag(&agent.StepContext{
+			Context:      context.Background(),
+			AgentContext: agentContext,
+			UserRequest:  &agent.UserRequest{Message: "cat"},
+			Session:      chat,
+			OutputChan:   make(chan *eventbus.Event, 1),
+		}, "step")
+		require.NoError(t, err)
+		require.Len(t, agentContext.messages, 1)
+		return retrievedIds(agentContext.messages[0])
+	}
+
+	// the request only
+	assert.Equal(t, []string{"doc-1"}, retrieve(false))
+	assert.Empty(t, chat.requests)
+
+	// the request and its paraphrases, the items found several times are merged
+	assert.Equal(t, []string{"doc-3", "doc-2", "doc-1"}, retrieve(true))
+	require.Len(t, chat.requests, 1)
+	assert.Contains(t, chat.requests[0], fmt.Sprintf("in %d different ways", DefaultRAGMultiQueryCount))
+	assert.Contains(t, chat.requests[0], "Question: cat")
+}
+
+func TestRAGPattern_RephraseQuery(t *testing.T) {
+	chat := &paraphrasingChat{answer: "- a dog\n* some fish\n1) a bird\n2. a cat"}
+	pattern, err := NewRAGPatternWithConfig(&RAGConfig{MultiQuery: true, MultiQueryCount: 2, MultiQueryChat: chat})
+	require.NoError(t, err)
+
+	paraphrases := pattern.(*ragPattern).rephraseQuery(&agent.StepContext{
+		Context:     context.Background(),
+		UserRequest: &agent.UserRequest{Message: "cat"},
+	}, "step")
+	assert.Equal(t, []string{"a dog", "some fish"}, paraphrases)
+}
+
+// recordingEmbedder records the texts it embeds
+type recordingEmbedder struct {
+	keywordEmbedder
+	texts []string
+}
+
+func (e *recordingEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	e.texts = append(e.texts, texts...)
+	return e.keywordEmbedder.Embed(ctx, texts)
+}
+
+func TestRAGPattern_HyDE(t *testing.T) {
+	emb := &recordingEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat", "dog", "fish"}}}
+	storage := knowledge.NewVectorDBStorage("rag", inmem.New(), emb)
+	kb := knowledge.NewKnowledgeBase(storage, knowledge.NewBaseKnowledgeItemFactory(), nil)
+	for i, content := range []string{"cat and dog", "fish only"} {
+		require.NoError(t, kb.AddItem(context.Background(), knowledge.NewKnowledgeItem(&document.Document{
+			Id:      document.DocumentId(fmt.Sprintf("doc-%d", i+1)),
+			Content: content,
+		})))
+	}
+	emb.texts = nil
+
+	chat := &paraphrasingChat{answer: "The pet fish lives in a bowl."}
+	pattern, err := NewRAGPatternWithConfig(&RAGConfig{
+		KnowledgeBases: []knowledge.KnowledgeBase{kb},
+		ScoreThreshold: 0.1,
+		HyDE:           true,
+		HyDEChat:       chat,
+	})
+	require.NoError(t, err)
+
+	agentContext := &memoryAgentContext{}
+	err = pattern.(*ragPattern).rag(&agent.StepContext{
+		Context:      context.Background(),
+		AgentContext: agentContext,
+		UserRequest:  &agent.UserRequest{Message: "Where does my pet live?"},
+		OutputChan:   make(chan *eventbus.Event, 1),
+	}, "step")
+	require.NoError(t, err)
+
+	// the draft is embedded and searched for, not the question
+	require.Len(t, chat.requests, 1)
+	assert.Contains(t, chat.requests[0], "Question: Where does my pet live?")
+	assert.Equal(t, []string{"The pet fish lives in a bowl."}, emb.texts)
+	require.Len(t, agentContext.messages, 1)
+	assert.Equal(t, []string{"doc-2"}, retrievedIds(agentContext.messages[0]))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/oopslink/agent-go/pkg/core/memory"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
 
@@ -45,3 +46,10 @@ type Context interface {
 	CanAutoCall(toolCall *llms.ToolCall) bool
 	ValidateToolCall(call *llms.ToolCall) error
 }
+
+// MemoryContext is a Context keeping the conversation history in a memory, the history is saved
+// into the snapshots of the agent, see Snapshotter
+type MemoryContext interface {
+	Context
+	GetMemory() memory.Memory
+}
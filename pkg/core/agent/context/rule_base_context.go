@@ -15,7 +15,7 @@ import (
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
 
-var _ agent.Context = &ruleBaseContext{}
+var _ agent.MemoryContext = &ruleBaseContext{}
 
 func NewRuleBaseContext(
 	agentId, systemPrompt string,
@@ -94,6 +94,10 @@ func (r *ruleBaseContext) GetState() agent.AgentState {
 	return r.state
 }
 
+func (r *ruleBaseContext) GetMemory() memory.Memory {
+	return r.memory
+}
+
 func (r *ruleBaseContext) GetModel() *llms.Model {
 	return r.model
 }
@@ -138,7 +142,7 @@ func (r *ruleBaseContext) ValidateToolCall(toolCall *llms.ToolCall) error {
 		return errors.Errorf(agent.ErrorCodeInvalidToolCall,
 			"invalid tool, tool [%s] is not exists", toolCall.Name)
 	}
-	return nil
+	return r.toolRegistry.ValidateToolCall(toolCall)
 }
 
 func (r *ruleBaseContext) CanAutoCall(toolCall *llms.ToolCall) bool {
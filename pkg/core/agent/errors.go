@@ -33,4 +33,19 @@ var (
 		Name:           "LoadPlanFailed",
 		DefaultMessage: "Failed to load plan",
 	}
+	ErrorCodeEmptyResponses = errors.ErrorCode{
+		Code:           20006,
+		Name:           "EmptyResponses",
+		DefaultMessage: "Model returned empty responses",
+	}
+	ErrorCodeSnapshotFailed = errors.ErrorCode{
+		Code:           20007,
+		Name:           "SnapshotFailed",
+		DefaultMessage: "Failed to snapshot the agent",
+	}
+	ErrorCodeRestoreFailed = errors.ErrorCode{
+		Code:           20008,
+		Name:           "RestoreFailed",
+		DefaultMessage: "Failed to restore the agent from its snapshot",
+	}
 )
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/journal"
+)
+
+// EventStore records the events emitted by an agent, keyed by session id, see WithEventStore
+type EventStore interface {
+	// AppendEvent records an event of the session
+	AppendEvent(sessionId string, event *eventbus.Event) error
+	// LoadEvents returns the events of the session in the order they were emitted
+	LoadEvents(sessionId string) ([]*eventbus.Event, error)
+}
+
+var _ EventStore = &InMemoryEventStore{}
+
+// InMemoryEventStore keeps the events in memory
+type InMemoryEventStore struct {
+	mu     sync.RWMutex
+	events map[string][]*eventbus.Event
+}
+
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{
+		events: make(map[string][]*eventbus.Event),
+	}
+}
+
+func (s *InMemoryEventStore) AppendEvent(sessionId string, event *eventbus.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[sessionId] = append(s.events[sessionId], event)
+	return nil
+}
+
+func (s *InMemoryEventStore) LoadEvents(sessionId string) ([]*eventbus.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*eventbus.Event(nil), s.events[sessionId]...), nil
+}
+
+var _ EventStore = &FileEventStore{}
+
+// FileEventStore appends the events of each session as JSON lines to a file of the directory.
+// The data of loaded events is decoded as generic JSON values, e.g. map[string]any.
+type FileEventStore struct {
+	mu      sync.Mutex
+	dataDir string
+}
+
+func NewFileEventStore(dataDir string) (*FileEventStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileEventStore{
+		dataDir: dataDir,
+	}, nil
+}
+
+func (s *FileEventStore) getFilePath(sessionId string) string {
+	// Replace unsafe filename characters
+	safeId := strings.ReplaceAll(sessionId, "/", "_")
+	safeId = strings.ReplaceAll(safeId, "\\", "_")
+	safeId = strings.ReplaceAll(safeId, ":", "_")
+	return filepath.Join(s.dataDir, safeId+".jsonl")
+}
+
+func (s *FileEventStore) AppendEvent(sessionId string, event *eventbus.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.getFilePath(sessionId), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (s *FileEventStore) LoadEvents(sessionId string) ([]*eventbus.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.getFilePath(sessionId))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []*eventbus.Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event eventbus.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("invalid event at line %d: %w", lineNumber, err)
+		}
+		events = append(events, &event)
+	}
+	return events, scanner.Err()
+}
+
+// eventRecorder tees the events of a session into an event store. Events are queued
+// and appended by a background goroutine, so a slow store never blocks the agent.
+type eventRecorder struct {
+	store     EventStore
+	sessionId string
+	agentId   string
+
+	mu      sync.Mutex
+	queue   []*eventbus.Event
+	closed  bool
+	pending chan struct{}
+	done    chan struct{}
+}
+
+func newEventRecorder(store EventStore, sessionId, agentId string) *eventRecorder {
+	r := &eventRecorder{
+		store:     store,
+		sessionId: sessionId,
+		agentId:   agentId,
+		pending:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// tee records the events read from the input and forwards them with send, until the input is closed
+func (r *eventRecorder) tee(input <-chan *eventbus.Event, send func(*eventbus.Event)) {
+	defer r.close()
+	for event := range input {
+		r.record(event)
+		send(event)
+	}
+}
+
+func (r *eventRecorder) record(event *eventbus.Event) {
+	r.mu.Lock()
+	r.queue = append(r.queue, event)
+	r.mu.Unlock()
+
+	select {
+	case r.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (r *eventRecorder) close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	select {
+	case r.pending <- struct{}{}:
+	default:
+	}
+	<-r.done
+}
+
+func (r *eventRecorder) run() {
+	defer close(r.done)
+	for range r.pending {
+		r.mu.Lock()
+		events, closed := r.queue, r.closed
+		r.queue = nil
+		r.mu.Unlock()
+
+		for _, event := range events {
+			if err := r.store.AppendEvent(r.sessionId, event); err != nil {
+				journal.Warning("agent", r.agentId,
+					"failed to record event", "session", r.sessionId, "event", event.ID, "err", err.Error())
+			}
+		}
+		if closed {
+			return
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubContext is an agent context without memory nor tools
+type stubContext struct{}
+
+func (c *stubContext) AgentId() string       { return "test-agent" }
+func (c *stubContext) SystemPrompt() string  { return "" }
+func (c *stubContext) GetModel() *llms.Model { return nil }
+func (c *stubContext) GetState() AgentState  { return nil }
+
+func (c *stubContext) Generate(ctx context.Context, params *GenerateContextParams) (*GeneratedContext, error) {
+	return &GeneratedContext{Messages: params.ToMessages()}, nil
+}
+
+func (c *stubContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error { return nil }
+
+func (c *stubContext) CallTool(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+	return nil, nil
+}
+
+func (c *stubContext) CanAutoCall(toolCall *llms.ToolCall) bool   { return false }
+func (c *stubContext) ValidateToolCall(call *llms.ToolCall) error { return nil }
+
+// blockingEventStore blocks every append until released
+type blockingEventStore struct {
+	*InMemoryEventStore
+	release chan struct{}
+}
+
+func (s *blockingEventStore) AppendEvent(sessionId string, event *eventbus.Event) error {
+	<-s.release
+	return s.InMemoryEventStore.AppendEvent(sessionId, event)
+}
+
+// newEchoAgent creates an agent answering each user request with start, message and end events
+func newEchoAgent(t *testing.T, opts ...AgentOption) Agent {
+	ctrl := gomock.NewController(t)
+
+	provider := llms.NewMockChatProvider(ctrl)
+	provider.EXPECT().NewChat(gomock.Any(), gomock.Any()).Return(llms.NewMockChat(ctrl), nil)
+
+	behavior := NewMockBehaviorPattern(ctrl)
+	behavior.EXPECT().NextStep(gomock.Any()).DoAndReturn(func(ctx *StepContext) error {
+		ctx.OutputChan <- NewAgentResponseStartEvent(ctx.StepId())
+		message := llms.NewAssistantMessage("message", llms.ModelId{}, "echo: "+ctx.UserRequest.Message)
+		ctx.OutputChan <- NewAgentMessageEvent(ctx.StepId(), message)
+		ctx.OutputChan <- NewAgentResponseEndEvent(ctx.StepId(), &AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+		return nil
+	}).AnyTimes()
+
+	a, err := NewGenericAgent(&stubContext{}, behavior, provider, &llms.Model{}, nil, opts...)
+	require.NoError(t, err)
+	return a
+}
+
+// runRequests sends the requests one after the other, returning the events emitted
+func runRequests(t *testing.T, a Agent, sessionId string, requests ...string) []*eventbus.Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	input, output, err := a.Run(&RunContext{SessionId: sessionId, Context: ctx})
+	require.NoError(t, err)
+
+	var events []*eventbus.Event
+	for _, request := range requests {
+		input <- NewUserRequestEvent(&UserRequest{Message: request})
+		for {
+			select {
+			case event := <-output:
+				events = append(events, event)
+				if event.Topic != EventTypeAgentResponseEnd {
+					continue
+				}
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "agent did not respond")
+			}
+			break
+		}
+	}
+	return events
+}
+
+func eventIds(events []*eventbus.Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestWithEventStore_PersistsEventsInOrder(t *testing.T) {
+	store := NewInMemoryEventStore()
+	a := newEchoAgent(t, WithEventStore(store))
+
+	events := runRequests(t, a, "session-1", "hello", "world")
+	require.Len(t, events, 6)
+
+	assert.Eventually(t, func() bool {
+		stored, err := store.LoadEvents("session-1")
+		return err == nil && len(stored) == len(events)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stored, err := store.LoadEvents("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, eventIds(events), eventIds(stored))
+	assert.Equal(t, "echo: world", GetAgentMessageEventData(stored[4]).Message.Parts[0].(*llms.TextPart).Text)
+
+	// other sessions are kept apart
+	stored, err = store.LoadEvents("session-2")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestWithEventStore_DoesNotBlockAgent(t *testing.T) {
+	store := &blockingEventStore{InMemoryEventStore: NewInMemoryEventStore(), release: make(chan struct{})}
+	a := newEchoAgent(t, WithEventStore(store))
+
+	// the agent answers while the store is stuck
+	events := runRequests(t, a, "session-1", "hello", "world")
+	require.Len(t, events, 6)
+
+	close(store.release)
+	assert.Eventually(t, func() bool {
+		stored, err := store.LoadEvents("session-1")
+		return err == nil && len(stored) == len(events)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestFileEventStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileEventStore(dir)
+	require.NoError(t, err)
+	a := newEchoAgent(t, WithEventStore(store))
+
+	events := runRequests(t, a, "session:1", "hello")
+	require.Len(t, events, 3)
+
+	assert.Eventually(t, func() bool {
+		stored, err := store.LoadEvents("session:1")
+		return err == nil && len(stored) == len(events)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a new store on the same directory loads the complete sequence
+	store, err = NewFileEventStore(dir)
+	require.NoError(t, err)
+	stored, err := store.LoadEvents("session:1")
+	require.NoError(t, err)
+	assert.Equal(t, eventIds(events), eventIds(stored))
+	assert.Equal(t, []string{EventTypeAgentResponseStart, EventTypeAgentMessage, EventTypeAgentResponseEnd},
+		[]string{stored[0].Topic, stored[1].Topic, stored[2].Topic})
+	assert.Equal(t, events[0].Timestamp.UnixNano(), stored[0].Timestamp.UnixNano())
+
+	stored, err = store.LoadEvents("unknown")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
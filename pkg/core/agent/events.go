@@ -1,6 +1,10 @@
 package agent
 
 import (
+	"context"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/eventbus"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
@@ -12,6 +16,8 @@ const (
 	EventTypeExternalActionResult = "agent:external_action_result"
 	EventTypeAgentResponseStart   = "agent:agent_response_start"
 	EventTypeAgentResponseEnd     = "agent:agent_response_end"
+	EventTypeAgentUsage           = "agent:agent_usage"
+	EventTypeAgentSources         = "agent:agent_sources"
 )
 
 func NewUserRequestEvent(userRequest *UserRequest) *eventbus.Event {
@@ -111,10 +117,44 @@ func NewAgentResponseEndEvent(traceId string, end *AgentResponseEnd) *eventbus.E
 	return eventbus.NewEvent(EventTypeAgentResponseEnd, end)
 }
 
+// NewCanceledResponseEnd returns the end of a response aborted because the context of the run is done
+func NewCanceledResponseEnd(ctx context.Context) *AgentResponseEnd {
+	return &AgentResponseEnd{
+		Abort:        true,
+		Error:        errors.Errorf(ErrorCodeChatSessionAbort, "canceled: %s", ctx.Err()),
+		FinishReason: llms.FinishReasonCanceled,
+	}
+}
+
 func GetAgentResponseEndEventData(event *eventbus.Event) *AgentResponseEnd {
 	return event.Data.(*AgentResponseEnd)
 }
 
+func NewAgentUsageEvent(traceId string, modelId llms.ModelId, usage llms.UsageMetadata) *eventbus.Event {
+	return eventbus.NewEvent(EventTypeAgentUsage,
+		&AgentUsage{
+			TraceId: traceId,
+			ModelId: modelId,
+			Usage:   usage,
+		})
+}
+
+func GetAgentUsageEventData(event *eventbus.Event) *AgentUsage {
+	return event.Data.(*AgentUsage)
+}
+
+func NewAgentSourcesEvent(traceId string, sources []Source) *eventbus.Event {
+	return eventbus.NewEvent(EventTypeAgentSources,
+		&AgentSources{
+			TraceId: traceId,
+			Sources: sources,
+		})
+}
+
+func GetAgentSourcesEventData(event *eventbus.Event) *AgentSources {
+	return event.Data.(*AgentSources)
+}
+
 type UserRequest struct {
 	Message string
 	Options []llms.ChatOption
@@ -135,6 +175,27 @@ type AgentMessage struct {
 	Message *llms.Message
 }
 
+// AgentUsage is the usage of the model answering a request of the agent
+type AgentUsage struct {
+	TraceId string
+	ModelId llms.ModelId
+	Usage   llms.UsageMetadata
+}
+
+// AgentSources are the knowledge the agent answers a request from, e.g. to cite them
+type AgentSources struct {
+	TraceId string
+	Sources []Source
+}
+
+// Source is a document the agent answers from
+type Source struct {
+	DocumentId document.DocumentId
+	Name       string
+	Score      float32        // Relevance of the document to the request
+	Metadata   map[string]any // Metadata of the document, e.g. its URL or page
+}
+
 type AgentResponseStart struct {
 	TraceId string
 }
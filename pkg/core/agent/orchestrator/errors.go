@@ -0,0 +1,21 @@
+package orchestrator
+
+import "github.com/oopslink/agent-go/pkg/commons/errors"
+
+var (
+	ErrorCodeInvalidSupervisor = errors.ErrorCode{
+		Code:           20400,
+		Name:           "InvalidSupervisor",
+		DefaultMessage: "Invalid supervisor configuration",
+	}
+	ErrorCodeDelegationFailed = errors.ErrorCode{
+		Code:           20401,
+		Name:           "DelegationFailed",
+		DefaultMessage: "Failed to delegate the task to a member",
+	}
+	ErrorCodeTooManyDelegations = errors.ErrorCode{
+		Code:           20402,
+		Name:           "TooManyDelegations",
+		DefaultMessage: "Supervisor did not answer within the delegations allowed",
+	}
+)
@@ -0,0 +1,460 @@
+// Package orchestrator composes specialized agents, e.g. a researcher and a writer, under a supervisor.
+// The supervisor is an agent.Agent itself: it is run and talked to through the same events as the
+// agents it supervises, so the existing handlers work with it.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/journal"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// DefaultMaxDelegations is the default number of delegation rounds of the router per request
+	DefaultMaxDelegations = 5
+
+	defaultRouterSystemPrompt = `You are a supervisor coordinating a team of specialized agents.
+Delegate the tasks, or parts of them, to the agents best suited for them by calling the tool named after
+the agent, with a complete description of the task: the agents do not see the conversation.
+Once the agents gave you what you need, answer the user yourself.`
+)
+
+var memberNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Mode tells how the supervisor delegates the requests to its members
+type Mode int
+
+const (
+	// ModeRouter lets the model of the supervisor choose the members to delegate to, as tool calls,
+	// and answer once it has their answers
+	ModeRouter Mode = iota
+	// ModeSequential hands the request off to every member in order, each member gets the answer of
+	// the previous one; the answer of the last member is the answer of the supervisor
+	ModeSequential
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRouter:
+		return "router"
+	case ModeSequential:
+		return "sequential"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// Member is a named agent of the supervisor
+type Member struct {
+	// Name of the member, the name of its tool for the router: letters, digits, '_' and '-' only
+	Name string
+	// Description tells the router which tasks to delegate to the member
+	Description string
+	Agent       agent.Agent
+}
+
+// SupervisorOption configures the supervisor
+type SupervisorOption func(*Supervisor)
+
+// WithSystemPrompt sets the system prompt of the router, the members are listed after it
+func WithSystemPrompt(systemPrompt string) SupervisorOption {
+	return func(s *Supervisor) {
+		s.systemPrompt = systemPrompt
+	}
+}
+
+// WithChatOptions sets the options of the completions of the router
+func WithChatOptions(opts ...llms.ChatOption) SupervisorOption {
+	return func(s *Supervisor) {
+		s.chatOptions = append(s.chatOptions, opts...)
+	}
+}
+
+// WithMaxDelegations limits the delegation rounds of the router per request, n <= 0 for DefaultMaxDelegations
+func WithMaxDelegations(n int) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxDelegations = n
+	}
+}
+
+// WithMemberMaxTurns limits the number of responses a member may end before its answer,
+// n <= 0 for agent.DefaultSubAgentMaxTurns
+func WithMemberMaxTurns(n int) SupervisorOption {
+	return func(s *Supervisor) {
+		s.memberMaxTurns = n
+	}
+}
+
+// WithMemberTimeout limits the time a member may take to answer, 0 for no limit
+func WithMemberTimeout(timeout time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.memberTimeout = timeout
+	}
+}
+
+// NewRouterSupervisor creates a supervisor whose model routes the requests to the members, see ModeRouter
+func NewRouterSupervisor(llmProvider llms.ChatProvider, model *llms.Model,
+	members []*Member, opts ...SupervisorOption) (*Supervisor, error) {
+	if llmProvider == nil || model == nil {
+		return nil, errors.Errorf(ErrorCodeInvalidSupervisor, "the router needs a chat provider and a model")
+	}
+	return newSupervisor(ModeRouter, llmProvider, model, members, opts...)
+}
+
+// NewSequentialSupervisor creates a supervisor handing the requests off to the members in order, see ModeSequential
+func NewSequentialSupervisor(members []*Member, opts ...SupervisorOption) (*Supervisor, error) {
+	return newSupervisor(ModeSequential, nil, nil, members, opts...)
+}
+
+func newSupervisor(mode Mode, llmProvider llms.ChatProvider, model *llms.Model,
+	members []*Member, opts ...SupervisorOption) (*Supervisor, error) {
+	if len(members) == 0 {
+		return nil, errors.Errorf(ErrorCodeInvalidSupervisor, "the supervisor needs members")
+	}
+	names := make(map[string]bool, len(members))
+	for _, member := range members {
+		if member == nil || member.Agent == nil {
+			return nil, errors.Errorf(ErrorCodeInvalidSupervisor, "member without agent")
+		}
+		if !memberNamePattern.MatchString(member.Name) {
+			return nil, errors.Errorf(ErrorCodeInvalidSupervisor, "invalid member name %q", member.Name)
+		}
+		if names[member.Name] {
+			return nil, errors.Errorf(ErrorCodeInvalidSupervisor, "duplicated member name %q", member.Name)
+		}
+		names[member.Name] = true
+	}
+
+	s := &Supervisor{
+		mode:           mode,
+		llmProvider:    llmProvider,
+		model:          model,
+		members:        members,
+		systemPrompt:   defaultRouterSystemPrompt,
+		maxDelegations: DefaultMaxDelegations,
+		memberMaxTurns: agent.DefaultSubAgentMaxTurns,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxDelegations <= 0 {
+		s.maxDelegations = DefaultMaxDelegations
+	}
+	if s.memberMaxTurns <= 0 {
+		s.memberMaxTurns = agent.DefaultSubAgentMaxTurns
+	}
+	return s, nil
+}
+
+var _ agent.Agent = &Supervisor{}
+
+// Supervisor delegates the requests it receives to its members. Every run of the supervisor runs its
+// members in sessions of their own, started on their first delegation, so the members keep the context
+// of the earlier delegations of the run. Nobody confirms the tool calls of the members, they are rejected.
+type Supervisor struct {
+	mode        Mode
+	llmProvider llms.ChatProvider
+	model       *llms.Model
+	members     []*Member
+
+	systemPrompt   string
+	chatOptions    []llms.ChatOption
+	maxDelegations int
+	memberMaxTurns int
+	memberTimeout  time.Duration
+}
+
+// Mode returns how the supervisor delegates the requests
+func (s *Supervisor) Mode() Mode {
+	return s.mode
+}
+
+func (s *Supervisor) Run(ctx *agent.RunContext) (chan<- *eventbus.Event, <-chan *eventbus.Event, error) {
+	r := &supervisorRun{
+		supervisor: s,
+		runContext: ctx,
+		sessions:   make(map[string]*memberSession),
+	}
+	if s.mode == ModeRouter {
+		session, err := s.llmProvider.NewChat(s.routerSystemPrompt(), s.model)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.session = session
+	}
+
+	input := make(chan *eventbus.Event, 10)
+	output := make(chan *eventbus.Event, agent.DefaultOutputBufferSize)
+	go func() {
+		defer close(output)
+		r.loop(input, output)
+	}()
+	return input, output, nil
+}
+
+// routerSystemPrompt returns the system prompt of the router, listing the members
+func (s *Supervisor) routerSystemPrompt() string {
+	var prompt strings.Builder
+	prompt.WriteString(s.systemPrompt)
+	prompt.WriteString("\n\nThe agents of your team:")
+	for _, member := range s.members {
+		fmt.Fprintf(&prompt, "\n- %s: %s", member.Name, member.Description)
+	}
+	return prompt.String()
+}
+
+// memberTools returns the tools delegating to the members
+func (s *Supervisor) memberTools() []*llms.ToolDescriptor {
+	descriptors := make([]*llms.ToolDescriptor, 0, len(s.members))
+	for _, member := range s.members {
+		descriptors = append(descriptors, &llms.ToolDescriptor{
+			Name:        member.Name,
+			Description: member.Description,
+			Parameters: &llms.Schema{
+				Type: llms.TypeObject,
+				Properties: map[string]*llms.Schema{
+					"task": {
+						Type:        llms.TypeString,
+						Description: "The task to perform, with all the information needed to perform it",
+					},
+				},
+				Required: []string{"task"},
+			},
+		})
+	}
+	return descriptors
+}
+
+func (s *Supervisor) member(name string) *Member {
+	for _, member := range s.members {
+		if member.Name == name {
+			return member
+		}
+	}
+	return nil
+}
+
+// memberSession is the running session of a member
+type memberSession struct {
+	input  chan<- *eventbus.Event
+	output <-chan *eventbus.Event
+}
+
+// supervisorRun is a run of the supervisor, its state is only accessed by its loop
+type supervisorRun struct {
+	supervisor *Supervisor
+	runContext *agent.RunContext
+	session    llms.Chat
+	history    []*llms.Message
+	sessions   map[string]*memberSession
+	requests   uint64
+}
+
+func (r *supervisorRun) loop(input <-chan *eventbus.Event, output chan<- *eventbus.Event) {
+	ctx := r.runContext.Context
+	for {
+		select {
+		case <-ctx.Done():
+			output <- agent.NewAgentResponseEndEvent("", agent.NewCanceledResponseEnd(ctx))
+			return
+		case event, ok := <-input:
+			if !ok {
+				return
+			}
+			if event == nil {
+				continue
+			}
+			r.requests++
+			traceId := fmt.Sprintf("supervisor:%s:%d", r.runContext.SessionId, r.requests)
+			if event.Topic != agent.EventTypeUserRequest {
+				output <- agent.NewAgentResponseEndEvent(traceId, &agent.AgentResponseEnd{
+					TraceId:      traceId,
+					Error:        errors.Errorf(agent.ErrorCodeInvalidInputEvent, "invalid input event type: %s", event.Topic),
+					FinishReason: llms.FinishReasonError,
+				})
+				continue
+			}
+			r.handleRequest(traceId, agent.GetUserRequestEventData(event), output)
+		}
+	}
+}
+
+func (r *supervisorRun) handleRequest(traceId string, request *agent.UserRequest, output chan<- *eventbus.Event) {
+	output <- agent.NewAgentResponseStartEvent(traceId)
+
+	var answer string
+	var err error
+	if r.supervisor.mode == ModeSequential {
+		answer, err = r.handOff(request.Message)
+	} else {
+		answer, err = r.route(traceId, request, output)
+	}
+
+	end := &agent.AgentResponseEnd{TraceId: traceId, FinishReason: llms.FinishReasonNormalEnd}
+	if err != nil {
+		journal.Warning("supervisor", traceId, "failed to handle the request", "err", err.Error())
+		end.Error = err
+		end.FinishReason = llms.FinishReasonError
+	} else if message := llms.NewAssistantMessage(traceId, r.modelId(), answer); message != nil {
+		output <- agent.NewAgentMessageEvent(traceId, message)
+	}
+	output <- agent.NewAgentResponseEndEvent(traceId, end)
+}
+
+// handOff delegates the request to every member in order, each member gets the answer of the previous one
+func (r *supervisorRun) handOff(request string) (string, error) {
+	var answer string
+	for idx, member := range r.supervisor.members {
+		task := request
+		if idx > 0 {
+			previous := r.supervisor.members[idx-1]
+			task = fmt.Sprintf("%s\n\nThe output of %s for this task:\n%s", request, previous.Name, answer)
+		}
+
+		var err error
+		answer, err = r.delegate(member, task)
+		if err != nil {
+			return "", errors.Errorf(ErrorCodeDelegationFailed, "member %s failed: %s", member.Name, err.Error())
+		}
+	}
+	return answer, nil
+}
+
+// route lets the model delegate to the members until it answers
+func (r *supervisorRun) route(traceId string, request *agent.UserRequest, output chan<- *eventbus.Event) (string, error) {
+	r.history = append(r.history, llms.NewUserMessage(request.Message))
+
+	options := append([]llms.ChatOption{llms.WithTools(r.supervisor.memberTools()...)}, r.supervisor.chatOptions...)
+	options = append(options, request.Options...)
+
+	for round := 0; round <= r.supervisor.maxDelegations; round++ {
+		text, toolCalls, err := r.ask(traceId, options, output)
+		if err != nil {
+			return "", errors.Wrap(agent.ErrorCodeChatSessionFailed, err)
+		}
+		if message := llms.NewAssistantMessage(traceId, r.modelId(), text, toolCalls...); message != nil {
+			r.history = append(r.history, message)
+		}
+		if len(toolCalls) == 0 {
+			return text, nil
+		}
+		if round == r.supervisor.maxDelegations {
+			break
+		}
+
+		for _, toolCall := range toolCalls {
+			r.history = append(r.history, llms.NewToolCallResultMessage(r.delegateToolCall(toolCall), time.Now()))
+		}
+	}
+	return "", errors.Errorf(ErrorCodeTooManyDelegations,
+		"no answer within %d delegation rounds", r.supervisor.maxDelegations)
+}
+
+// ask sends the history to the model, it returns the text and the tool calls of its answer
+func (r *supervisorRun) ask(traceId string,
+	options []llms.ChatOption, output chan<- *eventbus.Event) (string, []*llms.ToolCall, error) {
+	responses, err := r.session.Send(r.runContext.Context, r.history, options...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var text strings.Builder
+	var toolCalls []*llms.ToolCall
+	var usage llms.UsageMetadata
+	for response, err := range responses {
+		if err != nil {
+			return "", nil, err
+		}
+		if response == nil {
+			continue
+		}
+		if !response.Usage.IsZero() {
+			// the streamed responses carry the usage accumulated so far
+			usage = response.Usage
+		}
+		for _, part := range response.Parts {
+			switch p := part.(type) {
+			case *llms.TextPart:
+				if !p.Reasoning {
+					text.WriteString(p.Text)
+				}
+			case *llms.ToolCall:
+				toolCalls = append(toolCalls, p)
+			}
+		}
+	}
+	if !usage.IsZero() {
+		output <- agent.NewAgentUsageEvent(traceId, r.modelId(), usage)
+	}
+	return text.String(), toolCalls, nil
+}
+
+// delegateToolCall delegates the task of the tool call of the router to the member named by the tool,
+// the answer of the member, or its failure, is the result of the call
+func (r *supervisorRun) delegateToolCall(toolCall *llms.ToolCall) *llms.ToolCallResult {
+	failure := func(message string) *llms.ToolCallResult {
+		return &llms.ToolCallResult{
+			ToolCallId: toolCall.ToolCallId,
+			Name:       toolCall.Name,
+			Result:     map[string]any{"success": false, "error": message},
+		}
+	}
+
+	member := r.supervisor.member(toolCall.Name)
+	if member == nil {
+		return failure(fmt.Sprintf("no agent named %s", toolCall.Name))
+	}
+	task, _ := toolCall.Arguments["task"].(string)
+	if strings.TrimSpace(task) == "" {
+		return failure("task parameter is required and cannot be empty")
+	}
+
+	answer, err := r.delegate(member, task)
+	if err != nil {
+		return failure(err.Error())
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: toolCall.ToolCallId,
+		Name:       toolCall.Name,
+		Result:     map[string]any{"success": true, "answer": answer},
+	}
+}
+
+// delegate asks the member for the answer to the task, starting its session on the first delegation
+func (r *supervisorRun) delegate(member *Member, task string) (string, error) {
+	session, ok := r.sessions[member.Name]
+	if !ok {
+		input, output, err := member.Agent.Run(&agent.RunContext{
+			SessionId: fmt.Sprintf("%s:%s", r.runContext.SessionId, member.Name),
+			Context:   r.runContext.Context,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to run member: %w", err)
+		}
+		session = &memberSession{input: input, output: output}
+		r.sessions[member.Name] = session
+	}
+
+	ctx := r.runContext.Context
+	if r.supervisor.memberTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.supervisor.memberTimeout)
+		defer cancel()
+	}
+	return agent.AskForAnswer(ctx, session.input, session.output, task, r.supervisor.memberMaxTurns)
+}
+
+func (r *supervisorRun) modelId() llms.ModelId {
+	if r.supervisor.model == nil {
+		return llms.ModelId{}
+	}
+	return r.supervisor.model.ModelId
+}
@@ -0,0 +1,278 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMember answers every task with its name and the task
+type fakeMember struct {
+	name string
+
+	mu    sync.Mutex
+	runs  int
+	tasks []string
+}
+
+func (m *fakeMember) Run(ctx *agent.RunContext) (chan<- *eventbus.Event, <-chan *eventbus.Event, error) {
+	m.mu.Lock()
+	m.runs++
+	m.mu.Unlock()
+
+	input := make(chan *eventbus.Event, 10)
+	output := make(chan *eventbus.Event, 10)
+	go func() {
+		for step := 0; ; step++ {
+			var event *eventbus.Event
+			select {
+			case event = <-input:
+			case <-ctx.Context.Done():
+				return
+			}
+			task := agent.GetUserRequestEventData(event).Message
+			m.mu.Lock()
+			m.tasks = append(m.tasks, task)
+			m.mu.Unlock()
+
+			stepId := fmt.Sprintf("%s:%d", m.name, step)
+			output <- agent.NewAgentResponseStartEvent(stepId)
+			message := llms.NewAssistantMessage(stepId, llms.ModelId{}, fmt.Sprintf("%s did: %s", m.name, task))
+			output <- agent.NewAgentMessageEvent(stepId, message)
+			output <- agent.NewAgentResponseEndEvent(stepId, &agent.AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+		}
+	}()
+	return input, output, nil
+}
+
+func (m *fakeMember) receivedTasks() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.tasks...)
+}
+
+// scriptedRouter answers with the scripted responses in order, recording the messages it receives
+type scriptedRouter struct {
+	responses    []*llms.ChatResponse
+	systemPrompt string
+	received     [][]*llms.Message
+	tools        []*llms.ToolDescriptor
+}
+
+func (r *scriptedRouter) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	r.systemPrompt = systemPrompt
+	return r, nil
+}
+
+func (r *scriptedRouter) IsRetryableError(error) bool {
+	return false
+}
+
+func (r *scriptedRouter) Close() error {
+	return nil
+}
+
+func (r *scriptedRouter) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	chatOptions := &llms.ChatOptions{}
+	for _, opt := range options {
+		opt(chatOptions)
+	}
+	r.tools = chatOptions.Tools
+	r.received = append(r.received, append([]*llms.Message(nil), messages...))
+	if len(r.responses) == 0 {
+		return nil, fmt.Errorf("no more responses")
+	}
+	response := r.responses[0]
+	r.responses = r.responses[1:]
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		yield(response, nil)
+	}, nil
+}
+
+func toolCallResponse(toolCalls ...*llms.ToolCall) *llms.ChatResponse {
+	parts := make([]llms.Part, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		parts = append(parts, toolCall)
+	}
+	return &llms.ChatResponse{
+		Message:      llms.Message{Parts: parts},
+		Usage:        llms.UsageMetadata{InputTokens: 10, OutputTokens: 5},
+		FinishReason: llms.FinishReasonToolUse,
+	}
+}
+
+func textResponse(text string) *llms.ChatResponse {
+	return &llms.ChatResponse{
+		Message:      llms.Message{Parts: []llms.Part{&llms.TextPart{Text: text}}},
+		FinishReason: llms.FinishReasonNormalEnd,
+	}
+}
+
+func newTestMembers() (*fakeMember, *fakeMember, []*Member) {
+	researcher := &fakeMember{name: "researcher"}
+	writer := &fakeMember{name: "writer"}
+	return researcher, writer, []*Member{
+		{Name: "researcher", Description: "Finds facts", Agent: researcher},
+		{Name: "writer", Description: "Writes articles", Agent: writer},
+	}
+}
+
+// ask sends the request to the supervisor and collects its events until the end of its response
+func ask(t *testing.T, input chan<- *eventbus.Event, output <-chan *eventbus.Event, request string) []*eventbus.Event {
+	input <- agent.NewUserRequestEvent(&agent.UserRequest{Message: request})
+	var events []*eventbus.Event
+	for {
+		select {
+		case event := <-output:
+			events = append(events, event)
+			if event.Topic == agent.EventTypeAgentResponseEnd {
+				return events
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no response end from the supervisor")
+		}
+	}
+}
+
+func answerOf(events []*eventbus.Event) string {
+	for _, event := range events {
+		if event.Topic == agent.EventTypeAgentMessage {
+			return event.Data.(*agent.AgentMessage).Message.Parts[0].(*llms.TextPart).Text
+		}
+	}
+	return ""
+}
+
+func endOf(events []*eventbus.Event) *agent.AgentResponseEnd {
+	return agent.GetAgentResponseEndEventData(events[len(events)-1])
+}
+
+func TestSupervisor_Router(t *testing.T) {
+	researcher, writer, members := newTestMembers()
+	router := &scriptedRouter{responses: []*llms.ChatResponse{
+		toolCallResponse(&llms.ToolCall{ToolCallId: "1", Name: "researcher", Arguments: map[string]any{"task": "facts about Go"}}),
+		toolCallResponse(&llms.ToolCall{ToolCallId: "2", Name: "writer", Arguments: map[string]any{"task": "an article from the facts"}}),
+		textResponse("Here is your article."),
+	}}
+	model := &llms.Model{ModelId: llms.ModelId{Provider: "test", ID: "router"}}
+	supervisor, err := NewRouterSupervisor(router, model, members)
+	require.NoError(t, err)
+	assert.Equal(t, ModeRouter, supervisor.Mode())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input, output, err := supervisor.Run(&agent.RunContext{SessionId: "s1", Context: ctx})
+	require.NoError(t, err)
+	assert.Contains(t, router.systemPrompt, "- researcher: Finds facts\n- writer: Writes articles")
+
+	events := ask(t, input, output, "Write an article about Go")
+	assert.Equal(t, agent.EventTypeAgentResponseStart, events[0].Topic)
+	assert.Nil(t, endOf(events).Error)
+	assert.Equal(t, "Here is your article.", answerOf(events))
+
+	// the router chose the members with their tools
+	require.Len(t, router.tools, 2)
+	assert.Equal(t, "researcher", router.tools[0].Name)
+	assert.Equal(t, []string{"facts about Go"}, researcher.receivedTasks())
+	assert.Equal(t, []string{"an article from the facts"}, writer.receivedTasks())
+
+	// the answers of the members are the observations of the router
+	require.Len(t, router.received, 3)
+	last := router.received[2]
+	require.Len(t, last, 5)
+	result := last[2].Parts[0].(*llms.ToolCallResult)
+	assert.Equal(t, "1", result.ToolCallId)
+	assert.Equal(t, map[string]any{"success": true, "answer": "researcher did: facts about Go"}, result.Result)
+	result = last[4].Parts[0].(*llms.ToolCallResult)
+	assert.Equal(t, "writer did: an article from the facts", result.Result["answer"])
+
+	// the usage of the router is reported
+	var usageEvents int
+	for _, event := range events {
+		if event.Topic == agent.EventTypeAgentUsage {
+			usageEvents++
+			assert.Equal(t, model.ModelId, agent.GetAgentUsageEventData(event).ModelId)
+		}
+	}
+	assert.Equal(t, 2, usageEvents)
+
+	// the members keep their session across the requests of the run
+	router.responses = []*llms.ChatResponse{
+		toolCallResponse(&llms.ToolCall{ToolCallId: "3", Name: "researcher", Arguments: map[string]any{"task": "more facts"}}),
+		textResponse("Done."),
+	}
+	events = ask(t, input, output, "More")
+	assert.Equal(t, "Done.", answerOf(events))
+	assert.Equal(t, []string{"facts about Go", "more facts"}, researcher.receivedTasks())
+	assert.Equal(t, 1, researcher.runs)
+	assert.Len(t, router.received[3], 7) // the history of the first request is kept
+}
+
+func TestSupervisor_RouterFailures(t *testing.T) {
+	_, _, members := newTestMembers()
+	router := &scriptedRouter{responses: []*llms.ChatResponse{
+		toolCallResponse(&llms.ToolCall{ToolCallId: "1", Name: "painter", Arguments: map[string]any{"task": "a picture"}}),
+		toolCallResponse(&llms.ToolCall{ToolCallId: "2", Name: "writer", Arguments: map[string]any{"task": "a poem"}}),
+		toolCallResponse(&llms.ToolCall{ToolCallId: "3", Name: "writer", Arguments: map[string]any{"task": "another poem"}}),
+	}}
+	supervisor, err := NewRouterSupervisor(router, &llms.Model{}, members, WithMaxDelegations(2))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input, output, err := supervisor.Run(&agent.RunContext{SessionId: "s1", Context: ctx})
+	require.NoError(t, err)
+
+	events := ask(t, input, output, "Draw and write")
+	end := endOf(events)
+	require.Error(t, end.Error)
+	assert.True(t, errors.IsCode(end.Error, ErrorCodeTooManyDelegations))
+	assert.Equal(t, llms.FinishReasonError, end.FinishReason)
+
+	// an unknown member is reported to the router
+	result := router.received[1][2].Parts[0].(*llms.ToolCallResult)
+	assert.Equal(t, map[string]any{"success": false, "error": "no agent named painter"}, result.Result)
+}
+
+func TestSupervisor_Sequential(t *testing.T) {
+	researcher, writer, members := newTestMembers()
+	supervisor, err := NewSequentialSupervisor(members)
+	require.NoError(t, err)
+	assert.Equal(t, ModeSequential, supervisor.Mode())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input, output, err := supervisor.Run(&agent.RunContext{SessionId: "s1", Context: ctx})
+	require.NoError(t, err)
+
+	events := ask(t, input, output, "An article about Go")
+	assert.Nil(t, endOf(events).Error)
+
+	assert.Equal(t, []string{"An article about Go"}, researcher.receivedTasks())
+	handoff := "An article about Go\n\nThe output of researcher for this task:\nresearcher did: An article about Go"
+	assert.Equal(t, []string{handoff}, writer.receivedTasks())
+	assert.Equal(t, "writer did: "+handoff, answerOf(events))
+}
+
+func TestSupervisor_InvalidMembers(t *testing.T) {
+	_, err := NewSequentialSupervisor(nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidSupervisor))
+
+	_, err = NewSequentialSupervisor([]*Member{{Name: "has space", Agent: &fakeMember{}}})
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidSupervisor))
+
+	_, err = NewSequentialSupervisor([]*Member{{Name: "a", Agent: &fakeMember{}}, {Name: "a", Agent: &fakeMember{}}})
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidSupervisor))
+
+	_, err = NewRouterSupervisor(nil, nil, []*Member{{Name: "a", Agent: &fakeMember{}}})
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidSupervisor))
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/memory"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// Snapshotter is an Agent whose session can be checkpointed, e.g. to survive a restart, and
+// resumed with Restore
+type Snapshotter interface {
+	// Snapshot serializes the session of the agent: the id of its last session, the conversation
+	// history kept by a MemoryContext, and the progress of a ResumableBehaviorPattern. Take it
+	// between two responses of the agent, e.g. once a response ended.
+	Snapshot() ([]byte, error)
+}
+
+// agentSnapshot is the serialized session of the generic agent
+type agentSnapshot struct {
+	SessionId string `json:"sessionId"`
+	StepIndex uint64 `json:"stepIndex"`
+	// History are the memory items encoded by the memory JsonCodec, in order
+	History  []json.RawMessage `json:"history,omitempty"`
+	Progress json.RawMessage   `json:"progress,omitempty"`
+}
+
+var _ Snapshotter = &genericAgent{}
+
+func (a *genericAgent) Snapshot() ([]byte, error) {
+	a.sessionLock.RLock()
+	snapshot := &agentSnapshot{
+		SessionId: a.sessionId,
+		StepIndex: a.stepCounter.Load(),
+	}
+	a.sessionLock.RUnlock()
+
+	if memoryContext, ok := a.agentContext.(MemoryContext); ok && memoryContext.GetMemory() != nil {
+		items, err := memoryContext.GetMemory().Retrieve(context.Background())
+		if err != nil {
+			return nil, errors.Wrap(ErrorCodeSnapshotFailed, err)
+		}
+		codec := memory.NewJsonCodec()
+		for _, item := range items {
+			data, err := codec.Encode(item)
+			if err != nil {
+				return nil, errors.Wrap(ErrorCodeSnapshotFailed, err)
+			}
+			snapshot.History = append(snapshot.History, data)
+		}
+	}
+
+	if behavior, ok := a.behavior.(ResumableBehaviorPattern); ok && a.agentContext.GetState() != nil {
+		progress, err := behavior.SaveProgress(a.agentContext.GetState())
+		if err != nil {
+			return nil, errors.Wrap(ErrorCodeSnapshotFailed, err)
+		}
+		snapshot.Progress = progress
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, errors.Wrap(ErrorCodeSnapshotFailed, err)
+	}
+	return data, nil
+}
+
+// Restore creates a generic agent like NewGenericAgent, resuming the session of the snapshot taken by
+// Snapshot: the history replaces the items of the memory of the context, and the progress of the
+// behavior pattern is put back into its state. Run the agent with an empty session id to continue the
+// session of the snapshot from its next step.
+func Restore(snapshot []byte,
+	agentContext Context, behavior BehaviorPattern,
+	llmProvider llms.ChatProvider, model *llms.Model, chatOptions []llms.ChatOption,
+	opts ...AgentOption) (Agent, error) {
+	saved := &agentSnapshot{}
+	if err := json.Unmarshal(snapshot, saved); err != nil {
+		return nil, errors.Errorf(ErrorCodeRestoreFailed, "invalid snapshot: %v", err)
+	}
+
+	if len(saved.History) > 0 {
+		memoryContext, ok := agentContext.(MemoryContext)
+		if !ok || memoryContext.GetMemory() == nil {
+			return nil, errors.Errorf(ErrorCodeRestoreFailed, "the context has no memory to restore the history into")
+		}
+		mem := memoryContext.GetMemory()
+		if err := mem.Reset(); err != nil {
+			return nil, errors.Wrap(ErrorCodeRestoreFailed, err)
+		}
+		codec := memory.NewJsonCodec()
+		for idx, data := range saved.History {
+			item, err := codec.Decode(data)
+			if err != nil {
+				return nil, errors.Errorf(ErrorCodeRestoreFailed, "invalid history item %d: %v", idx, err)
+			}
+			if err = mem.Add(context.Background(), item); err != nil {
+				return nil, errors.Wrap(ErrorCodeRestoreFailed, err)
+			}
+		}
+	}
+
+	if len(saved.Progress) > 0 {
+		resumable, ok := behavior.(ResumableBehaviorPattern)
+		if !ok || agentContext.GetState() == nil {
+			return nil, errors.Errorf(ErrorCodeRestoreFailed, "the behavior pattern cannot restore its progress")
+		}
+		if err := resumable.RestoreProgress(agentContext.GetState(), saved.Progress); err != nil {
+			return nil, errors.Wrap(ErrorCodeRestoreFailed, err)
+		}
+	}
+
+	restored, err := NewGenericAgent(agentContext, behavior, llmProvider, model, chatOptions, opts...)
+	if err != nil {
+		return nil, err
+	}
+	a := restored.(*genericAgent)
+	a.sessionId = saved.SessionId
+	a.stepCounter.Store(saved.StepIndex)
+	return a, nil
+}
@@ -0,0 +1,242 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// DefaultSubAgentToolName is the default name of the sub-agent tool
+	DefaultSubAgentToolName = "sub_agent"
+	// DefaultSubAgentMaxTurns is the default number of responses a sub-agent may end before its answer
+	DefaultSubAgentMaxTurns = 10
+)
+
+// SubAgentOption configures the sub-agent tool, e.g. the budget of the sub-agents
+type SubAgentOption func(*subAgentTool)
+
+// WithSubAgentName sets the name of the sub-agent tool, e.g. to delegate to several kinds of sub-agents
+func WithSubAgentName(name string) SubAgentOption {
+	return func(t *subAgentTool) {
+		t.name = name
+	}
+}
+
+// WithSubAgentDescription sets the description of the sub-agent tool, telling the model which subtasks to delegate
+func WithSubAgentDescription(description string) SubAgentOption {
+	return func(t *subAgentTool) {
+		t.description = description
+	}
+}
+
+// WithSubAgentMaxTurns limits the number of responses a sub-agent may end, e.g. asking for tool calls,
+// before giving its answer; n <= 0 for DefaultSubAgentMaxTurns.
+func WithSubAgentMaxTurns(n int) SubAgentOption {
+	return func(t *subAgentTool) {
+		t.maxTurns = n
+	}
+}
+
+// WithSubAgentTimeout limits the time a sub-agent may run, 0 for no limit besides the context of the call
+func WithSubAgentTimeout(timeout time.Duration) SubAgentOption {
+	return func(t *subAgentTool) {
+		t.timeout = timeout
+	}
+}
+
+// NewSubAgentTool creates a tool delegating a scoped subtask to a sub-agent. Each call creates a fresh
+// agent with the factory and runs it on the task until it answers, the final answer of the sub-agent is
+// the result of the call: its intermediate turns, tool calls and context never reach the calling agent.
+// The sub-agent has no user to confirm its tool calls, the tools it can not auto call are rejected.
+func NewSubAgentTool(factory func() (Agent, error), opts ...SubAgentOption) tools.Tool {
+	t := &subAgentTool{
+		factory: factory,
+		name:    DefaultSubAgentToolName,
+		description: "Delegate a self-contained subtask to a sub-agent, which works on it on its own " +
+			"and returns its final answer. Describe the task completely, the sub-agent does not see the conversation.",
+		maxTurns: DefaultSubAgentMaxTurns,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.maxTurns <= 0 {
+		t.maxTurns = DefaultSubAgentMaxTurns
+	}
+	return t
+}
+
+var _ tools.Tool = &subAgentTool{}
+
+type subAgentTool struct {
+	factory     func() (Agent, error)
+	name        string
+	description string
+	maxTurns    int
+	timeout     time.Duration
+}
+
+func (t *subAgentTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        t.name,
+		Description: t.description,
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"task": {
+					Type:        llms.TypeString,
+					Description: "The subtask to perform, with all the information needed to perform it",
+				},
+			},
+			Required: []string{"task"},
+		},
+	}
+}
+
+func (t *subAgentTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	task, _ := params.Arguments["task"].(string)
+	if strings.TrimSpace(task) == "" {
+		return t.failure(params, "task parameter is required and cannot be empty", ""), nil
+	}
+
+	subAgent, err := t.factory()
+	if err != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed, "failed to create sub-agent: %v", err)
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if t.timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, t.timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel() // stops the sub-agent
+
+	input, output, err := subAgent.Run(&RunContext{
+		SessionId: fmt.Sprintf("%s:%s", t.name, params.ToolCallId),
+		Context:   runCtx,
+	})
+	if err != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed, "failed to run sub-agent: %v", err)
+	}
+
+	answer, err := AskForAnswer(runCtx, input, output, task, t.maxTurns)
+	if err != nil {
+		return t.failure(params, err.Error(), answer), nil
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": true,
+			"answer":  answer,
+		},
+	}, nil
+}
+
+// AskForAnswer sends the task to a running agent, through the channels returned by its Run, and collects
+// its responses until it answers; the answer is the text of the last message of the agent. Nobody confirms
+// the tool calls of the agent, they are rejected, and the agent must answer within maxTurns responses.
+// On failure the text of the agent so far is returned with the error.
+func AskForAnswer(ctx context.Context,
+	input chan<- *eventbus.Event, output <-chan *eventbus.Event, task string, maxTurns int) (string, error) {
+	send := func(event *eventbus.Event) error {
+		select {
+		case input <- event:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("sub-agent did not answer: %w", context.Cause(ctx))
+		}
+	}
+	if err := send(NewUserRequestEvent(&UserRequest{Message: task})); err != nil {
+		return "", err
+	}
+
+	var answer strings.Builder
+	var answerMessageId string
+	var toolCalls []*llms.ToolCall
+	pending := 1 // responses expected from the sub-agent
+	for turns := 0; ; {
+		var event *eventbus.Event
+		var ok bool
+		select {
+		case event, ok = <-output:
+			if !ok {
+				return answer.String(), fmt.Errorf("sub-agent stopped before answering")
+			}
+		case <-ctx.Done():
+			return answer.String(), fmt.Errorf("sub-agent did not answer: %w", context.Cause(ctx))
+		}
+		if event == nil {
+			continue
+		}
+
+		switch event.Topic {
+		case EventTypeAgentMessage:
+			message := GetAgentMessageEventData(event).Message
+			if message == nil {
+				continue
+			}
+			if message.MessageId != answerMessageId {
+				// a new message of the sub-agent, the previous ones were intermediate
+				answerMessageId = message.MessageId
+				answer.Reset()
+			}
+			for _, part := range message.Parts {
+				if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning {
+					answer.WriteString(textPart.Text)
+				}
+			}
+		case EventTypeExternalAction:
+			if toolCall := GetToolCallEventData(event); toolCall != nil {
+				toolCalls = append(toolCalls, toolCall)
+			}
+		case EventTypeAgentResponseEnd:
+			end := GetAgentResponseEndEventData(event)
+			turns++
+			pending--
+			if end.Error != nil {
+				return answer.String(), fmt.Errorf("sub-agent failed: %v", end.Error)
+			}
+			if len(toolCalls) == 0 {
+				if pending <= 0 {
+					return answer.String(), nil
+				}
+				continue
+			}
+			if turns >= maxTurns {
+				return answer.String(), fmt.Errorf("sub-agent did not answer within %d turns", maxTurns)
+			}
+			// nobody confirms the tool calls of the sub-agent, reject them
+			for _, toolCall := range toolCalls {
+				if err := send(NewUserSkipToolCallEvent(toolCall.ToolCallId, toolCall.Name)); err != nil {
+					return answer.String(), err
+				}
+				pending++
+			}
+			toolCalls = nil
+		}
+	}
+}
+
+func (t *subAgentTool) failure(params *llms.ToolCall, message, answer string) *llms.ToolCallResult {
+	result := map[string]any{
+		"success": false,
+		"error":   message,
+	}
+	if answer != "" {
+		result["partial_answer"] = answer
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result:     result,
+	}
+}
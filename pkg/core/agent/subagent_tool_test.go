@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubAgent asks for a tool call with an intermediate message, then answers once the call is rejected
+type fakeSubAgent struct {
+	mu       sync.Mutex
+	task     string
+	received []*eventbus.Event
+	turns    int // tool call turns before answering
+}
+
+func (a *fakeSubAgent) Run(ctx *RunContext) (chan<- *eventbus.Event, <-chan *eventbus.Event, error) {
+	input := make(chan *eventbus.Event, 10)
+	output := make(chan *eventbus.Event, 10)
+	go func() {
+		for step := 0; ; step++ {
+			var event *eventbus.Event
+			select {
+			case event = <-input:
+			case <-ctx.Context.Done():
+				return
+			}
+			a.mu.Lock()
+			a.received = append(a.received, event)
+			if request, ok := event.Data.(*UserRequest); ok {
+				a.task = request.Message
+			}
+			a.mu.Unlock()
+
+			stepId := fmt.Sprintf("step:%d", step)
+			output <- NewAgentResponseStartEvent(stepId)
+			if step < a.turns {
+				message := llms.NewAssistantMessage(fmt.Sprintf("m%d", step), llms.ModelId{}, "Let me look that up.")
+				output <- NewAgentMessageEvent(stepId, message)
+				output <- NewToolCallEvent(&llms.ToolCall{ToolCallId: fmt.Sprintf("call-%d", step), Name: "search"})
+				output <- NewAgentResponseEndEvent(stepId, &AgentResponseEnd{FinishReason: llms.FinishReasonToolUse})
+				continue
+			}
+			for _, text := range []string{"The answer ", "is 42."} {
+				message := llms.NewAssistantMessage("final", llms.ModelId{}, text)
+				output <- NewAgentMessageEvent(stepId, message)
+			}
+			output <- NewAgentResponseEndEvent(stepId, &AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+		}
+	}()
+	return input, output, nil
+}
+
+func callSubAgentTool(t *testing.T, tool tools.Tool, task string) *llms.ToolCallResult {
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "call-1",
+		Name:       DefaultSubAgentToolName,
+		Arguments:  map[string]any{"task": task},
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestSubAgentTool_ReturnsFinalAnswer(t *testing.T) {
+	var created []*fakeSubAgent
+	tool := NewSubAgentTool(func() (Agent, error) {
+		subAgent := &fakeSubAgent{turns: 1}
+		created = append(created, subAgent)
+		return subAgent, nil
+	})
+	assert.Equal(t, DefaultSubAgentToolName, tool.Descriptor().Name)
+
+	result := callSubAgentTool(t, tool, "What is the answer?")
+	assert.Equal(t, map[string]any{"success": true, "answer": "The answer is 42."}, result.Result)
+
+	require.Len(t, created, 1)
+	subAgent := created[0]
+	assert.Equal(t, "What is the answer?", subAgent.task)
+	// the tool call of the sub-agent is rejected, nobody confirms it
+	require.Len(t, subAgent.received, 2)
+	rejected := GetToolCallResultEventData(subAgent.received[1])
+	require.NotNil(t, rejected)
+	assert.Equal(t, "call-0", rejected.ToolCallId)
+	assert.Equal(t, "UserSkipped", rejected.Result["state"])
+
+	// each call runs a fresh sub-agent
+	callSubAgentTool(t, tool, "Again?")
+	assert.Len(t, created, 2)
+}
+
+func TestSubAgentTool_Budget(t *testing.T) {
+	tool := NewSubAgentTool(func() (Agent, error) {
+		return &fakeSubAgent{turns: 5}, nil
+	}, WithSubAgentMaxTurns(3))
+
+	result := callSubAgentTool(t, tool, "What is the answer?")
+	assert.Equal(t, false, result.Result["success"])
+	assert.Equal(t, "sub-agent did not answer within 3 turns", result.Result["error"])
+	assert.Equal(t, "Let me look that up.", result.Result["partial_answer"])
+
+	// a sub-agent never answering is stopped by the timeout
+	tool = NewSubAgentTool(func() (Agent, error) {
+		return &silentAgent{}, nil
+	}, WithSubAgentTimeout(50*time.Millisecond))
+	result = callSubAgentTool(t, tool, "What is the answer?")
+	assert.Equal(t, false, result.Result["success"])
+	assert.Contains(t, result.Result["error"], "deadline exceeded")
+}
+
+func TestSubAgentTool_InvalidCalls(t *testing.T) {
+	tool := NewSubAgentTool(func() (Agent, error) {
+		return nil, fmt.Errorf("no model")
+	})
+
+	result := callSubAgentTool(t, tool, " ")
+	assert.Equal(t, false, result.Result["success"])
+
+	_, err := tool.Call(context.Background(), &llms.ToolCall{
+		Name:      DefaultSubAgentToolName,
+		Arguments: map[string]any{"task": "What is the answer?"},
+	})
+	assert.ErrorContains(t, err, "no model")
+}
+
+// silentAgent never responds
+type silentAgent struct{}
+
+func (a *silentAgent) Run(ctx *RunContext) (chan<- *eventbus.Event, <-chan *eventbus.Event, error) {
+	return make(chan *eventbus.Event, 10), make(chan *eventbus.Event), nil
+}
+
+// historyContext records the memory of the agent and calls its tool
+type historyContext struct {
+	stubContext
+	tool    tools.Tool
+	history []*llms.Message
+}
+
+func (c *historyContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	c.history = append(c.history, messages...)
+	return nil
+}
+
+func (c *historyContext) CallTool(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+	return c.tool.Call(ctx, call)
+}
+
+func TestSubAgentTool_IsolatesParentHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	parentContext := &historyContext{
+		tool: NewSubAgentTool(func() (Agent, error) {
+			return &fakeSubAgent{turns: 2}, nil
+		}),
+	}
+
+	provider := llms.NewMockChatProvider(ctrl)
+	provider.EXPECT().NewChat(gomock.Any(), gomock.Any()).Return(llms.NewMockChat(ctrl), nil)
+	// the parent delegates the request to the sub-agent, then answers with its result
+	behavior := NewMockBehaviorPattern(ctrl)
+	behavior.EXPECT().NextStep(gomock.Any()).DoAndReturn(func(ctx *StepContext) error {
+		ctx.OutputChan <- NewAgentResponseStartEvent(ctx.StepId())
+		call := &llms.ToolCall{
+			ToolCallId: "delegate",
+			Name:       DefaultSubAgentToolName,
+			Arguments:  map[string]any{"task": ctx.UserRequest.Message},
+		}
+		_ = ctx.AgentContext.UpdateMemory(ctx.Context, llms.NewAssistantMessage("parent", llms.ModelId{}, "", call))
+		result, err := ctx.AgentContext.CallTool(ctx.Context, call)
+		if err != nil {
+			return err
+		}
+		_ = ctx.AgentContext.UpdateMemory(ctx.Context, llms.NewToolCallResultMessage(result, time.Now()))
+		answer := llms.NewAssistantMessage("parent-answer", llms.ModelId{}, "Sub-agent says: "+result.Result["answer"].(string))
+		_ = ctx.AgentContext.UpdateMemory(ctx.Context, answer)
+		ctx.OutputChan <- NewAgentMessageEvent(ctx.StepId(), answer)
+		ctx.OutputChan <- NewAgentResponseEndEvent(ctx.StepId(), &AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+		return nil
+	})
+
+	parent, err := NewGenericAgent(parentContext, behavior, provider, &llms.Model{}, nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input, output, err := parent.Run(&RunContext{SessionId: "parent", Context: ctx})
+	require.NoError(t, err)
+	input <- NewUserRequestEvent(&UserRequest{Message: "What is the answer?"})
+
+	var events []*eventbus.Event
+	for done := false; !done; {
+		select {
+		case event := <-output:
+			events = append(events, event)
+			done = event.Topic == EventTypeAgentResponseEnd
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "parent agent did not end its response")
+		}
+	}
+
+	// the parent only sees its own events
+	require.Len(t, events, 3)
+	assert.Equal(t, EventTypeAgentResponseStart, events[0].Topic)
+	assert.Equal(t, "Sub-agent says: The answer is 42.",
+		GetAgentMessageEventData(events[1]).Message.Parts[0].(*llms.TextPart).Text)
+
+	// and its history holds the delegation and the final answer of the sub-agent, not its turns
+	require.Len(t, parentContext.history, 3)
+	toolResult := parentContext.history[1].Parts[0].(*llms.ToolCallResult)
+	assert.Equal(t, map[string]any{"success": true, "answer": "The answer is 42."}, toolResult.Result)
+	for _, message := range parentContext.history {
+		for _, part := range message.Parts {
+			if toolCall, ok := part.(*llms.ToolCall); ok {
+				assert.Equal(t, DefaultSubAgentToolName, toolCall.Name)
+			}
+			if text, ok := part.(*llms.TextPart); ok {
+				assert.False(t, strings.Contains(text.Text, "look that up"))
+			}
+		}
+	}
+}
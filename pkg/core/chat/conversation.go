@@ -3,7 +3,9 @@ package chat
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	
 	"github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/commons/utils"
@@ -29,18 +31,37 @@ type ConversationContext struct {
 type AgentResponse struct {
 	Message   *llms.Message
 	ToolCalls []*llms.ToolCall
+	// Sources are the documents the answer is based on, e.g. retrieved by the RAG pattern
+	Sources []Source
 }
 
+// Source is a document an answer is based on
+type Source = agent.Source
+
 type ConversationHandler interface {
 	OnResponse(ctx *ConversationContext, agentResponse *AgentResponse) error
 }
 
+// ConversationStreamHandler is a ConversationHandler receiving the text of the answer as the agent
+// generates it, e.g. to print the tokens as they arrive when the chat is streaming. OnResponse is
+// still called with the assembled message once the response ends.
+type ConversationStreamHandler interface {
+	ConversationHandler
+	// OnStreamDelta receives the text generated since the previous delta
+	OnStreamDelta(ctx *ConversationContext, delta string) error
+}
+
 type Conversation struct {
 	theAgent agent.Agent
 	
 	// Temporary storage for collecting events until ResponseEnd
 	currentMessages  []*llms.Message
 	currentToolCalls []*llms.ToolCall
+	currentSources   []Source
+
+	// Usage of the models across the turns, by model
+	usageLock    sync.RWMutex
+	usageByModel map[llms.ModelId]llms.UsageMetadata
 }
 
 // NewConversation creates a new conversation instance
@@ -50,6 +71,55 @@ func NewConversation(agent agent.Agent) *Conversation {
 	}
 }
 
+// Usage returns the tokens used by the models across the turns of the conversation
+func (c *Conversation) Usage() llms.UsageMetadata {
+	c.usageLock.RLock()
+	defer c.usageLock.RUnlock()
+
+	var total llms.UsageMetadata
+	for _, usage := range c.usageByModel {
+		total.Add(usage)
+	}
+	return total
+}
+
+// EstimatedCost returns the estimated cost, in dollars, of the turns of the conversation,
+// from the prices of the models (see llms.RegisterModelPricing). The models without
+// price are not counted.
+func (c *Conversation) EstimatedCost() float64 {
+	c.usageLock.RLock()
+	defer c.usageLock.RUnlock()
+
+	var total float64
+	for modelId, usage := range c.usageByModel {
+		if cost, ok := llms.EstimateCost(modelId, usage); ok {
+			total += cost
+		}
+	}
+	return total
+}
+
+func (c *Conversation) addUsage(modelId llms.ModelId, usage llms.UsageMetadata) {
+	c.usageLock.Lock()
+	defer c.usageLock.Unlock()
+
+	if c.usageByModel == nil {
+		c.usageByModel = make(map[llms.ModelId]llms.UsageMetadata)
+	}
+	total := c.usageByModel[modelId]
+	total.Add(usage)
+	c.usageByModel[modelId] = total
+}
+
+// addSources collects the sources of the current response, once each
+func (c *Conversation) addSources(sources []Source) {
+	for _, source := range sources {
+		if !slices.ContainsFunc(c.currentSources, func(s Source) bool { return s.DocumentId == source.DocumentId }) {
+			c.currentSources = append(c.currentSources, source)
+		}
+	}
+}
+
 func (c *Conversation) Ask(ctx context.Context, question string, handler ConversationHandler) error {
 	// Generate a unique session ID
 	sessionId := fmt.Sprintf("conversation-%s", utils.GenerateUUID())
@@ -102,8 +172,17 @@ func (c *Conversation) Ask(ctx context.Context, question string, handler Convers
 func (c *Conversation) handleAgentEvent(conversationCtx *ConversationContext, event *eventbus.Event, handler ConversationHandler) (bool, error) {
 	switch event.Topic {
 	case agent.EventTypeAgentMessage:
-		if messageEvent := agent.GetAgentMessageEventData(event); messageEvent != nil {
+		if messageEvent := agent.GetAgentMessageEventData(event); messageEvent != nil && messageEvent.Message != nil {
 			c.currentMessages = append(c.currentMessages, messageEvent.Message)
+			if streamHandler, ok := handler.(ConversationStreamHandler); ok {
+				for _, part := range messageEvent.Message.Parts {
+					if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning && textPart.Text != "" {
+						if err := streamHandler.OnStreamDelta(conversationCtx, textPart.Text); err != nil {
+							return false, err
+						}
+					}
+				}
+			}
 		}
 		
 	case agent.EventTypeExternalAction:
@@ -113,6 +192,16 @@ func (c *Conversation) handleAgentEvent(conversationCtx *ConversationContext, ev
 			}
 		}
 		
+	case agent.EventTypeAgentSources:
+		if sourcesEvent := agent.GetAgentSourcesEventData(event); sourcesEvent != nil {
+			c.addSources(sourcesEvent.Sources)
+		}
+
+	case agent.EventTypeAgentUsage:
+		if usageEvent := agent.GetAgentUsageEventData(event); usageEvent != nil {
+			c.addUsage(usageEvent.ModelId, usageEvent.Usage)
+		}
+
 	case agent.EventTypeAgentResponseEnd:
 		if endEvent := agent.GetAgentResponseEndEventData(event); endEvent != nil {
 			if endEvent.Error != nil && !endEvent.Abort {
@@ -126,12 +215,13 @@ func (c *Conversation) handleAgentEvent(conversationCtx *ConversationContext, ev
 				if len(c.currentMessages) > 0 {
 					var textContent strings.Builder
 					for i, msg := range c.currentMessages {
-						if i > 0 {
+						// the chunks of a streamed message share its id
+						if i > 0 && (msg.MessageId == "" || msg.MessageId != c.currentMessages[i-1].MessageId) {
 							textContent.WriteString("\n")
 						}
 						// Extract text from message parts
 						for _, part := range msg.Parts {
-							if textPart, ok := part.(*llms.TextPart); ok {
+							if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning {
 								textContent.WriteString(textPart.Text)
 							}
 						}
@@ -150,6 +240,7 @@ func (c *Conversation) handleAgentEvent(conversationCtx *ConversationContext, ev
 				agentResponse := &AgentResponse{
 					Message:   mergedMessage,
 					ToolCalls: c.currentToolCalls,
+					Sources:   c.currentSources,
 				}
 				if err := handler.OnResponse(conversationCtx, agentResponse); err != nil {
 					return false, err
@@ -159,6 +250,7 @@ func (c *Conversation) handleAgentEvent(conversationCtx *ConversationContext, ev
 			// Clear the collected events for next round
 			c.currentMessages = nil
 			c.currentToolCalls = nil
+			c.currentSources = nil
 			
 			return true, nil // Signal to stop processing
 		}
@@ -2,11 +2,17 @@ package chat
 
 import (
 	"context"
+	"math"
+	"reflect"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/core/agent/behavior_patterns"
+	"github.com/oopslink/agent-go/pkg/core/knowledge"
+	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/eventbus"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
@@ -155,3 +161,235 @@ func TestConversation_ContextCancellation(t *testing.T) {
 		t.Fatalf("Expected context.Canceled, got: %v", err)
 	}
 }
+
+// usageChatProvider creates chats streaming a fixed answer with a known usage
+type usageChatProvider struct {
+	modelId llms.ModelId
+}
+
+func (p *usageChatProvider) Close() error { return nil }
+
+func (p *usageChatProvider) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return p, nil
+}
+
+func (p *usageChatProvider) IsRetryableError(error) bool { return false }
+
+func (p *usageChatProvider) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		// the streamed responses carry the usage accumulated so far
+		for _, usage := range []llms.UsageMetadata{
+			{InputTokens: 1000, OutputTokens: 100},
+			{InputTokens: 1000, OutputTokens: 500},
+		} {
+			message := llms.NewAssistantMessage("answer", p.modelId, "answer ")
+			if !yield(&llms.ChatResponse{Message: *message, Usage: usage, FinishReason: llms.FinishReasonNormalEnd}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// stubAgentContext is an agent context without memory nor tools
+type stubAgentContext struct{}
+
+func (c *stubAgentContext) AgentId() string            { return "test-agent" }
+func (c *stubAgentContext) SystemPrompt() string       { return "" }
+func (c *stubAgentContext) GetModel() *llms.Model      { return nil }
+func (c *stubAgentContext) GetState() agent.AgentState { return nil }
+
+func (c *stubAgentContext) Generate(ctx context.Context, params *agent.GenerateContextParams) (*agent.GeneratedContext, error) {
+	return &agent.GeneratedContext{Messages: params.ToMessages()}, nil
+}
+
+func (c *stubAgentContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	return nil
+}
+
+func (c *stubAgentContext) CallTool(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+	return nil, nil
+}
+
+func (c *stubAgentContext) CanAutoCall(toolCall *llms.ToolCall) bool { return false }
+
+func (c *stubAgentContext) ValidateToolCall(call *llms.ToolCall) error { return nil }
+
+func TestConversation_Usage(t *testing.T) {
+	modelId := llms.ModelId{Provider: "conversation-test", ID: "usage"}
+	llms.RegisterModelPricing(modelId, 0.01, 0.02)
+
+	pattern, err := behavior_patterns.NewGenericPattern()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	theAgent, err := agent.NewGenericAgent(&stubAgentContext{}, pattern,
+		&usageChatProvider{modelId: modelId}, &llms.Model{ModelId: modelId}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	conversation := NewConversation(theAgent)
+
+	if usage := conversation.Usage(); !usage.IsZero() {
+		t.Fatalf("Expected no usage before the first turn, got %+v", usage)
+	}
+
+	for turn := 1; turn <= 2; turn++ {
+		if err := conversation.Ask(context.Background(), "question", &mockHandler{}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := llms.UsageMetadata{InputTokens: int64(turn) * 1000, OutputTokens: int64(turn) * 500}
+		if usage := conversation.Usage(); usage != expected {
+			t.Fatalf("Expected usage %+v after turn %d, got %+v", expected, turn, usage)
+		}
+		// 1000 input tokens at 0.01 and 500 output tokens at 0.02 per 1000 tokens a turn
+		if cost := conversation.EstimatedCost(); math.Abs(cost-float64(turn)*0.02) > 1e-9 {
+			t.Fatalf("Expected cost %v after turn %d, got %v", float64(turn)*0.02, turn, cost)
+		}
+	}
+}
+
+// memoryAgentContext keeps the messages added to the memory for the next requests
+type memoryAgentContext struct {
+	stubAgentContext
+	messages []*llms.Message
+}
+
+func (c *memoryAgentContext) Generate(ctx context.Context, params *agent.GenerateContextParams) (*agent.GeneratedContext, error) {
+	return &agent.GeneratedContext{Messages: append(slices.Clone(c.messages), params.ToMessages()...)}, nil
+}
+
+func (c *memoryAgentContext) UpdateMemory(ctx context.Context, messages ...*llms.Message) error {
+	c.messages = append(c.messages, messages...)
+	return nil
+}
+
+func TestConversation_Sources(t *testing.T) {
+	kb := knowledge.NewKnowledgeBase(knowledge.NewInMemoryStorage(), knowledge.NewBaseKnowledgeItemFactory(), nil)
+	for _, doc := range []*document.Document{
+		{Id: "go-intro", Name: "Go intro", Content: "go is a programming language", Metadata: map[string]any{"url": "https://go.dev"}},
+		{Id: "go-gc", Name: "Go GC", Content: "the go garbage collector"},
+		{Id: "rust", Name: "Rust", Content: "rust is a programming language too"},
+	} {
+		if err := kb.AddItem(context.Background(), knowledge.NewKnowledgeItem(doc)); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	pattern, err := behavior_patterns.NewRAGPatternWithConfig(&behavior_patterns.RAGConfig{
+		KnowledgeBases: []knowledge.KnowledgeBase{kb},
+		ScoreThreshold: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	modelId := llms.ModelId{Provider: "conversation-test", ID: "sources"}
+	theAgent, err := agent.NewGenericAgent(&memoryAgentContext{}, pattern,
+		&usageChatProvider{modelId: modelId}, &llms.Model{ModelId: modelId}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	handler := &mockHandler{}
+	if err := NewConversation(theAgent).Ask(context.Background(), "go language", handler); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	responses := handler.getResponses()
+	if len(responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(responses))
+	}
+
+	// the items retrieved with all the terms, then the ones with half of them
+	var retrieved []Source
+	for _, source := range responses[0].Sources {
+		retrieved = append(retrieved, Source{DocumentId: source.DocumentId, Name: source.Name, Score: source.Score})
+	}
+	expected := []Source{
+		{DocumentId: "go-intro", Name: "Go intro", Score: 1},
+		{DocumentId: "go-gc", Name: "Go GC", Score: 0.5},
+		{DocumentId: "rust", Name: "Rust", Score: 0.5},
+	}
+	if !reflect.DeepEqual(expected, retrieved) {
+		t.Fatalf("Expected sources %+v, got %+v", expected, retrieved)
+	}
+	if url := responses[0].Sources[0].Metadata["url"]; url != "https://go.dev" {
+		t.Fatalf("Expected the metadata of the document, got url %v", url)
+	}
+	if _, exists := responses[0].Sources[0].Metadata[knowledge.MetadataKeyScore]; exists {
+		t.Fatalf("Expected the score out of the metadata")
+	}
+}
+
+// streamingChatProvider creates chats streaming the deltas of an answer
+type streamingChatProvider struct {
+	deltas []string
+}
+
+func (p *streamingChatProvider) Close() error { return nil }
+
+func (p *streamingChatProvider) NewChat(systemPrompt string, model *llms.Model) (llms.Chat, error) {
+	return p, nil
+}
+
+func (p *streamingChatProvider) IsRetryableError(error) bool { return false }
+
+func (p *streamingChatProvider) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	return func(yield func(*llms.ChatResponse, error) bool) {
+		for _, delta := range p.deltas {
+			message := llms.NewAssistantMessage("streamed", llms.ModelId{Provider: "test", ID: "model"}, delta)
+			if !yield(&llms.ChatResponse{Message: *message}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// streamHandler records the deltas and the responses
+type streamHandler struct {
+	mockHandler
+	deltas []string
+}
+
+func (h *streamHandler) OnStreamDelta(ctx *ConversationContext, delta string) error {
+	h.deltas = append(h.deltas, delta)
+	return nil
+}
+
+func TestConversation_StreamDeltas(t *testing.T) {
+	pattern, err := behavior_patterns.NewGenericPattern()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	provider := &streamingChatProvider{deltas: []string{"Hello", ", ", "world!"}}
+	theAgent, err := agent.NewGenericAgent(&stubAgentContext{}, pattern,
+		provider, &llms.Model{}, []llms.ChatOption{llms.WithStreaming(true)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	handler := &streamHandler{}
+	if err := NewConversation(theAgent).Ask(context.Background(), "hi", handler); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !slices.Equal(provider.deltas, handler.deltas) {
+		t.Fatalf("Expected deltas %q, got %q", provider.deltas, handler.deltas)
+	}
+
+	// the response is the assembled message
+	responses := handler.getResponses()
+	if len(responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(responses))
+	}
+	if text := responses[0].Message.Parts[0].(*llms.TextPart).Text; text != "Hello, world!" {
+		t.Fatalf("Expected the assembled message, got %q", text)
+	}
+
+	// the handlers without OnStreamDelta only receive the response
+	plainHandler := &mockHandler{}
+	if err := NewConversation(theAgent).Ask(context.Background(), "hi", plainHandler); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(plainHandler.getResponses()) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(plainHandler.getResponses()))
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
 )
 
 // NewKnowledgeBase creates a new knowledge base instance
@@ -21,6 +23,9 @@ func NewKnowledgeBase(
 	}
 }
 
+var _ KnowledgeLister = &knowledgeBase{}
+var _ KnowledgeUpserter = &knowledgeBase{}
+
 // knowledgeBase is the base implementation of knowledge base, combining retrieval, update and management functionality
 type knowledgeBase struct {
 	mu sync.RWMutex
@@ -63,6 +68,48 @@ func (kb *knowledgeBase) UpdateItem(ctx context.Context, id document.DocumentId,
 	return kb.storage.Update(ctx, id, doc, opts...)
 }
 
+// UpsertItem compares the content hash of the item with the hash recorded on the stored document
+// with its id, adding the item when it is not stored and updating it when the hashes differ
+func (kb *knowledgeBase) UpsertItem(ctx context.Context, item KnowledgeItem) (UpsertOutcome, error) {
+	doc := item.ToDocument()
+	hash, err := ContentHash(doc)
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := kb.storage.Get(ctx, doc.Id)
+	if err != nil && !isDocumentNotFound(err) {
+		return "", err
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]any)
+	}
+	doc.Metadata[MetadataKeyContentHash] = hash
+
+	if stored == nil {
+		if err := kb.storage.Add(ctx, doc); err != nil {
+			return "", err
+		}
+		return UpsertAdded, nil
+	}
+	if storedHash, _ := stored.Metadata[MetadataKeyContentHash].(string); storedHash == hash {
+		return UpsertUnchanged, nil
+	}
+	// the item keeps its creation time
+	if createdAt, ok := stored.Metadata["knowledge_item_created_at"]; ok {
+		doc.Metadata["knowledge_item_created_at"] = createdAt
+	}
+	if err := kb.storage.Update(ctx, doc.Id, doc); err != nil {
+		return "", err
+	}
+	return UpsertUpdated, nil
+}
+
+// isDocumentNotFound tells whether the storage failed for the document is not stored
+func isDocumentNotFound(err error) bool {
+	return errors.Is(err, ErrDocumentNotFound) || errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound)
+}
+
 // Implement KnowledgeManager interface
 func (kb *knowledgeBase) GetItem(ctx context.Context, id document.DocumentId) (KnowledgeItem, error) {
 	doc, err := kb.storage.Get(ctx, id)
@@ -77,6 +124,27 @@ func (kb *knowledgeBase) DeleteItem(ctx context.Context, id document.DocumentId)
 	return kb.storage.Delete(ctx, id)
 }
 
+// ListItems lists all items when the underlying storage supports enumeration
+func (kb *knowledgeBase) ListItems(ctx context.Context) ([]KnowledgeItem, error) {
+	lister, ok := kb.storage.(DocumentLister)
+	if !ok {
+		return nil, errors.Errorf(ErrorCodeListNotSupported,
+			"storage %T does not support listing documents", kb.storage)
+	}
+	docs, err := lister.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var items []KnowledgeItem
+	for _, doc := range docs {
+		item := kb.factory.FromDocument(doc)
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
 func (kb *knowledgeBase) GetMetadata() *KnowledgeBaseMetadata {
 	kb.mu.RLock()
 	defer kb.mu.RUnlock()
@@ -8,4 +8,19 @@ var (
 		Name:           "NoKnowledgeBaseFound",
 		DefaultMessage: "Failed to found knowledge base",
 	}
+	ErrorCodeListNotSupported = errors.ErrorCode{
+		Code:           20101,
+		Name:           "ListNotSupported",
+		DefaultMessage: "Knowledge storage does not support listing documents",
+	}
+	ErrorCodeInvalidGrepPattern = errors.ErrorCode{
+		Code:           20102,
+		Name:           "InvalidGrepPattern",
+		DefaultMessage: "Invalid grep pattern",
+	}
+	ErrorCodeRerankFailed = errors.ErrorCode{
+		Code:           20103,
+		Name:           "RerankFailed",
+		DefaultMessage: "Failed to rerank documents",
+	}
 )
@@ -0,0 +1,255 @@
+package knowledge
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+)
+
+// MetadataKeySource is the document metadata key under which federated search
+// results report the name of the knowledge base they came from
+const MetadataKeySource = "knowledge_source"
+
+// ScoreNormalizer maps the raw scores returned by one knowledge base onto a
+// common [0, 1] scale, so results of different backends can be compared
+type ScoreNormalizer func(scores []float32) []float32
+
+// ClampNormalizer keeps scores as they are, clamped into [0, 1].
+// It suits backends that already report similarities in that range.
+func ClampNormalizer(scores []float32) []float32 {
+	normalized := make([]float32, len(scores))
+	for idx, score := range scores {
+		normalized[idx] = min(max(score, 0), 1)
+	}
+	return normalized
+}
+
+// MinMaxNormalizer rescales scores so the best result of a knowledge base gets 1
+// and the worst gets 0. A single result, or results with equal scores, get 1.
+func MinMaxNormalizer(scores []float32) []float32 {
+	normalized := make([]float32, len(scores))
+	if len(scores) == 0 {
+		return normalized
+	}
+	lowest, highest := scores[0], scores[0]
+	for _, score := range scores[1:] {
+		lowest = min(lowest, score)
+		highest = max(highest, score)
+	}
+	for idx, score := range scores {
+		if highest == lowest {
+			normalized[idx] = 1
+		} else {
+			normalized[idx] = (score - lowest) / (highest - lowest)
+		}
+	}
+	return normalized
+}
+
+// FederatedOption configures a FederatedKnowledgeBase
+type FederatedOption func(*FederatedKnowledgeBase)
+
+// WithScoreNormalizer sets the normalizer applied to the results of each knowledge base
+func WithScoreNormalizer(normalizer ScoreNormalizer) FederatedOption {
+	return func(f *FederatedKnowledgeBase) {
+		f.normalizer = normalizer
+	}
+}
+
+// NewFederatedKnowledgeBase creates a knowledge base that searches all given knowledge bases
+// and ranks their results together. New items are added to the first knowledge base.
+func NewFederatedKnowledgeBase(metadata *KnowledgeBaseMetadata,
+	knowledgeBases []KnowledgeBase, opts ...FederatedOption) (*FederatedKnowledgeBase, error) {
+	if len(knowledgeBases) == 0 {
+		return nil, errors.Errorf(ErrorCodeNoKnowledgeBaseFound,
+			"federated knowledge base requires at least one knowledge base")
+	}
+	federated := &FederatedKnowledgeBase{
+		metadata:       metadata,
+		knowledgeBases: knowledgeBases,
+		normalizer:     ClampNormalizer,
+	}
+	for _, opt := range opts {
+		opt(federated)
+	}
+	return federated, nil
+}
+
+var _ KnowledgeBase = &FederatedKnowledgeBase{}
+
+// FederatedKnowledgeBase fans searches out to several knowledge bases, possibly backed by
+// different storages, and returns the global top results ranked by normalized score.
+type FederatedKnowledgeBase struct {
+	mu sync.RWMutex
+
+	metadata       *KnowledgeBaseMetadata
+	knowledgeBases []KnowledgeBase
+	normalizer     ScoreNormalizer
+}
+
+// KnowledgeBases returns the federated knowledge bases
+func (f *FederatedKnowledgeBase) KnowledgeBases() []KnowledgeBase {
+	return f.knowledgeBases
+}
+
+// Search queries every knowledge base concurrently and merges the results.
+// Failing knowledge bases are skipped, an error is returned only if all of them fail.
+func (f *FederatedKnowledgeBase) Search(ctx context.Context, query string, opts ...SearchOption) ([]KnowledgeItem, error) {
+	options := &SearchOptions{
+		MaxResults: 10,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	results := make([][]KnowledgeItem, len(f.knowledgeBases))
+	errs := make([]error, len(f.knowledgeBases))
+
+	var wg sync.WaitGroup
+	for idx, kb := range f.knowledgeBases {
+		wg.Add(1)
+		go func(idx int, kb KnowledgeBase) {
+			defer wg.Done()
+			results[idx], errs[idx] = kb.Search(ctx, query, opts...)
+		}(idx, kb)
+	}
+	wg.Wait()
+
+	var merged []*federatedItem
+	failed := 0
+	for idx, kb := range f.knowledgeBases {
+		if errs[idx] != nil {
+			failed++
+			continue
+		}
+		merged = append(merged, f.normalize(kb, results[idx])...)
+	}
+	if failed == len(f.knowledgeBases) {
+		return nil, errs[0]
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].score > merged[j].score
+	})
+
+	// the same document may be stored in several knowledge bases, keep the best one
+	seen := make(map[document.DocumentId]bool)
+	var items []KnowledgeItem
+	for _, item := range merged {
+		if seen[item.GetId()] {
+			continue
+		}
+		seen[item.GetId()] = true
+		items = append(items, item)
+		if options.MaxResults > 0 && len(items) >= options.MaxResults {
+			break
+		}
+	}
+	return items, nil
+}
+
+// normalize scores the results of one knowledge base.
+// Results without a reported score are scored by their rank.
+func (f *FederatedKnowledgeBase) normalize(kb KnowledgeBase, items []KnowledgeItem) []*federatedItem {
+	scores := make([]float32, len(items))
+	for idx, item := range items {
+		score, ok := GetScore(item.ToDocument())
+		if !ok {
+			score = 1 - float32(idx)/float32(len(items))
+		}
+		scores[idx] = score
+	}
+
+	var source string
+	if metadata := kb.GetMetadata(); metadata != nil {
+		source = metadata.Name
+	}
+
+	normalized := f.normalizer(scores)
+	result := make([]*federatedItem, len(items))
+	for idx, item := range items {
+		result[idx] = &federatedItem{
+			KnowledgeItem: item,
+			score:         normalized[idx],
+			source:        source,
+		}
+	}
+	return result
+}
+
+// AddItem adds the item to the first knowledge base
+func (f *FederatedKnowledgeBase) AddItem(ctx context.Context, item KnowledgeItem, opts ...AddOption) error {
+	return f.knowledgeBases[0].AddItem(ctx, item, opts...)
+}
+
+// UpdateItem updates the item in the knowledge base that holds it
+func (f *FederatedKnowledgeBase) UpdateItem(ctx context.Context, id document.DocumentId, item KnowledgeItem, opts ...UpdateOption) error {
+	kb, _, err := f.findItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	return kb.UpdateItem(ctx, id, item, opts...)
+}
+
+// GetItem gets the item from the first knowledge base that holds it
+func (f *FederatedKnowledgeBase) GetItem(ctx context.Context, id document.DocumentId) (KnowledgeItem, error) {
+	_, item, err := f.findItem(ctx, id)
+	return item, err
+}
+
+// DeleteItem deletes the item from the knowledge base that holds it
+func (f *FederatedKnowledgeBase) DeleteItem(ctx context.Context, id document.DocumentId) error {
+	kb, _, err := f.findItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	return kb.DeleteItem(ctx, id)
+}
+
+// findItem finds the first knowledge base holding the item
+func (f *FederatedKnowledgeBase) findItem(ctx context.Context, id document.DocumentId) (KnowledgeBase, KnowledgeItem, error) {
+	var lastErr error
+	for _, kb := range f.knowledgeBases {
+		item, err := kb.GetItem(ctx, id)
+		if err == nil && item != nil {
+			return kb, item, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = ErrDocumentNotFound
+	}
+	return nil, nil, lastErr
+}
+
+func (f *FederatedKnowledgeBase) GetMetadata() *KnowledgeBaseMetadata {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.metadata
+}
+
+func (f *FederatedKnowledgeBase) UpdateMetadata(metadata *KnowledgeBaseMetadata) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metadata = metadata
+}
+
+// federatedItem wraps a search result with its normalized score and source knowledge base
+type federatedItem struct {
+	KnowledgeItem
+
+	score  float32
+	source string
+}
+
+// ToDocument converts to Document, reporting the normalized score and the source knowledge base
+func (i *federatedItem) ToDocument() *document.Document {
+	doc := withScore(i.KnowledgeItem.ToDocument(), i.score)
+	if i.source != "" {
+		doc.Metadata[MetadataKeySource] = i.source
+	}
+	return doc
+}
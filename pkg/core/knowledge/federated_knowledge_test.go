@@ -0,0 +1,181 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFederatedTestKnowledgeBase(t *testing.T, name string, docs ...*document.Document) KnowledgeBase {
+	kb := NewKnowledgeBase(NewInMemoryStorage(), NewBaseKnowledgeItemFactory(),
+		NewKnowledgeBaseMetadata(name, name+" knowledge base", []string{name}, nil))
+	for _, doc := range docs {
+		require.NoError(t, kb.AddItem(context.Background(), NewKnowledgeItem(doc)))
+	}
+	return kb
+}
+
+func newFederatedTestKnowledgeBases(t *testing.T) []KnowledgeBase {
+	animals := newFederatedTestKnowledgeBase(t, "animals",
+		document.NewDocument("animal_1", "pythons", nil, "Pythons are large snakes"),
+		document.NewDocument("animal_2", "garden", nil, "Garden snakes are harmless"),
+	)
+	programming := newFederatedTestKnowledgeBase(t, "programming",
+		document.NewDocument("prog_1", "python", nil, "Python is a programming language"),
+		document.NewDocument("prog_2", "logo", nil, "The Python language logo shows two snakes"),
+		document.NewDocument("prog_3", "go", nil, "Go is a compiled language"),
+	)
+	return []KnowledgeBase{animals, programming}
+}
+
+func itemIds(items []KnowledgeItem) []document.DocumentId {
+	var ids []document.DocumentId
+	for _, item := range items {
+		ids = append(ids, item.GetId())
+	}
+	return ids
+}
+
+func TestFederatedKnowledgeBase_GlobalRanking(t *testing.T) {
+	federated, err := NewFederatedKnowledgeBase(
+		NewKnowledgeBaseMetadata("federated", "all domains", nil, nil),
+		newFederatedTestKnowledgeBases(t))
+	require.NoError(t, err)
+
+	items, err := federated.Search(context.Background(), "python snakes language", WithMaxResults(3))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"prog_2", "animal_1", "prog_1"}, itemIds(items))
+
+	doc := items[0].ToDocument()
+	score, ok := GetScore(doc)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, score, 1e-6)
+	assert.Equal(t, "programming", doc.Metadata[MetadataKeySource])
+	assert.Equal(t, "The Python language logo shows two snakes", doc.Content)
+
+	doc = items[1].ToDocument()
+	score, ok = GetScore(doc)
+	require.True(t, ok)
+	assert.InDelta(t, 2.0/3.0, score, 1e-6)
+	assert.Equal(t, "animals", doc.Metadata[MetadataKeySource])
+
+	// the global top-k may come from a single knowledge base
+	items, err = federated.Search(context.Background(), "compiled language", WithMaxResults(1))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"prog_3"}, itemIds(items))
+
+	items, err = federated.Search(context.Background(), "harmless garden", WithMaxResults(1))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"animal_2"}, itemIds(items))
+}
+
+func TestFederatedKnowledgeBase_Normalizers(t *testing.T) {
+	assert.Equal(t, []float32{0, 0.5, 1}, ClampNormalizer([]float32{-1, 0.5, 3}))
+	assert.Equal(t, []float32{1, 0.5, 0}, MinMaxNormalizer([]float32{4, 3, 2}))
+	assert.Equal(t, []float32{1, 1}, MinMaxNormalizer([]float32{0.2, 0.2}))
+	assert.Empty(t, MinMaxNormalizer(nil))
+
+	// with min-max normalization the best result of each knowledge base ties
+	federated, err := NewFederatedKnowledgeBase(nil, newFederatedTestKnowledgeBases(t),
+		WithScoreNormalizer(MinMaxNormalizer))
+	require.NoError(t, err)
+
+	items, err := federated.Search(context.Background(), "python snakes language", WithMaxResults(2))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"animal_1", "prog_2"}, itemIds(items))
+}
+
+func TestFederatedKnowledgeBase_DuplicatesAndFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shared := document.NewDocument("shared", "shared", nil, "python snakes")
+	first := newFederatedTestKnowledgeBase(t, "first", shared)
+	second := newFederatedTestKnowledgeBase(t, "second", shared,
+		document.NewDocument("other", "other", nil, "python"))
+
+	storage := NewMockKnowledgeStorage(ctrl)
+	storage.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("backend unavailable")).AnyTimes()
+	broken := NewKnowledgeBase(storage, NewBaseKnowledgeItemFactory(), nil)
+
+	federated, err := NewFederatedKnowledgeBase(nil, []KnowledgeBase{first, broken, second})
+	require.NoError(t, err)
+
+	items, err := federated.Search(context.Background(), "python snakes")
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"shared", "other"}, itemIds(items))
+	assert.Equal(t, "first", items[0].ToDocument().Metadata[MetadataKeySource])
+
+	// all knowledge bases failing is an error
+	federated, err = NewFederatedKnowledgeBase(nil, []KnowledgeBase{broken})
+	require.NoError(t, err)
+	_, err = federated.Search(context.Background(), "python")
+	assert.Error(t, err)
+
+	_, err = NewFederatedKnowledgeBase(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestFederatedKnowledgeBase_RankFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// storages that do not report scores are ranked by position
+	storage := NewMockKnowledgeStorage(ctrl)
+	storage.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*document.Document{
+		document.NewDocument("unscored_1", "unscored_1", nil, "first"),
+		document.NewDocument("unscored_2", "unscored_2", nil, "second"),
+	}, nil)
+	unscored := NewKnowledgeBase(storage, NewBaseKnowledgeItemFactory(), nil)
+
+	federated, err := NewFederatedKnowledgeBase(nil, []KnowledgeBase{unscored})
+	require.NoError(t, err)
+
+	items, err := federated.Search(context.Background(), "anything")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	score, _ := GetScore(items[0].ToDocument())
+	assert.InDelta(t, 1.0, score, 1e-6)
+	score, _ = GetScore(items[1].ToDocument())
+	assert.InDelta(t, 0.5, score, 1e-6)
+}
+
+func TestFederatedKnowledgeBase_Management(t *testing.T) {
+	ctx := context.Background()
+	kbs := newFederatedTestKnowledgeBases(t)
+	federated, err := NewFederatedKnowledgeBase(nil, kbs)
+	require.NoError(t, err)
+
+	// items are added to the first knowledge base
+	require.NoError(t, federated.AddItem(ctx,
+		NewKnowledgeItem(document.NewDocument("animal_3", "cats", nil, "Cats purr"))))
+	_, err = kbs[0].GetItem(ctx, "animal_3")
+	require.NoError(t, err)
+
+	// items are found in whichever knowledge base holds them
+	item, err := federated.GetItem(ctx, "prog_3")
+	require.NoError(t, err)
+	assert.Equal(t, "Go is a compiled language", item.ToDocument().Content)
+
+	require.NoError(t, federated.UpdateItem(ctx, "prog_3",
+		NewKnowledgeItem(document.NewDocument("prog_3", "go", nil, "Go has goroutines"))))
+	item, err = kbs[1].GetItem(ctx, "prog_3")
+	require.NoError(t, err)
+	assert.Equal(t, "Go has goroutines", item.ToDocument().Content)
+
+	require.NoError(t, federated.DeleteItem(ctx, "prog_3"))
+	_, err = federated.GetItem(ctx, "prog_3")
+	assert.ErrorIs(t, err, ErrDocumentNotFound)
+	assert.ErrorIs(t, federated.DeleteItem(ctx, "prog_3"), ErrDocumentNotFound)
+
+	metadata := NewKnowledgeBaseMetadata("renamed", "", nil, nil)
+	federated.UpdateMetadata(metadata)
+	assert.Equal(t, metadata, federated.GetMetadata())
+	assert.Len(t, federated.KnowledgeBases(), 2)
+}
@@ -0,0 +1,223 @@
+package knowledge
+
+import (
+	"context"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	KNOWLEDGE_GREP_TOOL = "ag_knowledge_grep"
+
+	defaultGrepMaxResults   = 10
+	defaultGrepMaxSnippets  = 5
+	defaultGrepContextChars = 40
+)
+
+var _ tools.Tool = &GrepTool{}
+
+func IsKnowledgeGrepTool(toolName string) bool {
+	return toolName == KNOWLEDGE_GREP_TOOL
+}
+
+// NewGrepTool creates a tool that runs a regex over the content of the documents
+// stored in the knowledge base, bypassing the embedding path.
+//
+// The knowledge base must be able to enumerate its items (see KnowledgeLister),
+// which requires a storage implementing DocumentLister. VectorDBStorage lists the
+// documents of the vector databases implementing vectordb.DocumentLister, the
+// other backends are reported by Call with ErrorCodeListNotSupported.
+func NewGrepTool(kb KnowledgeBase) *GrepTool {
+	return &GrepTool{kb: kb}
+}
+
+// GrepTool searches knowledge base documents by regular expression
+type GrepTool struct {
+	kb KnowledgeBase
+}
+
+// GrepParams are the parameters of the grep tool
+type GrepParams struct {
+	Pattern      string
+	IgnoreCase   bool
+	MaxResults   int
+	MaxSnippets  int
+	ContextChars int
+}
+
+// GrepMatch is a document matching the grep pattern
+type GrepMatch struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	MatchCount int      `json:"match_count"`
+	Snippets   []string `json:"snippets"`
+}
+
+func (g *GrepTool) toolName() string {
+	return KNOWLEDGE_GREP_TOOL
+}
+
+func (g *GrepTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: g.toolName(),
+		Description: "Search the content of knowledge base documents with a regular expression " +
+			"(exact matching, e.g. error codes or identifiers). Returns matching documents with the matched snippets",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"pattern": {
+					Type:        llms.TypeString,
+					Description: "Regular expression (RE2 syntax) to search for",
+				},
+				"ignore_case": {
+					Type:        llms.TypeBoolean,
+					Description: "Match case-insensitively (default: false)",
+				},
+				"max_results": {
+					Type:        llms.TypeInteger,
+					Description: "Maximum number of documents to return (default: 10)",
+				},
+				"max_snippets": {
+					Type:        llms.TypeInteger,
+					Description: "Maximum number of snippets per document (default: 5)",
+				},
+				"context_chars": {
+					Type:        llms.TypeInteger,
+					Description: "Number of characters of context around each match (default: 40)",
+				},
+			},
+			Required: []string{"pattern"},
+		},
+	}
+}
+
+func (g *GrepTool) Call(ctx context.Context, toolCall *llms.ToolCall) (*llms.ToolCallResult, error) {
+	params := g.makeGrepParams(toolCall)
+
+	matches, err := g.Grep(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: toolCall.ToolCallId,
+		Name:       g.toolName(),
+		Result: map[string]any{
+			"matches": matches,
+			"count":   len(matches),
+		},
+	}, nil
+}
+
+// Grep scans the knowledge base items and returns the documents matching the pattern
+func (g *GrepTool) Grep(ctx context.Context, params *GrepParams) ([]*GrepMatch, error) {
+	if params.Pattern == "" {
+		return nil, errors.Errorf(ErrorCodeInvalidGrepPattern, "pattern is required")
+	}
+	expr := params.Pattern
+	if params.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, errors.Errorf(ErrorCodeInvalidGrepPattern,
+			"invalid pattern %q: %v", params.Pattern, err)
+	}
+
+	lister, ok := g.kb.(KnowledgeLister)
+	if !ok {
+		return nil, errors.Errorf(ErrorCodeListNotSupported,
+			"knowledge base %T does not support listing items", g.kb)
+	}
+	items, err := lister.ListItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*GrepMatch
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		doc := item.ToDocument()
+		if doc == nil || doc.Content == "" {
+			continue
+		}
+		locs := re.FindAllStringIndex(doc.Content, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		match := &GrepMatch{
+			Id:         string(doc.Id),
+			Name:       doc.Name,
+			MatchCount: len(locs),
+		}
+		for idx, loc := range locs {
+			if params.MaxSnippets > 0 && idx >= params.MaxSnippets {
+				break
+			}
+			match.Snippets = append(match.Snippets,
+				makeSnippet(doc.Content, loc[0], loc[1], params.ContextChars))
+		}
+		matches = append(matches, match)
+		if params.MaxResults > 0 && len(matches) >= params.MaxResults {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func (g *GrepTool) makeGrepParams(toolCall *llms.ToolCall) *GrepParams {
+	params := &GrepParams{
+		MaxResults:   defaultGrepMaxResults,
+		MaxSnippets:  defaultGrepMaxSnippets,
+		ContextChars: defaultGrepContextChars,
+	}
+
+	if toolCall.Arguments != nil {
+		if pattern, ok := toolCall.Arguments["pattern"].(string); ok {
+			params.Pattern = pattern
+		}
+		if ignoreCase, ok := toolCall.Arguments["ignore_case"].(bool); ok {
+			params.IgnoreCase = ignoreCase
+		}
+		if maxResults, ok := toolCall.Arguments["max_results"].(float64); ok {
+			params.MaxResults = int(maxResults)
+		}
+		if maxSnippets, ok := toolCall.Arguments["max_snippets"].(float64); ok {
+			params.MaxSnippets = int(maxSnippets)
+		}
+		if contextChars, ok := toolCall.Arguments["context_chars"].(float64); ok && contextChars >= 0 {
+			params.ContextChars = int(contextChars)
+		}
+	}
+
+	return params
+}
+
+// makeSnippet returns the match with up to contextChars runes on each side
+func makeSnippet(content string, start, end, contextChars int) string {
+	from := start
+	for n := 0; n < contextChars && from > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(content[:from])
+		from -= size
+	}
+	to := end
+	for n := 0; n < contextChars && to < len(content); n++ {
+		_, size := utf8.DecodeRuneInString(content[to:])
+		to += size
+	}
+
+	snippet := content[from:to]
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(content) {
+		snippet = snippet + "..."
+	}
+	return snippet
+}
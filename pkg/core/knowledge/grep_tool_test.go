@@ -0,0 +1,135 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGrepTestKnowledgeBase(t *testing.T) KnowledgeBase {
+	kb := NewKnowledgeBase(NewInMemoryStorage(), NewBaseKnowledgeItemFactory(),
+		NewKnowledgeBaseMetadata("grep_kb", "grep test knowledge base", nil, nil))
+
+	docs := []*document.Document{
+		document.NewDocument("doc_1", "errors", nil,
+			"The request failed with ERR-1042 after the upstream timed out."),
+		document.NewDocument("doc_2", "runbook", nil,
+			"If you see err-1042 restart the worker. ERR-2001 means the disk is full."),
+		document.NewDocument("doc_3", "faq", nil,
+			"Nothing relevant here."),
+	}
+	for _, doc := range docs {
+		require.NoError(t, kb.AddItem(context.Background(), NewKnowledgeItem(doc)))
+	}
+	return kb
+}
+
+func TestGrepTool_Descriptor(t *testing.T) {
+	tool := NewGrepTool(newGrepTestKnowledgeBase(t))
+
+	descriptor := tool.Descriptor()
+	assert.Equal(t, KNOWLEDGE_GREP_TOOL, descriptor.Name)
+	assert.True(t, IsKnowledgeGrepTool(descriptor.Name))
+	assert.Equal(t, []string{"pattern"}, descriptor.Parameters.Required)
+}
+
+func TestGrepTool_Call(t *testing.T) {
+	tool := NewGrepTool(newGrepTestKnowledgeBase(t))
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "grep_1",
+		Name:       KNOWLEDGE_GREP_TOOL,
+		Arguments: map[string]any{
+			"pattern":       `ERR-\d+`,
+			"context_chars": 5.0,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "grep_1", result.ToolCallId)
+	assert.Equal(t, 2, result.Result["count"])
+
+	matches := result.Result["matches"].([]*GrepMatch)
+	require.Len(t, matches, 2)
+
+	assert.Equal(t, "doc_1", matches[0].Id)
+	assert.Equal(t, 1, matches[0].MatchCount)
+	assert.Equal(t, []string{"...with ERR-1042 afte..."}, matches[0].Snippets)
+
+	// case-sensitive by default: only ERR-2001 matches in doc_2
+	assert.Equal(t, "doc_2", matches[1].Id)
+	assert.Equal(t, 1, matches[1].MatchCount)
+	assert.Equal(t, []string{"...ker. ERR-2001 mean..."}, matches[1].Snippets)
+}
+
+func TestGrepTool_IgnoreCaseAndLimits(t *testing.T) {
+	tool := NewGrepTool(newGrepTestKnowledgeBase(t))
+
+	matches, err := tool.Grep(context.Background(), &GrepParams{
+		Pattern:      `err-1042`,
+		IgnoreCase:   true,
+		MaxResults:   10,
+		MaxSnippets:  5,
+		ContextChars: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, []string{"...ERR-1042..."}, matches[0].Snippets)
+	assert.Equal(t, []string{"...err-1042..."}, matches[1].Snippets)
+
+	matches, err = tool.Grep(context.Background(), &GrepParams{
+		Pattern:     `(?i)err-\d+`,
+		MaxResults:  1,
+		MaxSnippets: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "doc_1", matches[0].Id)
+
+	matches, err = tool.Grep(context.Background(), &GrepParams{Pattern: `no-such-code`})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestGrepTool_MultipleSnippets(t *testing.T) {
+	tool := NewGrepTool(newGrepTestKnowledgeBase(t))
+
+	matches, err := tool.Grep(context.Background(), &GrepParams{
+		Pattern:      `(?i)err-\d+`,
+		MaxSnippets:  1,
+		ContextChars: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, 2, matches[1].MatchCount)
+	assert.Equal(t, []string{"...err-1042..."}, matches[1].Snippets)
+}
+
+func TestGrepTool_Errors(t *testing.T) {
+	tool := NewGrepTool(newGrepTestKnowledgeBase(t))
+
+	_, err := tool.Grep(context.Background(), &GrepParams{Pattern: `(`})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pattern")
+
+	_, err = tool.Grep(context.Background(), &GrepParams{})
+	assert.Error(t, err)
+
+	// storages that can not enumerate documents are not supported
+	kb := NewKnowledgeBase(NewMockKnowledgeStorage(nil), NewBaseKnowledgeItemFactory(), nil)
+	_, err = NewGrepTool(kb).Grep(context.Background(), &GrepParams{Pattern: "x"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support listing")
+}
+
+func TestMakeSnippet(t *testing.T) {
+	content := "héllo wörld"
+	// match "wörld"
+	start := len("héllo ")
+	snippet := makeSnippet(content, start, len(content), 2)
+	assert.Equal(t, "...o wörld", snippet)
+	assert.Equal(t, content, makeSnippet(content, 0, len(content), 10))
+}
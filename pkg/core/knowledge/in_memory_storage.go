@@ -0,0 +1,143 @@
+package knowledge
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/support/document"
+)
+
+var _ KnowledgeStorage = &InMemoryStorage{}
+var _ DocumentLister = &InMemoryStorage{}
+
+// NewInMemoryStorage creates an in-memory knowledge storage
+func NewInMemoryStorage() *InMemoryStorage {
+	return &InMemoryStorage{
+		docs: make(map[document.DocumentId]*document.Document),
+	}
+}
+
+// InMemoryStorage keeps documents in memory, Search is a simple keyword match.
+// It is mainly intended for tests and small, local knowledge bases.
+type InMemoryStorage struct {
+	mu sync.RWMutex
+
+	docs  map[document.DocumentId]*document.Document
+	order []document.DocumentId
+}
+
+func (s *InMemoryStorage) Add(ctx context.Context, doc *document.Document, opts ...AddOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.docs[doc.Id]; !exists {
+		s.order = append(s.order, doc.Id)
+	}
+	s.docs[doc.Id] = doc
+	return nil
+}
+
+func (s *InMemoryStorage) Update(ctx context.Context, id document.DocumentId, doc *document.Document, opts ...UpdateOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.docs[id]; !exists {
+		return ErrDocumentNotFound
+	}
+	s.docs[id] = doc
+	return nil
+}
+
+func (s *InMemoryStorage) Get(ctx context.Context, id document.DocumentId) (*document.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, exists := s.docs[id]
+	if !exists {
+		return nil, ErrDocumentNotFound
+	}
+	return doc, nil
+}
+
+func (s *InMemoryStorage) Delete(ctx context.Context, id document.DocumentId) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.docs[id]; !exists {
+		return ErrDocumentNotFound
+	}
+	delete(s.docs, id)
+	for idx, docId := range s.order {
+		if docId == id {
+			s.order = append(s.order[:idx], s.order[idx+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Search scores documents by the fraction of query terms contained in the content
+func (s *InMemoryStorage) Search(ctx context.Context, query string, opts ...SearchOption) ([]*document.Document, error) {
+	options := &SearchOptions{
+		MaxResults: 10,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	type scored struct {
+		doc   *document.Document
+		score float32
+	}
+
+	s.mu.RLock()
+	var candidates []scored
+	for _, id := range s.order {
+		doc := s.docs[id]
+		content := strings.ToLower(doc.Content)
+		hits := 0
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				hits++
+			}
+		}
+		score := float32(hits) / float32(len(terms))
+		if hits == 0 || score < options.ScoreThreshold {
+			continue
+		}
+		candidates = append(candidates, scored{doc: doc, score: score})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if options.MaxResults > 0 && len(candidates) > options.MaxResults {
+		candidates = candidates[:options.MaxResults]
+	}
+
+	result := make([]*document.Document, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, withScore(c.doc, c.score))
+	}
+	return result, nil
+}
+
+// List lists all documents in insertion order
+func (s *InMemoryStorage) List(ctx context.Context) ([]*document.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*document.Document, 0, len(s.order))
+	for _, id := range s.order {
+		result = append(result, s.docs[id])
+	}
+	return result, nil
+}
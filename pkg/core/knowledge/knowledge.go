@@ -2,6 +2,8 @@ package knowledge
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/oopslink/agent-go/pkg/commons/utils"
@@ -56,6 +58,35 @@ type KnowledgeStorage interface {
 	Search(ctx context.Context, query string, opts ...SearchOption) ([]*document.Document, error)
 }
 
+// DocumentLister is implemented by storages that can enumerate their stored documents.
+// Vector-only backends that cannot return raw content do not implement it.
+type DocumentLister interface {
+	// List lists all documents in storage
+	List(ctx context.Context) ([]*document.Document, error)
+}
+
+// KnowledgeLister is implemented by knowledge bases that can enumerate their items
+type KnowledgeLister interface {
+	// ListItems lists all items in the knowledge base
+	ListItems(ctx context.Context) ([]KnowledgeItem, error)
+}
+
+// KnowledgeUpserter is implemented by knowledge bases that can ingest items idempotently
+type KnowledgeUpserter interface {
+	// UpsertItem adds the item, updates it when its content changed since it was stored,
+	// and leaves it untouched otherwise, so that unchanged items are not embedded again
+	UpsertItem(ctx context.Context, item KnowledgeItem) (UpsertOutcome, error)
+}
+
+// UpsertOutcome tells what UpsertItem did with an item
+type UpsertOutcome string
+
+const (
+	UpsertAdded     UpsertOutcome = "added"     // The item was not stored
+	UpsertUpdated   UpsertOutcome = "updated"   // The item was stored with another content
+	UpsertUnchanged UpsertOutcome = "unchanged" // The item was stored with the same content
+)
+
 // KnowledgeBase is the complete knowledge base interface, combining retrieval, update and management functionality
 type KnowledgeBase interface {
 	KnowledgeRetriever
@@ -121,6 +152,60 @@ type SearchOptions struct {
 	Filters        any
 }
 
+// MetadataKeyScore is the document metadata key under which storages report
+// the relevance score of a search result
+const MetadataKeyScore = "knowledge_score"
+
+// GetScore gets the relevance score reported for a search result
+func GetScore(doc *document.Document) (float32, bool) {
+	if doc == nil || doc.Metadata == nil {
+		return 0, false
+	}
+	switch score := doc.Metadata[MetadataKeyScore].(type) {
+	case float32:
+		return score, true
+	case float64:
+		return float32(score), true
+	}
+	return 0, false
+}
+
+// withScore returns a shallow copy of the document carrying the score in its metadata
+func withScore(doc *document.Document, score float32) *document.Document {
+	scored := *doc
+	scored.Metadata = make(map[string]any, len(doc.Metadata)+1)
+	for key, value := range doc.Metadata {
+		scored.Metadata[key] = value
+	}
+	scored.Metadata[MetadataKeyScore] = score
+	return &scored
+}
+
+// MetadataKeyContentHash is the document metadata key under which UpsertItem records
+// the content hash of an item, see ContentHash
+const MetadataKeyContentHash = "knowledge_content_hash"
+
+// ContentHash hashes the name, the content and the metadata of the document, leaving out
+// the metadata recorded by the knowledge base itself, e.g. the timestamps of the item
+func ContentHash(doc *document.Document) (string, error) {
+	metadata := make(map[string]any, len(doc.Metadata))
+	for key, value := range doc.Metadata {
+		if strings.HasPrefix(key, "knowledge_") {
+			continue
+		}
+		metadata[key] = value
+	}
+	data, err := json.Marshal(struct {
+		Name     string         `json:"name"`
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	}{doc.Name, doc.Content, metadata})
+	if err != nil {
+		return "", err
+	}
+	return string(document.ContentHashId(string(data))), nil
+}
+
 // GenerateDocumentId generates document ID
 func GenerateDocumentId(name string) document.DocumentId {
 	if name == "" {
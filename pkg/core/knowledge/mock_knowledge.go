@@ -305,6 +305,82 @@ func (mr *MockKnowledgeStorageMockRecorder) Update(ctx, id, doc interface{}, opt
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockKnowledgeStorage)(nil).Update), varargs...)
 }
 
+// MockDocumentLister is a mock of DocumentLister interface.
+type MockDocumentLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentListerMockRecorder
+}
+
+// MockDocumentListerMockRecorder is the mock recorder for MockDocumentLister.
+type MockDocumentListerMockRecorder struct {
+	mock *MockDocumentLister
+}
+
+// NewMockDocumentLister creates a new mock instance.
+func NewMockDocumentLister(ctrl *gomock.Controller) *MockDocumentLister {
+	mock := &MockDocumentLister{ctrl: ctrl}
+	mock.recorder = &MockDocumentListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocumentLister) EXPECT() *MockDocumentListerMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockDocumentLister) List(ctx context.Context) ([]*document.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]*document.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockDocumentListerMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDocumentLister)(nil).List), ctx)
+}
+
+// MockKnowledgeLister is a mock of KnowledgeLister interface.
+type MockKnowledgeLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockKnowledgeListerMockRecorder
+}
+
+// MockKnowledgeListerMockRecorder is the mock recorder for MockKnowledgeLister.
+type MockKnowledgeListerMockRecorder struct {
+	mock *MockKnowledgeLister
+}
+
+// NewMockKnowledgeLister creates a new mock instance.
+func NewMockKnowledgeLister(ctrl *gomock.Controller) *MockKnowledgeLister {
+	mock := &MockKnowledgeLister{ctrl: ctrl}
+	mock.recorder = &MockKnowledgeListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKnowledgeLister) EXPECT() *MockKnowledgeListerMockRecorder {
+	return m.recorder
+}
+
+// ListItems mocks base method.
+func (m *MockKnowledgeLister) ListItems(ctx context.Context) ([]KnowledgeItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListItems", ctx)
+	ret0, _ := ret[0].([]KnowledgeItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListItems indicates an expected call of ListItems.
+func (mr *MockKnowledgeListerMockRecorder) ListItems(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListItems", reflect.TypeOf((*MockKnowledgeLister)(nil).ListItems), ctx)
+}
+
 // MockKnowledgeBase is a mock of KnowledgeBase interface.
 type MockKnowledgeBase struct {
 	ctrl     *gomock.Controller
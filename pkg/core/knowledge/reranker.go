@@ -0,0 +1,162 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+// ScoredDocument is a document retrieved for a query with its relevance score
+type ScoredDocument = vectordb.ScoredDocument
+
+// Reranker reorders the documents retrieved for a query by their relevance to the query, e.g.
+// with a cross-encoder judging the query and each document together rather than their embeddings
+type Reranker interface {
+	// Rerank returns the documents from the most to the least relevant to the query, with their new scores
+	Rerank(ctx context.Context, query string, docs []*ScoredDocument) ([]*ScoredDocument, error)
+}
+
+// ToScoredDocuments converts the items found by a knowledge base search to scored documents,
+// scored by the score reported by the storage, see GetScore
+func ToScoredDocuments(items []KnowledgeItem) []*ScoredDocument {
+	docs := make([]*ScoredDocument, 0, len(items))
+	for _, item := range items {
+		doc := item.ToDocument()
+		if doc == nil {
+			continue
+		}
+		score, _ := GetScore(doc)
+		docs = append(docs, &ScoredDocument{Document: *doc, Score: score})
+	}
+	return docs
+}
+
+const (
+	// DefaultRerankConcurrency is the default number of passages scored at the same time
+	DefaultRerankConcurrency = 4
+
+	llmRerankPrompt = `Rate how relevant the passage is to answer the query, from 0 (irrelevant) to 10 (answers it fully).
+Reply with the number only.
+
+Query: %s
+
+Passage: %s`
+)
+
+var _ Reranker = &LLMReranker{}
+
+var rerankScorePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// LLMRerankerOption configures the LLM reranker
+type LLMRerankerOption func(*LLMReranker)
+
+// WithRerankChatOptions sets the options of the completions scoring the passages, e.g. a low temperature
+func WithRerankChatOptions(opts ...llms.ChatOption) LLMRerankerOption {
+	return func(r *LLMReranker) {
+		r.chatOptions = append(r.chatOptions, opts...)
+	}
+}
+
+// WithRerankConcurrency sets the number of passages scored at the same time
+func WithRerankConcurrency(concurrency int) LLMRerankerOption {
+	return func(r *LLMReranker) {
+		r.concurrency = concurrency
+	}
+}
+
+// NewLLMReranker creates a reranker asking the chat, preferably of a cheap model, to score the
+// relevance of each passage to the query
+func NewLLMReranker(chat llms.Chat, opts ...LLMRerankerOption) *LLMReranker {
+	r := &LLMReranker{
+		chat:        chat,
+		concurrency: DefaultRerankConcurrency,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.concurrency <= 0 {
+		r.concurrency = DefaultRerankConcurrency
+	}
+	return r
+}
+
+// LLMReranker scores the passages with a completion each, the scores are in range [0, 1]
+type LLMReranker struct {
+	chat        llms.Chat
+	chatOptions []llms.ChatOption
+	concurrency int
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []*ScoredDocument) ([]*ScoredDocument, error) {
+	scores := make([]float32, len(docs))
+	errs := make([]error, len(docs))
+	var wg sync.WaitGroup
+
+	// Use a semaphore to limit concurrent completions
+	semaphore := make(chan struct{}, r.concurrency)
+
+	for i, doc := range docs {
+		wg.Add(1)
+		go func(index int, doc *ScoredDocument) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire semaphore
+			defer func() { <-semaphore }() // Release semaphore
+
+			scores[index], errs[index] = r.score(ctx, query, doc)
+		}(i, doc)
+	}
+	wg.Wait()
+
+	reranked := make([]*ScoredDocument, len(docs))
+	for i, doc := range docs {
+		if errs[i] != nil {
+			return nil, errors.Errorf(ErrorCodeRerankFailed,
+				"scoring document %s failed: %s", doc.Id, errs[i].Error())
+		}
+		rescored := *doc
+		rescored.Score = scores[i]
+		reranked[i] = &rescored
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	return reranked, nil
+}
+
+func (r *LLMReranker) score(ctx context.Context, query string, doc *ScoredDocument) (float32, error) {
+	message := llms.NewUserMessage(fmt.Sprintf(llmRerankPrompt, query, doc.Content))
+	responses, err := r.chat.Send(ctx, []*llms.Message{message}, r.chatOptions...)
+	if err != nil {
+		return 0, err
+	}
+
+	var answer strings.Builder
+	for response, err := range responses {
+		if err != nil {
+			return 0, err
+		}
+		for _, part := range response.Parts {
+			if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning {
+				answer.WriteString(textPart.Text)
+			}
+		}
+	}
+
+	match := rerankScorePattern.FindString(answer.String())
+	if match == "" {
+		return 0, fmt.Errorf("no score in the answer %q", answer.String())
+	}
+	score, err := strconv.ParseFloat(match, 32)
+	if err != nil {
+		return 0, err
+	}
+	return float32(min(score, 10) / 10), nil
+}
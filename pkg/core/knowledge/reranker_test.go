@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scoringChat answers the score given to the first passage containing one of its keys
+type scoringChat struct {
+	scores map[string]string
+}
+
+func (c *scoringChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
+	prompt := messages[0].Parts[0].(*llms.TextPart).Text
+	_, passage, _ := strings.Cut(prompt, "Passage: ")
+	for key, score := range c.scores {
+		if strings.Contains(passage, key) {
+			return func(yield func(*llms.ChatResponse, error) bool) {
+				yield(&llms.ChatResponse{Message: *llms.NewAssistantMessage("score", llms.ModelId{}, score)}, nil)
+			}, nil
+		}
+	}
+	return nil, errors.New("chat unavailable")
+}
+
+func newScoredDocuments(contents ...string) []*ScoredDocument {
+	var docs []*ScoredDocument
+	for i, content := range contents {
+		docs = append(docs, &ScoredDocument{
+			Document: document.Document{Id: document.DocumentId(content), Content: content},
+			Score:    1 - float32(i)/10,
+		})
+	}
+	return docs
+}
+
+func TestLLMReranker_Rerank(t *testing.T) {
+	reranker := NewLLMReranker(&scoringChat{scores: map[string]string{
+		"near duplicate": "2",
+		"best passage":   "Score: 9.5",
+		"off topic":      "0",
+		"good passage":   "7",
+	}}, WithRerankConcurrency(2))
+
+	docs := newScoredDocuments("near duplicate", "off topic", "good passage", "best passage")
+	reranked, err := reranker.Rerank(context.Background(), "query", docs)
+	require.NoError(t, err)
+
+	var ids []document.DocumentId
+	var scores []float32
+	for _, doc := range reranked {
+		ids = append(ids, doc.Id)
+		scores = append(scores, doc.Score)
+	}
+	assert.Equal(t, []document.DocumentId{"best passage", "good passage", "near duplicate", "off topic"}, ids)
+	assert.InDeltaSlice(t, []float32{0.95, 0.7, 0.2, 0}, scores, 1e-6)
+	// the input documents are not modified
+	assert.Equal(t, float32(1), docs[0].Score)
+}
+
+func TestLLMReranker_Errors(t *testing.T) {
+	reranker := NewLLMReranker(&scoringChat{scores: map[string]string{"vague": "maybe relevant"}})
+
+	_, err := reranker.Rerank(context.Background(), "query", newScoredDocuments("vague"))
+	require.Error(t, err)
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeRerankFailed))
+	assert.Contains(t, err.Error(), "no score")
+
+	_, err = reranker.Rerank(context.Background(), "query", newScoredDocuments("unknown"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chat unavailable")
+}
+
+func TestToScoredDocuments(t *testing.T) {
+	items := []KnowledgeItem{
+		NewKnowledgeItem(withScore(&document.Document{Id: "scored"}, 0.8)),
+		NewKnowledgeItem(&document.Document{Id: "unscored"}),
+	}
+
+	docs := ToScoredDocuments(items)
+	require.Len(t, docs, 2)
+	assert.Equal(t, document.DocumentId("scored"), docs[0].Id)
+	assert.Equal(t, float32(0.8), docs[0].Score)
+	assert.Equal(t, float32(0), docs[1].Score)
+}
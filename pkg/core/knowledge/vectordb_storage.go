@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/embedder"
 	"github.com/oopslink/agent-go/pkg/support/vectordb"
@@ -15,6 +16,7 @@ var (
 )
 
 var _ KnowledgeStorage = &VectorDBStorage{}
+var _ DocumentLister = &VectorDBStorage{}
 
 // VectorDBStorage is the vector database based storage implementation
 type VectorDBStorage struct {
@@ -43,10 +45,13 @@ func (v *VectorDBStorage) Add(ctx context.Context, doc *document.Document, opts
 	return err
 }
 
+// Update replaces the content, metadata and embedding of the stored document with the id,
+// failing when the document is not stored
 func (v *VectorDBStorage) Update(ctx context.Context, id document.DocumentId, doc *document.Document, opts ...UpdateOption) error {
 	updateOptions := v.makeUpdateOptions(opts...)
-	_, err := v.vectorDB.AddDocuments(ctx, []*document.Document{doc}, updateOptions...)
-	return err
+	updated := *doc
+	updated.Id = id
+	return v.vectorDB.UpdateDocuments(ctx, []*document.Document{&updated}, updateOptions...)
 }
 
 func (v *VectorDBStorage) Search(ctx context.Context, query string, opts ...SearchOption) ([]*document.Document, error) {
@@ -58,7 +63,7 @@ func (v *VectorDBStorage) Search(ctx context.Context, query string, opts ...Sear
 	var result []*document.Document
 	for idx := range list {
 		scoredDoc := list[idx]
-		result = append(result, &scoredDoc.Document)
+		result = append(result, withScore(&scoredDoc.Document, scoredDoc.Score))
 	}
 	return result, nil
 }
@@ -71,6 +76,17 @@ func (v *VectorDBStorage) Delete(ctx context.Context, documentId document.Docume
 	return v.vectorDB.Delete(ctx, documentId)
 }
 
+// List lists the documents of the collection, when the vector database can enumerate them
+// (see vectordb.DocumentLister)
+func (v *VectorDBStorage) List(ctx context.Context) ([]*document.Document, error) {
+	lister, ok := v.vectorDB.(vectordb.DocumentLister)
+	if !ok {
+		return nil, commonerrors.Errorf(ErrorCodeListNotSupported,
+			"vector database %T does not support listing documents", v.vectorDB)
+	}
+	return lister.ListDocuments(ctx, v.Collection)
+}
+
 func (v *VectorDBStorage) makeSearchOptions(opts ...SearchOption) (int, []vectordb.SearchOption) {
 	options := &SearchOptions{
 		MaxResults:     10, // default value
@@ -124,7 +140,7 @@ func (v *VectorDBStorage) makeInsertOptions(opts ...AddOption) []vectordb.Insert
 	return vectordbOpts
 }
 
-func (v *VectorDBStorage) makeUpdateOptions(opts ...UpdateOption) []vectordb.InsertOption {
+func (v *VectorDBStorage) makeUpdateOptions(opts ...UpdateOption) []vectordb.UpdateOption {
 	options := &UpdateOptions{}
 
 	// Apply knowledge update options
@@ -132,12 +148,12 @@ func (v *VectorDBStorage) makeUpdateOptions(opts ...UpdateOption) []vectordb.Ins
 		opt(options)
 	}
 
-	// Convert to vectordb insert options (for update, we use insert with overwrite)
-	vectordbOpts := []vectordb.InsertOption{vectordb.WithInsertCollection(v.Collection)}
+	// Convert to vectordb update options
+	vectordbOpts := []vectordb.UpdateOption{vectordb.WithUpdateCollection(v.Collection)}
 
 	// Set embedder
 	if v.embedder != nil {
-		vectordbOpts = append(vectordbOpts, vectordb.WithInsertEmbedder(v.embedder))
+		vectordbOpts = append(vectordbOpts, vectordb.WithUpdateEmbedder(v.embedder))
 	}
 
 	return vectordbOpts
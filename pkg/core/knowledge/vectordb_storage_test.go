@@ -3,12 +3,15 @@ package knowledge
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/embedder"
 	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"github.com/oopslink/agent-go/pkg/support/vectordb/inmem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,8 +81,8 @@ func TestVectorDBStorage_Update(t *testing.T) {
 		}
 
 		mockVectorDB.EXPECT().
-			AddDocuments(gomock.Any(), gomock.Any(), gomock.Any()).
-			Return([]document.DocumentId{"test_doc_1"}, nil)
+			UpdateDocuments(gomock.Any(), []*document.Document{doc}, gomock.Any()).
+			Return(nil)
 
 		err := storage.Update(context.Background(), doc.Id, doc)
 		assert.NoError(t, err)
@@ -100,8 +103,8 @@ func TestVectorDBStorage_Update(t *testing.T) {
 		}
 
 		mockVectorDB.EXPECT().
-			AddDocuments(gomock.Any(), gomock.Any(), gomock.Any()).
-			Return(nil, errors.New("vector db error"))
+			UpdateDocuments(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(errors.New("vector db error"))
 
 		err := storage.Update(context.Background(), doc.Id, doc)
 		assert.Error(t, err)
@@ -289,8 +292,8 @@ func TestVectorDBStorage_Integration(t *testing.T) {
 			Return(doc, nil)
 
 		mockVectorDB.EXPECT().
-			AddDocuments(gomock.Any(), gomock.Any(), gomock.Any()).
-			Return([]document.DocumentId{"integration_test_doc"}, nil)
+			UpdateDocuments(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil)
 
 		searchResults := []*vectordb.ScoredDocument{
 			{
@@ -337,3 +340,172 @@ func TestVectorDBStorage_Integration(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+// keywordEmbedder embeds texts as counts of a fixed vocabulary
+type keywordEmbedder struct {
+	vocabulary []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i, text := range texts {
+		vector := make(embedder.FloatVector, len(e.vocabulary))
+		for j, word := range e.vocabulary {
+			vector[j] = float64(strings.Count(text, word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func newInMemoryKnowledgeBase() KnowledgeBase {
+	storage := NewVectorDBStorage("knowledge", inmem.New(),
+		&keywordEmbedder{vocabulary: []string{"cat", "dog", "fish"}})
+	return NewKnowledgeBase(storage, NewBaseKnowledgeItemFactory(), nil)
+}
+
+func searchIds(t *testing.T, kb KnowledgeBase, query string) []document.DocumentId {
+	items, err := kb.Search(context.Background(), query, WithScoreThreshold(0.1))
+	require.NoError(t, err)
+	var ids []document.DocumentId
+	for _, item := range items {
+		ids = append(ids, item.GetId())
+	}
+	return ids
+}
+
+func TestVectorDBStorage_AddThenDeleteItem(t *testing.T) {
+	ctx := context.Background()
+	kb := newInMemoryKnowledgeBase()
+
+	require.NoError(t, kb.AddItem(ctx, NewKnowledgeItem(&document.Document{Id: "cats", Content: "cat cat"})))
+	require.NoError(t, kb.AddItem(ctx, NewKnowledgeItem(&document.Document{Id: "pets", Content: "cat and dog"})))
+	assert.Equal(t, []document.DocumentId{"cats", "pets"}, searchIds(t, kb, "cat"))
+
+	require.NoError(t, kb.DeleteItem(ctx, "cats"))
+	assert.Equal(t, []document.DocumentId{"pets"}, searchIds(t, kb, "cat"))
+	_, err := kb.GetItem(ctx, "cats")
+	assert.True(t, commonerrors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+
+	err = kb.DeleteItem(ctx, "cats")
+	assert.True(t, commonerrors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+}
+
+func TestVectorDBStorage_List(t *testing.T) {
+	ctx := context.Background()
+	kb := newInMemoryKnowledgeBase()
+	require.NoError(t, kb.AddItem(ctx, NewKnowledgeItem(&document.Document{Id: "cats", Content: "cat cat"})))
+	require.NoError(t, kb.AddItem(ctx, NewKnowledgeItem(&document.Document{Id: "dogs", Content: "dog ERR-1042"})))
+
+	items, err := kb.(KnowledgeLister).ListItems(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "cat cat", items[0].ToDocument().Content)
+
+	// the grep tool runs over the vector database storage
+	result, err := NewGrepTool(kb).Grep(ctx, &GrepParams{Pattern: `ERR-\d+`})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "dogs", result[0].Id)
+
+	// vector databases that can not enumerate their documents are reported
+	ctrl := gomock.NewController(t)
+	storage := NewVectorDBStorage("knowledge", vectordb.NewMockVectorDB(ctrl), embedder.NewMockEmbedder(ctrl))
+	_, err = storage.List(ctx)
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeListNotSupported))
+}
+
+func TestVectorDBStorage_UpdateItemContent(t *testing.T) {
+	ctx := context.Background()
+	kb := newInMemoryKnowledgeBase()
+
+	require.NoError(t, kb.AddItem(ctx, NewKnowledgeItem(&document.Document{Id: "pet", Content: "cat cat"})))
+	assert.Equal(t, []document.DocumentId{"pet"}, searchIds(t, kb, "cat"))
+	assert.Empty(t, searchIds(t, kb, "fish"))
+
+	// the content and its embedding are replaced
+	err := kb.UpdateItem(ctx, "pet", NewKnowledgeItem(&document.Document{Id: "pet", Content: "fish fish"}))
+	require.NoError(t, err)
+	assert.Empty(t, searchIds(t, kb, "cat"))
+	assert.Equal(t, []document.DocumentId{"pet"}, searchIds(t, kb, "fish"))
+
+	item, err := kb.GetItem(ctx, "pet")
+	require.NoError(t, err)
+	assert.Equal(t, "fish fish", item.ToDocument().Content)
+
+	// the item must exist
+	err = kb.UpdateItem(ctx, "dog", NewKnowledgeItem(&document.Document{Id: "dog", Content: "dog"}))
+	assert.True(t, commonerrors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	assert.Empty(t, searchIds(t, kb, "dog"))
+}
+
+// countingEmbedder counts the texts embedded by the embedder
+type countingEmbedder struct {
+	embedder.Embedder
+	texts []string
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	e.texts = append(e.texts, texts...)
+	return e.Embedder.Embed(ctx, texts)
+}
+
+func TestVectorDBStorage_UpsertItem(t *testing.T) {
+	ctx := context.Background()
+	emb := &countingEmbedder{Embedder: &keywordEmbedder{vocabulary: []string{"cat", "dog", "fish"}}}
+	kb := NewKnowledgeBase(NewVectorDBStorage("knowledge", inmem.New(), emb),
+		NewBaseKnowledgeItemFactory(), nil).(KnowledgeUpserter)
+
+	ingest := func(contents map[document.DocumentId]string) map[document.DocumentId]UpsertOutcome {
+		outcomes := make(map[document.DocumentId]UpsertOutcome)
+		for id, content := range contents {
+			outcome, err := kb.UpsertItem(ctx, NewKnowledgeItem(&document.Document{
+				Id:       id,
+				Content:  content,
+				Metadata: map[string]any{"source": "pets.json"},
+			}))
+			require.NoError(t, err)
+			outcomes[id] = outcome
+		}
+		return outcomes
+	}
+
+	assert.Equal(t, map[document.DocumentId]UpsertOutcome{"cats": UpsertAdded, "dogs": UpsertAdded},
+		ingest(map[document.DocumentId]string{"cats": "cat cat", "dogs": "dog dog"}))
+	assert.ElementsMatch(t, []string{"cat cat", "dog dog"}, emb.texts)
+
+	// the unchanged items are not embedded again, the changed ones are
+	emb.texts = nil
+	assert.Equal(t, map[document.DocumentId]UpsertOutcome{
+		"cats": UpsertUnchanged, "dogs": UpsertUpdated, "fish": UpsertAdded,
+	}, ingest(map[document.DocumentId]string{"cats": "cat cat", "dogs": "dog dog dog", "fish": "fish"}))
+	assert.ElementsMatch(t, []string{"dog dog dog", "fish"}, emb.texts)
+
+	item, err := kb.(KnowledgeBase).GetItem(ctx, "dogs")
+	require.NoError(t, err)
+	assert.Equal(t, "dog dog dog", item.ToDocument().Content)
+	assert.Equal(t, "pets.json", item.ToDocument().Metadata["source"])
+}
+
+func TestContentHash(t *testing.T) {
+	doc := &document.Document{Id: "pet", Name: "pet", Content: "cat", Metadata: map[string]any{"source": "a"}}
+	hash, err := ContentHash(doc)
+	require.NoError(t, err)
+
+	// the metadata of the knowledge base are left out
+	same := *doc
+	same.Metadata = map[string]any{"source": "a", "knowledge_item_created_at": "2024-01-01T00:00:00Z"}
+	sameHash, err := ContentHash(&same)
+	require.NoError(t, err)
+	assert.Equal(t, hash, sameHash)
+
+	for _, other := range []*document.Document{
+		{Id: "pet", Name: "pet", Content: "dog", Metadata: map[string]any{"source": "a"}},
+		{Id: "pet", Name: "pet", Content: "cat", Metadata: map[string]any{"source": "b"}},
+		{Id: "pet", Name: "cat", Content: "cat", Metadata: map[string]any{"source": "a"}},
+	} {
+		otherHash, err := ContentHash(other)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, otherHash)
+	}
+}
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/oopslink/agent-go/pkg/support/journal"
+)
+
+// mcpConnection is the session with an MCP server shared by its tools, connected again when the
+// transport breaks if it knows the server
+type mcpConnection struct {
+	ref               McpServerRef // nil for a session given by the caller, never connected again
+	client            *mcp.Client
+	reconnectAttempts uint
+
+	lock    sync.Mutex
+	session *mcp.ClientSession
+}
+
+func newMcpClient() *mcp.Client {
+	return mcp.NewClient(&mcp.Implementation{
+		Name:    "agent-go-client",
+		Version: "1.0.0",
+	}, nil)
+}
+
+// connect opens a session with the server of the reference
+func connect(ctx context.Context, client *mcp.Client, ref McpServerRef) (*mcp.ClientSession, error) {
+	transport, err := ref.CreateTransport()
+	if err != nil {
+		return nil, commonerrors.Errorf(ErrorCodeCreateMcpToolFailed,
+			"failed to create transport: %s", err.Error())
+	}
+	session, err := client.Connect(ctx, transport)
+	if err != nil {
+		return nil, commonerrors.Errorf(ErrorCodeCreateMcpToolFailed,
+			"failed to connect to MCP server: %s", err.Error())
+	}
+	return session, nil
+}
+
+func (c *mcpConnection) currentSession() *mcp.ClientSession {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.session
+}
+
+// callTool calls the tool, connecting again and sending the call again up to reconnectAttempts
+// times when the transport was broken before the call went out. A call failing once sent is not
+// sent again, the server may have run the tool, e.g. a write the retry would run twice.
+func (c *mcpConnection) callTool(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+	session := c.currentSession()
+	result, err := session.CallTool(ctx, params)
+	if err == nil || c.ref == nil || c.reconnectAttempts == 0 || ctx.Err() != nil || !isUnsent(err) {
+		return result, err
+	}
+
+	journal.Warning("mcp", params.Name, "MCP server disconnected, connecting again", "error", err)
+	return utils.Retry(ctx, func() (*mcp.CallToolResult, error) {
+		session, err = c.reconnect(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		result, err := session.CallTool(ctx, params)
+		if err != nil && !isUnsent(err) {
+			return nil, commonerrors.Permanent(err)
+		}
+		return result, err
+	}, utils.WithMaxTries(c.reconnectAttempts))
+}
+
+// reconnect replaces the broken session with a new one, unless another call did it already
+func (c *mcpConnection) reconnect(ctx context.Context, broken *mcp.ClientSession) (*mcp.ClientSession, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.session != broken {
+		return c.session, nil
+	}
+
+	_ = broken.Close()
+	session, err := connect(ctx, c.client, c.ref)
+	if err != nil {
+		return broken, err
+	}
+	c.session = session
+	return session, nil
+}
+
+func (c *mcpConnection) close() error {
+	session := c.currentSession()
+	if session == nil {
+		return nil
+	}
+	return session.Close()
+}
+
+// isUnsent tells whether the error is a failure of the transport before the call reached the
+// server: the connection was already closed, the server could not be reached or it did not know
+// the session. The failures of the calls in flight, e.g. io.EOF, are not, the tool may have run.
+func isUnsent(err error) bool {
+	// the calls on a closed or broken connection are refused before being written
+	if errors.Is(err, mcp.ErrConnectionClosed) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	// the errors of the streamable HTTP transport are not typed, a server restarted rejects the unknown sessions
+	return strings.Contains(err.Error(), "broken session: 404")
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+var _ McpServerRef = &httpTransportMcpServerRef{}
+
+// HTTPOption configures the connection to a remote MCP server
+type HTTPOption func(*httpTransportMcpServerRef)
+
+// WithHeader adds the header to the requests to the server
+func WithHeader(key, value string) HTTPOption {
+	return func(h *httpTransportMcpServerRef) {
+		h.headers.Set(key, value)
+	}
+}
+
+// WithAuthHeader sets the Authorization header of the requests to the server, e.g. "Bearer <token>"
+func WithAuthHeader(value string) HTTPOption {
+	return WithHeader("Authorization", value)
+}
+
+// WithHTTPClient sets the HTTP client of the requests to the server, http.DefaultClient by default
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(h *httpTransportMcpServerRef) {
+		h.client = client
+	}
+}
+
+// WithLegacySSE connects with the HTTP+SSE transport of the servers predating Streamable HTTP
+func WithLegacySSE() HTTPOption {
+	return func(h *httpTransportMcpServerRef) {
+		h.legacySSE = true
+	}
+}
+
+// WithHTTP creates a reference to a remote MCP server at the URL, reached over Streamable HTTP
+// or, with WithLegacySSE, over HTTP+SSE
+func WithHTTP(url string, opts ...HTTPOption) (*httpTransportMcpServerRef, error) {
+	if url == "" {
+		return nil, errors.Errorf(ErrorCodeCreateMcpToolFailed, "URL cannot be empty")
+	}
+	ref := &httpTransportMcpServerRef{
+		url:     url,
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(ref)
+	}
+	return ref, nil
+}
+
+type httpTransportMcpServerRef struct {
+	url       string
+	headers   http.Header
+	client    *http.Client
+	legacySSE bool
+}
+
+func (h *httpTransportMcpServerRef) CreateTransport() (mcp.Transport, error) {
+	client := h.httpClient()
+	if h.legacySSE {
+		return mcp.NewSSEClientTransport(h.url, &mcp.SSEClientTransportOptions{HTTPClient: client}), nil
+	}
+	return mcp.NewStreamableClientTransport(h.url, &mcp.StreamableClientTransportOptions{HTTPClient: client}), nil
+}
+
+// httpClient returns the client adding the headers to the requests
+func (h *httpTransportMcpServerRef) httpClient() *http.Client {
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(h.headers) == 0 {
+		return client
+	}
+
+	withHeaders := *client
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	withHeaders.Transport = &headerRoundTripper{headers: h.headers, base: base}
+	return &withHeaders
+}
+
+// headerRoundTripper adds the headers to the requests
+type headerRoundTripper struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// httpMcpStub is a remote MCP server with an echo tool, requiring a bearer token, that can restart
+// and lose its sessions
+type httpMcpStub struct {
+	server   *mcp.Server
+	sessions atomic.Int32
+	calls    atomic.Int32
+	// dropNextCall drops the connection of the next tool call once the tool ran
+	dropNextCall atomic.Bool
+
+	lock    sync.Mutex
+	handler http.Handler
+}
+
+func newHttpMcpStub() *httpMcpStub {
+	stub := &httpMcpStub{}
+	server := mcp.NewServer(&mcp.Implementation{Name: "stub", Version: "1.0.0"}, nil)
+	server.AddTool(&mcp.Tool{
+		Name:        "echo",
+		Description: "Echoes the text",
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"text": {Type: "string"}},
+		},
+	}, func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResult, error) {
+		stub.calls.Add(1)
+		text, _ := params.Arguments["text"].(string)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil
+	})
+	stub.server = server
+	stub.restart()
+	return stub
+}
+
+// restart forgets the sessions, as a restarted server would
+func (s *httpMcpStub) restart() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		s.sessions.Add(1)
+		return s.server
+	}, nil)
+}
+
+func (s *httpMcpStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.lock.Lock()
+	handler := s.handler
+	s.lock.Unlock()
+
+	if r.Method == http.MethodPost && s.dropNextCall.Load() {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if strings.Contains(string(body), `"tools/call"`) {
+			s.dropNextCall.Store(false)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			panic(http.ErrAbortHandler)
+		}
+	}
+	handler.ServeHTTP(w, r)
+}
+
+func TestWithHTTP(t *testing.T) {
+	stub := newHttpMcpStub()
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+
+	ref, err := WithHTTP(httpServer.URL, WithAuthHeader("Bearer secret"))
+	if err != nil {
+		t.Fatalf("Failed to create reference: %v", err)
+	}
+	mcpTools, err := WithMcpTools(ref)
+	if err != nil {
+		t.Fatalf("Failed to create MCP tools: %v", err)
+	}
+	defer mcpTools[0].(*McpTool).Close()
+
+	if len(mcpTools) != 1 || mcpTools[0].Descriptor().Name != "echo" {
+		t.Fatalf("Expected the echo tool, got %v", mcpTools)
+	}
+	if stub.sessions.Load() != 1 {
+		t.Errorf("Expected 1 session, got %d", stub.sessions.Load())
+	}
+
+	echo := func(text string) {
+		t.Helper()
+		result, err := mcpTools[0].Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "1", Name: "echo", Arguments: map[string]any{"text": text},
+		})
+		if err != nil {
+			t.Fatalf("Failed to call the tool: %v", err)
+		}
+		content := result.Result["content"].([]string)
+		if len(content) != 1 || content[0] != text {
+			t.Errorf("Expected %q, got %v", text, content)
+		}
+	}
+	echo("hello")
+
+	// the tool connects again once the server lost its session
+	stub.restart()
+	echo("hello again")
+	if stub.sessions.Load() != 2 {
+		t.Errorf("Expected 2 sessions, got %d", stub.sessions.Load())
+	}
+}
+
+func TestWithHTTP_Unauthorized(t *testing.T) {
+	httpServer := httptest.NewServer(newHttpMcpStub())
+	defer httpServer.Close()
+
+	ref, err := WithHTTP(httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to create reference: %v", err)
+	}
+	if _, err := WithMcpTools(ref); err == nil {
+		t.Error("Expected error without the auth header")
+	}
+
+	if _, err := WithHTTP(""); err == nil {
+		t.Error("Expected error for empty URL")
+	}
+}
+
+func TestWithHTTP_NoReconnect(t *testing.T) {
+	stub := newHttpMcpStub()
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+
+	ref, _ := WithHTTP(httpServer.URL, WithAuthHeader("Bearer secret"))
+	mcpTools, err := WithMcpTools(ref, WithReconnectAttempts(0))
+	if err != nil {
+		t.Fatalf("Failed to create MCP tools: %v", err)
+	}
+
+	stub.restart()
+	_, err = mcpTools[0].Call(context.Background(), &llms.ToolCall{Name: "echo", Arguments: map[string]any{"text": "hi"}})
+	if err == nil {
+		t.Error("Expected error once the server lost the session")
+	}
+}
+
+func TestWithHTTP_CallInFlightNotSentAgain(t *testing.T) {
+	stub := newHttpMcpStub()
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+
+	ref, _ := WithHTTP(httpServer.URL, WithAuthHeader("Bearer secret"))
+	mcpTools, err := WithMcpTools(ref)
+	if err != nil {
+		t.Fatalf("Failed to create MCP tools: %v", err)
+	}
+	defer mcpTools[0].(*McpTool).Close()
+	call := func() error {
+		_, err := mcpTools[0].Call(context.Background(), &llms.ToolCall{Name: "echo", Arguments: map[string]any{"text": "hi"}})
+		return err
+	}
+
+	// the connection is lost once the tool ran, the call is not sent again
+	stub.dropNextCall.Store(true)
+	if err := call(); err == nil {
+		t.Error("Expected error once the connection was lost")
+	}
+	if stub.calls.Load() != 1 {
+		t.Errorf("Expected the tool to run once, got %d", stub.calls.Load())
+	}
+
+	// the next call connects again
+	if err := call(); err != nil {
+		t.Fatalf("Failed to call the tool: %v", err)
+	}
+	if stub.calls.Load() != 2 {
+		t.Errorf("Expected the tool to run twice, got %d", stub.calls.Load())
+	}
+}
@@ -2,7 +2,6 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -21,12 +20,8 @@ func StartMcpServer(
 		Version: version,
 	}, nil)
 
-	// Register each tool with the MCP server
-	for _, tool := range toolList {
-		if err := registerToolWithMcpServer(server, tool); err != nil {
-			return err
-		}
-	}
+	// Register the tools with the MCP server
+	AddTools(server, tools.OfTools(toolList...))
 
 	// Run the server with the provided transport in a goroutine
 	go func() {
@@ -38,85 +33,58 @@ func StartMcpServer(
 	return nil
 }
 
-// registerToolWithMcpServer converts a tools.Tool to MCP format and registers it with the server
-func registerToolWithMcpServer(server *mcp.Server, tool tools.Tool) error {
-	descriptor := tool.Descriptor()
-	if descriptor == nil {
-		return nil // Skip tools without descriptors
-	}
-
-	// Convert tool descriptor to MCP tool format
-	mcpTool := &mcp.Tool{
-		Name:        descriptor.Name,
-		Description: descriptor.Description,
-		InputSchema: convertSchemaToMcpSchema(descriptor.Parameters),
+// AddTools registers the tools of the collection with the MCP server, their calls dispatched to the
+// collection, see tools.ToolCollection.Call. The failure of a call is reported as the error result
+// of the call, holding the error message; its result is serialized as JSON, see llms.ToolCallResult.MarshalJson
+func AddTools(server *mcp.Server, collection *tools.ToolCollection) {
+	for _, descriptor := range collection.Descriptors() {
+		if descriptor == nil {
+			continue // Skip tools without descriptors
+		}
+		server.AddTool(&mcp.Tool{
+			Name:        descriptor.Name,
+			Description: descriptor.Description,
+			InputSchema: toolInputSchema(descriptor.Parameters),
+		}, callTool(collection))
 	}
+}
 
-	// Create a handler function that wraps the original tool
-	handler := func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
-		// Convert MCP call to our tool call format
-		var arguments map[string]any
-		if params.Arguments != nil {
-			if argsMap, ok := params.Arguments.(map[string]any); ok {
-				arguments = argsMap
-			} else {
-				// If it's not a map, try to convert to map through JSON
-				if argsBytes, err := json.Marshal(params.Arguments); err == nil {
-					if err := json.Unmarshal(argsBytes, &arguments); err != nil {
-						arguments = make(map[string]any)
-					}
-				} else {
-					arguments = make(map[string]any)
-				}
-			}
-		} else {
-			arguments = make(map[string]any)
+// callTool calls the tool of the collection, converting the MCP call to our tool call format
+func callTool(collection *tools.ToolCollection) mcp.ToolHandler {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResult, error) {
+		arguments := params.Arguments
+		if arguments == nil {
+			arguments = map[string]any{}
 		}
-
-		toolCall := &llms.ToolCall{
+		result, err := collection.Call(ctx, &llms.ToolCall{
 			Name:      params.Name,
 			Arguments: arguments,
-		}
-
-		// Call the original tool
-		result, err := tool.Call(ctx, toolCall)
+		})
 		if err != nil {
 			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					&mcp.TextContent{Text: "Error: " + err.Error()},
-				},
+				Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
 				IsError: true,
-			}, err
+			}, nil
 		}
-
-		// Convert tool result to MCP format
-		var content []mcp.Content
-
-		// Try to extract meaningful content from the result
-		if result != nil && result.Result != nil {
-			// Convert the map result to JSON string for display
-			if resultBytes, err := json.Marshal(result.Result); err == nil {
-				content = append(content, &mcp.TextContent{Text: string(resultBytes)})
-			} else {
-				content = append(content, &mcp.TextContent{Text: "Tool executed successfully"})
-			}
-		} else {
-			content = append(content, &mcp.TextContent{Text: "Tool executed successfully"})
+		if result == nil {
+			result = &llms.ToolCallResult{Result: map[string]any{}}
 		}
-
 		return &mcp.CallToolResult{
-			Content: content,
-			IsError: false,
+			Content: []mcp.Content{&mcp.TextContent{Text: result.MarshalJson()}},
 		}, nil
 	}
+}
 
-	// Register the tool with the MCP server
-	mcp.AddTool(server, mcpTool, handler)
-	return nil
+// toolInputSchema returns the schema of the parameters of a tool, an object without properties for a tool without parameters
+func toolInputSchema(parameters *llms.Schema) *jsonschema.Schema {
+	if parameters == nil {
+		return &jsonschema.Schema{Type: "object"}
+	}
+	return ToMcpSchema(parameters)
 }
 
-// convertSchemaToMcpSchema converts our llms.Schema to MCP jsonschema.Schema
-func convertSchemaToMcpSchema(schema *llms.Schema) *jsonschema.Schema {
+// ToMcpSchema converts our llms.Schema to MCP jsonschema.Schema
+func ToMcpSchema(schema *llms.Schema) *jsonschema.Schema {
 	if schema == nil {
 		return nil
 	}
@@ -137,7 +105,7 @@ func convertSchemaToMcpSchema(schema *llms.Schema) *jsonschema.Schema {
 	if schema.Properties != nil {
 		mcpSchema.Properties = make(map[string]*jsonschema.Schema)
 		for key, propSchema := range schema.Properties {
-			mcpSchema.Properties[key] = convertSchemaToMcpSchema(propSchema)
+			mcpSchema.Properties[key] = ToMcpSchema(propSchema)
 		}
 	}
 
@@ -149,7 +117,7 @@ func convertSchemaToMcpSchema(schema *llms.Schema) *jsonschema.Schema {
 
 	// Convert array items schema
 	if schema.Items != nil {
-		mcpSchema.Items = convertSchemaToMcpSchema(schema.Items)
+		mcpSchema.Items = ToMcpSchema(schema.Items)
 	}
 
 	return mcpSchema
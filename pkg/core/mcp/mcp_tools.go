@@ -26,30 +26,43 @@ type McpServerRef interface {
 	CreateTransport() (mcp.Transport, error)
 }
 
-func WithMcpTools(mcpServerRef McpServerRef) ([]tools.Tool, error) {
-	// Create a new MCP client
-	client := mcp.NewClient(&mcp.Implementation{
-		Name:    "agent-go-client",
-		Version: "1.0.0",
-	}, nil)
-
-	// Create a transport based on the endpoint
-	transport, err := mcpServerRef.CreateTransport()
-	if err != nil {
-		return nil, errors.Errorf(ErrorCodeCreateMcpToolFailed,
-			"failed to create transport: %s", err.Error())
+// DefaultReconnectAttempts is the default number of times the tools connect again to their MCP
+// server when its transport is broken
+const DefaultReconnectAttempts = 3
+
+// McpToolsOption configures the tools of an MCP server
+type McpToolsOption func(*mcpConnection)
+
+// WithReconnectAttempts sets the number of times the tools connect again to their MCP server when
+// its transport is broken, 0 to never connect again. The call is sent again only when it did not
+// go out, e.g. the connection was closed or the server lost the session: a call failing once
+// sent returns its error, as the server may have run the tool, and the next call connects again.
+func WithReconnectAttempts(attempts uint) McpToolsOption {
+	return func(c *mcpConnection) {
+		c.reconnectAttempts = attempts
+	}
+}
+
+// WithMcpTools connects to the MCP server and creates its tools. They connect again to the server
+// when its transport breaks, e.g. a remote server restarting, see WithReconnectAttempts.
+func WithMcpTools(mcpServerRef McpServerRef, opts ...McpToolsOption) ([]tools.Tool, error) {
+	conn := &mcpConnection{
+		ref:               mcpServerRef,
+		client:            newMcpClient(),
+		reconnectAttempts: DefaultReconnectAttempts,
+	}
+	for _, opt := range opts {
+		opt(conn)
 	}
 
 	// Connect to the MCP server
-	ctx := context.Background()
-	session, err := client.Connect(ctx, transport)
+	session, err := connect(context.Background(), conn.client, mcpServerRef)
 	if err != nil {
-		return nil, errors.Errorf(ErrorCodeCreateMcpToolFailed,
-			"failed to connect to MCP server: %s", err.Error())
+		return nil, err
 	}
+	conn.session = session
 
-	// Use the session-based function to create tools
-	mcpTools, err := WithMcpToolsFromSession(session)
+	mcpTools, err := toolsOf(conn)
 	if err != nil {
 		session.Close()
 		return nil, err
@@ -64,10 +77,14 @@ func WithMcpToolsFromSession(session *mcp.ClientSession) ([]tools.Tool, error) {
 	if session == nil {
 		return nil, errors.Errorf(ErrorCodeCreateMcpToolFailed, "session cannot be nil")
 	}
+	return toolsOf(&mcpConnection{session: session})
+}
 
+// toolsOf lists the tools of the MCP server of the connection
+func toolsOf(conn *mcpConnection) ([]tools.Tool, error) {
 	// List available tools from the MCP server
 	ctx := context.Background()
-	toolsResult, err := session.ListTools(ctx, &mcp.ListToolsParams{})
+	toolsResult, err := conn.currentSession().ListTools(ctx, &mcp.ListToolsParams{})
 	if err != nil {
 		return nil, errors.Errorf(ErrorCodeCreateMcpToolFailed,
 			"failed to list tools from MCP server: %s", err.Error())
@@ -77,11 +94,13 @@ func WithMcpToolsFromSession(session *mcp.ClientSession) ([]tools.Tool, error) {
 	var mcpTools []tools.Tool
 	for _, toolDescription := range toolsResult.Tools {
 		descriptor := convertMcpToolToDescriptor(toolDescription)
-		mcpTool, err := newMcpTool(descriptor, session)
-		if err != nil {
+		if descriptor == nil {
 			continue // Skip tools that can't be converted
 		}
-		mcpTools = append(mcpTools, mcpTool)
+		mcpTools = append(mcpTools, &McpTool{
+			descriptor: descriptor,
+			conn:       conn,
+		})
 	}
 
 	return mcpTools, nil
@@ -175,7 +194,7 @@ func newMcpTool(descriptor *llms.ToolDescriptor, session *mcp.ClientSession) (*M
 	}
 	return &McpTool{
 		descriptor: descriptor,
-		session:    session,
+		conn:       &mcpConnection{session: session},
 	}, nil
 }
 
@@ -184,7 +203,7 @@ var _ tools.Tool = &McpTool{}
 // McpTool is a tool that interacts with the Model Context Protocol (MCP) client.
 type McpTool struct {
 	descriptor *llms.ToolDescriptor
-	session    *mcp.ClientSession
+	conn       *mcpConnection
 }
 
 func (t *McpTool) Descriptor() *llms.ToolDescriptor {
@@ -198,8 +217,8 @@ func (t *McpTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCa
 		Arguments: params.Arguments,
 	}
 
-	// Call the MCP tool through the session
-	result, err := t.session.CallTool(ctx, mcpParams)
+	// Call the MCP tool through the session, connecting again if its transport is broken
+	result, err := t.conn.callTool(ctx, mcpParams)
 	if err != nil {
 		return &llms.ToolCallResult{
 			ToolCallId: params.ToolCallId,
@@ -246,10 +265,7 @@ func (t *McpTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCa
 	}, nil
 }
 
-// Close closes the underlying MCP session
+// Close closes the underlying MCP session, shared by the tools of the server
 func (t *McpTool) Close() error {
-	if t.session != nil {
-		return t.session.Close()
-	}
-	return nil
+	return t.conn.close()
 }
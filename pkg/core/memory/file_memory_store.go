@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileMemoryStoreOption configures the file memory store
+type FileMemoryStoreOption func(*FileMemoryStore)
+
+// WithMaxItems caps the number of items kept by the store, the oldest items are evicted first.
+// Zero or negative keeps all items.
+func WithMaxItems(maxItems int) FileMemoryStoreOption {
+	return func(s *FileMemoryStore) {
+		s.maxItems = maxItems
+	}
+}
+
+// NewFileMemory creates a memory persisted to the file, see NewFileMemoryStore
+func NewFileMemory(filePath string, opts ...FileMemoryStoreOption) (Memory, error) {
+	store, err := NewFileMemoryStore(filePath, NewJsonCodec(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSimpleMemoryWithStore(store), nil
+}
+
+// NewFileMemoryStore creates a storage persisting the items to the file as newline-delimited JSON,
+// one item encoded by the codec per line. The items already in the file are loaded, so that a
+// conversation survives a restart.
+func NewFileMemoryStore(filePath string, codec MemoryItemCodec, opts ...FileMemoryStoreOption) (*FileMemoryStore, error) {
+	s := &FileMemoryStore{
+		filePath: filePath,
+		codec:    codec,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	lines, unterminated, err := s.loadFromFile()
+	if err != nil {
+		return nil, err
+	}
+	s.fileItems = lines
+	s.evict()
+
+	if unterminated {
+		err = s.compact()
+	} else {
+		err = s.open()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+var _ MemoryStore = &FileMemoryStore{}
+
+// FileMemoryStore keeps the items in memory and appends them to a file. The file is rewritten
+// with the kept items only when the evicted items make up half of it.
+type FileMemoryStore struct {
+	filePath string
+	codec    MemoryItemCodec
+	maxItems int
+
+	mutex     sync.RWMutex
+	file      *os.File
+	items     []MemoryItem
+	fileItems int // number of items in the file, evicted ones included
+}
+
+// Store adds a MemoryItem to storage
+func (s *FileMemoryStore) Store(ctx context.Context, item MemoryItem) error {
+	data, err := s.encode(item)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("file memory store is closed")
+	}
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to append to file: %w", err)
+	}
+	s.items = append(s.items, item)
+	s.fileItems++
+
+	if s.evict() && s.fileItems >= 2*len(s.items) {
+		return s.compact()
+	}
+	return nil
+}
+
+// Load retrieves all MemoryItem
+func (s *FileMemoryStore) Load(ctx context.Context) ([]MemoryItem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	// Return a copy to avoid concurrent modification
+	result := make([]MemoryItem, len(s.items))
+	copy(result, s.items)
+	return result, nil
+}
+
+// Clear clears storage
+func (s *FileMemoryStore) Clear(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("file memory store is closed")
+	}
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate file: %w", err)
+	}
+	s.items = nil
+	s.fileItems = 0
+	return nil
+}
+
+// Close closes storage
+func (s *FileMemoryStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// encode encodes the item as a line of the file
+func (s *FileMemoryStore) encode(item MemoryItem) ([]byte, error) {
+	data, err := s.codec.Encode(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode item: %w", err)
+	}
+	if bytes.ContainsAny(data, "\r\n") {
+		return nil, fmt.Errorf("encoded item %s spans multiple lines", item.GetId())
+	}
+	return append(data, '\n'), nil
+}
+
+// evict drops the oldest items over the cap, it returns whether items were dropped
+func (s *FileMemoryStore) evict() bool {
+	if s.maxItems <= 0 || len(s.items) <= s.maxItems {
+		return false
+	}
+	s.items = append([]MemoryItem(nil), s.items[len(s.items)-s.maxItems:]...)
+	return true
+}
+
+// loadFromFile loads the items of the file, it returns the number of items in the file and
+// whether the file must be rewritten before appending to it, i.e. its last line is not terminated
+func (s *FileMemoryStore) loadFromFile() (int, bool, error) {
+	file, err := os.Open(s.filePath)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	lines := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return 0, false, fmt.Errorf("failed to read file: %w", readErr)
+		}
+		unterminated := readErr == io.EOF && len(line) > 0
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			item, err := s.codec.Decode(line)
+			if err != nil && unterminated {
+				// the process stopped while appending the last item
+				return lines, true, nil
+			}
+			lines++
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to decode item at line %d: %w", lines, err)
+			}
+			s.items = append(s.items, item)
+		}
+		if readErr == io.EOF {
+			return lines, unterminated, nil
+		}
+	}
+}
+
+// compact rewrites the file with the kept items only
+func (s *FileMemoryStore) compact() error {
+	var buffer bytes.Buffer
+	for _, item := range s.items {
+		data, err := s.encode(item)
+		if err != nil {
+			return err
+		}
+		buffer.Write(data)
+	}
+
+	// Write to a temporary file first so that a crash does not lose the items
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, buffer.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return fmt.Errorf("failed to close file: %w", err)
+		}
+		s.file = nil
+	}
+	renameErr := os.Rename(tmpPath, s.filePath)
+	if err := s.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("failed to replace file: %w", renameErr)
+	}
+	s.fileItems = len(s.items)
+	return nil
+}
+
+// open opens the file for appending the items
+func (s *FileMemoryStore) open() error {
+	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	s.file = file
+	return nil
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTextMemoryItem(text string) MemoryItem {
+	message := llms.NewUserMessage(text)
+	message.Timestamp = time.Now().UTC()
+	return NewChatMessageMemoryItem(message)
+}
+
+func memoryTexts(t *testing.T, items []MemoryItem) []string {
+	var texts []string
+	for _, message := range AsMessages(items) {
+		require.Len(t, message.Parts, 1)
+		texts = append(texts, message.Parts[0].(*llms.TextPart).Text)
+	}
+	return texts
+}
+
+func countLines(t *testing.T, filePath string) int {
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestFileMemoryStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "conversations", "memory.jsonl")
+
+	mem, err := NewFileMemory(filePath)
+	require.NoError(t, err)
+	first := newTextMemoryItem("hello")
+	require.NoError(t, mem.Add(ctx, first))
+	require.NoError(t, mem.Add(ctx, newTextMemoryItem("world")))
+	assert.Equal(t, 2, countLines(t, filePath))
+
+	// a new store, e.g. after a restart, loads the items
+	restored, err := NewFileMemoryStore(filePath, NewJsonCodec())
+	require.NoError(t, err)
+	defer restored.Close()
+
+	items, err := restored.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello", "world"}, memoryTexts(t, items))
+	assert.Equal(t, first.GetId(), items[0].GetId())
+	assert.True(t, first.GetCreatedAt().Equal(items[0].GetCreatedAt()))
+
+	// the items are appended to the loaded ones
+	require.NoError(t, restored.Store(ctx, newTextMemoryItem("again")))
+	assert.Equal(t, 3, countLines(t, filePath))
+
+	require.NoError(t, restored.Clear(ctx))
+	items, err = restored.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, 0, countLines(t, filePath))
+}
+
+func TestFileMemoryStore_Eviction(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "memory.jsonl")
+
+	store, err := NewFileMemoryStore(filePath, NewJsonCodec(), WithMaxItems(3))
+	require.NoError(t, err)
+	for _, text := range []string{"1", "2", "3", "4", "5"} {
+		require.NoError(t, store.Store(ctx, newTextMemoryItem(text)))
+	}
+
+	items, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "4", "5"}, memoryTexts(t, items))
+	assert.Equal(t, 5, countLines(t, filePath))
+
+	// the file is rewritten when the evicted items make up half of it
+	require.NoError(t, store.Store(ctx, newTextMemoryItem("6")))
+	assert.Equal(t, 3, countLines(t, filePath))
+	require.NoError(t, store.Close())
+
+	// a smaller cap evicts the oldest loaded items
+	restored, err := NewFileMemoryStore(filePath, NewJsonCodec(), WithMaxItems(2))
+	require.NoError(t, err)
+	defer restored.Close()
+
+	items, err = restored.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5", "6"}, memoryTexts(t, items))
+}
+
+func TestFileMemoryStore_PartiallyWrittenItem(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "memory.jsonl")
+
+	store, err := NewFileMemoryStore(filePath, NewJsonCodec())
+	require.NoError(t, err)
+	require.NoError(t, store.Store(ctx, newTextMemoryItem("kept")))
+	require.NoError(t, store.Close())
+
+	// the process stopped while appending an item
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":"lost","type":"chat_mess`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restored, err := NewFileMemoryStore(filePath, NewJsonCodec())
+	require.NoError(t, err)
+	defer restored.Close()
+	require.NoError(t, restored.Store(ctx, newTextMemoryItem("appended")))
+
+	reloaded, err := NewFileMemoryStore(filePath, NewJsonCodec())
+	require.NoError(t, err)
+	defer reloaded.Close()
+
+	items, err := reloaded.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept", "appended"}, memoryTexts(t, items))
+}
+
+func TestFileMemoryStore_CorruptedFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "memory.jsonl")
+	require.NoError(t, os.WriteFile(filePath, []byte("not json\n"), 0644))
+
+	_, err := NewFileMemoryStore(filePath, NewJsonCodec())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode item at line 1")
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+const (
+	// DefaultVectorMemoryCollection is the default collection of the messages in the vector database
+	DefaultVectorMemoryCollection = "memory"
+
+	// metadataKeyMemoryItem is the metadata of the documents holding the encoded memory item
+	metadataKeyMemoryItem = "memory_item"
+)
+
+// VectorMemoryOption configures the vector memory
+type VectorMemoryOption func(*VectorMemory)
+
+// WithVectorMemoryCollection sets the collection of the messages in the vector database
+func WithVectorMemoryCollection(collection string) VectorMemoryOption {
+	return func(m *VectorMemory) {
+		m.collection = collection
+	}
+}
+
+// WithVectorMemoryStore sets the storage of the items retrieved in order, in-memory by default
+func WithVectorMemoryStore(store MemoryStore) VectorMemoryOption {
+	return func(m *VectorMemory) {
+		m.store = store
+	}
+}
+
+// NewVectorMemory creates a memory indexing the text of the chat messages in the vector database,
+// embedded by the embedder, so that the messages relevant to a query can be recalled.
+func NewVectorMemory(vectorDB vectordb.VectorDB, embedder embedder.Embedder, opts ...VectorMemoryOption) *VectorMemory {
+	m := &VectorMemory{
+		vectorDB:   vectorDB,
+		embedder:   embedder,
+		codec:      NewJsonCodec(),
+		collection: DefaultVectorMemoryCollection,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store == nil {
+		m.store = NewInMemoryStore()
+	}
+	m.memory = NewSimpleMemoryWithStore(m.store)
+	return m
+}
+
+var _ Memory = &VectorMemory{}
+
+// VectorMemory retrieves the items in order like SimpleMemory, and recalls the chat messages
+// most similar to a query
+type VectorMemory struct {
+	memory     Memory
+	vectorDB   vectordb.VectorDB
+	embedder   embedder.Embedder
+	codec      MemoryItemCodec
+	collection string
+	store      MemoryStore
+}
+
+// Add stores the item, and indexes its text when it is a chat message with text
+func (m *VectorMemory) Add(ctx context.Context, item MemoryItem) error {
+	doc, err := m.toDocument(item)
+	if err != nil {
+		return err
+	}
+	if doc != nil {
+		_, err = m.vectorDB.AddDocuments(ctx, []*document.Document{doc},
+			vectordb.WithInsertCollection(m.collection),
+			vectordb.WithInsertEmbedder(m.embedder))
+		if err != nil {
+			return err
+		}
+	}
+	return m.memory.Add(ctx, item)
+}
+
+// Retrieve returns the items in the order they were added
+func (m *VectorMemory) Retrieve(ctx context.Context, options ...MemoryRetrieveOption) ([]MemoryItem, error) {
+	return m.memory.Retrieve(ctx, options...)
+}
+
+// Recall returns up to k prior chat messages, from the most to the least similar to the query
+func (m *VectorMemory) Recall(ctx context.Context, query string, k int) ([]MemoryItem, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	docs, err := m.vectorDB.Search(ctx, query, k,
+		vectordb.WithSearchCollection(m.collection),
+		vectordb.WithSearchEmbedder(m.embedder))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]MemoryItem, 0, len(docs))
+	for _, doc := range docs {
+		encoded, ok := doc.Metadata[metadataKeyMemoryItem].(string)
+		if !ok {
+			return nil, fmt.Errorf("document %s has no memory item", doc.Id)
+		}
+		item, err := m.codec.Decode([]byte(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode memory item %s: %w", doc.Id, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Reset removes the items, and their messages from the vector database
+func (m *VectorMemory) Reset() error {
+	ctx := context.Background()
+	items, err := m.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if text := messageText(item); text == "" {
+			continue
+		}
+		if err := m.vectorDB.Delete(ctx, document.DocumentId(item.GetId())); err != nil {
+			return err
+		}
+	}
+	return m.memory.Reset()
+}
+
+// toDocument converts the item to a document of its text, nil when it has no text to index
+func (m *VectorMemory) toDocument(item MemoryItem) (*document.Document, error) {
+	text := messageText(item)
+	if text == "" {
+		return nil, nil
+	}
+	encoded, err := m.codec.Encode(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode memory item: %w", err)
+	}
+	return document.NewDocument(document.DocumentId(item.GetId()), "", map[string]any{
+		metadataKeyMemoryItem: string(encoded),
+	}, text), nil
+}
+
+// messageText returns the text of the chat message item, reasoning excluded
+func messageText(item MemoryItem) string {
+	chatItem, ok := item.(*ChatMessageMemoryItem)
+	if !ok {
+		return ""
+	}
+	message, ok := chatItem.AsMessage()
+	if !ok {
+		return ""
+	}
+
+	var texts []string
+	for _, part := range message.Parts {
+		if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning && textPart.Text != "" {
+			texts = append(texts, textPart.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/vectordb/inmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder embeds texts as counts of a fixed vocabulary
+type keywordEmbedder struct {
+	vocabulary []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i, text := range texts {
+		vector := make(embedder.FloatVector, len(e.vocabulary))
+		for j, word := range e.vocabulary {
+			vector[j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func newTestVectorMemory(t *testing.T, texts ...string) *VectorMemory {
+	mem := NewVectorMemory(inmem.New(),
+		&keywordEmbedder{vocabulary: []string{"weather", "rain", "pizza", "pasta", "flight"}})
+	for _, text := range texts {
+		require.NoError(t, mem.Add(context.Background(), newTextMemoryItem(text)))
+	}
+	return mem
+}
+
+func TestVectorMemory_Recall(t *testing.T) {
+	ctx := context.Background()
+	mem := newTestVectorMemory(t,
+		"What is the weather tomorrow?",
+		"Book a flight to Rome",
+		"I'd like a pizza",
+		"Will rain spoil the weather?",
+		"Pasta or pizza for dinner?")
+
+	items, err := mem.Recall(ctx, "rain and weather", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Will rain spoil the weather?", "What is the weather tomorrow?"}, memoryTexts(t, items))
+
+	items, err = mem.Recall(ctx, "pizza", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I'd like a pizza"}, memoryTexts(t, items))
+
+	// the recalled items are the added ones
+	all, err := mem.Retrieve(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+	assert.Equal(t, all[2].GetId(), items[0].GetId())
+
+	items, err = mem.Recall(ctx, "pizza", 0)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestVectorMemory_OnlyIndexesText(t *testing.T) {
+	ctx := context.Background()
+	mem := newTestVectorMemory(t, "weather report")
+
+	toolCall := llms.NewAssistantMessage("m1", llms.ModelId{}, "", &llms.ToolCall{ToolCallId: "1", Name: "get_weather"})
+	require.NoError(t, mem.Add(ctx, NewChatMessageMemoryItem(toolCall)))
+	require.NoError(t, mem.Add(ctx, NewGenericMemoryItem("weather notes")))
+
+	items, err := mem.Recall(ctx, "weather", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"weather report"}, memoryTexts(t, items))
+
+	// all items are retrieved in order
+	all, err := mem.Retrieve(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestVectorMemory_Reset(t *testing.T) {
+	ctx := context.Background()
+	mem := newTestVectorMemory(t, "weather report", "pizza order")
+
+	require.NoError(t, mem.Reset())
+
+	items, err := mem.Recall(ctx, "weather", 5)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	all, err := mem.Retrieve(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
@@ -0,0 +1,260 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// NewCalculatorTool creates a tool evaluating arithmetic expressions, so the model does not compute them itself
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{}
+}
+
+var _ tools.Tool = &CalculatorTool{}
+
+// CalculatorTool evaluates arithmetic expressions: numbers, + - * / %, ^ (or **) for the exponent
+// and parentheses, with the usual precedence. The exponent binds tighter than the sign, -2^2 is -4.
+type CalculatorTool struct{}
+
+func (t *CalculatorTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "calculator_eval",
+		Description: "Evaluate an arithmetic expression and return its numeric result. Use it for any computation instead of computing yourself.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"expression": {
+					Type:        llms.TypeString,
+					Description: "The expression to evaluate, with numbers, + - * / %, ^ for the exponent and parentheses, e.g. '(15 * 23) ^ 2 / 7'",
+				},
+			},
+			Required: []string{"expression"},
+		},
+	}
+}
+
+func (t *CalculatorTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	expression, _ := params.Arguments["expression"].(string)
+	if strings.TrimSpace(expression) == "" {
+		return failure(params, "expression parameter is required and cannot be empty"), nil
+	}
+
+	result, err := Evaluate(expression)
+	if err != nil {
+		return failure(params, err.Error()), nil
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":    true,
+			"expression": expression,
+			"result":     result,
+		},
+	}, nil
+}
+
+func failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
+
+// Evaluate evaluates the arithmetic expression, see CalculatorTool
+func Evaluate(expression string) (float64, error) {
+	p := &parser{input: []rune(expression)}
+	value, err := p.parseExpression()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpaces(); p.pos < len(p.input) {
+		return 0, p.errorf("unexpected %q", string(p.input[p.pos]))
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("the result of %s is not a finite number", expression)
+	}
+	return value, nil
+}
+
+// parser is a recursive descent parser of the grammar:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = ("+" | "-") unary | power
+//	power      = primary [ ("^" | "**") unary ]
+//	primary    = number | "(" expression ")"
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("malformed expression at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// accept consumes the token when it is next in the input
+func (p *parser) accept(token string) bool {
+	p.skipSpaces()
+	if !strings.HasPrefix(string(p.input[p.pos:]), token) {
+		return false
+	}
+	p.pos += len([]rune(token))
+	return true
+}
+
+func (p *parser) parseExpression() (float64, error) {
+	value, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.accept("+"):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			value += right
+		case p.accept("-"):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			value -= right
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (p *parser) parseTerm() (float64, error) {
+	value, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var operator string
+		switch {
+		case p.accept("*"):
+			operator = "*"
+		case p.accept("/"):
+			operator = "/"
+		case p.accept("%"):
+			operator = "%"
+		default:
+			return value, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch operator {
+		case "*":
+			value *= right
+		case "/":
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			value /= right
+		case "%":
+			if right == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			value = math.Mod(value, right)
+		}
+	}
+}
+
+func (p *parser) parseUnary() (float64, error) {
+	switch {
+	case p.accept("+"):
+		return p.parseUnary()
+	case p.accept("-"):
+		value, err := p.parseUnary()
+		return -value, err
+	default:
+		return p.parsePower()
+	}
+}
+
+func (p *parser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if !p.accept("^") && !p.accept("**") {
+		return base, nil
+	}
+	// right associative, 2^3^2 is 2^(3^2)
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+func (p *parser) parsePrimary() (float64, error) {
+	if p.accept("(") {
+		value, err := p.parseExpression()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(")") {
+			return 0, p.errorf("missing closing parenthesis")
+		}
+		return value, nil
+	}
+	return p.parseNumber()
+}
+
+func (p *parser) parseNumber() (float64, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	// exponent of the scientific notation, e.g. 1.5e3
+	if p.pos > start && p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		next := p.pos + 1
+		if next < len(p.input) && (p.input[next] == '+' || p.input[next] == '-') {
+			next++
+		}
+		if next < len(p.input) && unicode.IsDigit(p.input[next]) {
+			p.pos = next
+			for p.pos < len(p.input) && unicode.IsDigit(p.input[p.pos]) {
+				p.pos++
+			}
+		}
+	}
+	if p.pos == start {
+		if p.pos >= len(p.input) {
+			return 0, p.errorf("unexpected end of expression")
+		}
+		return 0, p.errorf("unexpected %q, expected a number", string(p.input[p.pos]))
+	}
+	number := string(p.input[start:p.pos])
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("invalid number %q", number)
+	}
+	return value, nil
+}
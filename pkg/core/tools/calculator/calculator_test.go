@@ -0,0 +1,92 @@
+package calculator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{"15 * 23", 345},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"7 / 2", 3.5},
+		{"17 % 5 + 1", 3},
+		{"2 * 3 % 4", 2},
+		{"2 ^ 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 ^ -1", 0.5},
+		{"3 * -2", -6},
+		{"--3", 3},
+		{"1.5e3 + .5", 1500.5},
+		{" ( ( 1 ) ) ", 1},
+		{"123456789 * 987654321", 121932631112635269},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			result, err := Evaluate(test.expression)
+			require.NoError(t, err)
+			assert.InDelta(t, test.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestEvaluate_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{"1 / 0", "division by zero"},
+		{"5 % (2 - 2)", "modulo by zero"},
+		{"1 +", "malformed expression at position 4: unexpected end of expression"},
+		{"(1 + 2", "malformed expression at position 7: missing closing parenthesis"},
+		{"1 + 2)", `malformed expression at position 6: unexpected ")"`},
+		{"2 * x", `malformed expression at position 5: unexpected "x", expected a number`},
+		{"1.2.3", `malformed expression at position 1: invalid number "1.2.3"`},
+		{"1 2", `malformed expression at position 3: unexpected "2"`},
+		{"10 ^ 400", "the result of 10 ^ 400 is not a finite number"},
+		{"(-8) ^ 0.5", "is not a finite number"},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := Evaluate(test.expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestCalculatorTool_Call(t *testing.T) {
+	tool := NewCalculatorTool()
+	assert.Equal(t, "calculator_eval", tool.Descriptor().Name)
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "call-1",
+		Name:       "calculator_eval",
+		Arguments:  map[string]any{"expression": "15 * 23"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "call-1", result.ToolCallId)
+	assert.Equal(t, map[string]any{"success": true, "expression": "15 * 23", "result": 345.0}, result.Result)
+
+	for _, expression := range []string{"", "1 / 0", "1 +"} {
+		result, err = tool.Call(context.Background(), &llms.ToolCall{
+			Name:      "calculator_eval",
+			Arguments: map[string]any{"expression": expression},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, false, result.Result["success"], expression)
+		assert.NotEmpty(t, result.Result["error"], expression)
+	}
+}
@@ -16,20 +16,57 @@ import (
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
 
+const (
+	// DefaultSearchURL is the URL of the HTML search page of DuckDuckGo
+	DefaultSearchURL = "https://html.duckduckgo.com/html/"
+)
+
+// SafeSearch is the level of filtering of the adult content in the search results
+type SafeSearch string
+
+const (
+	// SafeSearchStrict filters out the adult content
+	SafeSearchStrict SafeSearch = "1"
+	// SafeSearchModerate filters out the explicit images and videos, the DuckDuckGo default
+	SafeSearchModerate SafeSearch = "-1"
+	// SafeSearchOff does not filter the results
+	SafeSearchOff SafeSearch = "-2"
+)
+
 // NewDuckDuckGoTool creates a new DuckDuckGo tool instance
 func NewDuckDuckGoTool() *DuckDuckGoTool {
 	return &DuckDuckGoTool{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		searchURL: DefaultSearchURL,
 	}
 }
 
+// WithSearchURL sets the URL of the HTML search page, DefaultSearchURL by default
+func (t *DuckDuckGoTool) WithSearchURL(searchURL string) *DuckDuckGoTool {
+	t.searchURL = searchURL
+	return t
+}
+
+// WithRegion sets the region the results are localized for, as a DuckDuckGo region code
+// such as "us-en", "fr-fr" or "wt-wt" for no region
+func (t *DuckDuckGoTool) WithRegion(region string) *DuckDuckGoTool {
+	t.region = region
+	return t
+}
+
+// WithSafeSearch sets the safe search level, DuckDuckGo applying SafeSearchModerate by default
+func (t *DuckDuckGoTool) WithSafeSearch(level SafeSearch) *DuckDuckGoTool {
+	t.safeSearch = level
+	return t
+}
+
 // SearchResult represents a single search result
 type SearchResult struct {
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	Description string `json:"description"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
 }
 
 // SearchResponse represents the response from a DuckDuckGo search
@@ -46,7 +83,10 @@ var _ tools.Tool = &DuckDuckGoTool{}
 
 // DuckDuckGoTool represents a tool for searching DuckDuckGo
 type DuckDuckGoTool struct {
-	client *http.Client
+	client     *http.Client
+	searchURL  string
+	region     string
+	safeSearch SafeSearch
 }
 
 // Call implements the Tool interface
@@ -87,8 +127,16 @@ func (t *DuckDuckGoTool) Call(ctx context.Context, params *llms.ToolCall) (*llms
 		}, nil
 	}
 
+	// Without max_results every result of the first page is returned
+	maxResults := 0
+	if value, ok := params.Arguments["max_results"].(float64); ok {
+		maxResults = int(value)
+	} else if value, ok := params.Arguments["max_results"].(int); ok {
+		maxResults = value
+	}
+
 	// Perform the search
-	results, err := t.search(ctx, query)
+	results, err := t.search(ctx, query, maxResults)
 	if err != nil {
 		klog.Errorf("duckduckgo search failed: %v", err)
 		return &llms.ToolCallResult{
@@ -117,7 +165,7 @@ func (t *DuckDuckGoTool) Call(ctx context.Context, params *llms.ToolCall) (*llms
 func (t *DuckDuckGoTool) Descriptor() *llms.ToolDescriptor {
 	return &llms.ToolDescriptor{
 		Name:        "duckduckgo_search",
-		Description: "Search DuckDuckGo for information. Returns search results with titles, URLs, and descriptions.",
+		Description: "Search DuckDuckGo for information. Returns search results with titles, URLs, and snippets.",
 		Parameters: &llms.Schema{
 			Type: llms.TypeObject,
 			Properties: map[string]*llms.Schema{
@@ -125,16 +173,28 @@ func (t *DuckDuckGoTool) Descriptor() *llms.ToolDescriptor {
 					Type:        llms.TypeString,
 					Description: "The search query to perform on DuckDuckGo",
 				},
+				"max_results": {
+					Type:        llms.TypeInteger,
+					Description: "Maximum number of results returned (default: all the results of the first page)",
+				},
 			},
 			Required: []string{"query"},
 		},
 	}
 }
 
-// search performs the actual DuckDuckGo search and parses the results
-func (t *DuckDuckGoTool) search(ctx context.Context, query string) (*SearchResponse, error) {
+// search performs the actual DuckDuckGo search and parses the results, keeping the first
+// maxResults ones when maxResults is positive
+func (t *DuckDuckGoTool) search(ctx context.Context, query string, maxResults int) (*SearchResponse, error) {
 	// Construct the DuckDuckGo search URL
-	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
+	values := url.Values{"q": {query}}
+	if t.region != "" {
+		values.Set("kl", t.region)
+	}
+	if t.safeSearch != "" {
+		values.Set("kp", string(t.safeSearch))
+	}
+	searchURL := t.searchURL + "?" + values.Encode()
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
@@ -185,16 +245,16 @@ func (t *DuckDuckGoTool) search(ctx context.Context, query string) (*SearchRespo
 			return
 		}
 
-		// Extract description
-		description := strings.TrimSpace(s.Find(".result__snippet").Text())
+		// Extract snippet
+		snippet := strings.TrimSpace(s.Find(".result__snippet").Text())
 
 		// Clean up the URL (DuckDuckGo uses redirect URLs)
 		cleanURL := t.cleanURL(href)
 
 		results = append(results, SearchResult{
-			Title:       title,
-			URL:         cleanURL,
-			Description: description,
+			Title:   title,
+			URL:     cleanURL,
+			Snippet: snippet,
 		})
 	})
 
@@ -209,17 +269,21 @@ func (t *DuckDuckGoTool) search(ctx context.Context, query string) (*SearchRespo
 				return
 			}
 
-			description := strings.TrimSpace(s.Find(".web-result__snippet").Text())
+			snippet := strings.TrimSpace(s.Find(".web-result__snippet").Text())
 			cleanURL := t.cleanURL(href)
 
 			results = append(results, SearchResult{
-				Title:       title,
-				URL:         cleanURL,
-				Description: description,
+				Title:   title,
+				URL:     cleanURL,
+				Snippet: snippet,
 			})
 		})
 	}
 
+	if maxResults > 0 && len(results) > maxResults {
+		results = results[:maxResults]
+	}
+
 	return &SearchResponse{
 		Query:   query,
 		Results: results,
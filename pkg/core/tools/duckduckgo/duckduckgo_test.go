@@ -2,6 +2,9 @@ package tools
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +22,7 @@ func TestDuckDuckGoTool_Descriptor(t *testing.T) {
 	assert.NotNil(t, descriptor.Parameters)
 	assert.Equal(t, llms.TypeObject, descriptor.Parameters.Type)
 	assert.Contains(t, descriptor.Parameters.Properties, "query")
+	assert.Contains(t, descriptor.Parameters.Properties, "max_results")
 	assert.Equal(t, []string{"query"}, descriptor.Parameters.Required)
 }
 
@@ -104,6 +108,74 @@ func TestDuckDuckGoTool_Call_ValidQuery(t *testing.T) {
 	}
 }
 
+// duckDuckGoResponse is the shape of the HTML search page of DuckDuckGo
+const duckDuckGoResponse = `<html><body>
+<div class="result results_links web-result">
+  <h2 class="result__title"><a class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&amp;rut=abc">The Go Programming Language</a></h2>
+  <a class="result__snippet" href="#">Go is an open source programming language.</a>
+</div>
+<div class="result results_links web-result">
+  <h2 class="result__title"><a class="result__a" href="https://en.wikipedia.org/wiki/Go_(programming_language)">Go (programming language)</a></h2>
+  <a class="result__snippet" href="#">Go is a statically typed, compiled language.</a>
+</div>
+<div class="result results_links web-result">
+  <h2 class="result__title"><a class="result__a" href="https://gobyexample.com/">Go by Example</a></h2>
+  <a class="result__snippet" href="#">Go by Example is a hands-on introduction to Go.</a>
+</div>
+</body></html>`
+
+func TestDuckDuckGoTool_Call_StubbedSearch(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(duckDuckGoResponse))
+	}))
+	defer server.Close()
+
+	call := func(tool *DuckDuckGoTool, arguments map[string]any) *llms.ToolCallResult {
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-id",
+			Name:       "duckduckgo_search",
+			Arguments:  arguments,
+		})
+		require.NoError(t, err)
+		require.True(t, result.Result["success"].(bool), result.Result["error"])
+		return result
+	}
+
+	tool := NewDuckDuckGoTool().
+		WithSearchURL(server.URL + "/html/").
+		WithRegion("fr-fr").
+		WithSafeSearch(SafeSearchStrict)
+	result := call(tool, map[string]any{"query": "golang", "max_results": float64(2)})
+	assert.Equal(t, 2, result.Result["count"])
+	assert.Equal(t, []SearchResult{
+		{
+			Title:   "The Go Programming Language",
+			URL:     "https://go.dev/",
+			Snippet: "Go is an open source programming language.",
+		},
+		{
+			Title:   "Go (programming language)",
+			URL:     "https://en.wikipedia.org/wiki/Go_(programming_language)",
+			Snippet: "Go is a statically typed, compiled language.",
+		},
+	}, result.Result["results"])
+
+	require.Len(t, queries, 1)
+	assert.Equal(t, "golang", queries[0].Get("q"))
+	assert.Equal(t, "fr-fr", queries[0].Get("kl"))
+	assert.Equal(t, "1", queries[0].Get("kp"))
+
+	// without options nor max_results, DuckDuckGo defaults apply and every result is returned
+	result = call(NewDuckDuckGoTool().WithSearchURL(server.URL+"/html/"), map[string]any{"query": "golang"})
+	assert.Equal(t, 3, result.Result["count"])
+	require.Len(t, queries, 2)
+	assert.False(t, queries[1].Has("kl"))
+	assert.False(t, queries[1].Has("kp"))
+}
+
 func TestDuckDuckGoTool_CleanURL(t *testing.T) {
 	tool := NewDuckDuckGoTool()
 
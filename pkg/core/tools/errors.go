@@ -13,4 +13,9 @@ var (
 		Name:           "ToolCallFailed",
 		DefaultMessage: "Failed to call tool",
 	}
+	ErrorCodeInvalidToolArguments = errors.ErrorCode{
+		Code:           20302,
+		Name:           "InvalidToolArguments",
+		DefaultMessage: "Tool arguments do not match the parameters schema",
+	}
 )
@@ -1,6 +1,10 @@
 package fetch
 
 import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,35 +12,87 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
 	"github.com/oopslink/agent-go/pkg/core/tools"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 	"golang.org/x/net/html"
+	"golang.org/x/net/http/httpproxy"
 )
 
 // URLsFetchTool provides URL fetching capabilities with content extraction
 type URLsFetchTool struct {
-	client  *http.Client
-	timeout time.Duration
+	client     *http.Client
+	transport  *http.Transport
+	timeout    time.Duration
+	newBackOff func() utils.BackOff
+	proxyErr   error
+	robots     *robotsCache
 }
 
-// NewURLsFetchTool creates a new URLs fetch tool instance
+// NewURLsFetchTool creates a new URLs fetch tool instance, requests go through the proxies
+// of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables unless WithProxy is used
 func NewURLsFetchTool() *URLsFetchTool {
+	transport := newEnvironmentTransport()
 	return &URLsFetchTool{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
-		timeout: 30 * time.Second,
+		transport: transport,
+		timeout:   30 * time.Second,
+		robots:    newRobotsCache(),
 	}
 }
 
+// newEnvironmentTransport clones the default transport with the proxies of the environment variables
+func newEnvironmentTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return transport
+}
+
 // WithTimeout sets the timeout for HTTP requests
 func (t *URLsFetchTool) WithTimeout(timeout time.Duration) *URLsFetchTool {
 	t.timeout = timeout
-	t.client.Timeout = timeout
+	if t.client != nil {
+		t.client.Timeout = timeout
+	}
+	return t
+}
+
+// WithProxy sends all requests through the proxy, e.g. "http://proxy.example.com:3128",
+// instead of the proxies of the environment variables
+func (t *URLsFetchTool) WithProxy(proxyURL string) *URLsFetchTool {
+	parsed, err := url.Parse(proxyURL)
+	if err == nil && (parsed.Scheme == "" || parsed.Host == "") {
+		err = fmt.Errorf("missing scheme or host")
+	}
+	if err != nil {
+		t.proxyErr = fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		return t
+	}
+	t.proxyErr = nil
+	if t.transport == nil {
+		// tools not made by NewURLsFetchTool have no transport yet
+		t.transport = newEnvironmentTransport()
+	}
+	t.transport.Proxy = http.ProxyURL(parsed)
+	return t
+}
+
+// WithRetryBackOff sets the backoff between the retries of a URL, see max_retries.
+// The function is called for each URL, the default is utils.NewExponentialBackOff.
+func (t *URLsFetchTool) WithRetryBackOff(newBackOff func() utils.BackOff) *URLsFetchTool {
+	t.newBackOff = newBackOff
 	return t
 }
 
@@ -50,6 +106,31 @@ type FetchParams struct {
 	MaxBodySize  int64    `json:"max_body_size,omitempty"` // Maximum response body size in bytes (default: 1MB)
 	FollowRedirect bool   `json:"follow_redirect,omitempty"` // Whether to follow redirects (default: true)
 	MaxConcurrency int    `json:"max_concurrency,omitempty"` // Maximum concurrent requests (default: 10)
+	Method       string            `json:"method,omitempty"`  // HTTP method (default: GET)
+	Body         string            `json:"body,omitempty"`    // Request body, sent for POST, PUT and PATCH
+	Headers      map[string]string `json:"headers,omitempty"` // Additional request headers
+	OutputFormat string            `json:"output_format,omitempty"` // OutputFormatText, OutputFormatMarkdown or OutputFormatRaw
+	MaxRetries   int               `json:"max_retries,omitempty"`     // Retries of a URL on network errors and RetryOnStatus (default: 0)
+	RetryOnStatus []int            `json:"retry_on_status,omitempty"` // Status codes retried, e.g. 503
+	RespectRobots bool             `json:"respect_robots,omitempty"`  // Whether to skip the URLs disallowed by the robots.txt of their origin
+}
+
+// Output formats of the fetched HTML pages
+const (
+	OutputFormatText     = "text"     // Plain text in TextContent
+	OutputFormatMarkdown = "markdown" // Markdown in Markdown, keeping headings, lists, links and code blocks
+	OutputFormatRaw      = "raw"      // Raw content only
+)
+
+// managedHeaders are request headers handled by the tool and the HTTP client, which are not
+// taken from the parameters: Accept-Encoding for instance only lists the encodings the tool
+// decodes, so that the body size limit applies to the decoded content
+var managedHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Host":              true,
 }
 
 // URLResult represents the result of fetching a single URL
@@ -61,9 +142,11 @@ type URLResult struct {
 	ContentLength int64            `json:"content_length"`         // Content length in bytes
 	Content      string            `json:"content,omitempty"`      // Raw content
 	TextContent  string            `json:"text_content,omitempty"` // Extracted text content (if extract_text=true)
+	Markdown     string            `json:"markdown,omitempty"`     // Markdown content (if output_format=markdown)
 	Title        string            `json:"title,omitempty"`        // Page title (if HTML)
 	Error        string            `json:"error,omitempty"`        // Error message if fetching failed
-	FetchTime    int64             `json:"fetch_time_ms"`          // Time taken to fetch in milliseconds
+	FetchTime    int64             `json:"fetch_time_ms"`          // Time taken to fetch in milliseconds, retries included
+	Attempts     int               `json:"attempts"`               // Number of requests made
 }
 
 // FetchResult represents the overall result of the fetch operation
@@ -101,7 +184,7 @@ func (t *URLsFetchTool) Descriptor() *llms.ToolDescriptor {
 				},
 				"max_body_size": {
 					Type:        llms.TypeInteger,
-					Description: "Maximum response body size in bytes (default: 1048576 = 1MB)",
+					Description: "Maximum response body size in bytes, the middle of larger bodies is elided (default: 1048576 = 1MB)",
 				},
 				"follow_redirect": {
 					Type:        llms.TypeBoolean,
@@ -111,6 +194,37 @@ func (t *URLsFetchTool) Descriptor() *llms.ToolDescriptor {
 					Type:        llms.TypeInteger,
 					Description: "Maximum number of concurrent requests (default: 10)",
 				},
+				"method": {
+					Type:        llms.TypeString,
+					Description: "HTTP method of the requests, e.g. GET, POST, PUT, PATCH, DELETE or HEAD (default: GET)",
+				},
+				"body": {
+					Type:        llms.TypeString,
+					Description: "Request body sent with POST, PUT and PATCH requests, e.g. a JSON document",
+				},
+				"output_format": {
+					Type:        llms.TypeString,
+					Description: "Format of the content extracted from HTML pages: 'text' for plain text, 'markdown' to keep headings, lists, links and code blocks, or 'raw' for the raw content only (default: 'text' when extract_text is true, 'raw' otherwise)",
+				},
+				"headers": {
+					Type:        llms.TypeObject,
+					Description: "Additional request headers, e.g. {\"Content-Type\": \"application/json\", \"Authorization\": \"Bearer ...\"}",
+				},
+				"max_retries": {
+					Type:        llms.TypeInteger,
+					Description: "Maximum number of retries of a URL, with exponential backoff, on network errors and the status codes of retry_on_status (default: 0, no retries)",
+				},
+				"retry_on_status": {
+					Type:        llms.TypeArray,
+					Description: "HTTP status codes retried when max_retries is set, e.g. [429, 502, 503, 504]",
+					Items: &llms.Schema{
+						Type: llms.TypeInteger,
+					},
+				},
+				"respect_robots": {
+					Type:        llms.TypeBoolean,
+					Description: "Whether to skip the URLs disallowed to user_agent by the robots.txt of their site (default: false)",
+				},
 			},
 			Required: []string{"urls"},
 		},
@@ -118,6 +232,10 @@ func (t *URLsFetchTool) Descriptor() *llms.ToolDescriptor {
 }
 
 func (t *URLsFetchTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	if t.proxyErr != nil {
+		return nil, t.proxyErr
+	}
+
 	var fetchParams FetchParams
 	if err := mapToStruct(params.Arguments, &fetchParams); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
@@ -138,10 +256,33 @@ func (t *URLsFetchTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.
 	if fetchParams.MaxConcurrency <= 0 {
 		fetchParams.MaxConcurrency = 10 // Default to 10 concurrent requests
 	}
+	if fetchParams.MaxRetries < 0 {
+		fetchParams.MaxRetries = 0
+	}
+	fetchParams.Method = strings.ToUpper(strings.TrimSpace(fetchParams.Method))
+	if fetchParams.Method == "" {
+		fetchParams.Method = http.MethodGet
+	}
+	if !isSupportedMethod(fetchParams.Method) {
+		return nil, fmt.Errorf("unsupported method: %s", fetchParams.Method)
+	}
+	switch fetchParams.OutputFormat {
+	case "":
+		fetchParams.OutputFormat = OutputFormatRaw
+		if fetchParams.ExtractText {
+			fetchParams.OutputFormat = OutputFormatText
+		}
+	case OutputFormatText:
+		fetchParams.ExtractText = true
+	case OutputFormatMarkdown, OutputFormatRaw:
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", fetchParams.OutputFormat)
+	}
 
-	// Configure HTTP client
-	client := &http.Client{
-		Timeout: t.timeout,
+	// Configure HTTP client, the tools not made by NewURLsFetchTool use the default transport
+	client := &http.Client{Timeout: t.timeout}
+	if t.transport != nil {
+		client.Transport = t.transport
 	}
 	if !fetchParams.FollowRedirect {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
@@ -232,23 +373,46 @@ func (t *URLsFetchTool) fetchSingleURL(ctx context.Context, client *http.Client,
 		return result
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
-	if err != nil {
-		result.Error = fmt.Sprintf("failed to create request: %v", err)
-		result.FetchTime = time.Since(startTime).Milliseconds()
-		return result
+	// Skip the URLs disallowed by robots.txt
+	if params.RespectRobots {
+		allowed, err := t.robotsAllowed(ctx, client, parsedURL, params.UserAgent)
+		if err != nil || !allowed {
+			result.Error = robotsDisallowedError
+			if err != nil {
+				result.Error = err.Error()
+			}
+			result.FetchTime = time.Since(startTime).Milliseconds()
+			return result
+		}
 	}
 
-	// Set User-Agent
-	req.Header.Set("User-Agent", params.UserAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-
-	// Make request
-	resp, err := client.Do(req)
+	// Make request, retrying on network errors and the configured status codes
+	resp, err := utils.Retry(ctx,
+		func() (*http.Response, error) {
+			result.Attempts++
+			req, err := t.newRequest(ctx, urlStr, params)
+			if err != nil {
+				return nil, errors.Permanent(fmt.Errorf("failed to create request: %w", err))
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			if result.Attempts <= params.MaxRetries && slices.Contains(params.RetryOnStatus, resp.StatusCode) {
+				resp.Body.Close()
+				return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+			}
+			return resp, nil
+		},
+		utils.WithBackOff(t.retryBackOff()),
+		utils.WithMaxTries(uint(params.MaxRetries)+1),
+		utils.WithMaxElapsedTime(0),
+	)
 	if err != nil {
-		result.Error = fmt.Sprintf("request failed: %v", err)
+		if errors.IsPermanent(err) {
+			err = errors.Unwrap(err)
+		}
+		result.Error = err.Error()
 		result.FetchTime = time.Since(startTime).Milliseconds()
 		return result
 	}
@@ -265,9 +429,16 @@ func (t *URLsFetchTool) fetchSingleURL(ctx context.Context, client *http.Client,
 		}
 	}
 
-	// Read body with size limit
-	limitedReader := io.LimitReader(resp.Body, params.MaxBodySize)
-	body, err := io.ReadAll(limitedReader)
+	// Decode the body before limiting its size, so that the limit applies to the decoded content
+	bodyReader, err := decodeBody(resp)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to decode response body: %v", err)
+		result.FetchTime = time.Since(startTime).Milliseconds()
+		return result
+	}
+
+	// Read body with size limit, the middle of the larger bodies is elided to keep their beginning and end
+	body, err := utils.ReadMiddleToFit(bodyReader, int(params.MaxBodySize))
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to read response body: %v", err)
 		result.FetchTime = time.Since(startTime).Milliseconds()
@@ -275,7 +446,7 @@ func (t *URLsFetchTool) fetchSingleURL(ctx context.Context, client *http.Client,
 	}
 
 	result.ContentLength = int64(len(body))
-	result.Content = string(body)
+	result.Content = body
 
 	// Extract text content if requested and content is HTML
 	if params.ExtractText && isHTMLContent(result.ContentType) {
@@ -284,10 +455,118 @@ func (t *URLsFetchTool) fetchSingleURL(ctx context.Context, client *http.Client,
 		result.Title = title
 	}
 
+	// Convert to Markdown if requested and content is HTML
+	if params.OutputFormat == OutputFormatMarkdown && isHTMLContent(result.ContentType) {
+		markdown, title := extractMarkdownFromHTML(result.Content, resp.Request.URL)
+		result.Markdown = markdown
+		result.Title = title
+	}
+
 	result.FetchTime = time.Since(startTime).Milliseconds()
 	return result
 }
 
+// newRequest creates a request to the URL, only methods sending content get the body
+func (t *URLsFetchTool) newRequest(ctx context.Context, urlStr string, params FetchParams) (*http.Request, error) {
+	var requestBody io.Reader
+	if hasRequestBody(params.Method) && params.Body != "" {
+		requestBody = strings.NewReader(params.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, params.Method, urlStr, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set User-Agent
+	req.Header.Set("User-Agent", params.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	// Setting it turns off the decoding of the HTTP client, which only knows gzip: decodeBody decodes the body
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	if requestBody != nil {
+		req.Header.Set("Content-Type", guessContentType(params.Body))
+	}
+	for key, value := range params.Headers {
+		if !managedHeaders[http.CanonicalHeaderKey(key)] {
+			req.Header.Set(key, value)
+		}
+	}
+	return req, nil
+}
+
+// decodeBody returns the reader of the body of the response decoded from its Content-Encoding,
+// gzip or deflate, and of the body as is when it is empty
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	var reader io.Reader = bufio.NewReader(resp.Body)
+	if _, err := reader.(*bufio.Reader).Peek(1); err != nil {
+		return reader, nil // Empty body, read errors are returned by the next read
+	}
+
+	// The encodings are listed in the order they were applied
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encoding := strings.ToLower(strings.TrimSpace(encodings[i])); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			gzipReader, err := gzip.NewReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			reader = gzipReader
+		case "deflate":
+			deflateReader, err := newDeflateReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			reader = deflateReader
+		default:
+			return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+	}
+	return reader, nil
+}
+
+// newDeflateReader returns the reader of deflate content, which is zlib wrapped as specified
+// by HTTP or raw as sent by some servers
+func newDeflateReader(reader io.Reader) (io.Reader, error) {
+	bufReader := bufio.NewReader(reader)
+	header, err := bufReader.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(bufReader)
+	}
+	return flate.NewReader(bufReader), nil
+}
+
+func (t *URLsFetchTool) retryBackOff() utils.BackOff {
+	if t.newBackOff != nil {
+		return t.newBackOff()
+	}
+	return utils.NewExponentialBackOff()
+}
+
+// isSupportedMethod checks if the HTTP method can be used to fetch URLs
+func isSupportedMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// hasRequestBody checks if requests of the HTTP method send a body
+func hasRequestBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// guessContentType returns the content type of a request body without a Content-Type header
+func guessContentType(body string) string {
+	if json.Valid([]byte(body)) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
 // isHTMLContent checks if the content type indicates HTML
 func isHTMLContent(contentType string) bool {
 	return strings.Contains(strings.ToLower(contentType), "text/html") ||
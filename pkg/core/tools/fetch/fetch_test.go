@@ -1,15 +1,22 @@
 package fetch
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/oopslink/agent-go/pkg/commons/utils"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 )
 
@@ -441,3 +448,321 @@ func TestCleanText(t *testing.T) {
 		}
 	}
 }
+
+func TestURLsFetchTool_Call_MethodBodyAndHeaders(t *testing.T) {
+	// Create test server echoing the request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":       r.Method,
+			"body":         string(body),
+			"content_type": r.Header.Get("Content-Type"),
+			"api_key":      r.Header.Get("X-Api-Key"),
+		})
+	}))
+	defer server.Close()
+
+	tool := NewURLsFetchTool()
+	fetch := func(arguments map[string]any) map[string]string {
+		t.Helper()
+		arguments["urls"] = []any{server.URL}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-method",
+			Name:       "urls_fetch",
+			Arguments:  arguments,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fetchResult := result.Result["data"].(FetchResult)
+		if len(fetchResult.Results) != 1 || fetchResult.Results[0].Error != "" {
+			t.Fatalf("unexpected results: %+v", fetchResult.Results)
+		}
+		var echo map[string]string
+		if err := json.Unmarshal([]byte(fetchResult.Results[0].Content), &echo); err != nil {
+			t.Fatalf("failed to decode echo: %v", err)
+		}
+		return echo
+	}
+
+	echo := fetch(map[string]any{
+		"method":  "post",
+		"body":    `{"query":"agents"}`,
+		"headers": map[string]any{"X-Api-Key": "secret"},
+	})
+	if echo["method"] != "POST" {
+		t.Errorf("expected method POST, got %s", echo["method"])
+	}
+	if echo["body"] != `{"query":"agents"}` {
+		t.Errorf("expected body to be sent, got %q", echo["body"])
+	}
+	if echo["content_type"] != "application/json" {
+		t.Errorf("expected JSON content type, got %s", echo["content_type"])
+	}
+	if echo["api_key"] != "secret" {
+		t.Errorf("expected custom header, got %q", echo["api_key"])
+	}
+
+	// headers override the default content type
+	echo = fetch(map[string]any{
+		"method":  "PUT",
+		"body":    "a=1",
+		"headers": map[string]any{"Content-Type": "application/x-www-form-urlencoded"},
+	})
+	if echo["method"] != "PUT" || echo["body"] != "a=1" {
+		t.Errorf("unexpected echo for PUT: %v", echo)
+	}
+	if echo["content_type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("expected custom content type, got %s", echo["content_type"])
+	}
+
+	// GET is the default and sends no body
+	echo = fetch(map[string]any{"body": "ignored"})
+	if echo["method"] != "GET" || echo["body"] != "" {
+		t.Errorf("unexpected echo for GET: %v", echo)
+	}
+
+	_, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "test-method",
+		Name:       "urls_fetch",
+		Arguments:  map[string]any{"urls": []any{server.URL}, "method": "CONNECT"},
+	})
+	if err == nil {
+		t.Error("expected error for unsupported method")
+	}
+}
+
+func TestURLsFetchTool_Call_Retries(t *testing.T) {
+	// Create test server failing until the third request of each test
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("recovered"))
+	}))
+	defer server.Close()
+
+	tool := NewURLsFetchTool().WithRetryBackOff(func() utils.BackOff {
+		return &utils.ZeroBackOff{}
+	})
+	fetch := func(urlStr string, arguments map[string]any) URLResult {
+		t.Helper()
+		requests.Store(0)
+		arguments["urls"] = []any{urlStr}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-retries",
+			Name:       "urls_fetch",
+			Arguments:  arguments,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results[0]
+	}
+
+	// no retries by default
+	result := fetch(server.URL, map[string]any{"retry_on_status": []any{503}})
+	if result.StatusCode != http.StatusServiceUnavailable || result.Attempts != 1 {
+		t.Errorf("expected a single 503 attempt, got status %d after %d attempts", result.StatusCode, result.Attempts)
+	}
+
+	// the configured status is retried until success
+	result = fetch(server.URL, map[string]any{"max_retries": 3, "retry_on_status": []any{503}})
+	if result.StatusCode != http.StatusOK || result.Content != "recovered" {
+		t.Errorf("expected recovered content, got status %d: %q", result.StatusCode, result.Content)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", result.Attempts)
+	}
+
+	// other statuses are not retried
+	result = fetch(server.URL, map[string]any{"max_retries": 3, "retry_on_status": []any{502}})
+	if result.StatusCode != http.StatusServiceUnavailable || result.Attempts != 1 {
+		t.Errorf("expected a single 503 attempt, got status %d after %d attempts", result.StatusCode, result.Attempts)
+	}
+
+	// the last response is returned when retries are exhausted
+	result = fetch(server.URL, map[string]any{"max_retries": 1, "retry_on_status": []any{503}})
+	if result.StatusCode != http.StatusServiceUnavailable || result.Error != "" {
+		t.Errorf("expected the last 503 response, got status %d, error %q", result.StatusCode, result.Error)
+	}
+	if result.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", result.Attempts)
+	}
+
+	// network errors are retried
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	result = fetch(closed.URL, map[string]any{"max_retries": 2})
+	if result.Error == "" || !strings.HasPrefix(result.Error, "request failed") {
+		t.Errorf("expected a request error, got %q", result.Error)
+	}
+	if result.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", result.Attempts)
+	}
+}
+
+func TestURLsFetchTool_Call_CompressedBody(t *testing.T) {
+	const page = "<html><head><title>Compressed</title></head><body><p>Decoded content.</p></body></html>"
+	compress := func(encoding string) []byte {
+		var buf bytes.Buffer
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(&buf)
+		case "deflate":
+			writer = zlib.NewWriter(&buf)
+		case "raw-deflate":
+			writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		}
+		writer.Write([]byte(page))
+		writer.Close()
+		return buf.Bytes()
+	}
+
+	// Create test server compressing the page with the encoding of the path
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+		w.Write(compress(encoding))
+	}))
+	defer server.Close()
+
+	tool := NewURLsFetchTool()
+	fetch := func(path string, arguments map[string]any) URLResult {
+		t.Helper()
+		arguments["urls"] = []any{server.URL + path}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-compressed",
+			Name:       "urls_fetch",
+			Arguments:  arguments,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results[0]
+	}
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		result := fetch("/"+encoding, map[string]any{"extract_text": true})
+		if result.Error != "" {
+			t.Fatalf("%s: unexpected error: %s", encoding, result.Error)
+		}
+		if result.Content != page {
+			t.Errorf("%s: expected the decoded page, got %q", encoding, result.Content)
+		}
+		if result.ContentLength != int64(len(page)) {
+			t.Errorf("%s: expected the decoded content length %d, got %d", encoding, len(page), result.ContentLength)
+		}
+		if !strings.Contains(result.TextContent, "Decoded content.") || result.Title != "Compressed" {
+			t.Errorf("%s: expected the text of the page, got %q (title %q)", encoding, result.TextContent, result.Title)
+		}
+	}
+	if acceptEncoding != "gzip, deflate" {
+		t.Errorf("expected the decoded encodings to be accepted, got %q", acceptEncoding)
+	}
+
+	// the size limit applies to the decoded content
+	result := fetch("/gzip", map[string]any{"max_body_size": 20})
+	if result.Content != page[:20] || result.ContentLength != 20 {
+		t.Errorf("expected the first 20 decoded bytes, got %q", result.Content)
+	}
+
+	// the requested encodings cannot be changed by the headers
+	fetch("/gzip", map[string]any{"headers": map[string]any{"Accept-Encoding": "br"}})
+	if acceptEncoding != "gzip, deflate" {
+		t.Errorf("expected the decoded encodings to be accepted, got %q", acceptEncoding)
+	}
+}
+
+func TestURLsFetchTool_Call_ElidesLargeBodies(t *testing.T) {
+	body := "BEGIN " + strings.Repeat("x", 100000) + " END"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	result, err := NewURLsFetchTool().Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "test-large",
+		Name:       "urls_fetch",
+		Arguments:  map[string]any{"urls": []any{server.URL}, "max_body_size": 100},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := result.Result["data"].(FetchResult).Results[0].Content
+	if len(content) > 100 || !strings.HasPrefix(content, "BEGIN ") || !strings.HasSuffix(content, " END") {
+		t.Errorf("expected the beginning and the end of the body within 100 bytes, got %q", content)
+	}
+	if !strings.Contains(content, "bytes omitted]") {
+		t.Errorf("expected the elided middle to be marked, got %q", content)
+	}
+}
+
+func TestURLsFetchTool_WithProxy(t *testing.T) {
+	// Create test proxy server recording the requested URLs
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	fetch := func(tool *URLsFetchTool, urlStr string) URLResult {
+		t.Helper()
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-proxy",
+			Name:       "urls_fetch",
+			Arguments:  map[string]any{"urls": []any{urlStr}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results[0]
+	}
+
+	result := fetch(NewURLsFetchTool().WithProxy(proxy.URL), "http://fetch.test/page")
+	if result.Error != "" || result.Content != "via proxy" {
+		t.Errorf("expected the content of the proxy, got %q (error %q)", result.Content, result.Error)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://fetch.test/page" {
+		t.Errorf("expected the request to go through the proxy, got %v", proxied)
+	}
+
+	// the environment variables are used by default
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "direct.test")
+	result = fetch(NewURLsFetchTool(), "http://env.test/page")
+	if result.Error != "" || len(proxied) != 2 || proxied[1] != "http://env.test/page" {
+		t.Errorf("expected the request to go through the environment proxy, got %v (error %q)", proxied, result.Error)
+	}
+	fetch(NewURLsFetchTool(), "http://direct.test/page")
+	if len(proxied) != 2 {
+		t.Errorf("expected NO_PROXY hosts to bypass the proxy, got %v", proxied)
+	}
+
+	// invalid proxies fail the calls
+	_, err := NewURLsFetchTool().WithProxy("proxy:3128").Call(context.Background(), &llms.ToolCall{
+		Arguments: map[string]any{"urls": []any{"http://fetch.test/page"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("expected invalid proxy error, got %v", err)
+	}
+
+	// tools without a transport get one
+	tool := (&URLsFetchTool{}).WithProxy(proxy.URL)
+	if tool.transport == nil {
+		t.Fatal("expected the transport to be created")
+	}
+	proxyURL, err := tool.transport.Proxy(httptest.NewRequest(http.MethodGet, "http://fetch.test/page", nil))
+	if err != nil || proxyURL.String() != proxy.URL {
+		t.Errorf("expected the proxy %q, got %v (error %v)", proxy.URL, proxyURL, err)
+	}
+}
@@ -0,0 +1,299 @@
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// extractMarkdownFromHTML converts HTML content to Markdown, keeping headings, lists, links,
+// emphasis, code blocks, quotes and tables. Relative links are resolved against the base URL.
+func extractMarkdownFromHTML(htmlContent string, base *url.URL) (markdown, title string) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return htmlContent, "" // Return original content if parsing fails
+	}
+
+	converter := &markdownConverter{base: base}
+	markdown = normalizeMarkdown(converter.node(doc))
+	return markdown, strings.TrimSpace(converter.title)
+}
+
+// markdownConverter renders HTML nodes as Markdown
+type markdownConverter struct {
+	base  *url.URL
+	title string
+}
+
+func (c *markdownConverter) children(n *html.Node) string {
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(c.node(child))
+	}
+	return builder.String()
+}
+
+func (c *markdownConverter) node(n *html.Node) string {
+	switch n.Type {
+	case html.DocumentNode:
+		return c.children(n)
+	case html.TextNode:
+		return whitespacePattern.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	tag := strings.ToLower(n.Data)
+	switch tag {
+	case "title":
+		if c.title == "" { // Get the first title
+			c.title = getTextContent(n)
+		}
+		return ""
+	case "script", "style", "noscript", "template", "svg", "iframe":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(c.children(n))
+		if text == "" {
+			return ""
+		}
+		level := int(tag[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "a":
+		return c.link(n)
+	case "img":
+		src := c.resolve(attribute(n, "src"))
+		if src == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)", attribute(n, "alt"), src)
+	case "strong", "b":
+		return wrapInline(c.children(n), "**")
+	case "em", "i":
+		return wrapInline(c.children(n), "*")
+	case "code":
+		code := getTextContent(n)
+		if strings.TrimSpace(code) == "" {
+			return ""
+		}
+		return "`" + code + "`"
+	case "pre":
+		return c.codeBlock(n)
+	case "ul", "ol":
+		return c.list(n, tag == "ol")
+	case "blockquote":
+		content := normalizeMarkdown(c.children(n))
+		if content == "" {
+			return ""
+		}
+		return "\n\n" + prefixLines(content, "> ") + "\n\n"
+	case "table":
+		return c.table(n)
+	case "p", "div", "section", "article", "main", "header", "footer", "nav", "aside",
+		"figure", "figcaption", "form", "fieldset", "details", "summary", "address", "dl", "dt", "dd":
+		return "\n\n" + strings.TrimSpace(c.children(n)) + "\n\n"
+	default:
+		return c.children(n)
+	}
+}
+
+func (c *markdownConverter) link(n *html.Node) string {
+	text := strings.TrimSpace(c.children(n))
+	href := attribute(n, "href")
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+	href = c.resolve(href)
+	if text == "" {
+		text = href
+	}
+	return fmt.Sprintf("[%s](%s)", text, href)
+}
+
+// codeBlock renders a pre element as a fenced code block, using the language-* class of its code element
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	language := ""
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || strings.ToLower(child.Data) != "code" {
+			continue
+		}
+		for _, class := range strings.Fields(attribute(child, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				language = lang
+				break
+			}
+		}
+	}
+	code := strings.Trim(getTextContent(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return "\n\n" + fence + language + "\n" + code + "\n" + fence + "\n\n"
+}
+
+func (c *markdownConverter) list(n *html.Node, ordered bool) string {
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || strings.ToLower(child.Data) != "li" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", len(items)+1)
+		}
+		content := normalizeMarkdown(c.children(child))
+		// nested blocks are indented under the item, without blank lines
+		content = strings.ReplaceAll(content, "\n\n", "\n")
+		items = append(items, marker+indentLines(content, strings.Repeat(" ", len(marker))))
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if strings.ToLower(child.Data) != "tr" {
+				collect(child)
+				continue
+			}
+			var cells []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (strings.ToLower(cell.Data) == "td" || strings.ToLower(cell.Data) == "th") {
+					text := strings.TrimSpace(whitespacePattern.ReplaceAllString(c.children(cell), " "))
+					cells = append(cells, strings.ReplaceAll(text, "|", "\\|"))
+				}
+			}
+			if len(cells) > 0 {
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var builder strings.Builder
+	builder.WriteString("\n\n")
+	for idx, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		builder.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if idx == 0 {
+			builder.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+func (c *markdownConverter) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if c.base == nil || href == "" {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return c.base.ResolveReference(ref).String()
+}
+
+// attribute returns the value of an attribute of the node, empty if missing
+func attribute(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// wrapInline wraps inline text with a marker, keeping the surrounding spaces outside of it
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailing := text[len(strings.TrimRight(text, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// indentLines indents every line but the first one
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for idx := 1; idx < len(lines); idx++ {
+		if lines[idx] != "" {
+			lines[idx] = indent + lines[idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes every line
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeMarkdown removes the whitespace left between blocks: blank lines are collapsed,
+// lines starting a block lose their leading spaces, code blocks are kept as is
+func normalizeMarkdown(markdown string) string {
+	var lines []string
+	fence := ""
+	blank := true
+	for _, line := range strings.Split(markdown, "\n") {
+		if fence != "" {
+			lines = append(lines, line)
+			if strings.TrimSpace(line) == fence {
+				fence = ""
+			}
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		if blank {
+			line = strings.TrimLeft(line, " \t")
+		}
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "`"))]
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,130 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const markdownTestPage = `<!DOCTYPE html>
+<html>
+<head><title>Docs</title><style>body { color: red; }</style></head>
+<body>
+	<h1>Getting Started</h1>
+	<p>Read the <a href="/guide">installation guide</a> or the <a href="https://example.org/faq">FAQ</a>.</p>
+	<h2>Features</h2>
+	<ul>
+		<li>Fast <strong>streaming</strong></li>
+		<li>Tools
+			<ol>
+				<li>Fetch</li>
+				<li>Search</li>
+			</ol>
+		</li>
+	</ul>
+	<pre><code class="language-go">func main() {
+	fmt.Println("hello")
+}</code></pre>
+	<blockquote><p>Quoted text</p></blockquote>
+	<script>console.log('ignored');</script>
+</body>
+</html>`
+
+func TestExtractMarkdownFromHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/index.html")
+	markdown, title := extractMarkdownFromHTML(markdownTestPage, base)
+
+	if title != "Docs" {
+		t.Errorf("expected title 'Docs', got '%s'", title)
+	}
+
+	expected := "# Getting Started\n\n" +
+		"Read the [installation guide](https://example.com/guide) or the [FAQ](https://example.org/faq).\n\n" +
+		"## Features\n\n" +
+		"- Fast **streaming**\n" +
+		"- Tools\n" +
+		"  1. Fetch\n" +
+		"  2. Search\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n\n" +
+		"> Quoted text"
+	if markdown != expected {
+		t.Errorf("unexpected markdown:\n%s\n\nexpected:\n%s", markdown, expected)
+	}
+}
+
+func TestExtractMarkdownFromHTML_Tables(t *testing.T) {
+	markdown, _ := extractMarkdownFromHTML(`<table>
+		<tr><th>Name</th><th>Value</th></tr>
+		<tr><td>a|b</td><td><em>1</em></td></tr>
+	</table>`, nil)
+
+	expected := "| Name | Value |\n| --- | --- |\n| a\\|b | *1* |"
+	if markdown != expected {
+		t.Errorf("unexpected markdown:\n%s\n\nexpected:\n%s", markdown, expected)
+	}
+}
+
+func TestURLsFetchTool_Call_MarkdownOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(markdownTestPage))
+	}))
+	defer server.Close()
+
+	tool := NewURLsFetchTool()
+	call := func(arguments map[string]any) (URLResult, error) {
+		arguments["urls"] = []any{server.URL}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-markdown",
+			Name:       "urls_fetch",
+			Arguments:  arguments,
+		})
+		if err != nil {
+			return URLResult{}, err
+		}
+		return result.Result["data"].(FetchResult).Results[0], nil
+	}
+
+	result, err := call(map[string]any{"output_format": "markdown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result.Markdown, "# Getting Started\n") {
+		t.Errorf("expected heading to become '#', got: %s", result.Markdown)
+	}
+	if !strings.Contains(result.Markdown, "[installation guide]("+server.URL+"/guide)") {
+		t.Errorf("expected link to become [text](url), got: %s", result.Markdown)
+	}
+	if result.TextContent != "" {
+		t.Error("expected no text content for markdown output")
+	}
+	if result.Title != "Docs" {
+		t.Errorf("expected title 'Docs', got '%s'", result.Title)
+	}
+
+	// the text format is the extract_text behavior
+	result, err = call(map[string]any{"output_format": "text"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Markdown != "" || !strings.Contains(result.TextContent, "Getting Started Read the installation guide") {
+		t.Errorf("unexpected text output: %+v", result)
+	}
+
+	result, err = call(map[string]any{"output_format": "raw"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Markdown != "" || result.TextContent != "" || result.Content != markdownTestPage {
+		t.Errorf("unexpected raw output: %+v", result)
+	}
+
+	if _, err := call(map[string]any{"output_format": "pdf"}); err == nil {
+		t.Error("expected error for unsupported output format")
+	}
+}
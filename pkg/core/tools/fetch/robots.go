@@ -0,0 +1,295 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRobotsCacheTTL is how long the robots.txt of an origin is cached by default
+	DefaultRobotsCacheTTL = time.Hour
+
+	// robotsFetchTimeout bounds the fetch of a robots.txt, which outlives the call starting it
+	robotsFetchTimeout = 30 * time.Second
+
+	// robotsMaxSize is the size of the robots.txt files parsed, the rest is ignored
+	robotsMaxSize = 500 * 1024
+
+	// robotsDisallowedError is the error of the URLs skipped because of robots.txt
+	robotsDisallowedError = "disallowed by robots.txt"
+)
+
+// WithRobotsCacheTTL sets how long the robots.txt of an origin is cached when respect_robots
+// is set, DefaultRobotsCacheTTL by default
+func (t *URLsFetchTool) WithRobotsCacheTTL(ttl time.Duration) *URLsFetchTool {
+	if t.robots == nil {
+		t.robots = newRobotsCache()
+	}
+	t.robots.ttl = ttl
+	return t
+}
+
+// robotsAllowed tells whether the robots.txt of the origin of the URL allows the user agent
+// to fetch it, as specified by RFC 9309
+func (t *URLsFetchTool) robotsAllowed(ctx context.Context, client *http.Client, target *url.URL, userAgent string) (bool, error) {
+	origin := target.Scheme + "://" + target.Host
+	if t.robots == nil {
+		// tools not made by NewURLsFetchTool fetch the robots.txt at each call
+		rules, _ := fetchRobots(ctx, client, origin, userAgent)
+		return rules.allowed(userAgent, robotsPath(target)), nil
+	}
+	rules, err := t.robots.get(ctx, origin, func(fetchCtx context.Context) (*robotsRules, bool) {
+		return fetchRobots(fetchCtx, client, origin, userAgent)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return rules.allowed(userAgent, robotsPath(target)), nil
+}
+
+// robotsPath is the path of the URL matched against the rules, with its query
+func robotsPath(target *url.URL) string {
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return path
+}
+
+// fetchRobots fetches and parses the robots.txt of the origin, telling whether the rules may be
+// cached. A missing robots.txt allows every URL, an unreachable one disallows them all until it
+// is fetched again, its failure being transient.
+func fetchRobots(ctx context.Context, client *http.Client, origin, userAgent string) (*robotsRules, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return disallowAllRobots, false
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	// The redirects of robots.txt are followed, whatever follow_redirect
+	robotsClient := &http.Client{Timeout: client.Timeout, Transport: client.Transport}
+	resp, err := robotsClient.Do(req)
+	if err != nil {
+		return disallowAllRobots, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, robotsMaxSize)), true
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsRules{}, true
+	default:
+		return disallowAllRobots, false
+	}
+}
+
+// robotsCache caches the robots.txt rules by origin, each origin being fetched once at a time
+type robotsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	ready   chan struct{} // Closed once the rules are fetched
+	rules   *robotsRules
+	expires time.Time
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		ttl:     DefaultRobotsCacheTTL,
+		entries: make(map[string]*robotsEntry),
+	}
+}
+
+// get returns the cached rules of the origin, fetching them when they are missing or expired.
+// The fetch is detached from the context of the caller, which only stops waiting for it: the
+// other callers of the origin share it. The rules of a failed fetch are not cached.
+func (c *robotsCache) get(ctx context.Context, origin string,
+	fetch func(ctx context.Context) (*robotsRules, bool)) (*robotsRules, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[origin]
+	if ok && entry.isExpired() {
+		ok = false
+	}
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.entries[origin] = entry
+		go c.fetch(context.WithoutCancel(ctx), origin, entry, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.rules, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", ctx.Err())
+	}
+}
+
+// fetch fetches the rules of the entry, dropping the entry once ready when they may not be cached
+func (c *robotsCache) fetch(ctx context.Context, origin string, entry *robotsEntry,
+	fetch func(ctx context.Context) (*robotsRules, bool)) {
+	ctx, cancel := context.WithTimeout(ctx, robotsFetchTimeout)
+	defer cancel()
+
+	rules, cacheable := fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.rules = rules
+	entry.expires = time.Now().Add(c.ttl)
+	close(entry.ready)
+	if !cacheable && c.entries[origin] == entry {
+		delete(c.entries, origin)
+	}
+}
+
+// isExpired tells whether the rules are fetched and expired, the rules being fetched are not
+func (e *robotsEntry) isExpired() bool {
+	select {
+	case <-e.ready:
+		return !time.Now().Before(e.expires)
+	default:
+		return false
+	}
+}
+
+// robotsRules are the rules of a robots.txt, by user agent
+type robotsRules struct {
+	groups []*robotsGroup
+}
+
+type robotsGroup struct {
+	userAgents []string // Lower-cased user agents, "*" for all
+	rules      []robotsRule
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// disallowAllRobots are the rules of the origins whose robots.txt is unreachable
+var disallowAllRobots = &robotsRules{groups: []*robotsGroup{{
+	userAgents: []string{"*"},
+	rules:      []robotsRule{{allow: false, pattern: "/"}},
+}}}
+
+// parseRobots parses the groups of user-agent lines followed by their allow and disallow rules
+func parseRobots(reader io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	var group *robotsGroup
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share their rules
+			if group == nil || len(group.rules) > 0 {
+				group = &robotsGroup{}
+				rules.groups = append(rules.groups, group)
+			}
+			group.userAgents = append(group.userAgents, strings.ToLower(value))
+		case "allow", "disallow":
+			// An empty disallow rule allows everything, as no rule does
+			if group == nil || value == "" {
+				continue
+			}
+			group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+		}
+	}
+	return rules
+}
+
+// allowed tells whether the path is allowed to the user agent: the most specific rule of the
+// groups of the user agent, or else of the "*" groups, applies, allow winning ties
+func (r *robotsRules) allowed(userAgent, path string) bool {
+	userAgent = strings.ToLower(userAgent)
+
+	var matched []*robotsGroup
+	longestAgent := 0
+	for _, group := range r.groups {
+		for _, agent := range group.userAgents {
+			if agent == "*" || !strings.Contains(userAgent, agent) {
+				continue
+			}
+			if len(agent) > longestAgent {
+				matched, longestAgent = nil, len(agent)
+			}
+			if len(agent) == longestAgent {
+				matched = append(matched, group)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		for _, group := range r.groups {
+			for _, agent := range group.userAgents {
+				if agent == "*" {
+					matched = append(matched, group)
+				}
+			}
+		}
+	}
+
+	allowed := true
+	longestPattern := -1
+	for _, group := range matched {
+		for _, rule := range group.rules {
+			if !matchRobotsPattern(rule.pattern, path) {
+				continue
+			}
+			if len(rule.pattern) > longestPattern || (len(rule.pattern) == longestPattern && rule.allow) {
+				allowed, longestPattern = rule.allow, len(rule.pattern)
+			}
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern tells whether the path starts with the pattern, where '*' matches any
+// characters and a trailing '$' anchors the pattern at the end of the path
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,227 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const testRobots = `# robots of the test site
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: BadBot
+User-agent: WorseBot
+Disallow: /
+`
+
+func TestRobotsRules_Allowed(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots))
+
+	tests := []struct {
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"agent-go/1.0", "/", true},
+		{"agent-go/1.0", "/page", true},
+		{"agent-go/1.0", "/private", false},
+		{"agent-go/1.0", "/private/secret?id=1", false},
+		{"agent-go/1.0", "/private/public/page", true},
+		{"agent-go/1.0", "/docs/report.pdf", false},
+		{"agent-go/1.0", "/docs/report.pdf?download=1", true},
+		{"BadBot/2.0", "/page", false},
+		{"Mozilla/5.0 (compatible; worsebot)", "/page", false},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.userAgent, tt.path); got != tt.allowed {
+			t.Errorf("allowed(%q, %q) = %v, expected %v", tt.userAgent, tt.path, got, tt.allowed)
+		}
+	}
+
+	// no rules allow everything
+	if !parseRobots(strings.NewReader("User-agent: *\nDisallow:\n")).allowed("agent-go", "/private") {
+		t.Error("expected an empty disallow rule to allow everything")
+	}
+	if disallowAllRobots.allowed("agent-go", "/page") {
+		t.Error("expected every path to be disallowed")
+	}
+}
+
+func TestURLsFetchTool_Call_RespectRobots(t *testing.T) {
+	// Create test server allowing /public and disallowing /private
+	var robotsRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsRequests.Add(1)
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		default:
+			w.Write([]byte("page " + r.URL.Path))
+		}
+	}))
+	defer server.Close()
+
+	fetch := func(tool *URLsFetchTool, arguments map[string]any) []URLResult {
+		t.Helper()
+		arguments["urls"] = []any{server.URL + "/public", server.URL + "/private/page"}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-robots",
+			Name:       "urls_fetch",
+			Arguments:  arguments,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results
+	}
+
+	tool := NewURLsFetchTool()
+	results := fetch(tool, map[string]any{"respect_robots": true})
+	if results[0].Error != "" || results[0].Content != "page /public" {
+		t.Errorf("expected the allowed page, got %q (error %q)", results[0].Content, results[0].Error)
+	}
+	if results[1].Error != "disallowed by robots.txt" || results[1].Attempts != 0 {
+		t.Errorf("expected the disallowed page to be skipped, got %+v", results[1])
+	}
+
+	// robots.txt is cached by the tool
+	fetch(tool, map[string]any{"respect_robots": true})
+	if robotsRequests.Load() != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d requests", robotsRequests.Load())
+	}
+
+	// robots.txt is ignored by default
+	results = fetch(tool, map[string]any{})
+	if results[1].Error != "" || results[1].Content != "page /private/page" {
+		t.Errorf("expected the page to be fetched, got %q (error %q)", results[1].Content, results[1].Error)
+	}
+
+	// robots.txt is fetched again once expired
+	robotsRequests.Store(0)
+	expiring := NewURLsFetchTool().WithRobotsCacheTTL(time.Nanosecond)
+	fetch(expiring, map[string]any{"respect_robots": true})
+	fetch(expiring, map[string]any{"respect_robots": true})
+	if robotsRequests.Load() != 2 {
+		t.Errorf("expected robots.txt to be fetched twice, got %d requests", robotsRequests.Load())
+	}
+
+	// tools not made by NewURLsFetchTool work too
+	results = fetch(&URLsFetchTool{}, map[string]any{"respect_robots": true})
+	if results[0].Error != "" || results[0].Content != "page /public" {
+		t.Errorf("expected the allowed page, got %q (error %q)", results[0].Content, results[0].Error)
+	}
+	if results[1].Error != "disallowed by robots.txt" {
+		t.Errorf("expected the disallowed page to be skipped, got %+v", results[1])
+	}
+}
+
+func TestURLsFetchTool_Call_RespectRobotsUnavailable(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	fetch := func() URLResult {
+		t.Helper()
+		result, err := NewURLsFetchTool().Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-robots",
+			Name:       "urls_fetch",
+			Arguments:  map[string]any{"urls": []any{server.URL + "/page"}, "respect_robots": true},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results[0]
+	}
+
+	// a missing robots.txt allows everything
+	if result := fetch(); result.Error != "" {
+		t.Errorf("expected the page to be fetched, got error %q", result.Error)
+	}
+
+	// an unavailable robots.txt disallows everything
+	status = http.StatusServiceUnavailable
+	if result := fetch(); result.Error != "disallowed by robots.txt" {
+		t.Errorf("expected the page to be skipped, got error %q", result.Error)
+	}
+}
+
+func TestURLsFetchTool_Call_RespectRobotsTransientFailure(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	tool := NewURLsFetchTool()
+	fetch := func() URLResult {
+		t.Helper()
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "test-robots",
+			Name:       "urls_fetch",
+			Arguments:  map[string]any{"urls": []any{server.URL + "/page"}, "respect_robots": true},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Result["data"].(FetchResult).Results[0]
+	}
+
+	if result := fetch(); result.Error != "disallowed by robots.txt" {
+		t.Errorf("expected the page to be skipped, got error %q", result.Error)
+	}
+
+	// the failure is not cached, the recovered robots.txt is fetched again
+	status.Store(http.StatusNotFound)
+	if result := fetch(); result.Error != "" {
+		t.Errorf("expected the page to be fetched, got error %q", result.Error)
+	}
+}
+
+func TestRobotsCache_FetchDetachedFromCaller(t *testing.T) {
+	cache := newRobotsCache()
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(ctx context.Context) (*robotsRules, bool) {
+		fetches.Add(1)
+		<-release
+		if ctx.Err() != nil {
+			return disallowAllRobots, false
+		}
+		return &robotsRules{}, true
+	}
+
+	// the first caller gives up, the fetch goes on for the next ones
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cache.get(ctx, "https://example.com", fetch); err == nil {
+		t.Fatal("expected the canceled caller to stop waiting")
+	}
+	close(release)
+	rules, err := cache.get(context.Background(), "https://example.com", fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rules.allowed("agent-go", "/page") || fetches.Load() != 1 {
+		t.Errorf("expected the rules of the single fetch, got %d fetches", fetches.Load())
+	}
+}
@@ -1,15 +1,21 @@
 package fs
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/oopslink/agent-go/pkg/commons/utils"
 	"github.com/oopslink/agent-go/pkg/core/tools"
 	"github.com/oopslink/agent-go/pkg/support/llms"
 	"gopkg.in/yaml.v3"
@@ -26,6 +32,7 @@ type DirectoryMeta struct {
 // FileSystemTools provides a collection of file system tools with a root path restriction
 type FileSystemTools struct {
 	rootPath string
+	readOnly bool
 }
 
 // NewFileSystemTools creates a new file system tools instance with the specified root path
@@ -45,41 +52,115 @@ func NewFileSystemTools(rootPath string) (*FileSystemTools, error) {
 	}, nil
 }
 
-// GetTools returns all available file system tools
+// NewReadOnlyFileSystemTools creates a file system tools instance with the specified root path
+// whose tools only list, stat, find, search and read the files, for the agents that must not change them.
+// Unlike NewFileSystemTools, the root directory must exist.
+func NewReadOnlyFileSystemTools(rootPath string) (*FileSystemTools, error) {
+	absRoot, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for root: %w", err)
+	}
+
+	stat, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access root directory: %w", err)
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("root is not a directory: %s", rootPath)
+	}
+
+	return &FileSystemTools{
+		rootPath: absRoot,
+		readOnly: true,
+	}, nil
+}
+
+// IsReadOnly tells whether the tools only read the file system
+func (fst *FileSystemTools) IsReadOnly() bool {
+	return fst.readOnly
+}
+
+// GetTools returns all available file system tools, only the ones reading the file system
+// when the instance is read-only
 func (fst *FileSystemTools) GetTools() []tools.Tool {
-	return []tools.Tool{
+	readTools := []tools.Tool{
 		NewListDirectoryTool(fst.rootPath),
 		NewGetFileStatTool(fst.rootPath),
 		NewReadFileTool(fst.rootPath),
+		NewFindFilesTool(fst.rootPath),
+		NewGrepTool(fst.rootPath),
+	}
+	if fst.readOnly {
+		return readTools
+	}
+	return append(readTools,
 		NewWriteFileTool(fst.rootPath),
 		NewCreateFileTool(fst.rootPath),
 		NewDeleteFileTool(fst.rootPath),
 		NewCreateDirectoryTool(fst.rootPath),
-	}
+		NewCopyFileTool(fst.rootPath),
+		NewEditFileTool(fst.rootPath),
+	)
 }
 
-// validatePath ensures the path is within the root directory
+// maxSymlinkDepth limits the symlinks followed when resolving a path that does not exist yet
+const maxSymlinkDepth = 40
+
+// validatePath ensures the path is within the root directory and returns it with its symlinks
+// resolved, so the file operated on is the one that was checked
 func (fst *FileSystemTools) validatePath(path string) (string, error) {
 	cleanPath := filepath.Clean(path)
 	absPath := filepath.Join(fst.rootPath, cleanPath)
 
 	// Resolve any symlinks to prevent directory traversal
-	resolvedPath, err := filepath.EvalSymlinks(absPath)
-	if err != nil && !os.IsNotExist(err) {
+	resolvedPath, err := resolvePath(absPath, 0)
+	if err != nil {
 		return "", fmt.Errorf("failed to resolve path: %w", err)
 	}
 
-	// If file doesn't exist, use the original absolute path for validation
-	if os.IsNotExist(err) {
-		resolvedPath = absPath
-	}
-
 	// Ensure the resolved path is still within the root
-	if !strings.HasPrefix(resolvedPath, fst.rootPath) {
+	if !isSubPath(fst.resolvedRoot(), resolvedPath) {
 		return "", fmt.Errorf("path '%s' is outside the allowed root directory", path)
 	}
 
-	return absPath, nil
+	return resolvedPath, nil
+}
+
+// resolvedRoot returns the root directory with its symlinks resolved
+func (fst *FileSystemTools) resolvedRoot() string {
+	root, err := filepath.EvalSymlinks(fst.rootPath)
+	if err != nil {
+		return fst.rootPath
+	}
+	return root
+}
+
+// resolvePath resolves the symlinks of an absolute path. When the path does not exist yet,
+// the symlinks of its parents, and the target of a dangling symlink, are resolved instead,
+// giving the path a file created at it would end up at.
+func resolvePath(path string, depth int) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil || !os.IsNotExist(err) {
+		return resolved, err
+	}
+	if depth > maxSymlinkDepth {
+		return "", fmt.Errorf("too many levels of symbolic links in '%s'", path)
+	}
+
+	parent, err := resolvePath(filepath.Dir(path), depth)
+	if err != nil {
+		return "", err
+	}
+	candidate := filepath.Join(parent, filepath.Base(path))
+	target, err := os.Readlink(candidate)
+	if err != nil {
+		// not a symlink, the path does not exist yet
+		return candidate, nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(parent, target)
+	}
+	return resolvePath(target, depth+1)
 }
 
 // loadDirectoryMeta loads metadata from .meta.yaml file in the directory
@@ -343,6 +424,9 @@ func (t *GetFileStatTool) Call(ctx context.Context, params *llms.ToolCall) (*llm
 
 // ===== Read File Tool =====
 
+// DefaultMaxReadBytes is the maximum number of bytes fs_read_file returns by default
+const DefaultMaxReadBytes = 1024 * 1024 // 1MB
+
 type ReadFileTool struct {
 	rootPath string
 }
@@ -354,13 +438,16 @@ func NewReadFileTool(rootPath string) *ReadFileTool {
 var _ tools.Tool = &ReadFileTool{}
 
 type ReadFileParams struct {
-	Path string `json:"path"`
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	MaxBytes  int    `json:"max_bytes,omitempty"` // Maximum number of bytes returned (default: 1MB)
 }
 
 func (t *ReadFileTool) Descriptor() *llms.ToolDescriptor {
 	return &llms.ToolDescriptor{
 		Name:        "fs_read_file",
-		Description: "Read the contents of a file.",
+		Description: "Read the contents of a file, or only a range of its lines for large files. The content is truncated to max_bytes: the middle of a file is elided, a range of lines ends early.",
 		Parameters: &llms.Schema{
 			Type: llms.TypeObject,
 			Properties: map[string]*llms.Schema{
@@ -368,6 +455,18 @@ func (t *ReadFileTool) Descriptor() *llms.ToolDescriptor {
 					Type:        llms.TypeString,
 					Description: "Relative path from root directory to the file to read",
 				},
+				"start_line": {
+					Type:        llms.TypeInteger,
+					Description: "First line to read, 1-based (default: 1)",
+				},
+				"end_line": {
+					Type:        llms.TypeInteger,
+					Description: "Last line to read, inclusive (default: the last line of the file)",
+				},
+				"max_bytes": {
+					Type:        llms.TypeInteger,
+					Description: "Maximum number of bytes of content to return (default: 1048576)",
+				},
 			},
 			Required: []string{"path"},
 		},
@@ -379,6 +478,9 @@ func (t *ReadFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.T
 	if err := mapToStruct(params.Arguments, &readParams); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
+	if readParams.MaxBytes <= 0 {
+		readParams.MaxBytes = DefaultMaxReadBytes
+	}
 
 	fst := &FileSystemTools{rootPath: t.rootPath}
 	absPath, err := fst.validatePath(readParams.Path)
@@ -386,23 +488,136 @@ func (t *ReadFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.T
 		return nil, err
 	}
 
-	content, err := os.ReadFile(absPath)
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	if readParams.StartLine == 0 && readParams.EndLine == 0 {
+		// the middle of the larger files is elided, keeping their beginning and end
+		content, err := utils.ReadMiddleToFit(file, readParams.MaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		truncated := int64(len(content)) < stat.Size()
+		return &llms.ToolCallResult{
+			ToolCallId: params.ToolCallId,
+			Name:       params.Name,
+			Result: map[string]any{
+				"success":    true,
+				"path":       readParams.Path,
+				"content":    content,
+				"size":       len(content),
+				"total_size": stat.Size(),
+				"truncated":  truncated,
+			},
+		}, nil
+	}
+
+	lines, err := readLines(file, readParams.StartLine, readParams.EndLine, readParams.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
 	return &llms.ToolCallResult{
 		ToolCallId: params.ToolCallId,
 		Name:       params.Name,
 		Result: map[string]any{
-			"success": true,
-			"path":    readParams.Path,
-			"content": string(content),
-			"size":    len(content),
+			"success":     true,
+			"path":        readParams.Path,
+			"content":     lines.content,
+			"size":        len(lines.content),
+			"start_line":  lines.start,
+			"end_line":    lines.end,
+			"total_lines": lines.total,
+			"total_size":  stat.Size(),
+			"truncated":   lines.truncated,
 		},
 	}, nil
 }
 
+// lineRange is a range of the lines of a file
+type lineRange struct {
+	content    string
+	start, end int
+	total      int
+	truncated  bool
+}
+
+// readLines reads the lines from startLine to endLine (1-based, inclusive) with their
+// original line endings, up to maxBytes, without loading the rest of the file. The range
+// is clamped to the content: startLine below 1 starts at the first line, endLine below 1
+// or past the end stops at the last line, and a range starting past the end is empty.
+// When the lines are truncated, the range ends at the last line read, even partially.
+func readLines(reader io.Reader, startLine, endLine, maxBytes int) (*lineRange, error) {
+	start := max(startLine, 1)
+	inRange := func(line int) bool {
+		return line >= start && (endLine <= 0 || line <= endLine)
+	}
+
+	var content []byte
+	truncated := false
+	lastLine := 0
+	total := 0
+	bufReader := bufio.NewReader(reader)
+	for line := 1; ; {
+		// the lines longer than the buffer are read in fragments
+		fragment, err := bufReader.ReadSlice('\n')
+		if len(fragment) > 0 {
+			total = line
+			if inRange(line) && !truncated {
+				if room := maxBytes - len(content); len(fragment) > room {
+					truncated = true
+					if part := truncateAtRune(fragment, room); len(part) > 0 {
+						content = append(content, part...)
+						lastLine = line
+					}
+				} else {
+					content = append(content, fragment...)
+					lastLine = line
+				}
+			}
+			if fragment[len(fragment)-1] == '\n' {
+				line++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+	}
+
+	end := endLine
+	if end <= 0 || end > total {
+		end = total
+	}
+	if truncated {
+		end = lastLine
+	}
+	if start > end {
+		return &lineRange{start: start, end: start - 1, total: total, truncated: truncated}, nil
+	}
+	return &lineRange{content: string(content), start: start, end: end, total: total, truncated: truncated}, nil
+}
+
+// truncateAtRune truncates the content to at most n bytes without splitting a UTF-8 character
+func truncateAtRune(content []byte, n int) []byte {
+	if n >= len(content) {
+		return content
+	}
+	for n > 0 && !utf8.RuneStart(content[n]) {
+		n--
+	}
+	return content[:n]
+}
+
 // ===== Write File Tool =====
 
 type WriteFileTool struct {
@@ -419,12 +634,13 @@ type WriteFileParams struct {
 	Path    string `json:"path"`
 	Content string `json:"content"`
 	Mode    string `json:"mode,omitempty"`
+	Append  bool   `json:"append,omitempty"`
 }
 
 func (t *WriteFileTool) Descriptor() *llms.ToolDescriptor {
 	return &llms.ToolDescriptor{
 		Name:        "fs_write_file",
-		Description: "Write content to a file. Creates directories as needed.",
+		Description: "Write content to a file, or append it to the file. Creates directories as needed.",
 		Parameters: &llms.Schema{
 			Type: llms.TypeObject,
 			Properties: map[string]*llms.Schema{
@@ -440,6 +656,10 @@ func (t *WriteFileTool) Descriptor() *llms.ToolDescriptor {
 					Type:        llms.TypeString,
 					Description: "File permissions in octal format (default: '0644')",
 				},
+				"append": {
+					Type:        llms.TypeBoolean,
+					Description: "Whether to append the content to the end of the file instead of overwriting it (default: false)",
+				},
 			},
 			Required: []string{"path", "content"},
 		},
@@ -473,7 +693,12 @@ func (t *WriteFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.
 		mode = fs.FileMode(modeInt)
 	}
 
-	if err := os.WriteFile(absPath, []byte(writeParams.Content), mode); err != nil {
+	size := len(writeParams.Content)
+	if writeParams.Append {
+		if size, err = appendFile(absPath, []byte(writeParams.Content), mode); err != nil {
+			return nil, fmt.Errorf("failed to append to file: %w", err)
+		}
+	} else if err := os.WriteFile(absPath, []byte(writeParams.Content), mode); err != nil {
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -483,11 +708,30 @@ func (t *WriteFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.
 		Result: map[string]any{
 			"success": true,
 			"path":    writeParams.Path,
-			"size":    len(writeParams.Content),
+			"size":    size,
 		},
 	}, nil
 }
 
+// appendFile appends the data to the file, creating it with the mode if it does not exist,
+// and returns the new size of the file
+func appendFile(path string, data []byte, mode fs.FileMode) (int, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, mode)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return 0, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return int(stat.Size()), nil
+}
+
 // ===== Create File Tool =====
 
 type CreateFileTool struct {
@@ -744,6 +988,782 @@ func (t *CreateDirectoryTool) Call(ctx context.Context, params *llms.ToolCall) (
 	}, nil
 }
 
+// ===== Copy File Tool =====
+
+type CopyFileTool struct {
+	rootPath string
+}
+
+func NewCopyFileTool(rootPath string) *CopyFileTool {
+	return &CopyFileTool{rootPath: rootPath}
+}
+
+var _ tools.Tool = &CopyFileTool{}
+
+type CopyFileParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Recursive   bool   `json:"recursive,omitempty"`
+}
+
+func (t *CopyFileTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "fs_copy_file",
+		Description: "Copy a file, or a directory with recursive=true. The destination must not exist or be an empty directory.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"source": {
+					Type:        llms.TypeString,
+					Description: "Relative path from root directory to the file/directory to copy",
+				},
+				"destination": {
+					Type:        llms.TypeString,
+					Description: "Relative path from root directory to the copy",
+				},
+				"recursive": {
+					Type:        llms.TypeBoolean,
+					Description: "Whether to copy directories recursively (default: false)",
+				},
+			},
+			Required: []string{"source", "destination"},
+		},
+	}
+}
+
+func (t *CopyFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var copyParams CopyFileParams
+	if err := mapToStruct(params.Arguments, &copyParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	fst := &FileSystemTools{rootPath: t.rootPath}
+	sourcePath, err := fst.validatePath(copyParams.Source)
+	if err != nil {
+		return nil, err
+	}
+	destinationPath, err := fst.validatePath(copyParams.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat source: %w", err)
+	}
+	if stat.IsDir() && !copyParams.Recursive {
+		return nil, fmt.Errorf("source is a directory, use recursive=true to copy")
+	}
+	if stat.IsDir() && isSubPath(sourcePath, destinationPath) {
+		return nil, fmt.Errorf("cannot copy directory '%s' into itself", copyParams.Source)
+	}
+
+	// Refuse to overwrite anything at the destination
+	if destinationStat, err := os.Stat(destinationPath); err == nil {
+		if !destinationStat.IsDir() {
+			return nil, fmt.Errorf("destination already exists: %s", copyParams.Destination)
+		}
+		if !stat.IsDir() {
+			return nil, fmt.Errorf("destination is a directory: %s", copyParams.Destination)
+		}
+		entries, err := os.ReadDir(destinationPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read destination directory: %w", err)
+		}
+		if len(entries) > 0 {
+			return nil, fmt.Errorf("destination is not empty: %s", copyParams.Destination)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat destination: %w", err)
+	}
+
+	var filesCopied, bytesCopied int64
+	if stat.IsDir() {
+		filesCopied, bytesCopied, err = copyDirectory(ctx, sourcePath, destinationPath)
+	} else {
+		bytesCopied, err = copyFile(sourcePath, destinationPath, stat.Mode())
+		filesCopied = 1
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy: %w", err)
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":      true,
+			"source":       copyParams.Source,
+			"destination":  copyParams.Destination,
+			"files_copied": filesCopied,
+			"bytes_copied": bytesCopied,
+		},
+	}, nil
+}
+
+// copyDirectory copies the directory tree, preserving the file and directory modes
+func copyDirectory(ctx context.Context, sourcePath, destinationPath string) (filesCopied, bytesCopied int64, err error) {
+	// the directories stay writable while their content is copied, their modes are applied once done
+	type directoryMode struct {
+		path string
+		mode fs.FileMode
+	}
+	var directoryModes []directoryMode
+	err = filepath.WalkDir(sourcePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destinationPath, relPath)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+			directoryModes = append(directoryModes, directoryMode{path: target, mode: info.Mode().Perm()})
+			return os.Chmod(target, info.Mode().Perm()|0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil // Skip symlinks, devices and other special files
+		}
+
+		n, err := copyFile(path, target, info.Mode())
+		if err != nil {
+			return err
+		}
+		filesCopied++
+		bytesCopied += n
+		return nil
+	})
+	if err != nil {
+		return filesCopied, bytesCopied, err
+	}
+
+	// the directories are walked parents first, their modes are applied deepest first
+	for i := len(directoryModes) - 1; i >= 0; i-- {
+		if err := os.Chmod(directoryModes[i].path, directoryModes[i].mode); err != nil {
+			return filesCopied, bytesCopied, err
+		}
+	}
+	return filesCopied, bytesCopied, nil
+}
+
+// copyFile copies a regular file, creating parent directories as needed
+func copyFile(sourcePath, destinationPath string, mode fs.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(destinationPath), 0755); err != nil {
+		return 0, err
+	}
+
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(destinationPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	// Apply the mode regardless of the umask
+	return n, os.Chmod(destinationPath, mode.Perm())
+}
+
+// isSubPath reports whether path is parent or inside parent
+func isSubPath(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	return err == nil && (rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))))
+}
+
+// ===== Find Files Tool =====
+
+const (
+	defaultFindMaxResults  = 100
+	maxFindLineMatches     = 20      // Content hits reported per file
+	maxFindLineLength      = 200     // Longer matching lines are shortened
+	maxFindContentFileSize = 1 << 20 // Larger files are not searched by content
+	binaryCheckSize        = 8000    // Bytes checked for NUL to detect binary files
+)
+
+type FindFilesTool struct {
+	rootPath string
+}
+
+func NewFindFilesTool(rootPath string) *FindFilesTool {
+	return &FindFilesTool{rootPath: rootPath}
+}
+
+var _ tools.Tool = &FindFilesTool{}
+
+type FindFilesParams struct {
+	Path       string `json:"path,omitempty"`
+	Pattern    string `json:"pattern"`
+	Regex      string `json:"regex,omitempty"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+type LineMatch struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type FileMatch struct {
+	Path  string      `json:"path"`
+	Lines []LineMatch `json:"lines,omitempty"`
+}
+
+func (t *FindFilesTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "fs_find_files",
+		Description: "Find files by name with a glob pattern, optionally only those whose content matches a regex. " +
+			"Returns the relative paths of the files and, for content searches, the matching line numbers. " +
+			"The .git directory is skipped, binary and large files are not searched by content.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"path": {
+					Type:        llms.TypeString,
+					Description: "Relative path from root directory to search from (default: '.' for root)",
+				},
+				"pattern": {
+					Type: llms.TypeString,
+					Description: "Glob pattern of the files, e.g. '*.go'. Patterns with '/' match the path relative to the search path, " +
+						"'**' matches any number of directories, e.g. 'cmd/**/*_test.go'",
+				},
+				"regex": {
+					Type:        llms.TypeString,
+					Description: "Regular expression the content of the files must match (optional)",
+				},
+				"max_results": {
+					Type:        llms.TypeInteger,
+					Description: fmt.Sprintf("Maximum number of files to return (default: %d)", defaultFindMaxResults),
+				},
+			},
+			Required: []string{"pattern"},
+		},
+	}
+}
+
+func (t *FindFilesTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var findParams FindFilesParams
+	if err := mapToStruct(params.Arguments, &findParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if findParams.Path == "" {
+		findParams.Path = "."
+	}
+	if findParams.Pattern == "" {
+		findParams.Pattern = "*"
+	}
+	if findParams.MaxResults <= 0 {
+		findParams.MaxResults = defaultFindMaxResults
+	}
+
+	nameMatcher, err := compileGlob(findParams.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", findParams.Pattern, err)
+	}
+	var contentMatcher *regexp.Regexp
+	if findParams.Regex != "" {
+		if contentMatcher, err = regexp.Compile(findParams.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %w", findParams.Regex, err)
+		}
+	}
+
+	fst := &FileSystemTools{rootPath: t.rootPath}
+	absPath, err := fst.validatePath(findParams.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat directory: %w", err)
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("path '%s' is not a directory", findParams.Path)
+	}
+
+	root := fst.resolvedRoot()
+	matches := make([]FileMatch, 0)
+	truncated := false
+	err = filepath.WalkDir(absPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip entries we can't read
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		searchPath, err := filepath.Rel(absPath, path)
+		if err != nil {
+			return nil
+		}
+		if !nameMatcher.MatchString(filepath.ToSlash(searchPath)) {
+			return nil
+		}
+
+		var lines []LineMatch
+		if contentMatcher != nil {
+			if lines = grepFile(path, contentMatcher); len(lines) == 0 {
+				return nil
+			}
+		}
+
+		if len(matches) >= findParams.MaxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		matches = append(matches, FileMatch{Path: filepath.ToSlash(relPath), Lines: lines})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":   true,
+			"path":      findParams.Path,
+			"matches":   matches,
+			"count":     len(matches),
+			"truncated": truncated,
+		},
+	}, nil
+}
+
+// compileGlob compiles a glob pattern to a regexp matching slash separated relative paths.
+// Patterns without '/' match the file name in any directory.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					expr.WriteString("(?:.*/)?") // Any number of directories
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// grepFile returns the lines of the file matching the regex, skipping binary and large files
+func grepFile(path string, matcher *regexp.Regexp) []LineMatch {
+	var lines []LineMatch
+	for _, match := range grepFileLines(path, matcher, 0, maxFindLineMatches) {
+		lines = append(lines, LineMatch{
+			Line: match.LineNumber,
+			Text: strings.TrimSpace(match.Line),
+		})
+	}
+	return lines
+}
+
+// ===== Grep Tool =====
+
+const (
+	defaultGrepMaxMatches = 100
+	maxGrepContextLines   = 10 // Context lines reported around each match
+)
+
+type GrepTool struct {
+	rootPath string
+}
+
+func NewGrepTool(rootPath string) *GrepTool {
+	return &GrepTool{rootPath: rootPath}
+}
+
+var _ tools.Tool = &GrepTool{}
+
+type GrepParams struct {
+	Pattern    string `json:"pattern"`
+	Path       string `json:"path,omitempty"`
+	Glob       string `json:"glob,omitempty"`
+	MaxMatches int    `json:"max_matches,omitempty"`
+	Context    int    `json:"context,omitempty"`
+}
+
+type GrepMatch struct {
+	File       string   `json:"file"`
+	LineNumber int      `json:"line_number"`
+	Line       string   `json:"line"`
+	Before     []string `json:"before,omitempty"` // Context lines before the match
+	After      []string `json:"after,omitempty"`  // Context lines after the match
+}
+
+func (t *GrepTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "fs_grep",
+		Description: "Search the content of the files for the lines matching a regex. " +
+			"Returns the relative path, the line number and the text of each matching line, with its surrounding lines on request. " +
+			"The .git directory is skipped, binary and large files are not searched.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"pattern": {
+					Type:        llms.TypeString,
+					Description: "Regular expression the lines must match, e.g. 'func \\w+Handler'",
+				},
+				"path": {
+					Type:        llms.TypeString,
+					Description: "Relative path from root directory of the directory or the file to search (default: '.' for root)",
+				},
+				"glob": {
+					Type:        llms.TypeString,
+					Description: "Glob pattern of the files to search, e.g. '*.go' or 'cmd/**/*.go' (default: all files)",
+				},
+				"max_matches": {
+					Type:        llms.TypeInteger,
+					Description: fmt.Sprintf("Maximum number of matching lines to return (default: %d)", defaultGrepMaxMatches),
+				},
+				"context": {
+					Type:        llms.TypeInteger,
+					Description: fmt.Sprintf("Number of lines to return before and after each match, at most %d (default: 0)", maxGrepContextLines),
+				},
+			},
+			Required: []string{"pattern"},
+		},
+	}
+}
+
+func (t *GrepTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var grepParams GrepParams
+	if err := mapToStruct(params.Arguments, &grepParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if grepParams.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	if grepParams.Path == "" {
+		grepParams.Path = "."
+	}
+	if grepParams.Glob == "" {
+		grepParams.Glob = "*"
+	}
+	if grepParams.MaxMatches <= 0 {
+		grepParams.MaxMatches = defaultGrepMaxMatches
+	}
+	grepParams.Context = min(max(grepParams.Context, 0), maxGrepContextLines)
+
+	matcher, err := regexp.Compile(grepParams.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", grepParams.Pattern, err)
+	}
+	fileMatcher, err := compileGlob(grepParams.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob '%s': %w", grepParams.Glob, err)
+	}
+
+	fst := &FileSystemTools{rootPath: t.rootPath}
+	absPath, err := fst.validatePath(grepParams.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	root := fst.resolvedRoot()
+	matches := make([]GrepMatch, 0)
+	truncated := false
+	err = filepath.WalkDir(absPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip entries we can't read
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		// A file given as the path is searched whatever its name
+		if path != absPath {
+			searchPath, err := filepath.Rel(absPath, path)
+			if err != nil || !fileMatcher.MatchString(filepath.ToSlash(searchPath)) {
+				return nil
+			}
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+
+		fileMatches := grepFileLines(path, matcher, grepParams.Context, grepParams.MaxMatches-len(matches)+1)
+		for i := range fileMatches {
+			if len(matches) >= grepParams.MaxMatches {
+				truncated = true
+				return filepath.SkipAll
+			}
+			fileMatches[i].File = filepath.ToSlash(relPath)
+			matches = append(matches, fileMatches[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":   true,
+			"path":      grepParams.Path,
+			"pattern":   grepParams.Pattern,
+			"matches":   matches,
+			"count":     len(matches),
+			"truncated": truncated,
+		},
+	}, nil
+}
+
+// grepFileLines returns up to limit lines of the file matching the regex, with the context
+// lines around them, skipping binary and large files
+func grepFileLines(path string, matcher *regexp.Regexp, contextLines, limit int) []GrepMatch {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFindContentFileSize {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(content[:min(len(content), binaryCheckSize)], 0) >= 0 {
+		return nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFindContentFileSize)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	displayLine := func(line string) string {
+		return utils.TruncateMiddleToFit(strings.TrimRight(line, "\r"), maxFindLineLength)
+	}
+
+	var matches []GrepMatch
+	for i := 0; i < len(lines) && len(matches) < limit; i++ {
+		if !matcher.MatchString(lines[i]) {
+			continue
+		}
+		match := GrepMatch{LineNumber: i + 1, Line: displayLine(lines[i])}
+		for _, line := range lines[max(i-contextLines, 0):i] {
+			match.Before = append(match.Before, displayLine(line))
+		}
+		for _, line := range lines[i+1 : min(i+1+contextLines, len(lines))] {
+			match.After = append(match.After, displayLine(line))
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// ===== Edit File Tool =====
+
+type EditFileTool struct {
+	rootPath string
+}
+
+func NewEditFileTool(rootPath string) *EditFileTool {
+	return &EditFileTool{rootPath: rootPath}
+}
+
+var _ tools.Tool = &EditFileTool{}
+
+type EditFileParams struct {
+	Path       string `json:"path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+func (t *EditFileTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "fs_edit_file",
+		Description: "Edit a file by replacing an exact string with another. The string must occur exactly once, " +
+			"include enough surrounding lines to make it unique, or set replace_all=true to replace every occurrence.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"path": {
+					Type:        llms.TypeString,
+					Description: "Relative path from root directory to the file to edit",
+				},
+				"old_string": {
+					Type:        llms.TypeString,
+					Description: "Exact text to replace, whitespace and indentation included",
+				},
+				"new_string": {
+					Type:        llms.TypeString,
+					Description: "Text to replace it with",
+				},
+				"replace_all": {
+					Type:        llms.TypeBoolean,
+					Description: "Whether to replace every occurrence of old_string (default: false)",
+				},
+			},
+			Required: []string{"path", "old_string", "new_string"},
+		},
+	}
+}
+
+func (t *EditFileTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var editParams EditFileParams
+	if err := mapToStruct(params.Arguments, &editParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if editParams.OldString == "" {
+		return nil, fmt.Errorf("old_string must not be empty")
+	}
+	if editParams.OldString == editParams.NewString {
+		return nil, fmt.Errorf("old_string and new_string are identical, nothing to edit")
+	}
+
+	fst := &FileSystemTools{rootPath: t.rootPath}
+	absPath, err := fst.validatePath(editParams.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("path '%s' is a directory", editParams.Path)
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	occurrences := strings.Count(string(content), editParams.OldString)
+	switch {
+	case occurrences == 0:
+		return nil, fmt.Errorf("old_string not found in file '%s', it must match the content exactly", editParams.Path)
+	case occurrences > 1 && !editParams.ReplaceAll:
+		return nil, fmt.Errorf("old_string occurs %d times in file '%s', add surrounding lines to make it unique "+
+			"or use replace_all=true", occurrences, editParams.Path)
+	}
+
+	replacements := 1
+	if editParams.ReplaceAll {
+		replacements = occurrences
+	}
+	edited := strings.Replace(string(content), editParams.OldString, editParams.NewString, replacements)
+
+	if err := writeFileAtomic(absPath, []byte(edited), stat.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":      true,
+			"path":         editParams.Path,
+			"replacements": replacements,
+			"size":         len(edited),
+		},
+	}, nil
+}
+
+// writeFileAtomic writes the file through a temporary file renamed over it, so the
+// file is never left partially written
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
 // ===== Utility Functions =====
 
 // mapToStruct converts a map[string]any to a struct using JSON marshaling/unmarshaling
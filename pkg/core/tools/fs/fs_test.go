@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/oopslink/agent-go/pkg/support/llms"
@@ -291,3 +292,786 @@ properties:
 		assert.Equal(t, "1.0", properties["version"].(string))
 	})
 }
+
+func TestCopyFile(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewCopyFileTool(fst.rootPath)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "template", "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "template", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "template", "sub", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+
+	t.Run("SingleFile", func(t *testing.T) {
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "copy_1",
+			Name:       "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template/main.go",
+				"destination": "copy/main.go",
+			},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		assert.Equal(t, int64(1), result.Result["files_copied"])
+		assert.Equal(t, int64(13), result.Result["bytes_copied"])
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "copy", "main.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package main\n", string(content))
+	})
+
+	t.Run("RecursiveDirectory", func(t *testing.T) {
+		// a directory is only copied with recursive=true
+		_, err := tool.Call(ctx, &llms.ToolCall{
+			Name:      "fs_copy_file",
+			Arguments: map[string]any{"source": "template", "destination": "project"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "recursive=true")
+
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "copy_2",
+			Name:       "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template",
+				"destination": "project",
+				"recursive":   true,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.Result["files_copied"])
+		assert.Equal(t, int64(23), result.Result["bytes_copied"])
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "project", "sub", "run.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh\n", string(content))
+
+		stat, err := os.Stat(filepath.Join(tempDir, "project", "sub", "run.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), stat.Mode().Perm())
+	})
+
+	t.Run("NotEmptyDestination", func(t *testing.T) {
+		_, err := tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template",
+				"destination": "project",
+				"recursive":   true,
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "destination is not empty")
+
+		_, err = tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template/main.go",
+				"destination": "copy/main.go",
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "destination already exists")
+
+		// an empty destination directory is filled
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "empty"), 0755))
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template",
+				"destination": "empty",
+				"recursive":   true,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.Result["files_copied"])
+
+		// a file is not copied over a directory, even an empty one
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "empty_too"), 0755))
+		_, err = tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template/main.go",
+				"destination": "empty_too",
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "destination is a directory")
+	})
+
+	t.Run("ReadOnlyDirectory", func(t *testing.T) {
+		readOnly := filepath.Join(tempDir, "read_only")
+		require.NoError(t, os.MkdirAll(filepath.Join(readOnly, "sub"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(readOnly, "sub", "a.txt"), []byte("a"), 0644))
+		require.NoError(t, os.Chmod(filepath.Join(readOnly, "sub"), 0555))
+		require.NoError(t, os.Chmod(readOnly, 0555))
+		t.Cleanup(func() {
+			for _, dir := range []string{"read_only", "read_only_copy"} {
+				_ = os.Chmod(filepath.Join(tempDir, dir), 0755)
+				_ = os.Chmod(filepath.Join(tempDir, dir, "sub"), 0755)
+			}
+		})
+
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "read_only",
+				"destination": "read_only_copy",
+				"recursive":   true,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Result["files_copied"])
+
+		// the modes of the directories are applied once their content is copied
+		for _, dir := range []string{"read_only_copy", "read_only_copy/sub"} {
+			stat, err := os.Stat(filepath.Join(tempDir, dir))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0555), stat.Mode().Perm(), dir)
+		}
+		content, err := os.ReadFile(filepath.Join(tempDir, "read_only_copy", "sub", "a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(content))
+	})
+
+	t.Run("PathValidation", func(t *testing.T) {
+		_, err := tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template/main.go",
+				"destination": "../outside.go",
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outside the allowed root directory")
+
+		_, err = tool.Call(ctx, &llms.ToolCall{
+			Name: "fs_copy_file",
+			Arguments: map[string]any{
+				"source":      "template",
+				"destination": "template/nested",
+				"recursive":   true,
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "into itself")
+	})
+}
+
+func TestFindFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewFindFilesTool(fst.rootPath)
+
+	files := map[string]string{
+		"main.go":                   "package main\n\nfunc main() {\n\t// TODO: parse flags\n\trun()\n}\n",
+		"cmd/server/server.go":      "package server\n\n// TODO: graceful shutdown\n",
+		"cmd/server/server_test.go": "package server\n",
+		"README.md":                 "# TODO list\n",
+		".git/config":               "[core]\n\tTODO = true\n",
+		"assets/logo.go":            "TODO\x00binary",
+	}
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644))
+	}
+
+	find := func(t *testing.T, arguments map[string]any) []FileMatch {
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "find",
+			Name:       "fs_find_files",
+			Arguments:  arguments,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		return result.Result["matches"].([]FileMatch)
+	}
+	paths := func(matches []FileMatch) []string {
+		var result []string
+		for _, match := range matches {
+			result = append(result, match.Path)
+		}
+		return result
+	}
+
+	t.Run("NameMatching", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]string{"main.go", "cmd/server/server.go", "cmd/server/server_test.go", "assets/logo.go"},
+			paths(find(t, map[string]any{"pattern": "*.go"})))
+		assert.Equal(t, []string{"cmd/server/server_test.go"},
+			paths(find(t, map[string]any{"pattern": "cmd/**/*_test.go"})))
+		assert.Equal(t, []string{"cmd/server/server.go"},
+			paths(find(t, map[string]any{"path": "cmd", "pattern": "server/server.go"})))
+		assert.Empty(t, find(t, map[string]any{"pattern": "config"}))
+	})
+
+	t.Run("ContentMatching", func(t *testing.T) {
+		matches := find(t, map[string]any{"pattern": "*", "regex": `TODO:\s+\w+`})
+		assert.ElementsMatch(t, []string{"main.go", "cmd/server/server.go"}, paths(matches))
+		for _, match := range matches {
+			if match.Path == "main.go" {
+				assert.Equal(t, []LineMatch{{Line: 4, Text: "// TODO: parse flags"}}, match.Lines)
+			}
+		}
+
+		// the binary file is not searched, .git is skipped
+		matches = find(t, map[string]any{"pattern": "*", "regex": "TODO"})
+		assert.ElementsMatch(t, []string{"main.go", "cmd/server/server.go", "README.md"}, paths(matches))
+	})
+
+	t.Run("MaxResults", func(t *testing.T) {
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			Name:      "fs_find_files",
+			Arguments: map[string]any{"pattern": "*.go", "max_results": 2},
+		})
+		require.NoError(t, err)
+		assert.Len(t, result.Result["matches"], 2)
+		assert.True(t, result.Result["truncated"].(bool))
+	})
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		_, err := tool.Call(ctx, &llms.ToolCall{
+			Name:      "fs_find_files",
+			Arguments: map[string]any{"pattern": "*", "regex": "("},
+		})
+		assert.Error(t, err)
+
+		_, err = tool.Call(ctx, &llms.ToolCall{
+			Name:      "fs_find_files",
+			Arguments: map[string]any{"path": "../", "pattern": "*"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outside the allowed root directory")
+	})
+}
+
+func TestGrep(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewGrepTool(fst.rootPath)
+
+	files := map[string]string{
+		"main.go":              "package main\n\nfunc main() {\n\t// TODO: parse flags\n\trun()\n}\n",
+		"cmd/server/server.go": "package server\n\n// TODO: graceful shutdown\nfunc Serve() {}\n",
+		"README.md":            "# TODO list\n",
+		".git/config":          "[core]\n\tTODO = true\n",
+		"assets/logo.go":       "TODO\x00binary",
+	}
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644))
+	}
+
+	grep := func(t *testing.T, arguments map[string]any) ([]GrepMatch, map[string]any) {
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "grep",
+			Name:       "fs_grep",
+			Arguments:  arguments,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		return result.Result["matches"].([]GrepMatch), result.Result
+	}
+
+	t.Run("RegexMatches", func(t *testing.T) {
+		matches, _ := grep(t, map[string]any{"pattern": `TODO: \w+`})
+		// the .git directory and the binary files are skipped
+		assert.ElementsMatch(t, []GrepMatch{
+			{File: "main.go", LineNumber: 4, Line: "\t// TODO: parse flags"},
+			{File: "cmd/server/server.go", LineNumber: 3, Line: "// TODO: graceful shutdown"},
+		}, matches)
+
+		matches, _ = grep(t, map[string]any{"pattern": "TODO", "glob": "*.md"})
+		assert.Equal(t, []GrepMatch{{File: "README.md", LineNumber: 1, Line: "# TODO list"}}, matches)
+
+		matches, _ = grep(t, map[string]any{"pattern": "^func", "path": "cmd"})
+		assert.Equal(t, []GrepMatch{{File: "cmd/server/server.go", LineNumber: 4, Line: "func Serve() {}"}}, matches)
+
+		matches, _ = grep(t, map[string]any{"pattern": "package", "path": "main.go"})
+		assert.Equal(t, []GrepMatch{{File: "main.go", LineNumber: 1, Line: "package main"}}, matches)
+	})
+
+	t.Run("ContextLines", func(t *testing.T) {
+		matches, _ := grep(t, map[string]any{"pattern": "TODO", "path": "main.go", "context": 2})
+		require.Len(t, matches, 1)
+		assert.Equal(t, []string{"", "func main() {"}, matches[0].Before)
+		assert.Equal(t, []string{"\trun()", "}"}, matches[0].After)
+
+		// the context stops at the start of the file
+		matches, _ = grep(t, map[string]any{"pattern": "package", "path": "main.go", "context": 1})
+		require.Len(t, matches, 1)
+		assert.Empty(t, matches[0].Before)
+		assert.Equal(t, []string{""}, matches[0].After)
+	})
+
+	t.Run("MaxMatches", func(t *testing.T) {
+		matches, result := grep(t, map[string]any{"pattern": "package", "max_matches": 1})
+		assert.Len(t, matches, 1)
+		assert.Equal(t, true, result["truncated"])
+
+		matches, result = grep(t, map[string]any{"pattern": "package", "max_matches": 2})
+		assert.Len(t, matches, 2)
+		assert.Equal(t, false, result["truncated"])
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		_, err := tool.Call(ctx, &llms.ToolCall{Name: "fs_grep", Arguments: map[string]any{"pattern": "("}})
+		assert.Error(t, err)
+		_, err = tool.Call(ctx, &llms.ToolCall{Name: "fs_grep", Arguments: map[string]any{"pattern": "x", "path": "../outside"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestEditFile(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewEditFileTool(fst.rootPath)
+
+	const original = "host: localhost\nport: 8080\nlog: debug\nport_admin: 8080\n"
+	filePath := filepath.Join(tempDir, "config.yaml")
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0600))
+
+	edit := func(arguments map[string]any) (*llms.ToolCallResult, error) {
+		return tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "edit",
+			Name:       "fs_edit_file",
+			Arguments:  arguments,
+		})
+	}
+	readFile := func() string {
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("SingleMatch", func(t *testing.T) {
+		result, err := edit(map[string]any{
+			"path":       "config.yaml",
+			"old_string": "log: debug",
+			"new_string": "log: info",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		assert.Equal(t, 1, result.Result["replacements"])
+		assert.Equal(t, "host: localhost\nport: 8080\nlog: info\nport_admin: 8080\n", readFile())
+
+		// the file mode is kept
+		stat, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	})
+
+	t.Run("AmbiguousMatch", func(t *testing.T) {
+		before := readFile()
+		_, err := edit(map[string]any{
+			"path":       "config.yaml",
+			"old_string": "8080",
+			"new_string": "9090",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "occurs 2 times")
+		assert.Equal(t, before, readFile())
+
+		result, err := edit(map[string]any{
+			"path":        "config.yaml",
+			"old_string":  "8080",
+			"new_string":  "9090",
+			"replace_all": true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Result["replacements"])
+		assert.Equal(t, "host: localhost\nport: 9090\nlog: info\nport_admin: 9090\n", readFile())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		before := readFile()
+		_, err := edit(map[string]any{
+			"path":       "config.yaml",
+			"old_string": "log: warn",
+			"new_string": "log: error",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "old_string not found")
+		assert.Equal(t, before, readFile())
+
+		_, err = edit(map[string]any{
+			"path":       "missing.yaml",
+			"old_string": "a",
+			"new_string": "b",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("NoTemporaryFilesLeft", func(t *testing.T) {
+		entries, err := os.ReadDir(tempDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "config.yaml", entries[0].Name())
+	})
+}
+
+func TestWriteFileAppend(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewWriteFileTool(fst.rootPath)
+
+	write := func(arguments map[string]any) (*llms.ToolCallResult, error) {
+		return tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "write",
+			Name:       "fs_write_file",
+			Arguments:  arguments,
+		})
+	}
+
+	t.Run("AppendToExistingFile", func(t *testing.T) {
+		filePath := filepath.Join(tempDir, "agent.log")
+		require.NoError(t, os.WriteFile(filePath, []byte("started\n"), 0644))
+
+		result, err := write(map[string]any{
+			"path":    "agent.log",
+			"content": "stopped\n",
+			"append":  true,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		// the size is the new size of the whole file
+		assert.Equal(t, len("started\nstopped\n"), result.Result["size"])
+
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "started\nstopped\n", string(data))
+	})
+
+	t.Run("AppendToNewFile", func(t *testing.T) {
+		result, err := write(map[string]any{
+			"path":    "logs/today/agent.log",
+			"content": "started\n",
+			"append":  true,
+			"mode":    "0600",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, len("started\n"), result.Result["size"])
+
+		filePath := filepath.Join(tempDir, "logs", "today", "agent.log")
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "started\n", string(data))
+		stat, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	})
+
+	t.Run("OverwriteByDefault", func(t *testing.T) {
+		result, err := write(map[string]any{
+			"path":    "agent.log",
+			"content": "reset\n",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, len("reset\n"), result.Result["size"])
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "agent.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "reset\n", string(data))
+	})
+}
+
+func TestReadFileLineRange(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewReadFileTool(fst.rootPath)
+
+	const content = "line 1\r\nline 2\nline 3\r\nline 4\nline 5"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "lines.txt"), []byte(content), 0644))
+
+	read := func(t *testing.T, arguments map[string]any) map[string]any {
+		arguments["path"] = "lines.txt"
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "read",
+			Name:       "fs_read_file",
+			Arguments:  arguments,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		return result.Result
+	}
+
+	t.Run("WholeFile", func(t *testing.T) {
+		result := read(t, map[string]any{})
+		assert.Equal(t, content, result["content"])
+		assert.NotContains(t, result, "total_lines")
+	})
+
+	t.Run("MidFile", func(t *testing.T) {
+		result := read(t, map[string]any{"start_line": 2, "end_line": 3})
+		// the original line endings are preserved
+		assert.Equal(t, "line 2\nline 3\r\n", result["content"])
+		assert.Equal(t, 2, result["start_line"])
+		assert.Equal(t, 3, result["end_line"])
+		assert.Equal(t, 5, result["total_lines"])
+
+		result = read(t, map[string]any{"start_line": 4})
+		assert.Equal(t, "line 4\nline 5", result["content"])
+		assert.Equal(t, 5, result["end_line"])
+
+		result = read(t, map[string]any{"end_line": 1})
+		assert.Equal(t, "line 1\r\n", result["content"])
+		assert.Equal(t, 1, result["start_line"])
+	})
+
+	t.Run("BeyondEOF", func(t *testing.T) {
+		result := read(t, map[string]any{"start_line": 3, "end_line": 100})
+		assert.Equal(t, "line 3\r\nline 4\nline 5", result["content"])
+		assert.Equal(t, 5, result["end_line"])
+		assert.Equal(t, 5, result["total_lines"])
+
+		result = read(t, map[string]any{"start_line": 10, "end_line": 20})
+		assert.Equal(t, "", result["content"])
+		assert.Equal(t, 5, result["total_lines"])
+
+		result = read(t, map[string]any{"start_line": -3, "end_line": 1})
+		assert.Equal(t, "line 1\r\n", result["content"])
+	})
+}
+
+func TestReadFileMaxBytes(t *testing.T) {
+	tempDir := t.TempDir()
+	fst, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tool := NewReadFileTool(fst.rootPath)
+
+	read := func(t *testing.T, path string, arguments map[string]any) map[string]any {
+		arguments["path"] = path
+		result, err := tool.Call(ctx, &llms.ToolCall{
+			ToolCallId: "read",
+			Name:       "fs_read_file",
+			Arguments:  arguments,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Result["success"].(bool))
+		return result.Result
+	}
+
+	const content = "line 1\nline 2\nline 3\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "lines.txt"), []byte(content), 0644))
+
+	t.Run("UnderLimit", func(t *testing.T) {
+		result := read(t, "lines.txt", map[string]any{"max_bytes": 100})
+		assert.Equal(t, content, result["content"])
+		assert.Equal(t, false, result["truncated"])
+		assert.Equal(t, int64(len(content)), result["total_size"])
+
+		result = read(t, "lines.txt", map[string]any{"max_bytes": len(content)})
+		assert.Equal(t, content, result["content"])
+		assert.Equal(t, false, result["truncated"])
+	})
+
+	t.Run("OverLimit", func(t *testing.T) {
+		result := read(t, "lines.txt", map[string]any{"max_bytes": 10})
+		assert.Equal(t, "line 1\nlin", result["content"])
+		assert.Equal(t, 10, result["size"])
+		assert.Equal(t, true, result["truncated"])
+		assert.Equal(t, int64(len(content)), result["total_size"])
+
+		// a line range is truncated too, ending at the last line read
+		result = read(t, "lines.txt", map[string]any{"start_line": 2, "max_bytes": 10})
+		assert.Equal(t, "line 2\nlin", result["content"])
+		assert.Equal(t, true, result["truncated"])
+		assert.Equal(t, 2, result["start_line"])
+		assert.Equal(t, 3, result["end_line"])
+		assert.Equal(t, 3, result["total_lines"])
+
+		result = read(t, "lines.txt", map[string]any{"start_line": 2, "end_line": 2, "max_bytes": 10})
+		assert.Equal(t, "line 2\n", result["content"])
+		assert.Equal(t, false, result["truncated"])
+	})
+
+	t.Run("ElidedMiddle", func(t *testing.T) {
+		large := "BEGIN\n" + strings.Repeat("middle line\n", 1000) + "END\n"
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "elided.txt"), []byte(large), 0644))
+
+		result := read(t, "elided.txt", map[string]any{"max_bytes": 100})
+		content := result["content"].(string)
+		assert.LessOrEqual(t, len(content), 100)
+		assert.True(t, strings.HasPrefix(content, "BEGIN\nmiddle"))
+		assert.True(t, strings.HasSuffix(content, "middle line\nEND\n"))
+		assert.Contains(t, content, "bytes omitted]")
+		assert.Equal(t, true, result["truncated"])
+	})
+
+	t.Run("MultiByteCharacters", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "utf8.txt"), []byte("héllo"), 0644))
+		// the truncation does not split the two bytes of "é"
+		result := read(t, "utf8.txt", map[string]any{"max_bytes": 2})
+		assert.Equal(t, "h", result["content"])
+		assert.Equal(t, true, result["truncated"])
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		line := strings.Repeat("x", 8191) + "\n"
+		large := strings.Repeat(line, DefaultMaxReadBytes/len(line)+10)
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "large.txt"), []byte(large), 0644))
+
+		result := read(t, "large.txt", map[string]any{})
+		assert.LessOrEqual(t, result["size"], DefaultMaxReadBytes)
+		assert.Greater(t, result["size"], DefaultMaxReadBytes-100)
+		assert.Equal(t, true, result["truncated"])
+		assert.Equal(t, int64(len(large)), result["total_size"])
+
+		// the lines longer than the read buffer are read whole
+		result = read(t, "large.txt", map[string]any{"start_line": 1, "end_line": 2})
+		assert.Equal(t, line+line, result["content"])
+		assert.Equal(t, false, result["truncated"])
+		assert.Equal(t, len(large)/len(line), result["total_lines"])
+	})
+}
+
+func TestValidatePath(t *testing.T) {
+	baseDir := t.TempDir()
+	rootDir := filepath.Join(baseDir, "root")
+	siblingDir := filepath.Join(baseDir, "rootfoo")
+	outsideDir := filepath.Join(baseDir, "outside")
+	for _, dir := range []string{rootDir, siblingDir, outsideDir} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(siblingDir, "secret.txt"), []byte("sibling"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("outside"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "inside.txt"), []byte("inside"), 0644))
+
+	// symlinks inside the root pointing outside of it
+	require.NoError(t, os.Symlink(outsideDir, filepath.Join(rootDir, "outside_dir")))
+	require.NoError(t, os.Symlink(filepath.Join(outsideDir, "secret.txt"), filepath.Join(rootDir, "outside_file")))
+	require.NoError(t, os.Symlink(filepath.Join(outsideDir, "created.txt"), filepath.Join(rootDir, "dangling")))
+	require.NoError(t, os.Symlink("inside.txt", filepath.Join(rootDir, "inside_link")))
+
+	fst, err := NewFileSystemTools(rootDir)
+	require.NoError(t, err)
+	resolvedRoot, err := filepath.EvalSymlinks(rootDir)
+	require.NoError(t, err)
+
+	t.Run("Allowed", func(t *testing.T) {
+		path, err := fst.validatePath("inside.txt")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(resolvedRoot, "inside.txt"), path)
+
+		path, err = fst.validatePath("inside_link")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(resolvedRoot, "inside.txt"), path)
+
+		path, err = fst.validatePath("new/dir/file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(resolvedRoot, "new", "dir", "file.txt"), path)
+
+		path, err = fst.validatePath(".")
+		require.NoError(t, err)
+		assert.Equal(t, resolvedRoot, path)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		for _, path := range []string{
+			"../rootfoo/secret.txt",
+			"../rootfoo",
+			"../outside/secret.txt",
+			"outside_dir/secret.txt",
+			"outside_dir/new.txt",
+			"outside_file",
+			"dangling",
+		} {
+			_, err := fst.validatePath(path)
+			assert.Error(t, err, path)
+		}
+	})
+
+	ctx := context.Background()
+
+	t.Run("WriteThroughSymlink", func(t *testing.T) {
+		_, err := NewWriteFileTool(fst.rootPath).Call(ctx, &llms.ToolCall{
+			ToolCallId: "write",
+			Name:       "fs_write_file",
+			Arguments:  map[string]any{"path": "outside_file", "content": "overwritten"},
+		})
+		assert.Error(t, err)
+		content, err := os.ReadFile(filepath.Join(outsideDir, "secret.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "outside", string(content))
+	})
+
+	t.Run("CreateThroughDanglingSymlink", func(t *testing.T) {
+		_, err := NewCreateFileTool(fst.rootPath).Call(ctx, &llms.ToolCall{
+			ToolCallId: "create",
+			Name:       "fs_create_file",
+			Arguments:  map[string]any{"path": "dangling", "content": "escaped"},
+		})
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Join(outsideDir, "created.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestReadOnlyFileSystemTools(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("notes"), 0644))
+
+	toolNames := func(fst *FileSystemTools) []string {
+		var names []string
+		for _, tool := range fst.GetTools() {
+			names = append(names, tool.Descriptor().Name)
+		}
+		return names
+	}
+
+	fst, err := NewReadOnlyFileSystemTools(tempDir)
+	require.NoError(t, err)
+	assert.True(t, fst.IsReadOnly())
+	assert.ElementsMatch(t, []string{
+		"fs_list_directory", "fs_get_file_stat", "fs_read_file", "fs_find_files", "fs_grep",
+	}, toolNames(fst))
+
+	// the read tools work
+	for _, tool := range fst.GetTools() {
+		if tool.Descriptor().Name != "fs_read_file" {
+			continue
+		}
+		result, err := tool.Call(context.Background(), &llms.ToolCall{
+			ToolCallId: "read",
+			Name:       "fs_read_file",
+			Arguments:  map[string]any{"path": "notes.txt"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "notes", result.Result["content"])
+	}
+
+	// the mutating tools are only given by the writable instance
+	writable, err := NewFileSystemTools(tempDir)
+	require.NoError(t, err)
+	assert.False(t, writable.IsReadOnly())
+	assert.Subset(t, toolNames(writable), []string{
+		"fs_write_file", "fs_create_file", "fs_delete_file", "fs_create_directory", "fs_copy_file", "fs_edit_file",
+	})
+
+	// the root of a read-only instance is not created
+	_, err = NewReadOnlyFileSystemTools(filepath.Join(tempDir, "missing"))
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(tempDir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
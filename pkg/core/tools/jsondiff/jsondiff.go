@@ -0,0 +1,166 @@
+// Package jsondiff provides a tool diffing and merging JSON documents with JSON Patch (RFC 6902).
+package jsondiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// OperationDiff computes the JSON Patch between two documents
+	OperationDiff = "diff"
+	// OperationMerge applies a JSON Patch to a document, or merges another document into it
+	OperationMerge = "merge"
+)
+
+// NewJSONDiffTool creates a tool diffing and merging JSON documents, for agents reconciling
+// configurations or API payloads structurally rather than as text.
+func NewJSONDiffTool() *JSONDiffTool {
+	return &JSONDiffTool{}
+}
+
+var _ tools.Tool = &JSONDiffTool{}
+
+// JSONDiffTool diffs JSON documents into JSON Patches and merges JSON documents
+type JSONDiffTool struct{}
+
+// JSONDiffParams defines the parameters of the JSON diff tool
+type JSONDiffParams struct {
+	Operation string      `json:"operation"`          // OperationDiff or OperationMerge
+	Source    any         `json:"source,omitempty"`   // Document to diff from
+	Target    any         `json:"target,omitempty"`   // Document to diff to
+	Document  any         `json:"document,omitempty"` // Document to merge into
+	Patch     []Operation `json:"patch,omitempty"`    // JSON Patch to apply to the document
+	Other     any         `json:"other,omitempty"`    // Document to merge into the document
+	Base      any         `json:"base,omitempty"`     // Common ancestor of document and other for a three-way merge
+}
+
+// Descriptor implements Tool.
+func (t *JSONDiffTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "json_diff",
+		Description: "Diff and merge JSON documents. The 'diff' operation returns the JSON Patch (RFC 6902) " +
+			"turning source into target. The 'merge' operation applies a JSON Patch to document, or deep-merges " +
+			"other into document; with base, the common ancestor of document and other, it merges the changes " +
+			"of both sides and reports conflicting changes to the same value.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"operation": {
+					Type:        llms.TypeString,
+					Description: "The operation to perform: 'diff' or 'merge'",
+				},
+				"source": {
+					Type:        llms.TypeObject,
+					Description: "diff: the original JSON document",
+				},
+				"target": {
+					Type:        llms.TypeObject,
+					Description: "diff: the changed JSON document",
+				},
+				"document": {
+					Type:        llms.TypeObject,
+					Description: "merge: the JSON document to apply the patch to or merge into",
+				},
+				"patch": {
+					Type: llms.TypeArray,
+					Description: "merge: JSON Patch operations to apply to document, " +
+						"e.g. [{\"op\": \"replace\", \"path\": \"/a/b\", \"value\": 1}]",
+					Items: &llms.Schema{Type: llms.TypeObject},
+				},
+				"other": {
+					Type:        llms.TypeObject,
+					Description: "merge: the JSON document to merge into document, its values win unless base is given",
+				},
+				"base": {
+					Type:        llms.TypeObject,
+					Description: "merge: the common ancestor of document and other, enables three-way merge with conflict detection",
+				},
+			},
+			Required: []string{"operation"},
+		},
+	}
+}
+
+// Call implements Tool.
+func (t *JSONDiffTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var diffParams JSONDiffParams
+	if err := mapToStruct(params.Arguments, &diffParams); err != nil {
+		return t.failure(params, fmt.Sprintf("invalid parameters: %s", err)), nil
+	}
+
+	switch diffParams.Operation {
+	case OperationDiff:
+		patch := Diff(diffParams.Source, diffParams.Target)
+		return t.success(params, map[string]any{
+			"patch":   patch,
+			"changes": len(patch),
+		}), nil
+	case OperationMerge:
+		return t.merge(params, &diffParams), nil
+	default:
+		return t.failure(params, fmt.Sprintf("unknown operation '%s', expected 'diff' or 'merge'", diffParams.Operation)), nil
+	}
+}
+
+func (t *JSONDiffTool) merge(params *llms.ToolCall, diffParams *JSONDiffParams) *llms.ToolCallResult {
+	_, hasPatch := params.Arguments["patch"]
+	_, hasOther := params.Arguments["other"]
+	_, hasBase := params.Arguments["base"]
+	switch {
+	case hasPatch == hasOther:
+		return t.failure(params, "merge needs either patch or other")
+	case hasPatch:
+		result, err := Apply(diffParams.Document, diffParams.Patch)
+		if err != nil {
+			return t.failure(params, fmt.Sprintf("failed to apply patch: %s", err))
+		}
+		return t.success(params, map[string]any{"result": result})
+	case hasBase:
+		result, conflicts := MergeThreeWay(diffParams.Base, diffParams.Document, diffParams.Other)
+		return &llms.ToolCallResult{
+			ToolCallId: params.ToolCallId,
+			Name:       params.Name,
+			Result: map[string]any{
+				"success":   len(conflicts) == 0,
+				"result":    result,
+				"conflicts": conflicts,
+			},
+		}
+	default:
+		return t.success(params, map[string]any{"result": Merge(diffParams.Document, diffParams.Other)})
+	}
+}
+
+func (t *JSONDiffTool) success(params *llms.ToolCall, result map[string]any) *llms.ToolCallResult {
+	result["success"] = true
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result:     result,
+	}
+}
+
+func (t *JSONDiffTool) failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
+
+// mapToStruct converts the arguments to the parameters struct, the documents get the types decoded from JSON
+func mapToStruct(m map[string]any, target any) error {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
@@ -0,0 +1,82 @@
+package jsondiff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callTool(t *testing.T, arguments map[string]any) map[string]any {
+	t.Helper()
+	result, err := NewJSONDiffTool().Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "call_1",
+		Name:       "json_diff",
+		Arguments:  arguments,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "call_1", result.ToolCallId)
+	return result.Result
+}
+
+func TestJSONDiffTool_Descriptor(t *testing.T) {
+	descriptor := NewJSONDiffTool().Descriptor()
+	assert.Equal(t, "json_diff", descriptor.Name)
+	assert.Equal(t, []string{"operation"}, descriptor.Parameters.Required)
+}
+
+func TestJSONDiffTool_DiffAndApply(t *testing.T) {
+	source := map[string]any{"name": "api", "version": 1, "tags": []any{"a"}}
+	target := map[string]any{"name": "api", "version": 2, "tags": []any{"a", "b"}}
+
+	result := callTool(t, map[string]any{"operation": "diff", "source": source, "target": target})
+	require.Equal(t, true, result["success"])
+	patch := result["patch"].([]Operation)
+	assert.Equal(t, 2, result["changes"])
+
+	// the patch goes through JSON as it would from a model
+	var rawPatch []any
+	for _, operation := range patch {
+		raw := map[string]any{"op": operation.Op, "path": operation.Path, "value": operation.Value}
+		rawPatch = append(rawPatch, raw)
+	}
+	result = callTool(t, map[string]any{"operation": "merge", "document": source, "patch": rawPatch})
+	require.Equal(t, true, result["success"])
+	assert.Equal(t, map[string]any{"name": "api", "version": float64(2), "tags": []any{"a", "b"}}, result["result"])
+}
+
+func TestJSONDiffTool_Merge(t *testing.T) {
+	result := callTool(t, map[string]any{
+		"operation": "merge",
+		"document":  map[string]any{"a": 1, "b": map[string]any{"c": 1}},
+		"other":     map[string]any{"b": map[string]any{"d": 2}},
+	})
+	require.Equal(t, true, result["success"])
+	assert.Equal(t, map[string]any{"a": float64(1), "b": map[string]any{"c": float64(1), "d": float64(2)}}, result["result"])
+
+	result = callTool(t, map[string]any{
+		"operation": "merge",
+		"base":      map[string]any{"mode": "a"},
+		"document":  map[string]any{"mode": "b"},
+		"other":     map[string]any{"mode": "c"},
+	})
+	assert.Equal(t, false, result["success"])
+	assert.Equal(t, []Conflict{{Path: "/mode", Base: "a", Left: "b", Right: "c"}}, result["conflicts"])
+	assert.Equal(t, map[string]any{"mode": "b"}, result["result"])
+}
+
+func TestJSONDiffTool_Failures(t *testing.T) {
+	for _, arguments := range []map[string]any{
+		{"operation": "compare"},
+		{"operation": "merge", "document": map[string]any{}},
+		{"operation": "merge", "document": map[string]any{}, "patch": []any{}, "other": map[string]any{}},
+		{"operation": "merge", "document": map[string]any{}, "patch": []any{map[string]any{"op": "remove", "path": "/x"}}},
+		{"operation": "merge", "document": map[string]any{}, "patch": "not a patch"},
+	} {
+		result := callTool(t, arguments)
+		assert.Equal(t, false, result["success"], arguments)
+		assert.NotEmpty(t, result["error"])
+	}
+}
@@ -0,0 +1,472 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operations, see RFC 6902
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is a JSON Patch (RFC 6902) operation
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of add, replace and test operations even when it is null
+func (o Operation) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{"op": o.Op, "path": o.Path}
+	if o.From != "" {
+		fields["from"] = o.From
+	}
+	if o.Op == OpAdd || o.Op == OpReplace || o.Op == OpTest {
+		fields["value"] = o.Value
+	}
+	return json.Marshal(fields)
+}
+
+// Conflict is a value changed differently on both sides of a three-way merge
+type Conflict struct {
+	Path  string `json:"path"`            // JSON Pointer of the value
+	Base  any    `json:"base,omitempty"`  // Value in the base document, nil if missing
+	Left  any    `json:"left,omitempty"`  // Value on the left side, nil if missing
+	Right any    `json:"right,omitempty"` // Value on the right side, nil if missing
+}
+
+// Normalize converts a value to the types decoded from JSON: map[string]any, []any,
+// float64, string, bool and nil
+func Normalize(value any) (any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// Diff returns the JSON Patch turning the source document into the target document.
+// Both documents must hold the types decoded from JSON, see Normalize.
+func Diff(source, target any) []Operation {
+	patch := make([]Operation, 0)
+	return diff("", source, target, patch)
+}
+
+func diff(path string, source, target any, patch []Operation) []Operation {
+	if reflect.DeepEqual(source, target) {
+		return patch
+	}
+
+	switch sourceValue := source.(type) {
+	case map[string]any:
+		targetValue, ok := target.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(sourceValue) {
+			if _, ok := targetValue[key]; !ok {
+				patch = append(patch, Operation{Op: OpRemove, Path: path + "/" + escapeToken(key)})
+			}
+		}
+		for _, key := range sortedKeys(targetValue) {
+			childPath := path + "/" + escapeToken(key)
+			if child, ok := sourceValue[key]; ok {
+				patch = diff(childPath, child, targetValue[key], patch)
+			} else {
+				patch = append(patch, Operation{Op: OpAdd, Path: childPath, Value: targetValue[key]})
+			}
+		}
+		return patch
+	case []any:
+		targetValue, ok := target.([]any)
+		if !ok {
+			break
+		}
+		common := min(len(sourceValue), len(targetValue))
+		for idx := 0; idx < common; idx++ {
+			patch = diff(path+"/"+strconv.Itoa(idx), sourceValue[idx], targetValue[idx], patch)
+		}
+		// remove from the end so the indices of the remaining elements stay valid
+		for idx := len(sourceValue) - 1; idx >= common; idx-- {
+			patch = append(patch, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(idx)})
+		}
+		for idx := common; idx < len(targetValue); idx++ {
+			patch = append(patch, Operation{Op: OpAdd, Path: path + "/" + strconv.Itoa(idx), Value: targetValue[idx]})
+		}
+		return patch
+	}
+
+	return append(patch, Operation{Op: OpReplace, Path: path, Value: target})
+}
+
+// Apply applies a JSON Patch to a copy of the document and returns the patched document.
+// Operations are applied in order, the first failing one stops the patch with an error.
+func Apply(document any, patch []Operation) (any, error) {
+	result := deepCopy(document)
+	for idx, operation := range patch {
+		var err error
+		if result, err = applyOperation(result, operation); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", idx, operation.Op, operation.Path, err)
+		}
+	}
+	return result, nil
+}
+
+func applyOperation(document any, operation Operation) (any, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case OpAdd:
+		return add(document, path, deepCopy(operation.Value))
+	case OpRemove:
+		result, _, err := remove(document, path)
+		return result, err
+	case OpReplace:
+		result, _, err := remove(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(result, path, deepCopy(operation.Value))
+	case OpMove, OpCopy:
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == OpMove && isPrefix(from, path) && len(from) < len(path) {
+			return nil, fmt.Errorf("cannot move a value into itself")
+		}
+		value, err := get(document, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == OpMove {
+			if document, _, err = remove(document, from); err != nil {
+				return nil, err
+			}
+		}
+		return add(document, path, deepCopy(value))
+	case OpTest:
+		value, err := get(document, path)
+		if err != nil {
+			return nil, err
+		}
+		expected, err := Normalize(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, expected) {
+			return nil, fmt.Errorf("test failed, value is %s", describe(value))
+		}
+		return document, nil
+	default:
+		return nil, fmt.Errorf("unknown operation '%s'", operation.Op)
+	}
+}
+
+// Merge deep-merges the other document into the document: objects are merged key by key,
+// any other value of the other document replaces the one of the document
+func Merge(document, other any) any {
+	documentObject, ok := document.(map[string]any)
+	otherObject, otherOk := other.(map[string]any)
+	if !ok || !otherOk {
+		return deepCopy(other)
+	}
+	result := deepCopy(documentObject).(map[string]any)
+	for key, value := range otherObject {
+		if existing, ok := result[key]; ok {
+			result[key] = Merge(existing, value)
+		} else {
+			result[key] = deepCopy(value)
+		}
+	}
+	return result
+}
+
+// absent marks a value missing from a document in three-way merges
+type absent struct{}
+
+// MergeThreeWay merges the changes made to the base document on the left and on the right.
+// Values changed on one side only take that change, values changed on both sides to the
+// same value are merged, values changed differently on both sides are conflicts: the left
+// value is kept in the result and the conflict is reported.
+func MergeThreeWay(base, left, right any) (any, []Conflict) {
+	conflicts := make([]Conflict, 0)
+	result := mergeThreeWay("", base, left, right, &conflicts)
+	if _, ok := result.(absent); ok {
+		result = nil
+	}
+	return result, conflicts
+}
+
+func mergeThreeWay(path string, base, left, right any, conflicts *[]Conflict) any {
+	switch {
+	case reflect.DeepEqual(left, right):
+		return deepCopy(left)
+	case reflect.DeepEqual(base, left):
+		return deepCopy(right)
+	case reflect.DeepEqual(base, right):
+		return deepCopy(left)
+	}
+
+	leftObject, leftOk := left.(map[string]any)
+	rightObject, rightOk := right.(map[string]any)
+	baseObject, baseOk := base.(map[string]any)
+	if _, missing := base.(absent); missing {
+		baseObject, baseOk = map[string]any{}, true
+	}
+	if leftOk && rightOk && baseOk {
+		result := make(map[string]any)
+		keys := sortedKeys(leftObject)
+		for _, key := range sortedKeys(rightObject) {
+			if _, ok := leftObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			merged := mergeThreeWay(path+"/"+escapeToken(key),
+				valueOrAbsent(baseObject, key), valueOrAbsent(leftObject, key), valueOrAbsent(rightObject, key), conflicts)
+			if _, removed := merged.(absent); !removed {
+				result[key] = merged
+			}
+		}
+		return result
+	}
+
+	*conflicts = append(*conflicts, Conflict{
+		Path:  path,
+		Base:  presentOrNil(base),
+		Left:  presentOrNil(left),
+		Right: presentOrNil(right),
+	})
+	return deepCopy(left)
+}
+
+func valueOrAbsent(object map[string]any, key string) any {
+	if value, ok := object[key]; ok {
+		return value
+	}
+	return absent{}
+}
+
+func presentOrNil(value any) any {
+	if _, ok := value.(absent); ok {
+		return nil
+	}
+	return value
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for idx, token := range tokens {
+		tokens[idx] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for idx := range prefix {
+		if prefix[idx] != path[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(document any, path []string) (any, error) {
+	current := document
+	for _, token := range path {
+		switch container := current.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("key '%s' not found", token)
+			}
+			current = value
+		case []any:
+			idx, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = container[idx]
+		default:
+			return nil, fmt.Errorf("cannot get '%s' of %s", token, describe(current))
+		}
+	}
+	return current, nil
+}
+
+// add adds the value at the path, returning the updated document
+func add(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[token] = value
+		return document, nil
+	case []any:
+		idx := len(container)
+		if token != "-" {
+			if idx, err = arrayIndex(token, len(container)); err != nil {
+				return nil, err
+			}
+		}
+		updated := make([]any, 0, len(container)+1)
+		updated = append(append(append(updated, container[:idx]...), value), container[idx:]...)
+		return replaceContainer(document, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("cannot add '%s' to %s", token, describe(parent))
+	}
+}
+
+// remove removes the value at the path, returning the updated document and the removed value
+func remove(document any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, document, nil
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		value, ok := container[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("key '%s' not found", token)
+		}
+		delete(container, token)
+		return document, value, nil
+	case []any:
+		idx, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := container[idx]
+		updated := append(append(make([]any, 0, len(container)-1), container[:idx]...), container[idx+1:]...)
+		document, err = replaceContainer(document, path[:len(path)-1], updated)
+		return document, value, err
+	default:
+		return nil, nil, fmt.Errorf("cannot remove '%s' from %s", token, describe(parent))
+	}
+}
+
+// replaceContainer replaces the array at the path, arrays being values that cannot be updated in place
+func replaceContainer(document any, path []string, container []any) (any, error) {
+	if len(path) == 0 {
+		return container, nil
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch value := parent.(type) {
+	case map[string]any:
+		value[token] = container
+	case []any:
+		idx, err := arrayIndex(token, len(value)-1)
+		if err != nil {
+			return nil, err
+		}
+		value[idx] = container
+	}
+	return document, nil
+}
+
+// arrayIndex parses an array index token, which must be between 0 and maxIndex
+func arrayIndex(token string, maxIndex int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	if idx > maxIndex {
+		return 0, fmt.Errorf("array index %d out of bounds", idx)
+	}
+	return idx, nil
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for idx, item := range v {
+			copied[idx] = deepCopy(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func describe(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case nil:
+		return "null"
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(raw)
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
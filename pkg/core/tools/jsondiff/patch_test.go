@@ -0,0 +1,95 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, text string) any {
+	t.Helper()
+	var value any
+	require.NoError(t, json.Unmarshal([]byte(text), &value))
+	return value
+}
+
+func TestDiff(t *testing.T) {
+	source := decode(t, `{"name": "svc", "replicas": 2, "env": {"LOG": "debug", "TZ": "UTC"}, "ports": [80, 443, 8080], "a/b": 1}`)
+	target := decode(t, `{"name": "svc", "replicas": 3, "env": {"LOG": "info"}, "ports": [80, 8443], "labels": {"tier": "web"}}`)
+
+	patch := Diff(source, target)
+	assert.Equal(t, []Operation{
+		{Op: OpRemove, Path: "/a~1b"},
+		{Op: OpRemove, Path: "/env/TZ"},
+		{Op: OpReplace, Path: "/env/LOG", Value: "info"},
+		{Op: OpAdd, Path: "/labels", Value: map[string]any{"tier": "web"}},
+		{Op: OpReplace, Path: "/ports/1", Value: float64(8443)},
+		{Op: OpRemove, Path: "/ports/2"},
+		{Op: OpReplace, Path: "/replicas", Value: float64(3)},
+	}, patch)
+
+	// applying the patch reproduces the target, without changing the source
+	patched, err := Apply(source, patch)
+	require.NoError(t, err)
+	assert.Equal(t, target, patched)
+	assert.Equal(t, decode(t, `{"name": "svc", "replicas": 2, "env": {"LOG": "debug", "TZ": "UTC"}, "ports": [80, 443, 8080], "a/b": 1}`), source)
+
+	assert.Empty(t, Diff(source, source))
+	assert.Equal(t, []Operation{{Op: OpReplace, Path: "", Value: "text"}}, Diff(source, "text"))
+}
+
+func TestApply(t *testing.T) {
+	document := decode(t, `{"items": ["a", "b"], "meta": {"owner": "x"}}`)
+	patch := []Operation{
+		{Op: OpAdd, Path: "/items/1", Value: "inserted"},
+		{Op: OpAdd, Path: "/items/-", Value: "last"},
+		{Op: OpTest, Path: "/meta/owner", Value: "x"},
+		{Op: OpMove, From: "/meta/owner", Path: "/owner"},
+		{Op: OpCopy, From: "/items/0", Path: "/first"},
+		{Op: OpRemove, Path: "/meta"},
+	}
+
+	result, err := Apply(document, patch)
+	require.NoError(t, err)
+	assert.Equal(t, decode(t, `{"items": ["a", "inserted", "b", "last"], "owner": "x", "first": "a"}`), result)
+
+	for _, invalid := range [][]Operation{
+		{{Op: OpRemove, Path: "/missing"}},
+		{{Op: OpReplace, Path: "/items/5", Value: 1}},
+		{{Op: OpTest, Path: "/meta/owner", Value: "y"}},
+		{{Op: OpAdd, Path: "items", Value: 1}},
+		{{Op: OpMove, From: "/meta", Path: "/meta/inner"}},
+		{{Op: "rename", Path: "/meta"}},
+	} {
+		_, err := Apply(document, invalid)
+		assert.Error(t, err, invalid[0].Op)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	document := decode(t, `{"a": 1, "nested": {"x": 1, "y": 2}, "list": [1, 2]}`)
+	other := decode(t, `{"b": 2, "nested": {"y": 3}, "list": [3]}`)
+	assert.Equal(t, decode(t, `{"a": 1, "b": 2, "nested": {"x": 1, "y": 3}, "list": [3]}`), Merge(document, other))
+}
+
+func TestMergeThreeWay(t *testing.T) {
+	base := decode(t, `{"timeout": 30, "retries": 3, "hosts": ["a"], "debug": false}`)
+	left := decode(t, `{"timeout": 60, "retries": 3, "hosts": ["a"], "debug": true, "owner": "team"}`)
+	right := decode(t, `{"timeout": 30, "retries": 5, "hosts": ["a", "b"], "debug": true}`)
+
+	result, conflicts := MergeThreeWay(base, left, right)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, decode(t, `{"timeout": 60, "retries": 5, "hosts": ["a", "b"], "debug": true, "owner": "team"}`), result)
+
+	// divergent changes to the same key are a conflict, the left value is kept
+	right = decode(t, `{"timeout": 90, "hosts": ["a"], "debug": false}`)
+	result, conflicts = MergeThreeWay(base, left, right)
+	assert.Equal(t, []Conflict{{Path: "/timeout", Base: float64(30), Left: float64(60), Right: float64(90)}}, conflicts)
+	assert.Equal(t, decode(t, `{"timeout": 60, "hosts": ["a"], "debug": true, "owner": "team"}`), result)
+
+	// a removal on one side and a change on the other is a conflict too
+	_, conflicts = MergeThreeWay(base, decode(t, `{"timeout": 30}`), decode(t, `{"timeout": 30, "retries": 4}`))
+	assert.Equal(t, []Conflict{{Path: "/retries", Base: float64(3), Right: float64(4)}}, conflicts)
+}
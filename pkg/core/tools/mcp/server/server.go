@@ -0,0 +1,77 @@
+// Package server serves a tool collection as an MCP server, so that other MCP clients can call
+// its tools, e.g. to expose the fs tools to an editor:
+//
+//	err := server.ServeStdio(ctx, tools.OfTools(fs.NewListDirectoryTool(root), fs.NewReadFileTool(root)))
+package server
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	amcp "github.com/oopslink/agent-go/pkg/core/mcp"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+)
+
+const (
+	// DefaultName is the name the server advertises to its clients
+	DefaultName = "agent-go"
+	// DefaultVersion is the version the server advertises to its clients
+	DefaultVersion = "v0.0.1"
+)
+
+// Option configures the server
+type Option func(*options)
+
+type options struct {
+	name         string
+	version      string
+	instructions string
+}
+
+// WithImplementation sets the name and the version the server advertises to its clients
+func WithImplementation(name, version string) Option {
+	return func(o *options) {
+		o.name = name
+		o.version = version
+	}
+}
+
+// WithInstructions sets the instructions the server gives to its clients on how to use its tools
+func WithInstructions(instructions string) Option {
+	return func(o *options) {
+		o.instructions = instructions
+	}
+}
+
+// ServeStdio serves the tools of the collection over the standard input and output, until the
+// context is done or the client disconnects
+func ServeStdio(ctx context.Context, collection *tools.ToolCollection, opts ...Option) error {
+	return Serve(ctx, mcp.NewStdioTransport(), collection, opts...)
+}
+
+// Serve serves the tools of the collection over the transport, until the context is done or the
+// client disconnects
+func Serve(ctx context.Context, transport mcp.Transport, collection *tools.ToolCollection, opts ...Option) error {
+	return NewServer(collection, opts...).Run(ctx, transport)
+}
+
+// NewServer creates an MCP server advertising the descriptors of the tools of the collection, and
+// dispatching the tools/call requests to the collection, see mcp.AddTools
+func NewServer(collection *tools.ToolCollection, opts ...Option) *mcp.Server {
+	options := &options{
+		name:    DefaultName,
+		version: DefaultVersion,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{
+		Name:    options.name,
+		Version: options.version,
+	}, &mcp.ServerOptions{Instructions: options.instructions})
+
+	amcp.AddTools(server, collection)
+	return server
+}
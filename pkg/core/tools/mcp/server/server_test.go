@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/core/tools/calculator"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+func connect(t *testing.T, collection *tools.ToolCollection) *mcp.ClientSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, serverTransport, collection, WithImplementation("test-server", "1.0.0"))
+	}()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = session.Close()
+		cancel()
+		<-served
+	})
+	return session
+}
+
+func TestServe(t *testing.T) {
+	ctx := context.Background()
+	session := connect(t, tools.OfTools(calculator.NewCalculatorTool()))
+
+	listed, err := session.ListTools(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, listed.Tools, 1)
+	descriptor := calculator.NewCalculatorTool().Descriptor()
+	assert.Equal(t, descriptor.Name, listed.Tools[0].Name)
+	assert.Equal(t, descriptor.Description, listed.Tools[0].Description)
+	assert.Equal(t, "object", listed.Tools[0].InputSchema.Type)
+	assert.Equal(t, []string{"expression"}, listed.Tools[0].InputSchema.Required)
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "calculator_eval",
+		Arguments: map[string]any{"expression": "(1 + 2) * 4"},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	var answer map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &answer))
+	assert.Equal(t, map[string]any{"success": true, "expression": "(1 + 2) * 4", "result": 12.0}, answer)
+}
+
+func TestServe_CallFailed(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	failing := tools.NewMockTool(ctrl)
+	failing.EXPECT().Descriptor().Return(&llms.ToolDescriptor{Name: "failing"}).AnyTimes()
+	failing.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("disk full"))
+	session := connect(t, tools.OfTools(calculator.NewCalculatorTool(), failing))
+
+	// the failure of the tool is the result of the call
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "failing"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "disk full", result.Content[0].(*mcp.TextContent).Text)
+
+	// the arguments not matching the schema and the unknown tools are rejected
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "calculator_eval",
+		Arguments: map[string]any{"expression": 42},
+	})
+	assert.Error(t, err)
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "unknown"})
+	assert.Error(t, err)
+}
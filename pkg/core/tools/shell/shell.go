@@ -0,0 +1,203 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// DefaultTimeout is the time a command may run when the call sets no timeout
+	DefaultTimeout = 30 * time.Second
+	// MaxTimeout is the longest time a command may run, whatever the call asks for
+	MaxTimeout = 10 * time.Minute
+)
+
+// NewShellTool creates a tool running commands in the working directory. Only the binaries of the
+// allowlist may be run: names looked up in the PATH, e.g. "echo", or paths, e.g. "/usr/bin/make".
+func NewShellTool(workdir string, allowlist []string) *ShellTool {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, binary := range allowlist {
+		allowed[binary] = true
+	}
+	return &ShellTool{
+		workdir:   workdir,
+		allowlist: allowed,
+	}
+}
+
+var _ tools.Tool = &ShellTool{}
+
+// ShellTool runs a command of the allowlist and returns its output and exit code. The command is not
+// interpreted by a shell: it is split into words, honoring quotes, and run as is, so pipes,
+// redirections and substitutions are passed to the binary as plain arguments.
+type ShellTool struct {
+	workdir   string
+	allowlist map[string]bool
+}
+
+func (t *ShellTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "shell_exec",
+		Description: fmt.Sprintf("Run a command in the working directory and return its stdout, stderr and exit code. "+
+			"The command is not run by a shell, pipes and redirections are not supported. Allowed binaries: %s.",
+			strings.Join(t.allowedBinaries(), ", ")),
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"command": {
+					Type:        llms.TypeString,
+					Description: "The command to run, e.g. 'ls -la'",
+				},
+				"timeout_seconds": {
+					Type: llms.TypeInteger,
+					Description: fmt.Sprintf("Time the command may run, in seconds (default: %d, max: %d)",
+						int(DefaultTimeout.Seconds()), int(MaxTimeout.Seconds())),
+				},
+			},
+			Required: []string{"command"},
+		},
+	}
+}
+
+func (t *ShellTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	command, _ := params.Arguments["command"].(string)
+	args, err := splitCommand(command)
+	if err != nil {
+		return failure(params, err.Error()), nil
+	}
+	if len(args) == 0 {
+		return failure(params, "command parameter is required and cannot be empty"), nil
+	}
+	if !t.allowlist[args[0]] {
+		return failure(params, fmt.Sprintf("binary %q is not allowed, allowed binaries: %s",
+			args[0], strings.Join(t.allowedBinaries(), ", "))), nil
+	}
+
+	timeout := DefaultTimeout
+	if seconds, ok := toSeconds(params.Arguments["timeout_seconds"]); ok && seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	timeout = min(timeout, MaxTimeout)
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Dir = t.workdir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// do not wait for the processes started by the command still holding its output once killed
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	result := map[string]any{
+		"success":   err == nil,
+		"command":   command,
+		"stdout":    stdout.String(),
+		"stderr":    stderr.String(),
+		"exit_code": cmd.ProcessState.ExitCode(),
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		result["error"] = fmt.Sprintf("command timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result["error"] = fmt.Sprintf("command exited with code %d", exitErr.ExitCode())
+	default:
+		result["error"] = fmt.Sprintf("failed to run the command: %v", err)
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result:     result,
+	}, nil
+}
+
+func (t *ShellTool) allowedBinaries() []string {
+	binaries := make([]string, 0, len(t.allowlist))
+	for binary := range t.allowlist {
+		binaries = append(binaries, binary)
+	}
+	sort.Strings(binaries)
+	return binaries
+}
+
+func failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
+
+func toSeconds(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// splitCommand splits the command into words separated by spaces, honoring single quotes,
+// double quotes and backslash escapes as a shell would
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("invalid command, unterminated quote or escape: %s", command)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,84 @@
+package shell
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callShell(t *testing.T, tool *ShellTool, arguments map[string]any) map[string]any {
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "call-1",
+		Name:       "shell_exec",
+		Arguments:  arguments,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "call-1", result.ToolCallId)
+	return result.Result
+}
+
+func TestShellTool_Descriptor(t *testing.T) {
+	descriptor := NewShellTool(t.TempDir(), []string{"ls", "echo"}).Descriptor()
+	assert.Equal(t, "shell_exec", descriptor.Name)
+	assert.Contains(t, descriptor.Description, "Allowed binaries: echo, ls.")
+	assert.Equal(t, []string{"command"}, descriptor.Parameters.Required)
+	assert.Contains(t, descriptor.Parameters.Properties, "timeout_seconds")
+}
+
+func TestShellTool_Call(t *testing.T) {
+	workdir := t.TempDir()
+	tool := NewShellTool(workdir, []string{"echo", "pwd", "false"})
+
+	result := callShell(t, tool, map[string]any{"command": `echo hello "big world" 'a | b'`})
+	assert.Equal(t, true, result["success"])
+	assert.Equal(t, "hello big world a | b\n", result["stdout"])
+	assert.Equal(t, "", result["stderr"])
+	assert.Equal(t, 0, result["exit_code"])
+
+	// the command runs in the working directory
+	result = callShell(t, tool, map[string]any{"command": "pwd"})
+	expected, err := filepath.EvalSymlinks(workdir)
+	require.NoError(t, err)
+	actual, err := filepath.EvalSymlinks(strings.TrimSpace(result["stdout"].(string)))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	result = callShell(t, tool, map[string]any{"command": "false"})
+	assert.Equal(t, false, result["success"])
+	assert.Equal(t, 1, result["exit_code"])
+	assert.Equal(t, "command exited with code 1", result["error"])
+}
+
+func TestShellTool_Allowlist(t *testing.T) {
+	tool := NewShellTool(t.TempDir(), []string{"echo"})
+
+	for _, command := range []string{"ls -la", "sh -c 'echo hello'", "/bin/echo hello", "./echo hello"} {
+		result := callShell(t, tool, map[string]any{"command": command})
+		assert.Equal(t, false, result["success"], command)
+		assert.Contains(t, result["error"], "is not allowed, allowed binaries: echo", command)
+	}
+
+	// shell operators are plain arguments of the allowed binary
+	result := callShell(t, tool, map[string]any{"command": "echo hello; rm -rf /"})
+	assert.Equal(t, true, result["success"])
+	assert.Equal(t, "hello; rm -rf /\n", result["stdout"])
+
+	for _, command := range []string{"", "  ", `echo "unterminated`} {
+		result = callShell(t, tool, map[string]any{"command": command})
+		assert.Equal(t, false, result["success"], command)
+	}
+}
+
+func TestShellTool_Timeout(t *testing.T) {
+	tool := NewShellTool(t.TempDir(), []string{"sleep"})
+
+	result := callShell(t, tool, map[string]any{"command": "sleep 5", "timeout_seconds": 0.2})
+	assert.Equal(t, false, result["success"])
+	assert.Equal(t, "command timed out after 200ms", result["error"])
+	assert.Equal(t, -1, result["exit_code"])
+}
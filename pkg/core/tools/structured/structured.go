@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// NewExtractStructuredTool creates a tool extracting the first JSON or YAML object from
+// free-form text, e.g. the output of another model wrapped in prose or code fences,
+// and optionally validating it against a JSON schema.
+func NewExtractStructuredTool() *ExtractStructuredTool {
+	return &ExtractStructuredTool{}
+}
+
+var _ tools.Tool = &ExtractStructuredTool{}
+
+// ExtractStructuredTool extracts structured values from text with llms.ExtractStructured
+type ExtractStructuredTool struct{}
+
+// Descriptor implements Tool.
+func (t *ExtractStructuredTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "extract_structured",
+		Description: "Extract the first JSON or YAML object from free-form text, handling code fences " +
+			"and surrounding prose, and optionally validate it against a JSON schema.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"text": {
+					Type:        llms.TypeString,
+					Description: "The text containing the JSON or YAML object",
+				},
+				"schema": {
+					Type: llms.TypeObject,
+					Description: "Optional JSON schema the object must match, with type, properties, " +
+						"items and required keywords",
+				},
+			},
+			Required: []string{"text"},
+		},
+	}
+}
+
+// Call implements Tool.
+func (t *ExtractStructuredTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	text, ok := params.Arguments["text"].(string)
+	if !ok {
+		return t.failure(params, "text parameter is required and must be a string"), nil
+	}
+
+	var schema *llms.Schema
+	if rawSchema, ok := params.Arguments["schema"]; ok && rawSchema != nil {
+		raw, err := json.Marshal(rawSchema)
+		if err == nil {
+			err = json.Unmarshal(raw, &schema)
+		}
+		if err != nil {
+			return t.failure(params, fmt.Sprintf("invalid schema: %s", err)), nil
+		}
+	}
+
+	output, err := llms.ExtractStructured(text, schema)
+	if err != nil {
+		return t.failure(params, err.Error()), nil
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": true,
+			"value":   output.Value,
+			"format":  output.Format,
+		},
+	}, nil
+}
+
+func (t *ExtractStructuredTool) failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
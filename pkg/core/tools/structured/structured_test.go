@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractStructuredTool_Descriptor(t *testing.T) {
+	descriptor := NewExtractStructuredTool().Descriptor()
+	assert.Equal(t, "extract_structured", descriptor.Name)
+	assert.Equal(t, []string{"text"}, descriptor.Parameters.Required)
+}
+
+func TestExtractStructuredTool_Call(t *testing.T) {
+	tool := NewExtractStructuredTool()
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "call_1",
+		Name:       "extract_structured",
+		Arguments: map[string]any{
+			"text": "Result:\n```json\n{\"city\": \"Paris\", \"population\": 2100000}\n```",
+			"schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city":       map[string]any{"type": "string"},
+					"population": map[string]any{"type": "integer"},
+				},
+				"required": []any{"city"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "call_1", result.ToolCallId)
+	assert.Equal(t, true, result.Result["success"])
+	assert.Equal(t, "json", result.Result["format"])
+	assert.Equal(t, map[string]any{"city": "Paris", "population": float64(2100000)}, result.Result["value"])
+}
+
+func TestExtractStructuredTool_Failures(t *testing.T) {
+	tool := NewExtractStructuredTool()
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		Arguments: map[string]any{
+			"text":   `{"city": 42}`,
+			"schema": map[string]any{"type": "object", "required": []any{"country"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, false, result.Result["success"])
+	assert.Contains(t, result.Result["error"], `field "country": is required`)
+
+	result, err = tool.Call(context.Background(), &llms.ToolCall{Arguments: map[string]any{"text": "nothing here"}})
+	require.NoError(t, err)
+	assert.Equal(t, false, result.Result["success"])
+
+	result, err = tool.Call(context.Background(), &llms.ToolCall{Arguments: map[string]any{}})
+	require.NoError(t, err)
+	assert.Equal(t, false, result.Result["success"])
+}
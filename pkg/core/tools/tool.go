@@ -47,3 +47,34 @@ func (w *withExtraInstruction) Descriptor() *llms.ToolDescriptor {
 func (w *withExtraInstruction) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
 	return w.tool.Call(ctx, params)
 }
+
+// WithArgumentCoercion makes the tool accept loosely-typed arguments: string-encoded numbers
+// and booleans, e.g. "42" or "true", are converted to the types declared by the parameters
+// schema of the tool before it is called. Values that do not encode the declared type are
+// passed unchanged, so the tool still reports them.
+func WithArgumentCoercion(tool Tool) Tool {
+	if tool == nil {
+		return nil
+	}
+	return &withArgumentCoercion{tool: tool}
+}
+
+var _ Tool = &withArgumentCoercion{}
+
+type withArgumentCoercion struct {
+	tool Tool
+}
+
+func (w *withArgumentCoercion) Descriptor() *llms.ToolDescriptor {
+	return w.tool.Descriptor()
+}
+
+func (w *withArgumentCoercion) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	descriptor := w.tool.Descriptor()
+	if params == nil || descriptor == nil || descriptor.Parameters == nil {
+		return w.tool.Call(ctx, params)
+	}
+	coerced := *params
+	coerced.Arguments = descriptor.Parameters.CoerceArguments(params.Arguments)
+	return w.tool.Call(ctx, &coerced)
+}
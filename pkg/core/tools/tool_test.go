@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the tools of the support packages, which do not depend on core
+var _ Tool = &embedder.EmbedTool{}
+
+type repeatParams struct {
+	Text  string `json:"text"`
+	Times int    `json:"times"`
+	Upper bool   `json:"upper"`
+}
+
+// repeatTool decodes its arguments into a struct, as the tools do
+type repeatTool struct{}
+
+func (t *repeatTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "repeat",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"text":  {Type: llms.TypeString},
+				"times": {Type: llms.TypeInteger},
+				"upper": {Type: llms.TypeBoolean},
+			},
+		},
+	}
+}
+
+func (t *repeatTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	raw, err := json.Marshal(params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	var repeat repeatParams
+	if err := json.Unmarshal(raw, &repeat); err != nil {
+		return nil, err
+	}
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result:     map[string]any{"times": repeat.Times, "upper": repeat.Upper},
+	}, nil
+}
+
+func TestWithArgumentCoercion(t *testing.T) {
+	ctx := context.Background()
+	call := &llms.ToolCall{
+		ToolCallId: "call_1",
+		Name:       "repeat",
+		Arguments:  map[string]any{"text": "hi", "times": "42", "upper": "true"},
+	}
+
+	// without coercion string-encoded values are rejected
+	_, err := (&repeatTool{}).Call(ctx, call)
+	assert.Error(t, err)
+
+	tool := WithArgumentCoercion(&repeatTool{})
+	assert.Equal(t, "repeat", tool.Descriptor().Name)
+
+	result, err := tool.Call(ctx, call)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"times": 42, "upper": true}, result.Result)
+	assert.Equal(t, "42", call.Arguments["times"])
+
+	// genuinely wrong types still fail
+	_, err = tool.Call(ctx, &llms.ToolCall{
+		Name:      "repeat",
+		Arguments: map[string]any{"times": "many", "upper": "true"},
+	})
+	assert.Error(t, err)
+
+	assert.Nil(t, WithArgumentCoercion(nil))
+}
@@ -4,11 +4,24 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
 	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Attributes of the spans of the tool calls, following the OpenTelemetry conventions of the generative AI
+const (
+	AttributeToolName   = attribute.Key("gen_ai.tool.name")
+	AttributeToolCallId = attribute.Key("gen_ai.tool.call.id")
+)
+
+// DefaultBatchConcurrency is the default number of tool calls of a batch running at the same time
+const DefaultBatchConcurrency = 10
+
 func OfTools(tools ...Tool) *ToolCollection {
 	return &ToolCollection{
 		Tools: tools,
@@ -17,6 +30,24 @@ func OfTools(tools ...Tool) *ToolCollection {
 
 type ToolCollection struct {
 	Tools []Tool
+
+	skipSchemaValidation bool
+	maxResultBytes       int
+}
+
+// WithSchemaValidation enables or disables the validation of the arguments of the calls against the
+// parameters schema of the tools before calling them, enabled by default.
+func (tc *ToolCollection) WithSchemaValidation(enabled bool) *ToolCollection {
+	tc.skipSchemaValidation = !enabled
+	return tc
+}
+
+// WithMaxResultBytes bounds the text of the results of the calls, the string values of a result
+// longer than maxBytes get their middle elided, see utils.TruncateMiddleToFit. The values of
+// other types, e.g. structs, are returned as is. Results are not bounded by default (0).
+func (tc *ToolCollection) WithMaxResultBytes(maxBytes int) *ToolCollection {
+	tc.maxResultBytes = maxBytes
+	return tc
 }
 
 func (tc *ToolCollection) AddTools(tools ...Tool) {
@@ -36,12 +67,126 @@ func (tc *ToolCollection) Descriptors() []*llms.ToolDescriptor {
 	return descriptors
 }
 
-func (tc *ToolCollection) Call(ctx context.Context, toolCall *llms.ToolCall) (*llms.ToolCallResult, error) {
+// Call calls the tool of the call in a span, see tracing.SetTracerProvider
+func (tc *ToolCollection) Call(ctx context.Context, toolCall *llms.ToolCall) (result *llms.ToolCallResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "execute_tool "+toolCall.Name,
+		AttributeToolName.String(toolCall.Name),
+		AttributeToolCallId.String(toolCall.ToolCallId))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+
 	tool := tc.findTool(toolCall.Name)
 	if tool == nil {
 		return nil, errors.Permanent(errors.Errorf(ErrorCodeToolNotFound, "tool %s not found", toolCall.Name))
 	}
-	return tool.Call(ctx, toolCall)
+	if !tc.skipSchemaValidation {
+		// malformed arguments are reported before reaching the tool, see ValidateArguments
+		if err := ValidateArguments(tool, toolCall); err != nil {
+			return nil, err
+		}
+	}
+	result, err = tool.Call(ctx, toolCall)
+	if err == nil && result != nil && tc.maxResultBytes > 0 {
+		result.Result = trimResultValue(result.Result, tc.maxResultBytes).(map[string]any)
+	}
+	return result, err
+}
+
+// trimResultValue returns the value with its strings, those of its maps and slices included,
+// truncated to maxBytes; the maps and slices are copied rather than changed
+func trimResultValue(value any, maxBytes int) any {
+	switch v := value.(type) {
+	case string:
+		return utils.TruncateMiddleToFit(v, maxBytes)
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		trimmed := make(map[string]any, len(v))
+		for key, item := range v {
+			trimmed[key] = trimResultValue(item, maxBytes)
+		}
+		return trimmed
+	case []any:
+		if v == nil {
+			return v
+		}
+		trimmed := make([]any, len(v))
+		for i, item := range v {
+			trimmed[i] = trimResultValue(item, maxBytes)
+		}
+		return trimmed
+	default:
+		return value
+	}
+}
+
+// CallBatch calls the tools of the calls concurrently, at most maxConcurrency at a time (0 for
+// DefaultBatchConcurrency), and returns the results in the order of the calls. A failed call does
+// not abort the batch, its result holds "success": false and the "error"; the error returned is the
+// cause of the cancellation of the context, the calls not started then fail with it.
+func (tc *ToolCollection) CallBatch(ctx context.Context, toolCalls []*llms.ToolCall, maxConcurrency int) ([]*llms.ToolCallResult, error) {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]*llms.ToolCallResult, len(toolCalls))
+	var wg sync.WaitGroup
+
+	// Use a semaphore to limit concurrent calls
+	semaphore := make(chan struct{}, maxConcurrency)
+
+	for i, toolCall := range toolCalls {
+		wg.Add(1)
+		go func(index int, toolCall *llms.ToolCall) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				results[index] = failedToolCallResult(toolCall, context.Cause(ctx))
+				return
+			}
+			select {
+			case semaphore <- struct{}{}: // Acquire semaphore
+				defer func() { <-semaphore }() // Release semaphore
+			case <-ctx.Done():
+				results[index] = failedToolCallResult(toolCall, context.Cause(ctx))
+				return
+			}
+
+			result, err := tc.Call(ctx, toolCall)
+			switch {
+			case err != nil:
+				result = failedToolCallResult(toolCall, err)
+			case result == nil:
+				result = &llms.ToolCallResult{ToolCallId: toolCall.ToolCallId, Name: toolCall.Name}
+			}
+			results[index] = result
+		}(i, toolCall)
+	}
+
+	wg.Wait()
+	return results, context.Cause(ctx)
+}
+
+func failedToolCallResult(toolCall *llms.ToolCall, err error) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: toolCall.ToolCallId,
+		Name:       toolCall.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   err.Error(),
+		},
+	}
+}
+
+// ValidateToolCall checks the tool of the call exists and the arguments of the call match its
+// parameters schema, see ValidateArguments
+func (tc *ToolCollection) ValidateToolCall(toolCall *llms.ToolCall) error {
+	tool := tc.findTool(toolCall.Name)
+	if tool == nil {
+		return errors.Errorf(ErrorCodeToolNotFound, "tool %s not found", toolCall.Name)
+	}
+	return ValidateArguments(tool, toolCall)
 }
 
 func (tc *ToolCollection) findTool(toolName string) Tool {
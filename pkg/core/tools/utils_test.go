@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/oopslink/agent-go/pkg/support/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newBatchTool creates a mock tool answering after the delay, tracking the calls in flight
+func newBatchTool(ctrl *gomock.Controller, name string, delay time.Duration, inFlight, maxInFlight *atomic.Int32) *MockTool {
+	tool := NewMockTool(ctrl)
+	tool.EXPECT().Descriptor().Return(&llms.ToolDescriptor{Name: name}).AnyTimes()
+	tool.EXPECT().Call(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, call *llms.ToolCall) (*llms.ToolCallResult, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(delay)
+			if name == "failing" {
+				return nil, fmt.Errorf("%s failed", call.ToolCallId)
+			}
+			return &llms.ToolCallResult{
+				ToolCallId: call.ToolCallId,
+				Name:       call.Name,
+				Result:     map[string]any{"success": true},
+			}, nil
+		}).AnyTimes()
+	return tool
+}
+
+func TestToolCollection_CallBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	var inFlight, maxInFlight atomic.Int32
+	collection := OfTools(
+		newBatchTool(ctrl, "slow", 100*time.Millisecond, &inFlight, &maxInFlight),
+		newBatchTool(ctrl, "fast", 0, &inFlight, &maxInFlight),
+		newBatchTool(ctrl, "failing", 0, &inFlight, &maxInFlight),
+	)
+
+	names := []string{"slow", "fast", "slow", "failing", "slow", "missing", "fast", "slow"}
+	var calls []*llms.ToolCall
+	for idx, name := range names {
+		calls = append(calls, &llms.ToolCall{ToolCallId: fmt.Sprintf("call_%d", idx), Name: name})
+	}
+
+	start := time.Now()
+	results, err := collection.CallBatch(context.Background(), calls, 4)
+	elapsed := time.Since(start)
+	require.NoError(t, err)
+
+	// the calls run concurrently, bounded by the pool
+	assert.Equal(t, int32(4), maxInFlight.Load())
+	assert.Less(t, elapsed, 350*time.Millisecond)
+
+	// the results keep the order of the calls, failures are captured per call
+	require.Len(t, results, len(calls))
+	for idx, result := range results {
+		assert.Equal(t, calls[idx].ToolCallId, result.ToolCallId)
+		assert.Equal(t, names[idx], result.Name)
+	}
+	assert.Equal(t, true, results[0].Result["success"])
+	assert.Equal(t, true, results[1].Result["success"])
+	assert.Equal(t, map[string]any{"success": false, "error": "call_3 failed"}, results[3].Result)
+	assert.Equal(t, false, results[5].Result["success"])
+	assert.Contains(t, results[5].Result["error"], "tool missing not found")
+}
+
+func TestToolCollection_CallBatchSequential(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	var inFlight, maxInFlight atomic.Int32
+	collection := OfTools(newBatchTool(ctrl, "slow", 10*time.Millisecond, &inFlight, &maxInFlight))
+
+	calls := []*llms.ToolCall{{ToolCallId: "a", Name: "slow"}, {ToolCallId: "b", Name: "slow"}, {ToolCallId: "c", Name: "slow"}}
+	results, err := collection.CallBatch(context.Background(), calls, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), maxInFlight.Load())
+	require.Len(t, results, 3)
+	assert.Equal(t, "c", results[2].ToolCallId)
+}
+
+func TestToolCollection_CallBatchCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tool := NewMockTool(ctrl)
+	tool.EXPECT().Descriptor().Return(&llms.ToolDescriptor{Name: "never"}).AnyTimes()
+	collection := OfTools(tool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// with a canceled context no call gets a slot, none is started
+	results, err := collection.CallBatch(ctx, []*llms.ToolCall{{ToolCallId: "a", Name: "never"}}, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a", results[0].ToolCallId)
+	assert.Equal(t, false, results[0].Result["success"])
+}
+
+func TestToolCollection_CallTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer tracing.SetTracerProvider(nil)
+
+	ctrl := gomock.NewController(t)
+	var inFlight, maxInFlight atomic.Int32
+	tc := OfTools(
+		newBatchTool(ctrl, "ok", 0, &inFlight, &maxInFlight),
+		newBatchTool(ctrl, "failing", 0, &inFlight, &maxInFlight))
+
+	// the span of the tool is a child of the span of the context
+	ctx, parent := tracing.StartSpan(context.Background(), "agent")
+	_, err := tc.Call(ctx, &llms.ToolCall{ToolCallId: "1", Name: "ok"})
+	require.NoError(t, err)
+	_, err = tc.Call(ctx, &llms.ToolCall{ToolCallId: "2", Name: "failing"})
+	require.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "execute_tool ok", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), AttributeToolName.String("ok"))
+	assert.Contains(t, spans[0].Attributes(), AttributeToolCallId.String("1"))
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "execute_tool failing", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "2 failed", spans[1].Status().Description)
+}
+
+func TestToolCollection_CallWithMaxResultBytes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	long := "BEGIN " + strings.Repeat("x", 1000) + " END"
+	result := map[string]any{
+		"output": long,
+		"lines":  []any{"short", long},
+		"nested": map[string]any{"stderr": long},
+		"code":   1,
+	}
+	tool := NewMockTool(ctrl)
+	tool.EXPECT().Descriptor().Return(&llms.ToolDescriptor{Name: "run"}).AnyTimes()
+	tool.EXPECT().Call(gomock.Any(), gomock.Any()).Return(
+		&llms.ToolCallResult{ToolCallId: "call-1", Name: "run", Result: result}, nil).Times(2)
+
+	// results are not bounded by default
+	collection := OfTools(tool)
+	unbounded, err := collection.Call(context.Background(), &llms.ToolCall{ToolCallId: "call-1", Name: "run"})
+	require.NoError(t, err)
+	assert.Equal(t, long, unbounded.Result["output"])
+
+	bounded, err := collection.WithMaxResultBytes(100).Call(context.Background(), &llms.ToolCall{ToolCallId: "call-1", Name: "run"})
+	require.NoError(t, err)
+	for _, value := range []any{
+		bounded.Result["output"], bounded.Result["lines"].([]any)[1], bounded.Result["nested"].(map[string]any)["stderr"],
+	} {
+		text := value.(string)
+		assert.LessOrEqual(t, len(text), 100)
+		assert.True(t, strings.HasPrefix(text, "BEGIN "))
+		assert.True(t, strings.HasSuffix(text, " END"))
+	}
+	assert.Equal(t, "short", bounded.Result["lines"].([]any)[0])
+	assert.Equal(t, 1, bounded.Result["code"])
+	// the result of the tool is left untouched
+	assert.Equal(t, long, result["output"])
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/journal"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+var _ errors.WithErrorCode = &ArgumentsValidationError{}
+
+// ArgumentsValidationError lists the arguments of a tool call not matching the parameters
+// schema of the tool. Its message tells the model what to fix: each problem field with its
+// expected type and an example value, then an example of valid arguments.
+type ArgumentsValidationError struct {
+	Tool       string                 // Name of the tool
+	Violations []llms.SchemaViolation // Problems of the arguments
+	Example    any                    // Example of arguments matching the schema
+}
+
+func (e *ArgumentsValidationError) Error() string {
+	lines := []string{fmt.Sprintf("invalid arguments for tool %q, fix them and call the tool again:", e.Tool)}
+	for _, violation := range e.Violations {
+		lines = append(lines, "- "+describeViolation(violation))
+	}
+	if example, err := json.Marshal(e.Example); err == nil && e.Example != nil {
+		lines = append(lines, "example of valid arguments: "+string(example))
+	}
+	code := e.GetCode()
+	return fmt.Sprintf("[ERR,%s]: %s", code.String(), strings.Join(lines, "\n"))
+}
+
+func (e *ArgumentsValidationError) GetCode() errors.ErrorCode {
+	return ErrorCodeInvalidToolArguments
+}
+
+func describeViolation(violation llms.SchemaViolation) string {
+	field := "arguments"
+	if violation.Path != "" {
+		field = fmt.Sprintf("field %q", violation.Path)
+	}
+	description := field + ": " + violation.Message
+	if violation.Schema == nil {
+		return description
+	}
+	if example, err := json.Marshal(violation.Schema.Example()); err == nil {
+		description += ", e.g. " + string(example)
+	}
+	if violation.Schema.Description != "" {
+		description += " (" + violation.Schema.Description + ")"
+	}
+	return description
+}
+
+// ValidateArguments validates the arguments of the call against the parameters schema of the tool,
+// once coerced when the tool accepts loosely-typed arguments, see WithArgumentCoercion.
+// It returns an *ArgumentsValidationError, logged to the journal, when they do not match.
+func ValidateArguments(tool Tool, call *llms.ToolCall) error {
+	descriptor := tool.Descriptor()
+	if descriptor == nil || descriptor.Parameters == nil || call == nil {
+		return nil
+	}
+
+	arguments := call.Arguments
+	if _, coerced := tool.(*withArgumentCoercion); coerced {
+		arguments = descriptor.Parameters.CoerceArguments(arguments)
+	}
+	var value any = arguments
+	if arguments == nil {
+		value = map[string]any{}
+	}
+
+	validationErr, ok := descriptor.Parameters.Validate(value).(*llms.SchemaValidationError)
+	if !ok {
+		return nil
+	}
+	err := &ArgumentsValidationError{
+		Tool:       descriptor.Name,
+		Violations: validationErr.Violations,
+		Example:    descriptor.Parameters.Example(),
+	}
+
+	problems := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		problems = append(problems, violation.String())
+	}
+	_ = journal.Warning("tool", descriptor.Name,
+		"invalid tool arguments", "tool_call_id", call.ToolCallId, "args", call.Arguments, "problems", problems)
+	return err
+}
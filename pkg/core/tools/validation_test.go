@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/journal"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStorage keeps the journal entries in memory
+type recordingStorage struct {
+	mu      sync.Mutex
+	entries []journal.Entry
+}
+
+func (s *recordingStorage) Write(entry journal.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingStorage) WriteUsage(sessionId string, usage map[string]float64) error { return nil }
+func (s *recordingStorage) Close() error                                                { return nil }
+
+func recordJournal(t *testing.T) *recordingStorage {
+	storage := &recordingStorage{}
+	previous := journal.GetGlobalJournal()
+	journal.SetGlobalJournal(journal.NewJournal(storage))
+	t.Cleanup(func() { journal.SetGlobalJournal(previous) })
+	return storage
+}
+
+// writeTool declares required and typed parameters
+type writeTool struct {
+	repeatTool
+}
+
+func (t *writeTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: "write_file",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"path":    {Type: llms.TypeString, Description: "Path of the file"},
+				"content": {Type: llms.TypeString},
+				"mode":    {Type: llms.TypeInteger},
+				"tags":    {Type: llms.TypeArray, Items: &llms.Schema{Type: llms.TypeString}},
+			},
+			Required: []string{"path", "content"},
+		},
+	}
+}
+
+func TestValidateArguments_EnumeratesProblems(t *testing.T) {
+	storage := recordJournal(t)
+	call := &llms.ToolCall{
+		ToolCallId: "call_1",
+		Name:       "write_file",
+		Arguments:  map[string]any{"content": "hello", "mode": "rw", "tags": []any{"a", 2}},
+	}
+
+	err := ValidateArguments(&writeTool{}, call)
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidToolArguments))
+
+	var validationErr *ArgumentsValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "write_file", validationErr.Tool)
+	require.Len(t, validationErr.Violations, 3)
+
+	message := err.Error()
+	assert.Contains(t, message, `invalid arguments for tool "write_file"`)
+	assert.Contains(t, message, `- field "path": is required, expected string, e.g. "text" (Path of the file)`)
+	assert.Contains(t, message, `- field "mode": expected integer, got string "rw", e.g. 1`)
+	assert.Contains(t, message, `- field "tags[1]": expected string, got number 2, e.g. "text"`)
+	assert.Contains(t, message, `example of valid arguments: {"content":"text","path":"text"}`)
+
+	// operators find the failure in the journal
+	require.Len(t, storage.entries, 1)
+	entry := storage.entries[0]
+	assert.Equal(t, journal.LevelWarning, entry.Level)
+	assert.Equal(t, "tool", entry.Category)
+	assert.Equal(t, "write_file", entry.Source)
+	assert.Equal(t, "invalid tool arguments", entry.Message)
+	assert.Equal(t, "call_1", entry.Data["tool_call_id"])
+	assert.Contains(t, entry.Data["problems"], `field "mode": expected integer, got string "rw"`)
+}
+
+func TestValidateArguments_Valid(t *testing.T) {
+	storage := recordJournal(t)
+
+	assert.NoError(t, ValidateArguments(&writeTool{}, &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": "hello", "mode": 644},
+	}))
+	// coerced arguments are validated once coerced
+	assert.NoError(t, ValidateArguments(WithArgumentCoercion(&writeTool{}), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": "hello", "mode": "644"},
+	}))
+	// null optional arguments are taken as absent
+	assert.NoError(t, ValidateArguments(&writeTool{}, &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": "hello", "mode": nil, "tags": nil},
+	}))
+	assert.NoError(t, ValidateArguments(WithArgumentCoercion(&writeTool{}), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": "hello", "mode": nil},
+	}))
+	// tools without parameters accept anything
+	assert.NoError(t, ValidateArguments(&noParametersTool{}, &llms.ToolCall{Name: "noop"}))
+	assert.Empty(t, storage.entries)
+
+	// null required arguments are not
+	err := ValidateArguments(&writeTool{}, &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": nil},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `field "content": expected string, got null`)
+}
+
+func TestToolCollection_ValidateToolCall(t *testing.T) {
+	collection := OfTools(&writeTool{})
+
+	err := collection.ValidateToolCall(&llms.ToolCall{Name: "missing"})
+	assert.True(t, errors.IsCode(err, ErrorCodeToolNotFound))
+
+	err = collection.ValidateToolCall(&llms.ToolCall{Name: "write_file"})
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidToolArguments))
+	assert.Contains(t, err.Error(), `field "content": is required`)
+}
+
+type noParametersTool struct {
+	repeatTool
+}
+
+func (t *noParametersTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{Name: "noop"}
+}
+
+// countingWriteTool counts the calls reaching the tool
+type countingWriteTool struct {
+	writeTool
+	calls int
+}
+
+func (t *countingWriteTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	t.calls++
+	return &llms.ToolCallResult{ToolCallId: params.ToolCallId, Name: params.Name, Result: map[string]any{"success": true}}, nil
+}
+
+func TestToolCollection_CallValidatesArguments(t *testing.T) {
+	recordJournal(t)
+	tool := &countingWriteTool{}
+	collection := OfTools(tool)
+
+	// missing required fields
+	_, err := collection.Call(context.Background(), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt"},
+	})
+	var validationErr *ArgumentsValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Violations, 1)
+	assert.Equal(t, "content", validationErr.Violations[0].Path)
+	assert.Contains(t, err.Error(), `- field "content": is required, expected string`)
+
+	// mismatching types
+	_, err = collection.Call(context.Background(), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": 42, "content": "hello", "mode": true},
+	})
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Violations, 2)
+	assert.Contains(t, err.Error(), `- field "path": expected string, got number 42`)
+	assert.Contains(t, err.Error(), `- field "mode": expected integer, got boolean true`)
+	assert.Equal(t, 0, tool.calls)
+
+	result, err := collection.Call(context.Background(), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"path": "a.txt", "content": "hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, result.Result["success"])
+	assert.Equal(t, 1, tool.calls)
+}
+
+func TestToolCollection_CallWithoutSchemaValidation(t *testing.T) {
+	tool := &countingWriteTool{}
+	collection := OfTools(tool).WithSchemaValidation(false)
+
+	// the tool gets the arguments as they are
+	_, err := collection.Call(context.Background(), &llms.ToolCall{
+		Name:      "write_file",
+		Arguments: map[string]any{"mode": "rw"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, tool.calls)
+}
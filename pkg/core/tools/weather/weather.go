@@ -0,0 +1,430 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// GeocodingURL is the URL of the geocoding API of Open-Meteo
+	GeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	// ForecastURL is the URL of the forecast API of Open-Meteo
+	ForecastURL = "https://api.open-meteo.com/v1/forecast"
+	// CustomerGeocodingURL is the URL of the geocoding API of Open-Meteo for the customers with an API key
+	CustomerGeocodingURL = "https://customer-geocoding-api.open-meteo.com/v1/search"
+	// CustomerForecastURL is the URL of the forecast API of Open-Meteo for the customers with an API key
+	CustomerForecastURL = "https://customer-api.open-meteo.com/v1/forecast"
+
+	// DefaultForecastDays is the number of days of the forecast when the call has no days
+	DefaultForecastDays = 3
+	// MaxForecastDays is the maximum number of days of the forecast
+	MaxForecastDays = 7
+)
+
+// Units of the weather
+const (
+	UnitsMetric   = "metric"   // Celsius, km/h and millimeters
+	UnitsImperial = "imperial" // Fahrenheit, mph and inches
+)
+
+// NewWeatherTool creates a tool getting the current weather and the forecast of a location from
+// Open-Meteo. The free API needs no key, the API key of a commercial plan of Open-Meteo can be
+// given instead of an empty one.
+func NewWeatherTool(apiKey string) *WeatherTool {
+	t := &WeatherTool{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		apiKey:       apiKey,
+		geocodingURL: GeocodingURL,
+		forecastURL:  ForecastURL,
+	}
+	if apiKey != "" {
+		t.geocodingURL = CustomerGeocodingURL
+		t.forecastURL = CustomerForecastURL
+	}
+	return t
+}
+
+// WithURLs sets the URLs of the geocoding and the forecast APIs
+func (t *WeatherTool) WithURLs(geocodingURL, forecastURL string) *WeatherTool {
+	t.geocodingURL = geocodingURL
+	t.forecastURL = forecastURL
+	return t
+}
+
+// Location is a place found by the geocoding of a location
+type Location struct {
+	Name      string  `json:"name"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone,omitempty"`
+}
+
+// CurrentWeather is the weather of a location at a time
+type CurrentWeather struct {
+	Time          string  `json:"time"`
+	Temperature   float64 `json:"temperature"`
+	FeelsLike     float64 `json:"feels_like"`
+	Humidity      float64 `json:"humidity"`
+	Conditions    string  `json:"conditions"`
+	WindSpeed     float64 `json:"wind_speed"`
+	WindDirection float64 `json:"wind_direction"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+// DailyForecast is the forecast of a location for a day
+type DailyForecast struct {
+	Date                     string  `json:"date"`
+	Conditions               string  `json:"conditions"`
+	TemperatureMax           float64 `json:"temperature_max"`
+	TemperatureMin           float64 `json:"temperature_min"`
+	PrecipitationProbability float64 `json:"precipitation_probability"`
+}
+
+// Ensure the tool implements the Tool interface
+var _ tools.Tool = &WeatherTool{}
+
+// WeatherTool gets the current weather and the forecast of a location
+type WeatherTool struct {
+	client       *http.Client
+	apiKey       string
+	geocodingURL string
+	forecastURL  string
+}
+
+// Descriptor implements the Tool interface
+func (t *WeatherTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "get_weather",
+		Description: "Get the current weather and the daily forecast of a location: temperature, conditions, humidity, wind and precipitation.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"location": {
+					Type:        llms.TypeString,
+					Description: "The city to get the weather of, optionally followed by its region or country, e.g. 'London', 'Paris, France' or 'Portland, Oregon'",
+				},
+				"units": {
+					Type:        llms.TypeString,
+					Description: "'metric' for Celsius, km/h and millimeters, or 'imperial' for Fahrenheit, mph and inches (default: metric)",
+				},
+				"days": {
+					Type:        llms.TypeInteger,
+					Description: fmt.Sprintf("Number of days of the forecast, from 1 to %d (default: %d)", MaxForecastDays, DefaultForecastDays),
+				},
+			},
+			Required: []string{"location"},
+		},
+	}
+}
+
+// Call implements the Tool interface
+func (t *WeatherTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	location, ok := params.Arguments["location"].(string)
+	if !ok || strings.TrimSpace(location) == "" {
+		return failure(params, "location parameter is required and must be a non-empty string"), nil
+	}
+	units := UnitsMetric
+	if value, ok := params.Arguments["units"].(string); ok && value != "" {
+		units = strings.ToLower(value)
+	}
+	if units != UnitsMetric && units != UnitsImperial {
+		return failure(params, fmt.Sprintf("unsupported units: %s, use metric or imperial", units)), nil
+	}
+	days := DefaultForecastDays
+	if value, ok := params.Arguments["days"].(float64); ok {
+		days = int(value)
+	} else if value, ok := params.Arguments["days"].(int); ok {
+		days = value
+	}
+	days = min(max(days, 1), MaxForecastDays)
+
+	place, err := t.geocode(ctx, location)
+	if err != nil {
+		klog.Errorf("weather geocoding failed: %v", err)
+		return failure(params, err.Error()), nil
+	}
+	forecast, err := t.forecast(ctx, place, units, days)
+	if err != nil {
+		klog.Errorf("weather forecast failed: %v", err)
+		return failure(params, err.Error()), nil
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":  true,
+			"location": place,
+			"units": map[string]string{
+				"system":        units,
+				"temperature":   forecast.CurrentUnits.Temperature,
+				"wind_speed":    forecast.CurrentUnits.WindSpeed,
+				"precipitation": forecast.CurrentUnits.Precipitation,
+			},
+			"current":  forecast.current(),
+			"forecast": forecast.daily(),
+		},
+	}, nil
+}
+
+type geocodingResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Country     string  `json:"country"`
+		Admin1      string  `json:"admin1"`
+		Timezone    string  `json:"timezone"`
+	} `json:"results"`
+}
+
+// geocode finds the place of the location, a city optionally followed by its region or country
+// which chooses among the cities of that name
+func (t *WeatherTool) geocode(ctx context.Context, location string) (*Location, error) {
+	parts := strings.Split(location, ",")
+	name := strings.TrimSpace(parts[0])
+	qualifiers := parts[1:]
+
+	values := url.Values{
+		"name":     {name},
+		"count":    {"10"},
+		"language": {"en"},
+		"format":   {"json"},
+	}
+	var response geocodingResponse
+	if err := t.get(ctx, t.geocodingURL, values, &response); err != nil {
+		return nil, err
+	}
+
+	for _, result := range response.Results {
+		if matchesAll(qualifiers, result.Country, result.CountryCode, result.Admin1) {
+			return &Location{
+				Name:      result.Name,
+				Region:    result.Admin1,
+				Country:   result.Country,
+				Latitude:  result.Latitude,
+				Longitude: result.Longitude,
+				Timezone:  result.Timezone,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf(tools.ErrorCodeToolCallFailed, "location not found: %s", location)
+}
+
+// matchesAll tells whether each qualifier is one of the names
+func matchesAll(qualifiers []string, names ...string) bool {
+	for _, qualifier := range qualifiers {
+		qualifier = strings.TrimSpace(qualifier)
+		if qualifier == "" {
+			continue
+		}
+		matched := false
+		for _, name := range names {
+			if strings.EqualFold(qualifier, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+type forecastResponse struct {
+	CurrentUnits struct {
+		Temperature   string `json:"temperature_2m"`
+		WindSpeed     string `json:"wind_speed_10m"`
+		Precipitation string `json:"precipitation"`
+	} `json:"current_units"`
+	Current struct {
+		Time          string  `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		FeelsLike     float64 `json:"apparent_temperature"`
+		Humidity      float64 `json:"relative_humidity_2m"`
+		WeatherCode   int     `json:"weather_code"`
+		WindSpeed     float64 `json:"wind_speed_10m"`
+		WindDirection float64 `json:"wind_direction_10m"`
+		Precipitation float64 `json:"precipitation"`
+	} `json:"current"`
+	Daily struct {
+		Time                     []string  `json:"time"`
+		WeatherCode              []int     `json:"weather_code"`
+		TemperatureMax           []float64 `json:"temperature_2m_max"`
+		TemperatureMin           []float64 `json:"temperature_2m_min"`
+		PrecipitationProbability []float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+}
+
+func (r *forecastResponse) current() *CurrentWeather {
+	return &CurrentWeather{
+		Time:          r.Current.Time,
+		Temperature:   r.Current.Temperature,
+		FeelsLike:     r.Current.FeelsLike,
+		Humidity:      r.Current.Humidity,
+		Conditions:    Conditions(r.Current.WeatherCode),
+		WindSpeed:     r.Current.WindSpeed,
+		WindDirection: r.Current.WindDirection,
+		Precipitation: r.Current.Precipitation,
+	}
+}
+
+func (r *forecastResponse) daily() []DailyForecast {
+	daily := r.Daily
+	forecasts := make([]DailyForecast, 0, len(daily.Time))
+	for i, date := range daily.Time {
+		forecast := DailyForecast{Date: date}
+		if i < len(daily.WeatherCode) {
+			forecast.Conditions = Conditions(daily.WeatherCode[i])
+		}
+		if i < len(daily.TemperatureMax) {
+			forecast.TemperatureMax = daily.TemperatureMax[i]
+		}
+		if i < len(daily.TemperatureMin) {
+			forecast.TemperatureMin = daily.TemperatureMin[i]
+		}
+		if i < len(daily.PrecipitationProbability) {
+			forecast.PrecipitationProbability = daily.PrecipitationProbability[i]
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts
+}
+
+// forecast gets the current weather and the daily forecast of the place
+func (t *WeatherTool) forecast(ctx context.Context, place *Location, units string, days int) (*forecastResponse, error) {
+	values := url.Values{
+		"latitude":      {strconv.FormatFloat(place.Latitude, 'f', -1, 64)},
+		"longitude":     {strconv.FormatFloat(place.Longitude, 'f', -1, 64)},
+		"current":       {"temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_direction_10m,precipitation"},
+		"daily":         {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max"},
+		"timezone":      {"auto"},
+		"forecast_days": {strconv.Itoa(days)},
+	}
+	if units == UnitsImperial {
+		values.Set("temperature_unit", "fahrenheit")
+		values.Set("wind_speed_unit", "mph")
+		values.Set("precipitation_unit", "inch")
+	}
+
+	var response forecastResponse
+	if err := t.get(ctx, t.forecastURL, values, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// get decodes the JSON response of the API to the query
+func (t *WeatherTool) get(ctx context.Context, apiURL string, values url.Values, target any) error {
+	if t.apiKey != "" {
+		values.Set("apikey", t.apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+values.Encode(), nil)
+	if err != nil {
+		return errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to create request: %s", err.Error())
+	}
+	req.Header.Set("User-Agent", "agent-go/1.0 WeatherTool")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to make request: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// the errors of Open-Meteo are {"error": true, "reason": "..."}
+		var apiError struct {
+			Reason string `json:"reason"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiError) == nil && apiError.Reason != "" {
+			return errors.Errorf(tools.ErrorCodeToolCallFailed,
+				"unexpected status code: %d, %s", resp.StatusCode, apiError.Reason)
+		}
+		return errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to parse response: %s", err.Error())
+	}
+	return nil
+}
+
+// Conditions describes the WMO weather interpretation code used by Open-Meteo
+func Conditions(code int) string {
+	switch code {
+	case 0:
+		return "clear sky"
+	case 1:
+		return "mainly clear"
+	case 2:
+		return "partly cloudy"
+	case 3:
+		return "overcast"
+	case 45, 48:
+		return "fog"
+	case 51, 53, 55:
+		return "drizzle"
+	case 56, 57:
+		return "freezing drizzle"
+	case 61:
+		return "slight rain"
+	case 63:
+		return "moderate rain"
+	case 65:
+		return "heavy rain"
+	case 66, 67:
+		return "freezing rain"
+	case 71:
+		return "slight snow fall"
+	case 73:
+		return "moderate snow fall"
+	case 75:
+		return "heavy snow fall"
+	case 77:
+		return "snow grains"
+	case 80, 81, 82:
+		return "rain showers"
+	case 85, 86:
+		return "snow showers"
+	case 95:
+		return "thunderstorm"
+	case 96, 99:
+		return "thunderstorm with hail"
+	default:
+		return fmt.Sprintf("unknown (code %d)", code)
+	}
+}
+
+func failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
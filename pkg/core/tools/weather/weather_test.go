@@ -0,0 +1,221 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// fixture is a response recorded from Open-Meteo, a file of testdata
+type fixture struct {
+	file   string
+	status int
+}
+
+// newOpenMeteoServer replays the fixtures of the geocoding and the forecast APIs and keeps the
+// queries it receives by path
+func newOpenMeteoServer(t *testing.T, queries map[string]url.Values, geocoding, forecast fixture) *httptest.Server {
+	bodies := map[string][]byte{}
+	statuses := map[string]int{}
+	for path, f := range map[string]fixture{"/v1/search": geocoding, "/v1/forecast": forecast} {
+		body, err := os.ReadFile(filepath.Join("testdata", f.file))
+		require.NoError(t, err)
+		bodies[path] = body
+		statuses[path] = f.status
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		queries[r.URL.Path] = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(statuses[r.URL.Path])
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestTool(apiKey string, server *httptest.Server) *WeatherTool {
+	return NewWeatherTool(apiKey).WithURLs(server.URL+"/v1/search", server.URL+"/v1/forecast")
+}
+
+func getWeather(t *testing.T, tool *WeatherTool, arguments map[string]any) map[string]any {
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "test-id",
+		Name:       "get_weather",
+		Arguments:  arguments,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-id", result.ToolCallId)
+	assert.Equal(t, "get_weather", result.Name)
+	return result.Result
+}
+
+func TestWeatherTool_Descriptor(t *testing.T) {
+	descriptor := NewWeatherTool("").Descriptor()
+
+	assert.Equal(t, "get_weather", descriptor.Name)
+	assert.Equal(t, llms.TypeObject, descriptor.Parameters.Type)
+	assert.Contains(t, descriptor.Parameters.Properties, "location")
+	assert.Contains(t, descriptor.Parameters.Properties, "units")
+	assert.Contains(t, descriptor.Parameters.Properties, "days")
+	assert.Equal(t, []string{"location"}, descriptor.Parameters.Required)
+}
+
+func TestNewWeatherTool_APIKey(t *testing.T) {
+	free := NewWeatherTool("")
+	assert.Equal(t, GeocodingURL, free.geocodingURL)
+	assert.Equal(t, ForecastURL, free.forecastURL)
+
+	customer := NewWeatherTool("secret")
+	assert.Equal(t, CustomerGeocodingURL, customer.geocodingURL)
+	assert.Equal(t, CustomerForecastURL, customer.forecastURL)
+}
+
+func TestWeatherTool_Call(t *testing.T) {
+	queries := map[string]url.Values{}
+	server := newOpenMeteoServer(t, queries,
+		fixture{file: "geocoding_portland.json", status: http.StatusOK},
+		fixture{file: "forecast_metric.json", status: http.StatusOK})
+	tool := newTestTool("", server)
+
+	result := getWeather(t, tool, map[string]any{"location": "Portland, Maine"})
+
+	assert.Equal(t, true, result["success"])
+	assert.Equal(t, &Location{
+		Name:      "Portland",
+		Region:    "Maine",
+		Country:   "United States",
+		Latitude:  43.65737,
+		Longitude: -70.2589,
+		Timezone:  "America/New_York",
+	}, result["location"])
+	assert.Equal(t, map[string]string{
+		"system":        UnitsMetric,
+		"temperature":   "°C",
+		"wind_speed":    "km/h",
+		"precipitation": "mm",
+	}, result["units"])
+	assert.Equal(t, &CurrentWeather{
+		Time:          "2025-06-14T10:15",
+		Temperature:   17.4,
+		FeelsLike:     16.9,
+		Humidity:      72,
+		Conditions:    "overcast",
+		WindSpeed:     11.2,
+		WindDirection: 205,
+		Precipitation: 0,
+	}, result["current"])
+	assert.Equal(t, []DailyForecast{
+		{Date: "2025-06-14", Conditions: "overcast", TemperatureMax: 21.3, TemperatureMin: 12.8, PrecipitationProbability: 15},
+		{Date: "2025-06-15", Conditions: "slight rain", TemperatureMax: 18.6, TemperatureMin: 13.5, PrecipitationProbability: 80},
+		{Date: "2025-06-16", Conditions: "mainly clear", TemperatureMax: 24.1, TemperatureMin: 11.9, PrecipitationProbability: 5},
+	}, result["forecast"])
+
+	geocoding := queries["/v1/search"]
+	assert.Equal(t, "Portland", geocoding.Get("name"))
+	assert.Empty(t, geocoding.Get("apikey"))
+
+	forecast := queries["/v1/forecast"]
+	assert.Equal(t, "43.65737", forecast.Get("latitude"))
+	assert.Equal(t, "-70.2589", forecast.Get("longitude"))
+	assert.Equal(t, "3", forecast.Get("forecast_days"))
+	assert.Equal(t, "auto", forecast.Get("timezone"))
+	assert.Empty(t, forecast.Get("temperature_unit"))
+}
+
+func TestWeatherTool_CallImperial(t *testing.T) {
+	queries := map[string]url.Values{}
+	server := newOpenMeteoServer(t, queries,
+		fixture{file: "geocoding_portland.json", status: http.StatusOK},
+		fixture{file: "forecast_metric.json", status: http.StatusOK})
+	tool := newTestTool("secret", server)
+
+	result := getWeather(t, tool, map[string]any{"location": "Portland", "units": "Imperial", "days": float64(30)})
+
+	assert.Equal(t, true, result["success"])
+	// the first place of the name when the location has no region or country
+	assert.Equal(t, "Oregon", result["location"].(*Location).Region)
+
+	forecast := queries["/v1/forecast"]
+	assert.Equal(t, "fahrenheit", forecast.Get("temperature_unit"))
+	assert.Equal(t, "mph", forecast.Get("wind_speed_unit"))
+	assert.Equal(t, "inch", forecast.Get("precipitation_unit"))
+	assert.Equal(t, "7", forecast.Get("forecast_days"))
+	assert.Equal(t, "secret", forecast.Get("apikey"))
+	assert.Equal(t, "secret", queries["/v1/search"].Get("apikey"))
+}
+
+func TestWeatherTool_CallFailed(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		geocoding fixture
+		forecast  fixture
+		error     string
+	}{
+		{
+			name:      "missing location",
+			arguments: map[string]any{},
+			error:     "location parameter is required and must be a non-empty string",
+		},
+		{
+			name:      "invalid units",
+			arguments: map[string]any{"location": "Portland", "units": "kelvin"},
+			error:     "unsupported units: kelvin, use metric or imperial",
+		},
+		{
+			name:      "unknown location",
+			arguments: map[string]any{"location": "Nowhereville"},
+			geocoding: fixture{file: "geocoding_empty.json", status: http.StatusOK},
+			error:     "location not found: Nowhereville",
+		},
+		{
+			name:      "unknown country",
+			arguments: map[string]any{"location": "Portland, France"},
+			error:     "location not found: Portland, France",
+		},
+		{
+			name:      "forecast error",
+			arguments: map[string]any{"location": "Portland"},
+			forecast:  fixture{file: "forecast_error.json", status: http.StatusBadRequest},
+			error:     "unexpected status code: 400, Cannot initialize WeatherVariable from invalid String value temperature_3m for key current",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.geocoding.file == "" {
+				tt.geocoding = fixture{file: "geocoding_portland.json", status: http.StatusOK}
+			}
+			if tt.forecast.file == "" {
+				tt.forecast = fixture{file: "forecast_metric.json", status: http.StatusOK}
+			}
+			server := newOpenMeteoServer(t, map[string]url.Values{}, tt.geocoding, tt.forecast)
+
+			result := getWeather(t, newTestTool("", server), tt.arguments)
+
+			assert.Equal(t, false, result["success"])
+			assert.Contains(t, result["error"], tt.error)
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	assert.Equal(t, "clear sky", Conditions(0))
+	assert.Equal(t, "fog", Conditions(48))
+	assert.Equal(t, "thunderstorm with hail", Conditions(99))
+	assert.Equal(t, "unknown (code 42)", Conditions(42))
+}
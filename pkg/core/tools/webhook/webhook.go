@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// DefaultTimeout is the default timeout of the requests to the webhooks
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxResponseBytes is the default size above which the body of a response is truncated
+	DefaultMaxResponseBytes = 64 * 1024
+)
+
+// managedHeaders are request headers set by the tool or the HTTP client, not taken from the parameters
+var managedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Host":              true,
+}
+
+// NewWebhookTool creates a tool posting JSON payloads to webhooks. Only the hosts of the allowlist
+// are reached, to keep the model from sending requests to internal services: an entry is a host
+// name ("hooks.example.com"), a host and a port ("localhost:8080") or a wildcard for the
+// subdomains of a domain ("*.example.com").
+func NewWebhookTool(allowedHosts []string) *WebhookTool {
+	t := &WebhookTool{
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			t.allowedHosts = append(t.allowedHosts, host)
+		}
+	}
+	t.client = &http.Client{
+		Timeout: DefaultTimeout,
+		// a redirect must not lead the request out of the allowlist
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return t.checkURL(req.URL)
+		},
+	}
+	return t
+}
+
+// WithTimeout sets the timeout of the requests, DefaultTimeout by default
+func (t *WebhookTool) WithTimeout(timeout time.Duration) *WebhookTool {
+	t.client.Timeout = timeout
+	return t
+}
+
+// WithMaxResponseBytes sets the size above which the body of a response is truncated,
+// DefaultMaxResponseBytes by default
+func (t *WebhookTool) WithMaxResponseBytes(maxResponseBytes int64) *WebhookTool {
+	t.maxResponseBytes = maxResponseBytes
+	return t
+}
+
+var _ tools.Tool = &WebhookTool{}
+
+// WebhookTool posts JSON payloads to the webhooks of the allowed hosts
+type WebhookTool struct {
+	client           *http.Client
+	allowedHosts     []string
+	maxResponseBytes int64
+}
+
+func (t *WebhookTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "webhook_post",
+		Description: "Trigger a webhook by posting a JSON payload to its URL, and return the status and the body of the response. Only the allowed hosts can be reached.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"url": {
+					Type:        llms.TypeString,
+					Description: "The HTTP or HTTPS URL of the webhook",
+				},
+				"payload": {
+					Type:        llms.TypeObject,
+					Description: "The JSON object posted to the webhook",
+				},
+				"headers": {
+					Type:        llms.TypeObject,
+					Description: "Additional request headers, e.g. {\"Authorization\": \"Bearer ...\"}",
+				},
+			},
+			Required: []string{"url", "payload"},
+		},
+	}
+}
+
+func (t *WebhookTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	rawURL, _ := params.Arguments["url"].(string)
+	if strings.TrimSpace(rawURL) == "" {
+		return failure(params, "url parameter is required and cannot be empty"), nil
+	}
+	payload, ok := params.Arguments["payload"].(map[string]any)
+	if !ok {
+		return failure(params, "payload parameter is required and must be an object"), nil
+	}
+	headers, err := headersOf(params.Arguments["headers"])
+	if err != nil {
+		return failure(params, err.Error()), nil
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return failure(params, fmt.Sprintf("invalid URL: %v", err)), nil
+	}
+	if err := t.checkURL(target); err != nil {
+		return failure(params, err.Error()), nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return failure(params, fmt.Sprintf("failed to encode payload: %v", err)), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return failure(params, fmt.Sprintf("failed to create request: %v", err)), nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "agent-go/1.0 WebhookTool")
+	for key, value := range headers {
+		if !managedHeaders[http.CanonicalHeaderKey(key)] {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return failure(params, fmt.Sprintf("request failed: %v", err)), nil
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
+	if err != nil {
+		return failure(params, fmt.Sprintf("failed to read response: %v", err)), nil
+	}
+	truncated := int64(len(responseBody)) > t.maxResponseBytes
+	if truncated {
+		responseBody = responseBody[:t.maxResponseBytes]
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success":      resp.StatusCode >= 200 && resp.StatusCode < 300,
+			"status_code":  resp.StatusCode,
+			"content_type": resp.Header.Get("Content-Type"),
+			"body":         string(responseBody),
+			"truncated":    truncated,
+		},
+	}, nil
+}
+
+// checkURL rejects the URLs which are not HTTP or HTTPS, or whose host is not allowed
+func (t *WebhookTool) checkURL(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("only HTTP and HTTPS URLs are supported")
+	}
+	if target.Hostname() == "" {
+		return fmt.Errorf("invalid URL: missing host")
+	}
+	if !t.isAllowed(target) {
+		return fmt.Errorf("host %s is not allowed", target.Host)
+	}
+	return nil
+}
+
+func (t *WebhookTool) isAllowed(target *url.URL) bool {
+	hostname := strings.ToLower(target.Hostname())
+	hostAndPort := hostname
+	if port := target.Port(); port != "" {
+		hostAndPort = net.JoinHostPort(hostname, port)
+	}
+	for _, allowed := range t.allowedHosts {
+		switch {
+		case strings.HasPrefix(allowed, "*."):
+			if strings.HasSuffix(hostname, allowed[1:]) {
+				return true
+			}
+		case allowed == hostname || allowed == hostAndPort:
+			return true
+		}
+	}
+	return false
+}
+
+// headersOf returns the headers of the parameters, an object of strings
+func headersOf(value any) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("headers parameter must be an object")
+	}
+	headers := make(map[string]string, len(object))
+	for key, value := range object {
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("header %s must be a string", key)
+		}
+		headers[key] = text
+	}
+	return headers, nil
+}
+
+func failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
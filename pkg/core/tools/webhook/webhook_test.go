@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+func post(t *testing.T, tool *WebhookTool, arguments map[string]any) map[string]any {
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "1",
+		Name:       "webhook_post",
+		Arguments:  arguments,
+	})
+	require.NoError(t, err)
+	return result.Result
+}
+
+func TestWebhookTool_Post(t *testing.T) {
+	var received map[string]any
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		header = r.Header
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"queued":true}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	tool := NewWebhookTool([]string{serverURL.Host})
+	result := post(t, tool, map[string]any{
+		"url":     server.URL + "/hooks/deploy",
+		"payload": map[string]any{"service": "api", "version": "1.2.0"},
+		"headers": map[string]any{"Authorization": "Bearer secret", "Host": "evil.example.com"},
+	})
+
+	assert.Equal(t, map[string]any{
+		"success":      true,
+		"status_code":  http.StatusAccepted,
+		"content_type": "application/json",
+		"body":         `{"queued":true}`,
+		"truncated":    false,
+	}, result)
+	assert.Equal(t, map[string]any{"service": "api", "version": "1.2.0"}, received)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+
+	// an error status is not a success, the body is truncated
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+	})
+	result = post(t, tool.WithMaxResponseBytes(7), map[string]any{"url": server.URL, "payload": map[string]any{}})
+	assert.Equal(t, false, result["success"])
+	assert.Equal(t, http.StatusUnauthorized, result["status_code"])
+	assert.Equal(t, "invalid", result["body"])
+	assert.Equal(t, true, result["truncated"])
+}
+
+func TestWebhookTool_BlockedHost(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	tool := NewWebhookTool([]string{"hooks.example.com", "*.example.org"})
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{server.URL, "is not allowed"},
+		{"http://169.254.169.254/latest/meta-data", "host 169.254.169.254 is not allowed"},
+		{"https://example.org/hook", "host example.org is not allowed"},
+		{"file:///etc/passwd", "only HTTP and HTTPS URLs are supported"},
+		{"ftp://hooks.example.com/hook", "only HTTP and HTTPS URLs are supported"},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			result := post(t, tool, map[string]any{"url": test.url, "payload": map[string]any{}})
+			assert.Equal(t, false, result["success"])
+			assert.Contains(t, result["error"], test.expected)
+		})
+	}
+	assert.Zero(t, requests.Load())
+
+	// the allowed hosts
+	serverURL, _ := url.Parse(server.URL)
+	assert.True(t, tool.isAllowed(&url.URL{Host: "HOOKS.example.com"}))
+	assert.True(t, tool.isAllowed(&url.URL{Host: "ci.example.org:8443"}))
+	assert.False(t, tool.isAllowed(&url.URL{Host: "hooks.example.com.evil.net"}))
+	assert.True(t, NewWebhookTool([]string{serverURL.Hostname()}).isAllowed(serverURL))
+	assert.False(t, NewWebhookTool([]string{serverURL.Hostname() + ":1"}).isAllowed(serverURL))
+}
+
+func TestWebhookTool_BlockedRedirect(t *testing.T) {
+	var redirected atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Add(1)
+	}))
+	defer internal.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	result := post(t, NewWebhookTool([]string{serverURL.Host}), map[string]any{
+		"url": server.URL, "payload": map[string]any{"a": 1},
+	})
+	assert.Equal(t, false, result["success"])
+	assert.Contains(t, result["error"], "is not allowed")
+	assert.Zero(t, redirected.Load())
+}
+
+func TestWebhookTool_InvalidParameters(t *testing.T) {
+	tool := NewWebhookTool([]string{"hooks.example.com"})
+
+	result := post(t, tool, map[string]any{"payload": map[string]any{}})
+	assert.Contains(t, result["error"], "url parameter is required")
+	result = post(t, tool, map[string]any{"url": "https://hooks.example.com", "payload": "text"})
+	assert.Contains(t, result["error"], "payload parameter is required and must be an object")
+	result = post(t, tool, map[string]any{"url": "https://hooks.example.com", "payload": map[string]any{}, "headers": map[string]any{"X-Retry": 3}})
+	assert.Contains(t, result["error"], "header X-Retry must be a string")
+}
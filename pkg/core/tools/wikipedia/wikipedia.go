@@ -0,0 +1,244 @@
+package wikipedia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/core/tools"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	// DefaultAPIURL is the URL of the MediaWiki API of Wikipedia, {lang} is replaced by the language
+	DefaultAPIURL = "https://{lang}.wikipedia.org/w/api.php"
+	// DefaultLanguage is the language of the articles searched when the call has no lang
+	DefaultLanguage = "en"
+	// DefaultMaxResults is the number of articles returned when the call has no max_results
+	DefaultMaxResults = 3
+	// MaxResults is the maximum number of articles returned by a call
+	MaxResults = 10
+)
+
+// languagePattern matches the Wikipedia language codes, e.g. "en", "zh-yue" or "simple"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,8}(-[a-z]{2,8})*$`)
+
+// NewWikipediaTool creates a new Wikipedia tool instance
+func NewWikipediaTool() *WikipediaTool {
+	return &WikipediaTool{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		apiURL:   DefaultAPIURL,
+		language: DefaultLanguage,
+	}
+}
+
+// WithAPIURL sets the URL of the MediaWiki API, {lang} is replaced by the language of the call
+func (t *WikipediaTool) WithAPIURL(apiURL string) *WikipediaTool {
+	t.apiURL = apiURL
+	return t
+}
+
+// WithLanguage sets the language of the articles searched when the call has no lang
+func (t *WikipediaTool) WithLanguage(language string) *WikipediaTool {
+	t.language = language
+	return t
+}
+
+// Article is an article found on Wikipedia
+type Article struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+}
+
+// Ensure the tool implements the Tool interface
+var _ tools.Tool = &WikipediaTool{}
+
+// WikipediaTool searches the articles of Wikipedia and returns their summaries
+type WikipediaTool struct {
+	client   *http.Client
+	apiURL   string
+	language string
+}
+
+// Call implements the Tool interface
+func (t *WikipediaTool) Call(ctx context.Context, params *llms.ToolCall) (*llms.ToolCallResult, error) {
+	queryArg, ok := params.Arguments["query"]
+	if !ok {
+		return failure(params, "query parameter is required"), nil
+	}
+	query, ok := queryArg.(string)
+	if !ok {
+		return failure(params, "query parameter must be a string"), nil
+	}
+	if strings.TrimSpace(query) == "" {
+		return failure(params, "query cannot be empty"), nil
+	}
+
+	language := t.language
+	if lang, ok := params.Arguments["lang"].(string); ok && strings.TrimSpace(lang) != "" {
+		language = strings.ToLower(strings.TrimSpace(lang))
+	}
+	if !languagePattern.MatchString(language) {
+		return failure(params, fmt.Sprintf("invalid language code: %s", language)), nil
+	}
+
+	maxResults := DefaultMaxResults
+	if value, ok := params.Arguments["max_results"].(float64); ok {
+		maxResults = int(value)
+	} else if value, ok := params.Arguments["max_results"].(int); ok {
+		maxResults = value
+	}
+	maxResults = min(max(maxResults, 1), MaxResults)
+
+	articles, err := t.search(ctx, query, language, maxResults)
+	if err != nil {
+		klog.Errorf("wikipedia search failed: %v", err)
+		return failure(params, fmt.Sprintf("search failed: %v", err)), nil
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": true,
+			"query":   query,
+			"lang":    language,
+			"results": articles,
+			"count":   len(articles),
+		},
+	}, nil
+}
+
+// Descriptor implements the Tool interface
+func (t *WikipediaTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "wikipedia_search",
+		Description: "Search Wikipedia for encyclopedic knowledge. Returns the top articles with their titles, URLs, and the summaries of their introductions.",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"query": {
+					Type:        llms.TypeString,
+					Description: "The search query to perform on Wikipedia",
+				},
+				"lang": {
+					Type:        llms.TypeString,
+					Description: fmt.Sprintf("The language code of the Wikipedia to search, e.g. 'en', 'fr' or 'zh' (default: %s)", t.language),
+				},
+				"max_results": {
+					Type:        llms.TypeInteger,
+					Description: fmt.Sprintf("Maximum number of articles returned, up to %d (default: %d)", MaxResults, DefaultMaxResults),
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+// queryResponse is the response of the MediaWiki API to a query generated by a search
+type queryResponse struct {
+	Query struct {
+		Pages []struct {
+			Title   string `json:"title"`
+			Index   int    `json:"index"`
+			Extract string `json:"extract"`
+			FullURL string `json:"fullurl"`
+			Missing bool   `json:"missing"`
+		} `json:"pages"`
+	} `json:"query"`
+	Error *struct {
+		Code string `json:"code"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// search returns the summaries of the articles found by the search, in the order of their relevance
+func (t *WikipediaTool) search(ctx context.Context, query, language string, maxResults int) ([]Article, error) {
+	values := url.Values{
+		"action":        {"query"},
+		"format":        {"json"},
+		"formatversion": {"2"},
+		"generator":     {"search"},
+		"gsrsearch":     {query},
+		"gsrlimit":      {strconv.Itoa(maxResults)},
+		"prop":          {"extracts|info"},
+		"exintro":       {"1"},
+		"explaintext":   {"1"},
+		"exlimit":       {"max"},
+		"inprop":        {"url"},
+		"redirects":     {"1"},
+	}
+	apiURL := strings.ReplaceAll(t.apiURL, "{lang}", language)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to create request: %s", err.Error())
+	}
+	// Wikimedia asks the clients to identify themselves
+	req.Header.Set("User-Agent", "agent-go/1.0 WikipediaTool")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to make request: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"unexpected status code: %d", resp.StatusCode)
+	}
+
+	var response queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"failed to parse response: %s", err.Error())
+	}
+	if response.Error != nil {
+		return nil, errors.Errorf(tools.ErrorCodeToolCallFailed,
+			"%s: %s", response.Error.Code, response.Error.Info)
+	}
+
+	pages := response.Query.Pages
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Index < pages[j].Index
+	})
+	articles := make([]Article, 0, len(pages))
+	for _, page := range pages {
+		if page.Missing {
+			continue
+		}
+		articles = append(articles, Article{
+			Title:   page.Title,
+			URL:     page.FullURL,
+			Summary: strings.TrimSpace(page.Extract),
+		})
+	}
+	return articles, nil
+}
+
+func failure(params *llms.ToolCall, message string) *llms.ToolCallResult {
+	return &llms.ToolCallResult{
+		ToolCallId: params.ToolCallId,
+		Name:       params.Name,
+		Result: map[string]any{
+			"success": false,
+			"error":   message,
+		},
+	}
+}
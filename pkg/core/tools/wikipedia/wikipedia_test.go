@@ -0,0 +1,145 @@
+package wikipedia
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// mediaWikiResponse is the shape of a query generated by a search, the pages not in the order of the search
+const mediaWikiResponse = `{
+  "batchcomplete": true,
+  "continue": {"gsroffset": 2, "continue": "gsroffset||"},
+  "query": {
+    "pages": [
+      {
+        "pageid": 25039021, "ns": 0, "title": "Go (game)", "index": 2,
+        "extract": "Go is an abstract strategy board game for two players.",
+        "contentmodel": "wikitext", "pagelanguage": "en",
+        "fullurl": "https://en.wikipedia.org/wiki/Go_(game)"
+      },
+      {
+        "pageid": 25039022, "ns": 0, "title": "Go (programming language)", "index": 1,
+        "extract": "Go is a high-level general purpose programming language.\n",
+        "contentmodel": "wikitext", "pagelanguage": "en",
+        "fullurl": "https://en.wikipedia.org/wiki/Go_(programming_language)"
+      }
+    ]
+  }
+}`
+
+func newMediaWikiServer(t *testing.T, requests *[]*http.Request, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func search(t *testing.T, tool *WikipediaTool, arguments map[string]any) *llms.ToolCallResult {
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "test-id",
+		Name:       "wikipedia_search",
+		Arguments:  arguments,
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestWikipediaTool_Descriptor(t *testing.T) {
+	descriptor := NewWikipediaTool().Descriptor()
+
+	assert.Equal(t, "wikipedia_search", descriptor.Name)
+	assert.Contains(t, descriptor.Description, "Search Wikipedia")
+	assert.Equal(t, llms.TypeObject, descriptor.Parameters.Type)
+	assert.Contains(t, descriptor.Parameters.Properties, "query")
+	assert.Contains(t, descriptor.Parameters.Properties, "lang")
+	assert.Equal(t, []string{"query"}, descriptor.Parameters.Required)
+}
+
+func TestWikipediaTool_Search(t *testing.T) {
+	var requests []*http.Request
+	server := newMediaWikiServer(t, &requests, mediaWikiResponse)
+	tool := NewWikipediaTool().WithAPIURL(server.URL + "/{lang}/w/api.php")
+
+	result := search(t, tool, map[string]any{"query": "golang", "max_results": float64(2)})
+	assert.Equal(t, "test-id", result.ToolCallId)
+	assert.Equal(t, "wikipedia_search", result.Name)
+	assert.Equal(t, map[string]any{
+		"success": true,
+		"query":   "golang",
+		"lang":    "en",
+		"count":   2,
+		"results": []Article{
+			{
+				Title:   "Go (programming language)",
+				URL:     "https://en.wikipedia.org/wiki/Go_(programming_language)",
+				Summary: "Go is a high-level general purpose programming language.",
+			},
+			{
+				Title:   "Go (game)",
+				URL:     "https://en.wikipedia.org/wiki/Go_(game)",
+				Summary: "Go is an abstract strategy board game for two players.",
+			},
+		},
+	}, result.Result)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/en/w/api.php", requests[0].URL.Path)
+	query := requests[0].URL.Query()
+	assert.Equal(t, "golang", query.Get("gsrsearch"))
+	assert.Equal(t, "2", query.Get("gsrlimit"))
+	assert.Equal(t, "extracts|info", query.Get("prop"))
+	assert.NotEmpty(t, requests[0].Header.Get("User-Agent"))
+
+	// the language of the call
+	result = search(t, tool, map[string]any{"query": "golang", "lang": "FR"})
+	assert.Equal(t, "fr", result.Result["lang"])
+	assert.Equal(t, "/fr/w/api.php", requests[1].URL.Path)
+	assert.Equal(t, "3", requests[1].URL.Query().Get("gsrlimit"))
+}
+
+func TestWikipediaTool_NoResults(t *testing.T) {
+	var requests []*http.Request
+	server := newMediaWikiServer(t, &requests, `{"batchcomplete": true}`)
+	tool := NewWikipediaTool().WithAPIURL(server.URL + "/{lang}/w/api.php")
+
+	result := search(t, tool, map[string]any{"query": "qwxzv"})
+	assert.Equal(t, true, result.Result["success"])
+	assert.Equal(t, 0, result.Result["count"])
+}
+
+func TestWikipediaTool_Errors(t *testing.T) {
+	var requests []*http.Request
+	server := newMediaWikiServer(t, &requests,
+		`{"error": {"code": "badvalue", "info": "Unrecognized value for parameter \"prop\"."}}`)
+	tool := NewWikipediaTool().WithAPIURL(server.URL + "/{lang}/w/api.php")
+
+	result := search(t, tool, map[string]any{"query": "golang"})
+	assert.Equal(t, false, result.Result["success"])
+	assert.Contains(t, result.Result["error"], "badvalue")
+
+	tests := []struct {
+		arguments map[string]any
+		expected  string
+	}{
+		{map[string]any{}, "query parameter is required"},
+		{map[string]any{"query": 123}, "query parameter must be a string"},
+		{map[string]any{"query": " "}, "query cannot be empty"},
+		{map[string]any{"query": "golang", "lang": "evil.com/x?"}, "invalid language code"},
+	}
+	for _, test := range tests {
+		result := search(t, tool, test.arguments)
+		assert.Equal(t, false, result.Result["success"])
+		assert.Contains(t, result.Result["error"], test.expected)
+	}
+	assert.Len(t, requests, 1)
+}
@@ -1,6 +1,42 @@
 package document
 
+import "fmt"
+
 type Chunker interface {
 	// Chunk takes a document and splits it into smaller chunks.
 	Chunk(doc *Document) ([]*Document, error)
 }
+
+// newChunk creates the chunkNumber-th chunk of the document, copying its metadata
+// and adding the chunk specific metadata.
+func newChunk(doc *Document, chunkNumber int, content string) *Document {
+	// Create metadata for this chunk
+	chunkMetadata := make(map[string]any)
+	if doc.Metadata != nil {
+		// Copy original metadata
+		for k, v := range doc.Metadata {
+			chunkMetadata[k] = v
+		}
+	}
+
+	// Add chunk-specific metadata
+	chunkMetadata["chunk"] = chunkNumber
+	chunkMetadata["chunk_size"] = len(content)
+
+	// Generate chunk ID
+	var chunkId DocumentId
+	if doc.Id != "" {
+		chunkId = DocumentId(fmt.Sprintf("%s_%d", doc.Id, chunkNumber))
+	} else if doc.Name != "" {
+		chunkId = DocumentId(fmt.Sprintf("%s_%d", doc.Name, chunkNumber))
+	} else {
+		chunkId = DocumentId(fmt.Sprintf("chunk_%d", chunkNumber))
+	}
+
+	return &Document{
+		Id:       chunkId,
+		Name:     doc.Name,
+		Metadata: chunkMetadata,
+		Content:  content,
+	}
+}
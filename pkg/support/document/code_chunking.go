@@ -0,0 +1,277 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+var _ Chunker = (*codeChunker)(nil)
+
+const (
+	// MetadataKeySymbol is the name of the top-level declaration of a chunk of source code,
+	// e.g. "Reader.Read" for a Go method
+	MetadataKeySymbol = "symbol"
+	// MetadataKeyLanguage is the programming language of a chunk of source code
+	MetadataKeyLanguage = "language"
+)
+
+// codeLanguage tells the top-level declarations of a programming language apart
+type codeLanguage struct {
+	name string
+	// indented tells whether the blocks are delimited by indentation rather than by braces
+	indented bool
+	// lineComment starts the comments attached to the declaration below them
+	lineComment string
+	// declaration matches the first line of a declaration, capturing its name and for the
+	// methods the type of their receiver
+	declaration *regexp.Regexp
+}
+
+var codeLanguages = map[string]*codeLanguage{
+	"go": {
+		name:        "go",
+		lineComment: "//",
+		declaration: regexp.MustCompile(`^(?:func\s+(?:\(\s*(?:\w+\s+)?\*?(\w+)(?:\[[^\]]*\])?\s*\)\s*)?(\w+)|(?:type|var|const)\s+(\w+))`),
+	},
+	"python": {
+		name:        "python",
+		indented:    true,
+		lineComment: "#",
+		declaration: regexp.MustCompile(`^(?:async\s+def|def|class)\s+()(\w+)`),
+	},
+	"javascript": {
+		name:        "javascript",
+		lineComment: "//",
+		declaration: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|interface|type|enum|const|let|var)\s+()([\w$]+)`),
+	},
+}
+
+func init() {
+	codeLanguages["golang"] = codeLanguages["go"]
+	codeLanguages["py"] = codeLanguages["python"]
+	codeLanguages["js"] = codeLanguages["javascript"]
+	codeLanguages["typescript"] = codeLanguages["javascript"]
+	codeLanguages["ts"] = codeLanguages["javascript"]
+}
+
+// NewCodeChunker creates a chunker splitting source code at its top-level declarations, so that
+// each function, type or class is a chunk of its own, with its comments, recording its name in
+// the MetadataKeySymbol metadata. The declarations longer than maxSize characters are split by
+// lines. The languages are "go", "python" and "javascript" or "typescript"; the blocks are found
+// by a brace or indentation heuristic rather than by parsing the code.
+func NewCodeChunker(language string, maxSize int) Chunker {
+	return &codeChunker{Language: strings.ToLower(language), MaxSize: maxSize}
+}
+
+type codeChunker struct {
+	Language string // Programming language of the documents
+	MaxSize  int    // Maximum size of each chunk in characters
+}
+
+// codeSegment is a top-level declaration, or the statements between declarations
+type codeSegment struct {
+	symbol string
+	lines  []string
+	// decorated tells whether the segment only holds the decorators of the declaration to come
+	decorated bool
+}
+
+func (c *codeChunker) Chunk(doc *Document) ([]*Document, error) {
+	if c.MaxSize <= 0 {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "max size must be greater than 0")
+	}
+	language, ok := codeLanguages[c.Language]
+	if !ok {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "unsupported language %q", c.Language)
+	}
+
+	var chunks []*Document
+	chunkNumber := 1
+	for _, segment := range splitCodeSegments(language, doc.Content) {
+		content := strings.Trim(strings.Join(segment.lines, "\n"), "\n")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		for _, piece := range c.splitLines(content) {
+			chunk := newChunk(doc, chunkNumber, piece)
+			chunk.Metadata[MetadataKeyLanguage] = language.name
+			if segment.symbol != "" {
+				chunk.Metadata[MetadataKeySymbol] = segment.symbol
+			}
+			chunks = append(chunks, chunk)
+			chunkNumber++
+		}
+	}
+	return chunks, nil
+}
+
+// splitLines splits the content longer than the max size between its lines, and the lines
+// longer than the max size between their characters
+func (c *codeChunker) splitLines(content string) []string {
+	if len(content) <= c.MaxSize {
+		return []string{content}
+	}
+
+	var pieces []string
+	var current strings.Builder
+	flush := func() {
+		if piece := strings.Trim(current.String(), "\n"); strings.TrimSpace(piece) != "" {
+			pieces = append(pieces, piece)
+		}
+		current.Reset()
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if current.Len()+len(line) > c.MaxSize {
+			flush()
+		}
+		for len(line) > c.MaxSize {
+			pieces = append(pieces, line[:c.MaxSize])
+			line = line[c.MaxSize:]
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return pieces
+}
+
+// splitCodeSegments splits the code before each top-level statement starting a declaration, and
+// before the first statement following a declaration
+func splitCodeSegments(language *codeLanguage, code string) []*codeSegment {
+	scanner := &codeScanner{language: language}
+	var segments []*codeSegment
+	current := &codeSegment{}
+
+	for _, line := range strings.Split(code, "\n") {
+		topLevel := scanner.atTopLevel() && isTopLevelStatement(language, line)
+		scanner.scan(line)
+		if !topLevel {
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		symbol, declaration := declarationSymbol(language, line)
+		decorator := language.indented && strings.HasPrefix(line, "@")
+		switch {
+		case current.decorated && declaration:
+			// the declaration of the decorators
+			current.symbol = symbol
+			current.decorated = false
+			current.lines = append(current.lines, line)
+			continue
+		case current.decorated && decorator:
+			current.lines = append(current.lines, line)
+			continue
+		case !declaration && !decorator && current.symbol == "" && !current.decorated:
+			// the statements between declarations stay together
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		// the comments right above the statement belong to it
+		next := &codeSegment{symbol: symbol, decorated: decorator}
+		comments := len(current.lines)
+		for comments > 0 && strings.HasPrefix(current.lines[comments-1], language.lineComment) {
+			comments--
+		}
+		next.lines = append(next.lines, current.lines[comments:]...)
+		current.lines = current.lines[:comments]
+		segments = append(segments, current)
+		current = next
+		current.lines = append(current.lines, line)
+	}
+	return append(segments, current)
+}
+
+// isTopLevelStatement tells whether the line starts a statement at the top level of the code,
+// given that it is not inside a block, a comment or a string
+func isTopLevelStatement(language *codeLanguage, line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	if strings.HasPrefix(line, language.lineComment) {
+		return false
+	}
+	// the end of a block or of a parenthesized group
+	return !strings.ContainsAny(line[:1], ")]}")
+}
+
+// declarationSymbol returns the name of the declaration starting on the line, the methods of
+// Go being named after their receiver
+func declarationSymbol(language *codeLanguage, line string) (string, bool) {
+	match := language.declaration.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	receiver, name := match[1], match[2]
+	if len(match) > 3 && match[3] != "" {
+		name = match[3]
+	}
+	if receiver != "" {
+		return receiver + "." + name, true
+	}
+	return name, true
+}
+
+// codeScanner follows the blocks, the multi-line comments and the multi-line strings of the code,
+// line by line
+type codeScanner struct {
+	language *codeLanguage
+
+	depth        int    // Depth of the braces, parentheses and brackets
+	blockComment bool   // Inside a /* */ comment
+	rawString    string // Delimiter of the multi-line string the scanner is inside, if any
+}
+
+func (s *codeScanner) atTopLevel() bool {
+	return s.depth <= 0 && !s.blockComment && s.rawString == ""
+}
+
+func (s *codeScanner) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		rest := line[i:]
+		switch {
+		case s.blockComment:
+			if strings.HasPrefix(rest, "*/") {
+				s.blockComment = false
+				i++
+			}
+		case s.rawString != "":
+			if strings.HasPrefix(rest, s.rawString) {
+				i += len(s.rawString) - 1
+				s.rawString = ""
+			}
+		case strings.HasPrefix(rest, s.language.lineComment):
+			return
+		case !s.language.indented && strings.HasPrefix(rest, "/*"):
+			s.blockComment = true
+			i++
+		case s.language.indented && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)):
+			s.rawString = rest[:3]
+			i += 2
+		case rest[0] == '`' && !s.language.indented:
+			s.rawString = "`"
+		case rest[0] == '"' || rest[0] == '\'':
+			i = skipQuoted(line, i)
+		case rest[0] == '{' || rest[0] == '(' || rest[0] == '[':
+			s.depth++
+		case rest[0] == '}' || rest[0] == ')' || rest[0] == ']':
+			s.depth--
+		}
+	}
+}
+
+// skipQuoted returns the index of the quote closing the string opened at the index of the line
+func skipQuoted(line string, open int) int {
+	quote := line[open]
+	for i := open + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(line)
+}
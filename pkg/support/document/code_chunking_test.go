@@ -0,0 +1,156 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGoCode = `package shapes
+
+import (
+	"fmt"
+	"math"
+)
+
+// Shape is a plane figure
+type Shape interface {
+	Area() float64
+}
+
+// Circle is a round shape
+type Circle struct {
+	Radius float64
+}
+
+// Area returns the area of the circle
+func (c *Circle) Area() float64 {
+	if c.Radius < 0 {
+		return 0
+	}
+	return math.Pi * c.Radius * c.Radius
+}
+
+func Describe(s Shape) string {
+	brace := "}"
+	return fmt.Sprintf("area %.2f %s", s.Area(), brace)
+}
+`
+
+const testPythonCode = `"""Shapes of the plane."""
+import math
+
+PI = math.pi
+
+
+# a round shape
+@dataclass
+class Circle:
+    radius: float
+
+    def area(self):
+        return PI * self.radius ** 2
+
+
+def describe(shape):
+    text = """
+def not_a_function():
+"""
+    return f"area {shape.area():.2f}"
+
+
+async def fetch(url):
+    return await get(url)
+`
+
+func chunkSymbols(chunks []*Document) []string {
+	var symbols []string
+	for _, chunk := range chunks {
+		symbol, _ := chunk.Metadata[MetadataKeySymbol].(string)
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+func TestCodeChunker_Go(t *testing.T) {
+	chunks, err := NewCodeChunker("go", 1000).Chunk(&Document{Id: "shapes.go", Content: testGoCode})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "Shape", "Circle", "Circle.Area", "Describe"}, chunkSymbols(chunks))
+	assert.Equal(t, "package shapes\n\nimport (\n\t\"fmt\"\n\t\"math\"\n)", chunks[0].Content)
+	// each declaration is whole, with its comments
+	assert.Equal(t, `// Area returns the area of the circle
+func (c *Circle) Area() float64 {
+	if c.Radius < 0 {
+		return 0
+	}
+	return math.Pi * c.Radius * c.Radius
+}`, chunks[3].Content)
+	assert.True(t, strings.HasSuffix(chunks[4].Content, "brace)\n}"))
+	assert.Equal(t, "go", chunks[3].Metadata[MetadataKeyLanguage])
+	assert.Equal(t, DocumentId("shapes.go_4"), chunks[3].Id)
+}
+
+func TestCodeChunker_Python(t *testing.T) {
+	chunks, err := NewCodeChunker("python", 1000).Chunk(&Document{Id: "shapes.py", Content: testPythonCode})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "Circle", "describe", "fetch"}, chunkSymbols(chunks))
+	assert.Equal(t, "\"\"\"Shapes of the plane.\"\"\"\nimport math\n\nPI = math.pi", chunks[0].Content)
+	// the decorators and the comments belong to the class, its methods stay in it
+	assert.True(t, strings.HasPrefix(chunks[1].Content, "# a round shape\n@dataclass\nclass Circle:"))
+	assert.Contains(t, chunks[1].Content, "def area(self):")
+	// a declaration inside a string is not one
+	assert.Contains(t, chunks[2].Content, "def not_a_function():")
+	assert.True(t, strings.HasSuffix(chunks[2].Content, `return f"area {shape.area():.2f}"`))
+}
+
+func TestCodeChunker_StatementsAfterDeclaration(t *testing.T) {
+	code := "def main():\n    run()\n\nif __name__ == \"__main__\":\n    main()\n"
+	chunks, err := NewCodeChunker("py", 1000).Chunk(&Document{Id: "main.py", Content: code})
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, []string{"main", ""}, chunkSymbols(chunks))
+	assert.Equal(t, "if __name__ == \"__main__\":\n    main()", chunks[1].Content)
+}
+
+func TestCodeChunker_SplitsLongDeclarations(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("func Long() {\n")
+	for i := 0; i < 20; i++ {
+		body.WriteString("\tstep()\n")
+	}
+	body.WriteString("}\n\nfunc Short() {}\n")
+
+	chunks, err := NewCodeChunker("go", 60).Chunk(&Document{Id: "long.go", Content: body.String()})
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 2)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Content), 60)
+	}
+	symbols := chunkSymbols(chunks)
+	for _, symbol := range symbols[:len(symbols)-1] {
+		assert.Equal(t, "Long", symbol)
+	}
+	assert.Equal(t, "Short", symbols[len(symbols)-1])
+	assert.Equal(t, "func Short() {}", chunks[len(chunks)-1].Content)
+}
+
+func TestCodeChunker_Invalid(t *testing.T) {
+	_, err := NewCodeChunker("cobol", 100).Chunk(&Document{Content: "IDENTIFICATION DIVISION."})
+	assert.Error(t, err)
+
+	_, err = NewCodeChunker("go", 0).Chunk(&Document{Content: "package main"})
+	assert.Error(t, err)
+}
+
+func TestCodeChunker_TypeScript(t *testing.T) {
+	code := "import { get } from './http';\n\n/* a user */\nexport interface User {\n  name: string;\n}\n\n" +
+		"export async function fetchUser(id: string): Promise<User> {\n  return get(`/users/${id}\n}`);\n}\n"
+	chunks, err := NewCodeChunker("typescript", 1000).Chunk(&Document{Id: "user.ts", Content: code})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "User", "fetchUser"}, chunkSymbols(chunks))
+	assert.True(t, strings.HasSuffix(chunks[2].Content, "`);\n}"))
+}
@@ -0,0 +1,197 @@
+package document
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+const (
+	// MetadataKeyRow is the number of the row of a document read from a CSV, from 1 for the
+	// first row after the header
+	MetadataKeyRow = "csv_row"
+)
+
+// CSVOption configures the CSV reader
+type CSVOption func(*csvReader)
+
+// WithContentColumns sets the columns rendered in the content of the documents, in order,
+// all the columns by default. All the columns are kept in the metadata.
+func WithContentColumns(columns ...string) CSVOption {
+	return func(r *csvReader) {
+		r.contentColumns = append(r.contentColumns, columns...)
+	}
+}
+
+// WithIdColumn sets the column holding the ids of the documents, the row numbers by default
+func WithIdColumn(column string) CSVOption {
+	return func(r *csvReader) {
+		r.idColumn = column
+	}
+}
+
+// WithCSVDelimiter sets the field delimiter, ',' by default
+func WithCSVDelimiter(delimiter rune) CSVOption {
+	return func(r *csvReader) {
+		r.delimiter = delimiter
+	}
+}
+
+var _ Reader = (*csvReader)(nil)
+
+// NewCSVReader creates a reader reading a document per row of a CSV, the header row naming
+// the fields. The content of a document renders its fields as "name: value" lines and its
+// metadata holds its fields typed as int64, float64, bool or string.
+func NewCSVReader(opts ...CSVOption) Reader {
+	r := &csvReader{delimiter: ','}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// OfCSV reads the documents of the rows of the CSV file, see NewCSVReader
+func OfCSV(path string, opts ...CSVOption) ([]*Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewCSVReader(opts...).Read(filepath.Base(path), file)
+}
+
+type csvReader struct {
+	contentColumns []string
+	idColumn       string
+	delimiter      rune
+}
+
+func (r *csvReader) Read(documentName string, reader io.Reader, options ...ReaderOption) ([]*Document, error) {
+	// Apply options
+	opts := &ReaderOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = r.delimiter
+	csvReader.FieldsPerRecord = -1 // the missing fields are empty
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the CSV exported by Excel start with a UTF-8 byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if i := slices.IndexFunc(header, func(name string) bool { return name == "" }); i >= 0 {
+		return nil, errors.Errorf(ErrorCodeInvalidCSV, "the column %d has no name", i+1)
+	}
+	for _, column := range append(slices.Clone(r.contentColumns), r.idColumn) {
+		if column != "" && !slices.Contains(header, column) {
+			return nil, errors.Errorf(ErrorCodeInvalidCSV, "no column %q in the header", column)
+		}
+	}
+	contentColumns := r.contentColumns
+	if len(contentColumns) == 0 {
+		contentColumns = header
+	}
+
+	var docs []*Document
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[string]string, len(header))
+		metadata := map[string]any{MetadataKeyRow: row}
+		for i, name := range header {
+			value := ""
+			if i < len(record) {
+				value = record[i]
+			}
+			fields[name] = value
+			metadata[name] = parseCSVField(value)
+		}
+
+		var content strings.Builder
+		for _, column := range contentColumns {
+			if value := fields[column]; value != "" {
+				content.WriteString(fmt.Sprintf("%s: %s\n", column, value))
+			}
+		}
+		id := DocumentId(fmt.Sprintf("row_%d", row))
+		if r.idColumn != "" && fields[r.idColumn] != "" {
+			id = DocumentId(fields[r.idColumn])
+		}
+
+		doc := &Document{
+			Id:       id,
+			Name:     documentName,
+			Metadata: metadata,
+			Content:  strings.TrimSuffix(content.String(), "\n"),
+		}
+		if opts.chunker == nil {
+			docs = append(docs, doc)
+			continue
+		}
+		chunks, err := opts.chunker.Chunk(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, chunks...)
+	}
+	return docs, nil
+}
+
+// parseCSVField types the value of a field as an int64, a float64 or a bool when it is one.
+// The numbers with a leading zero or a plus sign, e.g. zip codes or phone numbers, are text:
+// they would lose their leading characters.
+func parseCSVField(value string) any {
+	trimmed := strings.TrimSpace(value)
+	if isFormattedNumber(trimmed) {
+		return value
+	}
+	if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+		return i
+	}
+	// words such as "NaN" or "Inf" are text
+	if strings.ContainsAny(trimmed, "0123456789") {
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return f
+		}
+	}
+	switch strings.ToLower(trimmed) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
+
+// isFormattedNumber tells whether the digits of the value are formatted with a leading plus sign
+// or leading zeros, e.g. "+3312345" or "02134", not "0" nor "0.5"
+func isFormattedNumber(value string) bool {
+	if strings.HasPrefix(value, "+") {
+		return true
+	}
+	digits := strings.TrimPrefix(value, "-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9'
+}
@@ -0,0 +1,121 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `sku,name,description,price,stock,active
+A-1,Kettle,"Boils water, fast",29.9,12,true
+A-2,"Mug ""XL""","A large mug
+for large coffees",7.5,0,false
+A-3,Teapot,,15,3
+`
+
+func TestCSVReader(t *testing.T) {
+	docs, err := NewCSVReader().Read("products.csv", strings.NewReader(testCSV))
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, DocumentId("row_1"), docs[0].Id)
+	assert.Equal(t, "products.csv", docs[0].Name)
+	assert.Equal(t, "sku: A-1\nname: Kettle\ndescription: Boils water, fast\nprice: 29.9\nstock: 12\nactive: true", docs[0].Content)
+
+	// the quoted fields keep their commas, quotes and line breaks
+	assert.Equal(t, `Mug "XL"`, docs[1].Metadata["name"])
+	assert.Equal(t, "A large mug\nfor large coffees", docs[1].Metadata["description"])
+
+	// the fields are typed in the metadata
+	assert.Equal(t, 29.9, docs[0].Metadata["price"])
+	assert.Equal(t, int64(12), docs[0].Metadata["stock"])
+	assert.Equal(t, true, docs[0].Metadata["active"])
+	assert.Equal(t, false, docs[1].Metadata["active"])
+	assert.Equal(t, "A-1", docs[0].Metadata["sku"])
+	assert.Equal(t, 1, docs[0].Metadata[MetadataKeyRow])
+
+	// the empty and the missing fields are not rendered
+	assert.Equal(t, "sku: A-3\nname: Teapot\nprice: 15\nstock: 3", docs[2].Content)
+	assert.Equal(t, "", docs[2].Metadata["active"])
+}
+
+func TestCSVReader_ContentAndIdColumns(t *testing.T) {
+	docs, err := NewCSVReader(WithContentColumns("name", "description"), WithIdColumn("sku")).
+		Read("products.csv", strings.NewReader(testCSV))
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, DocumentId("A-1"), docs[0].Id)
+	assert.Equal(t, "name: Kettle\ndescription: Boils water, fast", docs[0].Content)
+	// the other columns stay in the metadata
+	assert.Equal(t, 29.9, docs[0].Metadata["price"])
+}
+
+func TestCSVReader_FormattedNumbers(t *testing.T) {
+	data := "zip,phone,id,count,ratio,delta\n02134,+3312345,007,0,0.5,-3\n"
+	docs, err := NewCSVReader().Read("contacts.csv", strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	// the leading zeros and signs are kept
+	assert.Equal(t, "02134", docs[0].Metadata["zip"])
+	assert.Equal(t, "+3312345", docs[0].Metadata["phone"])
+	assert.Equal(t, "007", docs[0].Metadata["id"])
+	assert.Equal(t, int64(0), docs[0].Metadata["count"])
+	assert.Equal(t, 0.5, docs[0].Metadata["ratio"])
+	assert.Equal(t, int64(-3), docs[0].Metadata["delta"])
+}
+
+func TestCSVReader_ByteOrderMark(t *testing.T) {
+	data := "\uFEFFsku,name\nA-1,Kettle\n"
+	docs, err := NewCSVReader(WithContentColumns("name"), WithIdColumn("sku")).
+		Read("products.csv", strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, DocumentId("A-1"), docs[0].Id)
+	assert.Equal(t, "A-1", docs[0].Metadata["sku"])
+}
+
+func TestCSVReader_DelimiterAndChunker(t *testing.T) {
+	data := "title;body\nIntro;The first sentence of the body. The second sentence of the body.\n"
+	docs, err := NewCSVReader(WithCSVDelimiter(';'), WithContentColumns("body")).
+		Read("posts.csv", strings.NewReader(data), WithChunker(NewFixedChunker(40, 0, false)))
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, DocumentId("row_1_1"), docs[0].Id)
+	assert.Equal(t, "Intro", docs[1].Metadata["title"])
+}
+
+func TestCSVReader_Invalid(t *testing.T) {
+	_, err := NewCSVReader(WithContentColumns("missing")).Read("products.csv", strings.NewReader(testCSV))
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidCSV))
+
+	_, err = NewCSVReader(WithIdColumn("id")).Read("products.csv", strings.NewReader(testCSV))
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidCSV))
+
+	_, err = NewCSVReader().Read("products.csv", strings.NewReader("name,,price\nKettle,x,1\n"))
+	assert.True(t, errors.IsCode(err, ErrorCodeInvalidCSV))
+
+	docs, err := NewCSVReader().Read("empty.csv", strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+}
+
+func TestOfCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.csv")
+	require.NoError(t, os.WriteFile(path, []byte(testCSV), 0644))
+
+	docs, err := OfCSV(path, WithIdColumn("sku"))
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, "products.csv", docs[0].Name)
+	assert.Equal(t, DocumentId("A-3"), docs[2].Id)
+
+	_, err = OfCSV(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package document
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MetadataKeySourcePath is the path of the file of a document read by ReadDir, relative to its root
+	MetadataKeySourcePath = "source_path"
+)
+
+// defaultFileReaders are the readers of ReadDir by file extension
+var defaultFileReaders = map[string]Reader{
+	".txt":      NewDefaultReader(),
+	".md":       NewMarkdownReader(),
+	".markdown": NewMarkdownReader(),
+	".csv":      NewCSVReader(),
+}
+
+// WithFileReader sets the reader ReadDir reads the files with the extension with, e.g. ".pdf",
+// in addition to the readers of the text, Markdown and CSV files.
+func WithFileReader(extension string, reader Reader) ReaderOption {
+	return func(opts *ReaderOptions) {
+		if opts.fileReaders == nil {
+			opts.fileReaders = make(map[string]Reader)
+		}
+		opts.fileReaders[strings.ToLower(extension)] = reader
+	}
+}
+
+// WithInclude makes ReadDir read only the files matching one of the glob patterns, see filepath.Match.
+// A pattern is matched against the slash separated path of the file relative to the root and
+// against its name, e.g. "*.md" or "docs/*.txt".
+func WithInclude(patterns ...string) ReaderOption {
+	return func(opts *ReaderOptions) {
+		opts.include = append(opts.include, patterns...)
+	}
+}
+
+// WithExclude makes ReadDir skip the files and the directories matching one of the glob patterns,
+// matched as with WithInclude, e.g. "CHANGELOG.md" or "vendor".
+func WithExclude(patterns ...string) ReaderOption {
+	return func(opts *ReaderOptions) {
+		opts.exclude = append(opts.exclude, patterns...)
+	}
+}
+
+// ReadDir reads the documents of the files under the root directory, picking the reader of each
+// file by its extension: text, Markdown and CSV files, and the extensions set with WithFileReader.
+// The files of other extensions and the hidden files and directories are skipped.
+// Each document is chunked by the chunker set with WithChunker, its id is prefixed by the path
+// of its file, and the path of its file is recorded in the MetadataKeySourcePath metadata.
+func ReadDir(root string, options ...ReaderOption) ([]*Document, error) {
+	opts := &ReaderOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	var docs []*Document
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		skipped := strings.HasPrefix(entry.Name(), ".") || matchesAny(opts.exclude, relPath)
+		if entry.IsDir() {
+			if skipped {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skipped || !entry.Type().IsRegular() {
+			return nil
+		}
+		if len(opts.include) > 0 && !matchesAny(opts.include, relPath) {
+			return nil
+		}
+		reader := opts.fileReader(filepath.Ext(path))
+		if reader == nil {
+			return nil
+		}
+
+		fileDocs, err := readFile(reader, path, relPath, options)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+		docs = append(docs, fileDocs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func readFile(reader Reader, path, relPath string, options []ReaderOption) ([]*Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	docs, err := reader.Read(relPath, file, options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		doc.Id = DocumentId(fmt.Sprintf("%s:%s", relPath, doc.Id))
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata[MetadataKeySourcePath] = relPath
+	}
+	return docs, nil
+}
+
+// fileReader returns the reader of the files with the extension, nil when there is none
+func (o *ReaderOptions) fileReader(extension string) Reader {
+	extension = strings.ToLower(extension)
+	if reader, ok := o.fileReaders[extension]; ok {
+		return reader
+	}
+	return defaultFileReaders[extension]
+}
+
+// matchesAny tells whether the slash separated path or its name matches one of the glob patterns
+func matchesAny(patterns []string, relPath string) bool {
+	name := relPath[strings.LastIndex(relPath, "/")+1:]
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
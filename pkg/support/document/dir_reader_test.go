@@ -0,0 +1,110 @@
+package document
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDir creates the files under a temp directory, by their slash separated paths
+func newTestDir(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+// upperReader reads a file as a single document of its upper-cased content
+type upperReader struct{}
+
+func (r *upperReader) Read(documentName string, reader io.Reader, options ...ReaderOption) ([]*Document, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return []*Document{{Id: "upper", Name: documentName, Content: strings.ToUpper(string(content))}}, nil
+}
+
+func sourcePaths(docs []*Document) []string {
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Metadata[MetadataKeySourcePath].(string))
+	}
+	return paths
+}
+
+func TestReadDir(t *testing.T) {
+	root := newTestDir(t, map[string]string{
+		"notes.txt":        "Some notes.",
+		"guide.md":         "# Install\n\nRun the installer.\n\n# Usage\n\nRun the tool.",
+		"docs/faq.TXT":     "Questions and answers.",
+		"docs/report.pdf":  "not read without a reader",
+		"image.png":        "not a document",
+		".env.txt":         "hidden file",
+		".git/config.txt":  "hidden directory",
+		"vendor/notes.txt": "vendored notes",
+	})
+
+	docs, err := ReadDir(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/faq.TXT", "guide.md", "guide.md", "notes.txt", "vendor/notes.txt"}, sourcePaths(docs))
+
+	// the Markdown sections and the text files are read by their readers
+	assert.Equal(t, DocumentId("docs/faq.TXT:default"), docs[0].Id)
+	assert.Equal(t, "docs/faq.TXT", docs[0].Name)
+	assert.Equal(t, "Questions and answers.", docs[0].Content)
+	assert.Equal(t, DocumentId("guide.md:section_1"), docs[1].Id)
+	assert.Equal(t, "Install", docs[1].Metadata[MetadataKeyHeading])
+	assert.Equal(t, DocumentId("guide.md:section_2"), docs[2].Id)
+	assert.Equal(t, "Usage", docs[2].Metadata[MetadataKeyHeading])
+}
+
+func TestReadDir_IncludeExclude(t *testing.T) {
+	root := newTestDir(t, map[string]string{
+		"notes.txt":        "Some notes.",
+		"guide.md":         "# Install\n\nRun the installer.",
+		"docs/faq.txt":     "Questions and answers.",
+		"docs/CHANGES.md":  "# Changes",
+		"vendor/notes.txt": "vendored notes",
+	})
+
+	docs, err := ReadDir(root, WithInclude("*.md", "docs/*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/CHANGES.md", "docs/faq.txt", "guide.md"}, sourcePaths(docs))
+
+	docs, err = ReadDir(root, WithExclude("vendor", "CHANGES.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/faq.txt", "guide.md", "notes.txt"}, sourcePaths(docs))
+}
+
+func TestReadDir_FileReaderAndChunker(t *testing.T) {
+	root := newTestDir(t, map[string]string{
+		"report.pdf": "pdf content",
+		"notes.txt":  "The first sentence of the notes. The second sentence of the notes.",
+	})
+
+	docs, err := ReadDir(root, WithFileReader(".pdf", &upperReader{}), WithChunker(NewFixedChunker(40, 0, false)))
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, []string{"notes.txt", "notes.txt", "report.pdf"}, sourcePaths(docs))
+
+	// the chunks keep the source path of their file
+	assert.Equal(t, DocumentId("notes.txt:default_1"), docs[0].Id)
+	assert.Equal(t, 1, docs[0].Metadata["chunk"])
+	assert.Equal(t, DocumentId("notes.txt:default_2"), docs[1].Id)
+	assert.Equal(t, DocumentId("report.pdf:upper"), docs[2].Id)
+	assert.Equal(t, "PDF CONTENT", docs[2].Content)
+}
+
+func TestReadDir_NotADirectory(t *testing.T) {
+	_, err := ReadDir(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -1,12 +1,21 @@
 package document
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/oopslink/agent-go/pkg/support/embedder"
 )
 
 type DocumentId string
 
+// ContentHashId returns a deterministic document id derived from the sha256 hash of the content,
+// so the same content always gets the same id.
+func ContentHashId(content string) DocumentId {
+	sum := sha256.Sum256([]byte(content))
+	return DocumentId(hex.EncodeToString(sum[:]))
+}
+
 func NewDocument(id DocumentId, name string, metadata map[string]any, content string) *Document {
 	return &Document{
 		Id:       id,
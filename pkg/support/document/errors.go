@@ -8,4 +8,14 @@ var (
 		Name:           "ChunkingFailed",
 		DefaultMessage: "Failed to chunking",
 	}
+	ErrorCodeUrlCacheFailed = errors.ErrorCode{
+		Code:           30101,
+		Name:           "UrlCacheFailed",
+		DefaultMessage: "Failed to access the URL cache",
+	}
+	ErrorCodeInvalidCSV = errors.ErrorCode{
+		Code:           30102,
+		Name:           "InvalidCSV",
+		DefaultMessage: "The CSV is not valid",
+	}
 )
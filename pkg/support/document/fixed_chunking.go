@@ -1,7 +1,6 @@
 package document
 
 import (
-	"fmt"
 	"github.com/oopslink/agent-go/pkg/commons/errors"
 	"regexp"
 	"strings"
@@ -11,18 +10,43 @@ import (
 
 var _ Chunker = (*fixedChunker)(nil)
 
+// OverlapMode is the unit of the overlap between the chunks of the fixed chunker
+type OverlapMode string
+
+const (
+	OverlapChars     OverlapMode = "chars"     // The overlap is a number of characters
+	OverlapSentences OverlapMode = "sentences" // The overlap is a number of whole sentences
+)
+
+// FixedChunkerOption configures the fixed chunker
+type FixedChunkerOption func(*fixedChunker)
+
+// WithOverlapMode sets the unit of the overlap, OverlapChars by default. With OverlapSentences the
+// chunks are made of whole sentences and the last overlap sentences of a chunk start the next one,
+// only the sentences longer than the chunk size are split.
+func WithOverlapMode(mode OverlapMode) FixedChunkerOption {
+	return func(c *fixedChunker) {
+		c.OverlapMode = mode
+	}
+}
+
 // NewFixedChunker creates a new fixed-size chunker with overlap support.
-func NewFixedChunker(chunkSize int, overlap int, cleanBeforeChunking bool) Chunker {
+func NewFixedChunker(chunkSize int, overlap int, cleanBeforeChunking bool, opts ...FixedChunkerOption) Chunker {
 	if overlap < 0 {
 		overlap = 0 // Ensure overlap is non-negative
 	}
-	return &fixedChunker{ChunkSize: chunkSize, Overlap: overlap, CleanBeforeChunking: cleanBeforeChunking}
+	c := &fixedChunker{ChunkSize: chunkSize, Overlap: overlap, CleanBeforeChunking: cleanBeforeChunking}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type fixedChunker struct {
-	ChunkSize           int  // Size of each chunk in characters
-	Overlap             int  // Number of characters to overlap between chunks
-	CleanBeforeChunking bool // Whether to clean the text before chunking
+	ChunkSize           int         // Size of each chunk in characters
+	Overlap             int         // Number of characters, or sentences, to overlap between chunks
+	OverlapMode         OverlapMode // Unit of the overlap, characters when empty
+	CleanBeforeChunking bool        // Whether to clean the text before chunking
 }
 
 func (c *fixedChunker) Chunk(doc *Document) ([]*Document, error) {
@@ -34,9 +58,15 @@ func (c *fixedChunker) Chunk(doc *Document) ([]*Document, error) {
 		return nil, errors.Errorf(ErrorCodeChunkingFailed, "overlap must be non-negative")
 	}
 
-	if c.Overlap >= c.ChunkSize {
-		return nil, errors.Errorf(ErrorCodeChunkingFailed,
-			"overlap (%d) must be less than chunk size (%d)", c.Overlap, c.ChunkSize)
+	switch c.OverlapMode {
+	case "", OverlapChars:
+		if c.Overlap >= c.ChunkSize {
+			return nil, errors.Errorf(ErrorCodeChunkingFailed,
+				"overlap (%d) must be less than chunk size (%d)", c.Overlap, c.ChunkSize)
+		}
+	case OverlapSentences:
+	default:
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "unknown overlap mode %q", c.OverlapMode)
 	}
 
 	// If cleaning is enabled, clean the text content
@@ -67,6 +97,10 @@ func (c *fixedChunker) Chunk(doc *Document) ([]*Document, error) {
 		return []*Document{cleanedDoc}, nil
 	}
 
+	if c.OverlapMode == OverlapSentences {
+		return c.chunkSentences(doc, content), nil
+	}
+
 	var chunks []*Document
 	start := 0
 	chunkNumber := 1
@@ -94,36 +128,7 @@ func (c *fixedChunker) Chunk(doc *Document) ([]*Document, error) {
 		chunk = strings.TrimSpace(chunk)
 
 		if len(chunk) > 0 {
-			// Create metadata for this chunk
-			chunkMetadata := make(map[string]any)
-			if doc.Metadata != nil {
-				// Copy original metadata
-				for k, v := range doc.Metadata {
-					chunkMetadata[k] = v
-				}
-			}
-
-			// Add chunk-specific metadata
-			chunkMetadata["chunk"] = chunkNumber
-			chunkMetadata["chunk_size"] = len(chunk)
-
-			// Generate chunk ID
-			var chunkId DocumentId
-			if doc.Id != "" {
-				chunkId = DocumentId(fmt.Sprintf("%s_%d", doc.Id, chunkNumber))
-			} else if doc.Name != "" {
-				chunkId = DocumentId(fmt.Sprintf("%s_%d", doc.Name, chunkNumber))
-			} else {
-				chunkId = DocumentId(fmt.Sprintf("chunk_%d", chunkNumber))
-			}
-
-			chunks = append(chunks, &Document{
-				Id:       chunkId,
-				Name:     doc.Name,
-				Metadata: chunkMetadata,
-				Content:  chunk,
-			})
-
+			chunks = append(chunks, newChunk(doc, chunkNumber, chunk))
 			chunkNumber++
 		}
 
@@ -139,6 +144,94 @@ func (c *fixedChunker) Chunk(doc *Document) ([]*Document, error) {
 	return chunks, nil
 }
 
+// chunkSentences packs the whole sentences of the content into the chunks, the last Overlap
+// sentences of a chunk starting the next one as long as they leave room for a new sentence
+func (c *fixedChunker) chunkSentences(doc *Document, content string) []*Document {
+	var chunks []*Document
+	chunkNumber := 1
+	var current []string
+	size := 0
+	fresh := 0 // number of the sentences of the current chunk not carried from the previous one
+
+	emit := func() {
+		chunk := strings.TrimSpace(strings.Join(current, ""))
+		if len(chunk) > 0 {
+			chunks = append(chunks, newChunk(doc, chunkNumber, chunk))
+			chunkNumber++
+		}
+	}
+
+	for _, sentence := range c.splitSentences(content) {
+		if size+len(sentence) > c.ChunkSize && fresh > 0 {
+			emit()
+			carried := current[len(current)-utils.MinInt(c.Overlap, len(current)):]
+			size = 0
+			for _, kept := range carried {
+				size += len(kept)
+			}
+			for len(carried) > 0 && size+len(sentence) > c.ChunkSize {
+				size -= len(carried[0])
+				carried = carried[1:]
+			}
+			current = append([]string(nil), carried...)
+			fresh = 0
+		}
+		current = append(current, sentence)
+		size += len(sentence)
+		fresh++
+	}
+	if fresh > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// splitSentences splits the text after the sentence terminators followed by a whitespace and
+// after the line breaks, keeping the whitespaces with the sentences. The sentences longer than
+// the chunk size are split at word boundaries.
+func (c *fixedChunker) splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		char := text[i]
+		endOfSentence := char == '\n' ||
+			((char == '.' || char == '!' || char == '?') && (i+1 == len(text) || isSpace(text[i+1])))
+		if !endOfSentence {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && isSpace(text[end]) {
+			end++
+		}
+		sentences = append(sentences, text[start:end])
+		start = end
+		i = end - 1
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+
+	var pieces []string
+	for _, sentence := range sentences {
+		for len(sentence) > c.ChunkSize {
+			end := c.findWordBoundary(sentence, c.ChunkSize)
+			if end <= 0 || end > c.ChunkSize {
+				end = c.ChunkSize
+			}
+			pieces = append(pieces, sentence[:end])
+			sentence = sentence[end:]
+		}
+		if len(sentence) > 0 {
+			pieces = append(pieces, sentence)
+		}
+	}
+	return pieces
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\n' || char == '\t' || char == '\r'
+}
+
 // cleanText cleans the text by normalizing whitespace and removing excessive newlines.
 func (c *fixedChunker) cleanText(text string) string {
 	// Replace multiple newlines with a single newline
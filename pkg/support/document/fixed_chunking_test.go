@@ -2,6 +2,7 @@ package document
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -500,3 +501,114 @@ func TestFixedChunker_FindWordBoundary(t *testing.T) {
 		})
 	}
 }
+
+func TestFixedChunker_SentenceOverlap(t *testing.T) {
+	sentences := []string{
+		"The cat sat on the mat.",
+		"It was a sunny day!",
+		"Was the dog there?",
+		"No, the dog stayed home.",
+		"The fish swam in its bowl.",
+	}
+	doc := &Document{Id: "doc", Name: "doc.txt", Content: strings.Join(sentences, " ")}
+
+	chunker := NewFixedChunker(60, 1, false, WithOverlapMode(OverlapSentences))
+	chunks, err := chunker.Chunk(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"The cat sat on the mat. It was a sunny day!",
+		"It was a sunny day! Was the dog there?",
+		"Was the dog there? No, the dog stayed home.",
+		"No, the dog stayed home. The fish swam in its bowl.",
+	}
+	if len(chunks) != len(expected) {
+		t.Fatalf("Expected %d chunks, got %d: %v", len(expected), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Content != expected[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i+1, expected[i], chunk.Content)
+		}
+		if len(chunk.Content) > 60 {
+			t.Errorf("Chunk %d is longer than the chunk size: %d", i+1, len(chunk.Content))
+		}
+	}
+}
+
+func TestFixedChunker_SentenceOverlapKeepsWholeSentences(t *testing.T) {
+	var sentences []string
+	for i := 1; i <= 20; i++ {
+		sentences = append(sentences, fmt.Sprintf("Sentence number %d ends here.", i))
+	}
+	doc := &Document{Id: "doc", Content: strings.Join(sentences, "\n")}
+
+	for _, overlap := range []int{0, 1, 2, 5} {
+		chunks, err := NewFixedChunker(100, overlap, false, WithOverlapMode(OverlapSentences)).Chunk(doc)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		seen := make(map[string]bool)
+		for i, chunk := range chunks {
+			chunkSentences := strings.Split(chunk.Content, "\n")
+			for _, sentence := range chunkSentences {
+				if !slices.Contains(sentences, sentence) {
+					t.Errorf("Overlap %d, chunk %d: %q is not a whole sentence", overlap, i+1, sentence)
+				}
+				seen[sentence] = true
+			}
+			if i == 0 {
+				continue
+			}
+			// the chunk starts with the last sentences of the previous one, fewer when they would
+			// leave no room for a new sentence
+			previous := strings.Split(chunks[i-1].Content, "\n")
+			carried := utils.MinInt(overlap, len(previous)-1)
+			if overlap > 0 && chunkSentences[0] != previous[len(previous)-carried] {
+				t.Errorf("Overlap %d, chunk %d starts with %q", overlap, i+1, chunkSentences[0])
+			}
+			if overlap == 0 && chunkSentences[0] == previous[len(previous)-1] {
+				t.Errorf("Overlap 0, chunk %d repeats %q", i+1, chunkSentences[0])
+			}
+		}
+		if len(seen) != len(sentences) {
+			t.Errorf("Overlap %d: %d sentences of %d chunked", overlap, len(seen), len(sentences))
+		}
+	}
+}
+
+func TestFixedChunker_SentenceOverlapLongSentence(t *testing.T) {
+	long := strings.Repeat("word ", 30) + "end."
+	doc := &Document{Id: "doc", Content: "Short one. " + long + " Another short one."}
+
+	chunks, err := NewFixedChunker(50, 1, false, WithOverlapMode(OverlapSentences)).Chunk(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var content []string
+	for i, chunk := range chunks {
+		if len(chunk.Content) > 50 {
+			t.Errorf("Chunk %d is longer than the chunk size: %q", i+1, chunk.Content)
+		}
+		content = append(content, chunk.Content)
+	}
+	if !strings.HasPrefix(content[0], "Short one.") || !strings.HasSuffix(content[len(content)-1], "Another short one.") {
+		t.Errorf("Unexpected chunks: %q", content)
+	}
+}
+
+func TestFixedChunker_InvalidOverlapMode(t *testing.T) {
+	chunker := NewFixedChunker(10, 2, false, WithOverlapMode("words"))
+	_, err := chunker.Chunk(&Document{Id: "doc", Content: strings.Repeat("word ", 10)})
+	if err == nil {
+		t.Fatal("Expected an error for an unknown overlap mode")
+	}
+
+	// the sentence overlap is not bounded by the chunk size in characters
+	chunker = NewFixedChunker(30, 50, false, WithOverlapMode(OverlapSentences))
+	if _, err := chunker.Chunk(&Document{Id: "doc", Content: "One sentence. Two sentences. Three sentences."}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
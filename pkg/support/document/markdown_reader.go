@@ -0,0 +1,207 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MetadataKeyHeading is the heading of the markdown section of a document
+	MetadataKeyHeading = "heading"
+	// MetadataKeyHeadingPath is the headings from the top-level section down to the section of a document
+	MetadataKeyHeadingPath = "heading_path"
+	// MetadataKeyHeadingLevel is the level, 1 to 6, of the heading of the section of a document
+	MetadataKeyHeadingLevel = "heading_level"
+)
+
+var (
+	atxHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	codeFencePattern  = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+)
+
+var _ Reader = (*markdownReader)(nil)
+
+// NewMarkdownReader creates a reader parsing Markdown into a document per section, each
+// heading starting a section. The section heading and the path of headings leading to it
+// are recorded in the MetadataKeyHeading and MetadataKeyHeadingPath metadata, which the
+// chunks of the sections keep when a chunker is set with WithChunker.
+// Headings inside code fences are ignored and code fences are never split by the chunker.
+func NewMarkdownReader() Reader {
+	return &markdownReader{}
+}
+
+type markdownReader struct{}
+
+// markdownBlock is a part of a section, either text or a whole code fence
+type markdownBlock struct {
+	content string
+	fence   bool
+}
+
+type markdownSection struct {
+	heading string
+	path    []string
+	level   int
+	blocks  []markdownBlock
+}
+
+func (s *markdownSection) content() string {
+	var builder strings.Builder
+	for _, block := range s.blocks {
+		builder.WriteString(block.content)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func (s *markdownSection) metadata() map[string]any {
+	return map[string]any{
+		MetadataKeyHeading:      s.heading,
+		MetadataKeyHeadingPath:  s.path,
+		MetadataKeyHeadingLevel: s.level,
+	}
+}
+
+func (s *markdownSection) hasFence() bool {
+	for _, block := range s.blocks {
+		if block.fence {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *markdownSection) appendLine(line string, fence bool) {
+	if n := len(s.blocks); n > 0 && s.blocks[n-1].fence == fence && !fence {
+		s.blocks[n-1].content += line
+		return
+	}
+	s.blocks = append(s.blocks, markdownBlock{content: line, fence: fence})
+}
+
+func (r *markdownReader) Read(documentName string, reader io.Reader, options ...ReaderOption) ([]*Document, error) {
+	// Apply options
+	opts := &ReaderOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*Document
+	sectionNumber := 1
+	for _, section := range parseMarkdownSections(string(content)) {
+		sectionContent := section.content()
+		if sectionContent == "" {
+			continue
+		}
+		doc := &Document{
+			Id:       DocumentId(fmt.Sprintf("section_%d", sectionNumber)),
+			Name:     documentName,
+			Metadata: section.metadata(),
+			Content:  sectionContent,
+		}
+		sectionNumber++
+		if opts.chunker == nil {
+			docs = append(docs, doc)
+			continue
+		}
+		chunks, err := r.chunkSection(doc, section, opts.chunker)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, chunks...)
+	}
+	return docs, nil
+}
+
+// chunkSection chunks the section, the code fences of the sections too long are kept whole
+func (r *markdownReader) chunkSection(doc *Document, section *markdownSection, chunker Chunker) ([]*Document, error) {
+	chunks, err := chunker.Chunk(doc)
+	if err != nil || len(chunks) <= 1 || !section.hasFence() {
+		return chunks, err
+	}
+
+	var pieces []string
+	for _, block := range section.blocks {
+		blockContent := strings.TrimSpace(block.content)
+		if blockContent == "" {
+			continue
+		}
+		if block.fence {
+			pieces = append(pieces, blockContent)
+			continue
+		}
+		blockChunks, err := chunker.Chunk(&Document{Id: doc.Id, Name: doc.Name, Content: blockContent})
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range blockChunks {
+			if chunk.Content != "" {
+				pieces = append(pieces, chunk.Content)
+			}
+		}
+	}
+
+	chunks = make([]*Document, 0, len(pieces))
+	for idx, piece := range pieces {
+		chunks = append(chunks, newChunk(doc, idx+1, piece))
+	}
+	return chunks, nil
+}
+
+// parseMarkdownSections splits markdown into sections at ATX headings (# to ######) outside of code fences
+func parseMarkdownSections(content string) []*markdownSection {
+	current := &markdownSection{path: []string{}}
+	sections := []*markdownSection{current}
+	var path []string
+	var levels []int
+
+	fence := ""
+	for line := range strings.Lines(content) {
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if fence != "" {
+			current.blocks[len(current.blocks)-1].content += line
+			if closing := codeFencePattern.FindStringSubmatch(trimmed); closing != nil &&
+				closing[1][0] == fence[0] && len(closing[1]) >= len(fence) &&
+				strings.TrimSpace(trimmed[len(closing[0]):]) == "" {
+				fence = ""
+			}
+			continue
+		}
+
+		if opening := codeFencePattern.FindStringSubmatch(trimmed); opening != nil {
+			fence = opening[1]
+			current.appendLine(line, true)
+			continue
+		}
+
+		heading := atxHeadingPattern.FindStringSubmatch(trimmed)
+		if heading == nil {
+			current.appendLine(line, false)
+			continue
+		}
+
+		level, title := len(heading[1]), strings.TrimSpace(heading[2])
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+			path = path[:len(path)-1]
+		}
+		levels = append(levels, level)
+		path = append(path, title)
+
+		current = &markdownSection{
+			heading: title,
+			path:    append([]string(nil), path...),
+			level:   level,
+		}
+		current.appendLine(line, false)
+		sections = append(sections, current)
+	}
+	return sections
+}
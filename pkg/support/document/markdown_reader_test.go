@@ -0,0 +1,114 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMarkdown = `Preamble before any heading.
+
+# Guide
+
+Welcome to the guide.
+
+## Install
+
+Run the installer.
+
+` + "```bash" + `
+# not a heading, a shell comment
+make install
+` + "```" + `
+
+## Usage ##
+
+### Advanced usage
+
+Tune the options.
+
+# Reference
+
+The API reference.
+`
+
+func TestMarkdownReader_Sections(t *testing.T) {
+	docs, err := NewMarkdownReader().Read("guide.md", strings.NewReader(testMarkdown))
+	require.NoError(t, err)
+
+	var headings []string
+	var paths [][]string
+	for _, doc := range docs {
+		assert.Equal(t, "guide.md", doc.Name)
+		headings = append(headings, doc.Metadata[MetadataKeyHeading].(string))
+		paths = append(paths, doc.Metadata[MetadataKeyHeadingPath].([]string))
+	}
+	assert.Equal(t, []string{"", "Guide", "Install", "Usage", "Advanced usage", "Reference"}, headings)
+	assert.Equal(t, [][]string{
+		{},
+		{"Guide"},
+		{"Guide", "Install"},
+		{"Guide", "Usage"},
+		{"Guide", "Usage", "Advanced usage"},
+		{"Reference"},
+	}, paths)
+	assert.Equal(t, 3, docs[4].Metadata[MetadataKeyHeadingLevel])
+
+	// the code fence stays in its section, its comment is not a heading
+	assert.Equal(t, "## Install\n\nRun the installer.\n\n```bash\n# not a heading, a shell comment\nmake install\n```",
+		docs[2].Content)
+	assert.Equal(t, "Preamble before any heading.", docs[0].Content)
+	assert.Equal(t, DocumentId("section_1"), docs[0].Id)
+	assert.Equal(t, DocumentId("section_6"), docs[5].Id)
+}
+
+func TestMarkdownReader_WithChunker(t *testing.T) {
+	longSection := "# Long\n\n" + strings.Repeat("Some words in a long paragraph. ", 10) + "\n\n## Short\n\nShort section."
+
+	docs, err := NewMarkdownReader().Read("long.md", strings.NewReader(longSection),
+		WithChunker(NewRecursiveChunker(100, nil)))
+	require.NoError(t, err)
+	require.Greater(t, len(docs), 2)
+
+	// the chunks of the long section keep its heading metadata
+	for _, doc := range docs[:len(docs)-1] {
+		assert.Equal(t, "Long", doc.Metadata[MetadataKeyHeading])
+		assert.Equal(t, []string{"Long"}, doc.Metadata[MetadataKeyHeadingPath])
+		assert.LessOrEqual(t, len(doc.Content), 100)
+		assert.NotNil(t, doc.Metadata["chunk"])
+	}
+	last := docs[len(docs)-1]
+	assert.Equal(t, "## Short\n\nShort section.", last.Content)
+	assert.Equal(t, []string{"Long", "Short"}, last.Metadata[MetadataKeyHeadingPath])
+}
+
+func TestMarkdownReader_CodeFencesAreNotSplit(t *testing.T) {
+	code := "```go\nfunc main() {\n\tfmt.Println(\"hello\")\n\tfmt.Println(\"world\")\n}\n```"
+	markdown := "# Example\n\nThe program below prints two lines.\n\n" + code + "\n\nThat is all."
+
+	docs, err := NewMarkdownReader().Read("example.md", strings.NewReader(markdown),
+		WithChunker(NewFixedChunker(30, 0, false)))
+	require.NoError(t, err)
+
+	var contents []string
+	for i, doc := range docs {
+		contents = append(contents, doc.Content)
+		assert.Equal(t, "Example", doc.Metadata[MetadataKeyHeading])
+		assert.Equal(t, i+1, doc.Metadata["chunk"])
+	}
+	assert.Contains(t, contents, code)
+	assert.Equal(t, "That is all.", contents[len(contents)-1])
+
+	// unterminated fences run to the end of the document
+	docs, err = NewMarkdownReader().Read("open.md", strings.NewReader("# A\n```\n# B\n"))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "# A\n```\n# B", docs[0].Content)
+}
+
+func TestMarkdownReader_ReadError(t *testing.T) {
+	_, err := NewMarkdownReader().Read("error.md", &errorReader{})
+	assert.Error(t, err)
+}
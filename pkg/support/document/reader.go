@@ -1,16 +1,22 @@
 package document
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 type ReaderOption func(*ReaderOptions)
 
 type ReaderOptions struct {
 	chunker Chunker // Optional chunker to split the document into smaller parts
+
+	fileReaders map[string]Reader // Readers of ReadDir by file extension, see WithFileReader
+	include     []string          // Glob patterns of the files ReadDir reads, all when empty
+	exclude     []string          // Glob patterns of the files and directories ReadDir skips
 }
 
 func WithChunker(chunker Chunker) ReaderOption {
@@ -70,11 +76,90 @@ func OfString(content string) (io.Reader, error) {
 	return io.NopCloser(strings.NewReader(content)), nil
 }
 
+type UrlOption func(*UrlOptions)
+
+type UrlOptions struct {
+	client *http.Client // HTTP client, http.DefaultClient by default
+	cache  UrlCache     // Optional cache of the URL contents
+}
+
+// WithHttpClient sets the HTTP client reading the URL
+func WithHttpClient(client *http.Client) UrlOption {
+	return func(opts *UrlOptions) {
+		opts.client = client
+	}
+}
+
+// WithUrlCache caches the content read from the URL. Once cached, the URL is requested
+// conditionally with its ETag and Last-Modified validators, and the cached content is
+// reused when the server answers 304 Not Modified.
+func WithUrlCache(cache UrlCache) UrlOption {
+	return func(opts *UrlOptions) {
+		opts.cache = cache
+	}
+}
+
 // OfUrl creates a io.Reader that reads from a URL.
-func OfUrl(url string) (io.Reader, error) {
-	resp, err := http.Get(url)
+func OfUrl(url string, options ...UrlOption) (io.Reader, error) {
+	opts := &UrlOptions{client: http.DefaultClient}
+	for _, option := range options {
+		option(opts)
+	}
+
+	if opts.cache == nil {
+		resp, err := opts.client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	return ofCachedUrl(url, opts)
+}
+
+func ofCachedUrl(url string, opts *UrlOptions) (io.Reader, error) {
+	cached, ok, err := opts.cache.Get(url)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Body, nil
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return io.NopCloser(bytes.NewReader(cached.Content)), nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// not cached, the response is read as without cache
+		return resp.Body, nil
+	}
+
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.cache.Put(url, &CachedResource{
+		Content:      content,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
 }
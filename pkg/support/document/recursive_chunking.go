@@ -0,0 +1,125 @@
+package document
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+var _ Chunker = (*recursiveChunker)(nil)
+
+// DefaultSeparators are the separators of the recursive chunker, from the coarsest to the finest structure
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// NewRecursiveChunker creates a chunker splitting documents on the first separator that
+// appears in the content, only descending to the next, finer separators for pieces still
+// longer than chunkSize characters, so paragraphs, then lines, then sentences stay intact
+// whenever they fit. Pieces without any separator left are split by characters.
+// DefaultSeparators are used when separators is empty.
+func NewRecursiveChunker(chunkSize int, separators []string) Chunker {
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	return &recursiveChunker{ChunkSize: chunkSize, Separators: separators}
+}
+
+type recursiveChunker struct {
+	ChunkSize  int      // Maximum size of each chunk in characters
+	Separators []string // Separators by priority
+}
+
+func (c *recursiveChunker) Chunk(doc *Document) ([]*Document, error) {
+	if c.ChunkSize <= 0 {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "chunk size must be greater than 0")
+	}
+
+	// If content is smaller than chunk size, return as single chunk
+	if len(doc.Content) <= c.ChunkSize {
+		return []*Document{{
+			Id:       doc.Id,
+			Name:     doc.Name,
+			Metadata: doc.Metadata,
+			Content:  doc.Content,
+		}}, nil
+	}
+
+	var chunks []*Document
+	chunkNumber := 1
+	for _, piece := range c.split(doc.Content, c.Separators) {
+		chunk := strings.TrimSpace(piece)
+		if len(chunk) == 0 {
+			continue
+		}
+		chunks = append(chunks, newChunk(doc, chunkNumber, chunk))
+		chunkNumber++
+	}
+	return chunks, nil
+}
+
+// split splits the text into pieces of at most ChunkSize characters, using the first
+// separator found in the text and the remaining separators for pieces too long
+func (c *recursiveChunker) split(text string, separators []string) []string {
+	separator, finer := "", []string(nil)
+	for idx, sep := range separators {
+		if sep != "" && strings.Contains(text, sep) {
+			separator, finer = sep, separators[idx+1:]
+			break
+		}
+	}
+	if separator == "" {
+		return c.splitCharacters(text)
+	}
+
+	var pieces []string
+	var fitting []string
+	// keep the separators at the end of the parts, so joining the parts gives back the text
+	for _, part := range strings.SplitAfter(text, separator) {
+		if len(part) <= c.ChunkSize {
+			fitting = append(fitting, part)
+			continue
+		}
+		pieces = append(pieces, c.merge(fitting)...)
+		fitting = nil
+		pieces = append(pieces, c.split(part, finer)...)
+	}
+	return append(pieces, c.merge(fitting)...)
+}
+
+// merge joins consecutive parts into pieces of at most ChunkSize characters
+func (c *recursiveChunker) merge(parts []string) []string {
+	var pieces []string
+	var current strings.Builder
+	for _, part := range parts {
+		if current.Len() > 0 && current.Len()+len(part) > c.ChunkSize {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		current.WriteString(part)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// splitCharacters splits the text into pieces of ChunkSize characters, never splitting a UTF-8 character
+func (c *recursiveChunker) splitCharacters(text string) []string {
+	var pieces []string
+	for len(text) > c.ChunkSize {
+		end := c.ChunkSize
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end == 0 {
+			// a single character longer than the chunk size
+			_, end = utf8.DecodeRuneInString(text)
+		}
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	if len(text) > 0 {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
@@ -0,0 +1,138 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkContents(chunks []*Document) []string {
+	var contents []string
+	for _, chunk := range chunks {
+		contents = append(contents, chunk.Content)
+	}
+	return contents
+}
+
+func TestNewRecursiveChunker(t *testing.T) {
+	rc, ok := NewRecursiveChunker(100, nil).(*recursiveChunker)
+	require.True(t, ok, "NewRecursiveChunker() should return a *recursiveChunker")
+	assert.Equal(t, 100, rc.ChunkSize)
+	assert.Equal(t, DefaultSeparators, rc.Separators)
+
+	rc = NewRecursiveChunker(50, []string{"\n"}).(*recursiveChunker)
+	assert.Equal(t, []string{"\n"}, rc.Separators)
+}
+
+func TestRecursiveChunker_InvalidChunkSize(t *testing.T) {
+	doc := &Document{Id: "test", Content: "test content"}
+
+	chunks, err := NewRecursiveChunker(0, nil).Chunk(doc)
+	assert.Error(t, err)
+	assert.Nil(t, chunks)
+
+	_, err = NewRecursiveChunker(-1, nil).Chunk(doc)
+	assert.Error(t, err)
+}
+
+func TestRecursiveChunker_EmptyAndSmallContent(t *testing.T) {
+	chunks, err := NewRecursiveChunker(10, nil).Chunk(&Document{Id: "test"})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "", chunks[0].Content)
+
+	doc := &Document{Id: "test", Content: "short\n\ntext"}
+	chunks, err = NewRecursiveChunker(100, nil).Chunk(doc)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, doc.Content, chunks[0].Content)
+}
+
+func TestRecursiveChunker_ParagraphsStayIntact(t *testing.T) {
+	paragraphs := []string{
+		"# Title",
+		"The first paragraph explains the topic in two sentences. It fits in a chunk.",
+		"The second paragraph is short.",
+		"The third paragraph also fits in a single chunk, so it is kept whole.",
+	}
+	doc := &Document{Id: "test", Content: strings.Join(paragraphs, "\n\n")}
+
+	chunks, err := NewRecursiveChunker(110, nil).Chunk(doc)
+	require.NoError(t, err)
+
+	// small paragraphs are merged, no paragraph is split
+	assert.Equal(t, []string{
+		"# Title\n\n" + paragraphs[1],
+		paragraphs[2] + "\n\n" + paragraphs[3],
+	}, chunkContents(chunks))
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Content), 110)
+	}
+}
+
+func TestRecursiveChunker_DescendsToFinerSeparators(t *testing.T) {
+	long := "This sentence is the first one. This sentence is the second one. And a third sentence."
+	doc := &Document{Id: "test", Content: "Intro paragraph.\n\n" + long + "\n\nOutro paragraph."}
+
+	chunks, err := NewRecursiveChunker(40, nil).Chunk(doc)
+	require.NoError(t, err)
+
+	// only the paragraph too long is split, on sentences
+	assert.Equal(t, []string{
+		"Intro paragraph.",
+		"This sentence is the first one.",
+		"This sentence is the second one.",
+		"And a third sentence.",
+		"Outro paragraph.",
+	}, chunkContents(chunks))
+}
+
+func TestRecursiveChunker_CharacterFallback(t *testing.T) {
+	doc := &Document{Id: "test", Content: "averyveryverylongwordwithoutanyseparator 世界世界"}
+
+	chunks, err := NewRecursiveChunker(10, nil).Chunk(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"averyveryv", "erylongwor", "dwithoutan", "yseparator", "世界世", "界"},
+		chunkContents(chunks))
+}
+
+func TestRecursiveChunker_CustomSeparators(t *testing.T) {
+	doc := &Document{Id: "test", Content: "a,b,c;d,e,f;g,h,i"}
+
+	chunks, err := NewRecursiveChunker(6, []string{";", ","}).Chunk(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a,b,c;", "d,e,f;", "g,h,i"}, chunkContents(chunks))
+}
+
+func TestRecursiveChunker_MetadataPreservation(t *testing.T) {
+	doc := &Document{
+		Id:       "doc",
+		Name:     "doc.md",
+		Metadata: map[string]any{"source": "test_source"},
+		Content:  "First paragraph.\n\nSecond paragraph.\n\nThird paragraph.",
+	}
+
+	chunks, err := NewRecursiveChunker(20, nil).Chunk(doc)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for i, chunk := range chunks {
+		assert.Equal(t, DocumentId(fmt.Sprintf("doc_%d", i+1)), chunk.Id)
+		assert.Equal(t, "doc.md", chunk.Name)
+		assert.Equal(t, "test_source", chunk.Metadata["source"])
+		assert.Equal(t, i+1, chunk.Metadata["chunk"])
+		assert.Equal(t, len(chunk.Content), chunk.Metadata["chunk_size"])
+	}
+	// the original metadata is not modified
+	assert.Equal(t, map[string]any{"source": "test_source"}, doc.Metadata)
+}
+
+func TestRecursiveChunker_WithReader(t *testing.T) {
+	content := "First paragraph.\n\nSecond paragraph."
+	docs, err := NewDefaultReader().Read("doc.md", strings.NewReader(content),
+		WithChunker(NewRecursiveChunker(20, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First paragraph.", "Second paragraph."}, chunkContents(docs))
+}
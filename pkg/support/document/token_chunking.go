@@ -0,0 +1,200 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+var _ Chunker = (*tokenChunker)(nil)
+
+const (
+	// EncodingCl100kBase is the tiktoken encoding of gpt-4, gpt-3.5 and the openai embedding models
+	EncodingCl100kBase = tiktoken.MODEL_CL100K_BASE
+	// EncodingO200kBase is the tiktoken encoding of gpt-4o and gpt-4.1
+	EncodingO200kBase = tiktoken.MODEL_O200K_BASE
+)
+
+var (
+	// the bpe ranks are embedded, so encodings never need to be downloaded
+	bpeLoaderOnce sync.Once
+
+	// loading an encoding is costly, encodings are shared by the chunkers
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktoken.Tiktoken{}
+
+	wordPattern = regexp.MustCompile(`\s*\S+\s*`)
+)
+
+// NewTokenChunker creates a chunker splitting documents into chunks of at most maxTokens tokens,
+// consecutive chunks sharing overlapTokens tokens. Tokens are counted with the tiktoken encoding
+// of the given name (e.g. EncodingCl100kBase), or as whitespace separated words when the encoding is unknown.
+func NewTokenChunker(maxTokens, overlapTokens int, encoding string) Chunker {
+	if overlapTokens < 0 {
+		overlapTokens = 0 // Ensure overlap is non-negative
+	}
+	return &tokenChunker{
+		MaxTokens:     maxTokens,
+		OverlapTokens: overlapTokens,
+		tokenizer:     newTokenizer(encoding),
+	}
+}
+
+type tokenChunker struct {
+	MaxTokens     int // Maximum number of tokens of each chunk
+	OverlapTokens int // Number of tokens to overlap between chunks
+	tokenizer     tokenizer
+}
+
+// tokenSpan is a piece of text made of whole tokens, forming valid UTF-8
+type tokenSpan struct {
+	text   string
+	tokens int
+}
+
+type tokenizer interface {
+	// split splits the text into spans, the concatenation of the spans is the text
+	split(text string) []tokenSpan
+	// count returns the number of tokens of the text
+	count(text string) int
+}
+
+func newTokenizer(encoding string) tokenizer {
+	bpeLoaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+	})
+
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	enc, ok := encodings[encoding]
+	if !ok {
+		var err error
+		if enc, err = tiktoken.GetEncoding(encoding); err != nil {
+			return &whitespaceTokenizer{}
+		}
+		encodings[encoding] = enc
+	}
+	return &tiktokenTokenizer{encoding: enc}
+}
+
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+func (t *tiktokenTokenizer) split(text string) []tokenSpan {
+	var spans []tokenSpan
+	var pending strings.Builder
+	pendingTokens := 0
+	for _, token := range t.encoding.EncodeOrdinary(text) {
+		pending.WriteString(t.encoding.Decode([]int{token}))
+		pendingTokens++
+		// a character may be encoded by several tokens, keep them in the same span
+		if !utf8.ValidString(pending.String()) {
+			continue
+		}
+		spans = append(spans, tokenSpan{text: pending.String(), tokens: pendingTokens})
+		pending.Reset()
+		pendingTokens = 0
+	}
+	if pendingTokens > 0 {
+		spans = append(spans, tokenSpan{text: pending.String(), tokens: pendingTokens})
+	}
+	return spans
+}
+
+func (t *tiktokenTokenizer) count(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// whitespaceTokenizer counts whitespace separated words as tokens
+type whitespaceTokenizer struct{}
+
+func (w *whitespaceTokenizer) split(text string) []tokenSpan {
+	var spans []tokenSpan
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		spans = append(spans, tokenSpan{text: word, tokens: 1})
+	}
+	return spans
+}
+
+func (w *whitespaceTokenizer) count(text string) int {
+	return len(strings.Fields(text))
+}
+
+func (c *tokenChunker) Chunk(doc *Document) ([]*Document, error) {
+	if c.MaxTokens <= 0 {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "max tokens must be greater than 0")
+	}
+
+	if c.OverlapTokens < 0 {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed, "overlap tokens must be non-negative")
+	}
+
+	if c.OverlapTokens >= c.MaxTokens {
+		return nil, errors.Errorf(ErrorCodeChunkingFailed,
+			"overlap tokens (%d) must be less than max tokens (%d)", c.OverlapTokens, c.MaxTokens)
+	}
+
+	spans := c.tokenizer.split(doc.Content)
+
+	// If content fits in a chunk, return it as single chunk
+	totalTokens := 0
+	for _, span := range spans {
+		totalTokens += span.tokens
+	}
+	if totalTokens <= c.MaxTokens {
+		return []*Document{{
+			Id:       doc.Id,
+			Name:     doc.Name,
+			Metadata: doc.Metadata,
+			Content:  doc.Content,
+		}}, nil
+	}
+
+	var chunks []*Document
+	start := 0
+	chunkNumber := 1
+
+	for start < len(spans) {
+		// Take as many spans as fit in the token budget, at least one to make progress
+		end := start
+		tokens := 0
+		for end < len(spans) && (end == start || tokens+spans[end].tokens <= c.MaxTokens) {
+			tokens += spans[end].tokens
+			end++
+		}
+
+		var builder strings.Builder
+		for _, span := range spans[start:end] {
+			builder.WriteString(span.text)
+		}
+		chunk := strings.TrimSpace(builder.String())
+
+		if len(chunk) > 0 {
+			chunkDoc := newChunk(doc, chunkNumber, chunk)
+			chunkDoc.Metadata["token_count"] = c.tokenizer.count(chunk)
+			chunks = append(chunks, chunkDoc)
+			chunkNumber++
+		}
+
+		if end >= len(spans) {
+			break
+		}
+
+		// Move to next chunk position with overlap
+		newStart := end
+		overlap := 0
+		for newStart > start+1 && overlap+spans[newStart-1].tokens <= c.OverlapTokens {
+			overlap += spans[newStart-1].tokens
+			newStart--
+		}
+		start = newStart
+	}
+
+	return chunks, nil
+}
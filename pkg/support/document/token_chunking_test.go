@@ -0,0 +1,177 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tokenChunkerTestContent = "Large language models read text as tokens rather than characters. " +
+	"Chunking documents by token count keeps every chunk within the context budget of the model, " +
+	"while chunking by characters either wastes the budget or overshoots it."
+
+func TestNewTokenChunker(t *testing.T) {
+	chunker := NewTokenChunker(100, 20, EncodingCl100kBase)
+
+	tc, ok := chunker.(*tokenChunker)
+	require.True(t, ok, "NewTokenChunker() should return a *tokenChunker")
+	assert.Equal(t, 100, tc.MaxTokens)
+	assert.Equal(t, 20, tc.OverlapTokens)
+	assert.IsType(t, &tiktokenTokenizer{}, tc.tokenizer)
+
+	// negative overlap is ignored
+	tc = NewTokenChunker(100, -1, EncodingCl100kBase).(*tokenChunker)
+	assert.Equal(t, 0, tc.OverlapTokens)
+
+	// unknown encodings fall back to whitespace splitting
+	tc = NewTokenChunker(100, 0, "unknown_encoding").(*tokenChunker)
+	assert.IsType(t, &whitespaceTokenizer{}, tc.tokenizer)
+}
+
+func TestTokenChunker_InvalidMaxTokens(t *testing.T) {
+	doc := &Document{Id: "test", Name: "test.txt", Content: "test content"}
+
+	chunks, err := NewTokenChunker(0, 0, EncodingCl100kBase).Chunk(doc)
+	assert.Error(t, err)
+	assert.Nil(t, chunks)
+
+	_, err = NewTokenChunker(-1, 0, EncodingCl100kBase).Chunk(doc)
+	assert.Error(t, err)
+}
+
+func TestTokenChunker_InvalidOverlap(t *testing.T) {
+	doc := &Document{Id: "test", Name: "test.txt", Content: "test content"}
+
+	chunker := &tokenChunker{MaxTokens: 10, OverlapTokens: -1, tokenizer: &whitespaceTokenizer{}}
+	chunks, err := chunker.Chunk(doc)
+	assert.Error(t, err)
+	assert.Nil(t, chunks)
+
+	// overlap >= max tokens
+	_, err = NewTokenChunker(10, 10, EncodingCl100kBase).Chunk(doc)
+	assert.Error(t, err)
+	_, err = NewTokenChunker(10, 11, EncodingCl100kBase).Chunk(doc)
+	assert.Error(t, err)
+}
+
+func TestTokenChunker_EmptyContent(t *testing.T) {
+	chunks, err := NewTokenChunker(10, 0, EncodingCl100kBase).Chunk(&Document{Id: "test", Name: "test.txt"})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "", chunks[0].Content)
+}
+
+func TestTokenChunker_ContentSmallerThanMaxTokens(t *testing.T) {
+	doc := &Document{Id: "test", Name: "test.txt", Content: "This is a short text."}
+
+	chunks, err := NewTokenChunker(100, 0, EncodingCl100kBase).Chunk(doc)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, doc.Content, chunks[0].Content)
+	assert.Equal(t, doc.Id, chunks[0].Id)
+}
+
+func TestTokenChunker_BasicChunking(t *testing.T) {
+	doc := &Document{
+		Id:       "test",
+		Name:     "test.txt",
+		Metadata: map[string]any{"source": "test_source"},
+		Content:  tokenChunkerTestContent,
+	}
+	tokenizer := newTokenizer(EncodingCl100kBase)
+
+	chunks, err := NewTokenChunker(10, 0, EncodingCl100kBase).Chunk(doc)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 1)
+
+	var contents []string
+	for i, chunk := range chunks {
+		assert.Equal(t, DocumentId(fmt.Sprintf("test_%d", i+1)), chunk.Id)
+		assert.Equal(t, doc.Name, chunk.Name)
+		assert.Equal(t, "test_source", chunk.Metadata["source"])
+		assert.Equal(t, i+1, chunk.Metadata["chunk"])
+		assert.Equal(t, len(chunk.Content), chunk.Metadata["chunk_size"])
+
+		tokenCount := chunk.Metadata["token_count"].(int)
+		assert.Equal(t, tokenizer.count(chunk.Content), tokenCount)
+		assert.LessOrEqual(t, tokenCount, 10)
+		assert.Greater(t, tokenCount, 0)
+		contents = append(contents, chunk.Content)
+	}
+
+	// without overlap the chunks cover the content exactly once, split at token boundaries
+	assert.Equal(t, strings.Join(strings.Fields(doc.Content), ""),
+		strings.Join(strings.Fields(strings.Join(contents, "")), ""))
+}
+
+func TestTokenChunker_WithOverlap(t *testing.T) {
+	doc := &Document{Id: "test", Content: tokenChunkerTestContent}
+
+	withoutOverlap, err := NewTokenChunker(12, 0, EncodingCl100kBase).Chunk(doc)
+	require.NoError(t, err)
+	chunks, err := NewTokenChunker(12, 4, EncodingCl100kBase).Chunk(doc)
+	require.NoError(t, err)
+	assert.Greater(t, len(chunks), len(withoutOverlap))
+
+	for i := 1; i < len(chunks); i++ {
+		prev := chunks[i-1].Content
+		words := strings.Fields(chunks[i].Content)
+		// the chunk starts with the end of the previous chunk
+		assert.True(t, strings.HasSuffix(prev, words[0]) || strings.Contains(prev, words[0]+" "),
+			"chunks %d and %d should overlap", i-1, i)
+		assert.LessOrEqual(t, chunks[i].Metadata["token_count"].(int), 12)
+	}
+}
+
+func TestTokenChunker_WhitespaceFallback(t *testing.T) {
+	doc := &Document{Id: "test", Content: "one two  three\nfour five six seven"}
+
+	chunks, err := NewTokenChunker(3, 1, "unknown_encoding").Chunk(doc)
+	require.NoError(t, err)
+
+	var contents []string
+	for _, chunk := range chunks {
+		contents = append(contents, chunk.Content)
+		assert.Equal(t, len(strings.Fields(chunk.Content)), chunk.Metadata["token_count"])
+	}
+	assert.Equal(t, []string{"one two  three", "three\nfour five", "five six seven"}, contents)
+}
+
+func TestTokenChunker_MultiByteCharacters(t *testing.T) {
+	doc := &Document{Id: "test", Content: strings.Repeat("大语言模型按词元读取文本。🙂🚀 ", 10)}
+
+	chunks, err := NewTokenChunker(7, 2, EncodingCl100kBase).Chunk(doc)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 1)
+	for i, chunk := range chunks {
+		assert.True(t, utf8.ValidString(chunk.Content), "chunk %d is not valid UTF-8", i)
+	}
+}
+
+func TestTokenChunker_ChunkIdGeneration(t *testing.T) {
+	chunker := NewTokenChunker(5, 0, EncodingCl100kBase)
+
+	tests := []struct {
+		name           string
+		doc            *Document
+		expectedPrefix string
+	}{
+		{"with document ID", &Document{Id: "doc123", Name: "test.txt", Content: tokenChunkerTestContent}, "doc123_"},
+		{"with document name only", &Document{Name: "test.txt", Content: tokenChunkerTestContent}, "test.txt_"},
+		{"without ID or name", &Document{Content: tokenChunkerTestContent}, "chunk_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := chunker.Chunk(tt.doc)
+			require.NoError(t, err)
+			for i, chunk := range chunks {
+				assert.Equal(t, DocumentId(fmt.Sprintf("%s%d", tt.expectedPrefix, i+1)), chunk.Id)
+			}
+		})
+	}
+}
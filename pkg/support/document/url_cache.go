@@ -0,0 +1,115 @@
+package document
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+// CachedResource is the content of a URL along with the validators used for conditional requests
+type CachedResource struct {
+	Content      []byte    `json:"content"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// UrlCache stores the resources read from URLs, keyed by URL
+type UrlCache interface {
+	// Get returns the cached resource of the URL, false when the URL is not cached
+	Get(url string) (*CachedResource, bool, error)
+	// Put stores the resource of the URL, replacing any previous one
+	Put(url string, resource *CachedResource) error
+}
+
+var _ UrlCache = (*inMemoryUrlCache)(nil)
+
+// NewInMemoryUrlCache creates a URL cache keeping the resources in memory
+func NewInMemoryUrlCache() UrlCache {
+	return &inMemoryUrlCache{resources: make(map[string]*CachedResource)}
+}
+
+type inMemoryUrlCache struct {
+	mu        sync.RWMutex
+	resources map[string]*CachedResource
+}
+
+func (c *inMemoryUrlCache) Get(url string) (*CachedResource, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resource, ok := c.resources[url]
+	return resource, ok, nil
+}
+
+func (c *inMemoryUrlCache) Put(url string, resource *CachedResource) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resources[url] = resource
+	return nil
+}
+
+var _ UrlCache = (*fileUrlCache)(nil)
+
+// NewFileUrlCache creates a URL cache storing each resource as a JSON file in the directory,
+// so the cache survives between runs
+func NewFileUrlCache(dir string) (UrlCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	return &fileUrlCache{dir: dir}, nil
+}
+
+type fileUrlCache struct {
+	dir string
+}
+
+func (c *fileUrlCache) Get(url string) (*CachedResource, bool, error) {
+	data, err := os.ReadFile(c.path(url))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	var resource CachedResource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		// a corrupted entry is refetched
+		return nil, false, nil
+	}
+	return &resource, true, nil
+}
+
+func (c *fileUrlCache) Put(url string, resource *CachedResource) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	// write then rename, so readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(url)); err != nil {
+		return errors.Wrap(ErrorCodeUrlCacheFailed, err)
+	}
+	return nil
+}
+
+func (c *fileUrlCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,141 @@
+package document
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedServer serves content with an ETag, answering 304 to matching conditional requests
+type versionedServer struct {
+	*httptest.Server
+	content     atomic.Value
+	fullReads   atomic.Int32
+	conditional atomic.Int32
+}
+
+func newVersionedServer(content string) *versionedServer {
+	s := &versionedServer{}
+	s.content.Store(content)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := s.content.Load().(string)
+		etag := `"` + content + `"`
+		if match := r.Header.Get("If-None-Match"); match != "" {
+			s.conditional.Add(1)
+			if match == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		s.fullReads.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	return s
+}
+
+func readUrl(t *testing.T, url string, options ...UrlOption) string {
+	reader, err := OfUrl(url, options...)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+	return string(data)
+}
+
+func TestOfUrl_WithUrlCache(t *testing.T) {
+	caches := map[string]func(t *testing.T) UrlCache{
+		"in memory": func(t *testing.T) UrlCache { return NewInMemoryUrlCache() },
+		"file": func(t *testing.T) UrlCache {
+			cache, err := NewFileUrlCache(t.TempDir())
+			require.NoError(t, err)
+			return cache
+		},
+	}
+
+	for name, newCache := range caches {
+		t.Run(name, func(t *testing.T) {
+			server := newVersionedServer("version 1")
+			defer server.Close()
+			cache := newCache(t)
+
+			assert.Equal(t, "version 1", readUrl(t, server.URL, WithUrlCache(cache)))
+			assert.Equal(t, int32(1), server.fullReads.Load())
+
+			// unchanged: a conditional request, the cached content is reused
+			assert.Equal(t, "version 1", readUrl(t, server.URL, WithUrlCache(cache)))
+			assert.Equal(t, "version 1", readUrl(t, server.URL, WithUrlCache(cache)))
+			assert.Equal(t, int32(1), server.fullReads.Load())
+			assert.Equal(t, int32(2), server.conditional.Load())
+
+			// changed: downloaded again and cached
+			server.content.Store("version 2")
+			assert.Equal(t, "version 2", readUrl(t, server.URL, WithUrlCache(cache)))
+			assert.Equal(t, "version 2", readUrl(t, server.URL, WithUrlCache(cache)))
+			assert.Equal(t, int32(2), server.fullReads.Load())
+		})
+	}
+}
+
+func TestOfUrl_WithUrlCacheLastModified(t *testing.T) {
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	var fullReads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullReads.Add(1)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	cache := NewInMemoryUrlCache()
+	assert.Equal(t, "content", readUrl(t, server.URL, WithUrlCache(cache)))
+	assert.Equal(t, "content", readUrl(t, server.URL, WithUrlCache(cache)))
+	assert.Equal(t, int32(1), fullReads.Load())
+}
+
+func TestOfUrl_WithUrlCacheErrorsNotCached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Not Found"))
+	}))
+	defer server.Close()
+
+	cache := NewInMemoryUrlCache()
+	assert.Equal(t, "Not Found", readUrl(t, server.URL, WithUrlCache(cache)))
+	_, ok, err := cache.Get(server.URL)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFileUrlCache_Persistent(t *testing.T) {
+	dir := t.TempDir()
+	server := newVersionedServer("persisted")
+	defer server.Close()
+
+	cache, err := NewFileUrlCache(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "persisted", readUrl(t, server.URL, WithUrlCache(cache)))
+
+	// a new cache on the same directory, as in a later run
+	cache, err = NewFileUrlCache(dir)
+	require.NoError(t, err)
+	resource, ok, err := cache.Get(server.URL)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []byte("persisted"), resource.Content)
+	assert.Equal(t, `"persisted"`, resource.ETag)
+
+	assert.Equal(t, "persisted", readUrl(t, server.URL, WithUrlCache(cache)))
+	assert.Equal(t, int32(1), server.fullReads.Load())
+}
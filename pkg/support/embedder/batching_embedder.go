@@ -0,0 +1,131 @@
+package embedder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+const (
+	// DefaultBatchSize is the default number of texts embedded in one upstream call
+	DefaultBatchSize = 100
+	// DefaultBatchConcurrency is the default number of upstream calls running at the same time
+	DefaultBatchConcurrency = 4
+)
+
+var _ Embedder = &BatchingEmbedder{}
+
+// BatchingEmbedderOption configures the batching embedder
+type BatchingEmbedderOption func(*BatchingEmbedder)
+
+// WithBatchSize sets the number of texts embedded in one upstream call
+func WithBatchSize(batchSize int) BatchingEmbedderOption {
+	return func(b *BatchingEmbedder) {
+		b.batchSize = batchSize
+	}
+}
+
+// WithBatchConcurrency sets the number of upstream calls running at the same time
+func WithBatchConcurrency(concurrency int) BatchingEmbedderOption {
+	return func(b *BatchingEmbedder) {
+		b.concurrency = concurrency
+	}
+}
+
+// NewBatchingEmbedder creates an embedder splitting the texts into batches embedded by the
+// embedder, e.g. to stay under the per-request item cap of the embedding providers.
+func NewBatchingEmbedder(embedder Embedder, opts ...BatchingEmbedderOption) *BatchingEmbedder {
+	b := &BatchingEmbedder{
+		embedder:    embedder,
+		batchSize:   DefaultBatchSize,
+		concurrency: DefaultBatchConcurrency,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.batchSize <= 0 {
+		b.batchSize = DefaultBatchSize
+	}
+	if b.concurrency <= 0 {
+		b.concurrency = DefaultBatchConcurrency
+	}
+	return b
+}
+
+// BatchingEmbedder embeds the texts in batches with bounded concurrency
+type BatchingEmbedder struct {
+	embedder    Embedder
+	batchSize   int
+	concurrency int
+}
+
+// Embed embeds the texts batch by batch and returns their vectors in the order of the texts.
+// The first failing batch cancels the others, its error tells the index of its texts.
+func (b *BatchingEmbedder) Embed(ctx context.Context, texts []string) ([]FloatVector, error) {
+	if len(texts) <= b.batchSize {
+		return b.embedBatch(ctx, texts, 0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	vectors := make([]FloatVector, len(texts))
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var failure error
+	fail := func(err error) {
+		failOnce.Do(func() {
+			failure = err
+			cancel()
+		})
+	}
+
+	// Use a semaphore to limit concurrent calls
+	semaphore := make(chan struct{}, b.concurrency)
+
+	for start := 0; start < len(texts); start += b.batchSize {
+		end := min(start+b.batchSize, len(texts))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				fail(errors.Wrap(ErrorCodeEmbeddingFailed, context.Cause(ctx)))
+				return
+			}
+			select {
+			case semaphore <- struct{}{}: // Acquire semaphore
+				defer func() { <-semaphore }() // Release semaphore
+			case <-ctx.Done():
+				fail(errors.Wrap(ErrorCodeEmbeddingFailed, context.Cause(ctx)))
+				return
+			}
+
+			batchVectors, err := b.embedBatch(ctx, texts[start:end], start)
+			if err != nil {
+				fail(err)
+				return
+			}
+			copy(vectors[start:end], batchVectors)
+		}(start, end)
+	}
+
+	wg.Wait()
+	if failure != nil {
+		return nil, failure
+	}
+	return vectors, nil
+}
+
+func (b *BatchingEmbedder) embedBatch(ctx context.Context, texts []string, offset int) ([]FloatVector, error) {
+	vectors, err := b.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, errors.Errorf(ErrorCodeEmbeddingFailed,
+			"embedding texts %d to %d failed: %s", offset, offset+len(texts)-1, err.Error())
+	}
+	if len(vectors) != len(texts) {
+		return nil, errors.Errorf(ErrorCodeEmbeddingFailed,
+			"embedding texts %d to %d returned %d vectors", offset, offset+len(texts)-1, len(vectors))
+	}
+	return vectors, nil
+}
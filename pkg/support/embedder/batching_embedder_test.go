@@ -0,0 +1,117 @@
+package embedder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexEmbedder embeds the texts, numbers, as one dimension vectors of their value
+type indexEmbedder struct {
+	mu         sync.Mutex
+	batchSizes []int
+	inFlight   atomic.Int32
+	maxFlight  atomic.Int32
+	failOn     string
+}
+
+func (e *indexEmbedder) Embed(ctx context.Context, texts []string) ([]FloatVector, error) {
+	e.mu.Lock()
+	e.batchSizes = append(e.batchSizes, len(texts))
+	e.mu.Unlock()
+
+	inFlight := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		maxFlight := e.maxFlight.Load()
+		if inFlight <= maxFlight || e.maxFlight.CompareAndSwap(maxFlight, inFlight) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	vectors := make([]FloatVector, len(texts))
+	for i, text := range texts {
+		if text == e.failOn {
+			return nil, errors.New("provider unavailable")
+		}
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = FloatVector{float64(value)}
+	}
+	return vectors, nil
+}
+
+func numberTexts(count int) []string {
+	texts := make([]string, count)
+	for i := range texts {
+		texts[i] = fmt.Sprint(i)
+	}
+	return texts
+}
+
+func TestBatchingEmbedder_Batches(t *testing.T) {
+	upstream := &indexEmbedder{}
+	embedder := NewBatchingEmbedder(upstream, WithBatchSize(100))
+
+	vectors, err := embedder.Embed(context.Background(), numberTexts(250))
+	require.NoError(t, err)
+	require.Len(t, vectors, 250)
+	for i, vector := range vectors {
+		assert.Equal(t, FloatVector{float64(i)}, vector)
+	}
+	assert.ElementsMatch(t, []int{100, 100, 50}, upstream.batchSizes)
+}
+
+func TestBatchingEmbedder_BoundedConcurrency(t *testing.T) {
+	upstream := &indexEmbedder{}
+	embedder := NewBatchingEmbedder(upstream, WithBatchSize(10), WithBatchConcurrency(2))
+
+	vectors, err := embedder.Embed(context.Background(), numberTexts(95))
+	require.NoError(t, err)
+	assert.Len(t, vectors, 95)
+	assert.Len(t, upstream.batchSizes, 10)
+	assert.Equal(t, int32(2), upstream.maxFlight.Load())
+}
+
+func TestBatchingEmbedder_SingleBatch(t *testing.T) {
+	upstream := &indexEmbedder{}
+	embedder := NewBatchingEmbedder(upstream)
+
+	vectors, err := embedder.Embed(context.Background(), numberTexts(3))
+	require.NoError(t, err)
+	assert.Equal(t, []FloatVector{{0}, {1}, {2}}, vectors)
+	assert.Equal(t, []int{3}, upstream.batchSizes)
+}
+
+func TestBatchingEmbedder_PartialFailure(t *testing.T) {
+	upstream := &indexEmbedder{failOn: "142"}
+	embedder := NewBatchingEmbedder(upstream, WithBatchSize(100), WithBatchConcurrency(1))
+
+	vectors, err := embedder.Embed(context.Background(), numberTexts(250))
+	require.Error(t, err)
+	assert.Nil(t, vectors)
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeEmbeddingFailed))
+	assert.Contains(t, err.Error(), "embedding texts 100 to 199 failed: provider unavailable")
+}
+
+func TestBatchingEmbedder_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewBatchingEmbedder(&indexEmbedder{}, WithBatchSize(10)).Embed(ctx, numberTexts(30))
+	require.Error(t, err)
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeEmbeddingFailed))
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+}
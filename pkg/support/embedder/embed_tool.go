@@ -0,0 +1,214 @@
+package embedder
+
+import (
+	"context"
+	"math"
+	"unicode/utf8"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+const (
+	EMBED_TOOL = "ag_embed"
+
+	// EmbedModeVectors returns the embedding vector of each text
+	EmbedModeVectors = "vectors"
+	// EmbedModeSimilarity returns the pairwise cosine similarities of the texts
+	EmbedModeSimilarity = "similarity"
+
+	defaultEmbedMaxTexts      = 32
+	defaultEmbedMaxTextLength = 8192
+)
+
+func IsEmbedTool(toolName string) bool {
+	return toolName == EMBED_TOOL
+}
+
+// EmbedToolOption configures the embed tool
+type EmbedToolOption func(*EmbedTool)
+
+// WithMaxTexts limits the number of texts embedded in one call
+func WithMaxTexts(maxTexts int) EmbedToolOption {
+	return func(t *EmbedTool) {
+		t.maxTexts = maxTexts
+	}
+}
+
+// WithMaxTextLength limits the length, in characters, of each text
+func WithMaxTextLength(maxTextLength int) EmbedToolOption {
+	return func(t *EmbedTool) {
+		t.maxTextLength = maxTextLength
+	}
+}
+
+// NewEmbedTool creates a tool computing text embeddings on demand, either the
+// vectors themselves or the pairwise cosine similarities between the texts,
+// e.g. for clustering or deduplication done by the agent.
+func NewEmbedTool(embedder Embedder, opts ...EmbedToolOption) *EmbedTool {
+	tool := &EmbedTool{
+		embedder:      embedder,
+		maxTexts:      defaultEmbedMaxTexts,
+		maxTextLength: defaultEmbedMaxTextLength,
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool
+}
+
+// EmbedTool embeds texts with an embedder
+type EmbedTool struct {
+	embedder      Embedder
+	maxTexts      int
+	maxTextLength int
+}
+
+func (e *EmbedTool) toolName() string {
+	return EMBED_TOOL
+}
+
+func (e *EmbedTool) Descriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name: e.toolName(),
+		Description: "Compute embeddings of texts. Returns the embedding vectors, " +
+			"or the pairwise cosine similarities (1 means identical meaning) useful to cluster or deduplicate texts",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"texts": {
+					Type:        llms.TypeArray,
+					Description: "Texts to embed",
+					Items:       &llms.Schema{Type: llms.TypeString},
+				},
+				"mode": {
+					Type: llms.TypeString,
+					Description: "What to return: \"vectors\" for the embedding vectors, " +
+						"\"similarity\" for the pairwise cosine similarity matrix (default: vectors)",
+				},
+			},
+			Required: []string{"texts"},
+		},
+	}
+}
+
+func (e *EmbedTool) Call(ctx context.Context, toolCall *llms.ToolCall) (*llms.ToolCallResult, error) {
+	var texts []string
+	switch raw := toolCall.Arguments["texts"].(type) {
+	case []string:
+		texts = raw
+	case []any:
+		for _, item := range raw {
+			text, ok := item.(string)
+			if !ok {
+				return nil, errors.Errorf(ErrorCodeInvalidEmbedInput, "texts must be a list of strings")
+			}
+			texts = append(texts, text)
+		}
+	}
+	mode, _ := toolCall.Arguments["mode"].(string)
+	if mode == "" {
+		mode = EmbedModeVectors
+	}
+
+	var result map[string]any
+	switch mode {
+	case EmbedModeVectors:
+		vectors, err := e.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		result = map[string]any{
+			"vectors":   vectors,
+			"dimension": len(vectors[0]),
+			"count":     len(vectors),
+		}
+	case EmbedModeSimilarity:
+		similarities, err := e.Similarities(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		result = map[string]any{
+			"similarities": similarities,
+			"count":        len(similarities),
+		}
+	default:
+		return nil, errors.Errorf(ErrorCodeInvalidEmbedInput, "unknown mode %q", mode)
+	}
+
+	return &llms.ToolCallResult{
+		ToolCallId: toolCall.ToolCallId,
+		Name:       e.toolName(),
+		Result:     result,
+	}, nil
+}
+
+// Embed embeds the texts, enforcing the count and length limits of the tool
+func (e *EmbedTool) Embed(ctx context.Context, texts []string) ([]FloatVector, error) {
+	if len(texts) == 0 {
+		return nil, errors.Errorf(ErrorCodeInvalidEmbedInput, "texts are required")
+	}
+	if e.maxTexts > 0 && len(texts) > e.maxTexts {
+		return nil, errors.Errorf(ErrorCodeInvalidEmbedInput,
+			"too many texts: %d, at most %d are allowed", len(texts), e.maxTexts)
+	}
+	for idx, text := range texts {
+		if e.maxTextLength > 0 && utf8.RuneCountInString(text) > e.maxTextLength {
+			return nil, errors.Errorf(ErrorCodeInvalidEmbedInput,
+				"text %d is too long, at most %d characters are allowed", idx, e.maxTextLength)
+		}
+	}
+
+	vectors, err := e.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, errors.Errorf(ErrorCodeEmbeddingFailed,
+			"number of vectors from embedder does not match number of texts")
+	}
+	return vectors, nil
+}
+
+// Similarities embeds the texts and returns their pairwise cosine similarity matrix
+func (e *EmbedTool) Similarities(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, err := e.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	return PairwiseSimilarities(vectors), nil
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors,
+// 0 when their dimensions differ or one of them is a zero vector
+func CosineSimilarity(a, b FloatVector) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for idx := range a {
+		dot += a[idx] * b[idx]
+		normA += a[idx] * a[idx]
+		normB += b[idx] * b[idx]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// PairwiseSimilarities returns the symmetric matrix of cosine similarities between the vectors
+func PairwiseSimilarities(vectors []FloatVector) [][]float64 {
+	matrix := make([][]float64, len(vectors))
+	for i := range vectors {
+		matrix[i] = make([]float64, len(vectors))
+	}
+	for i := range vectors {
+		for j := i; j < len(vectors); j++ {
+			similarity := CosineSimilarity(vectors[i], vectors[j])
+			matrix[i][j] = similarity
+			matrix[j][i] = similarity
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,151 @@
+package embedder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	commonerrors "github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder embeds texts as counts of a fixed vocabulary
+type fakeEmbedder struct {
+	vocabulary []string
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([]FloatVector, error) {
+	vectors := make([]FloatVector, len(texts))
+	for i, text := range texts {
+		vector := make(FloatVector, len(f.vocabulary))
+		for j, word := range f.vocabulary {
+			vector[j] = float64(strings.Count(text, word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func newFakeEmbedder() *fakeEmbedder {
+	return &fakeEmbedder{vocabulary: []string{"cat", "dog", "fish"}}
+}
+
+func TestEmbedTool_Descriptor(t *testing.T) {
+	tool := NewEmbedTool(newFakeEmbedder())
+
+	descriptor := tool.Descriptor()
+	assert.Equal(t, EMBED_TOOL, descriptor.Name)
+	assert.True(t, IsEmbedTool(descriptor.Name))
+	assert.Equal(t, []string{"texts"}, descriptor.Parameters.Required)
+}
+
+func TestEmbedTool_Vectors(t *testing.T) {
+	tool := NewEmbedTool(newFakeEmbedder())
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "embed_1",
+		Name:       EMBED_TOOL,
+		Arguments: map[string]any{
+			"texts": []any{"cat cat", "dog and fish"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "embed_1", result.ToolCallId)
+	assert.Equal(t, 3, result.Result["dimension"])
+	assert.Equal(t, 2, result.Result["count"])
+	assert.Equal(t, []FloatVector{{2, 0, 0}, {0, 1, 1}}, result.Result["vectors"])
+}
+
+func TestEmbedTool_Similarity(t *testing.T) {
+	tool := NewEmbedTool(newFakeEmbedder())
+
+	result, err := tool.Call(context.Background(), &llms.ToolCall{
+		ToolCallId: "embed_2",
+		Name:       EMBED_TOOL,
+		Arguments: map[string]any{
+			"texts": []any{"cat", "cat cat", "dog", "cat dog"},
+			"mode":  EmbedModeSimilarity,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Result["count"])
+
+	similarities := result.Result["similarities"].([][]float64)
+	require.Len(t, similarities, 4)
+	for i := range similarities {
+		require.Len(t, similarities[i], 4)
+		assert.InDelta(t, 1.0, similarities[i][i], 1e-9)
+		for j := range similarities {
+			assert.Equal(t, similarities[i][j], similarities[j][i])
+		}
+	}
+	// same direction, different magnitude
+	assert.InDelta(t, 1.0, similarities[0][1], 1e-9)
+	// orthogonal
+	assert.InDelta(t, 0.0, similarities[0][2], 1e-9)
+	// 45 degrees
+	assert.InDelta(t, 0.70710678, similarities[0][3], 1e-6)
+	assert.InDelta(t, 0.70710678, similarities[2][3], 1e-6)
+}
+
+func TestEmbedTool_Limits(t *testing.T) {
+	tool := NewEmbedTool(newFakeEmbedder(), WithMaxTexts(2), WithMaxTextLength(5))
+	ctx := context.Background()
+
+	_, err := tool.Embed(ctx, []string{"a", "b", "c"})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+	assert.Contains(t, err.Error(), "too many texts")
+
+	_, err = tool.Embed(ctx, []string{"cat cat"})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+	assert.Contains(t, err.Error(), "too long")
+
+	// length is counted in characters
+	vectors, err := tool.Embed(ctx, []string{"héllo"})
+	require.NoError(t, err)
+	assert.Len(t, vectors, 1)
+
+	_, err = tool.Embed(ctx, nil)
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+}
+
+func TestEmbedTool_InvalidArguments(t *testing.T) {
+	tool := NewEmbedTool(newFakeEmbedder())
+	ctx := context.Background()
+
+	_, err := tool.Call(ctx, &llms.ToolCall{Arguments: map[string]any{"texts": []any{"cat", 1.0}}})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+
+	_, err = tool.Call(ctx, &llms.ToolCall{Arguments: map[string]any{"texts": []any{"cat"}, "mode": "clusters"}})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+
+	_, err = tool.Call(ctx, &llms.ToolCall{Arguments: map[string]any{}})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeInvalidEmbedInput))
+}
+
+func TestEmbedTool_EmbedderErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	embedder := NewMockEmbedder(ctrl)
+	embedder.EXPECT().Embed(gomock.Any(), []string{"cat"}).Return(nil, errors.New("provider unavailable"))
+	_, err := NewEmbedTool(embedder).Embed(ctx, []string{"cat"})
+	assert.EqualError(t, err, "provider unavailable")
+
+	embedder.EXPECT().Embed(gomock.Any(), []string{"cat", "dog"}).Return([]FloatVector{{1}}, nil)
+	_, err = NewEmbedTool(embedder).Embed(ctx, []string{"cat", "dog"})
+	assert.True(t, commonerrors.IsCode(err, ErrorCodeEmbeddingFailed))
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1.0, CosineSimilarity(FloatVector{1, 2}, FloatVector{2, 4}), 1e-9)
+	assert.InDelta(t, -1.0, CosineSimilarity(FloatVector{1, 0}, FloatVector{-3, 0}), 1e-9)
+	assert.Equal(t, 0.0, CosineSimilarity(FloatVector{1, 0}, FloatVector{0, 0}))
+	assert.Equal(t, 0.0, CosineSimilarity(FloatVector{1, 0}, FloatVector{1, 0, 0}))
+	assert.Empty(t, PairwiseSimilarities(nil))
+}
@@ -8,4 +8,9 @@ var (
 		Name:           "EmbeddingFailed",
 		DefaultMessage: "Failed to embedding",
 	}
+	ErrorCodeInvalidEmbedInput = errors.ErrorCode{
+		Code:           30401,
+		Name:           "InvalidEmbedInput",
+		DefaultMessage: "Invalid input for embedding",
+	}
 )
@@ -13,4 +13,9 @@ var (
 		Name:           "SubscriberAlreadyClosed ",
 		DefaultMessage: "Event bus subscriber already closed",
 	}
+	ErrorCodePublishCanceled = errors.ErrorCode{
+		Code:           30502,
+		Name:           "PublishCanceled",
+		DefaultMessage: "Publish canceled while waiting for a subscriber",
+	}
 )
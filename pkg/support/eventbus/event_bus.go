@@ -2,7 +2,9 @@ package eventbus
 
 import (
 	"context"
+	"fmt"
 	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,33 +27,87 @@ func NewEvent(topic string, data any) *Event {
 type Event struct {
 	ID        string    `json:"id"`
 	Timestamp time.Time `json:"timestamp"`
+	// Sequence is the global publish order of the event, assigned by event buses with ordered delivery
+	Sequence uint64 `json:"sequence,omitempty"`
 
 	Topic string `json:"topic"`
 	Data  any    `json:"data"`
 }
 
-func newSubscriber(handler EventHandler, async bool, bufferSize int) *subscriber {
-	return &subscriber{
+// OverflowPolicy tells what Publish does with an event for an async subscriber whose buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Publish wait for room in the buffer, no event is lost; the wait is
+	// bounded by the context given to PublishContext
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop drops the event for the subscriber, Publish never waits for it
+	OverflowDrop
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDrop:
+		return "drop"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*subscriber)
+
+// WithOverflowPolicy sets what Publish does once the buffer of an async subscriber is full,
+// OverflowBlock by default
+func WithOverflowPolicy(policy OverflowPolicy) SubscribeOption {
+	return func(s *subscriber) {
+		s.overflowPolicy = policy
+	}
+}
+
+// EventFilter tells whether an event is handed to a subscriber
+type EventFilter func(event *Event) bool
+
+// WithFilter only hands the events accepted by the filter to the subscriber, the other events
+// take no room in its buffer
+func WithFilter(filter EventFilter) SubscribeOption {
+	return func(s *subscriber) {
+		s.filter = filter
+	}
+}
+
+func newSubscriber(handler EventHandler, async bool, bufferSize int, opts ...SubscribeOption) *subscriber {
+	s := &subscriber{
 		id: uuid.NewString(),
 
 		eventChan: make(chan *Event, bufferSize),
 		handler:   handler,
 		async:     async,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type subscriber struct {
 	id string
 
-	eventChan chan *Event
-	handler   EventHandler
-	async     bool
+	eventChan      chan *Event
+	handler        EventHandler
+	async          bool
+	overflowPolicy OverflowPolicy
+	replay         int
+	filter         EventFilter
 
 	mu     sync.Mutex
 	closed bool
 }
 
-func (s *subscriber) start() error {
+// start starts the loop of an async subscriber, handling the replayed events before the buffered ones
+func (s *subscriber) start(replayed []*Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -60,12 +116,24 @@ func (s *subscriber) start() error {
 	}
 
 	if s.async {
-		go s.asyncEventLoop()
+		go s.asyncEventLoop(replayed)
 	}
 
 	return nil
 }
 
+// replayLocked hands the replayed events to a synchronous subscriber, whose lock is held
+func (s *subscriber) replayLocked(replayed []*Event) {
+	for _, event := range replayed {
+		if s.closed {
+			return
+		}
+		if s.filter == nil || s.filter(event) {
+			s.safeHandle(context.Background(), event)
+		}
+	}
+}
+
 func (s *subscriber) close() {
 	s.mu.Lock()
 	defer func() {
@@ -76,22 +144,44 @@ func (s *subscriber) close() {
 	close(s.eventChan)
 }
 
-func (s *subscriber) handleEvent(ctx context.Context, event *Event) {
+func (s *subscriber) handleEvent(ctx context.Context, event *Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.closed {
-		return
+		return nil
+	}
+	if s.filter != nil && !s.filter(event) {
+		return nil
 	}
 
-	if s.async {
-		s.eventChan <- event
-	} else {
+	if !s.async {
 		s.safeHandle(ctx, event)
+		return nil
+	}
+	if s.overflowPolicy == OverflowDrop {
+		select {
+		case s.eventChan <- event:
+		default:
+			journal.Warning("subscriber/handle", "subscriber/"+s.id, "buffer full, dropped the event", "event", event)
+		}
+		return nil
+	}
+	select {
+	case s.eventChan <- event:
+		return nil
+	case <-ctx.Done():
+		return errors.Errorf(ErrorCodePublishCanceled,
+			"event %s not handed to subscriber %s: %v", event.ID, s.id, ctx.Err())
 	}
 }
 
-func (s *subscriber) asyncEventLoop() {
+func (s *subscriber) asyncEventLoop(replayed []*Event) {
+	for _, event := range replayed {
+		if s.filter == nil || s.filter(event) {
+			s.safeHandle(context.Background(), event)
+		}
+	}
 	for {
 		select {
 		case event, ok := <-s.eventChan:
@@ -114,38 +204,98 @@ func (s *subscriber) safeHandle(ctx context.Context, event *Event) {
 	}
 }
 
-func NewEventBus() *EventBus {
-	return &EventBus{
+// EventBusOption configures an event bus
+type EventBusOption func(*EventBus)
+
+// WithOrderedDelivery makes the event bus deliver events in a single global publish order:
+// publishes are serialized and numbered (Event.Sequence), and each event is handed to all of
+// its subscribers before the next one, so every subscriber observes the events it receives in
+// the same order, even with concurrent publishers, async subscribers and buffers.
+// The price is that a publisher waits for the synchronous handlers and for room in the buffers
+// of the async subscribers while holding the publish order, and synchronous handlers must not
+// publish on the same event bus.
+func WithOrderedDelivery() EventBusOption {
+	return func(eb *EventBus) {
+		eb.ordered = true
+	}
+}
+
+// TopicWildcard ends the topics subscribed to all the topics starting with the same prefix,
+// e.g. "agent:*" matches "agent:agent_message", and "*" alone matches all the topics
+const TopicWildcard = "*"
+
+func NewEventBus(opts ...EventBusOption) *EventBus {
+	eb := &EventBus{
 		subscribers: make(map[string][]*subscriber),
+		wildcards:   make(map[string][]*subscriber),
 	}
+	for _, opt := range opts {
+		opt(eb)
+	}
+	return eb
 }
 
+// EventBus dispatches published events to the subscribers of their topic.
+// Each subscriber handles its events one at a time, in the order they were handed to it:
+// events published sequentially are handled in publish order. Events published concurrently
+// may reach different subscribers in different orders, unless WithOrderedDelivery is used.
+// A topic ending with TopicWildcard subscribes to all the topics with its prefix.
 type EventBus struct {
 	mu          sync.RWMutex
 	closed      bool
 	subscribers map[string][]*subscriber
+	wildcards   map[string][]*subscriber // subscribers of the wildcard topics, by prefix
+
+	ordered   bool
+	publishMu sync.Mutex
+	sequence  uint64
+
+	replay *replayBuffer
 }
 
-func (eb *EventBus) Subscribe(topic string, handler EventHandler, async bool, bufferSize int) (string, error) {
+// Subscribe subscribes the handler to the topic. An async subscriber handles its events in the
+// background, from a buffer of bufferSize events, see WithOverflowPolicy for a full buffer.
+func (eb *EventBus) Subscribe(topic string, handler EventHandler, async bool, bufferSize int, opts ...SubscribeOption) (string, error) {
 	eb.mu.Lock()
-	defer eb.mu.Unlock()
 
 	if eb.closed {
+		eb.mu.Unlock()
 		return "", errors.New(ErrorCodeEventBusAlreadyClosed)
 	}
 
-	sub := newSubscriber(handler, async, bufferSize)
-	eb.subscribers[topic] = append(eb.subscribers[topic], sub)
+	sub := newSubscriber(handler, async, bufferSize, opts...)
 
+	// no event is published while subscribing: the replayed events are the ones before the live ones
+	var replayed []*Event
+	if sub.replay > 0 && eb.replay != nil {
+		replayed = eb.replay.last(topic, sub.replay)
+	}
 	if async {
-		if err := sub.start(); err != nil {
+		if err := sub.start(replayed); err != nil {
+			eb.mu.Unlock()
 			return "", err
 		}
+	} else if len(replayed) > 0 {
+		// the live events wait for the replayed ones, handed once the event bus is released
+		sub.mu.Lock()
+		defer func() {
+			sub.replayLocked(replayed)
+			sub.mu.Unlock()
+		}()
 	}
 
+	subscribers, key := eb.subscribersByTopic(topic)
+	subscribers[key] = append(subscribers[key], sub)
+	eb.mu.Unlock()
 	return sub.id, nil
 }
 
+// SubscribeWithFilter subscribes the handler to the topic like Subscribe, handing it only the
+// events accepted by the filter, in the order they were published
+func (eb *EventBus) SubscribeWithFilter(topic string, filter EventFilter, handler EventHandler, async bool, bufferSize int, opts ...SubscribeOption) (string, error) {
+	return eb.Subscribe(topic, handler, async, bufferSize, append(opts, WithFilter(filter))...)
+}
+
 func (eb *EventBus) Unsubscribe(topic, subscriberId string) error {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
@@ -154,7 +304,8 @@ func (eb *EventBus) Unsubscribe(topic, subscriberId string) error {
 		return errors.New(ErrorCodeEventBusAlreadyClosed)
 	}
 
-	subscribers, exists := eb.subscribers[topic]
+	subscribersByTopic, key := eb.subscribersByTopic(topic)
+	subscribers, exists := subscribersByTopic[key]
 	if !exists {
 		return nil
 	}
@@ -162,7 +313,10 @@ func (eb *EventBus) Unsubscribe(topic, subscriberId string) error {
 	for i, sub := range subscribers {
 		if sub.id == subscriberId {
 			sub.close()
-			eb.subscribers[topic] = append(subscribers[:i], subscribers[i+1:]...)
+			subscribersByTopic[key] = append(subscribers[:i], subscribers[i+1:]...)
+			if len(subscribersByTopic[key]) == 0 {
+				delete(subscribersByTopic, key)
+			}
 			return nil
 		}
 	}
@@ -170,7 +324,14 @@ func (eb *EventBus) Unsubscribe(topic, subscriberId string) error {
 	return nil
 }
 
+// Publish publishes the event, waiting as long as needed for the async subscribers blocking on a full buffer
 func (eb *EventBus) Publish(event *Event) error {
+	return eb.PublishContext(context.Background(), event)
+}
+
+// PublishContext publishes the event, waiting for the async subscribers blocking on a full buffer
+// until the context is done: the event is then not handed to them and an error is returned
+func (eb *EventBus) PublishContext(ctx context.Context, event *Event) error {
 	eb.mu.RLock()
 	defer eb.mu.RUnlock()
 
@@ -178,10 +339,47 @@ func (eb *EventBus) Publish(event *Event) error {
 		return errors.New(ErrorCodeEventBusAlreadyClosed)
 	}
 
-	for _, sub := range eb.subscribers[event.Topic] {
-		sub.handleEvent(context.Background(), event)
+	if eb.ordered {
+		eb.publishMu.Lock()
+		defer eb.publishMu.Unlock()
+		eb.sequence++
+		event.Sequence = eb.sequence
 	}
-	return nil
+
+	if eb.replay != nil {
+		eb.replay.retain(event)
+	}
+
+	var err error
+	for _, sub := range eb.subscribersOf(event.Topic) {
+		if handleErr := sub.handleEvent(ctx, event); handleErr != nil && err == nil {
+			err = handleErr
+		}
+	}
+	return err
+}
+
+// subscribersByTopic returns the subscribers of the subscribed topic and their key, the prefix of a wildcard topic
+func (eb *EventBus) subscribersByTopic(topic string) (map[string][]*subscriber, string) {
+	if prefix, ok := strings.CutSuffix(topic, TopicWildcard); ok {
+		return eb.wildcards, prefix
+	}
+	return eb.subscribers, topic
+}
+
+// subscribersOf returns the subscribers of the published topic: the ones of the topic itself,
+// then the ones of the wildcard topics matching it
+func (eb *EventBus) subscribersOf(topic string) []*subscriber {
+	if len(eb.wildcards) == 0 {
+		return eb.subscribers[topic]
+	}
+	subscribers := append([]*subscriber(nil), eb.subscribers[topic]...)
+	for prefix, wildcardSubscribers := range eb.wildcards {
+		if strings.HasPrefix(topic, prefix) {
+			subscribers = append(subscribers, wildcardSubscribers...)
+		}
+	}
+	return subscribers
 }
 
 func (eb *EventBus) Close() {
@@ -190,9 +388,11 @@ func (eb *EventBus) Close() {
 
 	eb.closed = true
 
-	for _, subscribers := range eb.subscribers {
-		for _, sub := range subscribers {
-			sub.close()
+	for _, subscribersByTopic := range []map[string][]*subscriber{eb.subscribers, eb.wildcards} {
+		for _, subscribers := range subscribersByTopic {
+			for _, sub := range subscribers {
+				sub.close()
+			}
 		}
 	}
 }
@@ -586,3 +586,371 @@ func TestEventBus_Seq(t *testing.T) {
 
 	time.Sleep(1 * time.Second)
 }
+
+func TestEventBus_OrderedDeliveryAcrossSubscribers(t *testing.T) {
+	eb := NewEventBus(WithOrderedDelivery())
+
+	numPublishers := 8
+	eventsPerPublisher := 50
+	totalEvents := numPublishers * eventsPerPublisher
+
+	// async subscribers with different buffers and speeds, and a sync subscriber
+	bufferSizes := []int{1, 10, 100, 0}
+	received := make([][]*Event, len(bufferSizes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(totalEvents * len(bufferSizes))
+
+	for idx, bufferSize := range bufferSizes {
+		handler := func(ctx context.Context, event *Event) error {
+			if idx == 0 {
+				time.Sleep(10 * time.Microsecond)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			received[idx] = append(received[idx], event)
+			wg.Done()
+			return nil
+		}
+		if _, err := eb.Subscribe("test.ordered", handler, bufferSize > 0, bufferSize); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	var publishers sync.WaitGroup
+	for p := 0; p < numPublishers; p++ {
+		publishers.Add(1)
+		go func(publisherID int) {
+			defer publishers.Done()
+			for i := 0; i < eventsPerPublisher; i++ {
+				if err := eb.Publish(NewEvent("test.ordered", [2]int{publisherID, i})); err != nil {
+					t.Errorf("Failed to publish event: %v", err)
+				}
+			}
+		}(p)
+	}
+	publishers.Wait()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for idx, events := range received {
+		if len(events) != totalEvents {
+			t.Fatalf("Subscriber %d: expected %d events, got %d", idx, totalEvents, len(events))
+		}
+
+		// events are observed in global publish order
+		lastOfPublisher := make(map[int]int)
+		for i, event := range events {
+			if event.Sequence != uint64(i+1) {
+				t.Fatalf("Subscriber %d: event %d has sequence %d, expected %d", idx, i, event.Sequence, i+1)
+			}
+			// which preserves the order of each publisher
+			data := event.Data.([2]int)
+			if last, ok := lastOfPublisher[data[0]]; ok && data[1] != last+1 {
+				t.Fatalf("Subscriber %d: publisher %d event %d received after %d", idx, data[0], data[1], last)
+			}
+			lastOfPublisher[data[0]] = data[1]
+		}
+
+		// all subscribers observe the same order
+		for i, event := range events {
+			if event != received[0][i] {
+				t.Fatalf("Subscriber %d: event %d differs from subscriber 0", idx, i)
+			}
+		}
+	}
+}
+
+func TestEventBus_OrderedDeliverySequenceIsGlobal(t *testing.T) {
+	eb := NewEventBus(WithOrderedDelivery())
+
+	var sequences []uint64
+	handler := func(ctx context.Context, event *Event) error {
+		sequences = append(sequences, event.Sequence)
+		return nil
+	}
+	for _, topic := range []string{"topic1", "topic2"} {
+		if _, err := eb.Subscribe(topic, handler, false, 0); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	for _, topic := range []string{"topic1", "topic2", "topic1"} {
+		if err := eb.Publish(NewEvent(topic, nil)); err != nil {
+			t.Fatalf("Failed to publish event: %v", err)
+		}
+	}
+
+	if fmt.Sprint(sequences) != "[1 2 3]" {
+		t.Errorf("Expected sequences [1 2 3], got %v", sequences)
+	}
+
+	// unordered event buses do not number events
+	event := NewEvent("topic1", nil)
+	if err := NewEventBus().Publish(event); err != nil {
+		t.Fatalf("Failed to publish event: %v", err)
+	}
+	if event.Sequence != 0 {
+		t.Errorf("Expected no sequence, got %d", event.Sequence)
+	}
+}
+
+// topicRecorder records the data of the events it handles
+type topicRecorder struct {
+	mu   sync.Mutex
+	data []any
+}
+
+func (r *topicRecorder) handle(ctx context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = append(r.data, event.Data)
+	return nil
+}
+
+func (r *topicRecorder) received() []any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]any(nil), r.data...)
+}
+
+func TestEventBus_WildcardSubscription(t *testing.T) {
+	eb := NewEventBus()
+
+	agentEvents := &topicRecorder{}
+	allEvents := &topicRecorder{}
+	if _, err := eb.Subscribe("agent.*", agentEvents.handle, true, 10); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if _, err := eb.Subscribe("*", allEvents.handle, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	numEvents := 50
+	for i := 0; i < numEvents; i++ {
+		topic := []string{"agent.message", "agent.usage", "tool.call"}[i%3]
+		if err := eb.Publish(NewEvent(topic, i)); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// the agent events are received in publish order, across their topics
+	received := agentEvents.received()
+	var expected []any
+	for i := 0; i < numEvents; i++ {
+		if i%3 != 2 {
+			expected = append(expected, i)
+		}
+	}
+	if fmt.Sprint(received) != fmt.Sprint(expected) {
+		t.Errorf("Expected agent events %v, got %v", expected, received)
+	}
+	if len(allEvents.received()) != numEvents {
+		t.Errorf("Expected %d events for the '*' subscriber, got %d", numEvents, len(allEvents.received()))
+	}
+}
+
+func TestEventBus_WildcardOverlapsExactSubscribers(t *testing.T) {
+	eb := NewEventBus()
+
+	exact := &topicRecorder{}
+	wildcard := &topicRecorder{}
+	other := &topicRecorder{}
+	for topic, recorder := range map[string]*topicRecorder{
+		"agent.message": exact, "agent.*": wildcard, "agent.usage": other} {
+		if _, err := eb.Subscribe(topic, recorder.handle, false, 0); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	_ = eb.Publish(NewEvent("agent.message", "message"))
+	_ = eb.Publish(NewEvent("agents", "not an agent event"))
+
+	// each subscriber receives the event once
+	if got := exact.received(); len(got) != 1 || got[0] != "message" {
+		t.Errorf("Expected the exact subscriber to receive the message once, got %v", got)
+	}
+	if got := wildcard.received(); len(got) != 1 || got[0] != "message" {
+		t.Errorf("Expected the wildcard subscriber to receive the message once, got %v", got)
+	}
+	if got := other.received(); len(got) != 0 {
+		t.Errorf("Expected no event for another topic, got %v", got)
+	}
+}
+
+func TestEventBus_UnsubscribeWildcard(t *testing.T) {
+	eb := NewEventBus()
+
+	recorder := &topicRecorder{}
+	subscriberID, err := eb.Subscribe("agent.*", recorder.handle, false, 0)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	exact := &topicRecorder{}
+	if _, err = eb.Subscribe("agent.message", exact.handle, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	_ = eb.Publish(NewEvent("agent.message", "before unsubscribe"))
+	// the id must be unsubscribed from the wildcard topic, not from a matching one
+	if err = eb.Unsubscribe("agent.message", subscriberID); err != nil {
+		t.Errorf("Failed to unsubscribe: %v", err)
+	}
+	_ = eb.Publish(NewEvent("agent.message", "still subscribed"))
+	if err = eb.Unsubscribe("agent.*", subscriberID); err != nil {
+		t.Errorf("Failed to unsubscribe: %v", err)
+	}
+	_ = eb.Publish(NewEvent("agent.message", "after unsubscribe"))
+
+	if got := recorder.received(); fmt.Sprint(got) != "[before unsubscribe still subscribed]" {
+		t.Errorf("Expected the events before the unsubscribe, got %v", got)
+	}
+	if got := exact.received(); len(got) != 3 {
+		t.Errorf("Expected the exact subscriber to receive 3 events, got %d", len(got))
+	}
+}
+
+func TestEventBus_OverflowBlockLosesNoEvents(t *testing.T) {
+	eb := NewEventBus()
+
+	recorder := &topicRecorder{}
+	slowHandler := func(ctx context.Context, event *Event) error {
+		time.Sleep(2 * time.Millisecond)
+		return recorder.handle(ctx, event)
+	}
+	if _, err := eb.Subscribe("test.block", slowHandler, true, 2, WithOverflowPolicy(OverflowBlock)); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	numEvents := 30
+	for i := 0; i < numEvents; i++ {
+		if err := eb.Publish(NewEvent("test.block", i)); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(recorder.received()) < numEvents && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	received := recorder.received()
+	if len(received) != numEvents {
+		t.Fatalf("Expected %d events, got %d", numEvents, len(received))
+	}
+	for i, data := range received {
+		if data != i {
+			t.Errorf("Event %d: expected data %d, got %v", i, i, data)
+		}
+	}
+}
+
+func TestEventBus_OverflowDrop(t *testing.T) {
+	eb := NewEventBus()
+
+	release := make(chan struct{})
+	recorder := &topicRecorder{}
+	blockedHandler := func(ctx context.Context, event *Event) error {
+		<-release
+		return recorder.handle(ctx, event)
+	}
+	if _, err := eb.Subscribe("test.drop", blockedHandler, true, 2, WithOverflowPolicy(OverflowDrop)); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// the publisher never waits for the subscriber
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_ = eb.Publish(NewEvent("test.drop", i))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a full buffer")
+	}
+
+	close(release)
+	time.Sleep(100 * time.Millisecond)
+	received := recorder.received()
+	if len(received) == 0 || len(received) > 3 {
+		t.Errorf("Expected the events of the buffer and the one handled, got %v", received)
+	}
+}
+
+func TestEventBus_PublishContextCanceled(t *testing.T) {
+	eb := NewEventBus()
+
+	release := make(chan struct{})
+	defer close(release)
+	blockedHandler := func(ctx context.Context, event *Event) error {
+		<-release
+		return nil
+	}
+	if _, err := eb.Subscribe("test.timeout", blockedHandler, true, 1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// one event is handled, one waits in the buffer, the next one has no room
+	_ = eb.Publish(NewEvent("test.timeout", 1))
+	time.Sleep(20 * time.Millisecond)
+	_ = eb.Publish(NewEvent("test.timeout", 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := eb.PublishContext(ctx, NewEvent("test.timeout", 3))
+	if !errors.IsCode(err, ErrorCodePublishCanceled) {
+		t.Errorf("Expected a canceled publish, got %v", err)
+	}
+}
+
+func TestEventBus_SubscribeWithFilter(t *testing.T) {
+	eb := NewEventBus(WithReplayBuffer(10))
+
+	even := func(event *Event) bool {
+		return event.Data.(int)%2 == 0
+	}
+	_ = eb.Publish(NewEvent("test.filter", 0))
+	_ = eb.Publish(NewEvent("test.filter", 1))
+
+	syncRecorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithFilter("test.filter", even, syncRecorder.handle, false, 0, WithReplay(2)); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	// the filtered out events take no room in the buffer
+	asyncRecorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithFilter("test.filter", even, asyncRecorder.handle, true, 1, WithOverflowPolicy(OverflowDrop)); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	unfiltered := &topicRecorder{}
+	if _, err := eb.Subscribe("test.filter", unfiltered.handle, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 2; i < 40; i++ {
+		_ = eb.Publish(NewEvent("test.filter", i))
+		if i%2 == 0 {
+			// leave the async subscriber the time to handle the event
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	var expected []any
+	for i := 2; i < 40; i += 2 {
+		expected = append(expected, i)
+	}
+	if got := fmt.Sprint(syncRecorder.received()); got != fmt.Sprint(append([]any{0}, expected...)) {
+		t.Errorf("Expected the even events in order, got %s", got)
+	}
+	if got := fmt.Sprint(asyncRecorder.received()); got != fmt.Sprint(expected) {
+		t.Errorf("Expected the even events in order, got %s", got)
+	}
+	if got := len(unfiltered.received()); got != 38 {
+		t.Errorf("Expected 38 events without filter, got %d", got)
+	}
+}
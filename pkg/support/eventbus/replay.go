@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WithReplayBuffer retains the last size events published on each topic, so that the subscribers
+// joining late can catch up with them, see SubscribeWithReplay
+func WithReplayBuffer(size int) EventBusOption {
+	return func(eb *EventBus) {
+		if size > 0 {
+			eb.replay = newReplayBuffer(size)
+		}
+	}
+}
+
+// WithReplay hands the last n events retained by the replay buffer of the event bus to the
+// subscriber before the events published after it subscribed
+func WithReplay(n int) SubscribeOption {
+	return func(s *subscriber) {
+		s.replay = n
+	}
+}
+
+// SubscribeWithReplay subscribes the handler to the topic like Subscribe, handing it first the
+// last n events of the topic retained by the replay buffer of the event bus, in publish order.
+// A synchronous handler is handed the events before SubscribeWithReplay returns, an async one
+// handles them before the events of its buffer, whatever its size and overflow policy.
+func (eb *EventBus) SubscribeWithReplay(topic string, handler EventHandler, n int, async bool, bufferSize int, opts ...SubscribeOption) (string, error) {
+	return eb.Subscribe(topic, handler, async, bufferSize, append(opts, WithReplay(n))...)
+}
+
+// retainedEvent is an event of the replay buffer, numbered in publish order across the topics
+type retainedEvent struct {
+	number uint64
+	event  *Event
+}
+
+// replayBuffer retains the last events of each topic
+type replayBuffer struct {
+	mu     sync.Mutex
+	size   int
+	number uint64
+	topics map[string]*eventRing
+}
+
+// eventRing holds the last events of a topic, overwriting the oldest one once full
+type eventRing struct {
+	events []retainedEvent
+	next   int // index of the oldest event once full, overwritten by the next one
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{
+		size:   size,
+		topics: make(map[string]*eventRing),
+	}
+}
+
+func (b *replayBuffer) retain(event *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.number++
+	retained := retainedEvent{number: b.number, event: event}
+	ring, ok := b.topics[event.Topic]
+	if !ok {
+		ring = &eventRing{}
+		b.topics[event.Topic] = ring
+	}
+	if len(ring.events) < b.size {
+		ring.events = append(ring.events, retained)
+		return
+	}
+	ring.events[ring.next] = retained
+	ring.next = (ring.next + 1) % b.size
+}
+
+// ordered returns the events of the ring in publish order
+func (r *eventRing) ordered() []retainedEvent {
+	return append(append([]retainedEvent(nil), r.events[r.next:]...), r.events[:r.next]...)
+}
+
+// last returns the last n events of the subscribed topic, a wildcard topic matching many, in publish order
+func (b *replayBuffer) last(topic string, n int) []*Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var retained []retainedEvent
+	if prefix, ok := strings.CutSuffix(topic, TopicWildcard); ok {
+		for eventTopic, ring := range b.topics {
+			if strings.HasPrefix(eventTopic, prefix) {
+				retained = append(retained, ring.events...)
+			}
+		}
+		sort.Slice(retained, func(i, j int) bool {
+			return retained[i].number < retained[j].number
+		})
+	} else if ring, ok := b.topics[topic]; ok {
+		retained = ring.ordered()
+	}
+
+	if len(retained) > n {
+		retained = retained[len(retained)-n:]
+	}
+	events := make([]*Event, 0, len(retained))
+	for _, r := range retained {
+		events = append(events, r.event)
+	}
+	return events
+}
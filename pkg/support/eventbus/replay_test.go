@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEventBus_SubscribeWithReplay(t *testing.T) {
+	eb := NewEventBus(WithReplayBuffer(5))
+
+	for i := 0; i < 8; i++ {
+		if err := eb.Publish(NewEvent("agent.message", i)); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+
+	// the last 3 events are replayed, then the new ones are received
+	syncRecorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.message", syncRecorder.handle, 3, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	asyncRecorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.message", asyncRecorder.handle, 3, true, 1); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	for i := 8; i < 10; i++ {
+		_ = eb.Publish(NewEvent("agent.message", i))
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	expected := "[5 6 7 8 9]"
+	if got := fmt.Sprint(syncRecorder.received()); got != expected {
+		t.Errorf("Expected the sync subscriber to receive %s, got %s", expected, got)
+	}
+	if got := fmt.Sprint(asyncRecorder.received()); got != expected {
+		t.Errorf("Expected the async subscriber to receive %s, got %s", expected, got)
+	}
+
+	// the buffer only retains the last events of the topic
+	allRecorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.message", allRecorder.handle, 100, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if got := fmt.Sprint(allRecorder.received()); got != expected {
+		t.Errorf("Expected the retained events %s, got %s", expected, got)
+	}
+}
+
+func TestEventBus_ReplayWildcardTopic(t *testing.T) {
+	eb := NewEventBus(WithReplayBuffer(10))
+
+	topics := []string{"agent.message", "agent.usage", "tool.call"}
+	for i := 0; i < 9; i++ {
+		_ = eb.Publish(NewEvent(topics[i%3], i))
+	}
+
+	// the events of the matching topics are replayed in publish order
+	recorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.*", recorder.handle, 4, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if got := fmt.Sprint(recorder.received()); got != "[3 4 6 7]" {
+		t.Errorf("Expected the last agent events in order, got %s", got)
+	}
+}
+
+func TestEventBus_ReplayWithoutBuffer(t *testing.T) {
+	eb := NewEventBus()
+	_ = eb.Publish(NewEvent("agent.message", "before"))
+
+	recorder := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.message", recorder.handle, 5, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	_ = eb.Publish(NewEvent("agent.message", "after"))
+
+	if got := fmt.Sprint(recorder.received()); got != "[after]" {
+		t.Errorf("Expected only the live event, got %s", got)
+	}
+
+	// a subscriber without replay only receives the live events
+	eb = NewEventBus(WithReplayBuffer(5))
+	_ = eb.Publish(NewEvent("agent.message", "before"))
+	recorder = &topicRecorder{}
+	if _, err := eb.Subscribe("agent.message", recorder.handle, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	_ = eb.Publish(NewEvent("agent.message", "after"))
+	if got := fmt.Sprint(recorder.received()); got != "[after]" {
+		t.Errorf("Expected only the live event, got %s", got)
+	}
+}
+
+func TestEventBus_ReplayNotDroppedNorBlocking(t *testing.T) {
+	eb := NewEventBus(WithReplayBuffer(5))
+	for i := 0; i < 5; i++ {
+		_ = eb.Publish(NewEvent("agent.message", i))
+	}
+
+	// an async subscriber dropping the overflow gets all the replayed events, whatever its buffer
+	release := make(chan struct{})
+	recorder := &topicRecorder{}
+	slow := func(ctx context.Context, event *Event) error {
+		<-release
+		return recorder.handle(ctx, event)
+	}
+	if _, err := eb.SubscribeWithReplay("agent.message", slow, 5, true, 1,
+		WithOverflowPolicy(OverflowDrop)); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	close(release)
+
+	// a synchronous handler uses the event bus while the events are replayed
+	if _, err := eb.SubscribeWithReplay("agent.message", func(ctx context.Context, event *Event) error {
+		return eb.Publish(NewEvent("agent.replayed", event.Data))
+	}, 2, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := fmt.Sprint(recorder.received()); got != "[0 1 2 3 4]" {
+		t.Errorf("Expected all the replayed events, got %s", got)
+	}
+	replayed := &topicRecorder{}
+	if _, err := eb.SubscribeWithReplay("agent.replayed", replayed.handle, 5, false, 0); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if got := fmt.Sprint(replayed.received()); got != "[3 4]" {
+		t.Errorf("Expected the events published while replaying, got %s", got)
+	}
+}
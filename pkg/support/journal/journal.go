@@ -1,6 +1,7 @@
 package journal
 
 import (
+	"io"
 	"sync"
 	"time"
 
@@ -34,6 +35,8 @@ type Entry struct {
 	Source    string                 `json:"source"`
 	Message   string                 `json:"message"`
 	Data      map[string]interface{} `json:"data,omitempty"`
+	// Values are the data values as passed to the journal, before their conversion to text
+	Values map[string]any `json:"-"`
 }
 
 // Journal 实现，使用 Storage 进行存储
@@ -72,6 +75,7 @@ func (j *journal) log(level Level, category, source, message string, data ...any
 		Source:    source,
 		Message:   message,
 		Data:      parseData(data...),
+		Values:    dataValues(data...),
 	}
 	return j.storage.Write(entry)
 }
@@ -104,8 +108,8 @@ func (j *journal) GetUsage(usageSessionId string) map[string]float64 {
 }
 
 // 便捷构造函数
-func NewFileJournal(path string) (Journal, error) {
-	storage, err := NewFileStorage(path)
+func NewFileJournal(path string, opts ...FileStorageOption) (Journal, error) {
+	storage, err := NewFileStorage(path, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +120,11 @@ func NewConsoleJournal() Journal {
 	return NewJournal(NewConsoleStorage())
 }
 
+// NewJSONJournal creates a journal writing its entries to w as JSON lines, see JSONStorage
+func NewJSONJournal(w io.Writer) Journal {
+	return NewJournal(NewJSONStorage(w))
+}
+
 func parseData(data ...any) map[string]interface{} {
 	result := make(map[string]interface{})
 	for i := 0; i+1 < len(data); i += 2 {
@@ -135,3 +144,14 @@ func parseData(data ...any) map[string]interface{} {
 	}
 	return result
 }
+
+// dataValues returns the key/value pairs of the data as they are
+func dataValues(data ...any) map[string]any {
+	result := make(map[string]any)
+	for i := 0; i+1 < len(data); i += 2 {
+		if key, ok := data[i].(string); ok {
+			result[key] = data[i+1]
+		}
+	}
+	return result
+}
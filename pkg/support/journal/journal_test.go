@@ -1,9 +1,15 @@
 package journal
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestJournal_LogAndUsage(t *testing.T) {
@@ -139,3 +145,130 @@ func TestNewJournalWithSingleStorage(t *testing.T) {
 		t.Errorf("single storage journal failed: %v", err)
 	}
 }
+
+func TestJSONJournal(t *testing.T) {
+	var buffer bytes.Buffer
+	journal := NewJSONJournal(&buffer)
+
+	err := journal.Warning("tool", "fetch", "failed to call the tool",
+		"url", "https://example.com", "attempt", 2, "err", errors.New("timeout"))
+	if err != nil {
+		t.Fatalf("Warning log failed: %v", err)
+	}
+	journal.AccumulateUsage("session-1", map[string]float64{"input_tokens": 10})
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buffer.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	for key, expected := range map[string]any{
+		"level": "warning", "component": "fetch", "category": "tool", "message": "failed to call the tool"} {
+		if entry[key] != expected {
+			t.Errorf("expected %s %v, got %v", key, expected, entry[key])
+		}
+	}
+	if _, ok := entry["timestamp"].(string); !ok {
+		t.Errorf("missing timestamp: %v", entry)
+	}
+	data, _ := entry["data"].(map[string]any)
+	if data["url"] != "https://example.com" || data["attempt"] != float64(2) || data["err"] != "timeout" {
+		t.Errorf("unexpected data: %v", data)
+	}
+
+	var usage map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &usage); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	data, _ = usage["data"].(map[string]any)
+	if usage["category"] != "usage" || data["session_id"] != "session-1" {
+		t.Errorf("unexpected usage entry: %v", usage)
+	}
+	if tokens, _ := data["usage"].(map[string]any); tokens["input_tokens"] != float64(10) {
+		t.Errorf("unexpected usage: %v", data["usage"])
+	}
+
+	// the global journal accepts it
+	previous := GetGlobalJournal()
+	SetGlobalJournal(journal)
+	defer SetGlobalJournal(previous)
+	if err := Info("test", "global", "through the global journal"); err != nil {
+		t.Errorf("Info log failed: %v", err)
+	}
+	if !strings.Contains(buffer.String(), `"message":"through the global journal"`) {
+		t.Errorf("global journal did not write to the JSON journal")
+	}
+}
+
+func TestFileJournal_RotateOnSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	journal, err := NewFileJournal(path, WithMaxSizeMB(1), WithMaxBackups(2))
+	if err != nil {
+		t.Fatalf("failed to create FileJournal: %v", err)
+	}
+
+	// about 4MB written concurrently
+	message := strings.Repeat("x", 1024)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1024; i++ {
+				if err := journal.Info("test", "rotation", message); err != nil {
+					t.Errorf("Info log failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("failed to list the backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups, got %v", backups)
+	}
+	for _, file := range append(backups, path) {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", file, err)
+		}
+		// a file is rotated once it crossed the size, with the entry crossing it
+		if info.Size() > 1024*1024+2048 {
+			t.Errorf("file %s not rotated: %d bytes", file, info.Size())
+		}
+	}
+}
+
+func TestFileJournal_RotateOnAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	journal, err := NewFileJournal(path, WithMaxAge(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create FileJournal: %v", err)
+	}
+
+	_ = journal.Info("test", "rotation", "first")
+	_ = journal.Info("test", "rotation", "second")
+	time.Sleep(30 * time.Millisecond)
+	_ = journal.Info("test", "rotation", "third")
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v", backups)
+	}
+	backup, _ := os.ReadFile(backups[0])
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(backup), "second") || strings.Contains(string(current), "second") {
+		t.Errorf("expected the old entries in the backup")
+	}
+	if !strings.Contains(string(current), "third") {
+		t.Errorf("expected the new entry in the current file")
+	}
+}
@@ -1,8 +1,13 @@
 package journal
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,30 +19,156 @@ type Storage interface {
 	Close() error
 }
 
+// FileStorageOption configures a file storage
+type FileStorageOption func(*FileStorage)
+
+// WithMaxSizeMB rotates the file once it reaches the size in megabytes, 0 for no limit
+func WithMaxSizeMB(size int) FileStorageOption {
+	return func(f *FileStorage) {
+		f.maxSize = int64(size) * 1024 * 1024
+	}
+}
+
+// WithMaxAge rotates the file once it has been written to for the duration, 0 for no limit
+func WithMaxAge(age time.Duration) FileStorageOption {
+	return func(f *FileStorage) {
+		f.maxAge = age
+	}
+}
+
+// WithMaxBackups keeps the n most recent rotated files, 0 to keep them all
+func WithMaxBackups(n int) FileStorageOption {
+	return func(f *FileStorage) {
+		f.maxBackups = n
+	}
+}
+
+// backupTimeFormat is the suffix of the rotated files, their order is the one of their names
+const backupTimeFormat = "20060102-150405.000"
+
 // FileStorage 文件存储实现
+// The file is rotated once it crossed the size or the age set by the options: it is renamed with the
+// time of the rotation as suffix, e.g. journal.log.20250102-150405.000, and a new file is opened.
 type FileStorage struct {
 	mu   sync.Mutex
+	path string
 	file *os.File
+
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	size       int64
+	openedAt   time.Time
 }
 
-func NewFileStorage(path string) (*FileStorage, error) {
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
+func NewFileStorage(path string, opts ...FileStorageOption) (*FileStorage, error) {
+	f := &FileStorage{path: path}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if err := f.open(); err != nil {
 		return nil, err
 	}
-	return &FileStorage{file: file}, nil
+	return f, nil
+}
+
+func (f *FileStorage) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// write writes the formatted record, counting the size of the file
+func (f *FileStorage) write(format string, args ...any) error {
+	n, err := fmt.Fprintf(f.file, format, args...)
+	f.size += int64(n)
+	return err
+}
+
+// rotateIfNeeded rotates the file when it crossed its size or its age
+func (f *FileStorage) rotateIfNeeded() error {
+	sizeExceeded := f.maxSize > 0 && f.size >= f.maxSize
+	ageExceeded := f.maxAge > 0 && f.size > 0 && time.Since(f.openedAt) >= f.maxAge
+	if !sizeExceeded && !ageExceeded {
+		return nil
+	}
+
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	backup := f.path + "." + time.Now().Format(backupTimeFormat)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.%s-%d", f.path, time.Now().Format(backupTimeFormat), i)
+	}
+	if err := os.Rename(f.path, backup); err != nil {
+		// keep writing to the current file
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.removeOldBackups()
+}
+
+// removeOldBackups removes the oldest rotated files beyond the backups to keep
+func (f *FileStorage) removeOldBackups() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, f.path+".")
+		if len(suffix) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)]); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > f.maxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
 }
 
 func (f *FileStorage) Write(entry Entry) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	_, err := fmt.Fprintf(f.file, "%s\t%s\t%s\t%s\t%s\n",
+	if err := f.rotateIfNeeded(); err != nil {
+		return err
+	}
+
+	err := f.write("%s\t%s\t%s\t%s\t%s\n",
 		entry.Timestamp.Format(time.RFC3339), entry.Level, entry.Category, entry.Source, entry.Message)
 
 	if entry.Data != nil {
 		for k, v := range entry.Data {
-			_, err = fmt.Fprintf(f.file, "# %s:\n```\n%s\n```\n", k, v)
+			err = f.write("# %s:\n```\n%s\n```\n", k, v)
 			if err != nil {
 				return err
 			}
@@ -50,8 +181,10 @@ func (f *FileStorage) Write(entry Entry) error {
 func (f *FileStorage) WriteUsage(sessionId string, usage map[string]float64) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	_, err := fmt.Fprintf(f.file, "%s\tUSAGE\t%s\t%v\n", time.Now().Format(time.RFC3339), sessionId, usage)
-	return err
+	if err := f.rotateIfNeeded(); err != nil {
+		return err
+	}
+	return f.write("%s\tUSAGE\t%s\t%v\n", time.Now().Format(time.RFC3339), sessionId, usage)
 }
 
 func (f *FileStorage) Close() error {
@@ -88,6 +221,77 @@ func (c *ConsoleStorage) Close() error {
 	return nil
 }
 
+// JSONStorage writes each entry as a JSON object on its own line, e.g. to ingest the journal into
+// observability tools. The objects have the fields timestamp, level, component (the source of the
+// entry), category, message and data, the key/value pairs of the entry; the usage is written as
+// an entry of the category usage, with the session_id and the usage as data.
+type JSONStorage struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewJSONStorage(w io.Writer) *JSONStorage {
+	return &JSONStorage{encoder: json.NewEncoder(w)}
+}
+
+// jsonEntry is an entry written by JSONStorage
+type jsonEntry struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Level     Level          `json:"level"`
+	Component string         `json:"component,omitempty"`
+	Category  string         `json:"category"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+func (s *JSONStorage) Write(entry Entry) error {
+	data := make(map[string]any, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	// keep the values JSON can encode as they are, rather than as text
+	for k, v := range entry.Values {
+		if err, ok := v.(error); ok {
+			data[k] = err.Error()
+			continue
+		}
+		if _, err := json.Marshal(v); err == nil {
+			data[k] = v
+		}
+	}
+	return s.write(&jsonEntry{
+		Timestamp: entry.Timestamp,
+		Level:     entry.Level,
+		Component: entry.Source,
+		Category:  entry.Category,
+		Message:   entry.Message,
+		Data:      data,
+	})
+}
+
+func (s *JSONStorage) WriteUsage(sessionId string, usage map[string]float64) error {
+	return s.write(&jsonEntry{
+		Timestamp: time.Now(),
+		Level:     LevelInfo,
+		Category:  "usage",
+		Message:   "usage accumulated",
+		Data: map[string]any{
+			"session_id": sessionId,
+			"usage":      usage,
+		},
+	})
+}
+
+func (s *JSONStorage) write(entry *jsonEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(entry)
+}
+
+func (s *JSONStorage) Close() error {
+	return nil
+}
+
 // CompositeStorage 组合存储实现
 type CompositeStorage struct {
 	storages []Storage
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -64,6 +65,8 @@ func newChatProvider(opts ...llms.ProviderOption) (llms.ChatProvider, error) {
 	return &anthropicChatProvider{
 		client: &client,
 		debug:  options.Debug,
+
+		retryOptions: options.RetryOptions(),
 	}, nil
 }
 
@@ -73,6 +76,8 @@ var _ llms.ChatProvider = &anthropicChatProvider{}
 type anthropicChatProvider struct {
 	client *anthropic.Client
 	debug  bool
+
+	retryOptions []utils.RetryOption // bounds of the retries of the requests
 }
 
 // Close implements the io.Closer interface.
@@ -99,6 +104,7 @@ func (a *anthropicChatProvider) NewChat(systemPrompt string, model *llms.Model)
 		systemPrompt: systemPrompt,
 		model:        model,
 		debug:        a.debug,
+		retryOptions: a.retryOptions,
 	}, nil
 }
 
@@ -110,6 +116,7 @@ type anthropicChat struct {
 	systemPrompt string
 	model        *llms.Model
 	debug        bool
+	retryOptions []utils.RetryOption
 }
 
 // Send sends messages to the Anthropic API and returns an iterator for responses.
@@ -120,6 +127,10 @@ func (a *anthropicChat) Send(ctx context.Context, messages []*llms.Message, opti
 		opt(opts)
 	}
 
+	if err := llms.CheckContextWindow(a.model, a.systemPrompt, messages, opts); err != nil {
+		return nil, err
+	}
+
 	if opts.Streaming {
 		return a.stream(ctx, messages, opts)
 	} else {
@@ -177,7 +188,7 @@ func (a *anthropicChat) sendOnce(ctx context.Context, messages []*llms.Message,
 				FinishReason: finishReason,
 			}, nil
 		},
-		utils.WithBackOff(utils.NewExponentialBackOff()),
+		slices.Concat(a.retryOptions, opts.RetryPolicy.RetryOptions())...,
 	)
 	if err != nil {
 		return nil, err
@@ -202,11 +213,27 @@ func (a *anthropicChat) stream(ctx context.Context, messages []*llms.Message, op
 
 	messageId := utils.GenerateUUID()
 	acc := anthropic.Message{}
+	emittedToolCalls := map[string]bool{}
+	guard := llms.NewResponseSizeGuard(opts)
 	return func(yield func(*llms.ChatResponse, error) bool) {
 		defer stream.Close()
 
 		for stream.Next() {
 			event := stream.Current()
+			if !guard.Allow(a.eventContentSize(&event)) {
+				// stop accumulating, report the truncated response
+				yield(&llms.ChatResponse{
+					Message: llms.Message{
+						MessageId: messageId,
+						Model:     a.model.ModelId,
+						Creator:   assistant,
+						Timestamp: time.Now(),
+					},
+					Usage:        a.getUsageStats(&acc.Usage),
+					FinishReason: llms.FinishReasonMaxTokens,
+				}, guard.Err())
+				return
+			}
 			acc.Accumulate(event)
 
 			if a.debug {
@@ -224,7 +251,7 @@ func (a *anthropicChat) stream(ctx context.Context, messages []*llms.Message, op
 						MessageId: messageId,
 						Model:     a.model.ModelId,
 						Creator:   assistant,
-						Parts:     []llms.Part{llms.NewTextPartBuilder().Text(event.Delta.Thinking).Build()},
+						Parts:     []llms.Part{llms.NewTextPartBuilder().Text(event.Delta.Thinking).Reasoning(true).Build()},
 						Timestamp: time.Now(),
 					}
 					if !yield(&llms.ChatResponse{
@@ -245,11 +272,49 @@ func (a *anthropicChat) stream(ctx context.Context, messages []*llms.Message, op
 					}, nil) {
 						return
 					}
-				} else if event.Delta.Type == "input_json_delta" {
-					// TODO: support tool use delta
 				}
+				// the input_json_delta of a tool use are assembled into its input by the accumulator
 			case anthropic.ContentBlockStopEvent:
-				// TODO: support tool use stop
+				// emit the tool call as soon as its input is complete
+				if event.Index < 0 || event.Index >= int64(len(acc.Content)) {
+					continue
+				}
+				var toolUse anthropic.ToolUseBlock
+				switch block := acc.Content[event.Index].AsAny().(type) {
+				case anthropic.ThinkingBlock:
+					// the thinking was streamed by its deltas, its signature comes with a reasoning part without text
+					if block.Signature == "" {
+						continue
+					}
+					if !a.yieldReasoning(yield, messageId,
+						llms.NewTextPartBuilder().Reasoning(true).Signature(block.Signature).Build()) {
+						return
+					}
+					continue
+				case anthropic.RedactedThinkingBlock:
+					if !a.yieldReasoning(yield, messageId,
+						llms.NewTextPartBuilder().Text(block.Data).Reasoning(true).Redacted(true).Build()) {
+						return
+					}
+					continue
+				case anthropic.ToolUseBlock:
+					toolUse = block
+				default:
+					continue
+				}
+				emittedToolCalls[toolUse.ID] = true
+				if !yield(&llms.ChatResponse{
+					Message: llms.Message{
+						MessageId: messageId,
+						Model:     a.model.ModelId,
+						Creator:   assistant,
+						Parts:     []llms.Part{a.toToolCall(toolUse)},
+						Timestamp: time.Now(),
+					},
+					FinishReason: llms.FinishReasonToolUse,
+				}, nil) {
+					return
+				}
 
 			case anthropic.MessageStopEvent:
 				// no-ops, handled by the last message
@@ -274,7 +339,8 @@ func (a *anthropicChat) stream(ctx context.Context, messages []*llms.Message, op
 			var toolCallParts []llms.Part
 			for idx := range finalMessage.Parts {
 				part := finalMessage.Parts[idx]
-				if toolCall, ok := part.(*llms.ToolCall); ok {
+				// the tool calls not emitted yet, e.g. their block did not stop
+				if toolCall, ok := part.(*llms.ToolCall); ok && !emittedToolCalls[toolCall.ToolCallId] {
 					toolCallParts = append(toolCallParts, toolCall)
 				}
 			}
@@ -298,6 +364,28 @@ func (a *anthropicChat) stream(ctx context.Context, messages []*llms.Message, op
 	}, nil
 }
 
+// yieldReasoning yields a response holding the reasoning part, it returns false when the iteration stops
+func (a *anthropicChat) yieldReasoning(
+	yield func(*llms.ChatResponse, error) bool, messageId string, part *llms.TextPart) bool {
+	return yield(&llms.ChatResponse{
+		Message: llms.Message{
+			MessageId: messageId,
+			Model:     a.model.ModelId,
+			Creator:   llms.MessageCreator{Role: llms.MessageRoleAssistant},
+			Parts:     []llms.Part{part},
+			Timestamp: time.Now(),
+		},
+	}, nil)
+}
+
+// eventContentSize returns the size of the text, thinking and tool input deltas of a stream event
+func (a *anthropicChat) eventContentSize(event *anthropic.MessageStreamEventUnion) int {
+	if event.Type != "content_block_delta" {
+		return 0
+	}
+	return len(event.Delta.Text) + len(event.Delta.Thinking) + len(event.Delta.PartialJSON)
+}
+
 func (a *anthropicChat) makeMessageFromAnthropicMessage(response *anthropic.Message) (llms.FinishReason, llms.Message) {
 	finishReason := llms.FinishReasonUnknown
 	message := llms.Message{
@@ -313,10 +401,11 @@ func (a *anthropicChat) makeMessageFromAnthropicMessage(response *anthropic.Mess
 			part := llms.NewTextPartBuilder().Text(variant.Text).Build()
 			message.Parts = append(message.Parts, part)
 		case anthropic.ThinkingBlock:
-			part := llms.NewTextPartBuilder().Text(variant.Thinking).Build()
+			part := llms.NewTextPartBuilder().Text(variant.Thinking).Reasoning(true).
+				Signature(variant.Signature).Build()
 			message.Parts = append(message.Parts, part)
 		case anthropic.RedactedThinkingBlock:
-			part := llms.NewTextPartBuilder().Text(variant.Data).Build()
+			part := llms.NewTextPartBuilder().Text(variant.Data).Reasoning(true).Redacted(true).Build()
 			message.Parts = append(message.Parts, part)
 		case anthropic.ToolUseBlock:
 			message.Parts = append(message.Parts, a.toToolCall(variant))
@@ -381,10 +470,9 @@ func (a *anthropicChat) makeMessageNewParams(
 	}
 	if len(anthropicTools) > 0 {
 		params.Tools = anthropicTools
-	}
-
-	if opts.Temperature != nil {
-		params.Temperature = anthropic.Float(*opts.Temperature)
+		if opts.ToolChoice != "" {
+			params.ToolChoice = a.convertToAnthropicToolChoice(opts.ToolChoice)
+		}
 	}
 
 	if opts.TopP != nil {
@@ -397,8 +485,23 @@ func (a *anthropicChat) makeMessageNewParams(
 		params.MaxTokens = a.model.DefaultMaxTokens
 	}
 
+	if len(opts.StopSequences) > 0 {
+		params.StopSequences = opts.StopSequences
+	}
+
 	if a.model.IsSupport(llms.ModelFeatureReasoning) && a.shouldThink(messages, opts) {
-		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(int64(float64(params.MaxTokens) * 0.8))
+		if budget := a.thinkingBudget(opts, params.MaxTokens); budget > 0 {
+			params.Thinking = anthropic.ThinkingConfigParamOfEnabled(budget)
+		}
+	}
+
+	if opts.Temperature != nil {
+		if params.Thinking.OfEnabled != nil {
+			// thinking is not compatible with a custom temperature
+			klog.Warningf("temperature %v ignored, thinking is enabled", *opts.Temperature)
+		} else {
+			params.Temperature = anthropic.Float(*opts.Temperature)
+		}
 	}
 
 	return params, nil
@@ -415,11 +518,30 @@ func (a *anthropicChat) convertToAnthropicMessages(messages []*llms.Message) ([]
 
 		// Convert message parts to content blocks
 		var contentBlocks []anthropic.ContentBlockParamUnion
+		// the signed thinking is sent back ahead of the content, Anthropic requires it before the tool uses
+		var thinkingBlocks []anthropic.ContentBlockParamUnion
+		var thinking strings.Builder
 		for _, part := range msg.Parts {
 			switch part.Type() {
 			case llms.PartTypeText:
-				if textPart, ok := part.(*llms.TextPart); ok {
+				textPart, ok := part.(*llms.TextPart)
+				if !ok {
+					continue
+				}
+				if !textPart.Reasoning {
 					contentBlocks = append(contentBlocks, anthropic.NewTextBlock(textPart.Text))
+					continue
+				}
+				// the streamed thinking is split over several parts, the last one carrying its signature.
+				// The unsigned thinking is not sent back, it would be taken for the answer
+				if textPart.Redacted {
+					thinkingBlocks = append(thinkingBlocks, anthropic.NewRedactedThinkingBlock(textPart.Text))
+					continue
+				}
+				thinking.WriteString(textPart.Text)
+				if textPart.Signature != "" {
+					thinkingBlocks = append(thinkingBlocks, anthropic.NewThinkingBlock(textPart.Signature, thinking.String()))
+					thinking.Reset()
 				}
 			case llms.PartTypeData:
 				if dataPart, ok := part.(*llms.DataPart); ok {
@@ -513,6 +635,13 @@ func (a *anthropicChat) convertToAnthropicMessages(messages []*llms.Message) ([]
 			}
 		}
 
+		if len(contentBlocks) == 0 {
+			continue // e.g. a response holding only reasoning
+		}
+		if msg.Creator.Role == llms.MessageRoleAssistant {
+			contentBlocks = append(thinkingBlocks, contentBlocks...)
+		}
+
 		// Create message based on role
 		var anthropicMsg anthropic.MessageParam
 		switch msg.Creator.Role {
@@ -534,6 +663,20 @@ func (a *anthropicChat) convertToAnthropicMessages(messages []*llms.Message) ([]
 	return anthropicMessages, nil
 }
 
+// convertToAnthropicToolChoice maps the choice to Anthropic, where a required tool call is "any"
+func (a *anthropicChat) convertToAnthropicToolChoice(choice llms.ToolChoice) anthropic.ToolChoiceUnionParam {
+	switch choice {
+	case llms.ToolChoiceAuto:
+		return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	case llms.ToolChoiceNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+	case llms.ToolChoiceRequired:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	default:
+		return anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: string(choice)}}
+	}
+}
+
 func (a *anthropicChat) convertToAnthropicTools(tools []*llms.ToolDescriptor) ([]anthropic.ToolUnionParam, error) {
 	if len(tools) == 0 {
 		return []anthropic.ToolUnionParam{}, nil
@@ -610,11 +753,76 @@ func (a *anthropicChat) isRetryable(err error) bool {
 	return errors.IsRetryableError(err)
 }
 
+// shouldThink tells whether the model thinks before answering: when the options ask for a reasoning effort or budget
 func (a *anthropicChat) shouldThink(messages []*llms.Message, opts *llms.ChatOptions) bool {
-	// TODO check if need thinking
+	// Anthropic rejects the thinking when a tool call is forced
+	if len(opts.Tools) > 0 && (opts.ToolChoice == llms.ToolChoiceRequired || opts.ToolChoice.IsTool()) {
+		return false
+	}
+	if opts.ReasoningEffort == "" && opts.ReasoningBudgetTokens <= 0 {
+		return false
+	}
+	// Anthropic rejects the thinking when the last tool uses are sent back without their signed thinking,
+	// e.g. a history not preserving the reasoning parts
+	if a.hasUnsignedToolUse(messages) {
+		klog.Warningf("thinking disabled, the tool uses of the history are not preceded by a signed thinking")
+		return false
+	}
+	return true
+}
+
+// hasUnsignedToolUse tells whether the last assistant message holds tool calls without a signed or redacted thinking
+func (a *anthropicChat) hasUnsignedToolUse(messages []*llms.Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Creator.Role != llms.MessageRoleAssistant {
+			continue
+		}
+		hasToolCall, hasThinking := false, false
+		for _, part := range messages[i].Parts {
+			switch p := part.(type) {
+			case *llms.ToolCall:
+				hasToolCall = true
+			case *llms.TextPart:
+				if p.Reasoning && (p.Signature != "" || p.Redacted) {
+					hasThinking = true
+				}
+			}
+		}
+		return hasToolCall && !hasThinking
+	}
 	return false
 }
 
+// minThinkingBudget is the smallest thinking budget accepted by Anthropic
+const minThinkingBudget = 1024
+
+// thinkingBudgetRatios is the share of the max tokens of the response the model may think with, per reasoning effort
+var thinkingBudgetRatios = map[llms.ReasoningEffort]float64{
+	llms.ReasoningEffortLow:    0.25,
+	llms.ReasoningEffortMedium: 0.5,
+	llms.ReasoningEffortHigh:   0.8,
+}
+
+// thinkingBudget returns the tokens the model may think with, out of the max tokens of the response:
+// the budget of the options, else a share of the max tokens depending on the reasoning effort.
+// It returns 0 when the budget does not fit the max tokens, the model then does not think.
+func (a *anthropicChat) thinkingBudget(opts *llms.ChatOptions, maxTokens int64) int64 {
+	budget := opts.ReasoningBudgetTokens
+	if budget <= 0 {
+		ratio, ok := thinkingBudgetRatios[opts.ReasoningEffort]
+		if !ok {
+			ratio = thinkingBudgetRatios[llms.ReasoningEffortMedium]
+		}
+		budget = int64(float64(maxTokens) * ratio)
+	}
+	budget = max(budget, minThinkingBudget)
+	if budget >= maxTokens {
+		klog.Warningf("thinking disabled, its budget of %d tokens does not fit the max tokens %d", budget, maxTokens)
+		return 0
+	}
+	return budget
+}
+
 func (a *anthropicChat) makeSystemInstruction(messages []*llms.Message) string {
 	return llms.MakeSystemInstruction(a.systemPrompt, messages)
 }
@@ -0,0 +1,423 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeEvent(w http.ResponseWriter, event, data string) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func TestAnthropicChat_StreamStopsAtMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = writeEvent(w, "message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"model":"claude","stop_reason":null,"usage":{"input_tokens":3,"output_tokens":1}}}`)
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		// an endless stream of 10 bytes deltas, cut by the client
+		for i := 0; i < 1000; i++ {
+			if err := writeEvent(w, "content_block_delta",
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"0123456789"}}`); err != nil {
+				return
+			}
+		}
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":0}`)
+		_ = writeEvent(w, "message_stop", `{"type":"message_stop"}`)
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model, err := llms.DefaultModel(ModelProviderAnthropic)
+	require.NoError(t, err)
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(),
+		[]*llms.Message{llms.NewUserMessage("question")},
+		llms.WithStreaming(true), llms.WithMaxResponseBytes(35))
+	require.NoError(t, err)
+
+	var content string
+	var lastErr error
+	var last *llms.ChatResponse
+	for response, err := range iterator {
+		if err != nil {
+			lastErr = err
+			last = response
+			continue
+		}
+		for _, part := range response.Message.Parts {
+			if text, ok := part.(*llms.TextPart); ok {
+				content += text.Text
+			}
+		}
+	}
+
+	// 3 deltas fit, the 4th one is over the limit
+	assert.Equal(t, strings.Repeat("0123456789", 3), content)
+	require.Error(t, lastErr)
+	assert.True(t, errors.IsCode(lastErr, llms.ErrorCodeResponseTooLarge))
+	require.NotNil(t, last)
+	assert.Equal(t, llms.FinishReasonMaxTokens, last.FinishReason)
+	assert.Equal(t, int64(3), last.Usage.InputTokens)
+}
+
+func TestAnthropicChat_StreamYieldsToolCallsMidStream(t *testing.T) {
+	received := make(chan struct{})
+	midStream := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = writeEvent(w, "message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"model":"claude","stop_reason":null,"usage":{"input_tokens":3,"output_tokens":1}}}`)
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}`)
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":0}`)
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":1,`+
+			`"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`)
+		for _, partial := range []string{`{\"city\"`, `: \"Par`, `is\", \"days\": 3}`} {
+			_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":1,`+
+				`"delta":{"type":"input_json_delta","partial_json":"`+partial+`"}}`)
+		}
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":1}`)
+		w.(http.Flusher).Flush()
+
+		// the rest of the response is only sent once the client got the first tool call
+		select {
+		case <-received:
+			midStream <- true
+		case <-time.After(5 * time.Second):
+			midStream <- false
+		}
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":2,`+
+			`"content_block":{"type":"tool_use","id":"toolu_2","name":"get_time","input":{}}}`)
+		_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":2,`+
+			`"delta":{"type":"input_json_delta","partial_json":"{}"}}`)
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":2}`)
+		_ = writeEvent(w, "message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},`+
+			`"usage":{"output_tokens":42}}`)
+		_ = writeEvent(w, "message_stop", `{"type":"message_stop"}`)
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model, err := llms.DefaultModel(ModelProviderAnthropic)
+	require.NoError(t, err)
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(),
+		[]*llms.Message{llms.NewUserMessage("What is the weather in Paris?")}, llms.WithStreaming(true))
+	require.NoError(t, err)
+
+	var toolCalls []*llms.ToolCall
+	var last *llms.ChatResponse
+	for response, err := range iterator {
+		require.NoError(t, err)
+		last = response
+		for _, part := range response.Message.Parts {
+			if toolCall, ok := part.(*llms.ToolCall); ok {
+				assert.Equal(t, llms.FinishReasonToolUse, response.FinishReason)
+				if len(toolCalls) == 0 {
+					close(received)
+				}
+				toolCalls = append(toolCalls, toolCall)
+			}
+		}
+	}
+
+	assert.True(t, <-midStream, "the tool call was not yielded before the end of the stream")
+	require.Len(t, toolCalls, 2)
+	assert.Equal(t, &llms.ToolCall{
+		ToolCallId: "toolu_1",
+		Name:       "get_weather",
+		Arguments:  map[string]any{"city": "Paris", "days": float64(3)},
+	}, toolCalls[0])
+	assert.Equal(t, &llms.ToolCall{ToolCallId: "toolu_2", Name: "get_time", Arguments: map[string]any{}}, toolCalls[1])
+
+	// the final response reports the usage, without repeating the tool calls
+	require.NotNil(t, last)
+	assert.Empty(t, last.Message.Parts)
+	assert.Equal(t, llms.FinishReasonToolUse, last.FinishReason)
+	assert.Equal(t, int64(42), last.Usage.OutputTokens)
+}
+
+func TestAnthropicChat_ThinkingConfig(t *testing.T) {
+	reasoningModel, haikuModel := AnthropicModels[ModelClaude37Sonnet], AnthropicModels[ModelClaude35Haiku]
+	messages := []*llms.Message{llms.NewUserMessage("question")}
+	makeParams := func(model *llms.Model, options ...llms.ChatOption) *anthropic.MessageNewParams {
+		opts := &llms.ChatOptions{}
+		for _, opt := range options {
+			opt(opts)
+		}
+		params, err := (&anthropicChat{model: model}).makeMessageNewParams(messages, opts)
+		require.NoError(t, err)
+		return params
+	}
+
+	params := makeParams(&reasoningModel, llms.WithReasoningEffort(llms.ReasoningEffortHigh), llms.WithTemperature(0.2))
+	require.NotNil(t, params.Thinking.OfEnabled)
+	assert.Equal(t, int64(40000), params.Thinking.OfEnabled.BudgetTokens)
+	// a custom temperature is not allowed while thinking
+	assert.False(t, params.Temperature.Valid())
+
+	params = makeParams(&reasoningModel, llms.WithReasoningEffort(llms.ReasoningEffortLow))
+	require.NotNil(t, params.Thinking.OfEnabled)
+	assert.Equal(t, int64(12500), params.Thinking.OfEnabled.BudgetTokens)
+
+	params = makeParams(&reasoningModel, llms.WithReasoningBudget(2000))
+	require.NotNil(t, params.Thinking.OfEnabled)
+	assert.Equal(t, int64(2000), params.Thinking.OfEnabled.BudgetTokens)
+
+	// no thinking unless asked for, supported, and fitting the max tokens
+	params = makeParams(&reasoningModel, llms.WithTemperature(0.2))
+	assert.Nil(t, params.Thinking.OfEnabled)
+	assert.Equal(t, 0.2, params.Temperature.Value)
+	assert.Nil(t, makeParams(&haikuModel,
+		llms.WithReasoningEffort(llms.ReasoningEffortHigh)).Thinking.OfEnabled)
+	assert.Nil(t, makeParams(&reasoningModel,
+		llms.WithReasoningEffort(llms.ReasoningEffortHigh), llms.WithMaxCompletionTokens(1000)).Thinking.OfEnabled)
+}
+
+func TestAnthropicChat_StreamTagsThinking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = writeEvent(w, "message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"model":"claude","stop_reason":null,"usage":{"input_tokens":3,"output_tokens":1}}}`)
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`)
+		_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"15 * 23 = 345"}}`)
+		_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`)
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":0}`)
+		_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`)
+		_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"345"}}`)
+		_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":1}`)
+		_ = writeEvent(w, "message_stop", `{"type":"message_stop"}`)
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model := AnthropicModels[ModelClaude37Sonnet]
+	chat, err := provider.NewChat("", &model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(), []*llms.Message{llms.NewUserMessage("What is 15 * 23?")},
+		llms.WithStreaming(true), llms.WithReasoningEffort(llms.ReasoningEffortMedium))
+	require.NoError(t, err)
+
+	var texts []*llms.TextPart
+	for response, err := range iterator {
+		require.NoError(t, err)
+		for _, part := range response.Message.Parts {
+			if text, ok := part.(*llms.TextPart); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	assert.Equal(t, []*llms.TextPart{
+		{Text: "15 * 23 = 345", Reasoning: true},
+		{Reasoning: true, Signature: "sig"},
+		{Text: "345"},
+	}, texts)
+}
+
+func TestAnthropicChat_ReasoningRoundTrip(t *testing.T) {
+	chat := &anthropicChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderAnthropic, ID: "test"}}}
+
+	var response anthropic.Message
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude",`+
+		`"content":[{"type":"thinking","thinking":"15 * 23 = 345","signature":"sig"},`+
+		`{"type":"redacted_thinking","data":"redacted"},{"type":"text","text":"345"}],`+
+		`"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":5}}`), &response))
+
+	_, message := chat.makeMessageFromAnthropicMessage(&response)
+	assert.Equal(t, []llms.Part{
+		&llms.TextPart{Text: "15 * 23 = 345", Reasoning: true, Signature: "sig"},
+		&llms.TextPart{Text: "redacted", Reasoning: true, Redacted: true},
+		&llms.TextPart{Text: "345"},
+	}, message.Parts)
+
+	// the signed and redacted thinking is sent back with the history, the unsigned one is not
+	thinkingOnly := llms.NewAssistantMessage("msg_0", llms.ModelId{}, "hmm")
+	thinkingOnly.Parts[0].(*llms.TextPart).Reasoning = true
+	params, err := chat.convertToAnthropicMessages([]*llms.Message{
+		llms.NewUserMessage("What is 15 * 23?"), thinkingOnly, &message, llms.NewUserMessage("Thanks"),
+	})
+	require.NoError(t, err)
+	require.Len(t, params, 3)
+	require.Len(t, params[1].Content, 3)
+	require.NotNil(t, params[1].Content[0].OfThinking)
+	assert.Equal(t, "15 * 23 = 345", params[1].Content[0].OfThinking.Thinking)
+	assert.Equal(t, "sig", params[1].Content[0].OfThinking.Signature)
+	require.NotNil(t, params[1].Content[1].OfRedactedThinking)
+	assert.Equal(t, "redacted", params[1].Content[1].OfRedactedThinking.Data)
+	assert.Equal(t, "345", params[1].Content[2].OfText.Text)
+}
+
+func TestAnthropicChat_ToolUseReplaysThinking(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = writeEvent(w, "message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"model":"claude","stop_reason":null,"usage":{"input_tokens":3,"output_tokens":1}}}`)
+		if len(requests) == 1 {
+			_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`)
+			for _, delta := range []string{"I need ", "the weather."} {
+				_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,`+
+					`"delta":{"type":"thinking_delta","thinking":"`+delta+`"}}`)
+			}
+			_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`)
+			_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":0}`)
+			_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"encrypted"}}`)
+			_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":1}`)
+			_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":2,`+
+				`"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`)
+			_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":2,`+
+				`"delta":{"type":"input_json_delta","partial_json":"{\"city\": \"Paris\"}"}}`)
+			_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":2}`)
+			_ = writeEvent(w, "message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},`+
+				`"usage":{"output_tokens":42}}`)
+		} else {
+			_ = writeEvent(w, "content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+			_ = writeEvent(w, "content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Sunny."}}`)
+			_ = writeEvent(w, "content_block_stop", `{"type":"content_block_stop","index":0}`)
+		}
+		_ = writeEvent(w, "message_stop", `{"type":"message_stop"}`)
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model := AnthropicModels[ModelClaude37Sonnet]
+	chat, err := provider.NewChat("", &model)
+	require.NoError(t, err)
+	tool := &llms.ToolDescriptor{Name: "get_weather", Description: "Get the weather", Parameters: &llms.Schema{Type: llms.TypeObject}}
+	options := []llms.ChatOption{llms.WithStreaming(true), llms.WithTools(tool), llms.WithReasoningEffort(llms.ReasoningEffortLow)}
+
+	history := []*llms.Message{llms.NewUserMessage("What is the weather in Paris?")}
+	iterator, err := chat.Send(context.Background(), history, options...)
+	require.NoError(t, err)
+	assistant := &llms.Message{Creator: llms.MessageCreator{Role: llms.MessageRoleAssistant}}
+	for response, err := range iterator {
+		require.NoError(t, err)
+		assistant.Parts = append(assistant.Parts, response.Message.Parts...)
+	}
+	history = append(history, assistant, llms.NewToolCallResultMessage(&llms.ToolCallResult{
+		ToolCallId: "toolu_1", Name: "get_weather", Result: map[string]any{"weather": "sunny"},
+	}, time.Now()))
+
+	iterator, err = chat.Send(context.Background(), history, options...)
+	require.NoError(t, err)
+	for _, err := range iterator {
+		require.NoError(t, err)
+	}
+
+	// the signed thinking and the redacted one are replayed ahead of the tool use
+	require.Len(t, requests, 2)
+	assert.NotNil(t, requests[1]["thinking"])
+	messages := requests[1]["messages"].([]any)
+	require.Len(t, messages, 3)
+	content := messages[1].(map[string]any)["content"].([]any)
+	require.Len(t, content, 3)
+	assert.Equal(t, map[string]any{"type": "thinking", "thinking": "I need the weather.", "signature": "sig"}, content[0])
+	assert.Equal(t, map[string]any{"type": "redacted_thinking", "data": "encrypted"}, content[1])
+	assert.Equal(t, "tool_use", content[2].(map[string]any)["type"])
+	assert.Equal(t, "toolu_1", content[2].(map[string]any)["id"])
+}
+
+func TestAnthropicChat_NoThinkingForUnsignedToolUse(t *testing.T) {
+	model := AnthropicModels[ModelClaude37Sonnet]
+	opts := &llms.ChatOptions{}
+	llms.WithReasoningEffort(llms.ReasoningEffortLow)(opts)
+
+	// the history lost the thinking of the tool use, Anthropic would reject it
+	toolCall := &llms.ToolCall{ToolCallId: "toolu_1", Name: "get_weather", Arguments: map[string]any{}}
+	messages := []*llms.Message{
+		llms.NewUserMessage("What is the weather in Paris?"),
+		llms.NewAssistantMessage("msg_1", llms.ModelId{}, "", toolCall),
+		llms.NewToolCallResultMessage(&llms.ToolCallResult{ToolCallId: "toolu_1", Name: "get_weather"}, time.Now()),
+	}
+	params, err := (&anthropicChat{model: &model}).makeMessageNewParams(messages, opts)
+	require.NoError(t, err)
+	assert.Nil(t, params.Thinking.OfEnabled)
+
+	messages[1].Parts = append([]llms.Part{
+		llms.NewTextPartBuilder().Text("I need the weather.").Reasoning(true).Signature("sig").Build(),
+	}, messages[1].Parts...)
+	params, err = (&anthropicChat{model: &model}).makeMessageNewParams(messages, opts)
+	require.NoError(t, err)
+	assert.NotNil(t, params.Thinking.OfEnabled)
+}
+
+func TestAnthropicChat_StopSequences(t *testing.T) {
+	model := AnthropicModels[ModelClaude35Haiku]
+	opts := &llms.ChatOptions{}
+	llms.WithStopSequences("Observation:")(opts)
+
+	params, err := (&anthropicChat{model: &model}).makeMessageNewParams(
+		[]*llms.Message{llms.NewUserMessage("question")}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Observation:"}, params.StopSequences)
+}
+
+func TestAnthropicChat_ToolChoice(t *testing.T) {
+	haikuModel, reasoningModel := AnthropicModels[ModelClaude35Haiku], AnthropicModels[ModelClaude37Sonnet]
+	messages := []*llms.Message{llms.NewUserMessage("question")}
+	tool := &llms.ToolDescriptor{
+		Name:        "get_weather",
+		Description: "Get the weather",
+		Parameters:  &llms.Schema{Type: llms.TypeObject},
+	}
+	makeParams := func(model *llms.Model, options ...llms.ChatOption) *anthropic.MessageNewParams {
+		opts := &llms.ChatOptions{}
+		for _, opt := range options {
+			opt(opts)
+		}
+		params, err := (&anthropicChat{model: model}).makeMessageNewParams(messages, opts)
+		require.NoError(t, err)
+		return params
+	}
+
+	params := makeParams(&haikuModel, llms.WithTools(tool), llms.WithToolChoice(llms.ToolChoiceAuto))
+	assert.NotNil(t, params.ToolChoice.OfAuto)
+	params = makeParams(&haikuModel, llms.WithTools(tool), llms.WithToolChoice(llms.ToolChoiceNone))
+	assert.NotNil(t, params.ToolChoice.OfNone)
+	params = makeParams(&haikuModel, llms.WithTools(tool), llms.WithToolChoice(llms.ToolChoiceRequired))
+	assert.NotNil(t, params.ToolChoice.OfAny)
+	params = makeParams(&haikuModel, llms.WithTools(tool), llms.WithToolChoice("get_weather"))
+	require.NotNil(t, params.ToolChoice.OfTool)
+	assert.Equal(t, "get_weather", params.ToolChoice.OfTool.Name)
+
+	// the provider default without a choice or without tools
+	params = makeParams(&haikuModel, llms.WithTools(tool))
+	assert.Nil(t, params.ToolChoice.GetType())
+	params = makeParams(&haikuModel, llms.WithToolChoice(llms.ToolChoiceRequired))
+	assert.Nil(t, params.ToolChoice.GetType())
+
+	// no thinking while a tool call is forced
+	params = makeParams(&reasoningModel, llms.WithReasoningEffort(llms.ReasoningEffortLow),
+		llms.WithTools(tool), llms.WithToolChoice("get_weather"))
+	assert.Nil(t, params.Thinking.OfEnabled)
+	params = makeParams(&reasoningModel, llms.WithReasoningEffort(llms.ReasoningEffortLow),
+		llms.WithTools(tool), llms.WithToolChoice(llms.ToolChoiceAuto))
+	assert.NotNil(t, params.Thinking.OfEnabled)
+}
@@ -11,6 +11,8 @@ import (
 const (
 	// ModelProviderAnthropic is the provider identifier for Anthropic models.
 	ModelProviderAnthropic llms.ModelProvider = "anthropic"
+	// DefaultAnthropicModel is the default Anthropic model declared to the model registry.
+	DefaultAnthropicModel = ModelClaude4Sonnet
 
 	// Anthropic model identifiers
 	ModelClaude35Sonnet = "claude-3.5-sonnet" // Claude 3.5 Sonnet model
@@ -39,6 +41,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       15.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   5000,
+		MaxOutputTokens:    8192,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
@@ -57,6 +60,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       1.25,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   4096,
+		MaxOutputTokens:    4096,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
@@ -75,6 +79,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       15.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    64000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
@@ -94,6 +99,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       4.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   4096,
+		MaxOutputTokens:    8192,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
@@ -112,6 +118,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       75.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   4096,
+		MaxOutputTokens:    4096,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
@@ -130,6 +137,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       15.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    64000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
@@ -149,6 +157,7 @@ var AnthropicModels = map[string]llms.Model{
 		CostPer1MOut:       75.0,
 		ContextWindowSize:  200000,
 		DefaultMaxTokens:   4096,
+		MaxOutputTokens:    32000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
@@ -165,4 +174,7 @@ func init() {
 			klog.Warningf("Failed to register Anthropic model %s: %v", model.ModelId.ID, err)
 		}
 	}
+	if err := llms.RegisterDefaultModel(llms.ModelId{Provider: ModelProviderAnthropic, ID: DefaultAnthropicModel}); err != nil {
+		klog.Warningf("Failed to register default Anthropic model: %v", err)
+	}
 }
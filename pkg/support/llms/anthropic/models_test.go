@@ -153,3 +153,25 @@ func TestAnthropicModels_DefaultMaxTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestAnthropicModels_MaxOutputTokens(t *testing.T) {
+	// Test that completion models declare how many tokens they can generate
+	for modelID, model := range AnthropicModels {
+		if !model.IsSupport(llms.ModelFeatureCompletion) {
+			continue
+		}
+		t.Run(modelID, func(t *testing.T) {
+			assert.Greater(t, model.MaxOutputTokens, int64(0))
+			assert.Less(t, model.MaxOutputTokens, model.ContextWindowSize)
+			assert.LessOrEqual(t, model.DefaultMaxTokens, model.MaxOutputTokens)
+		})
+	}
+}
+
+func TestAnthropicModels_DefaultModel(t *testing.T) {
+	model, err := llms.DefaultModel(ModelProviderAnthropic)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultAnthropicModel, model.ModelId.ID)
+	assert.Equal(t, ModelProviderAnthropic, model.ModelId.Provider)
+	assert.True(t, model.IsSupport(llms.ModelFeatureCompletion))
+}
@@ -0,0 +1,216 @@
+package llms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// Cache stores the responses of the chats by the keys of their requests, see NewCachingChat.
+// It can be backed by an external store, e.g. Redis or a file, to share the responses between runs.
+type Cache interface {
+	// Get returns the response stored for the key, false when there is none
+	Get(ctx context.Context, key string) (*ChatResponse, bool, error)
+	// Set stores the response for the key
+	Set(ctx context.Context, key string, response *ChatResponse) error
+}
+
+// NewInMemoryCache creates a cache holding the responses in memory, for the lifetime of the process
+func NewInMemoryCache() *InMemoryCache {
+	return &InMemoryCache{responses: make(map[string]*ChatResponse)}
+}
+
+var _ Cache = &InMemoryCache{}
+
+// InMemoryCache is a Cache holding the responses in a map
+type InMemoryCache struct {
+	lock      sync.RWMutex
+	responses map[string]*ChatResponse
+}
+
+func (c *InMemoryCache) Get(_ context.Context, key string) (*ChatResponse, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	response, ok := c.responses[key]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := *response
+	return &copied, true, nil
+}
+
+func (c *InMemoryCache) Set(_ context.Context, key string, response *ChatResponse) error {
+	copied := *response
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.responses[key] = &copied
+	return nil
+}
+
+// Len returns the number of the responses in the cache
+func (c *InMemoryCache) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.responses)
+}
+
+// CachingChatOption configures a chat created by NewCachingChat
+type CachingChatOption func(c *cachingChat)
+
+// WithCacheScope sets the scope of the keys of the chat, e.g. its model and its system prompt,
+// which the chat cannot see itself: the chats sharing a cache must have different scopes unless
+// they answer the same requests alike.
+func WithCacheScope(scope string) CachingChatOption {
+	return func(c *cachingChat) {
+		c.scope = scope
+	}
+}
+
+// WithForcedCaching caches the responses whatever the temperature of the requests, e.g. to
+// replay the sampled responses of a model in tests.
+func WithForcedCaching() CachingChatOption {
+	return func(c *cachingChat) {
+		c.forced = true
+	}
+}
+
+// NewCachingChat wraps the chat so that an identical request returns the response cached for it
+// rather than paying for it again: the key of a request hashes its messages and its options.
+// Only the deterministic requests, with a temperature of 0, are cached unless WithForcedCaching
+// is given, and streaming requests never are.
+func NewCachingChat(inner Chat, cache Cache, opts ...CachingChatOption) Chat {
+	c := &cachingChat{inner: inner, cache: cache}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type cachingChat struct {
+	inner  Chat
+	cache  Cache
+	scope  string
+	forced bool
+}
+
+func (c *cachingChat) Send(ctx context.Context, messages []*Message, options ...ChatOption) (ChatResponseIterator, error) {
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if !c.cacheable(opts) {
+		return c.inner.Send(ctx, messages, options...)
+	}
+	key, err := c.key(messages, opts)
+	if err != nil {
+		klog.Warningf("failed to compute the cache key of the request, not cached: %v", err)
+		return c.inner.Send(ctx, messages, options...)
+	}
+
+	cached, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		klog.Warningf("failed to get the cached response: %v", err)
+	} else if ok {
+		return func(yield func(*ChatResponse, error) bool) {
+			yield(cached, nil)
+		}, nil
+	}
+
+	responses, err := c.inner.Send(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// the response is cached once consumed, when the request succeeded with a single response
+	return func(yield func(*ChatResponse, error) bool) {
+		var received []*ChatResponse
+		failed := false
+		for response, err := range responses {
+			if err != nil {
+				failed = true
+			} else {
+				received = append(received, response)
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+		if failed || len(received) != 1 || received[0] == nil {
+			return
+		}
+		if err := c.cache.Set(ctx, key, received[0]); err != nil {
+			klog.Warningf("failed to cache the response: %v", err)
+		}
+	}, nil
+}
+
+// cacheable tells whether the response of the request can be cached
+func (c *cachingChat) cacheable(opts *ChatOptions) bool {
+	if opts.Streaming {
+		return false
+	}
+	return c.forced || (opts.Temperature != nil && *opts.Temperature == 0)
+}
+
+// cacheKey holds what makes the response of a request, leaving out the settings of the transport
+// of the request, e.g. its retries
+type cacheKey struct {
+	Scope                 string                             `json:"scope,omitempty"`
+	Messages              []json.RawMessage                  `json:"messages"`
+	Temperature           *float64                           `json:"temperature,omitempty"`
+	TopP                  *float64                           `json:"top_p,omitempty"`
+	FrequencyPenalty      *float64                           `json:"frequency_penalty,omitempty"`
+	PresencePenalty       *float64                           `json:"presence_penalty,omitempty"`
+	MaxCompletionTokens   *int64                             `json:"max_completion_tokens,omitempty"`
+	ReasoningEffort       ReasoningEffort                    `json:"reasoning_effort,omitempty"`
+	ReasoningBudgetTokens int64                              `json:"reasoning_budget_tokens,omitempty"`
+	N                     *int                               `json:"n,omitempty"`
+	StopSequences         []string                           `json:"stop_sequences,omitempty"`
+	Tools                 []*ToolDescriptor                  `json:"tools,omitempty"`
+	ToolChoice            ToolChoice                         `json:"tool_choice,omitempty"`
+	ResponseFormat        *ResponseFormat                    `json:"response_format,omitempty"`
+	SafetySettings        map[SafetyCategory]SafetyThreshold `json:"safety_settings,omitempty"`
+}
+
+// key hashes the messages, without their identifiers and timestamps, and the options of the request
+func (c *cachingChat) key(messages []*Message, opts *ChatOptions) (string, error) {
+	codec := NewJsonCodec()
+	key := cacheKey{
+		Scope:                 c.scope,
+		Messages:              make([]json.RawMessage, 0, len(messages)),
+		Temperature:           opts.Temperature,
+		TopP:                  opts.TopP,
+		FrequencyPenalty:      opts.FrequencyPenalty,
+		PresencePenalty:       opts.PresencePenalty,
+		MaxCompletionTokens:   opts.MaxCompletionTokens,
+		ReasoningEffort:       opts.ReasoningEffort,
+		ReasoningBudgetTokens: opts.ReasoningBudgetTokens,
+		N:                     opts.N,
+		StopSequences:         opts.StopSequences,
+		Tools:                 opts.Tools,
+		ToolChoice:            opts.ToolChoice,
+		ResponseFormat:        opts.ResponseFormat,
+		SafetySettings:        opts.SafetySettings,
+	}
+	for _, message := range messages {
+		encoded, err := codec.Encode(&Message{
+			Creator: message.Creator,
+			Model:   message.Model,
+			Parts:   message.Parts,
+		})
+		if err != nil {
+			return "", err
+		}
+		key.Messages = append(key.Messages, encoded)
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,114 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingChat answers each request with a new response, counting the requests
+type countingChat struct {
+	calls int
+	err   error
+}
+
+func (c *countingChat) Send(ctx context.Context, messages []*Message, options ...ChatOption) (ChatResponseIterator, error) {
+	c.calls++
+	calls := c.calls
+	return func(yield func(*ChatResponse, error) bool) {
+		if c.err != nil {
+			yield(nil, c.err)
+			return
+		}
+		yield(&ChatResponse{
+			Message:      Message{Parts: []Part{&TextPart{Text: fmt.Sprintf("answer %d", calls)}}},
+			Usage:        UsageMetadata{InputTokens: 10, OutputTokens: 2},
+			FinishReason: FinishReasonNormalEnd,
+		}, nil)
+	}, nil
+}
+
+func sendText(t *testing.T, chat Chat, text string, options ...ChatOption) string {
+	responses, err := chat.Send(context.Background(), []*Message{NewUserMessage(text)}, options...)
+	require.NoError(t, err)
+	var answer string
+	for response, err := range responses {
+		require.NoError(t, err)
+		answer += response.Message.Parts[0].(*TextPart).Text
+	}
+	return answer
+}
+
+func TestCachingChat(t *testing.T) {
+	inner := &countingChat{}
+	cache := NewInMemoryCache()
+	chat := NewCachingChat(inner, cache)
+
+	assert.Equal(t, "answer 1", sendText(t, chat, "question", WithTemperature(0)))
+	// the identical request is answered from the cache, though its message has another timestamp
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, "answer 1", sendText(t, chat, "question", WithTemperature(0)))
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, 1, cache.Len())
+
+	// other messages or options are other requests
+	assert.Equal(t, "answer 2", sendText(t, chat, "another question", WithTemperature(0)))
+	assert.Equal(t, "answer 3", sendText(t, chat, "question", WithTemperature(0), WithMaxCompletionTokens(10)))
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestCachingChat_Bypassed(t *testing.T) {
+	inner := &countingChat{}
+	chat := NewCachingChat(inner, NewInMemoryCache())
+
+	// the temperature is not 0, or the provider default
+	sendText(t, chat, "question", WithTemperature(0.7))
+	sendText(t, chat, "question", WithTemperature(0.7))
+	sendText(t, chat, "question")
+	sendText(t, chat, "question")
+	// streaming
+	sendText(t, chat, "question", WithTemperature(0), WithStreaming(true))
+	sendText(t, chat, "question", WithTemperature(0), WithStreaming(true))
+	assert.Equal(t, 6, inner.calls)
+}
+
+func TestCachingChat_Forced(t *testing.T) {
+	inner := &countingChat{}
+	chat := NewCachingChat(inner, NewInMemoryCache(), WithForcedCaching())
+
+	assert.Equal(t, "answer 1", sendText(t, chat, "question", WithTemperature(0.7)))
+	assert.Equal(t, "answer 1", sendText(t, chat, "question", WithTemperature(0.7)))
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestCachingChat_Scope(t *testing.T) {
+	inner := &countingChat{}
+	cache := NewInMemoryCache()
+	pirate := NewCachingChat(inner, cache, WithCacheScope("gpt-4o/You are a pirate"))
+	poet := NewCachingChat(inner, cache, WithCacheScope("gpt-4o/You are a poet"))
+
+	assert.Equal(t, "answer 1", sendText(t, pirate, "question", WithTemperature(0)))
+	assert.Equal(t, "answer 2", sendText(t, poet, "question", WithTemperature(0)))
+	assert.Equal(t, "answer 1", sendText(t, pirate, "question", WithTemperature(0)))
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestCachingChat_FailureNotCached(t *testing.T) {
+	inner := &countingChat{err: fmt.Errorf("unavailable")}
+	cache := NewInMemoryCache()
+	chat := NewCachingChat(inner, cache)
+
+	for i := 0; i < 2; i++ {
+		responses, err := chat.Send(context.Background(), []*Message{NewUserMessage("question")}, WithTemperature(0))
+		require.NoError(t, err)
+		for _, err := range responses {
+			assert.EqualError(t, err, "unavailable")
+		}
+	}
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, 0, cache.Len())
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"time"
 
 	"github.com/oopslink/agent-go/pkg/commons/utils"
 )
@@ -29,11 +30,28 @@ type ProviderOptions struct {
 	SkipVerifySSL           bool   // Whether to skip SSL certificate verification
 	Debug                   bool   // Whether to enable Debug logging
 	OpenaiCompatibilityMode bool   // Whether to enable openai compatibility mode
+
+	RetryMaxAttempts uint          // Maximum number of attempts of a request, 0 for no limit
+	RetryMaxElapsed  time.Duration // Maximum total time of the retries of a request, 0 for utils.DefaultMaxElapsedTime, negative for no limit
 }
 
 func (o *ProviderOptions) String() string {
-	return fmt.Sprintf("BaseUrl: %s, ApiKey: %s, SkipVerifySSL: %t, Debug: %t, OpenaiCompatibilityMode: %t",
-		o.BaseUrl, utils.Sensitive(o.ApiKey, "***", 3, 3), o.SkipVerifySSL, o.Debug, o.OpenaiCompatibilityMode)
+	return fmt.Sprintf("BaseUrl: %s, ApiKey: %s, SkipVerifySSL: %t, Debug: %t, OpenaiCompatibilityMode: %t, RetryMaxAttempts: %d, RetryMaxElapsed: %s",
+		o.BaseUrl, utils.Sensitive(o.ApiKey, "***", 3, 3), o.SkipVerifySSL, o.Debug, o.OpenaiCompatibilityMode,
+		o.RetryMaxAttempts, o.RetryMaxElapsed)
+}
+
+// RetryOptions returns the options of utils.Retry bounding the retries of the requests of the
+// provider. The options of the RetryPolicy of a chat follow them and take precedence.
+func (o *ProviderOptions) RetryOptions() []utils.RetryOption {
+	var options []utils.RetryOption
+	if o.RetryMaxAttempts > 0 {
+		options = append(options, utils.WithMaxTries(o.RetryMaxAttempts))
+	}
+	if o.RetryMaxElapsed != 0 {
+		options = append(options, utils.WithMaxElapsedTime(max(o.RetryMaxElapsed, 0)))
+	}
+	return options
 }
 
 // WithBaseUrl sets the base URL for the provider's API.
@@ -72,6 +90,21 @@ func OpenAICompatibilityMode() ProviderOption {
 	}
 }
 
+// WithRetryMaxAttempts limits the number of attempts of a request, the first one included,
+// so that a persistently failing endpoint is not retried until the maximum elapsed time.
+func WithRetryMaxAttempts(attempts uint) ProviderOption {
+	return func(p *ProviderOptions) {
+		p.RetryMaxAttempts = attempts
+	}
+}
+
+// WithRetryMaxElapsed limits the total time of the retries of a request, negative for no limit.
+func WithRetryMaxElapsed(elapsed time.Duration) ProviderOption {
+	return func(p *ProviderOptions) {
+		p.RetryMaxElapsed = elapsed
+	}
+}
+
 // ChatProvider is the main interface for AI model providers.
 // It defines the contract that all provider implementations must fulfill.
 type ChatProvider interface {
@@ -115,12 +148,113 @@ type ChatOptions struct {
 	MaxCompletionTokens *int64
 	// ReasoningEffort specifies the level of reasoning effort required
 	ReasoningEffort ReasoningEffort
+	// ReasoningBudgetTokens is the number of tokens the model may reason with, for the providers
+	// budgeting the reasoning, e.g. Anthropic: it overrides the budget derived from ReasoningEffort
+	ReasoningBudgetTokens int64
+	// How many chat completion choices to generate for each request. When greater
+	// than 1 all choices are returned in ChatResponse.Choices, which is useful for
+	// self-consistency or best-of sampling without multiple round-trips.
+	// Streaming responses only carry choice 0, except the final response of Gemini
+	// which carries all the accumulated choices.
+	N *int
+	// Up to 4 sequences where the model stops generating further tokens, e.g. "Observation:"
+	// for the ReAct loops. The returned text does not contain the stop sequence.
+	StopSequences []string
 
 	// Tools defines the tools available for the chat session
 	Tools []*ToolDescriptor
+	// ToolChoice controls whether the model calls the tools: ToolChoiceAuto, ToolChoiceNone,
+	// ToolChoiceRequired or the name of the tool it must call. Empty for the provider default,
+	// ignored without Tools.
+	ToolChoice ToolChoice
+	// ResponseFormat constrains the format of the answers of the model, nil for free-form text.
+	// It needs a model supporting ModelFeatureStructuredOutput.
+	ResponseFormat *ResponseFormat
+	// SafetySettings sets the threshold blocking the content of each safety category, for the
+	// providers filtering the content, e.g. Gemini. The categories left out keep the provider default.
+	SafetySettings map[SafetyCategory]SafetyThreshold
 
 	// Streaming enables streaming responses from the model
 	Streaming bool
+	// MaxResponseBytes caps the content accumulated from a streamed response: 0 for
+	// DefaultMaxResponseBytes, negative for no limit. See ResponseSizeGuard.
+	MaxResponseBytes int64
+	// RetryPolicy configures the retries of failed requests, nil for the default backoff
+	RetryPolicy *RetryPolicy
+	// TokenCounter enables checking that requests fit the context window of the model
+	// before sending them, see CheckContextWindow. Nil disables the check.
+	TokenCounter TokenCounter
+}
+
+// ToolChoice controls whether the model calls the tools, a mode or the name of a tool
+type ToolChoice string
+
+const (
+	ToolChoiceAuto     ToolChoice = "auto"     // The model decides whether to call tools
+	ToolChoiceNone     ToolChoice = "none"     // The model does not call any tool
+	ToolChoiceRequired ToolChoice = "required" // The model calls at least one tool
+)
+
+// IsTool tells whether the choice is the name of the tool the model must call rather than a mode
+func (c ToolChoice) IsTool() bool {
+	return c != "" && c != ToolChoiceAuto && c != ToolChoiceNone && c != ToolChoiceRequired
+}
+
+// SafetyCategory is a category of harmful content filtered by the provider. Besides the common
+// categories below, the name of a category of the provider, e.g. "HARM_CATEGORY_CIVIC_INTEGRITY"
+// for Gemini, is passed through.
+type SafetyCategory string
+
+const (
+	SafetyCategoryHarassment       SafetyCategory = "harassment"
+	SafetyCategoryHateSpeech       SafetyCategory = "hate_speech"
+	SafetyCategorySexuallyExplicit SafetyCategory = "sexually_explicit"
+	SafetyCategoryDangerousContent SafetyCategory = "dangerous_content"
+)
+
+// SafetyThreshold is the probability of harm from which the content of a category is blocked
+type SafetyThreshold string
+
+const (
+	SafetyThresholdBlockNone           SafetyThreshold = "block_none"             // Never blocked
+	SafetyThresholdBlockOnlyHigh       SafetyThreshold = "block_only_high"        // Blocked when the harm is highly probable
+	SafetyThresholdBlockMediumAndAbove SafetyThreshold = "block_medium_and_above" // Blocked from a medium probability
+	SafetyThresholdBlockLowAndAbove    SafetyThreshold = "block_low_and_above"    // Blocked from a low probability
+)
+
+// ResponseFormatType is the format the model answers with
+type ResponseFormatType string
+
+const (
+	ResponseFormatText       ResponseFormatType = "text"        // Free-form text
+	ResponseFormatJSONObject ResponseFormatType = "json_object" // Any valid JSON object
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema" // A JSON value matching a schema
+)
+
+// ResponseFormat constrains the format of the answers of the model
+type ResponseFormat struct {
+	Type ResponseFormatType
+	// Name of the schema, for the providers naming it, e.g. OpenAI
+	Name string
+	// JSONSchema is the schema the answers match, for ResponseFormatJSONSchema
+	JSONSchema *Schema
+}
+
+// WithResponseFormat constrains the format of the answers of the model.
+func WithResponseFormat(format *ResponseFormat) ChatOption {
+	return func(p *ChatOptions) {
+		p.ResponseFormat = format
+	}
+}
+
+// WithJSONObjectOutput makes the model answer with a valid JSON object.
+func WithJSONObjectOutput() ChatOption {
+	return WithResponseFormat(&ResponseFormat{Type: ResponseFormatJSONObject})
+}
+
+// WithJSONSchemaOutput makes the model answer with a JSON value matching the schema.
+func WithJSONSchemaOutput(name string, schema *Schema) ChatOption {
+	return WithResponseFormat(&ResponseFormat{Type: ResponseFormatJSONSchema, Name: name, JSONSchema: schema})
 }
 
 // WithTemperature sets the sampling temperature for the chat session.
@@ -158,6 +292,20 @@ func WithMaxCompletionTokens(maxCompletionTokens int64) ChatOption {
 	}
 }
 
+// WithN sets how many chat completion choices to generate for each request.
+func WithN(n int) ChatOption {
+	return func(p *ChatOptions) {
+		p.N = &n
+	}
+}
+
+// WithStopSequences sets the sequences where the model stops generating further tokens.
+func WithStopSequences(stopSequences ...string) ChatOption {
+	return func(p *ChatOptions) {
+		p.StopSequences = append(p.StopSequences, stopSequences...)
+	}
+}
+
 // WithStreaming enables or disables streaming responses.
 func WithStreaming(streaming bool) ChatOption {
 	return func(p *ChatOptions) {
@@ -165,6 +313,28 @@ func WithStreaming(streaming bool) ChatOption {
 	}
 }
 
+// WithMaxResponseBytes caps the content accumulated from a streamed response, negative for no limit.
+func WithMaxResponseBytes(maxResponseBytes int64) ChatOption {
+	return func(p *ChatOptions) {
+		p.MaxResponseBytes = maxResponseBytes
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried.
+func WithRetryPolicy(policy RetryPolicy) ChatOption {
+	return func(p *ChatOptions) {
+		p.RetryPolicy = &policy
+	}
+}
+
+// WithTokenCounter checks that requests fit the context window of the model before sending
+// them, counting their tokens with the counter, e.g. EstimateTokenCounter.
+func WithTokenCounter(counter TokenCounter) ChatOption {
+	return func(p *ChatOptions) {
+		p.TokenCounter = counter
+	}
+}
+
 // WithReasoningEffort sets the reasoning effort level for the chat session.
 func WithReasoningEffort(reasoningEffort ReasoningEffort) ChatOption {
 	return func(p *ChatOptions) {
@@ -172,6 +342,13 @@ func WithReasoningEffort(reasoningEffort ReasoningEffort) ChatOption {
 	}
 }
 
+// WithReasoningBudget sets the number of tokens the model may reason with, enabling its reasoning.
+func WithReasoningBudget(tokens int64) ChatOption {
+	return func(p *ChatOptions) {
+		p.ReasoningBudgetTokens = tokens
+	}
+}
+
 // WithTools adds tools to the chat session.
 func WithTools(tools ...*ToolDescriptor) ChatOption {
 	return func(p *ChatOptions) {
@@ -179,6 +356,25 @@ func WithTools(tools ...*ToolDescriptor) ChatOption {
 	}
 }
 
+// WithToolChoice controls whether the model calls the tools, e.g. ToolChoiceNone to disable them
+// for one turn or ToolChoice("search") to make the model call the search tool.
+func WithToolChoice(choice ToolChoice) ChatOption {
+	return func(p *ChatOptions) {
+		p.ToolChoice = choice
+	}
+}
+
+// WithSafetySetting sets the threshold blocking the content of the safety category, e.g. to
+// relax the filtering of legitimate content the provider blocks.
+func WithSafetySetting(category SafetyCategory, threshold SafetyThreshold) ChatOption {
+	return func(p *ChatOptions) {
+		if p.SafetySettings == nil {
+			p.SafetySettings = make(map[SafetyCategory]SafetyThreshold)
+		}
+		p.SafetySettings[category] = threshold
+	}
+}
+
 // Chat represents a chat session with an AI model.
 // It provides methods for sending messages and receiving responses.
 type Chat interface {
@@ -196,4 +392,19 @@ type ChatResponse struct {
 	Message                    // The response message
 	Usage        UsageMetadata // Token usage information
 	FinishReason FinishReason  // Why the response generation finished
+
+	// BlockedCategories holds the safety categories the request or the response was blocked for,
+	// with FinishReasonDenied, see ChatOptions.SafetySettings.
+	BlockedCategories []SafetyCategory
+
+	// Choices holds all choices when more than one was requested, see ChatOptions.N.
+	// Choice 0 is also the primary Message and FinishReason of the response.
+	Choices []*ChatChoice
+}
+
+// ChatChoice is one of several alternative completions generated for a request.
+type ChatChoice struct {
+	Index        int          // Index of the choice in the provider response
+	Message      Message      // The choice message
+	FinishReason FinishReason // Why the choice generation finished
 }
@@ -95,6 +95,16 @@ func TestChatOptions(t *testing.T) {
 		t.Errorf("WithMaxCompletionTokens: MaxCompletionTokens = %v, want %v", *opts.MaxCompletionTokens, maxTokens)
 	}
 
+	// Test WithN
+	opts = &ChatOptions{}
+	WithN(3)(opts)
+	if opts.N == nil {
+		t.Error("WithN: N should not be nil")
+	}
+	if *opts.N != 3 {
+		t.Errorf("WithN: N = %v, want %v", *opts.N, 3)
+	}
+
 	// Test WithStreaming
 	opts = &ChatOptions{}
 	WithStreaming(true)(opts)
@@ -60,9 +60,19 @@ func (c *JsonCodec) Encode(message *Message) ([]byte, error) {
 
 		switch p := part.(type) {
 		case *TextPart:
-			serializablePart.Content = map[string]interface{}{
+			content := map[string]interface{}{
 				"text": p.Text,
 			}
+			if p.Reasoning {
+				content["reasoning"] = true
+			}
+			if p.Signature != "" {
+				content["signature"] = p.Signature
+			}
+			if p.Redacted {
+				content["redacted"] = true
+			}
+			serializablePart.Content = content
 		case *DataPart:
 			serializablePart.Content = map[string]interface{}{
 				"data": p.Data,
@@ -140,7 +150,10 @@ func (c *JsonCodec) deserializePart(sp serializablePart) (Part, error) {
 		if !ok {
 			return nil, fmt.Errorf("invalid text part content")
 		}
-		return &TextPart{Text: text}, nil
+		reasoning, _ := content["reasoning"].(bool)
+		signature, _ := content["signature"].(string)
+		redacted, _ := content["redacted"].(bool)
+		return &TextPart{Text: text, Reasoning: reasoning, Signature: signature, Redacted: redacted}, nil
 
 	case PartTypeData:
 		data, ok := content["data"].(map[string]interface{})
@@ -384,3 +384,29 @@ func TestJsonCodec_DecodeBinaryPartVariations(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestJsonCodec_ReasoningTextPart(t *testing.T) {
+	codec := NewJsonCodec()
+	msg := &Message{
+		MessageId: "msg-1",
+		Creator:   MessageCreator{Role: MessageRoleAssistant},
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+		Parts: []Part{
+			&TextPart{Text: "15 * 23 = 345", Reasoning: true, Signature: "sig-1"},
+			&TextPart{Text: "encrypted", Reasoning: true, Redacted: true},
+			&TextPart{Text: "345"},
+		},
+	}
+
+	data, err := codec.Encode(msg)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(msg.Parts, decoded.Parts) {
+		t.Errorf("Decoded parts mismatch.\nGot: %#v\nWant: %#v", decoded.Parts, msg.Parts)
+	}
+}
@@ -0,0 +1,171 @@
+package llms
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+// messageOverheadTokens is the tokens a message costs besides its content, e.g. its role
+const messageOverheadTokens = 4
+
+var _ errors.WithErrorCode = &ContextWindowExceededError{}
+
+// TokenCounter counts the tokens of a text as the model would tokenize it
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+// EstimateTokenCounter estimates a token every 4 characters, a fair approximation for
+// English text with most tokenizers when the exact tokenizer of the model is not at hand.
+type EstimateTokenCounter struct{}
+
+func (EstimateTokenCounter) CountTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// ContextWindowExceededError reports a request estimated not to fit the context window of the model
+type ContextWindowExceededError struct {
+	Model     ModelId
+	Estimated int64 // Estimated tokens of the request, reserved output tokens included
+	Allowed   int64 // Context window size of the model
+}
+
+func (e *ContextWindowExceededError) Error() string {
+	code := e.GetCode()
+	return fmt.Sprintf("[ERR,%s]: request to %s needs an estimated %d tokens, the context window allows %d",
+		code.String(), e.Model.String(), e.Estimated, e.Allowed)
+}
+
+func (e *ContextWindowExceededError) GetCode() errors.ErrorCode {
+	return ErrorCodeContextWindowExceeded
+}
+
+// CountMessageTokens counts the tokens of the system prompt, the messages and the tool descriptors
+// of a request. Binary parts other than plain text are not counted.
+func CountMessageTokens(counter TokenCounter, systemPrompt string, messages []*Message, tools []*ToolDescriptor) int64 {
+	var tokens int64
+	count := func(text string) {
+		tokens += int64(counter.CountTokens(text))
+	}
+	countJson := func(value any) {
+		if data, err := json.Marshal(value); err == nil {
+			count(string(data))
+		}
+	}
+
+	if systemPrompt != "" {
+		tokens += messageOverheadTokens
+		count(systemPrompt)
+	}
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		tokens += messageOverheadTokens
+		for _, part := range message.Parts {
+			switch p := part.(type) {
+			case *TextPart:
+				count(p.Text)
+			case *DataPart:
+				count(p.MarshalJson())
+			case *BinaryPart:
+				if IsPlainTextPart(p) {
+					count(string(p.Content))
+				}
+			case *ToolCall, *ToolCallResult:
+				countJson(p)
+			}
+		}
+	}
+	for _, tool := range tools {
+		countJson(tool)
+	}
+	return tokens
+}
+
+// CheckContextWindow checks, before sending, that the request fits the context window of the model,
+// counting its tokens with the TokenCounter of the options. The output tokens of the request are
+// reserved: MaxCompletionTokens when set, the DefaultMaxTokens of the model otherwise.
+// It returns a *ContextWindowExceededError when the request does not fit, nil when it fits,
+// when the options have no TokenCounter or when the context window of the model is unknown.
+func CheckContextWindow(model *Model, systemPrompt string, messages []*Message, opts *ChatOptions) error {
+	if model == nil || model.ContextWindowSize <= 0 || opts == nil || opts.TokenCounter == nil {
+		return nil
+	}
+
+	reserved := model.DefaultMaxTokens
+	if opts.MaxCompletionTokens != nil {
+		reserved = *opts.MaxCompletionTokens
+	}
+	estimated := CountMessageTokens(opts.TokenCounter, systemPrompt, messages, opts.Tools) + max(reserved, 0)
+	if estimated > model.ContextWindowSize {
+		return &ContextWindowExceededError{
+			Model:     model.ModelId,
+			Estimated: estimated,
+			Allowed:   model.ContextWindowSize,
+		}
+	}
+	return nil
+}
+
+// TruncateMessages drops the oldest messages of the history until its tokens, counted by the
+// counter as CountMessageTokens does, are at most maxTokens. The system messages and the latest
+// user turn, i.e. the last user message and the messages following it, are always kept, so the
+// result may still exceed maxTokens. The tool results are dropped with the tool calls they answer.
+// The order of the kept messages is unchanged; the messages are returned as is when they fit.
+func TruncateMessages(messages []*Message, maxTokens int, counter TokenCounter) []*Message {
+	total := CountMessageTokens(counter, "", messages, nil)
+	if total <= int64(maxTokens) {
+		return messages
+	}
+
+	lastUser := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i] != nil && messages[i].Creator.Role == MessageRoleUser && !isToolResultMessage(messages[i]) {
+			lastUser = i
+			break
+		}
+	}
+
+	dropped := make([]bool, len(messages))
+	for i := 0; i < lastUser && total > int64(maxTokens); i++ {
+		if messages[i] == nil || messages[i].Creator.Role == MessageRoleSystem {
+			continue
+		}
+		total -= CountMessageTokens(counter, "", messages[i:i+1], nil)
+		dropped[i] = true
+		// drop the results of the tool calls of the message, they would be left without a call
+		for i+1 < lastUser && isToolResultMessage(messages[i+1]) {
+			i++
+			total -= CountMessageTokens(counter, "", messages[i:i+1], nil)
+			dropped[i] = true
+		}
+	}
+
+	truncated := make([]*Message, 0, len(messages))
+	for i, message := range messages {
+		if !dropped[i] {
+			truncated = append(truncated, message)
+		}
+	}
+	return truncated
+}
+
+// isToolResultMessage returns whether the message carries tool results
+func isToolResultMessage(message *Message) bool {
+	if message == nil {
+		return false
+	}
+	if message.Creator.Role == MessageRoleTool {
+		return true
+	}
+	for _, part := range message.Parts {
+		if _, ok := part.(*ToolCallResult); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package llms
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContextWindowModel() *Model {
+	return &Model{
+		ModelId:           ModelId{Provider: "test", ID: "small"},
+		ContextWindowSize: 1000,
+		DefaultMaxTokens:  200,
+		MaxOutputTokens:   400,
+	}
+}
+
+func TestEstimateTokenCounter(t *testing.T) {
+	counter := EstimateTokenCounter{}
+	assert.Equal(t, 0, counter.CountTokens(""))
+	assert.Equal(t, 1, counter.CountTokens("abc"))
+	assert.Equal(t, 2, counter.CountTokens("hello"))
+	assert.Equal(t, 1, counter.CountTokens("你好世界")) // runes, not bytes
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	counter := EstimateTokenCounter{}
+	messages := []*Message{
+		NewUserMessage(strings.Repeat("a", 40)),
+		{
+			Creator: MessageCreator{Role: MessageRoleAssistant},
+			Parts:   []Part{&ToolCall{ToolCallId: "1", Name: "search", Arguments: map[string]any{"q": "go"}}},
+		},
+		nil,
+	}
+
+	tokens := CountMessageTokens(counter, "", messages, nil)
+	toolCallTokens := int64(counter.CountTokens(`{"id":"1","name":"search","arguments":{"q":"go"}}`))
+	assert.Equal(t, 2*messageOverheadTokens+10+toolCallTokens, tokens)
+
+	// the system prompt and the tools count too
+	tool := &ToolDescriptor{Name: "search", Description: "Search the web"}
+	assert.Greater(t, CountMessageTokens(counter, "Be brief.", messages, []*ToolDescriptor{tool}), tokens)
+}
+
+func TestCheckContextWindow_Overflow(t *testing.T) {
+	model := newTestContextWindowModel()
+	messages := []*Message{NewUserMessage(strings.Repeat("word ", 800))} // 1000 tokens
+
+	err := CheckContextWindow(model, "", messages, &ChatOptions{TokenCounter: EstimateTokenCounter{}})
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, ErrorCodeContextWindowExceeded))
+
+	var exceeded *ContextWindowExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, model.ModelId, exceeded.Model)
+	// the default max tokens of the model are reserved for the output
+	assert.Equal(t, int64(messageOverheadTokens+1000+200), exceeded.Estimated)
+	assert.Equal(t, int64(1000), exceeded.Allowed)
+	assert.Contains(t, err.Error(), "1204")
+}
+
+func TestCheckContextWindow_WithinBudget(t *testing.T) {
+	model := newTestContextWindowModel()
+	messages := []*Message{NewUserMessage(strings.Repeat("word ", 400))} // 500 tokens
+	opts := &ChatOptions{TokenCounter: EstimateTokenCounter{}}
+
+	assert.NoError(t, CheckContextWindow(model, "You are helpful.", messages, opts))
+
+	// a larger completion budget does not fit anymore
+	WithMaxCompletionTokens(600)(opts)
+	err := CheckContextWindow(model, "", messages, opts)
+	var exceeded *ContextWindowExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, int64(messageOverheadTokens+500+600), exceeded.Estimated)
+}
+
+func TestCheckContextWindow_Disabled(t *testing.T) {
+	messages := []*Message{NewUserMessage(strings.Repeat("word ", 800))}
+
+	// no token counter
+	assert.NoError(t, CheckContextWindow(newTestContextWindowModel(), "", messages, &ChatOptions{}))
+	// unknown context window
+	assert.NoError(t, CheckContextWindow(&Model{}, "", messages, &ChatOptions{TokenCounter: EstimateTokenCounter{}}))
+}
+
+// wordCounter counts the words of a text as its tokens
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func messageTexts(messages []*Message) []string {
+	var texts []string
+	for _, message := range messages {
+		for _, part := range message.Parts {
+			switch p := part.(type) {
+			case *TextPart:
+				texts = append(texts, p.Text)
+			case *ToolCall:
+				texts = append(texts, "call "+p.ToolCallId)
+			case *ToolCallResult:
+				texts = append(texts, "result "+p.ToolCallId)
+			}
+		}
+	}
+	return texts
+}
+
+func newTextMessage(role MessageRole, text string) *Message {
+	return &Message{Creator: MessageCreator{Role: role}, Parts: []Part{&TextPart{Text: text}}}
+}
+
+func TestTruncateMessages(t *testing.T) {
+	// every message costs messageOverheadTokens and a token per word
+	history := []*Message{
+		newTextMessage(MessageRoleSystem, "be brief"),
+		newTextMessage(MessageRoleUser, "first question here"),
+		newTextMessage(MessageRoleAssistant, "first answer"),
+		newTextMessage(MessageRoleUser, "second question"),
+		newTextMessage(MessageRoleAssistant, "second answer"),
+		newTextMessage(MessageRoleUser, "latest question"),
+	}
+	counter := wordCounter{}
+	total := CountMessageTokens(counter, "", history, nil)
+	require.Equal(t, int64(6*messageOverheadTokens+13), total)
+
+	// fits: unchanged
+	assert.Equal(t, history, TruncateMessages(history, int(total), counter))
+
+	// the oldest messages are dropped first
+	truncated := TruncateMessages(history, int(total)-1, counter)
+	assert.Equal(t, []string{"be brief", "first answer", "second question", "second answer", "latest question"},
+		messageTexts(truncated))
+
+	truncated = TruncateMessages(history, 3*messageOverheadTokens+6, counter)
+	assert.Equal(t, []string{"be brief", "second answer", "latest question"}, messageTexts(truncated))
+	assert.LessOrEqual(t, CountMessageTokens(counter, "", truncated, nil), int64(3*messageOverheadTokens+6))
+
+	// the system message and the latest user turn are kept even when they do not fit
+	truncated = TruncateMessages(history, 1, counter)
+	assert.Equal(t, []string{"be brief", "latest question"}, messageTexts(truncated))
+}
+
+func TestTruncateMessages_ToolCalls(t *testing.T) {
+	history := []*Message{
+		newTextMessage(MessageRoleUser, "what is the weather"),
+		{
+			Creator: MessageCreator{Role: MessageRoleAssistant},
+			Parts:   []Part{&ToolCall{ToolCallId: "1", Name: "weather"}},
+		},
+		NewToolCallResultMessage(&ToolCallResult{ToolCallId: "1", Name: "weather"}, time.Now()),
+		newTextMessage(MessageRoleAssistant, "sunny"),
+		newTextMessage(MessageRoleUser, "and tomorrow"),
+		{
+			Creator: MessageCreator{Role: MessageRoleAssistant},
+			Parts:   []Part{&ToolCall{ToolCallId: "2", Name: "weather"}},
+		},
+		NewToolCallResultMessage(&ToolCallResult{ToolCallId: "2", Name: "weather"}, time.Now()),
+	}
+	counter := wordCounter{}
+
+	// the results are dropped with their call, the calls of the latest turn are kept
+	truncated := TruncateMessages(history, int(CountMessageTokens(counter, "", history[3:], nil)), counter)
+	assert.Equal(t, []string{"sunny", "and tomorrow", "call 2", "result 2"}, messageTexts(truncated))
+
+	truncated = TruncateMessages(history, 1, counter)
+	assert.Equal(t, []string{"and tomorrow", "call 2", "result 2"}, messageTexts(truncated))
+}
@@ -14,11 +14,38 @@ type EmbeddingResponse struct {
 	Usage   UsageMetadata // Token usage information
 }
 
+// EmbeddingOption is a function that configures an embedding request.
+type EmbeddingOption func(opt *EmbeddingOptions)
+
+// EmbeddingOptions holds configuration settings for embedding requests.
+type EmbeddingOptions struct {
+	// Dimensions is the number of dimensions of the vectors, for the models able to shrink them,
+	// e.g. OpenAI text-embedding-3. 0 keeps the default dimensions of the model.
+	Dimensions int
+}
+
+// OfEmbeddingOptions returns the settings configured by the options.
+func OfEmbeddingOptions(options ...EmbeddingOption) *EmbeddingOptions {
+	opts := &EmbeddingOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return opts
+}
+
+// WithDimensions shrinks the vectors to the number of dimensions, to save their storage.
+func WithDimensions(dimensions int) EmbeddingOption {
+	return func(p *EmbeddingOptions) {
+		p.Dimensions = dimensions
+	}
+}
+
 // EmbedderProvider defines the interface for AI model providers that can generate embeddings.
 // Implementations should provide thread-safe embedding generation capabilities.
 type EmbedderProvider interface {
 	// GetEmbeddings generates embeddings for the provided texts using the configured model.
 	// The context can be used to cancel the operation.
+	// Options can be used to configure the request, e.g. the dimensions of the vectors.
 	// Returns an EmbeddingResponse containing the vectors and usage information.
-	GetEmbeddings(ctx context.Context, texts []string) (*EmbeddingResponse, error)
+	GetEmbeddings(ctx context.Context, texts []string, options ...EmbeddingOption) (*EmbeddingResponse, error)
 }
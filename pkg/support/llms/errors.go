@@ -58,4 +58,39 @@ var (
 		Name:           "EmbeddingSessionFailed ",
 		DefaultMessage: "Embedding session failed",
 	}
+	ErrorCodeDefaultModelNotFound = errors.ErrorCode{
+		Code:           30711,
+		Name:           "DefaultModelNotFound ",
+		DefaultMessage: "Default model not found",
+	}
+	ErrorCodeSchemaValidationFailed = errors.ErrorCode{
+		Code:           30712,
+		Name:           "SchemaValidationFailed ",
+		DefaultMessage: "Value does not match the schema",
+	}
+	ErrorCodeStructuredOutputNotFound = errors.ErrorCode{
+		Code:           30713,
+		Name:           "StructuredOutputNotFound ",
+		DefaultMessage: "No structured output found in text",
+	}
+	ErrorCodeResponseTooLarge = errors.ErrorCode{
+		Code:           30714,
+		Name:           "ResponseTooLarge ",
+		DefaultMessage: "Response exceeds the maximum size",
+	}
+	ErrorCodeContextWindowExceeded = errors.ErrorCode{
+		Code:           30715,
+		Name:           "ContextWindowExceeded ",
+		DefaultMessage: "Request exceeds the context window of the model",
+	}
+	ErrorCodeModelNotFound = errors.ErrorCode{
+		Code:           30716,
+		Name:           "ModelNotFound ",
+		DefaultMessage: "Model not found",
+	}
+	ErrorCodeInvalidModel = errors.ErrorCode{
+		Code:           30717,
+		Name:           "InvalidModel ",
+		DefaultMessage: "Model invalid",
+	}
 )
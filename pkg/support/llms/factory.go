@@ -10,18 +10,32 @@ import (
 // _registry is the global registry instance for managing models and providers.
 var _registry = &registry{}
 
-// GetModel retrieves a model by its ID from the global registry.
-// Returns the model and a boolean indicating if it was found.
-func GetModel(modelId ModelId) (*Model, bool) {
+// GetModel retrieves a model by its ID from the global registry: a model of a provider package or
+// a custom one declared with RegisterModel. Returns an error if the model is not registered.
+func GetModel(modelId ModelId) (*Model, error) {
 	return _registry.GetModel(modelId)
 }
 
-// RegisterModel adds a new model to the global registry.
+// RegisterModel adds a new model to the global registry, e.g. a fine-tuned model or a model not
+// known to its provider package yet, declaring its features, DefaultMaxTokens and ApiModelName.
+// The ApiModelName defaults to the ID of the model.
 // Returns an error if a model with the same ID already exists.
 func RegisterModel(model *Model) error {
 	return _registry.AddModel(model)
 }
 
+// RegisterDefaultModel declares the default model of a provider.
+// The model must be registered (see RegisterModel) before DefaultModel can resolve it.
+func RegisterDefaultModel(modelId ModelId) error {
+	return _registry.SetDefaultModel(modelId)
+}
+
+// DefaultModel retrieves the default model declared for the given provider.
+// Returns an error if the provider has no default or the default model is not registered.
+func DefaultModel(provider ModelProvider) (*Model, error) {
+	return _registry.DefaultModel(provider)
+}
+
 // ChatProviderConstructor is a function type that creates new chat provider instances.
 // It takes provider options and returns a ChatProvider and potential error.
 type ChatProviderConstructor func(opts ...ProviderOption) (ChatProvider, error)
@@ -42,8 +56,8 @@ func RegisterEmbedderProvider(providerName ModelProvider, constructor EmbedderPr
 	return _registry.AddEmbedderProvider(providerName, constructor)
 }
 
-// NewChatProvider creates a new chat provider instance for the specified provider name.
-// Returns an error if the provider is not registered.
+// NewChatProvider creates a new chat provider instance for the specified provider name,
+// its chats traced (see WithTracing). Returns an error if the provider is not registered.
 func NewChatProvider(providerName ModelProvider, opts ...ProviderOption) (ChatProvider, error) {
 	return _registry.NewChatProvider(providerName, opts...)
 }
@@ -59,6 +73,7 @@ func NewEmbedderProvider(providerName ModelProvider, model *Model, opts ...Provi
 type registry struct {
 	lock              sync.RWMutex
 	models            []*Model
+	defaultModels     map[ModelProvider]string
 	chatProviders     map[ModelProvider]ChatProviderConstructor
 	embedderProviders map[ModelProvider]EmbedderProviderConstructor
 }
@@ -67,6 +82,13 @@ type registry struct {
 // Uses write lock to ensure thread safety.
 // Returns an error if a model with the same ID already exists.
 func (r *registry) AddModel(m *Model) error {
+	if m == nil || m.ModelId.Provider == "" || m.ModelId.ID == "" {
+		return errors.Errorf(ErrorCodeInvalidModel, "a model needs a provider and an ID")
+	}
+	if m.ApiModelName == "" {
+		m.ApiModelName = m.ModelId.ID
+	}
+
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
@@ -81,29 +103,56 @@ func (r *registry) AddModel(m *Model) error {
 
 // GetModel retrieves a model by its ID from the registry.
 // Uses read lock for thread-safe access.
-// If the model is not found, returns a default model with basic features.
-func (r *registry) GetModel(modelId ModelId) (*Model, bool) {
+// Returns an error if the model is not registered.
+func (r *registry) GetModel(modelId ModelId) (*Model, error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
 	model := r.findModel(modelId)
 	if model == nil {
-		return &Model{
-			ModelId:            modelId,
-			Name:               modelId.ID,
-			CostPer1MIn:        0,
-			CostPer1MOut:       0,
-			CostPer1MInCached:  0,
-			CostPer1MOutCached: 0,
-			ContextWindowSize:  0,
-			Features: []ModelFeature{
-				ModelFeatureEmbedding,
-				ModelFeatureCompletion,
-			},
-		}, false
+		return nil, errors.Errorf(ErrorCodeModelNotFound,
+			"model not registered: %v, declare it with RegisterModel", modelId)
 	}
+	return model, nil
+}
+
+// SetDefaultModel declares the default model of the model's provider.
+// Uses write lock to ensure thread safety.
+// Overrides any previously declared default of the same provider.
+func (r *registry) SetDefaultModel(modelId ModelId) error {
+	if modelId.Provider == "" || modelId.ID == "" {
+		return errors.Errorf(ErrorCodeDefaultModelNotFound,
+			"invalid default model: %v", modelId)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
-	return model, true
+	if r.defaultModels == nil {
+		r.defaultModels = make(map[ModelProvider]string)
+	}
+	r.defaultModels[modelId.Provider] = modelId.ID
+	return nil
+}
+
+// DefaultModel retrieves the default model declared for the given provider.
+// Uses read lock for thread-safe access.
+func (r *registry) DefaultModel(provider ModelProvider) (*Model, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	id, exists := r.defaultModels[provider]
+	if !exists {
+		return nil, errors.Errorf(ErrorCodeDefaultModelNotFound,
+			"no default model declared for provider: %s", provider)
+	}
+
+	model := r.findModel(ModelId{Provider: provider, ID: id})
+	if model == nil {
+		return nil, errors.Errorf(ErrorCodeDefaultModelNotFound,
+			"default model of provider %s is not registered: %s", provider, id)
+	}
+	return model, nil
 }
 
 // findModel searches for a model by its ID within the registry.
@@ -170,7 +219,11 @@ func (r *registry) NewChatProvider(providerName ModelProvider, opts ...ProviderO
 			"chat provider not registered: %s", providerName)
 	}
 
-	return constructor(opts...)
+	provider, err := constructor(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return WithTracing(provider), nil
 }
 
 // NewEmbedderProvider creates a new embedder provider instance using the registered constructor.
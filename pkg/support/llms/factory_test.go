@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
 )
 
 func TestRegistry_AddModel(t *testing.T) {
@@ -39,13 +41,10 @@ func TestRegistry_GetModel(t *testing.T) {
 		ID:       "non-existent",
 	}
 
-	model, found := reg.GetModel(modelId)
-	assert.False(t, found)
-	assert.NotNil(t, model)
-	assert.Equal(t, modelId, model.ModelId)
-	assert.Equal(t, modelId.ID, model.Name)
-	assert.Contains(t, model.Features, ModelFeatureEmbedding)
-	assert.Contains(t, model.Features, ModelFeatureCompletion)
+	model, err := reg.GetModel(modelId)
+	assert.Nil(t, model)
+	assert.True(t, errors.IsCode(err, ErrorCodeModelNotFound))
+	assert.Contains(t, err.Error(), "model not registered")
 
 	// Test getting an existing model
 	existingModel := &Model{
@@ -56,14 +55,79 @@ func TestRegistry_GetModel(t *testing.T) {
 		Name: "Existing Model",
 	}
 
-	err := reg.AddModel(existingModel)
+	err = reg.AddModel(existingModel)
 	require.NoError(t, err)
 
-	retrievedModel, found := reg.GetModel(existingModel.ModelId)
-	assert.True(t, found)
+	retrievedModel, err := reg.GetModel(existingModel.ModelId)
+	assert.NoError(t, err)
 	assert.Equal(t, existingModel, retrievedModel)
 }
 
+func TestRegistry_AddCustomModel(t *testing.T) {
+	reg := &registry{}
+
+	// Test declaring a fine-tuned model unknown to its provider package
+	custom := &Model{
+		ModelId: ModelId{
+			Provider: "test-provider",
+			ID:       "ft:base-model:acme::abc123",
+		},
+		Name:             "Fine-tuned Model",
+		DefaultMaxTokens: 4096,
+		Features:         []ModelFeature{ModelFeatureCompletion, ModelFeatureReasoning},
+	}
+	require.NoError(t, reg.AddModel(custom))
+
+	model, err := reg.GetModel(custom.ModelId)
+	require.NoError(t, err)
+	assert.True(t, model.IsSupport(ModelFeatureCompletion))
+	assert.True(t, model.IsSupport(ModelFeatureReasoning))
+	assert.False(t, model.IsSupport(ModelFeatureEmbedding))
+	assert.Equal(t, int64(4096), model.DefaultMaxTokens)
+	// the API model name defaults to the ID
+	assert.Equal(t, "ft:base-model:acme::abc123", model.ApiModelName)
+
+	// Test declaring invalid models
+	for _, invalid := range []*Model{nil, {Name: "No ID"}, {ModelId: ModelId{ID: "no-provider"}}} {
+		err = reg.AddModel(invalid)
+		assert.True(t, errors.IsCode(err, ErrorCodeInvalidModel), "model %v", invalid)
+	}
+}
+
+func TestRegistry_DefaultModel(t *testing.T) {
+	reg := &registry{}
+
+	// Test provider without a declared default
+	_, err := reg.DefaultModel("test-provider")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no default model declared")
+
+	// Test declared default that is not registered
+	defaultId := ModelId{Provider: "test-provider", ID: "default-model"}
+	require.NoError(t, reg.SetDefaultModel(defaultId))
+	_, err = reg.DefaultModel("test-provider")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not registered")
+
+	// Test declared and registered default
+	defaultModel := &Model{ModelId: defaultId, Name: "Default Model"}
+	require.NoError(t, reg.AddModel(defaultModel))
+	model, err := reg.DefaultModel("test-provider")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultModel, model)
+
+	// Test overriding the default
+	otherModel := &Model{ModelId: ModelId{Provider: "test-provider", ID: "other-model"}}
+	require.NoError(t, reg.AddModel(otherModel))
+	require.NoError(t, reg.SetDefaultModel(otherModel.ModelId))
+	model, err = reg.DefaultModel("test-provider")
+	assert.NoError(t, err)
+	assert.Equal(t, otherModel, model)
+
+	// Test invalid model id
+	assert.Error(t, reg.SetDefaultModel(ModelId{Provider: "test-provider"}))
+}
+
 func TestRegistry_AddChatProvider(t *testing.T) {
 	reg := &registry{}
 
@@ -189,10 +253,18 @@ func TestGlobalFunctions(t *testing.T) {
 	err := RegisterModel(model)
 	assert.NoError(t, err)
 
-	retrievedModel, found := GetModel(model.ModelId)
-	assert.True(t, found)
+	retrievedModel, err := GetModel(model.ModelId)
+	assert.NoError(t, err)
 	assert.Equal(t, model, retrievedModel)
 
+	// Test RegisterDefaultModel and DefaultModel
+	err = RegisterDefaultModel(model.ModelId)
+	assert.NoError(t, err)
+
+	defaultModel, err := DefaultModel("global-test")
+	assert.NoError(t, err)
+	assert.Equal(t, model, defaultModel)
+
 	// Test RegisterChatProvider and NewChatProvider
 	constructor := func(opts ...ProviderOption) (ChatProvider, error) {
 		return &mockChatProvider{}, nil
@@ -241,7 +313,7 @@ func (m *mockChat) Send(ctx context.Context, messages []*Message, options ...Cha
 
 type mockEmbedderProvider struct{}
 
-func (m *mockEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+func (m *mockEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string, options ...EmbeddingOption) (*EmbeddingResponse, error) {
 	return &EmbeddingResponse{
 		Model:   &Model{},
 		Vectors: []FloatVector{},
@@ -2,9 +2,13 @@ package gemini
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"google.golang.org/genai"
@@ -56,6 +60,8 @@ func newChatProvider(opts ...llms.ProviderOption) (llms.ChatProvider, error) {
 	return &geminiChatProvider{
 		client: client,
 		debug:  options.Debug,
+
+		retryOptions: options.RetryOptions(),
 	}, nil
 }
 
@@ -64,6 +70,8 @@ var _ llms.ChatProvider = &geminiChatProvider{}
 type geminiChatProvider struct {
 	client *genai.Client
 	debug  bool
+
+	retryOptions []utils.RetryOption // bounds of the retries of the requests
 }
 
 func (g *geminiChatProvider) Close() error {
@@ -85,6 +93,7 @@ func (g *geminiChatProvider) NewChat(systemPrompt string, model *llms.Model) (ll
 		systemPrompt: systemPrompt,
 		model:        model,
 		debug:        g.debug,
+		retryOptions: g.retryOptions,
 	}, nil
 }
 
@@ -95,6 +104,7 @@ type geminiChat struct {
 	systemPrompt string
 	model        *llms.Model
 	debug        bool
+	retryOptions []utils.RetryOption
 }
 
 func (g *geminiChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
@@ -103,6 +113,10 @@ func (g *geminiChat) Send(ctx context.Context, messages []*llms.Message, options
 		opt(opts)
 	}
 
+	if err := llms.CheckContextWindow(g.model, g.systemPrompt, messages, opts); err != nil {
+		return nil, err
+	}
+
 	if opts.Streaming {
 		return g.stream(ctx, messages, opts)
 	} else {
@@ -122,7 +136,10 @@ func (g *geminiChat) Send(ctx context.Context, messages []*llms.Message, options
 func (g *geminiChat) sendOnce(ctx context.Context, messages []*llms.Message, opts *llms.ChatOptions) (*llms.ChatResponse, error) {
 	// Create generation config
 	systemInstruction := g.makeSystemInstruction(messages)
-	config := g.createGenerationConfig(systemInstruction, opts)
+	config, err := g.createGenerationConfig(systemInstruction, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert messages to Gemini format
 	history, currentParts, err := g.convertMessages(messages)
@@ -162,17 +179,20 @@ func (g *geminiChat) sendOnce(ctx context.Context, messages []*llms.Message, opt
 				}
 			}
 
-			finishReason, message := g.makeMessageFromResponse(utils.GenerateUUID(), response)
+			messageId := utils.GenerateUUID()
+			finishReason, message := g.makeMessageFromResponse(messageId, response)
 			usage := g.getUsageStats(response)
 			journal.AccumulateUsage("chat", usage.AsMap())
 
 			return &llms.ChatResponse{
-				Message:      message,
-				Usage:        usage,
-				FinishReason: finishReason,
+				Message:           message,
+				Usage:             usage,
+				FinishReason:      finishReason,
+				Choices:           g.makeChoicesFromResponse(messageId, response),
+				BlockedCategories: g.blockedCategories(response),
 			}, nil
 		},
-		utils.WithBackOff(utils.NewExponentialBackOff()),
+		slices.Concat(g.retryOptions, opts.RetryPolicy.RetryOptions())...,
 	)
 	if err != nil {
 		return nil, err
@@ -183,7 +203,10 @@ func (g *geminiChat) sendOnce(ctx context.Context, messages []*llms.Message, opt
 func (g *geminiChat) stream(ctx context.Context, messages []*llms.Message, opts *llms.ChatOptions) (llms.ChatResponseIterator, error) {
 	// Create generation config
 	systemInstruction := g.makeSystemInstruction(messages)
-	config := g.createGenerationConfig(systemInstruction, opts)
+	config, err := g.createGenerationConfig(systemInstruction, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert messages to Gemini format
 	history, currentParts, err := g.convertMessages(messages)
@@ -203,17 +226,21 @@ func (g *geminiChat) stream(ctx context.Context, messages []*llms.Message, opts
 			"modelId", g.model.ModelId, "historyLength", len(history), "config", config, "history", history)
 	}
 
-	assistant := llms.MessageCreator{Role: llms.MessageRoleAssistant}
-
 	// Send message with streaming
-	iter := chat.SendMessageStream(ctx, currentParts...)
+	responses := chat.SendMessageStream(ctx, currentParts...)
 
-	messageId := utils.GenerateUUID()
+	return g.streamResponses(utils.GenerateUUID(), responses, llms.NewResponseSizeGuard(opts)), nil
+}
+
+// streamResponses converts the streamed responses, accumulating them for the final response
+func (g *geminiChat) streamResponses(messageId string,
+	responses iter.Seq2[*genai.GenerateContentResponse, error], guard *llms.ResponseSizeGuard) llms.ChatResponseIterator {
+	assistant := llms.MessageCreator{Role: llms.MessageRoleAssistant}
 	acc := newGeminiChatCompletionAccumulator()
 
 	return func(yield func(*llms.ChatResponse, error) bool) {
 
-		for response, err := range iter {
+		for response, err := range responses {
 			if err != nil {
 				if errors.Is(err, io.EOF) {
 					// End of stream
@@ -225,6 +252,20 @@ func (g *geminiChat) stream(ctx context.Context, messages []*llms.Message, opts
 				continue
 			}
 
+			if !guard.Allow(g.responseContentSize(response)) {
+				// stop accumulating, report the truncated response
+				yield(&llms.ChatResponse{
+					Message: llms.Message{
+						MessageId: messageId,
+						Model:     g.model.ModelId,
+						Creator:   assistant,
+						Timestamp: time.Now(),
+					},
+					Usage:        g.getUsageStats(&acc.GenerateContentResponse),
+					FinishReason: llms.FinishReasonMaxTokens,
+				}, guard.Err())
+				return
+			}
 			acc.AddChunk(response)
 
 			if g.debug {
@@ -255,15 +296,39 @@ func (g *geminiChat) stream(ctx context.Context, messages []*llms.Message, opts
 				Creator:   assistant,
 				Timestamp: time.Now(),
 			},
-			Usage:        usage,
-			FinishReason: finishReason,
+			Usage:             usage,
+			FinishReason:      finishReason,
+			Choices:           g.makeChoicesFromResponse(messageId, &acc.GenerateContentResponse),
+			BlockedCategories: g.blockedCategories(&acc.GenerateContentResponse),
 		}, nil) {
 			return
 		}
-	}, nil
+	}
+}
+
+// responseContentSize returns the size of the text and function call arguments of a streamed response
+func (g *geminiChat) responseContentSize(response *genai.GenerateContentResponse) int {
+	size := 0
+	for _, candidate := range response.Candidates {
+		if candidate == nil || candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if part == nil {
+				continue
+			}
+			size += len(part.Text)
+			if part.FunctionCall != nil {
+				if args, err := json.Marshal(part.FunctionCall.Args); err == nil {
+					size += len(args)
+				}
+			}
+		}
+	}
+	return size
 }
 
-func (g *geminiChat) createGenerationConfig(systemInstruction string, opts *llms.ChatOptions) *genai.GenerateContentConfig {
+func (g *geminiChat) createGenerationConfig(systemInstruction string, opts *llms.ChatOptions) (*genai.GenerateContentConfig, error) {
 	config := &genai.GenerateContentConfig{}
 
 	// Set system instruction
@@ -287,6 +352,18 @@ func (g *geminiChat) createGenerationConfig(systemInstruction string, opts *llms
 		config.MaxOutputTokens = int32(*opts.MaxCompletionTokens)
 	}
 
+	if opts.N != nil {
+		config.CandidateCount = int32(*opts.N)
+	}
+
+	if len(opts.StopSequences) > 0 {
+		config.StopSequences = opts.StopSequences
+	}
+
+	if len(opts.SafetySettings) > 0 {
+		config.SafetySettings = g.convertToGeminiSafetySettings(opts.SafetySettings)
+	}
+
 	// Configure tools if provided
 	if len(opts.Tools) > 0 {
 		tools, err := g.convertToGeminiTools(opts.Tools)
@@ -294,10 +371,26 @@ func (g *geminiChat) createGenerationConfig(systemInstruction string, opts *llms
 			journal.Warning("llm", "gemini", "Failed to convert tools for Gemini", "error", err)
 		} else {
 			config.Tools = tools
+			if opts.ToolChoice != "" {
+				config.ToolConfig = g.convertToGeminiToolConfig(opts.ToolChoice)
+			}
 		}
 	}
 
-	return config
+	if opts.ResponseFormat != nil &&
+		opts.ResponseFormat.Type != "" && opts.ResponseFormat.Type != llms.ResponseFormatText {
+		if !g.model.IsSupport(llms.ModelFeatureStructuredOutput) {
+			return nil, errors.Errorf(llms.ErrorCodeModelFeatureNotMatched,
+				"model %s does not support structured output", g.model.ModelId.String())
+		}
+		config.ResponseMIMEType = "application/json"
+		if opts.ResponseFormat.Type == llms.ResponseFormatJSONSchema {
+			// without a schema to match, any JSON value
+			config.ResponseSchema = g.convertSchemaToGenai(opts.ResponseFormat.JSONSchema)
+		}
+	}
+
+	return config, nil
 }
 
 func (g *geminiChat) convertMessages(messages []*llms.Message) ([]*genai.Content, []genai.Part, error) {
@@ -323,7 +416,8 @@ func (g *geminiChat) convertMessages(messages []*llms.Message) ([]*genai.Content
 		for _, part := range msg.Parts {
 			switch part.Type() {
 			case llms.PartTypeText:
-				if textPart, ok := part.(*llms.TextPart); ok {
+				// the reasoning of previous responses is not sent back, it would be taken for their answer
+				if textPart, ok := part.(*llms.TextPart); ok && !textPart.Reasoning {
 					content.Parts = append(content.Parts, genai.NewPartFromText(textPart.Text))
 				}
 			case llms.PartTypeData:
@@ -372,14 +466,32 @@ func (g *geminiChat) convertMessages(messages []*llms.Message) ([]*genai.Content
 			for j, part := range content.Parts {
 				currentParts[j] = *part
 			}
-		} else {
-			history = append(history, content)
+		} else if len(content.Parts) > 0 {
+			history = append(history, content) // e.g. a response holding only reasoning is left out
 		}
 	}
 
 	return history, currentParts, nil
 }
 
+// convertToGeminiToolConfig maps the choice to the function calling mode of Gemini, where a
+// required tool call is "ANY" and a forced tool is the only allowed function
+func (g *geminiChat) convertToGeminiToolConfig(choice llms.ToolChoice) *genai.ToolConfig {
+	config := &genai.FunctionCallingConfig{}
+	switch choice {
+	case llms.ToolChoiceAuto:
+		config.Mode = genai.FunctionCallingConfigModeAuto
+	case llms.ToolChoiceNone:
+		config.Mode = genai.FunctionCallingConfigModeNone
+	case llms.ToolChoiceRequired:
+		config.Mode = genai.FunctionCallingConfigModeAny
+	default:
+		config.Mode = genai.FunctionCallingConfigModeAny
+		config.AllowedFunctionNames = []string{string(choice)}
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: config}
+}
+
 func (g *geminiChat) convertToGeminiTools(tools []*llms.ToolDescriptor) ([]*genai.Tool, error) {
 	var geminiTools []*genai.Tool
 
@@ -456,41 +568,138 @@ func (g *geminiChat) convertSchemaType(schemaType llms.SchemaType) genai.Type {
 
 func (g *geminiChat) makeMessageFromResponse(
 	messageId string, response *genai.GenerateContentResponse) (llms.FinishReason, llms.Message) {
-	finishReason := llms.FinishReasonUnknown
-	message := llms.Message{
+	if len(response.Candidates) > 0 {
+		return g.makeMessageFromCandidate(messageId, response.Candidates[0])
+	}
+	if response.PromptFeedback != nil && response.PromptFeedback.BlockReason != "" {
+		// the prompt itself was blocked, no candidate was generated
+		return llms.FinishReasonDenied, g.newAssistantMessage(messageId)
+	}
+	return llms.FinishReasonUnknown, g.newAssistantMessage(messageId)
+}
+
+// blockedCategories returns the safety categories the prompt or the first candidate was blocked for
+func (g *geminiChat) blockedCategories(response *genai.GenerateContentResponse) []llms.SafetyCategory {
+	var ratings []*genai.SafetyRating
+	if response.PromptFeedback != nil {
+		ratings = append(ratings, response.PromptFeedback.SafetyRatings...)
+	}
+	if len(response.Candidates) > 0 && response.Candidates[0] != nil {
+		ratings = append(ratings, response.Candidates[0].SafetyRatings...)
+	}
+
+	var categories []llms.SafetyCategory
+	for _, rating := range ratings {
+		if rating == nil || !rating.Blocked {
+			continue
+		}
+		category := g.toSafetyCategory(rating.Category)
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// makeChoicesFromResponse converts all candidates when the response holds more than one
+func (g *geminiChat) makeChoicesFromResponse(messageId string, response *genai.GenerateContentResponse) []*llms.ChatChoice {
+	if len(response.Candidates) < 2 {
+		return nil
+	}
+	choices := make([]*llms.ChatChoice, 0, len(response.Candidates))
+	for _, candidate := range response.Candidates {
+		finishReason, message := g.makeMessageFromCandidate(messageId, candidate)
+		choices = append(choices, &llms.ChatChoice{
+			Index:        int(candidate.Index),
+			Message:      message,
+			FinishReason: finishReason,
+		})
+	}
+	return choices
+}
+
+func (g *geminiChat) makeMessageFromCandidate(
+	messageId string, candidate *genai.Candidate) (llms.FinishReason, llms.Message) {
+	message := g.newAssistantMessage(messageId)
+
+	// Convert finish reason
+	finishReason := g.toFinishReason(candidate.FinishReason)
+
+	// Convert content
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
+			if part.Text != "" {
+				textPart := llms.NewTextPartBuilder().Text(part.Text).Reasoning(part.Thought).Build()
+				message.Parts = append(message.Parts, textPart)
+			}
+			if part.FunctionCall != nil {
+				toolCall := &llms.ToolCall{
+					ToolCallId: part.FunctionCall.ID,
+					Name:       part.FunctionCall.Name,
+					Arguments:  part.FunctionCall.Args,
+				}
+				message.Parts = append(message.Parts, toolCall)
+				finishReason = llms.FinishReasonToolUse
+			}
+		}
+	}
+
+	return finishReason, message
+}
+
+func (g *geminiChat) newAssistantMessage(messageId string) llms.Message {
+	return llms.Message{
 		Creator:   llms.MessageCreator{Role: llms.MessageRoleAssistant},
 		MessageId: messageId,
 		Model:     g.model.ModelId,
 		Timestamp: time.Now(),
 	}
+}
 
-	if len(response.Candidates) > 0 {
-		candidate := response.Candidates[0]
+var geminiHarmCategories = map[llms.SafetyCategory]genai.HarmCategory{
+	llms.SafetyCategoryHarassment:       genai.HarmCategoryHarassment,
+	llms.SafetyCategoryHateSpeech:       genai.HarmCategoryHateSpeech,
+	llms.SafetyCategorySexuallyExplicit: genai.HarmCategorySexuallyExplicit,
+	llms.SafetyCategoryDangerousContent: genai.HarmCategoryDangerousContent,
+}
 
-		// Convert finish reason
-		finishReason = g.toFinishReason(candidate.FinishReason)
+var geminiHarmBlockThresholds = map[llms.SafetyThreshold]genai.HarmBlockThreshold{
+	llms.SafetyThresholdBlockNone:           genai.HarmBlockThresholdBlockNone,
+	llms.SafetyThresholdBlockOnlyHigh:       genai.HarmBlockThresholdBlockOnlyHigh,
+	llms.SafetyThresholdBlockMediumAndAbove: genai.HarmBlockThresholdBlockMediumAndAbove,
+	llms.SafetyThresholdBlockLowAndAbove:    genai.HarmBlockThresholdBlockLowAndAbove,
+}
 
-		// Convert content
-		if candidate.Content != nil {
-			for _, part := range candidate.Content.Parts {
-				if part.Text != "" {
-					textPart := llms.NewTextPartBuilder().Text(part.Text).Build()
-					message.Parts = append(message.Parts, textPart)
-				}
-				if part.FunctionCall != nil {
-					toolCall := &llms.ToolCall{
-						ToolCallId: part.FunctionCall.ID,
-						Name:       part.FunctionCall.Name,
-						Arguments:  part.FunctionCall.Args,
-					}
-					message.Parts = append(message.Parts, toolCall)
-					finishReason = llms.FinishReasonToolUse
-				}
-			}
+// convertToGeminiSafetySettings maps the settings to the harm categories and thresholds of Gemini,
+// passing the names of Gemini through, sorted by category for the requests to be stable
+func (g *geminiChat) convertToGeminiSafetySettings(
+	settings map[llms.SafetyCategory]llms.SafetyThreshold) []*genai.SafetySetting {
+	result := make([]*genai.SafetySetting, 0, len(settings))
+	for category, threshold := range settings {
+		harmCategory, ok := geminiHarmCategories[category]
+		if !ok {
+			harmCategory = genai.HarmCategory(category)
 		}
+		blockThreshold, ok := geminiHarmBlockThresholds[threshold]
+		if !ok {
+			blockThreshold = genai.HarmBlockThreshold(threshold)
+		}
+		result = append(result, &genai.SafetySetting{Category: harmCategory, Threshold: blockThreshold})
 	}
+	slices.SortFunc(result, func(a, b *genai.SafetySetting) int {
+		return strings.Compare(string(a.Category), string(b.Category))
+	})
+	return result
+}
 
-	return finishReason, message
+// toSafetyCategory maps the harm category of Gemini back to the common category, if any
+func (g *geminiChat) toSafetyCategory(harmCategory genai.HarmCategory) llms.SafetyCategory {
+	for category, mapped := range geminiHarmCategories {
+		if mapped == harmCategory {
+			return category
+		}
+	}
+	return llms.SafetyCategory(harmCategory)
 }
 
 func (g *geminiChat) toFinishReason(reason genai.FinishReason) llms.FinishReason {
@@ -543,6 +752,24 @@ type geminiChatCompletionAccumulator struct {
 	genai.GenerateContentResponse
 }
 
+// candidate returns the accumulated candidate of the index, the candidates being kept in index order
+func (a *geminiChatCompletionAccumulator) candidate(index int32) *genai.Candidate {
+	position, found := slices.BinarySearchFunc(a.Candidates, index, func(c *genai.Candidate, index int32) int {
+		return int(c.Index - index)
+	})
+	if found {
+		return a.Candidates[position]
+	}
+	candidate := &genai.Candidate{
+		Index: index,
+		Content: &genai.Content{
+			Parts: make([]*genai.Part, 0),
+		},
+	}
+	a.Candidates = slices.Insert(a.Candidates, position, candidate)
+	return candidate
+}
+
 func (a *geminiChatCompletionAccumulator) AddChunk(response *genai.GenerateContentResponse) {
 	if response == nil {
 		return
@@ -553,18 +780,9 @@ func (a *geminiChatCompletionAccumulator) AddChunk(response *genai.GenerateConte
 		a.Candidates = make([]*genai.Candidate, 0)
 	}
 
-	// Process each candidate in the response
-	for i, candidate := range response.Candidates {
-		// Ensure we have enough candidates in accumulator
-		for len(a.Candidates) <= i {
-			a.Candidates = append(a.Candidates, &genai.Candidate{
-				Content: &genai.Content{
-					Parts: make([]*genai.Part, 0),
-				},
-			})
-		}
-
-		accCandidate := a.Candidates[i]
+	// Process each candidate in the response, a chunk may carry some of the candidates only
+	for _, candidate := range response.Candidates {
+		accCandidate := a.candidate(candidate.Index)
 
 		// Update finish reason (last one wins)
 		if candidate.FinishReason != "" {
@@ -611,7 +829,8 @@ func (a *geminiChatCompletionAccumulator) AddChunk(response *genai.GenerateConte
 					var lastTextPart *genai.Part
 					if len(accCandidate.Content.Parts) > 0 {
 						lastPart := accCandidate.Content.Parts[len(accCandidate.Content.Parts)-1]
-						if lastPart.Text != "" {
+						// thoughts and answer are kept in separate parts
+						if lastPart.Text != "" && lastPart.Thought == part.Thought {
 							lastTextPart = lastPart
 						}
 					}
@@ -622,7 +841,8 @@ func (a *geminiChatCompletionAccumulator) AddChunk(response *genai.GenerateConte
 					} else {
 						// Create new text part
 						newPart := &genai.Part{
-							Text: part.Text,
+							Text:    part.Text,
+							Thought: part.Thought,
 						}
 						accCandidate.Content.Parts = append(accCandidate.Content.Parts, newPart)
 					}
@@ -696,8 +916,8 @@ func (a *geminiChatCompletionAccumulator) AddChunk(response *genai.GenerateConte
 					accCandidate.Content.Parts = append(accCandidate.Content.Parts, newPart)
 				}
 
-				// Handle thought - add as new parts
-				if part.Thought {
+				// Handle thought without text - add as new parts
+				if part.Thought && part.Text == "" {
 					newPart := &genai.Part{
 						Thought: true,
 					}
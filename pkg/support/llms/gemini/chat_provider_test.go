@@ -0,0 +1,310 @@
+package gemini
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestGeminiChat_StreamStopsAtMaxResponseBytes(t *testing.T) {
+	chunksRead := 0
+	// an endless stream of 10 bytes chunks
+	responses := func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for {
+			chunksRead++
+			if !yield(&genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{
+					Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "0123456789"}}},
+				}},
+			}, nil) {
+				return
+			}
+		}
+	}
+
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+	guard := llms.NewResponseSizeGuard(&llms.ChatOptions{MaxResponseBytes: 45})
+
+	var content string
+	var lastErr error
+	var last *llms.ChatResponse
+	for response, err := range g.streamResponses("message-1", responses, guard) {
+		if err != nil {
+			lastErr = err
+			last = response
+			continue
+		}
+		for _, part := range response.Message.Parts {
+			if text, ok := part.(*llms.TextPart); ok {
+				content += text.Text
+			}
+		}
+	}
+
+	// 4 chunks fit, the 5th one is over the limit and the stream is no longer read
+	assert.Equal(t, strings.Repeat("0123456789", 4), content)
+	assert.Equal(t, 5, chunksRead)
+	require.Error(t, lastErr)
+	assert.True(t, errors.IsCode(lastErr, llms.ErrorCodeResponseTooLarge))
+	require.NotNil(t, last)
+	assert.Equal(t, "message-1", last.Message.MessageId)
+	assert.Equal(t, llms.FinishReasonMaxTokens, last.FinishReason)
+}
+
+func TestGeminiChat_ReasoningRoundTrip(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	// the thoughts streamed are accumulated apart from the answer
+	acc := newGeminiChatCompletionAccumulator()
+	for _, part := range []*genai.Part{
+		{Text: "15 * 23 ", Thought: true}, {Text: "= 345", Thought: true}, {Text: "The answer "}, {Text: "is 345."},
+	} {
+		acc.AddChunk(&genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}}}},
+		})
+	}
+	_, message := g.makeMessageFromResponse("msg-1", &acc.GenerateContentResponse)
+	assert.Equal(t, []llms.Part{
+		&llms.TextPart{Text: "15 * 23 = 345", Reasoning: true},
+		&llms.TextPart{Text: "The answer is 345."},
+	}, message.Parts)
+
+	// the reasoning is not sent back with the history
+	thinkingOnly := llms.NewAssistantMessage("msg-0", llms.ModelId{}, "hmm")
+	thinkingOnly.Parts[0].(*llms.TextPart).Reasoning = true
+	history, current, err := g.convertMessages([]*llms.Message{
+		llms.NewUserMessage("What is 15 * 23?"), thinkingOnly, &message, llms.NewUserMessage("Thanks"),
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, []*genai.Part{{Text: "The answer is 345."}}, history[1].Parts)
+	assert.Equal(t, "Thanks", current[0].Text)
+}
+
+func TestGeminiChat_JSONSchemaResponseFormat(t *testing.T) {
+	model := GeminiModels[ModelGemini25Flash]
+	g := &geminiChat{model: &model}
+
+	opts := &llms.ChatOptions{}
+	llms.WithJSONSchemaOutput("answer", &llms.Schema{
+		Type: llms.TypeObject,
+		Properties: map[string]*llms.Schema{
+			"answer":  {Type: llms.TypeString},
+			"sources": {Type: llms.TypeArray, Items: &llms.Schema{Type: llms.TypeString}},
+		},
+		Required: []string{"answer"},
+	})(opts)
+	config, err := g.createGenerationConfig("", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", config.ResponseMIMEType)
+	require.NotNil(t, config.ResponseSchema)
+	assert.Equal(t, genai.TypeObject, config.ResponseSchema.Type)
+	assert.Equal(t, []string{"answer"}, config.ResponseSchema.Required)
+	assert.Equal(t, genai.TypeString, config.ResponseSchema.Properties["answer"].Type)
+	assert.Equal(t, genai.TypeString, config.ResponseSchema.Properties["sources"].Items.Type)
+
+	// any JSON value
+	opts = &llms.ChatOptions{}
+	llms.WithJSONObjectOutput()(opts)
+	config, err = g.createGenerationConfig("", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", config.ResponseMIMEType)
+	assert.Nil(t, config.ResponseSchema)
+
+	// free-form text by default
+	config, err = g.createGenerationConfig("", &llms.ChatOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, config.ResponseMIMEType)
+	assert.Nil(t, config.ResponseSchema)
+}
+
+func TestGeminiChat_ResponseFormatNeedsStructuredOutput(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	opts := &llms.ChatOptions{}
+	llms.WithJSONObjectOutput()(opts)
+	_, err := g.createGenerationConfig("", opts)
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, llms.ErrorCodeModelFeatureNotMatched))
+}
+
+func TestGeminiChat_StopSequences(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	opts := &llms.ChatOptions{}
+	llms.WithStopSequences("Observation:")(opts)
+	config, err := g.createGenerationConfig("", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Observation:"}, config.StopSequences)
+}
+
+func TestGeminiChat_ToolChoice(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+	tool := &llms.ToolDescriptor{
+		Name:        "get_weather",
+		Description: "Get the weather",
+		Parameters:  &llms.Schema{Type: llms.TypeObject},
+	}
+
+	tests := []struct {
+		choice  llms.ToolChoice
+		mode    genai.FunctionCallingConfigMode
+		allowed []string
+	}{
+		{choice: llms.ToolChoiceAuto, mode: genai.FunctionCallingConfigModeAuto},
+		{choice: llms.ToolChoiceNone, mode: genai.FunctionCallingConfigModeNone},
+		{choice: llms.ToolChoiceRequired, mode: genai.FunctionCallingConfigModeAny},
+		{choice: "get_weather", mode: genai.FunctionCallingConfigModeAny, allowed: []string{"get_weather"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.choice), func(t *testing.T) {
+			opts := &llms.ChatOptions{}
+			llms.WithTools(tool)(opts)
+			llms.WithToolChoice(tt.choice)(opts)
+			config, err := g.createGenerationConfig("", opts)
+			require.NoError(t, err)
+			require.NotNil(t, config.ToolConfig)
+			require.NotNil(t, config.ToolConfig.FunctionCallingConfig)
+			assert.Equal(t, tt.mode, config.ToolConfig.FunctionCallingConfig.Mode)
+			assert.Equal(t, tt.allowed, config.ToolConfig.FunctionCallingConfig.AllowedFunctionNames)
+		})
+	}
+
+	// the provider default without a choice or without tools
+	config, err := g.createGenerationConfig("", &llms.ChatOptions{Tools: []*llms.ToolDescriptor{tool}})
+	require.NoError(t, err)
+	assert.Nil(t, config.ToolConfig)
+	config, err = g.createGenerationConfig("", &llms.ChatOptions{ToolChoice: llms.ToolChoiceRequired})
+	require.NoError(t, err)
+	assert.Nil(t, config.ToolConfig)
+}
+
+func TestGeminiChat_MultipleCandidates(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	opts := &llms.ChatOptions{}
+	llms.WithN(2)(opts)
+	config, err := g.createGenerationConfig("", opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), config.CandidateCount)
+
+	// two candidates streamed side by side
+	responses := func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for _, chunk := range [][]string{{"The answer", "I think"}, {" is 42", " it is 41"}} {
+			candidates := make([]*genai.Candidate, 0, len(chunk))
+			for idx, text := range chunk {
+				candidates = append(candidates, &genai.Candidate{
+					Index:   int32(idx),
+					Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: text}}},
+				})
+			}
+			if !yield(&genai.GenerateContentResponse{Candidates: candidates}, nil) {
+				return
+			}
+		}
+		yield(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+			{Index: 0, FinishReason: genai.FinishReasonStop},
+			{Index: 1, FinishReason: genai.FinishReasonMaxTokens},
+		}}, nil)
+	}
+
+	var last *llms.ChatResponse
+	for response, err := range g.streamResponses("message-1", responses, llms.NewResponseSizeGuard(opts)) {
+		require.NoError(t, err)
+		last = response
+	}
+	require.NotNil(t, last)
+	require.Len(t, last.Choices, 2)
+	assert.Equal(t, llms.FinishReasonNormalEnd, last.FinishReason)
+	for idx, want := range []string{"The answer is 42", "I think it is 41"} {
+		choice := last.Choices[idx]
+		assert.Equal(t, idx, choice.Index)
+		assert.Equal(t, "message-1", choice.Message.MessageId)
+		require.Len(t, choice.Message.Parts, 1)
+		assert.Equal(t, want, choice.Message.Parts[0].(*llms.TextPart).Text)
+	}
+	assert.Equal(t, llms.FinishReasonMaxTokens, last.Choices[1].FinishReason)
+}
+
+func TestGeminiChat_CandidatesInSeparateChunks(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	// each chunk carries a single candidate, merged by its index rather than its position
+	acc := newGeminiChatCompletionAccumulator()
+	for _, candidate := range []*genai.Candidate{
+		{Index: 1, Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "I think"}}}},
+		{Index: 0, Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "The answer"}}}},
+		{Index: 1, Content: &genai.Content{Parts: []*genai.Part{{Text: " it is 41"}}}, FinishReason: genai.FinishReasonMaxTokens},
+		{Index: 0, Content: &genai.Content{Parts: []*genai.Part{{Text: " is 42"}}}, FinishReason: genai.FinishReasonStop},
+	} {
+		acc.AddChunk(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{candidate}})
+	}
+
+	choices := g.makeChoicesFromResponse("message-1", &acc.GenerateContentResponse)
+	require.Len(t, choices, 2)
+	for idx, want := range []string{"The answer is 42", "I think it is 41"} {
+		assert.Equal(t, idx, choices[idx].Index)
+		require.Len(t, choices[idx].Message.Parts, 1)
+		assert.Equal(t, want, choices[idx].Message.Parts[0].(*llms.TextPart).Text)
+	}
+	assert.Equal(t, llms.FinishReasonNormalEnd, choices[0].FinishReason)
+	assert.Equal(t, llms.FinishReasonMaxTokens, choices[1].FinishReason)
+}
+
+func TestGeminiChat_SafetySettings(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	opts := &llms.ChatOptions{}
+	llms.WithSafetySetting(llms.SafetyCategoryHarassment, llms.SafetyThresholdBlockOnlyHigh)(opts)
+	llms.WithSafetySetting(llms.SafetyCategoryDangerousContent, llms.SafetyThresholdBlockNone)(opts)
+	llms.WithSafetySetting("HARM_CATEGORY_CIVIC_INTEGRITY", "OFF")(opts)
+	config, err := g.createGenerationConfig("", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []*genai.SafetySetting{
+		{Category: genai.HarmCategoryCivicIntegrity, Threshold: genai.HarmBlockThresholdOff},
+		{Category: genai.HarmCategoryDangerousContent, Threshold: genai.HarmBlockThresholdBlockNone},
+		{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockThresholdBlockOnlyHigh},
+	}, config.SafetySettings)
+
+	// the provider defaults without settings
+	config, err = g.createGenerationConfig("", &llms.ChatOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, config.SafetySettings)
+}
+
+func TestGeminiChat_BlockedCategories(t *testing.T) {
+	g := &geminiChat{model: &llms.Model{ModelId: llms.ModelId{Provider: ModelProviderGemini, ID: "test"}}}
+
+	// a blocked response
+	response := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		FinishReason: genai.FinishReasonSafety,
+		SafetyRatings: []*genai.SafetyRating{
+			{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityLow},
+			{Category: genai.HarmCategoryDangerousContent, Probability: genai.HarmProbabilityHigh, Blocked: true},
+		},
+	}}}
+	finishReason, _ := g.makeMessageFromResponse("message-1", response)
+	assert.Equal(t, llms.FinishReasonDenied, finishReason)
+	assert.Equal(t, []llms.SafetyCategory{llms.SafetyCategoryDangerousContent}, g.blockedCategories(response))
+
+	// a blocked prompt, without candidates
+	response = &genai.GenerateContentResponse{PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+		BlockReason: genai.BlockedReasonSafety,
+		SafetyRatings: []*genai.SafetyRating{
+			{Category: genai.HarmCategoryCivicIntegrity, Probability: genai.HarmProbabilityHigh, Blocked: true},
+		},
+	}}
+	finishReason, _ = g.makeMessageFromResponse("message-1", response)
+	assert.Equal(t, llms.FinishReasonDenied, finishReason)
+	assert.Equal(t, []llms.SafetyCategory{"HARM_CATEGORY_CIVIC_INTEGRITY"}, g.blockedCategories(response))
+
+	// nothing blocked
+	assert.Empty(t, g.blockedCategories(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		FinishReason: genai.FinishReasonStop,
+	}}}))
+}
@@ -72,7 +72,7 @@ type geminiEmbedderProvider struct {
 
 // GetEmbeddings generates embeddings for the provided texts using the configured Gemini model.
 // It processes each text individually and returns a combined response with all vectors.
-func (g *geminiEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string) (*llms.EmbeddingResponse, error) {
+func (g *geminiEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string, options ...llms.EmbeddingOption) (*llms.EmbeddingResponse, error) {
 	// Check if model is provided
 	if g.model == nil {
 		return nil, errors.Errorf(llms.ErrorCodeEmbeddingSessionFailed,
@@ -85,6 +85,11 @@ func (g *geminiEmbedderProvider) GetEmbeddings(ctx context.Context, texts []stri
 			"model %s does not support embedding, features: [%v]", g.model.ModelId.String(), g.model.Features)
 	}
 
+	var config *genai.EmbedContentConfig
+	if opts := llms.OfEmbeddingOptions(options...); opts.Dimensions > 0 {
+		config = &genai.EmbedContentConfig{OutputDimensionality: genai.Ptr(int32(opts.Dimensions))}
+	}
+
 	var vectors []llms.FloatVector
 	var totalInputTokens, totalOutputTokens int64
 
@@ -96,7 +101,7 @@ func (g *geminiEmbedderProvider) GetEmbeddings(ctx context.Context, texts []stri
 		}
 
 		// Call Gemini's embedContent API
-		result, err := g.client.Models.EmbedContent(ctx, g.model.ApiModelName, contents, nil)
+		result, err := g.client.Models.EmbedContent(ctx, g.model.ApiModelName, contents, config)
 		if err != nil {
 			return nil, errors.Errorf(llms.ErrorCodeEmbeddingSessionFailed,
 				"failed to get embedding for text: %s", err.Error())
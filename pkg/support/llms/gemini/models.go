@@ -11,6 +11,8 @@ import (
 const (
 	// ModelProviderGemini is the provider identifier for Google Gemini models.
 	ModelProviderGemini llms.ModelProvider = "gemini"
+	// DefaultGeminiModel is the default Gemini model declared to the model registry.
+	DefaultGeminiModel = ModelGemini25Flash
 
 	// Gemini model identifiers for completion tasks
 	ModelGemini25Flash     = "gemini-2.5-flash"      // Gemini 2.5 Flash model
@@ -38,9 +40,11 @@ var GeminiModels = map[string]llms.Model{
 		CostPer1MOut:       0.60,
 		ContextWindowSize:  1000000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    65536,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGemini25: {
@@ -56,9 +60,11 @@ var GeminiModels = map[string]llms.Model{
 		CostPer1MOut:       10,
 		ContextWindowSize:  1000000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    65536,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGemini20Flash: {
@@ -74,9 +80,11 @@ var GeminiModels = map[string]llms.Model{
 		CostPer1MOut:       0.40,
 		ContextWindowSize:  1000000,
 		DefaultMaxTokens:   6000,
+		MaxOutputTokens:    8192,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGemini20FlashLite: {
@@ -92,9 +100,11 @@ var GeminiModels = map[string]llms.Model{
 		CostPer1MOut:       0.30,
 		ContextWindowSize:  1000000,
 		DefaultMaxTokens:   6000,
+		MaxOutputTokens:    8192,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 
@@ -127,4 +137,7 @@ func init() {
 			klog.Warningf("Failed to register Gemini model %s: %v", model.ModelId.ID, err)
 		}
 	}
+	if err := llms.RegisterDefaultModel(llms.ModelId{Provider: ModelProviderGemini, ID: DefaultGeminiModel}); err != nil {
+		klog.Warningf("Failed to register default Gemini model: %v", err)
+	}
 }
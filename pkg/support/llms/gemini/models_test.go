@@ -159,6 +159,20 @@ func TestGeminiModels_DefaultMaxTokens(t *testing.T) {
 	}
 }
 
+func TestGeminiModels_MaxOutputTokens(t *testing.T) {
+	// Test that completion models declare how many tokens they can generate
+	for modelID, model := range GeminiModels {
+		if !model.IsSupport(llms.ModelFeatureCompletion) {
+			continue
+		}
+		t.Run(modelID, func(t *testing.T) {
+			assert.Greater(t, model.MaxOutputTokens, int64(0))
+			assert.Less(t, model.MaxOutputTokens, model.ContextWindowSize)
+			assert.LessOrEqual(t, model.DefaultMaxTokens, model.MaxOutputTokens)
+		})
+	}
+}
+
 func TestGeminiModels_EmbeddingModel(t *testing.T) {
 	// Test that embedding model has correct configuration
 	embeddingModel := GeminiModels[ModelGeminiEmbedding001]
@@ -176,3 +190,11 @@ func TestGeminiModels_EmbeddingModel(t *testing.T) {
 	assert.Equal(t, int64(2048), embeddingModel.ContextWindowSize)
 	assert.Equal(t, int64(2048), embeddingModel.DefaultMaxTokens)
 }
+
+func TestGeminiModels_DefaultModel(t *testing.T) {
+	model, err := llms.DefaultModel(ModelProviderGemini)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultGeminiModel, model.ModelId.ID)
+	assert.Equal(t, ModelProviderGemini, model.ModelId.Provider)
+	assert.True(t, model.IsSupport(llms.ModelFeatureCompletion))
+}
@@ -33,6 +33,8 @@ const (
 	FinishReasonError     FinishReason = "error"      // An error occurred
 	FinishReasonDenied    FinishReason = "denied"     // Request was denied
 	FinishReasonUnknown   FinishReason = "unknown"    // Unknown reason
+
+	FinishReasonEmptyResponse FinishReason = "empty_response" // The model repeatedly returned empty responses
 )
 
 // ReasoningEffort indicates the level of reasoning effort required or used.
@@ -162,7 +164,12 @@ var _ Part = &BinaryPart{}
 
 // TextPart represents plain text content in a message.
 type TextPart struct {
-	Text string // The text content
+	Text      string // The text content
+	Reasoning bool   // Whether the text is the reasoning of the model rather than its answer
+	// Signature is the signature of the reasoning, for the providers requiring it back, e.g. Anthropic
+	Signature string
+	// Redacted tells the reasoning is encrypted by the provider, its Text then holds the encrypted data
+	Redacted bool
 }
 
 // Type returns the part type as PartTypeText.
@@ -245,7 +252,10 @@ func IsPDFPart(p *BinaryPart) bool {
 
 // TextPartBuilder provides a fluent interface for building TextPart instances
 type TextPartBuilder struct {
-	text string // The text content to be built
+	text      string // The text content to be built
+	reasoning bool   // Whether the text is reasoning
+	signature string // The signature of the reasoning
+	redacted  bool   // Whether the reasoning is redacted
 }
 
 // NewTextPartBuilder creates a new TextPartBuilder
@@ -259,10 +269,31 @@ func (b *TextPartBuilder) Text(text string) *TextPartBuilder {
 	return b
 }
 
+// Reasoning marks the text as the reasoning of the model, e.g. its thinking, rather than its answer
+func (b *TextPartBuilder) Reasoning(reasoning bool) *TextPartBuilder {
+	b.reasoning = reasoning
+	return b
+}
+
+// Signature sets the signature of the reasoning, e.g. of the thinking of Anthropic
+func (b *TextPartBuilder) Signature(signature string) *TextPartBuilder {
+	b.signature = signature
+	return b
+}
+
+// Redacted marks the reasoning as encrypted by the provider, the text then holds the encrypted data
+func (b *TextPartBuilder) Redacted(redacted bool) *TextPartBuilder {
+	b.redacted = redacted
+	return b
+}
+
 // Build creates and returns a new TextPart instance
 func (b *TextPartBuilder) Build() *TextPart {
 	return &TextPart{
-		Text: b.text,
+		Text:      b.text,
+		Reasoning: b.reasoning,
+		Signature: b.signature,
+		Redacted:  b.redacted,
 	}
 }
 
@@ -40,6 +40,7 @@ func TestFinishReason(t *testing.T) {
 		{"error", FinishReasonError, "error"},
 		{"denied", FinishReasonDenied, "denied"},
 		{"unknown", FinishReasonUnknown, "unknown"},
+		{"empty_response", FinishReasonEmptyResponse, "empty_response"},
 	}
 
 	for _, tt := range tests {
@@ -35,16 +35,21 @@ func (m *MockEmbedderProvider) EXPECT() *MockEmbedderProviderMockRecorder {
 }
 
 // GetEmbeddings mocks base method.
-func (m *MockEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+func (m *MockEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string, options ...EmbeddingOption) (*EmbeddingResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEmbeddings", ctx, texts)
+	varargs := []interface{}{ctx, texts}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetEmbeddings", varargs...)
 	ret0, _ := ret[0].(*EmbeddingResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetEmbeddings indicates an expected call of GetEmbeddings.
-func (mr *MockEmbedderProviderMockRecorder) GetEmbeddings(ctx, texts interface{}) *gomock.Call {
+func (mr *MockEmbedderProviderMockRecorder) GetEmbeddings(ctx, texts interface{}, options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddings", reflect.TypeOf((*MockEmbedderProvider)(nil).GetEmbeddings), ctx, texts)
+	varargs := append([]interface{}{ctx, texts}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddings", reflect.TypeOf((*MockEmbedderProvider)(nil).GetEmbeddings), varargs...)
 }
@@ -18,6 +18,8 @@ const (
 	ModelFeatureEmbedding  ModelFeature = "can_embedding"        // Model can perform embedding
 	ModelFeatureReasoning  ModelFeature = "can_reason"           // Model can perform reasoning tasks
 	ModelFeatureAttachment ModelFeature = "supports_attachments" // Model supports file attachments
+	// Model can constrain its answers to JSON, see ResponseFormat
+	ModelFeatureStructuredOutput ModelFeature = "supports_structured_output"
 )
 
 // ModelId uniquely identifies an AI model by its provider and ID.
@@ -42,6 +44,7 @@ type Model struct {
 	CostPer1MOutCached float64        `json:"cost_per_1m_out_cached"` // Cost per 1M cached output tokens
 	ContextWindowSize  int64          `json:"context_window_size"`    // Maximum context window size in tokens
 	DefaultMaxTokens   int64          `json:"default_max_tokens"`     // Default maximum tokens for responses
+	MaxOutputTokens    int64          `json:"max_output_tokens"`      // Maximum tokens the model can generate in a response
 	Features           []ModelFeature `json:"features,omitempty"`     // List of model capabilities
 }
 
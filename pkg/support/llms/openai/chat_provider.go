@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +34,8 @@ func newChatProvider(opts ...llms.ProviderOption) (llms.ChatProvider, error) {
 	return &openAIChatProvider{
 		client: client,
 		debug:  options.Debug,
+
+		retryOptions: options.RetryOptions(),
 	}, nil
 }
 
@@ -73,6 +77,8 @@ var _ llms.ChatProvider = &openAIChatProvider{}
 type openAIChatProvider struct {
 	client openai.Client
 	debug  bool
+
+	retryOptions []utils.RetryOption // bounds of the retries of the requests
 }
 
 func (o *openAIChatProvider) Close() error {
@@ -95,6 +101,7 @@ func (o *openAIChatProvider) NewChat(systemPrompt string, model *llms.Model) (ll
 		systemPrompt: systemPrompt,
 		model:        model,
 		debug:        o.debug,
+		retryOptions: o.retryOptions,
 	}, nil
 }
 
@@ -106,6 +113,7 @@ type openAIChat struct {
 	systemPrompt string
 	model        *llms.Model
 	debug        bool
+	retryOptions []utils.RetryOption
 }
 
 func (o *openAIChat) Send(ctx context.Context, messages []*llms.Message, options ...llms.ChatOption) (llms.ChatResponseIterator, error) {
@@ -114,6 +122,10 @@ func (o *openAIChat) Send(ctx context.Context, messages []*llms.Message, options
 		opt(opts)
 	}
 
+	if err := llms.CheckContextWindow(o.model, o.systemPrompt, messages, opts); err != nil {
+		return nil, err
+	}
+
 	if opts.Streaming {
 		return o.stream(ctx, messages, opts)
 	} else {
@@ -169,9 +181,10 @@ func (o *openAIChat) sendOnce(ctx context.Context, messages []*llms.Message, opt
 				Message:      message,
 				Usage:        usage,
 				FinishReason: finishReason,
+				Choices:      o.makeChoicesFromChatCompletion(response),
 			}, nil
 		},
-		utils.WithBackOff(utils.NewExponentialBackOff()),
+		slices.Concat(o.retryOptions, opts.RetryPolicy.RetryOptions())...,
 	)
 	if err != nil {
 		return nil, err
@@ -180,33 +193,58 @@ func (o *openAIChat) sendOnce(ctx context.Context, messages []*llms.Message, opt
 }
 
 func (o *openAIChat) makeMessageFromChatCompletion(response *openai.ChatCompletion) (llms.FinishReason, llms.Message) {
-	finishReason := llms.FinishReasonUnknown
-	message := llms.Message{
-		Creator:   llms.MessageCreator{Role: llms.MessageRoleAssistant},
-		MessageId: response.ID,
-		Model:     o.model.ModelId,
-		Timestamp: time.Now(),
+	if len(response.Choices) == 0 {
+		return llms.FinishReasonUnknown, o.newAssistantMessage(response.ID)
 	}
+	return o.makeMessageFromChoice(response.ID, &response.Choices[0])
+}
 
-	if len(response.Choices) > 0 {
-		choice := response.Choices[0]
-		if len(choice.Message.Content) > 0 {
-			part := llms.NewTextPartBuilder().Text(choice.Message.Content).Build()
-			message.Parts = append(message.Parts, part)
-		}
-		if toolCalls := o.toToolCalls(choice.Message.ToolCalls); len(toolCalls) > 0 {
-			for idx := range toolCalls {
-				toolCall := toolCalls[idx]
-				message.Parts = append(message.Parts, toolCall)
-			}
-			finishReason = llms.FinishReasonToolUse
-		} else {
-			finishReason = o.toFinishReason(choice.FinishReason)
+// makeChoicesFromChatCompletion converts all choices when the response holds more than one
+func (o *openAIChat) makeChoicesFromChatCompletion(response *openai.ChatCompletion) []*llms.ChatChoice {
+	if len(response.Choices) < 2 {
+		return nil
+	}
+	choices := make([]*llms.ChatChoice, 0, len(response.Choices))
+	for idx := range response.Choices {
+		choice := &response.Choices[idx]
+		finishReason, message := o.makeMessageFromChoice(response.ID, choice)
+		choices = append(choices, &llms.ChatChoice{
+			Index:        int(choice.Index),
+			Message:      message,
+			FinishReason: finishReason,
+		})
+	}
+	return choices
+}
+
+func (o *openAIChat) makeMessageFromChoice(messageId string, choice *openai.ChatCompletionChoice) (llms.FinishReason, llms.Message) {
+	var finishReason llms.FinishReason
+	message := o.newAssistantMessage(messageId)
+	if len(choice.Message.Content) > 0 {
+		part := llms.NewTextPartBuilder().Text(choice.Message.Content).Build()
+		message.Parts = append(message.Parts, part)
+	}
+	if toolCalls := o.toToolCalls(choice.Message.ToolCalls); len(toolCalls) > 0 {
+		for idx := range toolCalls {
+			toolCall := toolCalls[idx]
+			message.Parts = append(message.Parts, toolCall)
 		}
+		finishReason = llms.FinishReasonToolUse
+	} else {
+		finishReason = o.toFinishReason(choice.FinishReason)
 	}
 	return finishReason, message
 }
 
+func (o *openAIChat) newAssistantMessage(messageId string) llms.Message {
+	return llms.Message{
+		Creator:   llms.MessageCreator{Role: llms.MessageRoleAssistant},
+		MessageId: messageId,
+		Model:     o.model.ModelId,
+		Timestamp: time.Now(),
+	}
+}
+
 func (o *openAIChat) stream(ctx context.Context, messages []*llms.Message, opts *llms.ChatOptions) (llms.ChatResponseIterator, error) {
 	params, err := o.makeChatCompletionParams(messages, opts)
 	if err != nil {
@@ -223,11 +261,26 @@ func (o *openAIChat) stream(ctx context.Context, messages []*llms.Message, opts
 	stream := o.client.Chat.Completions.NewStreaming(ctx, *params)
 
 	acc := openai.ChatCompletionAccumulator{}
+	guard := llms.NewResponseSizeGuard(opts)
 	return func(yield func(*llms.ChatResponse, error) bool) {
 		defer stream.Close()
 
 		for stream.Next() {
 			chunk := stream.Current()
+			if !guard.Allow(o.chunkContentSize(&chunk)) {
+				// stop accumulating, report the truncated response
+				yield(&llms.ChatResponse{
+					Message: llms.Message{
+						MessageId: acc.ChatCompletion.ID,
+						Model:     o.model.ModelId,
+						Creator:   assistant,
+						Timestamp: time.Now(),
+					},
+					Usage:        o.getUsageStats(&acc.Usage),
+					FinishReason: llms.FinishReasonMaxTokens,
+				}, guard.Err())
+				return
+			}
 			acc.AddChunk(chunk)
 
 			if o.debug {
@@ -316,6 +369,18 @@ func (o *openAIChat) stream(ctx context.Context, messages []*llms.Message, opts
 	}, nil
 }
 
+// chunkContentSize returns the size of the content, refusal and tool call arguments of a chunk
+func (o *openAIChat) chunkContentSize(chunk *openai.ChatCompletionChunk) int {
+	size := 0
+	for _, choice := range chunk.Choices {
+		size += len(choice.Delta.Content) + len(choice.Delta.Refusal)
+		for _, toolCall := range choice.Delta.ToolCalls {
+			size += len(toolCall.Function.Arguments)
+		}
+	}
+	return size
+}
+
 func (o *openAIChat) convertToOpenAIMessages(messages []*llms.Message) ([]openai.ChatCompletionMessageParamUnion, error) {
 	openaiMessages := []openai.ChatCompletionMessageParamUnion{
 		o.makeSystemMessage(messages),
@@ -354,6 +419,17 @@ func (o *openAIChat) convertToOpenAIMessages(messages []*llms.Message) ([]openai
 	return openaiMessages, nil
 }
 
+func (o *openAIChat) convertToOpenAIToolChoice(choice llms.ToolChoice) openai.ChatCompletionToolChoiceOptionUnionParam {
+	if choice.IsTool() {
+		return openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfChatCompletionNamedToolChoice: &openai.ChatCompletionNamedToolChoiceParam{
+				Function: openai.ChatCompletionNamedToolChoiceFunctionParam{Name: string(choice)},
+			},
+		}
+	}
+	return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(string(choice))}
+}
+
 func (o *openAIChat) convertToOpenAITools(tools []*llms.ToolDescriptor) ([]openai.ChatCompletionToolParam, error) {
 	openaiTools := make([]openai.ChatCompletionToolParam, len(tools))
 	for i, t := range tools {
@@ -425,6 +501,9 @@ func (o *openAIChat) makeChatCompletionParams(
 	}
 	if len(openaiTools) > 0 {
 		params.Tools = openaiTools
+		if opts.ToolChoice != "" {
+			params.ToolChoice = o.convertToOpenAIToolChoice(opts.ToolChoice)
+		}
 	}
 
 	if opts.Temperature != nil {
@@ -447,15 +526,110 @@ func (o *openAIChat) makeChatCompletionParams(
 		params.MaxCompletionTokens = openai.Int(*opts.MaxCompletionTokens)
 	}
 
+	if opts.N != nil {
+		params.N = openai.Int(int64(*opts.N))
+	}
+
+	if len(opts.StopSequences) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: opts.StopSequences}
+	}
+
 	if o.model.IsSupport(llms.ModelFeatureReasoning) {
 		params.ReasoningEffort = o.convertToOpenAIReasoningEffort(opts.ReasoningEffort)
 	} else {
 		params.ReasoningEffort = o.convertToOpenAIReasoningEffort(OpenAIDefaultReasoningEffort)
 	}
 
+	if opts.ResponseFormat != nil {
+		responseFormat, err := o.convertToOpenAIResponseFormat(opts.ResponseFormat)
+		if err != nil {
+			return nil, err
+		}
+		params.ResponseFormat = responseFormat
+	}
+
 	return params, nil
 }
 
+// defaultResponseSchemaName names the schemas of the JSON answers not naming theirs
+const defaultResponseSchemaName = "response"
+
+func (o *openAIChat) convertToOpenAIResponseFormat(
+	format *llms.ResponseFormat) (openai.ChatCompletionNewParamsResponseFormatUnion, error) {
+	var responseFormat openai.ChatCompletionNewParamsResponseFormatUnion
+	if format.Type == "" || format.Type == llms.ResponseFormatText {
+		return responseFormat, nil
+	}
+	if !o.model.IsSupport(llms.ModelFeatureStructuredOutput) {
+		return responseFormat, errors.Errorf(llms.ErrorCodeModelFeatureNotMatched,
+			"model %s does not support structured output", o.model.ModelId.String())
+	}
+
+	switch format.Type {
+	case llms.ResponseFormatJSONObject:
+		responseFormat.OfJSONObject = &shared.ResponseFormatJSONObjectParam{}
+	case llms.ResponseFormatJSONSchema:
+		if format.JSONSchema == nil {
+			// no schema to match, any JSON object
+			responseFormat.OfJSONObject = &shared.ResponseFormatJSONObjectParam{}
+			break
+		}
+		schema, err := o.convertSchemaForOpenAI(format.JSONSchema)
+		if err != nil {
+			return responseFormat, errors.Errorf(llms.ErrorCodeInvalidSchema,
+				"response schema conversion failed: %s", err.Error())
+		}
+		name := format.Name
+		if name == "" {
+			name = defaultResponseSchemaName
+		}
+		jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   name,
+			Strict: openai.Bool(true),
+			Schema: toStrictJSONSchema(schema, false),
+		}
+		if schema.Description != "" {
+			jsonSchema.Description = openai.String(schema.Description)
+		}
+		responseFormat.OfJSONSchema = &shared.ResponseFormatJSONSchemaParam{JSONSchema: jsonSchema}
+	default:
+		return responseFormat, errors.Errorf(llms.ErrorCodeModelFeatureNotMatched,
+			"response format %s is not supported", format.Type)
+	}
+	return responseFormat, nil
+}
+
+// toStrictJSONSchema converts the schema to the strict mode of the OpenAI structured outputs:
+// the objects require all their properties and forbid the others, the optional ones are nullable
+func toStrictJSONSchema(schema *llms.Schema, nullable bool) map[string]any {
+	result := map[string]any{"type": string(schema.Type)}
+	if nullable {
+		result["type"] = []any{string(schema.Type), "null"}
+	}
+	if schema.Description != "" {
+		result["description"] = schema.Description
+	}
+
+	switch schema.Type {
+	case llms.TypeObject:
+		properties := make(map[string]any, len(schema.Properties))
+		required := make([]string, 0, len(schema.Properties))
+		for name, property := range schema.Properties {
+			properties[name] = toStrictJSONSchema(property, !slices.Contains(schema.Required, name))
+			required = append(required, name)
+		}
+		sort.Strings(required)
+		result["properties"] = properties
+		result["required"] = required
+		result["additionalProperties"] = false
+	case llms.TypeArray:
+		if schema.Items != nil {
+			result["items"] = toStrictJSONSchema(schema.Items, false)
+		}
+	}
+	return result
+}
+
 func (o *openAIChat) convertToOpenAIReasoningEffort(reasoningEffort llms.ReasoningEffort) openai.ReasoningEffort {
 	switch reasoningEffort {
 	case llms.ReasoningEffortLow:
@@ -591,8 +765,9 @@ func (o *openAIChat) convertSchemaForOpenAI(schema *llms.Schema) (*llms.Schema,
 		validated.Type = llms.TypeNumber
 
 	case llms.TypeInteger:
-		// OpenAI prefers "number" for integers
-		validated.Type = llms.TypeNumber
+		// Keep integer semantics, OpenAI supports "integer" and the model
+		// will then produce whole numbers instead of floats like 1.5
+		validated.Type = llms.TypeInteger
 
 	case llms.TypeBoolean:
 		validated.Type = llms.TypeBoolean
@@ -0,0 +1,419 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+	"github.com/openai/openai-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIntegerToolDescriptor() *llms.ToolDescriptor {
+	return &llms.ToolDescriptor{
+		Name:        "list_items",
+		Description: "List items",
+		Parameters: &llms.Schema{
+			Type: llms.TypeObject,
+			Properties: map[string]*llms.Schema{
+				"count": {Type: llms.TypeInteger, Description: "number of items"},
+				"ratio": {Type: llms.TypeNumber, Description: "sampling ratio"},
+				"pages": {Type: llms.TypeArray, Items: &llms.Schema{Type: llms.TypeInteger}},
+			},
+			Required: []string{"count"},
+		},
+	}
+}
+
+func TestOpenAIChat_ConvertSchemaKeepsInteger(t *testing.T) {
+	o := &openAIChat{}
+	descriptor := newTestIntegerToolDescriptor()
+
+	converted, err := o.convertSchemaForOpenAI(descriptor.Parameters)
+	require.NoError(t, err)
+	assert.Equal(t, llms.TypeInteger, converted.Properties["count"].Type)
+	assert.Equal(t, llms.TypeNumber, converted.Properties["ratio"].Type)
+	assert.Equal(t, llms.TypeInteger, converted.Properties["pages"].Items.Type)
+
+	params, err := o.convertToFunctionParameters(descriptor)
+	require.NoError(t, err)
+	require.NotNil(t, params)
+
+	properties := (*params)["properties"].(map[string]any)
+	assert.Equal(t, "integer", properties["count"].(map[string]any)["type"])
+	assert.Equal(t, "number", properties["ratio"].(map[string]any)["type"])
+	assert.Equal(t, "integer", properties["pages"].(map[string]any)["items"].(map[string]any)["type"])
+}
+
+func TestOpenAIChat_IntegerArgumentsRoundTrip(t *testing.T) {
+	var requestBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"created": 1700000000,
+			"model": "gpt-4.1",
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant",
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "list_items", "arguments": "{\"count\": 3}"}}]
+			}}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model, err := llms.DefaultModel(ModelProviderOpenAI)
+	require.NoError(t, err)
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(),
+		[]*llms.Message{llms.NewUserMessage("list 3 items")}, llms.WithTools(newTestIntegerToolDescriptor()))
+	require.NoError(t, err)
+	var toolCall *llms.ToolCall
+	for response, err := range iterator {
+		require.NoError(t, err)
+		for _, part := range response.Parts {
+			if call, ok := part.(*llms.ToolCall); ok {
+				toolCall = call
+			}
+		}
+	}
+
+	// the model is asked for an integer
+	tools := requestBody["tools"].([]any)
+	properties := tools[0].(map[string]any)["function"].(map[string]any)["parameters"].(map[string]any)["properties"]
+	assert.Equal(t, "integer", properties.(map[string]any)["count"].(map[string]any)["type"])
+
+	// the decoded argument is a whole number, as the JSON numbers of the other providers
+	require.NotNil(t, toolCall)
+	assert.Equal(t, "list_items", toolCall.Name)
+	assert.IsType(t, float64(0), toolCall.Arguments["count"])
+	assert.Equal(t, float64(3), toolCall.Arguments["count"])
+	assert.NoError(t, newTestIntegerToolDescriptor().Parameters.Validate(toolCall.Arguments))
+
+	// decoding the arguments into the tool's parameter struct keeps the integer
+	raw, err := json.Marshal(toolCall.Arguments)
+	require.NoError(t, err)
+	var params struct {
+		Count int `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &params))
+	assert.Equal(t, 3, params.Count)
+}
+
+func TestOpenAIChat_MultipleChoices(t *testing.T) {
+	var requestBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"created": 1700000000,
+			"model": "gpt-4.1",
+			"choices": [
+				{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "answer one"}},
+				{"index": 1, "finish_reason": "length", "message": {"role": "assistant", "content": "answer two"}},
+				{"index": 2, "finish_reason": "stop", "message": {"role": "assistant", "content": "answer three"}}
+			],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 9, "total_tokens": 14}
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model, err := llms.DefaultModel(ModelProviderOpenAI)
+	require.NoError(t, err)
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(),
+		[]*llms.Message{llms.NewUserMessage("question")}, llms.WithN(3))
+	require.NoError(t, err)
+
+	var responses []*llms.ChatResponse
+	for response, err := range iterator {
+		require.NoError(t, err)
+		responses = append(responses, response)
+	}
+	require.Len(t, responses, 1)
+	assert.Equal(t, float64(3), requestBody["n"])
+
+	response := responses[0]
+	// choice 0 stays the primary message
+	assert.Equal(t, "answer one", response.Message.Parts[0].(*llms.TextPart).Text)
+	assert.Equal(t, llms.FinishReasonNormalEnd, response.FinishReason)
+	assert.Equal(t, int64(9), response.Usage.OutputTokens)
+
+	require.Len(t, response.Choices, 3)
+	for idx, expected := range []string{"answer one", "answer two", "answer three"} {
+		choice := response.Choices[idx]
+		assert.Equal(t, idx, choice.Index)
+		assert.Equal(t, "chatcmpl-1", choice.Message.MessageId)
+		assert.Equal(t, expected, choice.Message.Parts[0].(*llms.TextPart).Text)
+	}
+	assert.Equal(t, llms.FinishReasonMaxTokens, response.Choices[1].FinishReason)
+}
+
+func TestOpenAIChat_SingleChoice(t *testing.T) {
+	o := &openAIChat{model: &llms.Model{}}
+	response := &openai.ChatCompletion{
+		ID: "chatcmpl-2",
+		Choices: []openai.ChatCompletionChoice{
+			{Index: 0, FinishReason: "stop", Message: openai.ChatCompletionMessage{Content: "only"}},
+		},
+	}
+	assert.Nil(t, o.makeChoicesFromChatCompletion(response))
+
+	finishReason, message := o.makeMessageFromChatCompletion(&openai.ChatCompletion{ID: "empty"})
+	assert.Equal(t, llms.FinishReasonUnknown, finishReason)
+	assert.Equal(t, "empty", message.MessageId)
+	assert.Empty(t, message.Parts)
+}
+
+func TestOpenAIChat_StreamStopsAtMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// an endless stream of 10 bytes chunks, cut by the client
+		for i := 0; i < 1000; i++ {
+			_, err := fmt.Fprintf(w, "data: %s\n\n", `{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,`+
+				`"model":"gpt-4.1","choices":[{"index":0,"delta":{"content":"0123456789"},"finish_reason":null}]}`)
+			if err != nil {
+				return
+			}
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model, err := llms.DefaultModel(ModelProviderOpenAI)
+	require.NoError(t, err)
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+
+	iterator, err := chat.Send(context.Background(),
+		[]*llms.Message{llms.NewUserMessage("question")},
+		llms.WithStreaming(true), llms.WithMaxResponseBytes(55))
+	require.NoError(t, err)
+
+	var content string
+	var lastErr error
+	var last *llms.ChatResponse
+	for response, err := range iterator {
+		if err != nil {
+			lastErr = err
+			last = response
+			continue
+		}
+		for _, part := range response.Message.Parts {
+			if text, ok := part.(*llms.TextPart); ok {
+				content += text.Text
+			}
+		}
+	}
+
+	// 5 chunks fit, the 6th one is over the limit
+	assert.Equal(t, strings.Repeat("0123456789", 5), content)
+	require.Error(t, lastErr)
+	assert.True(t, errors.IsCode(lastErr, llms.ErrorCodeResponseTooLarge))
+	require.NotNil(t, last)
+	assert.Equal(t, llms.FinishReasonMaxTokens, last.FinishReason)
+	assert.Equal(t, "chatcmpl-1", last.Message.MessageId)
+}
+
+func TestOpenAIChat_ContextWindowCheckedBeforeSending(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"created": 1700000000,
+			"model": "gpt-4o",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider, err := newChatProvider(llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	model := OpenAIModels[ModelGPT4o]
+	chat, err := provider.NewChat("", &model)
+	require.NoError(t, err)
+
+	tooLong := []*llms.Message{llms.NewUserMessage(strings.Repeat("word ", 120_000))}
+	_, err = chat.Send(context.Background(), tooLong, llms.WithTokenCounter(llms.EstimateTokenCounter{}))
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, llms.ErrorCodeContextWindowExceeded))
+	assert.Equal(t, 0, requests)
+
+	_, err = chat.Send(context.Background(), []*llms.Message{llms.NewUserMessage("question")},
+		llms.WithTokenCounter(llms.EstimateTokenCounter{}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func newTestAnswerSchema() *llms.Schema {
+	return &llms.Schema{
+		Type:        llms.TypeObject,
+		Description: "the answer",
+		Properties: map[string]*llms.Schema{
+			"city": {Type: llms.TypeString},
+			"tags": {Type: llms.TypeArray, Items: &llms.Schema{
+				Type: llms.TypeObject,
+				Properties: map[string]*llms.Schema{
+					"name": {Type: llms.TypeString},
+				},
+				Required: []string{"name"},
+			}},
+		},
+		Required: []string{"city"},
+	}
+}
+
+func TestOpenAIChat_JSONSchemaResponseFormat(t *testing.T) {
+	model := OpenAIModels[ModelGPT4o]
+	o := &openAIChat{model: &model}
+
+	opts := &llms.ChatOptions{}
+	llms.WithJSONSchemaOutput("weather", newTestAnswerSchema())(opts)
+	params, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("weather?")}, opts)
+	require.NoError(t, err)
+
+	jsonSchema := params.ResponseFormat.OfJSONSchema
+	require.NotNil(t, jsonSchema)
+	assert.Equal(t, "weather", jsonSchema.JSONSchema.Name)
+	assert.True(t, jsonSchema.JSONSchema.Strict.Value)
+	assert.Equal(t, "the answer", jsonSchema.JSONSchema.Description.Value)
+
+	raw, err := json.Marshal(jsonSchema.JSONSchema.Schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"description": "the answer",
+		"properties": {
+			"city": {"type": "string"},
+			"tags": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"properties": {"name": {"type": "string"}},
+				"required": ["name"],
+				"additionalProperties": false
+			}}
+		},
+		"required": ["city", "tags"],
+		"additionalProperties": false
+	}`, string(raw))
+
+	body, err := json.Marshal(params)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"response_format":{"json_schema":`)
+	assert.Contains(t, string(body), `"type":"json_schema"`)
+}
+
+func TestOpenAIChat_JSONObjectResponseFormat(t *testing.T) {
+	model := OpenAIModels[ModelGPT4o]
+	o := &openAIChat{model: &model}
+
+	opts := &llms.ChatOptions{}
+	llms.WithJSONObjectOutput()(opts)
+	params, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("weather?")}, opts)
+	require.NoError(t, err)
+	assert.NotNil(t, params.ResponseFormat.OfJSONObject)
+	assert.Nil(t, params.ResponseFormat.OfJSONSchema)
+
+	// without a schema to match, any JSON object
+	opts = &llms.ChatOptions{}
+	llms.WithJSONSchemaOutput("", nil)(opts)
+	params, err = o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("weather?")}, opts)
+	require.NoError(t, err)
+	assert.NotNil(t, params.ResponseFormat.OfJSONObject)
+
+	// free-form text by default
+	params, err = o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("weather?")}, &llms.ChatOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, params.ResponseFormat.OfJSONObject)
+	assert.Nil(t, params.ResponseFormat.OfJSONSchema)
+}
+
+func TestOpenAIChat_ResponseFormatNeedsStructuredOutput(t *testing.T) {
+	model := OpenAIModels[ModelO1Mini]
+	o := &openAIChat{model: &model}
+
+	opts := &llms.ChatOptions{}
+	llms.WithJSONSchemaOutput("weather", newTestAnswerSchema())(opts)
+	_, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("weather?")}, opts)
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, llms.ErrorCodeModelFeatureNotMatched))
+}
+
+func TestOpenAIChat_StopSequences(t *testing.T) {
+	model := OpenAIModels[ModelGPT4o]
+	o := &openAIChat{model: &model}
+
+	opts := &llms.ChatOptions{}
+	llms.WithStopSequences("Observation:", "\n\n")(opts)
+	params, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("question")}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Observation:", "\n\n"}, params.Stop.OfStringArray)
+
+	params, err = o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("question")}, &llms.ChatOptions{})
+	require.NoError(t, err)
+	body, err := json.Marshal(params)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `"stop"`)
+}
+
+func TestOpenAIChat_ToolChoice(t *testing.T) {
+	model := OpenAIModels[ModelGPT4o]
+	o := &openAIChat{model: &model}
+
+	tests := []struct {
+		choice llms.ToolChoice
+		want   string
+	}{
+		{choice: llms.ToolChoiceAuto, want: `"auto"`},
+		{choice: llms.ToolChoiceNone, want: `"none"`},
+		{choice: llms.ToolChoiceRequired, want: `"required"`},
+		{choice: "list_items", want: `{"function":{"name":"list_items"},"type":"function"}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.choice), func(t *testing.T) {
+			opts := &llms.ChatOptions{}
+			llms.WithTools(newTestIntegerToolDescriptor())(opts)
+			llms.WithToolChoice(tt.choice)(opts)
+			params, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("question")}, opts)
+			require.NoError(t, err)
+
+			body, err := json.Marshal(params.ToolChoice)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+
+	// the provider default without a choice or without tools
+	for _, opts := range []*llms.ChatOptions{
+		{Tools: []*llms.ToolDescriptor{newTestIntegerToolDescriptor()}},
+		{ToolChoice: llms.ToolChoiceRequired},
+	} {
+		params, err := o.makeChatCompletionParams([]*llms.Message{llms.NewUserMessage("question")}, opts)
+		require.NoError(t, err)
+		body, err := json.Marshal(params)
+		require.NoError(t, err)
+		assert.NotContains(t, string(body), `"tool_choice"`)
+	}
+}
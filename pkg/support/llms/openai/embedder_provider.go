@@ -35,7 +35,8 @@ type openAIEmbedderProvider struct {
 	model  *llms.Model
 }
 
-func (o *openAIEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string) (*llms.EmbeddingResponse, error) {
+func (o *openAIEmbedderProvider) GetEmbeddings(ctx context.Context, texts []string, options ...llms.EmbeddingOption) (*llms.EmbeddingResponse, error) {
+	opts := llms.OfEmbeddingOptions(options...)
 	params := openai.EmbeddingNewParams{
 		Model:          o.model.ApiModelName,
 		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
@@ -43,6 +44,10 @@ func (o *openAIEmbedderProvider) GetEmbeddings(ctx context.Context, texts []stri
 			OfArrayOfStrings: texts,
 		},
 	}
+	// only the text-embedding-3 models and later support shrinking the vectors
+	if opts.Dimensions > 0 {
+		params.Dimensions = openai.Int(int64(opts.Dimensions))
+	}
 	response, err := o.client.Embeddings.New(ctx, params)
 	if err != nil {
 		return nil, err
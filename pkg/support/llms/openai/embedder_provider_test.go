@@ -0,0 +1,51 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+func newTestEmbedderProvider(t *testing.T, requestBody *map[string]any) llms.EmbedderProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requestBody = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(requestBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"object": "list",
+			"model": "text-embedding-3-small",
+			"data": [{"object": "embedding", "index": 0, "embedding": [0.1, 0.2, 0.3]}],
+			"usage": {"prompt_tokens": 4, "total_tokens": 4}
+		}`))
+	}))
+	t.Cleanup(server.Close)
+
+	model, err := llms.GetModel(llms.ModelId{Provider: ModelProviderOpenAI, ID: ModelTextEmbedding3Small})
+	require.NoError(t, err)
+	provider, err := newEmbedderProvider(model, llms.WithBaseUrl(server.URL), llms.WithAPIKey("test-key"))
+	require.NoError(t, err)
+	return provider
+}
+
+func TestOpenAIEmbedder_Dimensions(t *testing.T) {
+	var requestBody map[string]any
+	provider := newTestEmbedderProvider(t, &requestBody)
+
+	response, err := provider.GetEmbeddings(context.Background(), []string{"hello"}, llms.WithDimensions(256))
+	require.NoError(t, err)
+	assert.Equal(t, []llms.FloatVector{{0.1, 0.2, 0.3}}, response.Vectors)
+	assert.Equal(t, "text-embedding-3-small", requestBody["model"])
+	assert.Equal(t, float64(256), requestBody["dimensions"])
+
+	// the default dimensions of the model without the option
+	_, err = provider.GetEmbeddings(context.Background(), []string{"hello"})
+	require.NoError(t, err)
+	assert.NotContains(t, requestBody, "dimensions")
+}
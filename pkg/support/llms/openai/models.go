@@ -11,6 +11,8 @@ import (
 const (
 	// ModelProviderOpenAI is the provider identifier for OpenAI models.
 	ModelProviderOpenAI llms.ModelProvider = "openai"
+	// DefaultOpenAIModel is the default OpenAI model declared to the model registry.
+	DefaultOpenAIModel = ModelGPT41
 	// OpenAIDefaultReasoningEffort is the default reasoning effort for OpenAI models.
 	OpenAIDefaultReasoningEffort = llms.ReasoningEffortMedium
 
@@ -53,9 +55,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       8.00,
 		ContextWindowSize:  1_047_576,
 		DefaultMaxTokens:   20000,
+		MaxOutputTokens:    32_768,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGPT41Mini: {
@@ -71,9 +75,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       1.60,
 		ContextWindowSize:  200_000,
 		DefaultMaxTokens:   20000,
+		MaxOutputTokens:    32_768,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGPT41Nano: {
@@ -89,9 +95,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       0.40,
 		ContextWindowSize:  1_047_576,
 		DefaultMaxTokens:   20000,
+		MaxOutputTokens:    32_768,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGPT45Preview: {
@@ -107,9 +115,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       150.00,
 		ContextWindowSize:  128_000,
 		DefaultMaxTokens:   15000,
+		MaxOutputTokens:    16_384,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGPT4o: {
@@ -125,9 +135,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       10.00,
 		ContextWindowSize:  128_000,
 		DefaultMaxTokens:   4096,
+		MaxOutputTokens:    16_384,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelGPT4oMini: {
@@ -142,9 +154,11 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOutCached: 0.0,
 		CostPer1MOut:       0.60,
 		ContextWindowSize:  128_000,
+		MaxOutputTokens:    16_384,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelO1: {
@@ -160,10 +174,12 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       60.00,
 		ContextWindowSize:  200_000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    100_000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelO1Pro: {
@@ -179,6 +195,7 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       600.00,
 		ContextWindowSize:  200_000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    100_000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
@@ -198,6 +215,7 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOut:       4.40,
 		ContextWindowSize:  128_000,
 		DefaultMaxTokens:   50000,
+		MaxOutputTokens:    65_536,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
@@ -216,10 +234,12 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOutCached: 0.0,
 		CostPer1MOut:       40.00,
 		ContextWindowSize:  200_000,
+		MaxOutputTokens:    100_000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelO3Mini: {
@@ -234,10 +254,12 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOutCached: 0.0,
 		CostPer1MOut:       10.00,
 		ContextWindowSize:  128_000,
+		MaxOutputTokens:    100_000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 	ModelO4Mini: {
@@ -252,10 +274,12 @@ var OpenAIModels = map[string]llms.Model{
 		CostPer1MOutCached: 0.0,
 		CostPer1MOut:       20.00,
 		ContextWindowSize:  128_000,
+		MaxOutputTokens:    100_000,
 		Features: []llms.ModelFeature{
 			llms.ModelFeatureCompletion,
 			llms.ModelFeatureReasoning,
 			llms.ModelFeatureAttachment,
+			llms.ModelFeatureStructuredOutput,
 		},
 	},
 
@@ -323,4 +347,7 @@ func init() {
 			klog.Warningf("Failed to register OpenAI model %s: %v", model.ModelId.ID, err)
 		}
 	}
+	if err := llms.RegisterDefaultModel(llms.ModelId{Provider: ModelProviderOpenAI, ID: DefaultOpenAIModel}); err != nil {
+		klog.Warningf("Failed to register default OpenAI model: %v", err)
+	}
 }
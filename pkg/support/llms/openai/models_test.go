@@ -212,6 +212,20 @@ func TestOpenAIModels_DefaultMaxTokens(t *testing.T) {
 	}
 }
 
+func TestOpenAIModels_MaxOutputTokens(t *testing.T) {
+	// Test that completion models declare how many tokens they can generate
+	for modelID, model := range OpenAIModels {
+		if !model.IsSupport(llms.ModelFeatureCompletion) {
+			continue
+		}
+		t.Run(modelID, func(t *testing.T) {
+			assert.Greater(t, model.MaxOutputTokens, int64(0))
+			assert.Less(t, model.MaxOutputTokens, model.ContextWindowSize)
+			assert.LessOrEqual(t, model.DefaultMaxTokens, model.MaxOutputTokens)
+		})
+	}
+}
+
 func TestOpenAIModels_ApiModelNames(t *testing.T) {
 	// Test that API model names are properly set
 	testCases := []struct {
@@ -243,3 +257,11 @@ func TestOpenAIModels_ApiModelNames(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAIModels_DefaultModel(t *testing.T) {
+	model, err := llms.DefaultModel(ModelProviderOpenAI)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultOpenAIModel, model.ModelId.ID)
+	assert.Equal(t, ModelProviderOpenAI, model.ModelId.Provider)
+	assert.True(t, model.IsSupport(llms.ModelFeatureCompletion))
+}
@@ -0,0 +1,76 @@
+// Package provider provides AI model provider implementations for the agent-go framework.
+// This file contains the price table used to estimate the cost of the model usage.
+package llms
+
+import "sync"
+
+// _pricing is the global price table of the models
+var _pricing = &pricingTable{}
+
+// ModelPricing is the price of the tokens of a model, in dollars per 1000 tokens
+type ModelPricing struct {
+	InputPer1K  float64 // Price of 1000 input tokens
+	OutputPer1K float64 // Price of 1000 output tokens
+}
+
+// RegisterModelPricing sets the price of the tokens of a model, in dollars per 1000 tokens.
+// It overrides the previously registered price of the model and the costs of the model
+// registered with RegisterModel.
+func RegisterModelPricing(modelId ModelId, inputPer1k, outputPer1k float64) {
+	_pricing.set(modelId, ModelPricing{InputPer1K: inputPer1k, OutputPer1K: outputPer1k})
+}
+
+// GetModelPricing retrieves the price of the tokens of a model: the registered price, or else
+// the costs of the registered model. Returns false when the model has no price.
+func GetModelPricing(modelId ModelId) (ModelPricing, bool) {
+	if pricing, exists := _pricing.get(modelId); exists {
+		return pricing, true
+	}
+	model, err := GetModel(modelId)
+	if err != nil || (model.CostPer1MIn == 0 && model.CostPer1MOut == 0) {
+		return ModelPricing{}, false
+	}
+	return ModelPricing{
+		InputPer1K:  model.CostPer1MIn / 1000,
+		OutputPer1K: model.CostPer1MOut / 1000,
+	}, true
+}
+
+// EstimateCost estimates the cost, in dollars, of the usage of a model from its price,
+// see GetModelPricing. The cache tokens are not priced. Returns false when the model has no price.
+func EstimateCost(modelId ModelId, usage UsageMetadata) (float64, bool) {
+	pricing, exists := GetModelPricing(modelId)
+	if !exists {
+		return 0, false
+	}
+	return pricing.Cost(usage), true
+}
+
+// Cost returns the cost, in dollars, of the input and output tokens of the usage
+func (p ModelPricing) Cost(usage UsageMetadata) float64 {
+	return float64(usage.InputTokens)/1000*p.InputPer1K + float64(usage.OutputTokens)/1000*p.OutputPer1K
+}
+
+// pricingTable holds the registered prices of the models, safe for concurrent use
+type pricingTable struct {
+	lock   sync.RWMutex
+	prices map[ModelId]ModelPricing
+}
+
+func (t *pricingTable) set(modelId ModelId, pricing ModelPricing) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.prices == nil {
+		t.prices = make(map[ModelId]ModelPricing)
+	}
+	t.prices[modelId] = pricing
+}
+
+func (t *pricingTable) get(modelId ModelId) (ModelPricing, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	pricing, exists := t.prices[modelId]
+	return pricing, exists
+}
@@ -0,0 +1,48 @@
+package llms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCost_RegisteredPricing(t *testing.T) {
+	modelId := ModelId{Provider: "pricing-test", ID: "priced"}
+	RegisterModelPricing(modelId, 0.5, 1.5)
+
+	pricing, exists := GetModelPricing(modelId)
+	require.True(t, exists)
+	assert.Equal(t, ModelPricing{InputPer1K: 0.5, OutputPer1K: 1.5}, pricing)
+
+	cost, exists := EstimateCost(modelId, UsageMetadata{InputTokens: 2000, OutputTokens: 500, CacheReadTokens: 100})
+	require.True(t, exists)
+	assert.InDelta(t, 1.75, cost, 1e-9)
+
+	// a later registration overrides the price
+	RegisterModelPricing(modelId, 1, 1)
+	cost, _ = EstimateCost(modelId, UsageMetadata{InputTokens: 1000, OutputTokens: 1000})
+	assert.InDelta(t, 2.0, cost, 1e-9)
+}
+
+func TestEstimateCost_ModelCosts(t *testing.T) {
+	modelId := ModelId{Provider: "pricing-test", ID: "registered"}
+	require.NoError(t, RegisterModel(&Model{ModelId: modelId, CostPer1MIn: 3, CostPer1MOut: 15}))
+
+	cost, exists := EstimateCost(modelId, UsageMetadata{InputTokens: 1_000_000, OutputTokens: 100_000})
+	require.True(t, exists)
+	assert.InDelta(t, 4.5, cost, 1e-9)
+
+	_, exists = EstimateCost(ModelId{Provider: "pricing-test", ID: "unknown"}, UsageMetadata{InputTokens: 10})
+	assert.False(t, exists)
+}
+
+func TestUsageMetadata_Add(t *testing.T) {
+	usage := UsageMetadata{}
+	assert.True(t, usage.IsZero())
+
+	usage.Add(UsageMetadata{InputTokens: 10, OutputTokens: 5, CacheReadTokens: 2})
+	usage.Add(UsageMetadata{InputTokens: 1, OutputTokens: 1, CacheCreationTokens: 3})
+	assert.Equal(t, UsageMetadata{InputTokens: 11, OutputTokens: 6, CacheCreationTokens: 3, CacheReadTokens: 2}, usage)
+	assert.False(t, usage.IsZero())
+}
@@ -0,0 +1,58 @@
+package llms
+
+import "github.com/oopslink/agent-go/pkg/commons/errors"
+
+// DefaultMaxResponseBytes is the default cap of the content accumulated from a streamed response
+const DefaultMaxResponseBytes int64 = 16 << 20
+
+// ResponseSizeGuard stops the accumulation of a streamed response once its content, text,
+// reasoning and tool call arguments, exceeds the maximum size of the chat options.
+// Providers check every chunk before accumulating it, and on the first chunk over the
+// limit end the stream with the response received so far and the guard error.
+type ResponseSizeGuard struct {
+	limit    int64
+	received int64
+	exceeded bool
+}
+
+// NewResponseSizeGuard creates a guard with the ChatOptions.MaxResponseBytes limit
+func NewResponseSizeGuard(opts *ChatOptions) *ResponseSizeGuard {
+	limit := DefaultMaxResponseBytes
+	if opts != nil && opts.MaxResponseBytes != 0 {
+		limit = opts.MaxResponseBytes
+	}
+	return &ResponseSizeGuard{limit: limit}
+}
+
+// Allow counts the size of a chunk, it returns false when the chunk would exceed the limit,
+// the chunk and all the following ones must then be dropped
+func (g *ResponseSizeGuard) Allow(size int) bool {
+	if g.exceeded {
+		return false
+	}
+	if g.limit > 0 && g.received+int64(size) > g.limit {
+		g.exceeded = true
+		return false
+	}
+	g.received += int64(size)
+	return true
+}
+
+// Received returns the size of the content accumulated so far
+func (g *ResponseSizeGuard) Received() int64 {
+	return g.received
+}
+
+// Exceeded reports whether the response was truncated
+func (g *ResponseSizeGuard) Exceeded() bool {
+	return g.exceeded
+}
+
+// Err returns the ErrorCodeResponseTooLarge error reporting the truncation, nil if the limit was not exceeded
+func (g *ResponseSizeGuard) Err() error {
+	if !g.exceeded {
+		return nil
+	}
+	return errors.Errorf(ErrorCodeResponseTooLarge,
+		"response truncated at %d bytes, exceeding the limit of %d bytes", g.received, g.limit)
+}
@@ -0,0 +1,38 @@
+package llms
+
+import (
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseSizeGuard(t *testing.T) {
+	guard := NewResponseSizeGuard(&ChatOptions{MaxResponseBytes: 10})
+	assert.True(t, guard.Allow(4))
+	assert.True(t, guard.Allow(6))
+	assert.NoError(t, guard.Err())
+
+	// the chunk over the limit and all the following ones are dropped
+	assert.False(t, guard.Allow(1))
+	assert.False(t, guard.Allow(0))
+	assert.True(t, guard.Exceeded())
+	assert.Equal(t, int64(10), guard.Received())
+
+	err := guard.Err()
+	assert.True(t, errors.IsCode(err, ErrorCodeResponseTooLarge))
+	assert.Contains(t, err.Error(), "truncated at 10 bytes")
+}
+
+func TestResponseSizeGuard_Limits(t *testing.T) {
+	guard := NewResponseSizeGuard(&ChatOptions{})
+	assert.True(t, guard.Allow(int(DefaultMaxResponseBytes)))
+	assert.False(t, guard.Allow(1))
+
+	opts := &ChatOptions{}
+	WithMaxResponseBytes(-1)(opts)
+	guard = NewResponseSizeGuard(opts)
+	assert.True(t, guard.Allow(int(DefaultMaxResponseBytes)))
+	assert.True(t, guard.Allow(int(DefaultMaxResponseBytes)))
+	assert.False(t, guard.Exceeded())
+}
@@ -0,0 +1,49 @@
+package llms
+
+import (
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+)
+
+// RetryPolicy configures how providers retry a failed request with exponential backoff.
+// Zero fields keep the defaults of utils.NewExponentialBackOff and utils.DefaultMaxElapsedTime.
+type RetryPolicy struct {
+	InitialInterval     time.Duration    // Delay before the first retry
+	Multiplier          float64          // Factor to multiply the delay by on each retry
+	MaxInterval         time.Duration    // Maximum delay between retries
+	Jitter              utils.JitterMode // How the delays are randomized
+	RandomizationFactor float64          // Randomization of the delays with utils.JitterProportional
+	MaxElapsedTime      time.Duration    // Maximum total time of the retries, negative for no limit
+}
+
+// BackOff returns the exponential backoff of the policy, the default one for a nil policy.
+func (p *RetryPolicy) BackOff() *utils.ExponentialBackOff {
+	backOff := utils.NewExponentialBackOff()
+	if p == nil {
+		return backOff
+	}
+	if p.InitialInterval > 0 {
+		backOff.InitialInterval = p.InitialInterval
+	}
+	if p.Multiplier > 0 {
+		backOff.Multiplier = p.Multiplier
+	}
+	if p.MaxInterval > 0 {
+		backOff.MaxInterval = p.MaxInterval
+	}
+	if p.RandomizationFactor > 0 {
+		backOff.RandomizationFactor = p.RandomizationFactor
+	}
+	backOff.Jitter = p.Jitter
+	return backOff
+}
+
+// RetryOptions returns the options of utils.Retry applying the policy.
+func (p *RetryPolicy) RetryOptions() []utils.RetryOption {
+	options := []utils.RetryOption{utils.WithBackOff(p.BackOff())}
+	if p == nil || p.MaxElapsedTime == 0 {
+		return options
+	}
+	return append(options, utils.WithMaxElapsedTime(max(p.MaxElapsedTime, 0)))
+}
@@ -0,0 +1,102 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_BackOff(t *testing.T) {
+	// nil and zero policies keep the defaults
+	var policy *RetryPolicy
+	assert.Equal(t, utils.NewExponentialBackOff(), policy.BackOff())
+	assert.Equal(t, utils.NewExponentialBackOff(), (&RetryPolicy{}).BackOff())
+
+	backOff := (&RetryPolicy{
+		InitialInterval: 10 * time.Millisecond,
+		Multiplier:      3,
+		MaxInterval:     50 * time.Millisecond,
+		Jitter:          utils.JitterNone,
+	}).BackOff()
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delays = append(delays, backOff.NextBackOff())
+	}
+	assert.Equal(t, []time.Duration{
+		10 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond,
+	}, delays)
+}
+
+func TestRetryPolicy_RetryOptions(t *testing.T) {
+	opts := &ChatOptions{}
+	WithRetryPolicy(RetryPolicy{
+		InitialInterval: time.Millisecond,
+		Multiplier:      2,
+		MaxInterval:     4 * time.Millisecond,
+		Jitter:          utils.JitterNone,
+		MaxElapsedTime:  20 * time.Millisecond,
+	})(opts)
+
+	var delays []time.Duration
+	attempts := 0
+	start := time.Now()
+	_, err := utils.Retry(context.Background(), func() (string, error) {
+		attempts++
+		return "", errors.New("unavailable")
+	}, append(opts.RetryPolicy.RetryOptions(), utils.WithNotify(func(err error, delay time.Duration) {
+		delays = append(delays, delay)
+	}))...)
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, len(delays)+1, attempts)
+	// the delays follow the policy and stop within the maximum elapsed time
+	if assert.GreaterOrEqual(t, len(delays), 3) {
+		assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}, delays[:3])
+	}
+	for _, delay := range delays[3:] {
+		assert.Equal(t, 4*time.Millisecond, delay)
+	}
+	assert.LessOrEqual(t, len(delays), 7)
+}
+
+func TestProviderOptions_RetryOptions(t *testing.T) {
+	assert.Empty(t, OfProviderOptions().RetryOptions())
+
+	policy := &RetryPolicy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Jitter: utils.JitterNone}
+	retry := func(options []utils.RetryOption) (int, error) {
+		attempts := 0
+		_, err := utils.Retry(context.Background(), func() (string, error) {
+			attempts++
+			return "", errors.New("throttled")
+		}, options...)
+		return attempts, err
+	}
+
+	// the retries of the provider give up after the maximum attempts
+	options := OfProviderOptions(WithRetryMaxAttempts(3), WithRetryMaxElapsed(time.Minute))
+	attempts, err := retry(append(options.RetryOptions(), policy.RetryOptions()...))
+	assert.EqualError(t, err, "throttled")
+	assert.Equal(t, 3, attempts)
+
+	// the maximum elapsed time of the provider bounds the retries without a maximum of attempts
+	options = OfProviderOptions(WithRetryMaxElapsed(20 * time.Millisecond))
+	start := time.Now()
+	attempts, err = retry(append(options.RetryOptions(), policy.RetryOptions()...))
+	assert.EqualError(t, err, "throttled")
+	assert.Greater(t, attempts, 1)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the maximum elapsed time of the policy of a chat overrides the one of the provider
+	options = OfProviderOptions(WithRetryMaxElapsed(time.Hour))
+	withElapsed := &RetryPolicy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond,
+		Jitter: utils.JitterNone, MaxElapsedTime: 20 * time.Millisecond}
+	start = time.Now()
+	_, err = retry(append(options.RetryOptions(), withElapsed.RetryOptions()...))
+	assert.EqualError(t, err, "throttled")
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,72 @@
+package llms
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Coerce converts the string-encoded numbers and booleans of the value, as decoded from JSON,
+// to the types declared by the schema: "42" for an integer or "4.2" for a number become
+// float64 like JSON numbers, "true"/"false" for a boolean become bool. Objects and arrays
+// are coerced recursively into copies, the value itself is never modified.
+// Strings that do not encode the declared type are kept as is, so real type errors still surface.
+func (s *Schema) Coerce(value any) any {
+	if s == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case string:
+		return s.coerceString(v)
+	case map[string]any:
+		if s.Type != TypeObject || len(s.Properties) == 0 {
+			return value
+		}
+		coerced := make(map[string]any, len(v))
+		for name, property := range v {
+			coerced[name] = s.Properties[name].Coerce(property)
+		}
+		return coerced
+	case []any:
+		if s.Type != TypeArray || s.Items == nil {
+			return value
+		}
+		coerced := make([]any, len(v))
+		for idx, item := range v {
+			coerced[idx] = s.Items.Coerce(item)
+		}
+		return coerced
+	}
+	return value
+}
+
+// CoerceArguments coerces the arguments of a tool call with the parameters schema, see Schema.Coerce
+func (s *Schema) CoerceArguments(arguments map[string]any) map[string]any {
+	if arguments == nil {
+		return nil
+	}
+	coerced, _ := s.Coerce(arguments).(map[string]any)
+	return coerced
+}
+
+func (s *Schema) coerceString(value string) any {
+	trimmed := strings.TrimSpace(value)
+	switch s.Type {
+	case TypeInteger:
+		if n, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return float64(n)
+		}
+	case TypeNumber:
+		if n, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return n
+		}
+	case TypeBoolean:
+		switch strings.ToLower(trimmed) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return value
+}
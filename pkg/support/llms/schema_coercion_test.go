@@ -0,0 +1,72 @@
+package llms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_CoerceArguments(t *testing.T) {
+	schema := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"count":   {Type: TypeInteger},
+			"ratio":   {Type: TypeNumber},
+			"enabled": {Type: TypeBoolean},
+			"name":    {Type: TypeString},
+			"ids":     {Type: TypeArray, Items: &Schema{Type: TypeInteger}},
+			"nested": {Type: TypeObject, Properties: map[string]*Schema{
+				"flag": {Type: TypeBoolean},
+			}},
+		},
+	}
+
+	arguments := map[string]any{
+		"count":   "42",
+		"ratio":   " 0.5 ",
+		"enabled": "true",
+		"name":    "7",
+		"ids":     []any{"1", float64(2)},
+		"nested":  map[string]any{"flag": "FALSE"},
+		"extra":   "1",
+	}
+	coerced := schema.CoerceArguments(arguments)
+
+	assert.Equal(t, map[string]any{
+		"count":   float64(42),
+		"ratio":   0.5,
+		"enabled": true,
+		"name":    "7",
+		"ids":     []any{float64(1), float64(2)},
+		"nested":  map[string]any{"flag": false},
+		"extra":   "1",
+	}, coerced)
+	require.NoError(t, schema.Validate(coerced))
+
+	// the arguments are not modified
+	assert.Equal(t, "42", arguments["count"])
+	assert.Equal(t, "FALSE", arguments["nested"].(map[string]any)["flag"])
+}
+
+func TestSchema_CoerceKeepsWrongTypes(t *testing.T) {
+	schema := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"count":   {Type: TypeInteger},
+			"enabled": {Type: TypeBoolean},
+		},
+	}
+
+	coerced := schema.CoerceArguments(map[string]any{"count": "4.5", "enabled": "yes"})
+	assert.Equal(t, map[string]any{"count": "4.5", "enabled": "yes"}, coerced)
+
+	err := schema.Validate(coerced)
+	var validationErr *SchemaValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Violations, 2)
+
+	var nilSchema *Schema
+	assert.Equal(t, "42", nilSchema.Coerce("42"))
+	assert.Nil(t, schema.CoerceArguments(nil))
+}
@@ -0,0 +1,237 @@
+package llms
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+)
+
+var _ errors.WithErrorCode = &SchemaValidationError{}
+
+// SchemaViolation describes a value not matching its schema
+type SchemaViolation struct {
+	Path     string     // Path of the value, e.g. "items[2].name", empty for the root value
+	Expected SchemaType // Type expected by the schema
+	Actual   string     // Type of the value, empty when the value is missing
+	Message  string     // What is wrong
+	Schema   *Schema    // Schema of the value, nil when the schema does not describe it
+}
+
+func (v SchemaViolation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return fmt.Sprintf("field %q: %s", v.Path, v.Message)
+}
+
+// SchemaValidationError lists the violations of a value validated against a schema
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	problems := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		problems = append(problems, violation.String())
+	}
+	code := e.GetCode()
+	return fmt.Sprintf("[ERR,%s]: %s", code.String(), strings.Join(problems, "; "))
+}
+
+func (e *SchemaValidationError) GetCode() errors.ErrorCode {
+	return ErrorCodeSchemaValidationFailed
+}
+
+// Validate checks the value, as decoded from JSON, against the schema: types match and
+// required properties are present. Properties not in the schema are allowed, as are the null
+// values of the optional properties, taken as absent.
+// It returns a *SchemaValidationError listing every violation, nil if the value is valid.
+func (s *Schema) Validate(value any) error {
+	var violations []SchemaViolation
+	s.validate("", value, &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SchemaValidationError{Violations: violations}
+}
+
+// Example returns an example value matching the schema, e.g. to show a model the values
+// expected from it. Objects get their required properties, all of them when none is required.
+func (s *Schema) Example() any {
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case TypeString:
+		return "text"
+	case TypeInteger:
+		return 1
+	case TypeNumber:
+		return 1.5
+	case TypeBoolean:
+		return true
+	case TypeArray:
+		if s.Items == nil {
+			return []any{}
+		}
+		return []any{s.Items.Example()}
+	case TypeObject:
+		names := s.Required
+		if len(names) == 0 {
+			for name := range s.Properties {
+				names = append(names, name)
+			}
+		}
+		example := make(map[string]any, len(names))
+		for _, name := range names {
+			example[name] = s.Properties[name].Example()
+		}
+		return example
+	default:
+		return nil
+	}
+}
+
+func (s *Schema) validate(path string, value any, violations *[]SchemaViolation) {
+	if s == nil || s.Type == "" {
+		return
+	}
+
+	actual := jsonTypeOf(value)
+	if !s.matchesType(value, actual) {
+		*violations = append(*violations, SchemaViolation{
+			Path:     path,
+			Expected: s.Type,
+			Actual:   actual,
+			Message:  fmt.Sprintf("expected %s, got %s", s.Type, describeValue(value, actual)),
+			Schema:   s,
+		})
+		return
+	}
+
+	switch s.Type {
+	case TypeObject:
+		object := reflect.ValueOf(value)
+		for _, name := range s.Required {
+			if object.MapIndex(reflect.ValueOf(name)).IsValid() {
+				continue
+			}
+			expected := SchemaType("")
+			property := s.Properties[name]
+			if property != nil {
+				expected = property.Type
+			}
+			message := "is required"
+			if expected != "" {
+				message = fmt.Sprintf("is required, expected %s", expected)
+			}
+			*violations = append(*violations, SchemaViolation{
+				Path:     joinPath(path, name),
+				Expected: expected,
+				Message:  message,
+				Schema:   property,
+			})
+		}
+		// validate properties in a stable order
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := object.MapIndex(reflect.ValueOf(name))
+			if !property.IsValid() {
+				continue
+			}
+			// models often send null for the optional properties they leave out
+			if property.Interface() == nil && !slices.Contains(s.Required, name) {
+				continue
+			}
+			s.Properties[name].validate(joinPath(path, name), property.Interface(), violations)
+		}
+	case TypeArray:
+		array := reflect.ValueOf(value)
+		for idx := 0; idx < array.Len(); idx++ {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, idx), array.Index(idx).Interface(), violations)
+		}
+	}
+}
+
+func (s *Schema) matchesType(value any, actual string) bool {
+	switch s.Type {
+	case TypeInteger:
+		return isInteger(value)
+	case TypeNumber:
+		return actual == "number"
+	case TypeObject:
+		if actual != "object" {
+			return false
+		}
+		return reflect.TypeOf(value).Key().Kind() == reflect.String
+	default:
+		return actual == string(s.Type)
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonTypeOf returns the JSON type name of a value
+func jsonTypeOf(value any) string {
+	if value == nil {
+		return "null"
+	}
+	if _, ok := value.(json.Number); ok {
+		return "number"
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return reflect.TypeOf(value).String()
+	}
+}
+
+func isInteger(value any) bool {
+	switch v := value.(type) {
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	case float64:
+		return v == math.Trunc(v) && !math.IsInf(v, 0)
+	case float32:
+		return float64(v) == math.Trunc(float64(v)) && !math.IsInf(float64(v), 0)
+	}
+	return jsonTypeOf(value) == "number"
+}
+
+func describeValue(value any, actual string) string {
+	switch actual {
+	case "string", "number", "boolean":
+		raw, err := json.Marshal(value)
+		if err == nil && len(raw) <= 40 {
+			return fmt.Sprintf("%s %s", actual, raw)
+		}
+	}
+	return actual
+}
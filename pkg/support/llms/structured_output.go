@@ -0,0 +1,150 @@
+package llms
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StructuredFormatJSON = "json"
+	StructuredFormatYAML = "yaml"
+)
+
+var codeFencePattern = regexp.MustCompile("(?s)```[ \\t]*([A-Za-z0-9_-]*)[^\\n]*\\n(.*?)```")
+
+// keyValueLinePattern matches a "key: value" line of a flat YAML object, the key being a single word
+var keyValueLinePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*:(\s.*)?$`)
+
+// StructuredOutput is a JSON or YAML value extracted from free-form text
+type StructuredOutput struct {
+	Value  any    // The parsed value, a map[string]any or a []any decoded as JSON would be
+	Format string // StructuredFormatJSON or StructuredFormatYAML
+	Raw    string // The text the value was parsed from
+}
+
+// ExtractStructured extracts the first JSON or YAML object (or array) from free-form model text:
+// code fences (```json, ```yaml or untagged) first, then JSON inline amid prose, then the whole
+// text as YAML when it is made of "key: value" lines only, at least two of them: a sentence
+// holding a colon is not taken for an object.
+// When schema is not nil the value is validated against it, violations are reported
+// by a *SchemaValidationError along with the extracted output.
+func ExtractStructured(text string, schema *Schema) (*StructuredOutput, error) {
+	output := extractStructured(text)
+	if output == nil {
+		return nil, errors.Errorf(ErrorCodeStructuredOutputNotFound,
+			"no JSON or YAML object found in text")
+	}
+	if err := schema.Validate(output.Value); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+func extractStructured(text string) *StructuredOutput {
+	// fenced blocks, in order
+	for _, match := range codeFencePattern.FindAllStringSubmatch(text, -1) {
+		lang, body := strings.ToLower(match[1]), match[2]
+		switch lang {
+		case "json", "jsonc", "json5":
+			if output := parseJSON(body); output != nil {
+				return output
+			}
+		case "yaml", "yml":
+			if output := parseYAML(body); output != nil {
+				return output
+			}
+		case "":
+			if output := parseJSON(body); output != nil {
+				return output
+			}
+			if output := parseKeyValueYAML(body); output != nil {
+				return output
+			}
+		}
+	}
+
+	// inline JSON amid prose
+	for idx := 0; idx < len(text); idx++ {
+		if text[idx] != '{' && text[idx] != '[' {
+			continue
+		}
+		if output := decodeJSONPrefix(text[idx:]); output != nil {
+			return output
+		}
+	}
+
+	// the whole text as YAML
+	return parseKeyValueYAML(text)
+}
+
+// parseKeyValueYAML parses the text as YAML when all its non-blank lines are "key: value" pairs,
+// at least two of them, the untagged text being prose otherwise
+func parseKeyValueYAML(text string) *StructuredOutput {
+	pairs := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !keyValueLinePattern.MatchString(line) {
+			return nil
+		}
+		pairs++
+	}
+	if pairs < 2 {
+		return nil
+	}
+	return parseYAML(text)
+}
+
+func parseJSON(text string) *StructuredOutput {
+	text = strings.TrimSpace(text)
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil || !isContainer(value) {
+		return nil
+	}
+	return &StructuredOutput{Value: value, Format: StructuredFormatJSON, Raw: text}
+}
+
+// decodeJSONPrefix decodes the JSON value at the beginning of the text, ignoring what follows
+func decodeJSONPrefix(text string) *StructuredOutput {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	var value any
+	if err := decoder.Decode(&value); err != nil || !isContainer(value) {
+		return nil
+	}
+	return &StructuredOutput{
+		Value:  value,
+		Format: StructuredFormatJSON,
+		Raw:    text[:decoder.InputOffset()],
+	}
+}
+
+func parseYAML(text string) *StructuredOutput {
+	var value any
+	if err := yaml.Unmarshal([]byte(text), &value); err != nil || !isContainer(value) {
+		return nil
+	}
+	// normalize to the values decoded from JSON, e.g. float64 numbers
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var normalized any
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil
+	}
+	return &StructuredOutput{Value: normalized, Format: StructuredFormatYAML, Raw: strings.TrimSpace(text)}
+}
+
+func isContainer(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package llms
+
+import (
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPersonSchema() *Schema {
+	return &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"name": {Type: TypeString},
+			"age":  {Type: TypeInteger},
+			"tags": {Type: TypeArray, Items: &Schema{Type: TypeString}},
+		},
+		Required: []string{"name", "age"},
+	}
+}
+
+func TestExtractStructured_FencedJSON(t *testing.T) {
+	text := "Sure! Here is the person you asked for:\n\n```json\n{\"name\": \"Ada\", \"age\": 36}\n```\n\nLet me know if you need more."
+
+	output, err := ExtractStructured(text, newTestPersonSchema())
+	require.NoError(t, err)
+	assert.Equal(t, StructuredFormatJSON, output.Format)
+	assert.Equal(t, map[string]any{"name": "Ada", "age": float64(36)}, output.Value)
+	assert.Equal(t, `{"name": "Ada", "age": 36}`, output.Raw)
+}
+
+func TestExtractStructured_InlineJSON(t *testing.T) {
+	text := `The answer is {"name": "Alan", "age": 41, "tags": ["math", "computing"]} as requested, {"ignored": true}.`
+
+	output, err := ExtractStructured(text, newTestPersonSchema())
+	require.NoError(t, err)
+	assert.Equal(t, StructuredFormatJSON, output.Format)
+	assert.Equal(t, "Alan", output.Value.(map[string]any)["name"])
+	assert.Equal(t, `{"name": "Alan", "age": 41, "tags": ["math", "computing"]}`, output.Raw)
+
+	// braces in prose that are not JSON are skipped
+	output, err = ExtractStructured(`Use {curly} braces: [1, 2, 3]`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{float64(1), float64(2), float64(3)}, output.Value)
+}
+
+func TestExtractStructured_YAML(t *testing.T) {
+	fenced := "Here it is:\n```yaml\nname: Grace\nage: 85\ntags:\n  - navy\n  - cobol\n```"
+	output, err := ExtractStructured(fenced, newTestPersonSchema())
+	require.NoError(t, err)
+	assert.Equal(t, StructuredFormatYAML, output.Format)
+	assert.Equal(t, map[string]any{
+		"name": "Grace",
+		"age":  float64(85),
+		"tags": []any{"navy", "cobol"},
+	}, output.Value)
+
+	// untagged fences and bare YAML
+	output, err = ExtractStructured("```\nname: Grace\nage: 85\n```", newTestPersonSchema())
+	require.NoError(t, err)
+	assert.Equal(t, StructuredFormatYAML, output.Format)
+
+	output, err = ExtractStructured("name: Grace\nage: 85\n", newTestPersonSchema())
+	require.NoError(t, err)
+	assert.Equal(t, "Grace", output.Value.(map[string]any)["name"])
+}
+
+func TestExtractStructured_InvalidContent(t *testing.T) {
+	_, err := ExtractStructured("I could not find anything, sorry.", nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeStructuredOutputNotFound))
+
+	_, err = ExtractStructured("```json\n{\"name\": \"Ada\",\n```", nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeStructuredOutputNotFound))
+
+	_, err = ExtractStructured("", nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeStructuredOutputNotFound))
+
+	// prose holding a colon is not YAML
+	_, err = ExtractStructured("Sorry: I could not find the answer.", nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeStructuredOutputNotFound))
+	_, err = ExtractStructured("Here is the problem: the page is empty.\nI tried twice.", nil)
+	assert.True(t, errors.IsCode(err, ErrorCodeStructuredOutputNotFound))
+}
+
+func TestExtractStructured_ValidationError(t *testing.T) {
+	output, err := ExtractStructured(`{"age": "36", "tags": ["math", 42]}`, newTestPersonSchema())
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, ErrorCodeSchemaValidationFailed))
+	// the extracted value is returned along with the violations
+	require.NotNil(t, output)
+
+	var validationErr *SchemaValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []SchemaViolation{
+		{Path: "name", Expected: TypeString, Message: "is required, expected string", Schema: &Schema{Type: TypeString}},
+		{Path: "age", Expected: TypeInteger, Actual: "string", Message: `expected integer, got string "36"`,
+			Schema: &Schema{Type: TypeInteger}},
+		{Path: "tags[1]", Expected: TypeString, Actual: "number", Message: "expected string, got number 42",
+			Schema: &Schema{Type: TypeString}},
+	}, validationErr.Violations)
+	assert.Contains(t, err.Error(), `field "age": expected integer, got string "36"`)
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := newTestPersonSchema()
+
+	assert.NoError(t, schema.Validate(map[string]any{"name": "Ada", "age": float64(36), "extra": true}))
+	assert.NoError(t, schema.Validate(map[string]any{"name": "Ada", "age": 36, "tags": []string{"a"}}))
+	assert.Error(t, schema.Validate(map[string]any{"name": "Ada", "age": 36.5}))
+	assert.Error(t, schema.Validate([]any{"not", "an", "object"}))
+	assert.Error(t, schema.Validate(nil))
+
+	// schemas without type accept anything
+	assert.NoError(t, (&Schema{}).Validate("anything"))
+	var nilSchema *Schema
+	assert.NoError(t, nilSchema.Validate(42))
+
+	err := (&Schema{Type: TypeBoolean}).Validate("true")
+	assert.EqualError(t, err, `[ERR,SchemaValidationFailed ]: expected boolean, got string "true"`)
+}
+
+func TestSchema_Example(t *testing.T) {
+	schema := newTestPersonSchema()
+
+	// objects get their required properties
+	example := schema.Example()
+	assert.Equal(t, map[string]any{"name": "text", "age": 1}, example)
+	assert.NoError(t, schema.Validate(example))
+
+	// all of them when none is required
+	schema.Required = nil
+	assert.Equal(t, map[string]any{"name": "text", "age": 1, "tags": []any{"text"}}, schema.Example())
+
+	assert.Equal(t, 1.5, (&Schema{Type: TypeNumber}).Example())
+	assert.Equal(t, true, (&Schema{Type: TypeBoolean}).Example())
+	assert.Equal(t, []any{}, (&Schema{Type: TypeArray}).Example())
+	assert.Nil(t, (*Schema)(nil).Example())
+}
@@ -0,0 +1,93 @@
+package llms
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/oopslink/agent-go/pkg/support/tracing"
+)
+
+// Attributes of the spans of the chats, following the OpenTelemetry conventions of the generative AI
+const (
+	AttributeSystem        = attribute.Key("gen_ai.system")
+	AttributeRequestModel  = attribute.Key("gen_ai.request.model")
+	AttributeInputTokens   = attribute.Key("gen_ai.usage.input_tokens")
+	AttributeOutputTokens  = attribute.Key("gen_ai.usage.output_tokens")
+	AttributeFinishReasons = attribute.Key("gen_ai.response.finish_reasons")
+)
+
+// WithTracing wraps the chat provider so that each Send of its chats is traced by a span, see
+// tracing.SetTracerProvider. The providers created by NewChatProvider are already traced.
+func WithTracing(provider ChatProvider) ChatProvider {
+	if _, ok := provider.(*tracedChatProvider); ok {
+		return provider
+	}
+	return &tracedChatProvider{ChatProvider: provider}
+}
+
+type tracedChatProvider struct {
+	ChatProvider
+}
+
+func (p *tracedChatProvider) NewChat(systemPrompt string, model *Model) (Chat, error) {
+	chat, err := p.ChatProvider.NewChat(systemPrompt, model)
+	if err != nil {
+		return nil, err
+	}
+	var modelId ModelId
+	if model != nil {
+		modelId = model.ModelId
+	}
+	return &tracedChat{Chat: chat, modelId: modelId}, nil
+}
+
+type tracedChat struct {
+	Chat
+	modelId ModelId
+}
+
+// Send traces the request in a span ended once the responses are consumed, holding the usage and
+// the finish reason of the last response
+func (c *tracedChat) Send(ctx context.Context, messages []*Message, options ...ChatOption) (ChatResponseIterator, error) {
+	ctx, span := tracing.StartSpan(ctx, "chat "+c.modelId.ID,
+		AttributeSystem.String(string(c.modelId.Provider)),
+		AttributeRequestModel.String(c.modelId.ID))
+
+	responses, err := c.Chat.Send(ctx, messages, options...)
+	if err != nil {
+		tracing.EndSpan(span, err)
+		return nil, err
+	}
+
+	return func(yield func(*ChatResponse, error) bool) {
+		var usage UsageMetadata
+		var finishReason FinishReason
+		var lastErr error
+		defer func() {
+			span.SetAttributes(
+				AttributeInputTokens.Int64(usage.InputTokens),
+				AttributeOutputTokens.Int64(usage.OutputTokens))
+			if finishReason != "" {
+				span.SetAttributes(AttributeFinishReasons.StringSlice([]string{string(finishReason)}))
+			}
+			tracing.EndSpan(span, lastErr)
+		}()
+
+		for response, err := range responses {
+			if err != nil {
+				lastErr = err
+			} else if response != nil {
+				if response.Usage != (UsageMetadata{}) {
+					usage = response.Usage
+				}
+				if response.FinishReason != "" {
+					finishReason = response.FinishReason
+				}
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,103 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/oopslink/agent-go/pkg/support/tracing"
+)
+
+// streamingChatProvider streams the responses, or fails with the error
+type streamingChatProvider struct {
+	mockChatProvider
+	responses []*ChatResponse
+	err       error
+}
+
+func (p *streamingChatProvider) NewChat(systemPrompt string, model *Model) (Chat, error) {
+	return p, nil
+}
+
+func (p *streamingChatProvider) Send(ctx context.Context, messages []*Message, options ...ChatOption) (ChatResponseIterator, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return func(yield func(*ChatResponse, error) bool) {
+		for _, response := range p.responses {
+			if !yield(response, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		tracing.SetTracerProvider(nil)
+	})
+	return recorder
+}
+
+func TestWithTracing(t *testing.T) {
+	recorder := newSpanRecorder(t)
+	provider := WithTracing(&streamingChatProvider{responses: []*ChatResponse{
+		{Message: Message{Parts: []Part{&TextPart{Text: "Hello"}}}},
+		{Message: Message{Parts: []Part{&TextPart{Text: " world"}}},
+			Usage: UsageMetadata{InputTokens: 12, OutputTokens: 3}, FinishReason: FinishReasonNormalEnd},
+	}})
+	assert.Same(t, provider, WithTracing(provider))
+
+	model := &Model{ModelId: ModelId{Provider: "openai", ID: "gpt-4o"}}
+	chat, err := provider.NewChat("", model)
+	require.NoError(t, err)
+	responses, err := chat.Send(context.Background(), []*Message{NewUserMessage("Hi")})
+	require.NoError(t, err)
+
+	// the span ends once the responses are consumed
+	assert.Empty(t, recorder.Ended())
+	var count int
+	for _, err := range responses {
+		require.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 2, count)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "chat gpt-4o", spans[0].Name())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		AttributeSystem.String("openai"),
+		AttributeRequestModel.String("gpt-4o"),
+		AttributeInputTokens.Int64(12),
+		AttributeOutputTokens.Int64(3),
+		AttributeFinishReasons.StringSlice([]string{"normal_end"}),
+	}, spans[0].Attributes())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestWithTracing_SendFailed(t *testing.T) {
+	recorder := newSpanRecorder(t)
+	provider := WithTracing(&streamingChatProvider{err: fmt.Errorf("rate limited")})
+
+	chat, err := provider.NewChat("", &Model{ModelId: ModelId{Provider: "openai", ID: "gpt-4o"}})
+	require.NoError(t, err)
+	_, err = chat.Send(context.Background(), []*Message{NewUserMessage("Hi")})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "rate limited", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
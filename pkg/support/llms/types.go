@@ -24,6 +24,19 @@ type UsageMetadata struct {
 	CacheReadTokens     int64 // Number of tokens read from cache (if applicable)
 }
 
+// Add adds the tokens of the other usage to the usage, e.g. to total the usage of several requests
+func (u *UsageMetadata) Add(other UsageMetadata) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+}
+
+// IsZero reports whether no token is used
+func (u *UsageMetadata) IsZero() bool {
+	return *u == UsageMetadata{}
+}
+
 func (u *UsageMetadata) AsMap() map[string]float64 {
 	return map[string]float64{
 		"input_tokens":          float64(u.InputTokens),
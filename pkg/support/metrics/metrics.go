@@ -0,0 +1,251 @@
+// Package metrics measures the agents with Prometheus metrics computed from the events of the
+// agents published on an event bus: the responses and their latency, the tokens used by the
+// models and the tool calls and their errors.
+//
+// The Collector is registered with a Prometheus registry and subscribed to the event bus:
+//
+//	collector := metrics.NewCollector()
+//	prometheus.MustRegister(collector)
+//	_, err := collector.Subscribe(bus)
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+// DefaultNamespace is the namespace of the metrics
+const DefaultNamespace = "agent"
+
+// Statuses of the responses, the label "status" of the responses counter
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusAborted   = "aborted"
+)
+
+// Kinds of tokens, the label "kind" of the tokens histogram
+const (
+	TokenKindInput  = "input"
+	TokenKindOutput = "output"
+)
+
+// DefaultMaxTurnAge is the age after which a response never ended is no longer tracked
+const DefaultMaxTurnAge = time.Hour
+
+// defaultTokenBuckets spread from 16 to 131072 tokens
+var defaultTokenBuckets = prometheus.ExponentialBuckets(16, 2, 14)
+
+// CollectorOption configures the collector
+type CollectorOption func(*collectorOptions)
+
+type collectorOptions struct {
+	namespace      string
+	latencyBuckets []float64
+	tokenBuckets   []float64
+	maxTurnAge     time.Duration
+}
+
+// WithNamespace sets the namespace of the metrics, DefaultNamespace by default
+func WithNamespace(namespace string) CollectorOption {
+	return func(o *collectorOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithLatencyBuckets sets the buckets of the turn latency histogram, in seconds
+func WithLatencyBuckets(buckets []float64) CollectorOption {
+	return func(o *collectorOptions) {
+		o.latencyBuckets = buckets
+	}
+}
+
+// WithTokenBuckets sets the buckets of the tokens histogram
+func WithTokenBuckets(buckets []float64) CollectorOption {
+	return func(o *collectorOptions) {
+		o.tokenBuckets = buckets
+	}
+}
+
+// WithMaxTurnAge sets the age after which the start of a response never ended is forgotten,
+// DefaultMaxTurnAge by default: its end was missed, e.g. published on another bus
+func WithMaxTurnAge(age time.Duration) CollectorOption {
+	return func(o *collectorOptions) {
+		o.maxTurnAge = age
+	}
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// Collector is a prometheus.Collector of the metrics of the agent events it handles
+type Collector struct {
+	responses   *prometheus.CounterVec
+	turnLatency prometheus.Histogram
+	tokens      *prometheus.HistogramVec
+	toolCalls   *prometheus.CounterVec
+	toolErrors  *prometheus.CounterVec
+
+	maxTurnAge time.Duration
+	lock       sync.Mutex
+	started    map[string]time.Time // the start of the responses in progress, by trace id
+}
+
+// NewCollector creates a collector of the metrics of the agent events
+func NewCollector(opts ...CollectorOption) *Collector {
+	options := &collectorOptions{
+		namespace:      DefaultNamespace,
+		latencyBuckets: prometheus.DefBuckets,
+		tokenBuckets:   defaultTokenBuckets,
+		maxTurnAge:     DefaultMaxTurnAge,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &Collector{
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.namespace,
+			Name:      "responses_total",
+			Help:      "Number of responses of the agents, by status.",
+		}, []string{"status"}),
+		turnLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: options.namespace,
+			Name:      "turn_duration_seconds",
+			Help:      "Time from the start to the end of the responses of the agents.",
+			Buckets:   options.latencyBuckets,
+		}),
+		tokens: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: options.namespace,
+			Name:      "tokens",
+			Help:      "Tokens used by the models per request, by provider, model and kind.",
+			Buckets:   options.tokenBuckets,
+		}, []string{"provider", "model", "kind"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of tool calls requested by the models, by tool.",
+		}, []string{"tool"}),
+		toolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: options.namespace,
+			Name:      "tool_errors_total",
+			Help:      "Number of tool calls that failed, by tool.",
+		}, []string{"tool"}),
+		maxTurnAge: options.maxTurnAge,
+		started:    make(map[string]time.Time),
+	}
+}
+
+// Topics are the topics of the events handled by the collector
+func (c *Collector) Topics() []string {
+	return []string{
+		agent.EventTypeAgentResponseStart,
+		agent.EventTypeAgentResponseEnd,
+		agent.EventTypeAgentUsage,
+		agent.EventTypeExternalAction,
+		agent.EventTypeExternalActionResult,
+	}
+}
+
+// Subscribe subscribes the collector to the topics of the event bus, returning the ids of the subscriptions
+func (c *Collector) Subscribe(bus *eventbus.EventBus) ([]string, error) {
+	topics := c.Topics()
+	ids := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		id, err := bus.Subscribe(topic, c.Handle, false, 0)
+		if err != nil {
+			for i, subscribed := range ids {
+				_ = bus.Unsubscribe(topics[i], subscribed)
+			}
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Handle updates the metrics with the event, it is an eventbus.EventHandler
+func (c *Collector) Handle(ctx context.Context, event *eventbus.Event) error {
+	switch data := event.Data.(type) {
+	case *agent.AgentResponseStart:
+		c.lock.Lock()
+		c.evictStarted(event.Timestamp)
+		c.started[data.TraceId] = event.Timestamp
+		c.lock.Unlock()
+	case *agent.AgentResponseEnd:
+		c.responses.WithLabelValues(responseStatus(data)).Inc()
+		c.lock.Lock()
+		start, ok := c.started[data.TraceId]
+		delete(c.started, data.TraceId)
+		c.lock.Unlock()
+		if ok {
+			c.turnLatency.Observe(event.Timestamp.Sub(start).Seconds())
+		}
+	case *agent.AgentUsage:
+		provider, model := string(data.ModelId.Provider), data.ModelId.ID
+		c.tokens.WithLabelValues(provider, model, TokenKindInput).Observe(float64(data.Usage.InputTokens))
+		c.tokens.WithLabelValues(provider, model, TokenKindOutput).Observe(float64(data.Usage.OutputTokens))
+	case *agent.ExternalAction:
+		if data.ToolCall != nil {
+			c.toolCalls.WithLabelValues(data.ToolCall.Name).Inc()
+		}
+	case *agent.ExternalActionResult:
+		if data.ToolCallResult != nil && isFailedToolCall(data.ToolCallResult) {
+			c.toolErrors.WithLabelValues(data.ToolCallResult.Name).Inc()
+		}
+	}
+	return nil
+}
+
+// evictStarted forgets the responses started for longer than the max turn age, their end was missed
+func (c *Collector) evictStarted(now time.Time) {
+	for traceId, start := range c.started {
+		if now.Sub(start) > c.maxTurnAge {
+			delete(c.started, traceId)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.responses.Describe(ch)
+	c.turnLatency.Describe(ch)
+	c.tokens.Describe(ch)
+	c.toolCalls.Describe(ch)
+	c.toolErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.responses.Collect(ch)
+	c.turnLatency.Collect(ch)
+	c.tokens.Collect(ch)
+	c.toolCalls.Collect(ch)
+	c.toolErrors.Collect(ch)
+}
+
+func responseStatus(end *agent.AgentResponseEnd) string {
+	switch {
+	case end.Abort:
+		return StatusAborted
+	case end.Error != nil || end.FinishReason == llms.FinishReasonError:
+		return StatusFailed
+	default:
+		return StatusCompleted
+	}
+}
+
+// isFailedToolCall tells whether the result is the one of a failed call, reported by the tool
+// collection with "success": false or by the agent with the state "InvokeFailed"
+func isFailedToolCall(result *llms.ToolCallResult) bool {
+	if success, ok := result.Result["success"].(bool); ok && !success {
+		return true
+	}
+	return result.Result["state"] == "InvokeFailed"
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oopslink/agent-go/pkg/core/agent"
+	"github.com/oopslink/agent-go/pkg/support/eventbus"
+	"github.com/oopslink/agent-go/pkg/support/llms"
+)
+
+func publish(t *testing.T, bus *eventbus.EventBus, events ...*eventbus.Event) {
+	for _, event := range events {
+		require.NoError(t, bus.Publish(event))
+	}
+}
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector(WithLatencyBuckets([]float64{1, 5}), WithTokenBuckets([]float64{50}))
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	bus := eventbus.NewEventBus()
+	ids, err := collector.Subscribe(bus)
+	require.NoError(t, err)
+	assert.Len(t, ids, len(collector.Topics()))
+
+	// a completed response of 2 seconds, calling a tool that fails
+	start := agent.NewAgentResponseStartEvent("t1")
+	toolCall := &llms.ToolCall{ToolCallId: "c1", Name: "fetch"}
+	end := agent.NewAgentResponseEndEvent("t1", &agent.AgentResponseEnd{FinishReason: llms.FinishReasonNormalEnd})
+	end.Timestamp = start.Timestamp.Add(2 * time.Second)
+	publish(t, bus,
+		start,
+		agent.NewToolCallEvent(toolCall),
+		agent.NewFailedToolCallEvent("c1", "fetch", fmt.Errorf("timeout")),
+		agent.NewToolCallEvent(&llms.ToolCall{ToolCallId: "c2", Name: "fetch"}),
+		agent.NewToolCallResultEvent(&llms.ToolCallResult{ToolCallId: "c2", Name: "fetch", Result: map[string]any{"success": true}}),
+		agent.NewAgentUsageEvent("t1", llms.ModelId{Provider: "openai", ID: "gpt-4o"}, llms.UsageMetadata{InputTokens: 100, OutputTokens: 20}),
+		end)
+
+	// a failed response ending at once, and an aborted response
+	start = agent.NewAgentResponseStartEvent("t2")
+	end = agent.NewAgentResponseEndEvent("t2", &agent.AgentResponseEnd{Error: fmt.Errorf("boom"), FinishReason: llms.FinishReasonError})
+	end.Timestamp = start.Timestamp
+	publish(t, bus,
+		start,
+		end,
+		agent.NewAgentResponseEndEvent("t3", &agent.AgentResponseEnd{Abort: true, FinishReason: llms.FinishReasonCanceled}))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.responses.WithLabelValues(StatusCompleted)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.responses.WithLabelValues(StatusFailed)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.responses.WithLabelValues(StatusAborted)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.toolCalls.WithLabelValues("fetch")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.toolErrors.WithLabelValues("fetch")))
+
+	// the latency of the responses started, and the tokens by kind
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP agent_turn_duration_seconds Time from the start to the end of the responses of the agents.
+# TYPE agent_turn_duration_seconds histogram
+agent_turn_duration_seconds_bucket{le="1"} 1
+agent_turn_duration_seconds_bucket{le="5"} 2
+agent_turn_duration_seconds_bucket{le="+Inf"} 2
+agent_turn_duration_seconds_sum 2
+agent_turn_duration_seconds_count 2
+# HELP agent_tokens Tokens used by the models per request, by provider, model and kind.
+# TYPE agent_tokens histogram
+agent_tokens_bucket{kind="input",model="gpt-4o",provider="openai",le="50"} 0
+agent_tokens_bucket{kind="input",model="gpt-4o",provider="openai",le="+Inf"} 1
+agent_tokens_sum{kind="input",model="gpt-4o",provider="openai"} 100
+agent_tokens_count{kind="input",model="gpt-4o",provider="openai"} 1
+agent_tokens_bucket{kind="output",model="gpt-4o",provider="openai",le="50"} 1
+agent_tokens_bucket{kind="output",model="gpt-4o",provider="openai",le="+Inf"} 1
+agent_tokens_sum{kind="output",model="gpt-4o",provider="openai"} 20
+agent_tokens_count{kind="output",model="gpt-4o",provider="openai"} 1
+`), "agent_turn_duration_seconds", "agent_tokens"))
+}
+
+func TestCollector_EvictsStaleStarts(t *testing.T) {
+	collector := NewCollector(WithMaxTurnAge(time.Minute))
+	bus := eventbus.NewEventBus()
+	_, err := collector.Subscribe(bus)
+	require.NoError(t, err)
+
+	// the end of t1 is missed, it is forgotten once older than the max age
+	stale := agent.NewAgentResponseStartEvent("t1")
+	fresh := agent.NewAgentResponseStartEvent("t2")
+	fresh.Timestamp = stale.Timestamp.Add(2 * time.Minute)
+	publish(t, bus, stale, fresh)
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	assert.Equal(t, map[string]time.Time{"t2": fresh.Timestamp}, collector.started)
+}
+
+func TestCollector_Namespace(t *testing.T) {
+	collector := NewCollector(WithNamespace("bot"))
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	bus := eventbus.NewEventBus()
+	_, err := collector.Subscribe(bus)
+	require.NoError(t, err)
+	publish(t, bus, agent.NewAgentResponseEndEvent("t1", &agent.AgentResponseEnd{}))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Equal(t, []string{"bot_responses_total", "bot_turn_duration_seconds"}, names)
+}
@@ -0,0 +1,53 @@
+// Package tracing traces the calls to the models and the tools with OpenTelemetry spans.
+// Tracing is disabled until a tracer provider is set with SetTracerProvider, the spans
+// are then children of the span carried by the context of the calls.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName is the name of the tracer of the spans
+const InstrumentationName = "github.com/oopslink/agent-go"
+
+var (
+	lock           sync.RWMutex
+	tracerProvider trace.TracerProvider = noop.NewTracerProvider()
+)
+
+// SetTracerProvider sets the provider of the tracer of the spans, nil to disable tracing
+func SetTracerProvider(provider trace.TracerProvider) {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	tracerProvider = provider
+}
+
+// Tracer returns the tracer of the spans, a no-op one when no tracer provider is set
+func Tracer() trace.Tracer {
+	lock.RLock()
+	defer lock.RUnlock()
+	return tracerProvider.Tracer(InstrumentationName)
+}
+
+// StartSpan starts a span with the attributes, child of the span of the context
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends the span, recording the error when it is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
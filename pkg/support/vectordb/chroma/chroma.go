@@ -0,0 +1,645 @@
+// Package chroma provides a vectordb.VectorDB implementation on top of the HTTP API
+// of the Chroma vector database.
+package chroma
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+const (
+	// DefaultTimeout is the default timeout of the requests to Chroma
+	DefaultTimeout = 15 * time.Second
+	// DefaultCollection is the collection used when no collection is given in the options
+	DefaultCollection = "documents"
+	// DefaultTenant is the tenant of the collections by default
+	DefaultTenant = "default_tenant"
+	// DefaultDatabase is the database of the collections by default
+	DefaultDatabase = "default_database"
+
+	// metadataKeyName stores the name of the documents in the Chroma metadata
+	metadataKeyName = "_name"
+)
+
+var (
+	_ vectordb.VectorDB = &Store{}
+)
+
+// Config holds the connection configuration of the Chroma store.
+type Config struct {
+	// URL is the address of the Chroma server, e.g. "http://localhost:8000"
+	URL string
+	// Tenant and Database hold the collections, DefaultTenant and DefaultDatabase by default
+	Tenant   string
+	Database string
+	// Token is sent as a bearer token when the server requires authentication
+	Token string
+	// DefaultCollection is the collection used by Get/Delete and when no collection is given
+	DefaultCollection string
+	// Timeout is the timeout of each request, DefaultTimeout by default
+	Timeout time.Duration
+}
+
+// NewClientConfig creates a new Chroma client configuration.
+func NewClientConfig(url string) (*Config, error) {
+	if url == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorClientFailed, "url cannot be empty")
+	}
+	return &Config{
+		URL:               url,
+		Tenant:            DefaultTenant,
+		Database:          DefaultDatabase,
+		DefaultCollection: DefaultCollection,
+		Timeout:           DefaultTimeout,
+	}, nil
+}
+
+// ChromaInsertOptions implements vectordb.InsertOptions.
+type ChromaInsertOptions struct {
+	collection     string
+	embedder       embedder.Embedder
+	contentHashIds bool
+}
+
+func (o *ChromaInsertOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *ChromaInsertOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *ChromaInsertOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *ChromaInsertOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+func (o *ChromaInsertOptions) GetContentHashIds() bool {
+	return o.contentHashIds
+}
+
+func (o *ChromaInsertOptions) SetContentHashIds(contentHashIds bool) {
+	o.contentHashIds = contentHashIds
+}
+
+// ChromaUpdateOptions implements vectordb.UpdateOptions.
+type ChromaUpdateOptions struct {
+	collection string
+	embedder   embedder.Embedder
+}
+
+func (o *ChromaUpdateOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *ChromaUpdateOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *ChromaUpdateOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *ChromaUpdateOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+// ChromaSearchOptions implements vectordb.SearchOptions.
+//
+// Filters are a map[string]any of metadata values the documents must equal, e.g.
+// {"kind": "faq"}, or a Chroma where clause with its operators, e.g. {"year": {"$gte": 2020}}.
+type ChromaSearchOptions struct {
+	collection     string
+	scoreThreshold float32
+	filters        any
+	embedder       embedder.Embedder
+}
+
+func (o *ChromaSearchOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *ChromaSearchOptions) GetScoreThreshold() float32 {
+	return o.scoreThreshold
+}
+
+func (o *ChromaSearchOptions) GetFilters() any {
+	return o.filters
+}
+
+func (o *ChromaSearchOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *ChromaSearchOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *ChromaSearchOptions) SetScoreThreshold(threshold float32) {
+	o.scoreThreshold = threshold
+}
+
+func (o *ChromaSearchOptions) SetFilters(filters any) {
+	o.filters = filters
+}
+
+func (o *ChromaSearchOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+// New connects to the Chroma server and verifies it.
+func New(ctx context.Context, config Config) (*Store, error) {
+	if config.URL == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorStoreFailed, "chroma url cannot be empty")
+	}
+	if config.Tenant == "" {
+		config.Tenant = DefaultTenant
+	}
+	if config.Database == "" {
+		config.Database = DefaultDatabase
+	}
+	if config.DefaultCollection == "" {
+		config.DefaultCollection = DefaultCollection
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	store := &Store{
+		baseURL:           strings.TrimSuffix(config.URL, "/") + "/api/v2",
+		token:             config.Token,
+		collectionsPath:   fmt.Sprintf("/tenants/%s/databases/%s/collections", url.PathEscape(config.Tenant), url.PathEscape(config.Database)),
+		defaultCollection: config.DefaultCollection,
+		client:            &http.Client{Timeout: config.Timeout},
+		collections:       make(map[string]string),
+	}
+	if err := store.do(ctx, http.MethodGet, "/heartbeat", nil, nil); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeCreateVectorStoreFailed, err)
+	}
+	return store, nil
+}
+
+// Store is a client of a Chroma database. The collections are created on first use with
+// the cosine distance, so that the scores are the cosine similarities of the documents.
+type Store struct {
+	baseURL           string
+	token             string
+	collectionsPath   string
+	defaultCollection string
+	client            *http.Client
+
+	mu          sync.RWMutex
+	collections map[string]string // Ids of the collections by name
+}
+
+// AddDocuments inserts the documents, creating the collection on first use.
+// Documents already present are overwritten, with vectordb.WithContentHashIds the ids are
+// derived from the document contents.
+func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.InsertOption) ([]document.DocumentId, error) {
+	options := &ChromaInsertOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+	collectionId, err := s.collectionId(ctx, options.GetCollection())
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+
+	docIds := make([]document.DocumentId, 0, len(documents))
+	for _, doc := range documents {
+		id := doc.Id
+		if options.GetContentHashIds() {
+			id = document.ContentHashId(doc.Content)
+		}
+		if id == "" {
+			id = document.DocumentId(utils.GenerateUUID())
+		}
+		docIds = append(docIds, id)
+	}
+
+	request, err := newRecordsRequest(docIds, documents, vectors)
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+	if err := s.do(ctx, http.MethodPost, s.collectionPath(collectionId, "upsert"), request, nil); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+	return docIds, nil
+}
+
+// UpdateDocuments updates existing documents matched by id.
+func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.UpdateOption) error {
+	options := &ChromaUpdateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if len(documents) == 0 {
+		return nil
+	}
+
+	collectionId, err := s.collectionId(ctx, options.GetCollection())
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	docIds := make([]document.DocumentId, 0, len(documents))
+	for _, doc := range documents {
+		docIds = append(docIds, doc.Id)
+	}
+
+	// Chroma ignores the missing ids of an update
+	existing, err := s.getRecords(ctx, collectionId, docIds, false)
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	if len(existing.Ids) != len(docIds) {
+		for _, id := range docIds {
+			if !slices.Contains(existing.Ids, string(id)) {
+				return errors.Errorf(vectordb.ErrorCodeDocumentNotFound,
+					"document %s not found in collection %s", id, s.collectionName(options.GetCollection()))
+			}
+		}
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	request, err := newRecordsRequest(docIds, documents, vectors)
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	if err := s.do(ctx, http.MethodPost, s.collectionPath(collectionId, "update"), request, nil); err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	return nil
+}
+
+// Search embeds the query and returns the nearest documents by cosine distance.
+// Scores are the cosine similarities, 1 - distance, so that higher means more similar.
+func (s *Store) Search(ctx context.Context, query string, maxDocuments int, opts ...vectordb.SearchOption) ([]*vectordb.ScoredDocument, error) {
+	options := &ChromaSearchOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	emb := options.GetEmbedder()
+	if emb == nil {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "no embedder provided")
+	}
+	where, err := buildWhere(options.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := emb.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "failed to generate embedding for query")
+	}
+
+	collectionId, err := s.collectionId(ctx, options.GetCollection())
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+	if maxDocuments <= 0 {
+		maxDocuments = 10
+	}
+	request := map[string]any{
+		"query_embeddings": []embedder.FloatVector{vectors[0]},
+		"n_results":        maxDocuments,
+		"include":          []string{"documents", "metadatas", "distances"},
+	}
+	if where != nil {
+		request["where"] = where
+	}
+
+	var response queryResponse
+	if err := s.do(ctx, http.MethodPost, s.collectionPath(collectionId, "query"), request, &response); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+	if len(response.Ids) == 0 {
+		return nil, nil
+	}
+
+	threshold := options.GetScoreThreshold()
+	var docs []*vectordb.ScoredDocument
+	for idx, id := range response.Ids[0] {
+		score := distanceToScore(valueAt(response.Distances, idx))
+		if threshold > 0 && score < threshold {
+			continue
+		}
+		doc := newDocument(id, valueAt(response.Documents, idx), valueAt(response.Metadatas, idx))
+		docs = append(docs, &vectordb.ScoredDocument{Document: *doc, Score: score})
+	}
+	return docs, nil
+}
+
+// Get retrieves a document by its ID from the default collection.
+func (s *Store) Get(ctx context.Context, documentId document.DocumentId) (*document.Document, error) {
+	collectionId, err := s.collectionId(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.getRecords(ctx, collectionId, []document.DocumentId{documentId}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(records.Ids) == 0 {
+		return nil, errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+	}
+
+	doc := newDocument(records.Ids[0], elementAt(records.Documents, 0), elementAt(records.Metadatas, 0))
+	doc.Embedding = elementAt(records.Embeddings, 0)
+	return doc, nil
+}
+
+// Delete removes a document by its ID from the default collection.
+func (s *Store) Delete(ctx context.Context, documentId document.DocumentId) error {
+	collectionId, err := s.collectionId(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	// Chroma ignores the missing ids of a delete
+	records, err := s.getRecords(ctx, collectionId, []document.DocumentId{documentId}, false)
+	if err != nil {
+		return err
+	}
+	if len(records.Ids) == 0 {
+		return errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+	}
+	return s.do(ctx, http.MethodPost, s.collectionPath(collectionId, "delete"),
+		map[string]any{"ids": []string{string(documentId)}}, nil)
+}
+
+// DropCollection deletes the collection and its documents.
+func (s *Store) DropCollection(ctx context.Context, collection string) error {
+	name := s.collectionName(collection)
+	if err := s.do(ctx, http.MethodDelete, s.collectionsPath+"/"+url.PathEscape(name), nil, nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.collections, name)
+	s.mu.Unlock()
+	return nil
+}
+
+// collectionId returns the id of the collection, creating the collection if it does not exist
+func (s *Store) collectionId(ctx context.Context, collection string) (string, error) {
+	name := s.collectionName(collection)
+	s.mu.RLock()
+	id, ok := s.collections[name]
+	s.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var response struct {
+		Id string `json:"id"`
+	}
+	request := map[string]any{
+		"name":          name,
+		"get_or_create": true,
+		"metadata":      map[string]any{"hnsw:space": "cosine"},
+	}
+	if err := s.do(ctx, http.MethodPost, s.collectionsPath, request, &response); err != nil {
+		return "", errors.Wrap(vectordb.ErrorCodeLoadCollectionFailed, err)
+	}
+	if response.Id == "" {
+		return "", errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed, "no id returned for collection %s", name)
+	}
+
+	s.mu.Lock()
+	s.collections[name] = response.Id
+	s.mu.Unlock()
+	return response.Id, nil
+}
+
+func (s *Store) collectionName(collection string) string {
+	if collection == "" {
+		return s.defaultCollection
+	}
+	return collection
+}
+
+func (s *Store) collectionPath(collectionId, operation string) string {
+	return fmt.Sprintf("%s/%s/%s", s.collectionsPath, url.PathEscape(collectionId), operation)
+}
+
+// getRecords gets the records of the ids, with their embeddings on request
+func (s *Store) getRecords(ctx context.Context, collectionId string, ids []document.DocumentId, withEmbeddings bool) (*getResponse, error) {
+	stringIds := make([]string, len(ids))
+	for idx, id := range ids {
+		stringIds[idx] = string(id)
+	}
+	include := []string{"documents", "metadatas"}
+	if withEmbeddings {
+		include = append(include, "embeddings")
+	}
+
+	var response getResponse
+	request := map[string]any{"ids": stringIds, "include": include}
+	if err := s.do(ctx, http.MethodPost, s.collectionPath(collectionId, "get"), request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// do sends the request to the Chroma API and decodes its JSON response into out, if any
+func (s *Store) do(ctx context.Context, method, path string, body, out any) error {
+	var requestBody io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("chroma %s %s failed with status %d: %s",
+			method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// recordsRequest is the body of the upsert and update requests
+type recordsRequest struct {
+	Ids        []string               `json:"ids"`
+	Embeddings []embedder.FloatVector `json:"embeddings"`
+	Documents  []string               `json:"documents"`
+	Metadatas  []map[string]any       `json:"metadatas"`
+}
+
+func newRecordsRequest(ids []document.DocumentId, documents []*document.Document, vectors []embedder.FloatVector) (*recordsRequest, error) {
+	request := &recordsRequest{Embeddings: vectors}
+	for idx, doc := range documents {
+		metadata, err := toChromaMetadata(doc)
+		if err != nil {
+			return nil, err
+		}
+		request.Ids = append(request.Ids, string(ids[idx]))
+		request.Documents = append(request.Documents, doc.Content)
+		request.Metadatas = append(request.Metadatas, metadata)
+	}
+	return request, nil
+}
+
+type getResponse struct {
+	Ids        []string               `json:"ids"`
+	Documents  []*string              `json:"documents"`
+	Metadatas  []map[string]any       `json:"metadatas"`
+	Embeddings []embedder.FloatVector `json:"embeddings"`
+}
+
+// queryResponse holds a list of results per query embedding
+type queryResponse struct {
+	Ids       [][]string         `json:"ids"`
+	Documents [][]*string        `json:"documents"`
+	Metadatas [][]map[string]any `json:"metadatas"`
+	Distances [][]float64        `json:"distances"`
+}
+
+// toChromaMetadata converts the metadata of the document to Chroma metadata, which only holds
+// strings, numbers and booleans: the other values are stored as JSON, nil values are dropped
+func toChromaMetadata(doc *document.Document) (map[string]any, error) {
+	metadata := map[string]any{metadataKeyName: doc.Name}
+	for key, value := range doc.Metadata {
+		switch value.(type) {
+		case nil:
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			metadata[key] = value
+		default:
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metadata %s: %w", key, err)
+			}
+			metadata[key] = string(raw)
+		}
+	}
+	return metadata, nil
+}
+
+// newDocument creates the document of a Chroma record
+func newDocument(id string, content *string, metadata map[string]any) *document.Document {
+	doc := &document.Document{Id: document.DocumentId(id)}
+	if content != nil {
+		doc.Content = *content
+	}
+	for key, value := range metadata {
+		if key == metadataKeyName {
+			doc.Name, _ = value.(string)
+			continue
+		}
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata[key] = value
+	}
+	return doc
+}
+
+// buildWhere translates the search filters into a Chroma where clause: several metadata
+// values are combined with $and, and clauses with operators are used as is
+func buildWhere(filters any) (map[string]any, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		if len(f) == 0 {
+			return nil, nil
+		}
+		if len(f) == 1 {
+			return f, nil
+		}
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			if strings.HasPrefix(key, "$") {
+				return f, nil
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		conditions := make([]map[string]any, 0, len(keys))
+		for _, key := range keys {
+			conditions = append(conditions, map[string]any{key: f[key]})
+		}
+		return map[string]any{"$and": conditions}, nil
+	default:
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed,
+			"unsupported filters type: %T", filters)
+	}
+}
+
+// distanceToScore converts a cosine distance, in range [0, 2], to a cosine similarity
+func distanceToScore(distance float64) float32 {
+	return float32(1 - math.Min(math.Max(distance, 0), 2))
+}
+
+// valueAt returns the value of the first query result at the index, the zero value if missing
+func valueAt[T any](values [][]T, idx int) T {
+	var zero T
+	if len(values) == 0 {
+		return zero
+	}
+	return elementAt(values[0], idx)
+}
+
+func elementAt[T any](values []T, idx int) T {
+	var zero T
+	if idx >= len(values) {
+		return zero
+	}
+	return values[idx]
+}
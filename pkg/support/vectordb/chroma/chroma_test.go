@@ -0,0 +1,257 @@
+package chroma
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmbedder is a mock implementation of the embedder.Embedder interface
+type MockEmbedder struct {
+	dimension int
+}
+
+func (m *MockEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i := range texts {
+		// Generate deterministic vectors based on text length and dimension
+		vector := make([]float64, m.dimension)
+		for j := 0; j < m.dimension; j++ {
+			vector[j] = float64((len(texts[i])+j)%10+1) / 10.0
+		}
+		vectors[i] = embedder.FloatVector(vector)
+	}
+	return vectors, nil
+}
+
+func TestNewClientConfig(t *testing.T) {
+	config, err := NewClientConfig("http://localhost:8000")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8000", config.URL)
+	assert.Equal(t, DefaultTenant, config.Tenant)
+	assert.Equal(t, DefaultDatabase, config.Database)
+	assert.Equal(t, DefaultCollection, config.DefaultCollection)
+
+	_, err = NewClientConfig("")
+	assert.Error(t, err)
+}
+
+func TestBuildWhere(t *testing.T) {
+	where, err := buildWhere(nil)
+	require.NoError(t, err)
+	assert.Nil(t, where)
+
+	where, err = buildWhere(map[string]any{"kind": "faq"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"kind": "faq"}, where)
+
+	// several values are all required
+	where, err = buildWhere(map[string]any{"lang": "en", "kind": "faq"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"$and": []map[string]any{{"kind": "faq"}, {"lang": "en"}}}, where)
+
+	// clauses with operators are used as is
+	clause := map[string]any{"$or": []any{map[string]any{"kind": "faq"}, map[string]any{"kind": "guide"}}}
+	where, err = buildWhere(clause)
+	require.NoError(t, err)
+	assert.Equal(t, clause, where)
+
+	_, err = buildWhere("kind = 'faq'")
+	assert.Error(t, err)
+}
+
+func TestMetadataConversion(t *testing.T) {
+	doc := document.NewDocument("doc_1", "guide", map[string]any{
+		"kind":  "faq",
+		"year":  2024,
+		"tags":  []string{"a", "b"},
+		"empty": nil,
+	}, "content")
+
+	metadata, err := toChromaMetadata(doc)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		metadataKeyName: "guide",
+		"kind":          "faq",
+		"year":          2024,
+		"tags":          `["a","b"]`,
+	}, metadata)
+
+	content := "content"
+	converted := newDocument("doc_1", &content, metadata)
+	assert.Equal(t, document.DocumentId("doc_1"), converted.Id)
+	assert.Equal(t, "guide", converted.Name)
+	assert.Equal(t, "content", converted.Content)
+	assert.NotContains(t, converted.Metadata, metadataKeyName)
+	assert.Equal(t, "faq", converted.Metadata["kind"])
+}
+
+func TestDistanceToScore(t *testing.T) {
+	assert.Equal(t, float32(1), distanceToScore(0))
+	assert.Equal(t, float32(0.75), distanceToScore(0.25))
+	assert.Equal(t, float32(-1), distanceToScore(2))
+	assert.Equal(t, float32(1), distanceToScore(-1e-7))
+}
+
+func TestStore_Search(t *testing.T) {
+	// Create test server answering like Chroma
+	var queries []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/api/v2/heartbeat":
+			w.Write([]byte(`{"nanosecond heartbeat": 1}`))
+		case r.URL.Path == "/api/v2/tenants/default_tenant/databases/default_database/collections":
+			var request map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, "faq", request["name"])
+			assert.Equal(t, true, request["get_or_create"])
+			w.Write([]byte(`{"id": "c0ffee", "name": "faq"}`))
+		case strings.HasSuffix(r.URL.Path, "/collections/c0ffee/query"):
+			var request map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			queries = append(queries, request)
+			w.Write([]byte(`{
+				"ids": [["doc_1", "doc_2"]],
+				"documents": [["first", null]],
+				"metadatas": [[{"_name": "one", "kind": "faq"}, null]],
+				"distances": [[0.1, 0.6]]
+			}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config, err := NewClientConfig(server.URL)
+	require.NoError(t, err)
+	config.Token = "secret"
+	store, err := New(context.Background(), *config)
+	require.NoError(t, err)
+
+	emb := &MockEmbedder{dimension: 4}
+	results, err := store.Search(context.Background(), "question", 2,
+		vectordb.WithSearchCollection("faq"),
+		vectordb.WithSearchEmbedder(emb),
+		vectordb.WithFilters(map[string]any{"kind": "faq"}))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.Equal(t, "one", results[0].Name)
+	assert.Equal(t, "first", results[0].Content)
+	assert.Equal(t, map[string]any{"kind": "faq"}, results[0].Metadata)
+	assert.InDelta(t, 0.9, results[0].Score, 1e-6)
+	assert.Equal(t, "", results[1].Content)
+
+	require.Len(t, queries, 1)
+	assert.Equal(t, float64(2), queries[0]["n_results"])
+	assert.Equal(t, map[string]any{"kind": "faq"}, queries[0]["where"])
+
+	// the score threshold is a minimum similarity
+	results, err = store.Search(context.Background(), "question", 2,
+		vectordb.WithSearchCollection("faq"),
+		vectordb.WithSearchEmbedder(emb),
+		vectordb.WithScoreThreshold(0.5))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.NotContains(t, queries[1], "where")
+
+	_, err = store.Search(context.Background(), "question", 2, vectordb.WithSearchCollection("faq"))
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeSearchDocumentFailed))
+}
+
+func TestNew_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := New(context.Background(), Config{URL: server.URL})
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeCreateVectorStoreFailed))
+}
+
+// setupChromaTest connects to the Chroma server given by CHROMA_URL
+func setupChromaTest(t *testing.T) *Store {
+	chromaURL := os.Getenv("CHROMA_URL")
+	if chromaURL == "" {
+		t.Skip("Skipping Chroma tests: CHROMA_URL not set")
+	}
+	config, err := NewClientConfig(chromaURL)
+	require.NoError(t, err)
+	config.Token = os.Getenv("CHROMA_TOKEN")
+	config.DefaultCollection = "test_chroma_documents_" + utils.GenerateUUID()[:8]
+
+	store, err := New(context.Background(), *config)
+	if err != nil {
+		t.Skipf("Skipping Chroma tests: cannot connect to Chroma at %s: %v", chromaURL, err)
+	}
+	t.Cleanup(func() {
+		_ = store.DropCollection(context.Background(), "")
+	})
+	return store
+}
+
+func TestStore_Integration(t *testing.T) {
+	store := setupChromaTest(t)
+	ctx := context.Background()
+	emb := &MockEmbedder{dimension: 8}
+
+	docs := []*document.Document{
+		document.NewDocument("doc_1", "short", map[string]any{"kind": "a"}, "short"),
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "b"}, "a longer text"),
+	}
+	ids, err := store.AddDocuments(ctx, docs, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc_1", "doc_2"}, ids)
+
+	results, err := store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.Equal(t, "short", results[0].Name)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithFilters(map[string]any{"kind": "b"}))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_2"), results[0].Id)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithScoreThreshold(0.999))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "c"}, "updated"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	require.NoError(t, err)
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_3", "missing", nil, "missing"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+
+	doc, err := store.Get(ctx, "doc_2")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", doc.Content)
+	assert.Equal(t, "c", doc.Metadata["kind"])
+	assert.Len(t, doc.Embedding, 8)
+
+	require.NoError(t, store.Delete(ctx, "doc_2"))
+	_, err = store.Get(ctx, "doc_2")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	assert.True(t, errors.IsCode(store.Delete(ctx, "doc_2"), vectordb.ErrorCodeDocumentNotFound))
+}
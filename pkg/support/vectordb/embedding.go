@@ -0,0 +1,46 @@
+package vectordb
+
+import (
+	"context"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+)
+
+// EmbedDocuments returns one vector per document. Documents carrying a precomputed
+// Embedding are reused as is, only the others are embedded with the embedder,
+// which may be nil when every document has a vector.
+func EmbedDocuments(ctx context.Context, documents []*document.Document, emb embedder.Embedder) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(documents))
+
+	var texts []string
+	var indexes []int
+	for idx, doc := range documents {
+		if len(doc.Embedding) > 0 {
+			vectors[idx] = doc.Embedding
+			continue
+		}
+		texts = append(texts, doc.Content)
+		indexes = append(indexes, idx)
+	}
+	if len(texts) == 0 {
+		return vectors, nil
+	}
+
+	if emb == nil {
+		return nil, errors.Errorf(errors.InvalidInput, "no embedder provided")
+	}
+	embedded, err := emb.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(texts) {
+		return nil, errors.Errorf(errors.InvalidInput,
+			"number of vectors from embedder does not match number of documents")
+	}
+	for idx, vector := range embedded {
+		vectors[indexes[idx]] = vector
+	}
+	return vectors, nil
+}
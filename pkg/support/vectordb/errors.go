@@ -38,4 +38,14 @@ var (
 		Name:           "CreateVectorClientFailed ",
 		DefaultMessage: "Failed to create vector client",
 	}
+	ErrorCodeDocumentNotFound = errors.ErrorCode{
+		Code:           30607,
+		Name:           "DocumentNotFound ",
+		DefaultMessage: "Document not found",
+	}
+	ErrorCodeVectorDBUnavailable = errors.ErrorCode{
+		Code:           30608,
+		Name:           "VectorDBUnavailable ",
+		DefaultMessage: "Vector database is unavailable",
+	}
 )
@@ -0,0 +1,454 @@
+// Package inmem provides an in-memory vectordb.VectorDB implementation.
+// It keeps documents and vectors in process memory and computes similarity in pure Go,
+// which makes it suitable for tests and small datasets without external infrastructure.
+package inmem
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+const (
+	// DefaultCollection is used when no collection is given in the options.
+	DefaultCollection = "documents"
+)
+
+var (
+	_ vectordb.VectorDB = &Store{}
+)
+
+// Metric is the similarity metric used to score documents.
+type Metric string
+
+const (
+	// MetricCosine scores by cosine similarity, in range [-1, 1].
+	MetricCosine Metric = "cosine"
+	// MetricL2 scores by 1 / (1 + euclidean distance), in range (0, 1].
+	MetricL2 Metric = "l2"
+)
+
+// FilterFunc filters documents during search; return true to keep the document.
+// Filters given by vectordb.WithFilters may also be a map[string]any,
+// which keeps documents whose metadata equals every given key/value.
+type FilterFunc func(doc *document.Document) bool
+
+// Option configures the Store.
+type Option func(*Store)
+
+// WithMetric sets the similarity metric, MetricCosine by default.
+func WithMetric(metric Metric) Option {
+	return func(s *Store) {
+		s.metric = metric
+	}
+}
+
+// New creates an empty in-memory store.
+func New(opts ...Option) *Store {
+	s := &Store{
+		metric: MetricCosine,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Store is an in-memory vector store.
+type Store struct {
+	mu      sync.RWMutex
+	metric  Metric
+	entries []*entry
+}
+
+type entry struct {
+	collection string
+	doc        *document.Document
+	vector     embedder.FloatVector
+}
+
+// InsertOptions implements vectordb.InsertOptions.
+type InsertOptions struct {
+	collection     string
+	embedder       embedder.Embedder
+	contentHashIds bool
+}
+
+func (o *InsertOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *InsertOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *InsertOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *InsertOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+func (o *InsertOptions) GetContentHashIds() bool {
+	return o.contentHashIds
+}
+
+func (o *InsertOptions) SetContentHashIds(contentHashIds bool) {
+	o.contentHashIds = contentHashIds
+}
+
+// UpdateOptions implements vectordb.UpdateOptions.
+type UpdateOptions struct {
+	collection string
+	embedder   embedder.Embedder
+}
+
+func (o *UpdateOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *UpdateOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *UpdateOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *UpdateOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+// SearchOptions implements vectordb.SearchOptions.
+type SearchOptions struct {
+	collection     string
+	scoreThreshold float32
+	filters        any
+	embedder       embedder.Embedder
+}
+
+func (o *SearchOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *SearchOptions) GetScoreThreshold() float32 {
+	return o.scoreThreshold
+}
+
+func (o *SearchOptions) GetFilters() any {
+	return o.filters
+}
+
+func (o *SearchOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *SearchOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *SearchOptions) SetScoreThreshold(threshold float32) {
+	o.scoreThreshold = threshold
+}
+
+func (o *SearchOptions) SetFilters(filters any) {
+	o.filters = filters
+}
+
+func (o *SearchOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+// AddDocuments stores the documents in the collection.
+// Documents carrying an Embedding are stored as is, the others are embedded with the embedder option.
+// Documents without id get a generated one; adding an existing id replaces the stored document.
+// With vectordb.WithContentHashIds the ids are derived from the document contents.
+func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.InsertOption) ([]document.DocumentId, error) {
+	options := &InsertOptions{collection: DefaultCollection}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+
+	collection := collectionOrDefault(options.GetCollection())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docIds := make([]document.DocumentId, 0, len(documents))
+	for idx, doc := range documents {
+		stored := copyDocument(doc)
+		if options.GetContentHashIds() {
+			stored.Id = document.ContentHashId(stored.Content)
+		}
+		if stored.Id == "" {
+			stored.Id = document.DocumentId(utils.GenerateUUID())
+		}
+		stored.Embedding = vectors[idx]
+
+		if existing := s.find(collection, stored.Id); existing != nil {
+			existing.doc = stored
+			existing.vector = vectors[idx]
+		} else {
+			s.entries = append(s.entries, &entry{
+				collection: collection,
+				doc:        stored,
+				vector:     vectors[idx],
+			})
+		}
+		docIds = append(docIds, stored.Id)
+	}
+	return docIds, nil
+}
+
+// UpdateDocuments replaces existing documents in the collection, matched by id.
+func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.UpdateOption) error {
+	options := &UpdateOptions{collection: DefaultCollection}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+
+	collection := collectionOrDefault(options.GetCollection())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// check all documents first, so that a failed update leaves the store untouched
+	targets := make([]*entry, 0, len(documents))
+	for _, doc := range documents {
+		existing := s.find(collection, doc.Id)
+		if existing == nil {
+			return errors.Errorf(vectordb.ErrorCodeDocumentNotFound,
+				"document %s not found in collection %s", doc.Id, collection)
+		}
+		targets = append(targets, existing)
+	}
+
+	for idx, doc := range documents {
+		stored := copyDocument(doc)
+		stored.Embedding = vectors[idx]
+		targets[idx].doc = stored
+		targets[idx].vector = vectors[idx]
+	}
+	return nil
+}
+
+// Search embeds the query and returns the most similar documents of the collection.
+func (s *Store) Search(ctx context.Context, query string, maxDocuments int, opts ...vectordb.SearchOption) ([]*vectordb.ScoredDocument, error) {
+	options := &SearchOptions{collection: DefaultCollection}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	emb := options.GetEmbedder()
+	if emb == nil {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "no embedder provided")
+	}
+	vectors, err := emb.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "failed to generate embedding for query")
+	}
+
+	filter, err := makeFilter(options.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	collection := collectionOrDefault(options.GetCollection())
+	threshold := options.GetScoreThreshold()
+
+	s.mu.RLock()
+	var results []*vectordb.ScoredDocument
+	for _, e := range s.entries {
+		if e.collection != collection {
+			continue
+		}
+		if filter != nil && !filter(e.doc) {
+			continue
+		}
+		score := s.score(vectors[0], e.vector)
+		if threshold > 0 && score < threshold {
+			continue
+		}
+		results = append(results, &vectordb.ScoredDocument{
+			Document: *copyDocument(e.doc),
+			Score:    score,
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if maxDocuments > 0 && len(results) > maxDocuments {
+		results = results[:maxDocuments]
+	}
+	return results, nil
+}
+
+// Get returns the document with the given id, from any collection.
+func (s *Store) Get(ctx context.Context, documentId document.DocumentId) (*document.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.entries {
+		if e.doc.Id == documentId {
+			return copyDocument(e.doc), nil
+		}
+	}
+	return nil, errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+}
+
+// ListDocuments returns the documents of the collection, in insertion order.
+func (s *Store) ListDocuments(ctx context.Context, collection string) ([]*document.Document, error) {
+	collection = collectionOrDefault(collection)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var docs []*document.Document
+	for _, e := range s.entries {
+		if e.collection == collection {
+			docs = append(docs, copyDocument(e.doc))
+		}
+	}
+	return docs, nil
+}
+
+// Delete removes the document with the given id from all collections.
+func (s *Store) Delete(ctx context.Context, documentId document.DocumentId) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if e.doc.Id != documentId {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(s.entries) {
+		return errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+	}
+	// clear the tail so removed entries can be collected
+	for idx := len(kept); idx < len(s.entries); idx++ {
+		s.entries[idx] = nil
+	}
+	s.entries = kept
+	return nil
+}
+
+// Len returns the number of stored documents across all collections.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// find returns the entry of the document in the collection, callers must hold the lock.
+func (s *Store) find(collection string, id document.DocumentId) *entry {
+	for _, e := range s.entries {
+		if e.collection == collection && e.doc.Id == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Store) score(query, vector embedder.FloatVector) float32 {
+	switch s.metric {
+	case MetricL2:
+		return float32(1 / (1 + l2Distance(query, vector)))
+	default:
+		return float32(cosineSimilarity(query, vector))
+	}
+}
+
+func cosineSimilarity(a, b embedder.FloatVector) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func l2Distance(a, b embedder.FloatVector) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func makeFilter(filters any) (FilterFunc, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case FilterFunc:
+		return f, nil
+	case func(doc *document.Document) bool:
+		return f, nil
+	case map[string]any:
+		return func(doc *document.Document) bool {
+			for key, value := range f {
+				actual, ok := doc.Metadata[key]
+				if !ok || !reflect.DeepEqual(actual, value) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	default:
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed,
+			"unsupported filters type: %T", filters)
+	}
+}
+
+func collectionOrDefault(collection string) string {
+	if collection == "" {
+		return DefaultCollection
+	}
+	return collection
+}
+
+func copyDocument(doc *document.Document) *document.Document {
+	copied := *doc
+	if doc.Metadata != nil {
+		copied.Metadata = make(map[string]any, len(doc.Metadata))
+		for key, value := range doc.Metadata {
+			copied.Metadata[key] = value
+		}
+	}
+	return &copied
+}
@@ -0,0 +1,340 @@
+package inmem
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder embeds texts as counts of a fixed vocabulary, so that
+// similarity is predictable in tests.
+type keywordEmbedder struct {
+	vocabulary []string
+	calls      int
+}
+
+func (k *keywordEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	k.calls++
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i, text := range texts {
+		vector := make(embedder.FloatVector, len(k.vocabulary))
+		for j, word := range k.vocabulary {
+			vector[j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func newKeywordEmbedder() *keywordEmbedder {
+	return &keywordEmbedder{vocabulary: []string{"cat", "dog", "fish"}}
+}
+
+func newTestDocuments() []*document.Document {
+	return []*document.Document{
+		document.NewDocument("doc_cat", "cat", map[string]any{"kind": "mammal"}, "cat cat cat"),
+		document.NewDocument("doc_dog", "dog", map[string]any{"kind": "mammal"}, "dog dog"),
+		document.NewDocument("doc_fish", "fish", map[string]any{"kind": "fish"}, "fish and a cat"),
+	}
+}
+
+func TestStore_AddAndSearch(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	ids, err := store.AddDocuments(ctx, newTestDocuments(), vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc_cat", "doc_dog", "doc_fish"}, ids)
+	assert.Equal(t, 3, store.Len())
+
+	results, err := store.Search(ctx, "cat", 10, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, document.DocumentId("doc_cat"), results[0].Id)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	assert.Equal(t, document.DocumentId("doc_fish"), results[1].Id)
+	assert.InDelta(t, 0.7071, results[1].Score, 1e-4)
+	assert.Equal(t, document.DocumentId("doc_dog"), results[2].Id)
+	assert.InDelta(t, 0.0, results[2].Score, 1e-6)
+
+	// maxDocuments limits the results
+	results, err = store.Search(ctx, "cat", 1, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_cat"), results[0].Id)
+}
+
+func TestStore_SearchOptions(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	_, err := store.AddDocuments(ctx, newTestDocuments(), vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	_, err = store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("other_cat", "cat", nil, "cat"),
+	}, vectordb.WithInsertEmbedder(emb), vectordb.WithInsertCollection("other"))
+	require.NoError(t, err)
+
+	t.Run("score threshold", func(t *testing.T) {
+		results, err := store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithScoreThreshold(0.7))
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			assert.GreaterOrEqual(t, result.Score, float32(0.7))
+		}
+	})
+
+	t.Run("collection", func(t *testing.T) {
+		results, err := store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithSearchCollection("other"))
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, document.DocumentId("other_cat"), results[0].Id)
+
+		results, err = store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithSearchCollection("missing"))
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("metadata filters", func(t *testing.T) {
+		results, err := store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithFilters(map[string]any{"kind": "mammal"}))
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, document.DocumentId("doc_cat"), results[0].Id)
+		assert.Equal(t, document.DocumentId("doc_dog"), results[1].Id)
+	})
+
+	t.Run("filter func", func(t *testing.T) {
+		results, err := store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithFilters(FilterFunc(func(doc *document.Document) bool {
+				return doc.Name == "fish"
+			})))
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, document.DocumentId("doc_fish"), results[0].Id)
+	})
+
+	t.Run("unsupported filters", func(t *testing.T) {
+		_, err := store.Search(ctx, "cat", 10,
+			vectordb.WithSearchEmbedder(emb), vectordb.WithFilters("kind == 'mammal'"))
+		assert.Error(t, err)
+	})
+
+	t.Run("no embedder", func(t *testing.T) {
+		_, err := store.Search(ctx, "cat", 10)
+		assert.Error(t, err)
+		assert.True(t, errors.IsCode(err, vectordb.ErrorCodeSearchDocumentFailed))
+	})
+}
+
+func TestStore_L2Metric(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New(WithMetric(MetricL2))
+
+	_, err := store.AddDocuments(ctx, newTestDocuments(), vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+
+	results, err := store.Search(ctx, "dog dog", 10, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, document.DocumentId("doc_dog"), results[0].Id)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	for _, result := range results[1:] {
+		assert.Less(t, result.Score, results[0].Score)
+	}
+}
+
+func TestStore_PrecomputedEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	doc := document.NewDocument("doc_vec", "vec", nil, "no keywords here")
+	doc.Embedding = embedder.FloatVector{0, 0, 1}
+
+	// no embedder needed when all documents carry vectors
+	_, err := store.AddDocuments(ctx, []*document.Document{doc})
+	require.NoError(t, err)
+	assert.Equal(t, 0, emb.calls)
+
+	results, err := store.Search(ctx, "fish", 10, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+
+	// documents without vectors require an embedder
+	_, err = store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_text", "text", nil, "cat"),
+	})
+	assert.Error(t, err)
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeAddDocumentFailed))
+}
+
+func TestStore_GetUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	_, err := store.AddDocuments(ctx, newTestDocuments(), vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+
+	doc, err := store.Get(ctx, "doc_dog")
+	require.NoError(t, err)
+	assert.Equal(t, "dog dog", doc.Content)
+	assert.Equal(t, "mammal", doc.Metadata["kind"])
+
+	// returned documents are copies
+	doc.Metadata["kind"] = "changed"
+	doc, err = store.Get(ctx, "doc_dog")
+	require.NoError(t, err)
+	assert.Equal(t, "mammal", doc.Metadata["kind"])
+
+	// update re-embeds the content
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_dog", "dog", map[string]any{"kind": "mammal"}, "fish fish"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	require.NoError(t, err)
+
+	results, err := store.Search(ctx, "fish", 1, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_dog"), results[0].Id)
+
+	// updating an unknown document fails without partial updates
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_cat", "cat", nil, "dog"),
+		document.NewDocument("missing", "missing", nil, "dog"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	assert.Error(t, err)
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	doc, err = store.Get(ctx, "doc_cat")
+	require.NoError(t, err)
+	assert.Equal(t, "cat cat cat", doc.Content)
+
+	// delete
+	require.NoError(t, store.Delete(ctx, "doc_dog"))
+	assert.Equal(t, 2, store.Len())
+	_, err = store.Get(ctx, "doc_dog")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	err = store.Delete(ctx, "doc_dog")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+}
+
+func TestStore_ListDocuments(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	_, err := store.AddDocuments(ctx, newTestDocuments(), vectordb.WithInsertEmbedder(newKeywordEmbedder()))
+	require.NoError(t, err)
+	_, err = store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_other", "other", nil, "dog"),
+	}, vectordb.WithInsertEmbedder(newKeywordEmbedder()), vectordb.WithInsertCollection("other"))
+	require.NoError(t, err)
+
+	docs, err := store.ListDocuments(ctx, "")
+	require.NoError(t, err)
+	var ids []document.DocumentId
+	for _, doc := range docs {
+		ids = append(ids, doc.Id)
+	}
+	assert.Equal(t, []document.DocumentId{"doc_cat", "doc_dog", "doc_fish"}, ids)
+
+	// returned documents are copies
+	docs[0].Metadata["kind"] = "changed"
+	doc, err := store.Get(ctx, "doc_cat")
+	require.NoError(t, err)
+	assert.Equal(t, "mammal", doc.Metadata["kind"])
+
+	docs, err = store.ListDocuments(ctx, "other")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "dog", docs[0].Content)
+}
+
+func TestStore_AddReplacesAndGeneratesIds(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	ids, err := store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("", "anonymous", nil, "cat"),
+	}, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.NotEmpty(t, ids[0])
+
+	_, err = store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument(ids[0], "anonymous", nil, "dog"),
+	}, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	doc, err := store.Get(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "dog", doc.Content)
+}
+
+func TestStore_ContentHashIds(t *testing.T) {
+	ctx := context.Background()
+	emb := newKeywordEmbedder()
+	store := New()
+
+	ids, err := store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("", "first", nil, "cat and dog"),
+	}, vectordb.WithInsertEmbedder(emb), vectordb.WithContentHashIds())
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.Equal(t, document.ContentHashId("cat and dog"), ids[0])
+
+	// re-inserting identical content, even under another id, is a no-op
+	again, err := store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_other", "second", nil, "cat and dog"),
+	}, vectordb.WithInsertEmbedder(emb), vectordb.WithContentHashIds())
+	require.NoError(t, err)
+	assert.Equal(t, ids, again)
+	assert.Equal(t, 1, store.Len())
+
+	_, err = store.AddDocuments(ctx, []*document.Document{
+		document.NewDocument("", "third", nil, "fish"),
+	}, vectordb.WithInsertEmbedder(emb), vectordb.WithContentHashIds())
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestStore_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			doc := document.NewDocument("", "cat", nil, "cat")
+			doc.Embedding = embedder.FloatVector{1, 0, 0}
+			_, err := store.AddDocuments(ctx, []*document.Document{doc})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := store.Search(ctx, "cat", 5, vectordb.WithSearchEmbedder(newKeywordEmbedder()))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, store.Len())
+}
@@ -0,0 +1,200 @@
+package milvus
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+// SparseEmbedder computes sparse vectors, e.g. BM25 style term weights, used for
+// the keyword side of hybrid search.
+type SparseEmbedder interface {
+	EmbedSparse(ctx context.Context, texts []string) ([]entity.SparseEmbedding, error)
+}
+
+const (
+	// DefaultSparseField is the sparse vector field name used by NewHybridCollectionSchema
+	DefaultSparseField = "sparse_vector"
+
+	// bm25K1 controls the term frequency saturation of the hashing sparse embedder
+	bm25K1 = 1.2
+)
+
+// NewHybridCollectionSchema returns the default collection schema with a sparse vector
+// field, so the collection can be searched with WithMilvusHybrid.
+func NewHybridCollectionSchema() *CollectionSchema {
+	schema := DefaultCollectionSchema()
+	schema.SparseField = DefaultSparseField
+	return schema
+}
+
+// NewHashingSparseEmbedder creates a sparse embedder that needs no vocabulary nor model:
+// terms are hashed to positions and weighted by their BM25 saturated term frequency.
+// It is used for collections with a sparse field when no other sparse embedder is set.
+func NewHashingSparseEmbedder() SparseEmbedder {
+	return &hashingSparseEmbedder{}
+}
+
+type hashingSparseEmbedder struct{}
+
+func (h *hashingSparseEmbedder) EmbedSparse(ctx context.Context, texts []string) ([]entity.SparseEmbedding, error) {
+	vectors := make([]entity.SparseEmbedding, 0, len(texts))
+	for _, text := range texts {
+		frequencies := make(map[uint32]float32)
+		for _, term := range tokenize(text) {
+			frequencies[hashTerm(term)]++
+		}
+		positions := make([]uint32, 0, len(frequencies))
+		values := make([]float32, 0, len(frequencies))
+		for position, tf := range frequencies {
+			positions = append(positions, position)
+			values = append(values, tf*(bm25K1+1)/(tf+bm25K1))
+		}
+		vector, err := entity.NewSliceSparseEmbedding(positions, values)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func hashTerm(term string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(term))
+	return h.Sum32()
+}
+
+// WithMilvusHybrid enables hybrid dense+sparse search: the query is searched against the
+// dense vector field and the sparse field, and the results are fused by the reranker.
+// An empty sparseField uses the sparse field of the collection schema, a nil reranker
+// uses reciprocal rank fusion (client.NewRRFReranker).
+func WithMilvusHybrid(sparseField string, reranker client.Reranker) vectordb.SearchOption {
+	return func(o vectordb.SearchOptions) {
+		if milvusOpts, ok := o.(*MilvusSearchOptions); ok {
+			milvusOpts.SetHybrid(sparseField, reranker)
+		}
+	}
+}
+
+// WithMilvusSearchSparseEmbedder sets the sparse embedder of the query for hybrid search
+func WithMilvusSearchSparseEmbedder(embedder SparseEmbedder) vectordb.SearchOption {
+	return func(o vectordb.SearchOptions) {
+		if milvusOpts, ok := o.(*MilvusSearchOptions); ok {
+			milvusOpts.SetSparseEmbedder(embedder)
+		}
+	}
+}
+
+// WithMilvusInsertSparseEmbedder sets the sparse embedder of collections with a sparse field
+func WithMilvusInsertSparseEmbedder(embedder SparseEmbedder) vectordb.InsertOption {
+	return func(o vectordb.InsertOptions) {
+		if milvusOpts, ok := o.(*MilvusInsertOptions); ok {
+			milvusOpts.SetSparseEmbedder(embedder)
+		}
+	}
+}
+
+// WithMilvusUpdateSparseEmbedder sets the sparse embedder of collections with a sparse field
+func WithMilvusUpdateSparseEmbedder(embedder SparseEmbedder) vectordb.UpdateOption {
+	return func(o vectordb.UpdateOptions) {
+		if milvusOpts, ok := o.(*MilvusUpdateOptions); ok {
+			milvusOpts.SetSparseEmbedder(embedder)
+		}
+	}
+}
+
+// embedSparse computes the sparse vectors of the documents when the collection has a sparse field
+func embedSparse(ctx context.Context, schema *CollectionSchema,
+	embedder SparseEmbedder, documents []*document.Document) ([]entity.SparseEmbedding, error) {
+	if schema.SparseField == "" {
+		return nil, nil
+	}
+	if embedder == nil {
+		embedder = NewHashingSparseEmbedder()
+	}
+	texts := make([]string, 0, len(documents))
+	for _, doc := range documents {
+		texts = append(texts, doc.Content)
+	}
+	vectors, err := embedder.EmbedSparse(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(documents) {
+		return nil, errors.Errorf(errors.InvalidInput,
+			"number of sparse vectors from embedder does not match number of documents")
+	}
+	return vectors, nil
+}
+
+// hybridSearch searches the dense and the sparse field and fuses the results
+func (s *Store) hybridSearch(ctx context.Context, collectionName string, info *collectionInfo,
+	query string, dense entity.Vector, denseParam entity.SearchParam,
+	maxDocuments int, options *MilvusSearchOptions) ([]client.SearchResult, error) {
+	schema := info.collectionSchema
+
+	sparseField := options.GetHybridSparseField()
+	if sparseField == "" {
+		sparseField = schema.SparseField
+	}
+	if sparseField == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed,
+			"hybrid search requires a sparse vector field in collection %s", collectionName)
+	}
+
+	sparseEmbedder := options.GetSparseEmbedder()
+	if sparseEmbedder == nil {
+		sparseEmbedder = NewHashingSparseEmbedder()
+	}
+	sparse, err := sparseEmbedder.EmbedSparse(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(sparse) == 0 {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed,
+			"failed to generate sparse embedding for query")
+	}
+	sparseParam, err := entity.NewIndexSparseInvertedSearchParam(0)
+	if err != nil {
+		return nil, err
+	}
+
+	reranker := options.GetHybridReranker()
+	if reranker == nil {
+		reranker = client.NewRRFReranker()
+	}
+
+	filter := options.GetFilterExpression()
+	requests := []*client.ANNSearchRequest{
+		client.NewANNSearchRequest(schema.VectorField, schema.MetricType, filter,
+			[]entity.Vector{dense}, denseParam, maxDocuments),
+		client.NewANNSearchRequest(sparseField, entity.IP, filter,
+			[]entity.Vector{sparse[0]}, sparseParam, maxDocuments),
+	}
+
+	return callWithResult(ctx, s, func(c client.Client) ([]client.SearchResult, error) {
+		return c.HybridSearch(ctx,
+			collectionName,
+			options.GetPartitionNames(),
+			maxDocuments,
+			s.getSearchFields(info),
+			reranker,
+			requests,
+			client.WithSearchQueryConsistencyLevel(options.GetConsistencyLevel()),
+		)
+	})
+}
@@ -0,0 +1,206 @@
+package milvus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hybridFakeClient records collection creation and searches
+type hybridFakeClient struct {
+	fakeClient
+	schema          *entity.Schema
+	searches        int
+	hybridRequests  []*client.ANNSearchRequest
+	hybridReranker  client.Reranker
+	hybridOutFields []string
+}
+
+func (f *hybridFakeClient) CreateCollection(ctx context.Context, schema *entity.Schema, shardsNum int32, opts ...client.CreateCollectionOption) error {
+	f.schema = schema
+	return nil
+}
+
+func (f *hybridFakeClient) Search(ctx context.Context, collName string, partitions []string,
+	expr string, outputFields []string, vectors []entity.Vector, vectorField string, metricType entity.MetricType,
+	topK int, sp entity.SearchParam, opts ...client.SearchQueryOptionFunc) ([]client.SearchResult, error) {
+	f.searches++
+	return fakeSearchResult(), nil
+}
+
+func (f *hybridFakeClient) HybridSearch(ctx context.Context, collName string, partitions []string,
+	limit int, outputFields []string, reranker client.Reranker, subRequests []*client.ANNSearchRequest,
+	opts ...client.SearchQueryOptionFunc) ([]client.SearchResult, error) {
+	f.hybridRequests = subRequests
+	f.hybridReranker = reranker
+	f.hybridOutFields = outputFields
+	return fakeSearchResult(), nil
+}
+
+func fakeSearchResult() []client.SearchResult {
+	return []client.SearchResult{{
+		ResultCount: 2,
+		Scores:      []float32{0.03, 0.01},
+		Fields: client.ResultSet{
+			entity.NewColumnVarChar("text", []string{"milvus hybrid search", "dense only"}),
+			entity.NewColumnJSONBytes("metadata", [][]byte{[]byte(`{"id":"1"}`), []byte(`{"id":"2"}`)}),
+		},
+	}}
+}
+
+// newHybridFakeStore returns a store with a created collection of the given schema
+func newHybridFakeStore(t *testing.T, schema *CollectionSchema) (*Store, *hybridFakeClient) {
+	fake := &hybridFakeClient{}
+	info := &collectionInfo{loaded: true, collectionSchema: schema}
+	store := &Store{
+		client:      fake,
+		collections: map[string]*collectionInfo{schema.CollectionName: info},
+	}
+	require.NoError(t, store.createCollection(context.Background(), schema.CollectionName, info, 3))
+	return store, fake
+}
+
+// sparseValue returns the weight of a term in a sparse vector of the hashing embedder
+func sparseValue(t *testing.T, vector entity.SparseEmbedding, term string) float32 {
+	for idx := 0; idx < vector.Len(); idx++ {
+		position, value, ok := vector.Get(idx)
+		if ok && position == hashTerm(term) {
+			return value
+		}
+	}
+	t.Fatalf("term %q not found in sparse vector", term)
+	return 0
+}
+
+func TestHashingSparseEmbedder(t *testing.T) {
+	sparseEmbedder := NewHashingSparseEmbedder()
+
+	vectors, err := sparseEmbedder.EmbedSparse(context.Background(),
+		[]string{"Milvus search, milvus!", "milvus search milvus"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 2)
+
+	// tokenization ignores case and punctuation
+	assert.Equal(t, vectors[0], vectors[1])
+	assert.Equal(t, 2, vectors[0].Len())
+
+	repeated := sparseValue(t, vectors[0], "milvus")
+	single := sparseValue(t, vectors[0], "search")
+	assert.InDelta(t, 1.0, single, 1e-6)
+	assert.Greater(t, repeated, single)
+	// term frequency saturates
+	assert.Less(t, repeated, 2*single)
+}
+
+func TestCreateCollectionWithSparseField(t *testing.T) {
+	schema := NewHybridCollectionSchema()
+	schema.CollectionName = "hybrid"
+	store, fake := newHybridFakeStore(t, schema)
+
+	require.NotNil(t, fake.schema)
+	fields := map[string]entity.FieldType{}
+	for _, field := range fake.schema.Fields {
+		fields[field.Name] = field.DataType
+	}
+	assert.Equal(t, entity.FieldTypeFloatVector, fields["vector"])
+	assert.Equal(t, entity.FieldTypeSparseVector, fields[DefaultSparseField])
+	assert.NotContains(t, store.getSearchFields(store.collections["hybrid"]), DefaultSparseField)
+
+	// the default schema stays dense-only
+	dense := DefaultCollectionSchema()
+	dense.CollectionName = "dense"
+	_, fake = newHybridFakeStore(t, dense)
+	for _, field := range fake.schema.Fields {
+		assert.NotEqual(t, entity.FieldTypeSparseVector, field.DataType)
+	}
+}
+
+func TestAddDocumentsWithSparseVectors(t *testing.T) {
+	store, fake := newFakeStore("documents")
+	store.collections["documents"].collectionSchema.SparseField = DefaultSparseField
+
+	_, err := store.AddDocuments(context.Background(), []*document.Document{
+		{Content: "hybrid search"},
+		{Content: "keyword matching"},
+	}, vectordb.WithInsertCollection("documents"),
+		vectordb.WithInsertEmbedder(&MockEmbedder{dimension: 3}))
+	require.NoError(t, err)
+	require.Len(t, fake.rows, 2)
+
+	expected, err := NewHashingSparseEmbedder().EmbedSparse(context.Background(), []string{"hybrid search"})
+	require.NoError(t, err)
+	row := fake.rows[0].(map[string]any)
+	assert.Equal(t, expected[0], row[DefaultSparseField])
+
+	// no sparse vectors without a sparse field
+	store, fake = newFakeStore("documents")
+	_, err = store.AddDocuments(context.Background(), []*document.Document{{Content: "dense"}},
+		vectordb.WithInsertCollection("documents"),
+		vectordb.WithInsertEmbedder(&MockEmbedder{dimension: 3}))
+	require.NoError(t, err)
+	assert.NotContains(t, fake.rows[0].(map[string]any), DefaultSparseField)
+}
+
+func TestHybridSearch(t *testing.T) {
+	schema := NewHybridCollectionSchema()
+	schema.CollectionName = "hybrid"
+	store, fake := newHybridFakeStore(t, schema)
+
+	docs, err := store.Search(context.Background(), "milvus hybrid", 2,
+		vectordb.WithSearchCollection("hybrid"),
+		vectordb.WithSearchEmbedder(&MockEmbedder{dimension: 3}),
+		WithMilvusHybrid("", nil),
+		vectordb.WithScoreThreshold(0.02))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, fake.searches)
+	require.Len(t, fake.hybridRequests, 2)
+	assert.Equal(t, client.NewRRFReranker(), fake.hybridReranker)
+	assert.NotContains(t, fake.hybridOutFields, DefaultSparseField)
+
+	// fused scores are higher for better results
+	require.Len(t, docs, 1)
+	assert.Equal(t, "milvus hybrid search", docs[0].Content)
+
+	// explicit reranker
+	reranker := client.NewWeightedReranker([]float64{0.7, 0.3})
+	_, err = store.Search(context.Background(), "milvus", 2,
+		vectordb.WithSearchCollection("hybrid"),
+		vectordb.WithSearchEmbedder(&MockEmbedder{dimension: 3}),
+		WithMilvusHybrid(DefaultSparseField, reranker))
+	require.NoError(t, err)
+	assert.Equal(t, reranker, fake.hybridReranker)
+}
+
+func TestSearchDenseOnlyByDefault(t *testing.T) {
+	schema := NewHybridCollectionSchema()
+	schema.CollectionName = "hybrid"
+	store, fake := newHybridFakeStore(t, schema)
+
+	docs, err := store.Search(context.Background(), "milvus", 2,
+		vectordb.WithSearchCollection("hybrid"),
+		vectordb.WithSearchEmbedder(&MockEmbedder{dimension: 3}))
+	require.NoError(t, err)
+	assert.Len(t, docs, 2)
+	assert.Equal(t, 1, fake.searches)
+	assert.Nil(t, fake.hybridRequests)
+}
+
+func TestHybridSearchRequiresSparseField(t *testing.T) {
+	schema := DefaultCollectionSchema()
+	schema.CollectionName = "dense"
+	store, fake := newHybridFakeStore(t, schema)
+
+	_, err := store.Search(context.Background(), "milvus", 2,
+		vectordb.WithSearchCollection("dense"),
+		vectordb.WithSearchEmbedder(&MockEmbedder{dimension: 3}),
+		WithMilvusHybrid("", nil))
+	assert.Error(t, err)
+	assert.Nil(t, fake.hybridRequests)
+}
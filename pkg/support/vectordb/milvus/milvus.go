@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/oopslink/agent-go/pkg/commons/errors"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/embedder"
 	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -31,6 +34,7 @@ type CollectionSchema struct {
 	MetaField      string
 	PrimaryField   string
 	VectorField    string
+	SparseField    string // optional sparse vector field, enables hybrid search
 	MaxTextLength  int
 	ShardNum       int32
 	MetricType     entity.MetricType
@@ -53,6 +57,70 @@ func DefaultCollectionSchema() *CollectionSchema {
 	}
 }
 
+// NewCollectionSchema returns the default collection schema using the given metric type.
+// entity.COSINE or entity.IP give intuitive scores (higher is better) for normalized embeddings.
+func NewCollectionSchema(metricType entity.MetricType) (*CollectionSchema, error) {
+	index, err := entity.NewIndexHNSW(metricType, 8, 64)
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeInvalidVectorDataSchema, err)
+	}
+	schema := DefaultCollectionSchema()
+	schema.MetricType = metricType
+	schema.Index = index
+	return schema, nil
+}
+
+// isSimilarityMetric reports whether higher scores mean closer vectors for the metric type
+func isSimilarityMetric(metricType entity.MetricType) bool {
+	return metricType == entity.IP || metricType == entity.COSINE
+}
+
+// passScoreThreshold checks a result against the score threshold, which is a minimum
+// similarity for similarity metrics and a maximum distance for distance metrics
+func passScoreThreshold(metricType entity.MetricType, score, threshold float32) bool {
+	if isSimilarityMetric(metricType) {
+		return score >= threshold
+	}
+	return score <= threshold
+}
+
+// indexForSchema returns the vector index of the schema, making sure it uses the schema metric type
+func indexForSchema(schema *CollectionSchema) (entity.Index, error) {
+	metricType := schema.MetricType
+	if metricType == "" {
+		metricType = entity.L2
+	}
+	if schema.Index == nil {
+		return entity.NewIndexHNSW(metricType, 8, 64)
+	}
+	params := schema.Index.Params()
+	if entity.MetricType(params["metric_type"]) == metricType {
+		return schema.Index, nil
+	}
+	params["metric_type"] = string(metricType)
+	return entity.NewGenericIndex(schema.Index.Name(), schema.Index.IndexType(), params), nil
+}
+
+// searchParamForIndex builds the search parameters matching the index type
+func searchParamForIndex(index entity.Index, topK int) (entity.SearchParam, error) {
+	var indexType entity.IndexType
+	if index != nil {
+		indexType = index.IndexType()
+	}
+	switch indexType {
+	case entity.HNSW:
+		return entity.NewIndexHNSWSearchParam(min(max(64, topK), 32768))
+	case entity.IvfFlat, entity.IvfSQ8, entity.IvfPQ, entity.GPUIvfFlat, entity.GPUIvfPQ:
+		return entity.NewIndexIvfFlatSearchParam(16)
+	case entity.DISKANN:
+		return entity.NewIndexDISKANNSearchParam(max(100, topK))
+	case entity.AUTOINDEX:
+		return entity.NewIndexAUTOINDEXSearchParam(1)
+	default:
+		return entity.NewIndexFlatSearchParam()
+	}
+}
+
 // MilvusInsertOptions implements vectordb.InsertOptions with Milvus-specific fields.
 type MilvusInsertOptions struct {
 	collection       string
@@ -62,6 +130,8 @@ type MilvusInsertOptions struct {
 	collectionSchema *CollectionSchema
 	dropOld          bool
 	async            bool
+	sparseEmbedder   SparseEmbedder
+	contentHashIds   bool
 }
 
 // MilvusSearchOptions implements vectordb.SearchOptions with Milvus-specific fields.
@@ -73,6 +143,13 @@ type MilvusSearchOptions struct {
 	partitionNames   []string
 	searchParameters entity.SearchParam
 	consistencyLevel entity.ConsistencyLevel
+	// searchParametersSet is true when the caller chose the search parameters,
+	// otherwise they are derived from the collection index
+	searchParametersSet bool
+	hybrid              bool
+	hybridSparseField   string
+	hybridReranker      client.Reranker
+	sparseEmbedder      SparseEmbedder
 }
 
 // Implement vectordb.InsertOptions interface
@@ -118,6 +195,22 @@ func (o *MilvusSearchOptions) GetFilterExpression() string {
 	return o.filters
 }
 
+func (o *MilvusSearchOptions) IsHybrid() bool {
+	return o.hybrid
+}
+
+func (o *MilvusSearchOptions) GetHybridSparseField() string {
+	return o.hybridSparseField
+}
+
+func (o *MilvusSearchOptions) GetHybridReranker() client.Reranker {
+	return o.hybridReranker
+}
+
+func (o *MilvusSearchOptions) GetSparseEmbedder() SparseEmbedder {
+	return o.sparseEmbedder
+}
+
 // Milvus-specific getters for InsertOptions
 func (o *MilvusInsertOptions) GetPartitionName() string {
 	return o.partitionName
@@ -139,6 +232,14 @@ func (o *MilvusInsertOptions) GetAsync() bool {
 	return o.async
 }
 
+func (o *MilvusInsertOptions) GetContentHashIds() bool {
+	return o.contentHashIds
+}
+
+func (o *MilvusInsertOptions) GetSparseEmbedder() SparseEmbedder {
+	return o.sparseEmbedder
+}
+
 // Setters for MilvusInsertOptions
 func (o *MilvusInsertOptions) SetCollection(collection string) {
 	o.collection = collection
@@ -168,6 +269,14 @@ func (o *MilvusInsertOptions) SetAsync(async bool) {
 	o.async = async
 }
 
+func (o *MilvusInsertOptions) SetContentHashIds(contentHashIds bool) {
+	o.contentHashIds = contentHashIds
+}
+
+func (o *MilvusInsertOptions) SetSparseEmbedder(embedder SparseEmbedder) {
+	o.sparseEmbedder = embedder
+}
+
 // Setters for MilvusSearchOptions
 func (o *MilvusSearchOptions) SetCollection(collection string) {
 	o.collection = collection
@@ -193,6 +302,7 @@ func (o *MilvusSearchOptions) SetPartitionNames(names []string) {
 
 func (o *MilvusSearchOptions) SetSearchParameters(params entity.SearchParam) {
 	o.searchParameters = params
+	o.searchParametersSet = true
 }
 
 func (o *MilvusSearchOptions) SetConsistencyLevel(level entity.ConsistencyLevel) {
@@ -203,6 +313,16 @@ func (o *MilvusSearchOptions) SetFilterExpression(filter string) {
 	o.filters = filter
 }
 
+func (o *MilvusSearchOptions) SetHybrid(sparseField string, reranker client.Reranker) {
+	o.hybrid = true
+	o.hybridSparseField = sparseField
+	o.hybridReranker = reranker
+}
+
+func (o *MilvusSearchOptions) SetSparseEmbedder(embedder SparseEmbedder) {
+	o.sparseEmbedder = embedder
+}
+
 // NewMilvusInsertOptions creates a new MilvusInsertOptions instance.
 func NewMilvusInsertOptions() *MilvusInsertOptions {
 	return &MilvusInsertOptions{
@@ -303,6 +423,7 @@ type MilvusUpdateOptions struct {
 	skipFlushOnWrite bool
 	collectionSchema *CollectionSchema
 	async            bool
+	sparseEmbedder   SparseEmbedder
 }
 
 // Implement vectordb.UpdateOptions interface
@@ -331,6 +452,10 @@ func (o *MilvusUpdateOptions) GetAsync() bool {
 	return o.async
 }
 
+func (o *MilvusUpdateOptions) GetSparseEmbedder() SparseEmbedder {
+	return o.sparseEmbedder
+}
+
 // Setters for MilvusUpdateOptions
 func (o *MilvusUpdateOptions) SetCollection(collection string) {
 	o.collection = collection
@@ -356,6 +481,10 @@ func (o *MilvusUpdateOptions) SetAsync(async bool) {
 	o.async = async
 }
 
+func (o *MilvusUpdateOptions) SetSparseEmbedder(embedder SparseEmbedder) {
+	o.sparseEmbedder = embedder
+}
+
 // NewMilvusUpdateOptions creates a new MilvusUpdateOptions instance.
 func NewMilvusUpdateOptions() *MilvusUpdateOptions {
 	return &MilvusUpdateOptions{
@@ -402,6 +531,8 @@ type collectionInfo struct {
 	loaded           bool
 	collectionExists bool
 	collectionSchema *CollectionSchema
+	// contentHashIds creates the collection with a VarChar primary key holding content hashes
+	contentHashIds bool
 }
 
 func NewClientConfig(endpoint, username, password string) (*client.Config, error) {
@@ -435,13 +566,18 @@ func NewClientConfigWithTimeout(endpoint, username, password string, timeout tim
 
 // New creates an active client connection to the Milvus server.
 func New(ctx context.Context, config client.Config) (*Store, error) {
+	return NewWithTimeout(ctx, config, DefaultTimeout)
+}
+
+// NewWithTimeout creates an active client connection to the Milvus server with custom timeout.
+func NewWithTimeout(ctx context.Context, config client.Config, timeout time.Duration) (*Store, error) {
 	// Validate config before attempting connection
 	if config.Address == "" {
 		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorStoreFailed, "milvus endpoint cannot be empty")
 	}
 
 	// Create context with timeout
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	client, err := client.NewClient(ctxWithTimeout, config)
@@ -452,45 +588,148 @@ func New(ctx context.Context, config client.Config) (*Store, error) {
 	return &Store{
 		client:      client,
 		collections: make(map[string]*collectionInfo),
+		config:      config,
+		timeout:     timeout,
 	}, nil
 }
 
-// NewWithTimeout creates an active client connection to the Milvus server with custom timeout.
-func NewWithTimeout(ctx context.Context, config client.Config, timeout time.Duration) (*Store, error) {
-	// Validate config before attempting connection
-	if config.Address == "" {
-		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorStoreFailed, "milvus endpoint cannot be empty")
+// Store is a wrapper around the milvus client.
+type Store struct {
+	client      client.Client
+	collections map[string]*collectionInfo
+	mu          sync.RWMutex // 保护collections map的读写锁
+
+	// config and timeout rebuild the client when its connection is lost
+	config   client.Config
+	timeout  time.Duration
+	clientMu sync.RWMutex // guards client, replaced on reconnection
+	// newClient creates the clients, client.NewClient by default
+	newClient func(ctx context.Context, config client.Config) (client.Client, error)
+}
+
+// Ping checks the health of the Milvus server, reconnecting first if the connection is lost.
+func (s *Store) Ping(ctx context.Context) error {
+	state, err := callWithResult(ctx, s, func(c client.Client) (*entity.MilvusState, error) {
+		return c.CheckHealth(ctx)
+	})
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeVectorDBUnavailable, err)
+	}
+	if !state.IsHealthy {
+		return errors.Errorf(vectordb.ErrorCodeVectorDBUnavailable,
+			"milvus is unhealthy: %s", strings.Join(state.Reasons, "; "))
 	}
+	return nil
+}
 
-	// Create context with timeout
+// call runs the operation with the current client. On a connection-level error the client is
+// rebuilt from the stored config and the operation is run once more with the new client.
+func (s *Store) call(ctx context.Context, operation func(c client.Client) error) error {
+	_, err := callWithResult(ctx, s, func(c client.Client) (struct{}, error) {
+		return struct{}{}, operation(c)
+	})
+	return err
+}
+
+// callInsert is call for the inserts, run once more only when the client was not ready: the
+// insert failing on a lost connection may have been written, and Milvus would store its rows twice.
+func (s *Store) callInsert(ctx context.Context, operation func(c client.Client) error) error {
+	_, err := callRetrying(ctx, s, isClientNotReady, func(c client.Client) (struct{}, error) {
+		return struct{}{}, operation(c)
+	})
+	return err
+}
+
+// callWithResult is call for the operations returning a result.
+func callWithResult[T any](ctx context.Context, s *Store, operation func(c client.Client) (T, error)) (T, error) {
+	return callRetrying(ctx, s, isConnectionError, operation)
+}
+
+// callRetrying runs the operation, and once more with a new client when the error is retryable.
+func callRetrying[T any](ctx context.Context, s *Store,
+	retryable func(ctx context.Context, err error) bool, operation func(c client.Client) (T, error)) (T, error) {
+	current := s.getClient()
+	result, err := operation(current)
+	if err == nil || !retryable(ctx, err) {
+		return result, err
+	}
+
+	reconnected, reconnectErr := s.reconnect(ctx, current)
+	if reconnectErr != nil {
+		return result, errors.Errorf(vectordb.ErrorCodeCreateVectorClientFailed,
+			"%s, and reconnection failed: %s", err.Error(), reconnectErr.Error())
+	}
+	return operation(reconnected)
+}
+
+func (s *Store) getClient() client.Client {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.client
+}
+
+// reconnect replaces the failed client by a new one, unless another operation already did.
+func (s *Store) reconnect(ctx context.Context, failed client.Client) (client.Client, error) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	if s.client != failed {
+		return s.client, nil
+	}
+	if s.config.Address == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorClientFailed, "no milvus config to reconnect with")
+	}
+
+	timeout := s.timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	client, err := client.NewClient(ctxWithTimeout, config)
+	newClient := s.newClient
+	if newClient == nil {
+		newClient = client.NewClient
+	}
+	reconnected, err := newClient(ctxWithTimeout, s.config)
 	if err != nil {
 		return nil, err
 	}
+	if failed != nil {
+		_ = failed.Close()
+	}
+	s.client = reconnected
+	return reconnected, nil
+}
 
-	return &Store{
-		client:      client,
-		collections: make(map[string]*collectionInfo),
-	}, nil
+// isConnectionError reports whether the error comes from a lost connection rather than from
+// the request, the cancellation of the context not being one.
+func isConnectionError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, client.ErrClientNotReady) {
+		return true
+	}
+	// Calls on a closed connection are canceled
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.Canceled
 }
 
-// Store is a wrapper around the milvus client.
-type Store struct {
-	client      client.Client
-	collections map[string]*collectionInfo
-	mu          sync.RWMutex // 保护collections map的读写锁
+// isClientNotReady reports whether the client failed the request without sending it.
+func isClientNotReady(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(err, client.ErrClientNotReady)
 }
 
 // AddDocuments adds the text and metadata from the documents to the Milvus collection.
+// Documents carrying a precomputed Embedding are stored with it, the others are embedded.
+// With vectordb.WithContentHashIds the documents are upserted with ids derived from their
+// content, which needs a VarChar primary key: collections created by such an insert get one,
+// collections with auto-generated ids are rejected.
 func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.InsertOption) ([]document.DocumentId, error) {
 	options := s.parseInsertOptions(opts...)
 
-	// Use provided embedder or default
-	embedder := options.GetEmbedder()
-	if embedder == nil {
+	if options.GetEmbedder() == nil && !hasEmbeddings(documents) {
 		return nil, errors.Errorf(vectordb.ErrorCodeAddDocumentFailed, "no embedder provided")
 	}
 
@@ -505,26 +744,30 @@ func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document
 		return nil, err
 	}
 
-	// Extract texts from documents
-	texts := make([]string, 0, len(documents))
-	for _, doc := range documents {
-		texts = append(texts, doc.Content)
-	}
-
-	// Generate embeddings
-	vectors, err := embedder.Embed(ctx, texts)
+	// Generate embeddings, reusing precomputed ones
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
 	}
 
-	if len(vectors) != len(documents) {
-		return nil, errors.Errorf(vectordb.ErrorCodeAddDocumentFailed,
-			"number of vectors from embedder does not match number of documents")
+	contentHashIds := options.GetContentHashIds()
+	if contentHashIds && !info.collectionExists {
+		info.contentHashIds = true
 	}
-
 	if err := s.initCollection(ctx, collectionName, info, len(vectors[0]), options.GetAsync()); err != nil {
 		return nil, err
 	}
+	if contentHashIds {
+		if field := primaryKeyField(info.schema); field == nil || field.DataType != entity.FieldTypeVarChar {
+			return nil, errors.Errorf(vectordb.ErrorCodeAddDocumentFailed,
+				"collection %s has no VarChar primary key for content-hash ids", collectionName)
+		}
+	}
+
+	sparseVectors, err := embedSparse(ctx, info.collectionSchema, options.GetSparseEmbedder(), documents)
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
 
 	// Prepare data for insertion
 	colsData := make([]interface{}, 0, len(documents))
@@ -543,18 +786,46 @@ func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document
 			schema.TextField:   doc.Content,
 			schema.VectorField: vector32,
 		}
+		if sparseVectors != nil {
+			docMap[schema.SparseField] = sparseVectors[i]
+		}
+		docId := doc.Id
+		if contentHashIds {
+			docId = document.ContentHashId(doc.Content)
+			docMap[schema.PrimaryField] = string(docId)
+		}
 		colsData = append(colsData, docMap)
-		docIds = append(docIds, doc.Id)
+		docIds = append(docIds, docId)
 	}
 
-	// Insert data into Milvus
-	_, err = s.client.InsertRows(ctx, collectionName, options.GetPartitionName(), colsData)
-	if err != nil {
-		return nil, err
+	if contentHashIds {
+		// Upsert by content hash, so identical content replaces the stored row
+		columns, err := entity.AnyToColumns(colsData, info.schema)
+		if err != nil {
+			return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+		}
+		err = s.call(ctx, func(c client.Client) error {
+			_, err := c.Upsert(ctx, collectionName, options.GetPartitionName(), columns...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Insert data into Milvus
+		err = s.callInsert(ctx, func(c client.Client) error {
+			_, err := c.InsertRows(ctx, collectionName, options.GetPartitionName(), colsData)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if !options.GetSkipFlushOnWrite() {
-		if err = s.client.Flush(ctx, collectionName, false); err != nil {
+		if err = s.call(ctx, func(c client.Client) error {
+			return c.Flush(ctx, collectionName, false)
+		}); err != nil {
 			return nil, err
 		}
 	}
@@ -608,21 +879,37 @@ func (s *Store) Search(ctx context.Context,
 	// Use configured filters
 	filter := options.GetFilterExpression()
 
-	// Use configured search parameters
+	// Use configured search parameters, or the ones matching the collection index
 	sp := options.GetSearchParameters()
+	if !options.searchParametersSet {
+		index, err := indexForSchema(info.collectionSchema)
+		if err != nil {
+			return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+		}
+		if sp, err = searchParamForIndex(index, maxDocuments); err != nil {
+			return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+		}
+	}
 
-	searchResult, err := s.client.Search(ctx,
-		collectionName,
-		partitions,
-		filter,
-		s.getSearchFields(info),
-		vectors,
-		info.collectionSchema.VectorField,
-		info.collectionSchema.MetricType,
-		maxDocuments,
-		sp,
-		client.WithSearchQueryConsistencyLevel(options.GetConsistencyLevel()),
-	)
+	var searchResult []client.SearchResult
+	if options.IsHybrid() {
+		searchResult, err = s.hybridSearch(ctx, collectionName, info, query, vectors[0], sp, maxDocuments, options)
+	} else {
+		searchResult, err = callWithResult(ctx, s, func(c client.Client) ([]client.SearchResult, error) {
+			return c.Search(ctx,
+				collectionName,
+				partitions,
+				filter,
+				s.getSearchFields(info),
+				vectors,
+				info.collectionSchema.VectorField,
+				info.collectionSchema.MetricType,
+				maxDocuments,
+				sp,
+				client.WithSearchQueryConsistencyLevel(options.GetConsistencyLevel()),
+			)
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -632,11 +919,16 @@ func (s *Store) Search(ctx context.Context,
 		return nil, err
 	}
 
-	// Apply score threshold if specified
+	// Apply score threshold if specified, for distance metrics it is the maximum distance.
+	// Scores fused by hybrid search are always higher for better results.
+	metricType := info.collectionSchema.MetricType
+	if options.IsHybrid() {
+		metricType = entity.IP
+	}
 	if threshold := options.GetScoreThreshold(); threshold > 0 {
 		filteredDocs := make([]*vectordb.ScoredDocument, 0)
 		for _, doc := range docs {
-			if doc.Score >= threshold {
+			if passScoreThreshold(metricType, doc.Score, threshold) {
 				filteredDocs = append(filteredDocs, doc)
 			}
 		}
@@ -647,12 +939,13 @@ func (s *Store) Search(ctx context.Context,
 }
 
 // UpdateDocuments updates existing documents in the Milvus collection.
+// Documents carrying a precomputed Embedding are not embedded again, so updating only
+// the metadata of documents fetched with their vectors costs no embedding calls.
+// Documents without an Embedding are embedded with the embedder option as usual.
 func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.UpdateOption) error {
 	options := s.parseUpdateOptions(opts...)
 
-	// Use provided embedder or default
-	embedder := options.GetEmbedder()
-	if embedder == nil {
+	if options.GetEmbedder() == nil && !hasEmbeddings(documents) {
 		return errors.Errorf(vectordb.ErrorCodeUpdateDocumentFailed, "no embedder provided")
 	}
 
@@ -667,21 +960,15 @@ func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Docum
 		return err
 	}
 
-	// Extract texts from documents
-	texts := make([]string, 0, len(documents))
-	for _, doc := range documents {
-		texts = append(texts, doc.Content)
-	}
-
-	// Generate embeddings
-	vectors, err := embedder.Embed(ctx, texts)
+	// Generate embeddings, reusing precomputed ones
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
 	if err != nil {
-		return err
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
 	}
 
-	if len(vectors) != len(documents) {
-		return errors.Errorf(vectordb.ErrorCodeUpdateDocumentFailed,
-			"number of vectors from embedder does not match number of documents")
+	sparseVectors, err := embedSparse(ctx, info.collectionSchema, options.GetSparseEmbedder(), documents)
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
 	}
 
 	// Prepare data for update
@@ -701,17 +988,25 @@ func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Docum
 			schema.TextField:    doc.Content,
 			schema.VectorField:  vector32,
 		}
+		if sparseVectors != nil {
+			docMap[schema.SparseField] = sparseVectors[i]
+		}
 		colsData = append(colsData, docMap)
 	}
 
 	// Update data in Milvus using insert (Milvus will handle upsert based on primary key)
-	_, err = s.client.InsertRows(ctx, collectionName, options.GetPartitionName(), colsData)
+	err = s.callInsert(ctx, func(c client.Client) error {
+		_, err := c.InsertRows(ctx, collectionName, options.GetPartitionName(), colsData)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	if !options.GetSkipFlushOnWrite() {
-		if err = s.client.Flush(ctx, collectionName, false); err != nil {
+		if err = s.call(ctx, func(c client.Client) error {
+			return c.Flush(ctx, collectionName, false)
+		}); err != nil {
 			return err
 		}
 	}
@@ -744,6 +1039,79 @@ func (s *Store) Delete(ctx context.Context, documentId document.DocumentId) erro
 		"delete operation requires collection name - consider using Milvus-specific delete methods")
 }
 
+// CollectionStats holds the statistics of a Milvus collection.
+type CollectionStats struct {
+	Name string
+	// RowCount is the number of entities stored, the entities not flushed yet may be missing
+	RowCount int64
+	// LoadState tells whether the collection is loaded in memory and can be searched
+	LoadState entity.LoadState
+}
+
+// IsLoaded reports whether the collection is loaded and can be searched.
+func (s CollectionStats) IsLoaded() bool {
+	return s.LoadState == entity.LoadStateLoaded
+}
+
+// HasCollection reports whether the collection exists in the Milvus database.
+func (s *Store) HasCollection(ctx context.Context, collectionName string) (bool, error) {
+	exists, err := callWithResult(ctx, s, func(c client.Client) (bool, error) {
+		return c.HasCollection(ctx, collectionName)
+	})
+	if err != nil {
+		return false, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
+			"failed to check collection existence: %s", err.Error())
+	}
+	return exists, nil
+}
+
+// CollectionStats returns the row count and the load state of the collection.
+func (s *Store) CollectionStats(ctx context.Context, collectionName string) (CollectionStats, error) {
+	exists, err := s.HasCollection(ctx, collectionName)
+	if err != nil {
+		return CollectionStats{}, err
+	}
+	if !exists {
+		return CollectionStats{}, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
+			"collection %s not found", collectionName)
+	}
+
+	statistics, err := callWithResult(ctx, s, func(c client.Client) (map[string]string, error) {
+		return c.GetCollectionStatistics(ctx, collectionName)
+	})
+	if err != nil {
+		return CollectionStats{}, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
+			"failed to get collection statistics: %s", err.Error())
+	}
+	stats := CollectionStats{Name: collectionName}
+	if rowCount, ok := statistics["row_count"]; ok {
+		stats.RowCount, err = strconv.ParseInt(rowCount, 10, 64)
+		if err != nil {
+			return CollectionStats{}, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
+				"invalid row count %q: %s", rowCount, err.Error())
+		}
+	}
+
+	stats.LoadState, err = callWithResult(ctx, s, func(c client.Client) (entity.LoadState, error) {
+		return c.GetLoadState(ctx, collectionName, nil)
+	})
+	if err != nil {
+		return CollectionStats{}, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
+			"failed to get collection load state: %s", err.Error())
+	}
+	return stats, nil
+}
+
+// hasEmbeddings reports whether every document carries a precomputed embedding
+func hasEmbeddings(documents []*document.Document) bool {
+	for _, doc := range documents {
+		if len(doc.Embedding) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // parseInsertOptions parses both standard and Milvus-specific insert options.
 func (s *Store) parseInsertOptions(opts ...vectordb.InsertOption) *MilvusInsertOptions {
 	options := NewMilvusInsertOptions()
@@ -798,7 +1166,9 @@ func (s *Store) getOrCreateCollection(ctx context.Context,
 	}
 
 	// Check if collection exists in Milvus
-	exists, err := s.client.HasCollection(ctx, collectionName)
+	exists, err := callWithResult(ctx, s, func(c client.Client) (bool, error) {
+		return c.HasCollection(ctx, collectionName)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -809,7 +1179,9 @@ func (s *Store) getOrCreateCollection(ctx context.Context,
 	}
 
 	if exists && dropOld {
-		if err := s.client.DropCollection(ctx, collectionName); err != nil {
+		if err := s.call(ctx, func(c client.Client) error {
+			return c.DropCollection(ctx, collectionName)
+		}); err != nil {
 			return nil, err
 		}
 		info.collectionExists = false
@@ -839,7 +1211,9 @@ func (s *Store) getOrLoadCollection(ctx context.Context, collectionName string)
 	}
 
 	// Check if collection exists in remote Milvus database
-	exists, err := s.client.HasCollection(ctx, collectionName)
+	exists, err := callWithResult(ctx, s, func(c client.Client) (bool, error) {
+		return c.HasCollection(ctx, collectionName)
+	})
 	if err != nil {
 		return nil, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
 			"failed to check collection existence: %s", err.Error())
@@ -851,7 +1225,9 @@ func (s *Store) getOrLoadCollection(ctx context.Context, collectionName string)
 	}
 
 	// Collection exists in remote database, load its schema and create info
-	collection, err := s.client.DescribeCollection(ctx, collectionName)
+	collection, err := callWithResult(ctx, s, func(c client.Client) (*entity.Collection, error) {
+		return c.DescribeCollection(ctx, collectionName)
+	})
 	if err != nil {
 		return nil, errors.Errorf(vectordb.ErrorCodeLoadCollectionFailed,
 			"failed to describe collection: %s", err.Error())
@@ -865,13 +1241,17 @@ func (s *Store) getOrLoadCollection(ctx context.Context, collectionName string)
 	for _, field := range collection.Schema.Fields {
 		switch field.DataType {
 		case entity.FieldTypeVarChar:
-			if field.Name != schema.PrimaryField {
+			if field.PrimaryKey {
+				schema.PrimaryField = field.Name
+			} else if field.Name != schema.PrimaryField {
 				schema.TextField = field.Name
 			}
 		case entity.FieldTypeJSON:
 			schema.MetaField = field.Name
 		case entity.FieldTypeFloatVector, entity.FieldTypeBinaryVector:
 			schema.VectorField = field.Name
+		case entity.FieldTypeSparseVector:
+			schema.SparseField = field.Name
 		case entity.FieldTypeInt64:
 			if field.PrimaryKey {
 				schema.PrimaryField = field.Name
@@ -879,6 +1259,17 @@ func (s *Store) getOrLoadCollection(ctx context.Context, collectionName string)
 		}
 	}
 
+	// Use the metric type the vector field was indexed with
+	indexes, err := callWithResult(ctx, s, func(c client.Client) ([]entity.Index, error) {
+		return c.DescribeIndex(ctx, collectionName, schema.VectorField)
+	})
+	if err == nil && len(indexes) > 0 {
+		schema.Index = indexes[0]
+		if metricType := indexes[0].Params()["metric_type"]; metricType != "" {
+			schema.MetricType = entity.MetricType(metricType)
+		}
+	}
+
 	info := &collectionInfo{
 		schema:           collection.Schema,
 		loaded:           false, // We'll check load status separately if needed
@@ -917,16 +1308,28 @@ func (s *Store) createCollection(ctx context.Context, collectionName string, inf
 	}
 
 	schema := info.collectionSchema
+	primaryField := &entity.Field{
+		Name:       schema.PrimaryField,
+		DataType:   entity.FieldTypeInt64,
+		AutoID:     true,
+		PrimaryKey: true,
+	}
+	if info.contentHashIds {
+		// hex encoded sha256 content hashes
+		primaryField = &entity.Field{
+			Name:       schema.PrimaryField,
+			DataType:   entity.FieldTypeVarChar,
+			PrimaryKey: true,
+			TypeParams: map[string]string{
+				entity.TypeParamMaxLength: "64",
+			},
+		}
+	}
 	info.schema = &entity.Schema{
 		CollectionName: collectionName,
-		AutoID:         true,
+		AutoID:         primaryField.AutoID,
 		Fields: []*entity.Field{
-			{
-				Name:       schema.PrimaryField,
-				DataType:   entity.FieldTypeInt64,
-				AutoID:     true,
-				PrimaryKey: true,
-			},
+			primaryField,
 			{
 				Name:     schema.TextField,
 				DataType: entity.FieldTypeVarChar,
@@ -950,8 +1353,16 @@ func (s *Store) createCollection(ctx context.Context, collectionName string, inf
 			},
 		},
 	}
+	if schema.SparseField != "" {
+		info.schema.Fields = append(info.schema.Fields, &entity.Field{
+			Name:     schema.SparseField,
+			DataType: entity.FieldTypeSparseVector,
+		})
+	}
 
-	err := s.client.CreateCollection(ctx, info.schema, schema.ShardNum, client.WithMetricsType(schema.MetricType))
+	err := s.call(ctx, func(c client.Client) error {
+		return c.CreateCollection(ctx, info.schema, schema.ShardNum, client.WithMetricsType(schema.MetricType))
+	})
 	if err != nil {
 		return err
 	}
@@ -964,7 +1375,9 @@ func (s *Store) extractFields(ctx context.Context, collectionName string, info *
 	if !info.collectionExists || info.schema != nil {
 		return nil
 	}
-	collection, err := s.client.DescribeCollection(ctx, collectionName)
+	collection, err := callWithResult(ctx, s, func(c client.Client) (*entity.Collection, error) {
+		return c.DescribeCollection(ctx, collectionName)
+	})
 	if err != nil {
 		return err
 	}
@@ -972,13 +1385,46 @@ func (s *Store) extractFields(ctx context.Context, collectionName string, info *
 	return nil
 }
 
+// primaryKeyField returns the primary key field of the schema, nil if there is none
+func primaryKeyField(schema *entity.Schema) *entity.Field {
+	if schema == nil {
+		return nil
+	}
+	for _, field := range schema.Fields {
+		if field.PrimaryKey {
+			return field
+		}
+	}
+	return nil
+}
+
 // createIndex creates an index for the vector field.
 func (s *Store) createIndex(ctx context.Context, collectionName string, info *collectionInfo, async bool) error {
 	if !info.collectionExists {
 		return nil
 	}
 
-	return s.client.CreateIndex(ctx, collectionName, info.collectionSchema.VectorField, info.collectionSchema.Index, async)
+	index, err := indexForSchema(info.collectionSchema)
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeInvalidVectorDataSchema, err)
+	}
+	if err := s.call(ctx, func(c client.Client) error {
+		return c.CreateIndex(ctx, collectionName, info.collectionSchema.VectorField, index, async)
+	}); err != nil {
+		return err
+	}
+
+	// sparse vectors are scored by inner product of the term weights
+	if sparseField := info.collectionSchema.SparseField; sparseField != "" {
+		sparseIndex, err := entity.NewIndexSparseInverted(entity.IP, 0)
+		if err != nil {
+			return errors.Wrap(vectordb.ErrorCodeInvalidVectorDataSchema, err)
+		}
+		return s.call(ctx, func(c client.Client) error {
+			return c.CreateIndex(ctx, collectionName, sparseField, sparseIndex, async)
+		})
+	}
+	return nil
 }
 
 // loadCollection loads a collection into memory.
@@ -987,7 +1433,9 @@ func (s *Store) loadCollection(ctx context.Context, collectionName string, info
 		return nil
 	}
 
-	err := s.client.LoadCollection(ctx, collectionName, async)
+	err := s.call(ctx, func(c client.Client) error {
+		return c.LoadCollection(ctx, collectionName, async)
+	})
 	if err == nil {
 		info.loaded = true
 	}
@@ -998,7 +1446,8 @@ func (s *Store) loadCollection(ctx context.Context, collectionName string, info
 func (s *Store) getSearchFields(info *collectionInfo) []string {
 	fields := []string{}
 	for _, f := range info.schema.Fields {
-		if f.DataType == entity.FieldTypeBinaryVector || f.DataType == entity.FieldTypeFloatVector {
+		if f.DataType == entity.FieldTypeBinaryVector || f.DataType == entity.FieldTypeFloatVector ||
+			f.DataType == entity.FieldTypeSparseVector {
 			continue
 		}
 		fields = append(fields, f.Name)
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/oopslink/agent-go/pkg/commons/errors"
 	"github.com/oopslink/agent-go/pkg/support/document"
 	"github.com/oopslink/agent-go/pkg/support/embedder"
 	"github.com/oopslink/agent-go/pkg/support/vectordb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // MockEmbedder is a mock implementation of the embedder.Embedder interface
@@ -34,6 +38,87 @@ func (m *MockEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.Fl
 	return vectors, nil
 }
 
+// countingEmbedder counts the texts it is asked to embed
+type countingEmbedder struct {
+	MockEmbedder
+	calls int
+	texts int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	c.calls++
+	c.texts += len(texts)
+	return c.MockEmbedder.Embed(ctx, texts)
+}
+
+// fakeClient records inserted rows and upserted documents, other client methods are not implemented
+type fakeClient struct {
+	client.Client
+	rows     []interface{}
+	upserted map[string]string // content by primary key
+	flushes  int
+}
+
+func (f *fakeClient) InsertRows(ctx context.Context, collName string, partitionName string, rows []interface{}) (entity.Column, error) {
+	f.rows = append(f.rows, rows...)
+	return nil, nil
+}
+
+func (f *fakeClient) Upsert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error) {
+	if f.upserted == nil {
+		f.upserted = map[string]string{}
+	}
+	var ids, texts entity.Column
+	for _, column := range columns {
+		switch column.Name() {
+		case "id":
+			ids = column
+		case "text":
+			texts = column
+		}
+	}
+	for i := 0; i < ids.Len(); i++ {
+		id, _ := ids.GetAsString(i)
+		text, _ := texts.GetAsString(i)
+		f.upserted[id] = text
+	}
+	return ids, nil
+}
+
+func (f *fakeClient) CreateCollection(ctx context.Context, schema *entity.Schema, shardsNum int32, opts ...client.CreateCollectionOption) error {
+	return nil
+}
+
+func (f *fakeClient) CreateIndex(ctx context.Context, collName string, fieldName string, idx entity.Index, async bool, opts ...client.IndexOption) error {
+	return nil
+}
+
+func (f *fakeClient) LoadCollection(ctx context.Context, collName string, async bool, opts ...client.LoadCollectionOption) error {
+	return nil
+}
+
+func (f *fakeClient) Flush(ctx context.Context, collName string, async bool, opts ...client.FlushOption) error {
+	f.flushes++
+	return nil
+}
+
+// newFakeStore returns a store with a loaded collection backed by the fake client
+func newFakeStore(collectionName string) (*Store, *fakeClient) {
+	fake := &fakeClient{}
+	schema := DefaultCollectionSchema()
+	schema.CollectionName = collectionName
+	return &Store{
+		client: fake,
+		collections: map[string]*collectionInfo{
+			collectionName: {
+				loaded:           true,
+				collectionExists: true,
+				collectionSchema: schema,
+			},
+		},
+	}, fake
+}
+
 // getMilvusConfig returns Milvus configuration from environment variables
 // Returns nil if required environment variables are not set
 func getMilvusConfig() *client.Config {
@@ -76,8 +161,11 @@ func setupMilvusTest(t *testing.T) (*Store, func()) {
 				if collection.Name == "test_collection" ||
 					collection.Name == "test_documents" ||
 					collection.Name == "test_search_collection" ||
+					collection.Name == "test_stats_collection" ||
 					collection.Name == "test_threshold_collection" ||
-					collection.Name == "concurrent_test_collection" {
+					collection.Name == "concurrent_test_collection" ||
+					collection.Name == "test_content_hash_collection" ||
+					strings.HasPrefix(collection.Name, "test_metric_") {
 					store.client.DropCollection(ctx, collection.Name)
 				}
 			}
@@ -145,6 +233,392 @@ func TestCollectionSchema(t *testing.T) {
 	assert.NotNil(t, schema.Index)
 }
 
+func TestNewCollectionSchema(t *testing.T) {
+	for _, metricType := range []entity.MetricType{entity.L2, entity.IP, entity.COSINE} {
+		t.Run(string(metricType), func(t *testing.T) {
+			schema, err := NewCollectionSchema(metricType)
+			require.NoError(t, err)
+			assert.Equal(t, metricType, schema.MetricType)
+			assert.Equal(t, entity.HNSW, schema.Index.IndexType())
+			assert.Equal(t, string(metricType), schema.Index.Params()["metric_type"])
+		})
+	}
+}
+
+func TestIndexForSchema(t *testing.T) {
+	t.Run("index follows the schema metric type", func(t *testing.T) {
+		// the default index is built for L2, switching the metric type only must not mismatch
+		schema := DefaultCollectionSchema()
+		schema.MetricType = entity.COSINE
+
+		index, err := indexForSchema(schema)
+		require.NoError(t, err)
+		assert.Equal(t, entity.HNSW, index.IndexType())
+		assert.Equal(t, "COSINE", index.Params()["metric_type"])
+		assert.Equal(t, schema.Index.Params()["params"], index.Params()["params"])
+	})
+
+	t.Run("missing index", func(t *testing.T) {
+		schema := &CollectionSchema{MetricType: entity.IP}
+		index, err := indexForSchema(schema)
+		require.NoError(t, err)
+		assert.Equal(t, entity.HNSW, index.IndexType())
+		assert.Equal(t, "IP", index.Params()["metric_type"])
+
+		index, err = indexForSchema(&CollectionSchema{})
+		require.NoError(t, err)
+		assert.Equal(t, "L2", index.Params()["metric_type"])
+	})
+
+	t.Run("matching index is kept", func(t *testing.T) {
+		schema, err := NewCollectionSchema(entity.IP)
+		require.NoError(t, err)
+		index, err := indexForSchema(schema)
+		require.NoError(t, err)
+		assert.Same(t, schema.Index, index)
+	})
+}
+
+func TestSearchParamForIndex(t *testing.T) {
+	hnsw, _ := entity.NewIndexHNSW(entity.COSINE, 8, 64)
+	ivf, _ := entity.NewIndexIvfFlat(entity.IP, 128)
+	flat, _ := entity.NewIndexFlat(entity.L2)
+	auto, _ := entity.NewIndexAUTOINDEX(entity.COSINE)
+
+	testCases := []struct {
+		name     string
+		index    entity.Index
+		topK     int
+		expected map[string]interface{}
+	}{
+		{"hnsw", hnsw, 10, map[string]interface{}{"ef": 64}},
+		{"hnsw with large topK", hnsw, 200, map[string]interface{}{"ef": 200}},
+		{"ivf flat", ivf, 10, map[string]interface{}{"nprobe": 16}},
+		{"flat", flat, 10, map[string]interface{}{}},
+		{"autoindex", auto, 10, map[string]interface{}{"level": 1}},
+		{"no index", nil, 10, map[string]interface{}{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sp, err := searchParamForIndex(tc.index, tc.topK)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sp.Params())
+		})
+	}
+}
+
+func TestPassScoreThreshold(t *testing.T) {
+	testCases := []struct {
+		metricType entity.MetricType
+		score      float32
+		threshold  float32
+		expected   bool
+	}{
+		// similarity metrics, higher is better
+		{entity.COSINE, 0.95, 0.8, true},
+		{entity.COSINE, 0.5, 0.8, false},
+		{entity.IP, 3.2, 1.0, true},
+		{entity.IP, 0.2, 1.0, false},
+		// distance metrics, lower is better
+		{entity.L2, 0.1, 0.5, true},
+		{entity.L2, 2.5, 0.5, false},
+		{entity.HAMMING, 3, 4, true},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, passScoreThreshold(tc.metricType, tc.score, tc.threshold),
+			"%s score=%v threshold=%v", tc.metricType, tc.score, tc.threshold)
+	}
+	assert.True(t, isSimilarityMetric(entity.COSINE))
+	assert.True(t, isSimilarityMetric(entity.IP))
+	assert.False(t, isSimilarityMetric(entity.L2))
+}
+
+func TestUpdateDocumentsReusesVectors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("precomputed vectors skip the embedder", func(t *testing.T) {
+		store, fake := newFakeStore("documents")
+		emb := &countingEmbedder{MockEmbedder: MockEmbedder{dimension: 3}}
+
+		docs := []*document.Document{
+			{Id: "doc1", Content: "first", Metadata: map[string]any{"v": 2}, Embedding: embedder.FloatVector{0.1, 0.2, 0.3}},
+			{Id: "doc2", Content: "second", Metadata: map[string]any{"v": 2}, Embedding: embedder.FloatVector{0.4, 0.5, 0.6}},
+		}
+		err := store.UpdateDocuments(ctx, docs, vectordb.WithUpdateEmbedder(emb))
+		require.NoError(t, err)
+		assert.Equal(t, 0, emb.calls)
+
+		require.Len(t, fake.rows, 2)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, fake.rows[0].(map[string]any)["vector"])
+		assert.Equal(t, []float32{0.4, 0.5, 0.6}, fake.rows[1].(map[string]any)["vector"])
+		assert.Equal(t, 1, fake.flushes)
+
+		// no embedder is needed at all
+		err = store.UpdateDocuments(ctx, docs)
+		require.NoError(t, err)
+	})
+
+	t.Run("only documents without vectors are embedded", func(t *testing.T) {
+		store, fake := newFakeStore("documents")
+		emb := &countingEmbedder{MockEmbedder: MockEmbedder{dimension: 3}}
+
+		docs := []*document.Document{
+			{Id: "doc1", Content: "first", Embedding: embedder.FloatVector{0.1, 0.2, 0.3}},
+			{Id: "doc2", Content: "second"},
+		}
+		err := store.UpdateDocuments(ctx, docs, vectordb.WithUpdateEmbedder(emb))
+		require.NoError(t, err)
+		assert.Equal(t, 1, emb.calls)
+		assert.Equal(t, 1, emb.texts)
+		require.Len(t, fake.rows, 2)
+		assert.Len(t, fake.rows[1].(map[string]any)["vector"], 3)
+	})
+
+	t.Run("documents without vectors require an embedder", func(t *testing.T) {
+		store, fake := newFakeStore("documents")
+		err := store.UpdateDocuments(ctx, []*document.Document{{Id: "doc1", Content: "first"}})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no embedder provided")
+		assert.Empty(t, fake.rows)
+	})
+}
+
+func TestAddDocumentsReusesVectors(t *testing.T) {
+	store, fake := newFakeStore("documents")
+	emb := &countingEmbedder{MockEmbedder: MockEmbedder{dimension: 3}}
+
+	ids, err := store.AddDocuments(context.Background(), []*document.Document{
+		{Id: "doc1", Content: "first", Embedding: embedder.FloatVector{1, 0, 0}},
+	}, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc1"}, ids)
+	assert.Equal(t, 0, emb.calls)
+	require.Len(t, fake.rows, 1)
+	assert.Equal(t, []float32{1, 0, 0}, fake.rows[0].(map[string]any)["vector"])
+}
+
+func TestAddDocumentsContentHashIds(t *testing.T) {
+	store, fake := newFakeStore("documents")
+	info := store.collections["documents"]
+	info.loaded, info.collectionExists = false, false
+	emb := &MockEmbedder{dimension: 3}
+
+	docs := []*document.Document{
+		{Id: "doc1", Content: "same content", Metadata: map[string]any{"n": 1}},
+	}
+	ids, err := store.AddDocuments(context.Background(), docs,
+		vectordb.WithInsertEmbedder(emb), vectordb.WithContentHashIds())
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{document.ContentHashId("same content")}, ids)
+
+	// the created collection has a VarChar primary key
+	field := primaryKeyField(info.schema)
+	require.NotNil(t, field)
+	assert.Equal(t, entity.FieldTypeVarChar, field.DataType)
+	assert.False(t, field.AutoID)
+
+	// inserting the same content again is an upsert of the same row
+	docs[0].Id = "doc2"
+	again, err := store.AddDocuments(context.Background(), docs,
+		vectordb.WithInsertEmbedder(emb), vectordb.WithContentHashIds())
+	require.NoError(t, err)
+	assert.Equal(t, ids, again)
+	assert.Equal(t, map[string]string{string(ids[0]): "same content"}, fake.upserted)
+	assert.Empty(t, fake.rows)
+	assert.Equal(t, 2, fake.flushes)
+}
+
+func TestAddDocumentsContentHashIdsNeedVarCharPrimaryKey(t *testing.T) {
+	store, fake := newFakeStore("documents")
+	store.collections["documents"].schema = &entity.Schema{Fields: []*entity.Field{
+		{Name: "id", DataType: entity.FieldTypeInt64, PrimaryKey: true, AutoID: true},
+	}}
+
+	_, err := store.AddDocuments(context.Background(), []*document.Document{
+		{Content: "content", Embedding: embedder.FloatVector{1, 0, 0}},
+	}, vectordb.WithContentHashIds())
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeAddDocumentFailed))
+	assert.Empty(t, fake.upserted)
+	assert.Empty(t, fake.rows)
+}
+
+// statsFakeClient answers the existence, statistics and load state requests of the collections
+type statsFakeClient struct {
+	client.Client
+	rowCounts map[string]string
+}
+
+func (f *statsFakeClient) HasCollection(ctx context.Context, collName string) (bool, error) {
+	_, ok := f.rowCounts[collName]
+	return ok, nil
+}
+
+func (f *statsFakeClient) GetCollectionStatistics(ctx context.Context, collName string) (map[string]string, error) {
+	return map[string]string{"row_count": f.rowCounts[collName]}, nil
+}
+
+func (f *statsFakeClient) GetLoadState(ctx context.Context, collName string, partitionNames []string) (entity.LoadState, error) {
+	return entity.LoadStateLoaded, nil
+}
+
+func TestCollectionStatsWithFakeClient(t *testing.T) {
+	store := &Store{
+		client:      &statsFakeClient{rowCounts: map[string]string{"documents": "42"}},
+		collections: make(map[string]*collectionInfo),
+	}
+	ctx := context.Background()
+
+	exists, err := store.HasCollection(ctx, "documents")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = store.HasCollection(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	stats, err := store.CollectionStats(ctx, "documents")
+	require.NoError(t, err)
+	assert.Equal(t, CollectionStats{Name: "documents", RowCount: 42, LoadState: entity.LoadStateLoaded}, stats)
+	assert.True(t, stats.IsLoaded())
+
+	_, err = store.CollectionStats(ctx, "missing")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeLoadCollectionFailed))
+}
+
+// droppedFakeClient fails every request as if the connection to the server was lost
+type droppedFakeClient struct {
+	client.Client
+	closed bool
+}
+
+func (f *droppedFakeClient) HasCollection(ctx context.Context, collName string) (bool, error) {
+	return false, status.Error(codes.Unavailable, "connection refused")
+}
+
+func (f *droppedFakeClient) CheckHealth(ctx context.Context) (*entity.MilvusState, error) {
+	return nil, status.Error(codes.Unavailable, "connection refused")
+}
+
+func (f *droppedFakeClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+// healthyFakeClient answers like a healthy server
+type healthyFakeClient struct {
+	statsFakeClient
+	healthy bool
+}
+
+func (f *healthyFakeClient) CheckHealth(ctx context.Context) (*entity.MilvusState, error) {
+	if f.healthy {
+		return &entity.MilvusState{IsHealthy: true}, nil
+	}
+	return &entity.MilvusState{IsHealthy: false, Reasons: []string{"querynode down"}}, nil
+}
+
+func TestReconnectOnConnectionError(t *testing.T) {
+	dropped := &droppedFakeClient{}
+	healthy := &healthyFakeClient{statsFakeClient: statsFakeClient{rowCounts: map[string]string{"documents": "1"}}, healthy: true}
+	dials := 0
+	store := &Store{
+		client:      dropped,
+		collections: make(map[string]*collectionInfo),
+		config:      client.Config{Address: "localhost:19530"},
+		newClient: func(ctx context.Context, config client.Config) (client.Client, error) {
+			dials++
+			assert.Equal(t, "localhost:19530", config.Address)
+			return healthy, nil
+		},
+	}
+	ctx := context.Background()
+
+	exists, err := store.HasCollection(ctx, "documents")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 1, dials)
+	assert.True(t, dropped.closed)
+	assert.Equal(t, client.Client(healthy), store.client)
+
+	// the new client is kept
+	require.NoError(t, store.Ping(ctx))
+	assert.Equal(t, 1, dials)
+
+	healthy.healthy = false
+	err = store.Ping(ctx)
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeVectorDBUnavailable))
+	assert.Contains(t, err.Error(), "querynode down")
+}
+
+// insertFakeClient fails the inserts as configured, recording the rows of the others
+type insertFakeClient struct {
+	fakeClient
+	failures []error
+	inserts  int
+}
+
+func (f *insertFakeClient) InsertRows(ctx context.Context, collName string, partitionName string, rows []interface{}) (entity.Column, error) {
+	f.inserts++
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return nil, err
+	}
+	return f.fakeClient.InsertRows(ctx, collName, partitionName, rows)
+}
+
+func (f *insertFakeClient) Close() error {
+	return nil
+}
+
+func TestInsertNotReplayedOnLostConnection(t *testing.T) {
+	store, _ := newFakeStore("documents")
+	inserting := &insertFakeClient{failures: []error{status.Error(codes.Unavailable, "connection reset")}}
+	dials := 0
+	store.client = inserting
+	store.config = client.Config{Address: "localhost:19530"}
+	store.newClient = func(ctx context.Context, config client.Config) (client.Client, error) {
+		dials++
+		return inserting, nil
+	}
+	documents := []*document.Document{{Id: "doc1", Content: "first", Embedding: embedder.FloatVector{1, 0, 0}}}
+
+	// the rows may have been written before the connection was lost, they are not inserted again
+	_, err := store.AddDocuments(context.Background(), documents)
+	require.Error(t, err)
+	assert.Equal(t, 1, inserting.inserts)
+	assert.Equal(t, 0, dials)
+
+	// the client not ready did not send the rows, they are inserted with a new client
+	inserting.failures = []error{client.ErrClientNotReady}
+	_, err = store.AddDocuments(context.Background(), documents)
+	require.NoError(t, err)
+	assert.Equal(t, 3, inserting.inserts)
+	assert.Equal(t, 1, dials)
+	assert.Len(t, inserting.rows, 1)
+}
+
+func TestReconnectFailure(t *testing.T) {
+	store := &Store{
+		client:      &droppedFakeClient{},
+		collections: make(map[string]*collectionInfo),
+		config:      client.Config{Address: "localhost:19530"},
+		newClient: func(ctx context.Context, config client.Config) (client.Client, error) {
+			return nil, fmt.Errorf("dial failed")
+		},
+	}
+
+	err := store.Ping(context.Background())
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeVectorDBUnavailable))
+	assert.Contains(t, err.Error(), "dial failed")
+
+	// the requests canceled by their context are not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, isConnectionError(ctx, status.Error(codes.Canceled, "context canceled")))
+	assert.False(t, isConnectionError(context.Background(), status.Error(codes.InvalidArgument, "bad request")))
+}
+
 func TestMilvusInsertOptions(t *testing.T) {
 	options := NewMilvusInsertOptions()
 
@@ -317,6 +791,37 @@ func TestAddDocuments(t *testing.T) {
 	assert.Equal(t, customSchema, collectionInfo.collectionSchema)
 }
 
+func TestAddDocumentsContentHashIdsMilvus(t *testing.T) {
+	store, cleanup := setupMilvusTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	mockEmbedder := &MockEmbedder{dimension: 128}
+	schema := DefaultCollectionSchema()
+	schema.CollectionName = "test_content_hash_collection"
+
+	docs := []*document.Document{
+		{Content: "identical content", Metadata: map[string]any{"category": "test"}},
+	}
+	for i := 0; i < 2; i++ {
+		ids, err := store.AddDocuments(ctx, docs,
+			vectordb.WithInsertEmbedder(mockEmbedder),
+			vectordb.WithContentHashIds(),
+			WithMilvusCollectionSchema(schema),
+			WithMilvusDropOld(i == 0),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []document.DocumentId{document.ContentHashId("identical content")}, ids)
+	}
+
+	result, err := store.client.Query(ctx, schema.CollectionName, nil, "", []string{"count(*)"},
+		client.WithSearchQueryConsistencyLevel(entity.ClStrong))
+	require.NoError(t, err)
+	count, err := result.GetColumn("count(*)").GetAsInt64(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
 func TestSearch(t *testing.T) {
 	store, cleanup := setupMilvusTest(t)
 	defer cleanup()
@@ -383,6 +888,61 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestCollectionStats(t *testing.T) {
+	store, cleanup := setupMilvusTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	mockEmbedder := &MockEmbedder{dimension: 128}
+
+	customSchema := DefaultCollectionSchema()
+	customSchema.CollectionName = "test_stats_collection"
+
+	_, err := store.AddDocuments(ctx, []*document.Document{
+		{Id: document.DocumentId("doc1"), Content: "first", Metadata: map[string]any{}},
+		{Id: document.DocumentId("doc2"), Content: "second", Metadata: map[string]any{}},
+	},
+		vectordb.WithInsertEmbedder(mockEmbedder),
+		WithMilvusCollectionSchema(customSchema),
+		WithMilvusDropOld(true),
+		WithMilvusAsync(false),
+	)
+	require.NoError(t, err)
+
+	exists, err := store.HasCollection(ctx, "test_stats_collection")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	stats, err := store.CollectionStats(ctx, "test_stats_collection")
+	require.NoError(t, err)
+	assert.Equal(t, "test_stats_collection", stats.Name)
+	assert.Equal(t, int64(2), stats.RowCount)
+	assert.True(t, stats.IsLoaded())
+
+	exists, err = store.HasCollection(ctx, "nonexistent")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, err = store.CollectionStats(ctx, "nonexistent")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeLoadCollectionFailed))
+}
+
+func TestPingAndReconnect(t *testing.T) {
+	store, cleanup := setupMilvusTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	require.NoError(t, store.Ping(ctx))
+
+	// Simulate a dropped connection by closing the client under the store
+	dropped := store.client
+	require.NoError(t, dropped.Close())
+
+	_, err := store.HasCollection(ctx, "test_collection")
+	require.NoError(t, err)
+	assert.NotSame(t, dropped, store.client)
+	require.NoError(t, store.Ping(ctx))
+}
+
 func TestSearchWithScoreThreshold(t *testing.T) {
 	store, cleanup := setupMilvusTest(t)
 	defer cleanup()
@@ -409,7 +969,7 @@ func TestSearchWithScoreThreshold(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	// Search with high score threshold (should return fewer or no results)
+	// Search with a small distance threshold (should return fewer or no results)
 	results, err := store.Search(ctx, "completely different content", 10,
 		vectordb.WithSearchCollection("test_threshold_collection"),
 		vectordb.WithSearchEmbedder(mockEmbedder),
@@ -417,9 +977,58 @@ func TestSearchWithScoreThreshold(t *testing.T) {
 	)
 
 	require.NoError(t, err)
-	// With a high threshold and different content, we should get fewer results
+	// L2 is a distance metric, the threshold is the maximum distance
 	for _, result := range results {
-		assert.GreaterOrEqual(t, result.Score, float32(0.9))
+		assert.LessOrEqual(t, result.Score, float32(0.9))
+	}
+}
+
+func TestSearchMetricTypes(t *testing.T) {
+	store, cleanup := setupMilvusTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	mockEmbedder := &MockEmbedder{dimension: 8}
+
+	docs := []*document.Document{
+		{Id: "doc1", Content: "short", Metadata: map[string]any{"id": 1}},
+		{Id: "doc2", Content: "a much longer text", Metadata: map[string]any{"id": 2}},
+	}
+
+	for _, metricType := range []entity.MetricType{entity.L2, entity.IP, entity.COSINE} {
+		t.Run(string(metricType), func(t *testing.T) {
+			collection := "test_metric_" + strings.ToLower(string(metricType))
+			schema, err := NewCollectionSchema(metricType)
+			require.NoError(t, err)
+			schema.CollectionName = collection
+
+			_, err = store.AddDocuments(ctx, docs,
+				vectordb.WithInsertEmbedder(mockEmbedder),
+				WithMilvusCollectionSchema(schema),
+				WithMilvusDropOld(true),
+			)
+			require.NoError(t, err)
+
+			results, err := store.Search(ctx, "short", 2,
+				vectordb.WithSearchCollection(collection),
+				vectordb.WithSearchEmbedder(mockEmbedder),
+				WithMilvusConsistencyLevel(entity.ClStrong),
+			)
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, "short", results[0].Content)
+
+			// the best result passes a threshold at its own score for every metric
+			results, err = store.Search(ctx, "short", 2,
+				vectordb.WithSearchCollection(collection),
+				vectordb.WithSearchEmbedder(mockEmbedder),
+				vectordb.WithScoreThreshold(max(results[0].Score, 1e-6)),
+				WithMilvusConsistencyLevel(entity.ClStrong),
+			)
+			require.NoError(t, err)
+			require.NotEmpty(t, results)
+			assert.Equal(t, "short", results[0].Content)
+		})
 	}
 }
 
@@ -0,0 +1,197 @@
+package pgvector
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	pgv "github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmbedder is a mock implementation of the embedder.Embedder interface
+type MockEmbedder struct {
+	dimension int
+}
+
+func (m *MockEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i := range texts {
+		// Generate deterministic vectors based on text length and dimension
+		vector := make([]float64, m.dimension)
+		for j := 0; j < m.dimension; j++ {
+			vector[j] = float64((len(texts[i])+j)%10) / 10.0
+		}
+		vectors[i] = embedder.FloatVector(vector)
+	}
+	return vectors, nil
+}
+
+func TestNewClientConfig(t *testing.T) {
+	config, err := NewClientConfig("postgres://localhost:5432/test")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://localhost:5432/test", config.DSN)
+	assert.Equal(t, DefaultCollection, config.DefaultCollection)
+
+	_, err = NewClientConfig("")
+	assert.Error(t, err)
+}
+
+func TestNewWithDB_InvalidCollection(t *testing.T) {
+	_, err := NewWithDB(nil, "documents; DROP TABLE users")
+	assert.Error(t, err)
+
+	store, err := NewWithDB(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCollection, store.defaultCollection)
+}
+
+func TestStore_TableName(t *testing.T) {
+	store, err := NewWithDB(nil, "docs")
+	require.NoError(t, err)
+
+	table, err := store.tableName("")
+	require.NoError(t, err)
+	assert.Equal(t, "docs", table)
+
+	table, err = store.tableName("other_docs")
+	require.NoError(t, err)
+	assert.Equal(t, "other_docs", table)
+
+	_, err = store.tableName("bad-name")
+	assert.Error(t, err)
+	_, err = store.tableName(`x" OR 1=1`)
+	assert.Error(t, err)
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	vector := pgv.NewVector([]float32{1, 2, 3})
+
+	t.Run("no options", func(t *testing.T) {
+		query, args, err := buildSearchQuery("docs", vector, 5, 0, nil)
+		require.NoError(t, err)
+		assert.Equal(t,
+			`SELECT id, name, content, metadata, embedding <-> $1 AS distance FROM "docs" ORDER BY embedding <-> $1 LIMIT $2`,
+			query)
+		assert.Equal(t, []any{vector, 5}, args)
+	})
+
+	t.Run("metadata filters and threshold", func(t *testing.T) {
+		query, args, err := buildSearchQuery("docs", vector, 3, 0.5,
+			map[string]any{"kind": "faq", "lang": "en"})
+		require.NoError(t, err)
+		assert.Equal(t,
+			`SELECT id, name, content, metadata, embedding <-> $1 AS distance FROM "docs" `+
+				`WHERE metadata @> $2::jsonb AND (embedding <-> $1) <= $3 ORDER BY embedding <-> $1 LIMIT $4`,
+			query)
+		require.Len(t, args, 4)
+		assert.Equal(t, `{"kind":"faq","lang":"en"}`, args[1])
+		assert.InDelta(t, 1.0, args[2].(float64), 1e-9)
+		assert.Equal(t, 3, args[3])
+	})
+
+	t.Run("raw sql filter", func(t *testing.T) {
+		query, _, err := buildSearchQuery("docs", vector, 0, 0, "name = 'a'")
+		require.NoError(t, err)
+		assert.Equal(t,
+			`SELECT id, name, content, metadata, embedding <-> $1 AS distance FROM "docs" `+
+				`WHERE (name = 'a') ORDER BY embedding <-> $1`,
+			query)
+	})
+
+	t.Run("unsupported filters", func(t *testing.T) {
+		_, _, err := buildSearchQuery("docs", vector, 1, 0, 42)
+		assert.Error(t, err)
+	})
+}
+
+func TestScoreConversion(t *testing.T) {
+	assert.Equal(t, float32(1), distanceToScore(0))
+	assert.Equal(t, float32(0.5), distanceToScore(1))
+	assert.InDelta(t, 1.0, scoreToDistance(0.5), 1e-9)
+	assert.InDelta(t, 0.0, scoreToDistance(1), 1e-9)
+}
+
+func TestVectorConversion(t *testing.T) {
+	vector := embedder.FloatVector{0.5, 1, -2}
+	assert.Equal(t, vector, fromPgVector(toPgVector(vector)))
+}
+
+// setupPgvectorTest connects to the database given by PGVECTOR_DSN
+func setupPgvectorTest(t *testing.T) *Store {
+	dsn := os.Getenv("PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("Skipping pgvector tests: PGVECTOR_DSN not set")
+	}
+	config, err := NewClientConfig(dsn)
+	require.NoError(t, err)
+	config.DefaultCollection = "test_pgvector_documents"
+
+	store, err := New(context.Background(), *config)
+	if err != nil {
+		t.Skipf("Skipping pgvector tests: cannot connect to database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.DropCollection(context.Background(), "test_pgvector_documents")
+		_ = store.Close()
+	})
+	return store
+}
+
+func TestStore_Integration(t *testing.T) {
+	store := setupPgvectorTest(t)
+	ctx := context.Background()
+	emb := &MockEmbedder{dimension: 8}
+
+	docs := []*document.Document{
+		document.NewDocument("doc_1", "short", map[string]any{"kind": "a"}, "short"),
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "b"}, "a longer text"),
+	}
+	ids, err := store.AddDocuments(ctx, docs, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc_1", "doc_2"}, ids)
+
+	results, err := store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithFilters(map[string]any{"kind": "b"}))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_2"), results[0].Id)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithScoreThreshold(0.99))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "c"}, "updated"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	require.NoError(t, err)
+
+	doc, err := store.Get(ctx, "doc_2")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", doc.Content)
+	assert.Equal(t, "c", doc.Metadata["kind"])
+	assert.Len(t, doc.Embedding, 8)
+
+	listed, err := store.ListDocuments(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "short", listed[0].Content)
+	assert.Equal(t, "c", listed[1].Metadata["kind"])
+
+	require.NoError(t, store.Delete(ctx, "doc_2"))
+	_, err = store.Get(ctx, "doc_2")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	assert.True(t, errors.IsCode(store.Delete(ctx, "doc_2"), vectordb.ErrorCodeDocumentNotFound))
+}
@@ -0,0 +1,721 @@
+// Package qdrant provides a vectordb.VectorDB implementation on top of the HTTP API
+// of the Qdrant vector database.
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+)
+
+const (
+	// DefaultTimeout is the default timeout of the requests to Qdrant
+	DefaultTimeout = 15 * time.Second
+	// DefaultCollection is the collection used when no collection is given in the options
+	DefaultCollection = "documents"
+
+	// Keys of the payload of the points
+	payloadKeyDocumentId = "document_id"
+	payloadKeyName       = "name"
+	payloadKeyContent    = "content"
+	payloadKeyMetadata   = "metadata"
+)
+
+var (
+	_ vectordb.VectorDB = &Store{}
+
+	// pointIdNamespace derives the UUID point ids from the document ids which are not UUIDs
+	pointIdNamespace = uuid.NameSpaceOID
+)
+
+// Distance is the metric comparing the vectors of a collection.
+type Distance string
+
+const (
+	// DistanceCosine scores by cosine similarity, higher is more similar.
+	DistanceCosine Distance = "Cosine"
+	// DistanceDot scores by dot product, higher is more similar.
+	DistanceDot Distance = "Dot"
+	// DistanceEuclid scores by euclidean distance, lower is more similar: the score
+	// threshold of a search is then a maximum distance.
+	DistanceEuclid Distance = "Euclid"
+)
+
+// Config holds the connection configuration of the Qdrant store.
+type Config struct {
+	// URL is the address of the HTTP API of Qdrant, e.g. "http://localhost:6333"
+	URL string
+	// APIKey is sent in the api-key header when the server requires authentication
+	APIKey string
+	// DefaultCollection is the collection used by Get/Delete and when no collection is given
+	DefaultCollection string
+	// Timeout is the timeout of each request, DefaultTimeout by default
+	Timeout time.Duration
+}
+
+// NewClientConfig creates a new Qdrant client configuration.
+func NewClientConfig(url string) (*Config, error) {
+	if url == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorClientFailed, "url cannot be empty")
+	}
+	return &Config{
+		URL:               url,
+		DefaultCollection: DefaultCollection,
+		Timeout:           DefaultTimeout,
+	}, nil
+}
+
+// QdrantInsertOptions implements vectordb.InsertOptions with Qdrant-specific fields.
+type QdrantInsertOptions struct {
+	collection     string
+	embedder       embedder.Embedder
+	contentHashIds bool
+	distance       Distance
+	wait           bool
+}
+
+func (o *QdrantInsertOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *QdrantInsertOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *QdrantInsertOptions) GetContentHashIds() bool {
+	return o.contentHashIds
+}
+
+func (o *QdrantInsertOptions) GetDistance() Distance {
+	return o.distance
+}
+
+func (o *QdrantInsertOptions) GetWait() bool {
+	return o.wait
+}
+
+func (o *QdrantInsertOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *QdrantInsertOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+func (o *QdrantInsertOptions) SetContentHashIds(contentHashIds bool) {
+	o.contentHashIds = contentHashIds
+}
+
+func (o *QdrantInsertOptions) SetDistance(distance Distance) {
+	o.distance = distance
+}
+
+func (o *QdrantInsertOptions) SetWait(wait bool) {
+	o.wait = wait
+}
+
+// NewQdrantInsertOptions creates a new QdrantInsertOptions instance.
+func NewQdrantInsertOptions() *QdrantInsertOptions {
+	return &QdrantInsertOptions{
+		distance: DistanceCosine,
+		wait:     true,
+	}
+}
+
+// QdrantUpdateOptions implements vectordb.UpdateOptions with Qdrant-specific fields.
+type QdrantUpdateOptions struct {
+	collection string
+	embedder   embedder.Embedder
+	wait       bool
+}
+
+func (o *QdrantUpdateOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *QdrantUpdateOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *QdrantUpdateOptions) GetWait() bool {
+	return o.wait
+}
+
+func (o *QdrantUpdateOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *QdrantUpdateOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+func (o *QdrantUpdateOptions) SetWait(wait bool) {
+	o.wait = wait
+}
+
+// NewQdrantUpdateOptions creates a new QdrantUpdateOptions instance.
+func NewQdrantUpdateOptions() *QdrantUpdateOptions {
+	return &QdrantUpdateOptions{
+		wait: true,
+	}
+}
+
+// QdrantSearchOptions implements vectordb.SearchOptions with Qdrant-specific fields.
+//
+// Filters are a map[string]any of metadata values the documents must equal, a slice value
+// matching any of its values, e.g. {"kind": "faq", "lang": []string{"en", "fr"}}, or a Qdrant
+// filter with its must, should and must_not clauses over the payload, used as is.
+type QdrantSearchOptions struct {
+	collection     string
+	scoreThreshold float32
+	filters        any
+	embedder       embedder.Embedder
+	hnswEf         int
+}
+
+func (o *QdrantSearchOptions) GetCollection() string {
+	return o.collection
+}
+
+func (o *QdrantSearchOptions) GetScoreThreshold() float32 {
+	return o.scoreThreshold
+}
+
+func (o *QdrantSearchOptions) GetFilters() any {
+	return o.filters
+}
+
+func (o *QdrantSearchOptions) GetEmbedder() embedder.Embedder {
+	return o.embedder
+}
+
+func (o *QdrantSearchOptions) GetHnswEf() int {
+	return o.hnswEf
+}
+
+func (o *QdrantSearchOptions) SetCollection(collection string) {
+	o.collection = collection
+}
+
+func (o *QdrantSearchOptions) SetScoreThreshold(threshold float32) {
+	o.scoreThreshold = threshold
+}
+
+func (o *QdrantSearchOptions) SetFilters(filters any) {
+	o.filters = filters
+}
+
+func (o *QdrantSearchOptions) SetEmbedder(embedder embedder.Embedder) {
+	o.embedder = embedder
+}
+
+func (o *QdrantSearchOptions) SetHnswEf(ef int) {
+	o.hnswEf = ef
+}
+
+// NewQdrantSearchOptions creates a new QdrantSearchOptions instance.
+func NewQdrantSearchOptions() *QdrantSearchOptions {
+	return &QdrantSearchOptions{}
+}
+
+// Qdrant-specific option functions for Insert
+func WithQdrantDistance(distance Distance) vectordb.InsertOption {
+	return func(o vectordb.InsertOptions) {
+		if qdrantOpts, ok := o.(*QdrantInsertOptions); ok {
+			qdrantOpts.SetDistance(distance)
+		}
+	}
+}
+
+func WithQdrantWait(wait bool) vectordb.InsertOption {
+	return func(o vectordb.InsertOptions) {
+		if qdrantOpts, ok := o.(*QdrantInsertOptions); ok {
+			qdrantOpts.SetWait(wait)
+		}
+	}
+}
+
+// Qdrant-specific option functions for Update
+func WithQdrantUpdateWait(wait bool) vectordb.UpdateOption {
+	return func(o vectordb.UpdateOptions) {
+		if qdrantOpts, ok := o.(*QdrantUpdateOptions); ok {
+			qdrantOpts.SetWait(wait)
+		}
+	}
+}
+
+// Qdrant-specific option functions for Search
+func WithQdrantHnswEf(ef int) vectordb.SearchOption {
+	return func(o vectordb.SearchOptions) {
+		if qdrantOpts, ok := o.(*QdrantSearchOptions); ok {
+			qdrantOpts.SetHnswEf(ef)
+		}
+	}
+}
+
+// New connects to the Qdrant server and verifies it.
+func New(ctx context.Context, config Config) (*Store, error) {
+	if config.URL == "" {
+		return nil, errors.Errorf(vectordb.ErrorCodeCreateVectorStoreFailed, "qdrant url cannot be empty")
+	}
+	if config.DefaultCollection == "" {
+		config.DefaultCollection = DefaultCollection
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	store := &Store{
+		baseURL:           strings.TrimSuffix(config.URL, "/"),
+		apiKey:            config.APIKey,
+		defaultCollection: config.DefaultCollection,
+		client:            &http.Client{Timeout: config.Timeout},
+		collections:       make(map[string]bool),
+	}
+	if err := store.do(ctx, http.MethodGet, "/", nil, nil); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeCreateVectorStoreFailed, err)
+	}
+	return store, nil
+}
+
+// Store is a client of a Qdrant database. The documents are stored as points whose
+// payload holds their id, name, content and metadata.
+type Store struct {
+	baseURL           string
+	apiKey            string
+	defaultCollection string
+	client            *http.Client
+
+	mu          sync.RWMutex
+	collections map[string]bool
+}
+
+// AddDocuments upserts the documents, creating the collection sized from the first
+// embedding on first use. With vectordb.WithContentHashIds the ids are derived from
+// the document contents.
+func (s *Store) AddDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.InsertOption) ([]document.DocumentId, error) {
+	options := NewQdrantInsertOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+
+	collection := s.collectionName(options.GetCollection())
+	if err := s.ensureCollection(ctx, collection, len(vectors[0]), options.GetDistance()); err != nil {
+		return nil, err
+	}
+
+	docIds := make([]document.DocumentId, 0, len(documents))
+	points := make([]*point, 0, len(documents))
+	for idx, doc := range documents {
+		id := doc.Id
+		if options.GetContentHashIds() {
+			id = document.ContentHashId(doc.Content)
+		}
+		if id == "" {
+			id = document.DocumentId(uuid.New().String())
+		}
+		docIds = append(docIds, id)
+		points = append(points, newPoint(id, doc, vectors[idx]))
+	}
+
+	if err := s.upsertPoints(ctx, collection, points, options.GetWait()); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeAddDocumentFailed, err)
+	}
+	return docIds, nil
+}
+
+// UpdateDocuments updates existing documents matched by id.
+func (s *Store) UpdateDocuments(ctx context.Context, documents []*document.Document, opts ...vectordb.UpdateOption) error {
+	options := NewQdrantUpdateOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if len(documents) == 0 {
+		return nil
+	}
+
+	collection := s.collectionName(options.GetCollection())
+	docIds := make([]document.DocumentId, 0, len(documents))
+	for _, doc := range documents {
+		docIds = append(docIds, doc.Id)
+	}
+
+	// An upsert would add the missing documents
+	existing, err := s.retrievePoints(ctx, collection, docIds, false)
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	for _, id := range docIds {
+		if _, ok := existing[id]; !ok {
+			return errors.Errorf(vectordb.ErrorCodeDocumentNotFound,
+				"document %s not found in collection %s", id, collection)
+		}
+	}
+
+	vectors, err := vectordb.EmbedDocuments(ctx, documents, options.GetEmbedder())
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	points := make([]*point, 0, len(documents))
+	for idx, doc := range documents {
+		points = append(points, newPoint(doc.Id, doc, vectors[idx]))
+	}
+	if err := s.upsertPoints(ctx, collection, points, options.GetWait()); err != nil {
+		return errors.Wrap(vectordb.ErrorCodeUpdateDocumentFailed, err)
+	}
+	return nil
+}
+
+// Search embeds the query and returns the nearest documents with the scores of Qdrant,
+// the cosine similarities with the default distance.
+func (s *Store) Search(ctx context.Context, query string, maxDocuments int, opts ...vectordb.SearchOption) ([]*vectordb.ScoredDocument, error) {
+	options := NewQdrantSearchOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	emb := options.GetEmbedder()
+	if emb == nil {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "no embedder provided")
+	}
+	filter, err := buildFilter(options.GetFilters())
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := emb.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+	if len(vectors) == 0 {
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed, "failed to generate embedding for query")
+	}
+
+	if maxDocuments <= 0 {
+		maxDocuments = 10
+	}
+	request := map[string]any{
+		"vector":       vectors[0],
+		"limit":        maxDocuments,
+		"with_payload": true,
+	}
+	if filter != nil {
+		request["filter"] = filter
+	}
+	if threshold := options.GetScoreThreshold(); threshold > 0 {
+		request["score_threshold"] = threshold
+	}
+	if ef := options.GetHnswEf(); ef > 0 {
+		request["params"] = map[string]any{"hnsw_ef": ef}
+	}
+
+	var results []*point
+	path := s.collectionPath(s.collectionName(options.GetCollection()), "/points/search")
+	if err := s.do(ctx, http.MethodPost, path, request, &results); err != nil {
+		return nil, errors.Wrap(vectordb.ErrorCodeSearchDocumentFailed, err)
+	}
+
+	docs := make([]*vectordb.ScoredDocument, 0, len(results))
+	for _, result := range results {
+		docs = append(docs, &vectordb.ScoredDocument{
+			Document: *result.document(),
+			Score:    result.Score,
+		})
+	}
+	return docs, nil
+}
+
+// Get retrieves a document by its ID from the default collection.
+func (s *Store) Get(ctx context.Context, documentId document.DocumentId) (*document.Document, error) {
+	points, err := s.retrievePoints(ctx, s.defaultCollection, []document.DocumentId{documentId}, true)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := points[documentId]
+	if !ok {
+		return nil, errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+	}
+	return p.document(), nil
+}
+
+// Delete removes a document by its ID from the default collection.
+func (s *Store) Delete(ctx context.Context, documentId document.DocumentId) error {
+	// Qdrant ignores the missing points of a delete
+	points, err := s.retrievePoints(ctx, s.defaultCollection, []document.DocumentId{documentId}, false)
+	if err != nil {
+		return err
+	}
+	if _, ok := points[documentId]; !ok {
+		return errors.Errorf(vectordb.ErrorCodeDocumentNotFound, "document %s not found", documentId)
+	}
+
+	request := map[string]any{"points": []string{pointId(documentId)}}
+	return s.do(ctx, http.MethodPost, s.collectionPath(s.defaultCollection, "/points/delete?wait=true"), request, nil)
+}
+
+// DropCollection deletes the collection and its documents.
+func (s *Store) DropCollection(ctx context.Context, collection string) error {
+	name := s.collectionName(collection)
+	if err := s.do(ctx, http.MethodDelete, s.collectionPath(name, ""), nil, nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.collections, name)
+	s.mu.Unlock()
+	return nil
+}
+
+// ensureCollection creates the collection with vectors of the given dimension if it does not exist.
+func (s *Store) ensureCollection(ctx context.Context, collection string, dim int, distance Distance) error {
+	s.mu.RLock()
+	exists := s.collections[collection]
+	s.mu.RUnlock()
+	if exists {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.collections[collection] {
+		return nil
+	}
+
+	if dim == 0 {
+		return errors.Errorf(vectordb.ErrorCodeInvalidVectorDataSchema, "embedding dimension cannot be 0")
+	}
+
+	err := s.do(ctx, http.MethodGet, s.collectionPath(collection, ""), nil, nil)
+	if isNotFound(err) {
+		request := map[string]any{
+			"vectors": map[string]any{"size": dim, "distance": distance},
+		}
+		err = s.do(ctx, http.MethodPut, s.collectionPath(collection, ""), request, nil)
+	}
+	if err != nil {
+		return errors.Wrap(vectordb.ErrorCodeLoadCollectionFailed, err)
+	}
+
+	s.collections[collection] = true
+	return nil
+}
+
+func (s *Store) upsertPoints(ctx context.Context, collection string, points []*point, wait bool) error {
+	path := s.collectionPath(collection, fmt.Sprintf("/points?wait=%t", wait))
+	return s.do(ctx, http.MethodPut, path, map[string]any{"points": points}, nil)
+}
+
+// retrievePoints returns the points of the documents found in the collection by document id,
+// a missing collection holding no documents
+func (s *Store) retrievePoints(ctx context.Context, collection string, documentIds []document.DocumentId, withVector bool) (map[document.DocumentId]*point, error) {
+	ids := make([]string, 0, len(documentIds))
+	for _, id := range documentIds {
+		ids = append(ids, pointId(id))
+	}
+	request := map[string]any{
+		"ids":          ids,
+		"with_payload": true,
+		"with_vector":  withVector,
+	}
+
+	var results []*point
+	err := s.do(ctx, http.MethodPost, s.collectionPath(collection, "/points"), request, &results)
+	if isNotFound(err) {
+		return map[document.DocumentId]*point{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[document.DocumentId]*point, len(results))
+	for _, result := range results {
+		points[result.document().Id] = result
+	}
+	return points, nil
+}
+
+func (s *Store) collectionName(collection string) string {
+	if collection == "" {
+		return s.defaultCollection
+	}
+	return collection
+}
+
+func (s *Store) collectionPath(collection, operation string) string {
+	return "/collections/" + url.PathEscape(collection) + operation
+}
+
+// apiError is an error response of the Qdrant API
+type apiError struct {
+	method, path string
+	statusCode   int
+	message      string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("qdrant %s %s failed with status %d: %s", e.method, e.path, e.statusCode, e.message)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.statusCode == http.StatusNotFound
+}
+
+// do sends the request to the Qdrant API and decodes the result of its JSON response into out, if any
+func (s *Store) do(ctx context.Context, method, path string, body, out any) error {
+	var requestBody io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var response struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		message := strings.TrimSpace(string(raw))
+		if json.Unmarshal(raw, &response) == nil && response.Status.Error != "" {
+			message = response.Status.Error
+		}
+		return &apiError{method: method, path: path, statusCode: resp.StatusCode, message: message}
+	}
+	if out == nil {
+		return nil
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return err
+	}
+	return json.Unmarshal(response.Result, out)
+}
+
+// point is a Qdrant point, sent by upserts and returned by searches and retrievals
+type point struct {
+	Id      any                  `json:"id"`
+	Vector  embedder.FloatVector `json:"vector,omitempty"`
+	Payload map[string]any       `json:"payload,omitempty"`
+	Score   float32              `json:"score,omitempty"`
+}
+
+func newPoint(id document.DocumentId, doc *document.Document, vector embedder.FloatVector) *point {
+	payload := map[string]any{
+		payloadKeyDocumentId: string(id),
+		payloadKeyName:       doc.Name,
+		payloadKeyContent:    doc.Content,
+	}
+	if len(doc.Metadata) > 0 {
+		payload[payloadKeyMetadata] = doc.Metadata
+	}
+	return &point{Id: pointId(id), Vector: vector, Payload: payload}
+}
+
+// document returns the document stored in the payload of the point
+func (p *point) document() *document.Document {
+	doc := &document.Document{Embedding: p.Vector}
+	if id, ok := p.Payload[payloadKeyDocumentId].(string); ok {
+		doc.Id = document.DocumentId(id)
+	} else {
+		doc.Id = document.DocumentId(fmt.Sprint(p.Id))
+	}
+	doc.Name, _ = p.Payload[payloadKeyName].(string)
+	doc.Content, _ = p.Payload[payloadKeyContent].(string)
+	doc.Metadata, _ = p.Payload[payloadKeyMetadata].(map[string]any)
+	return doc
+}
+
+// pointId returns the id of the point of a document: Qdrant point ids are UUIDs or integers,
+// so the document ids which are not UUIDs are hashed into UUIDs
+func pointId(documentId document.DocumentId) string {
+	if id, err := uuid.Parse(string(documentId)); err == nil {
+		return id.String()
+	}
+	return uuid.NewSHA1(pointIdNamespace, []byte(documentId)).String()
+}
+
+// buildFilter translates the search filters into a Qdrant filter over the metadata of the
+// payload; filters with must, should or must_not clauses are used as is
+func buildFilter(filters any) (map[string]any, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		if len(f) == 0 {
+			return nil, nil
+		}
+		for _, clause := range []string{"must", "should", "must_not", "min_should"} {
+			if _, ok := f[clause]; ok {
+				return f, nil
+			}
+		}
+
+		keys := make([]string, 0, len(f))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		conditions := make([]map[string]any, 0, len(keys))
+		for _, key := range keys {
+			match := map[string]any{"value": f[key]}
+			switch f[key].(type) {
+			case []string, []int, []int64, []any:
+				// Slices match any of their values
+				match = map[string]any{"any": f[key]}
+			}
+			conditions = append(conditions, map[string]any{
+				"key":   payloadKeyMetadata + "." + key,
+				"match": match,
+			})
+		}
+		return map[string]any{"must": conditions}, nil
+	default:
+		return nil, errors.Errorf(vectordb.ErrorCodeSearchDocumentFailed,
+			"unsupported filters type: %T", filters)
+	}
+}
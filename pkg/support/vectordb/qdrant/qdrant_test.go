@@ -0,0 +1,275 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oopslink/agent-go/pkg/commons/errors"
+	"github.com/oopslink/agent-go/pkg/commons/utils"
+	"github.com/oopslink/agent-go/pkg/support/document"
+	"github.com/oopslink/agent-go/pkg/support/embedder"
+	"github.com/oopslink/agent-go/pkg/support/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmbedder is a mock implementation of the embedder.Embedder interface
+type MockEmbedder struct {
+	dimension int
+}
+
+func (m *MockEmbedder) Embed(ctx context.Context, texts []string) ([]embedder.FloatVector, error) {
+	vectors := make([]embedder.FloatVector, len(texts))
+	for i := range texts {
+		// Generate deterministic vectors based on text length and dimension
+		vector := make([]float64, m.dimension)
+		for j := 0; j < m.dimension; j++ {
+			vector[j] = float64((len(texts[i])+j)%10+1) / 10.0
+		}
+		vectors[i] = embedder.FloatVector(vector)
+	}
+	return vectors, nil
+}
+
+func TestNewClientConfig(t *testing.T) {
+	config, err := NewClientConfig("http://localhost:6333")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:6333", config.URL)
+	assert.Equal(t, DefaultCollection, config.DefaultCollection)
+	assert.Equal(t, DefaultTimeout, config.Timeout)
+
+	_, err = NewClientConfig("")
+	assert.Error(t, err)
+}
+
+func TestQdrantOptions(t *testing.T) {
+	insertOptions := NewQdrantInsertOptions()
+	assert.Equal(t, DistanceCosine, insertOptions.GetDistance())
+	assert.True(t, insertOptions.GetWait())
+	for _, opt := range []vectordb.InsertOption{
+		vectordb.WithInsertCollection("faq"),
+		vectordb.WithContentHashIds(),
+		WithQdrantDistance(DistanceDot),
+		WithQdrantWait(false),
+	} {
+		opt(insertOptions)
+	}
+	assert.Equal(t, "faq", insertOptions.GetCollection())
+	assert.True(t, insertOptions.GetContentHashIds())
+	assert.Equal(t, DistanceDot, insertOptions.GetDistance())
+	assert.False(t, insertOptions.GetWait())
+
+	updateOptions := NewQdrantUpdateOptions()
+	WithQdrantUpdateWait(false)(updateOptions)
+	assert.False(t, updateOptions.GetWait())
+
+	searchOptions := NewQdrantSearchOptions()
+	WithQdrantHnswEf(128)(searchOptions)
+	assert.Equal(t, 128, searchOptions.GetHnswEf())
+}
+
+func TestPointId(t *testing.T) {
+	// UUIDs are used as is
+	id := uuid.New().String()
+	assert.Equal(t, id, pointId(document.DocumentId(id)))
+
+	// other ids are hashed into stable UUIDs
+	derived := pointId("doc_1")
+	_, err := uuid.Parse(derived)
+	require.NoError(t, err)
+	assert.Equal(t, derived, pointId("doc_1"))
+	assert.NotEqual(t, derived, pointId("doc_2"))
+}
+
+func TestBuildFilter(t *testing.T) {
+	filter, err := buildFilter(nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = buildFilter(map[string]any{"lang": []string{"en", "fr"}, "kind": "faq"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"must": []map[string]any{
+		{"key": "metadata.kind", "match": map[string]any{"value": "faq"}},
+		{"key": "metadata.lang", "match": map[string]any{"any": []string{"en", "fr"}}},
+	}}, filter)
+
+	// Qdrant filters are used as is
+	raw := map[string]any{"should": []any{map[string]any{"key": "metadata.kind", "match": map[string]any{"value": "faq"}}}}
+	filter, err = buildFilter(raw)
+	require.NoError(t, err)
+	assert.Equal(t, raw, filter)
+
+	_, err = buildFilter("kind = 'faq'")
+	assert.Error(t, err)
+}
+
+func TestStore_AddAndSearch(t *testing.T) {
+	// Create test server answering like Qdrant
+	requests := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		var request map[string]any
+		if r.Body != nil && r.ContentLength > 0 {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		}
+		requests[r.Method+" "+r.URL.Path] = request
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /":
+			w.Write([]byte(`{"title": "qdrant - vector search engine", "version": "1.12.0"}`))
+		case "GET /collections/faq":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status": {"error": "Not found: Collection faq doesn't exist!"}}`))
+		case "PUT /collections/faq", "PUT /collections/faq/points":
+			w.Write([]byte(`{"result": true, "status": "ok"}`))
+		case "POST /collections/faq/points/search":
+			w.Write([]byte(`{"result": [
+				{"id": "1", "score": 0.9, "payload": {"document_id": "doc_1", "name": "one", "content": "first", "metadata": {"kind": "faq"}}},
+				{"id": "2", "score": 0.4, "payload": {"document_id": "doc_2", "content": "second"}}
+			], "status": "ok"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config, err := NewClientConfig(server.URL)
+	require.NoError(t, err)
+	config.APIKey = "secret"
+	store, err := New(context.Background(), *config)
+	require.NoError(t, err)
+
+	emb := &MockEmbedder{dimension: 4}
+	ids, err := store.AddDocuments(context.Background(), []*document.Document{
+		document.NewDocument("doc_1", "one", map[string]any{"kind": "faq"}, "first"),
+	}, vectordb.WithInsertCollection("faq"), vectordb.WithInsertEmbedder(emb), WithQdrantDistance(DistanceDot))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc_1"}, ids)
+
+	// the collection is sized from the first embedding
+	assert.Equal(t, map[string]any{"vectors": map[string]any{"size": float64(4), "distance": "Dot"}},
+		requests["PUT /collections/faq"])
+	points := requests["PUT /collections/faq/points"]["points"].([]any)
+	require.Len(t, points, 1)
+	upserted := points[0].(map[string]any)
+	assert.Equal(t, pointId("doc_1"), upserted["id"])
+	assert.Equal(t, map[string]any{
+		"document_id": "doc_1",
+		"name":        "one",
+		"content":     "first",
+		"metadata":    map[string]any{"kind": "faq"},
+	}, upserted["payload"])
+
+	results, err := store.Search(context.Background(), "question", 2,
+		vectordb.WithSearchCollection("faq"),
+		vectordb.WithSearchEmbedder(emb),
+		vectordb.WithScoreThreshold(0.3),
+		vectordb.WithFilters(map[string]any{"kind": "faq"}),
+		WithQdrantHnswEf(64))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.Equal(t, "one", results[0].Name)
+	assert.Equal(t, "first", results[0].Content)
+	assert.Equal(t, map[string]any{"kind": "faq"}, results[0].Metadata)
+	assert.InDelta(t, 0.9, results[0].Score, 1e-6)
+
+	search := requests["POST /collections/faq/points/search"]
+	assert.Equal(t, float64(2), search["limit"])
+	assert.InDelta(t, 0.3, search["score_threshold"], 1e-6)
+	assert.Equal(t, map[string]any{"hnsw_ef": float64(64)}, search["params"])
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "metadata.kind", "match": map[string]any{"value": "faq"}},
+	}}, search["filter"])
+
+	_, err = store.Search(context.Background(), "question", 2, vectordb.WithSearchCollection("faq"))
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeSearchDocumentFailed))
+}
+
+func TestNew_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := New(context.Background(), Config{URL: server.URL})
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeCreateVectorStoreFailed))
+}
+
+// setupQdrantTest connects to the Qdrant server given by QDRANT_URL
+func setupQdrantTest(t *testing.T) *Store {
+	qdrantURL := os.Getenv("QDRANT_URL")
+	if qdrantURL == "" {
+		t.Skip("Skipping Qdrant tests: QDRANT_URL not set")
+	}
+	config, err := NewClientConfig(qdrantURL)
+	require.NoError(t, err)
+	config.APIKey = os.Getenv("QDRANT_API_KEY")
+	config.DefaultCollection = "test_qdrant_documents_" + utils.GenerateUUID()[:8]
+
+	store, err := New(context.Background(), *config)
+	if err != nil {
+		t.Skipf("Skipping Qdrant tests: cannot connect to Qdrant at %s: %v", qdrantURL, err)
+	}
+	t.Cleanup(func() {
+		_ = store.DropCollection(context.Background(), "")
+	})
+	return store
+}
+
+func TestStore_Integration(t *testing.T) {
+	store := setupQdrantTest(t)
+	ctx := context.Background()
+	emb := &MockEmbedder{dimension: 8}
+
+	docs := []*document.Document{
+		document.NewDocument("doc_1", "short", map[string]any{"kind": "a"}, "short"),
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "b"}, "a longer text"),
+	}
+	ids, err := store.AddDocuments(ctx, docs, vectordb.WithInsertEmbedder(emb))
+	require.NoError(t, err)
+	assert.Equal(t, []document.DocumentId{"doc_1", "doc_2"}, ids)
+
+	results, err := store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, document.DocumentId("doc_1"), results[0].Id)
+	assert.Equal(t, "short", results[0].Name)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithFilters(map[string]any{"kind": "b"}))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, document.DocumentId("doc_2"), results[0].Id)
+
+	results, err = store.Search(ctx, "short", 2, vectordb.WithSearchEmbedder(emb),
+		vectordb.WithScoreThreshold(0.999))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_2", "longer", map[string]any{"kind": "c"}, "updated"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	require.NoError(t, err)
+	err = store.UpdateDocuments(ctx, []*document.Document{
+		document.NewDocument("doc_3", "missing", nil, "missing"),
+	}, vectordb.WithUpdateEmbedder(emb))
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+
+	doc, err := store.Get(ctx, "doc_2")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", doc.Content)
+	assert.Equal(t, "c", doc.Metadata["kind"])
+	assert.Len(t, doc.Embedding, 8)
+
+	require.NoError(t, store.Delete(ctx, "doc_2"))
+	_, err = store.Get(ctx, "doc_2")
+	assert.True(t, errors.IsCode(err, vectordb.ErrorCodeDocumentNotFound))
+	assert.True(t, errors.IsCode(store.Delete(ctx, "doc_2"), vectordb.ErrorCodeDocumentNotFound))
+}
@@ -26,6 +26,13 @@ type VectorDB interface {
 	Delete(ctx context.Context, documentId document.DocumentId) error
 }
 
+// DocumentLister is implemented by the vector databases that can enumerate the documents of a
+// collection, e.g. to scan their content rather than search it by similarity.
+type DocumentLister interface {
+	// ListDocuments returns the documents of the collection, the default one when empty.
+	ListDocuments(ctx context.Context, collection string) ([]*document.Document, error)
+}
+
 // Retriever is a vector database that can retrieve documents based on a query.
 type Retriever interface {
 	// Search performs a similarity search in the vector database using the provided query.
@@ -91,6 +98,37 @@ func WithInsertEmbedder(emb embedder.Embedder) InsertOption {
 	}
 }
 
+// WithContentHashIds derives the id of every inserted document from the hash of its content,
+// see document.ContentHashId, and inserts with upsert semantics, so inserting identical content
+// again replaces the stored document instead of adding a duplicate.
+func WithContentHashIds() InsertOption {
+	return func(o InsertOptions) {
+		if setter, ok := o.(interface{ SetContentHashIds(bool) }); ok {
+			setter.SetContentHashIds(true)
+		}
+	}
+}
+
+// Standard Update Options
+
+// WithUpdateCollection sets the collection name for the update operation.
+func WithUpdateCollection(collection string) UpdateOption {
+	return func(o UpdateOptions) {
+		if setter, ok := o.(interface{ SetCollection(string) }); ok {
+			setter.SetCollection(collection)
+		}
+	}
+}
+
+// WithUpdateEmbedder sets the embedder to use for generating vector embeddings during update.
+func WithUpdateEmbedder(emb embedder.Embedder) UpdateOption {
+	return func(o UpdateOptions) {
+		if setter, ok := o.(interface{ SetEmbedder(embedder.Embedder) }); ok {
+			setter.SetEmbedder(emb)
+		}
+	}
+}
+
 // Standard Search Options
 
 // WithSearchCollection sets the collection name for the search operation.